@@ -0,0 +1,351 @@
+// Package access/access.go
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/cache"
+	"github.com/4cecoder/saas/models"
+)
+
+// DefaultTTL bounds how long a missed invalidation can leave access stale
+const DefaultTTL = 5 * time.Minute
+
+// generationKey holds the generation of the cached entries; bumping it drops them all
+const generationKey = "access:generation"
+
+// User is what a user may access: their roles, permissions and organizations
+type User struct {
+	Roles []string `json:"roles"`
+	// Permissions are granted directly or through the user's roles
+	Permissions     []string `json:"permissions"`
+	OrganizationIDs []uint   `json:"organization_ids"`
+	// SeatOrganizationIDs are the organizations where the user holds an active seat
+	SeatOrganizationIDs []uint `json:"seat_organization_ids"`
+	// AdminOrganizationIDs are the organizations where the user's active seat
+	// has the admin role
+	AdminOrganizationIDs []uint `json:"admin_organization_ids"`
+	// SessionsRevokedAt invalidates the tokens issued to the user up to then
+	SessionsRevokedAt *time.Time `json:"sessions_revoked_at,omitempty"`
+	// PasswordChangeRequired restricts the user to changing their password
+	PasswordChangeRequired bool `json:"password_change_required,omitempty"`
+	// Suspended rejects every token of the user until they're reactivated
+	Suspended bool `json:"suspended,omitempty"`
+	// Teams are the teams the user is a member of
+	Teams []Team `json:"teams"`
+}
+
+// Team is what a user may access in a team they're a member of
+type Team struct {
+	ID             uint `json:"id"`
+	OrganizationID uint `json:"organization_id"`
+	// Permissions are granted by the user's roles in the team
+	Permissions []string `json:"permissions"`
+}
+
+// HasRole reports whether the user has the named role
+func (u *User) HasRole(role string) bool {
+	return contains(u.Roles, role)
+}
+
+// HasPermission reports whether the user was granted the named permission
+func (u *User) HasPermission(permission string) bool {
+	return contains(u.Permissions, permission)
+}
+
+// SessionRevoked reports whether a token issued to the user at issuedAt was
+// revoked; every token of a suspended user is
+func (u *User) SessionRevoked(issuedAt time.Time) bool {
+	return u.Suspended || u.SessionsRevokedAt != nil && !issuedAt.After(*u.SessionsRevokedAt)
+}
+
+// MemberOf reports whether the user belongs to the organization
+func (u *User) MemberOf(orgID uint) bool {
+	return contains(u.OrganizationIDs, orgID)
+}
+
+// HasSeat reports whether the user holds an active seat in the organization
+func (u *User) HasSeat(orgID uint) bool {
+	return contains(u.SeatOrganizationIDs, orgID)
+}
+
+// AdministersOrganization reports whether the user is an admin of the
+// organization: a platform admin, or an admin by their seat in it
+func (u *User) AdministersOrganization(orgID uint) bool {
+	return u.HasRole(models.AdminRole) || contains(u.AdminOrganizationIDs, orgID)
+}
+
+// MemberOfTeam reports whether the user belongs to the team
+func (u *User) MemberOfTeam(teamID uint) bool {
+	return u.team(teamID) != nil
+}
+
+// HasTeamPermission reports whether the user was granted the named permission,
+// either directly or by their roles in the team
+func (u *User) HasTeamPermission(teamID uint, permission string) bool {
+	if u.HasPermission(permission) {
+		return true
+	}
+	t := u.team(teamID)
+	return t != nil && contains(t.Permissions, permission)
+}
+
+// team returns the team of the user with the ID, or nil
+func (u *User) team(teamID uint) *Team {
+	for i := range u.Teams {
+		if u.Teams[i].ID == teamID {
+			return &u.Teams[i]
+		}
+	}
+	return nil
+}
+
+// Entitlement is what an organization's subscription grants
+type Entitlement struct {
+	// SubscriptionStatus is the status of the latest subscription, if any
+	SubscriptionStatus string `json:"subscription_status"`
+	// Entitled is set when the latest subscription is active or trialing
+	Entitled bool `json:"entitled"`
+	// Reason explains why the organization isn't entitled
+	Reason string `json:"reason,omitempty"`
+	// Features are the features of the subscribed plan
+	Features []string `json:"features"`
+}
+
+// Access loads the authorization data checked on most requests, caching it until
+// the events of the changes it depends on invalidate it
+type Access struct {
+	DB    *gorm.DB
+	Cache cache.Cache
+	TTL   time.Duration
+}
+
+// New creates an access loader caching in c
+func New(db *gorm.DB, c cache.Cache) *Access {
+	return &Access{DB: db, Cache: c, TTL: DefaultTTL}
+}
+
+// User returns the access of a user; unknown users have none
+func (a *Access) User(ctx context.Context, userID uint) (*User, error) {
+	u := &User{}
+	err := a.cached(ctx, "user", userID, u, func(db *gorm.DB) error {
+		return a.loadUser(db, userID, u)
+	})
+	return u, err
+}
+
+// Organization returns the entitlement of an organization, or
+// gorm.ErrRecordNotFound if it doesn't exist
+func (a *Access) Organization(ctx context.Context, orgID uint) (*Entitlement, error) {
+	e := &Entitlement{}
+	err := a.cached(ctx, "org", orgID, e, func(db *gorm.DB) error {
+		return a.loadOrganization(db, orgID, e)
+	})
+	return e, err
+}
+
+// InvalidateUser drops the cached access of a user
+func (a *Access) InvalidateUser(ctx context.Context, userID uint) {
+	a.Cache.Delete(ctx, a.key(ctx, "user", userID))
+}
+
+// InvalidateOrganization drops the cached entitlement of an organization
+func (a *Access) InvalidateOrganization(ctx context.Context, orgID uint) {
+	a.Cache.Delete(ctx, a.key(ctx, "org", orgID))
+}
+
+// InvalidateAll drops every cached entry, e.g. after a role's permissions change
+func (a *Access) InvalidateAll(ctx context.Context) {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 10)
+	// Outliving the entries is enough; once it expires they have too
+	a.Cache.Set(ctx, generationKey, []byte(generation), 2*a.TTL)
+}
+
+// cached decodes the entry of kind and id into v, or loads and stores it
+func (a *Access) cached(ctx context.Context, kind string, id interface{}, v interface{}, load func(db *gorm.DB) error) error {
+	key := a.key(ctx, kind, id)
+	if data, ok := a.Cache.Get(ctx, key); ok && json.Unmarshal(data, v) == nil {
+		return nil
+	}
+
+	if err := load(a.DB.WithContext(ctx)); err != nil {
+		return err
+	}
+	if data, err := json.Marshal(v); err == nil {
+		a.Cache.Set(ctx, key, data, a.TTL)
+	}
+	return nil
+}
+
+// key returns the cache key of an entry in the current generation
+func (a *Access) key(ctx context.Context, kind string, id interface{}) string {
+	generation, ok := a.Cache.Get(ctx, generationKey)
+	if !ok {
+		generation = []byte("0")
+	}
+	return fmt.Sprintf("access:%s:%s:%v", generation, kind, id)
+}
+
+// loadUser reads the session revocation, password and suspension state, roles, permissions, memberships, seats, admin seats and teams of a user
+func (a *Access) loadUser(db *gorm.DB, userID uint, u *User) error {
+	u.Roles, u.Permissions = []string{}, []string{}
+	u.OrganizationIDs, u.SeatOrganizationIDs, u.AdminOrganizationIDs = []uint{}, []uint{}, []uint{}
+	u.Teams = []Team{}
+
+	var user models.User
+	if err := db.Select("id", "sessions_revoked_at", "password_change_required", "suspended_at").Limit(1).Find(&user, userID).Error; err != nil {
+		return fmt.Errorf("load user: %w", err)
+	}
+	u.SessionsRevokedAt, u.PasswordChangeRequired = user.SessionsRevokedAt, user.PasswordChangeRequired
+	u.Suspended = user.SuspendedAt != nil
+
+	err := db.Table("roles").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ? AND roles.deleted_at IS NULL", userID).
+		Order("roles.name").
+		Pluck("roles.name", &u.Roles).Error
+	if err != nil {
+		return fmt.Errorf("load roles: %w", err)
+	}
+
+	direct := db.Table("user_permissions").Select("permission_id").Where("user_id = ?", userID)
+	viaRoles := db.Table("role_permissions").
+		Select("role_permissions.permission_id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID)
+	err = db.Model(&models.Permission{}).
+		Where("id IN (?) OR id IN (?)", direct, viaRoles).
+		Order("name").
+		Distinct().
+		Pluck("name", &u.Permissions).Error
+	if err != nil {
+		return fmt.Errorf("load permissions: %w", err)
+	}
+
+	err = db.Table("user_organizations").
+		Where("user_id = ?", userID).
+		Order("organization_id").
+		Pluck("organization_id", &u.OrganizationIDs).Error
+	if err != nil {
+		return fmt.Errorf("load memberships: %w", err)
+	}
+
+	err = db.Model(&models.Seat{}).
+		Where("user_id = ? AND status = ?", userID, models.SeatStatusActive).
+		Order("organization_id").
+		Distinct().
+		Pluck("organization_id", &u.SeatOrganizationIDs).Error
+	if err != nil {
+		return fmt.Errorf("load seats: %w", err)
+	}
+
+	err = db.Model(&models.Seat{}).
+		Joins("JOIN seat_roles ON seat_roles.seat_id = seats.id").
+		Joins("JOIN roles ON roles.id = seat_roles.role_id AND roles.deleted_at IS NULL").
+		Where("seats.user_id = ? AND seats.status = ? AND roles.name = ?", userID, models.SeatStatusActive, models.AdminRole).
+		Order("seats.organization_id").
+		Distinct().
+		Pluck("seats.organization_id", &u.AdminOrganizationIDs).Error
+	if err != nil {
+		return fmt.Errorf("load admin seats: %w", err)
+	}
+
+	var members []models.TeamMember
+	err = db.Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+		Where("team_members.user_id = ?", userID).
+		Order("team_members.team_id").
+		Find(&members).Error
+	if err != nil {
+		return fmt.Errorf("load teams: %w", err)
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	memberIDs := make([]uint, len(members))
+	for i, m := range members {
+		memberIDs[i] = m.ID
+	}
+	var grants []struct {
+		TeamMemberID uint
+		Name         string
+	}
+	err = db.Table("team_member_roles").
+		Select("DISTINCT team_member_roles.team_member_id, permissions.name").
+		Joins("JOIN roles ON roles.id = team_member_roles.role_id AND roles.deleted_at IS NULL").
+		Joins("JOIN role_permissions ON role_permissions.role_id = roles.id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id AND permissions.deleted_at IS NULL").
+		Where("team_member_roles.team_member_id IN ?", memberIDs).
+		Order("permissions.name").
+		Scan(&grants).Error
+	if err != nil {
+		return fmt.Errorf("load team permissions: %w", err)
+	}
+	for _, m := range members {
+		team := Team{ID: m.TeamID, OrganizationID: m.OrganizationID, Permissions: []string{}}
+		for _, g := range grants {
+			if g.TeamMemberID == m.ID {
+				team.Permissions = append(team.Permissions, g.Name)
+			}
+		}
+		u.Teams = append(u.Teams, team)
+	}
+	return nil
+}
+
+// loadOrganization reads the latest subscription of an organization and the
+// features of its plan
+func (a *Access) loadOrganization(db *gorm.DB, orgID uint, e *Entitlement) error {
+	var org models.Organization
+	if err := db.Select("id").First(&org, orgID).Error; err != nil {
+		return err
+	}
+
+	e.Features = []string{}
+	var sub models.Subscription
+	err := db.Where("organization_id = ?", orgID).
+		Order("start_date DESC, id DESC").
+		First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		e.Reason = "no subscription"
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load subscription: %w", err)
+	}
+
+	e.SubscriptionStatus = string(sub.Status)
+	if sub.Status != models.SubscriptionStatusActive && sub.Status != models.SubscriptionStatusTrialing {
+		e.Reason = "no active subscription"
+		return nil
+	}
+	e.Entitled = true
+
+	if sub.SubscriptionPlanID != nil {
+		err := db.Model(&models.Feature{}).
+			Joins("JOIN subscription_plan_features ON subscription_plan_features.feature_id = features.id").
+			Where("subscription_plan_features.subscription_plan_id = ?", *sub.SubscriptionPlanID).
+			Order("name").
+			Pluck("name", &e.Features).Error
+		if err != nil {
+			return fmt.Errorf("load plan features: %w", err)
+		}
+	}
+	return nil
+}
+
+// contains reports whether values contains v
+func contains[T comparable](values []T, v T) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+// Package access/apikeys.go
+package access
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// APIKey identifies the owner of an API key
+type APIKey struct {
+	ID             uint      `json:"id"`
+	UserID         uint      `json:"user_id"`
+	OrganizationID uint      `json:"organization_id"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the key has passed its expiry; keys without one never expire
+func (k *APIKey) Expired() bool {
+	return !k.ExpiresAt.IsZero() && time.Now().After(k.ExpiresAt)
+}
+
+// APIKey returns the owner of an API key, or gorm.ErrRecordNotFound if it
+// doesn't exist. Keys are cached by their hash, so the cache never holds them.
+func (a *Access) APIKey(ctx context.Context, key string) (*APIKey, error) {
+	k := &APIKey{}
+	err := a.cached(ctx, "apikey", keyHash(key), k, func(db *gorm.DB) error {
+		var record models.APIKey
+		if err := db.Where(&models.APIKey{Key: key}).First(&record).Error; err != nil {
+			return err
+		}
+		*k = APIKey{
+			ID:             record.ID,
+			UserID:         record.UserID,
+			OrganizationID: record.OrganizationID,
+			ExpiresAt:      record.ExpiresAt,
+		}
+		return nil
+	})
+	return k, err
+}
+
+// CachedAPIKey returns the owner of an API key when it is cached, without
+// reading the database
+func (a *Access) CachedAPIKey(ctx context.Context, key string) (*APIKey, bool) {
+	k := &APIKey{}
+	data, ok := a.Cache.Get(ctx, a.key(ctx, "apikey", keyHash(key)))
+	return k, ok && json.Unmarshal(data, k) == nil
+}
+
+// InvalidateAPIKey drops the cached owner of an API key
+func (a *Access) InvalidateAPIKey(ctx context.Context, key string) {
+	a.Cache.Delete(ctx, a.key(ctx, "apikey", keyHash(key)))
+}
+
+// keyHash returns the hex SHA-256 of an API key
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
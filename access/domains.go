@@ -0,0 +1,34 @@
+// Package access/domains.go
+package access
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// Domain returns the organization that verified host as its custom domain, or
+// 0 if none did. Unknown hosts are cached too, as browsers ask on every request.
+func (a *Access) Domain(ctx context.Context, host string) (uint, error) {
+	host = strings.ToLower(host)
+	var orgID uint
+	err := a.cached(ctx, "domain", host, &orgID, func(db *gorm.DB) error {
+		var domain models.Domain
+		err := db.Where("domain = ? AND verified = ?", host, true).First(&domain).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		orgID = domain.OrganizationID
+		return err
+	})
+	return orgID, err
+}
+
+// InvalidateDomain drops the cached owner of a custom domain
+func (a *Access) InvalidateDomain(ctx context.Context, host string) {
+	a.Cache.Delete(ctx, a.key(ctx, "domain", strings.ToLower(host)))
+}
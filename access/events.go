@@ -0,0 +1,65 @@
+// Package access/events.go
+package access
+
+import (
+	"context"
+	"strings"
+
+	"github.com/4cecoder/saas/events"
+)
+
+// Subscribe invalidates cached access when the records it was read from change.
+// Users, roles, permissions and plans aren't audited, so their events come from
+// events.Bus.PublishChanges and must be enabled there.
+func (a *Access) Subscribe(bus *events.Bus) {
+	bus.Subscribe("*", a.handleEvent)
+}
+
+// handleEvent drops the entries an event may have made stale
+func (a *Access) handleEvent(ctx context.Context, e events.Event) {
+	resource, _, _ := strings.Cut(e.Type, ".")
+	switch resource {
+	case "user":
+		if id, ok := toUint(e.Payload["resource_id"]); ok {
+			a.InvalidateUser(ctx, id)
+		}
+	case "user_organization", "seat", "team_member":
+		if id, ok := toUint(e.Payload["user_id"]); ok {
+			a.InvalidateUser(ctx, id)
+		}
+	case "subscription":
+		a.InvalidateOrganization(ctx, e.OrganizationID)
+	case "organization":
+		if id, ok := toUint(e.Payload["resource_id"]); ok {
+			a.InvalidateOrganization(ctx, id)
+		}
+	case "api_key":
+		if key, ok := e.Payload["key"].(string); ok {
+			a.InvalidateAPIKey(ctx, key)
+		}
+	case "domain":
+		if host, ok := e.Payload["domain"].(string); ok {
+			a.InvalidateDomain(ctx, host)
+		}
+	case "role", "permission", "subscription_plan", "feature":
+		// Any user or organization may depend on them
+		a.InvalidateAll(ctx)
+	}
+}
+
+// toUint converts an ID from an event payload
+func toUint(v interface{}) (uint, bool) {
+	switch n := v.(type) {
+	case uint:
+		return n, n != 0
+	case uint64:
+		return uint(n), n != 0
+	case int:
+		return uint(n), n > 0
+	case int64:
+		return uint(n), n > 0
+	case float64:
+		return uint(n), n > 0
+	}
+	return 0, false
+}
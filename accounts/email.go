@@ -0,0 +1,191 @@
+// Package accounts/email.go
+//
+// Package accounts holds the flows through which users manage their accounts
+// and that need more than updating a field, such as changing their email.
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+)
+
+// DefaultEmailChangeTTL is how long the links of an email change are valid
+const DefaultEmailChangeTTL = 24 * time.Hour
+
+// ConfirmEmailPath is the page of the web frontend the links of an email
+// change open, with the token in its query; the page posts it to
+// /v1/auth/email-change/confirm
+const ConfirmEmailPath = "/account/confirm-email"
+
+// Errors of email changes
+var (
+	ErrEmailTaken     = errors.New("email is used by another account")
+	ErrEmailUnchanged = errors.New("email is the current one")
+	ErrInvalidToken   = errors.New("invalid or expired token")
+)
+
+// EmailChanges changes the emails of users once both the current and the new
+// address confirmed the change, so neither a typo nor a stolen session takes
+// over the account
+type EmailChanges struct {
+	DB     *gorm.DB
+	Mailer mailer.Mailer
+	// AppURL is the URL of the web frontend serving ConfirmEmailPath
+	AppURL string
+	TTL    time.Duration
+}
+
+// NewEmailChanges creates the email change flow, emailing links to appURL
+func NewEmailChanges(db *gorm.DB, mail mailer.Mailer, appURL string) *EmailChanges {
+	return &EmailChanges{DB: db, Mailer: mail, AppURL: appURL, TTL: DefaultEmailChangeTTL}
+}
+
+// Request starts changing the email of user to newEmail, replacing the change
+// pending, and emails both addresses a link confirming it
+func (e *EmailChanges) Request(ctx context.Context, user *models.User, newEmail string) (*models.EmailChange, error) {
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, ErrEmailUnchanged
+	}
+	db := e.DB.WithContext(ctx)
+	if taken, err := emailTaken(db, newEmail, user.ID); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, ErrEmailTaken
+	}
+
+	oldToken, oldHash, err := auth.NewLinkToken()
+	if err != nil {
+		return nil, err
+	}
+	newToken, newHash, err := auth.NewLinkToken()
+	if err != nil {
+		return nil, err
+	}
+	change := &models.EmailChange{
+		UserID:       user.ID,
+		NewEmail:     newEmail,
+		ExpiresAt:    time.Now().Add(e.TTL),
+		OldTokenHash: oldHash,
+		NewTokenHash: newHash,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.EmailChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expires := change.ExpiresAt.UTC().Format(time.RFC1123)
+	messages := []mailer.Message{
+		{
+			To:      []string{user.Email},
+			Subject: "Confirm changing your email",
+			Text: fmt.Sprintf("Changing the email of your account from %s to %s was requested. Confirm it by %s at:\n\n%s\n\n"+
+				"If you didn't request it, ignore this email and change your password; your email only changes once you confirm.\n",
+				user.Email, newEmail, expires, e.link(oldToken)),
+		},
+		{
+			To:      []string{newEmail},
+			Subject: "Confirm your new email",
+			Text: fmt.Sprintf("Confirm %s as the new email of your account by %s at:\n\n%s\n\n"+
+				"If you didn't request it, you can ignore this email.\n",
+				newEmail, expires, e.link(newToken)),
+		},
+	}
+	for _, msg := range messages {
+		if err := e.Mailer.Send(ctx, msg); err != nil {
+			return nil, fmt.Errorf("send email change confirmation: %w", err)
+		}
+	}
+	return change, nil
+}
+
+// Pending returns the email change of a user awaiting confirmation, or
+// gorm.ErrRecordNotFound
+func (e *EmailChanges) Pending(ctx context.Context, userID uint) (*models.EmailChange, error) {
+	var change models.EmailChange
+	err := e.DB.WithContext(ctx).Where("user_id = ? AND expires_at > ?", userID, time.Now()).First(&change).Error
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// Cancel drops the email change of a user awaiting confirmation
+func (e *EmailChanges) Cancel(ctx context.Context, userID uint) error {
+	return e.DB.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.EmailChange{}).Error
+}
+
+// Confirm confirms an email change with the token of one of its links. Once
+// both addresses confirmed, the email of the user is changed, unless another
+// account took it meanwhile, and the address counts as verified. It reports
+// whether the email was changed.
+func (e *EmailChanges) Confirm(ctx context.Context, token string) (*models.EmailChange, bool, error) {
+	hash := auth.HashLinkToken(token)
+	var change models.EmailChange
+	changed := false
+	err := e.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("(old_token_hash = ? OR new_token_hash = ?) AND expires_at > ?", hash, hash, time.Now()).
+			First(&change).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		} else if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if change.OldTokenHash == hash {
+			change.OldConfirmedAt = &now
+		} else {
+			change.NewConfirmedAt = &now
+		}
+		if change.OldConfirmedAt == nil || change.NewConfirmedAt == nil {
+			return tx.Model(&change).Select("OldConfirmedAt", "NewConfirmedAt").Updates(&change).Error
+		}
+
+		// The unique index on users.email guards against a race past this check
+		if taken, err := emailTaken(tx, change.NewEmail, change.UserID); err != nil {
+			return err
+		} else if taken {
+			return ErrEmailTaken
+		}
+		user := models.User{Base: models.Base{ID: change.UserID}}
+		if err := tx.Model(&user).Updates(map[string]interface{}{"email": change.NewEmail, "verified": true}).Error; err != nil {
+			return err
+		}
+		changed = true
+		return tx.Delete(&change).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &change, changed, nil
+}
+
+// link returns the URL of the page confirming an email change with token
+func (e *EmailChanges) link(token string) string {
+	return strings.TrimSuffix(e.AppURL, "/") + ConfirmEmailPath + "?token=" + url.QueryEscape(token)
+}
+
+// emailTaken reports whether an account other than userID's uses email,
+// deleted accounts included as the unique index does
+func emailTaken(db *gorm.DB, email string, userID uint) (bool, error) {
+	var count int64
+	err := db.Unscoped().Model(&models.User{}).Where("email = ? AND id <> ?", email, userID).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,147 @@
+// Package accounts/suspension.go
+package accounts
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/access"
+	"github.com/4cecoder/saas/models"
+)
+
+// Errors of suspensions
+var (
+	ErrSuspended    = errors.New("user is suspended")
+	ErrNotSuspended = errors.New("user is not suspended")
+)
+
+// Suspensions suspends users, disabling their account without deleting it,
+// and reactivates them
+type Suspensions struct {
+	DB     *gorm.DB
+	Access *access.Access
+}
+
+// NewSuspensions creates the suspension flow, dropping the cached access of
+// the users it changes from acc
+func NewSuspensions(db *gorm.DB, acc *access.Access) *Suspensions {
+	return &Suspensions{DB: db, Access: acc}
+}
+
+// Suspend suspends a user for reason: their tokens are revoked, their API keys
+// expire and their active seats become inactive, taking effect on the next
+// request rather than once cached access expires. It returns the user.
+func (s *Suspensions) Suspend(ctx context.Context, userID uint, reason string) (*models.User, error) {
+	var user models.User
+	var keys []models.APIKey
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.SuspendedAt != nil {
+			return ErrSuspended
+		}
+
+		now := time.Now()
+		err := tx.Model(&user).Updates(map[string]interface{}{
+			"suspended_at":        now,
+			"suspension_reason":   reason,
+			"sessions_revoked_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		user.SuspendedAt, user.SuspensionReason, user.SessionsRevokedAt = &now, reason, &now
+
+		// Seats are updated one by one so each change is audited
+		var seats []models.Seat
+		if err := tx.Where("user_id = ? AND status = ?", userID, models.SeatStatusActive).Find(&seats).Error; err != nil {
+			return err
+		}
+		for i := range seats {
+			err := tx.Model(&seats[i]).Updates(map[string]interface{}{"status": models.SeatStatusInactive, "suspended_at": now}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		var owned []models.APIKey
+		if err := tx.Where("user_id = ?", userID).Find(&owned).Error; err != nil {
+			return err
+		}
+		for i := range owned {
+			// A zero expiry never expires
+			if !owned[i].ExpiresAt.IsZero() && !owned[i].ExpiresAt.After(now) {
+				continue
+			}
+			if err := tx.Model(&owned[i]).Update("expires_at", now).Error; err != nil {
+				return err
+			}
+			keys = append(keys, owned[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, userID, keys)
+	slog.WarnContext(ctx, "accounts: user suspended", "user_id", userID, "api_keys", len(keys))
+	return &user, nil
+}
+
+// Reactivate lifts the suspension of a user: they can sign in again and the
+// seats their suspension made inactive are active again. Their revoked tokens
+// and expired API keys stay so; new ones must be issued. It returns the user.
+func (s *Suspensions) Reactivate(ctx context.Context, userID uint) (*models.User, error) {
+	var user models.User
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.SuspendedAt == nil {
+			return ErrNotSuspended
+		}
+
+		err := tx.Model(&user).Updates(map[string]interface{}{"suspended_at": nil, "suspension_reason": ""}).Error
+		if err != nil {
+			return err
+		}
+		user.SuspendedAt, user.SuspensionReason = nil, ""
+
+		var seats []models.Seat
+		if err := tx.Where("user_id = ? AND suspended_at IS NOT NULL", userID).Find(&seats).Error; err != nil {
+			return err
+		}
+		for i := range seats {
+			err := tx.Model(&seats[i]).Updates(map[string]interface{}{"status": models.SeatStatusActive, "suspended_at": nil}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(ctx, userID, nil)
+	slog.InfoContext(ctx, "accounts: user reactivated", "user_id", userID)
+	return &user, nil
+}
+
+// invalidate drops the cached access of a user and of their API keys
+func (s *Suspensions) invalidate(ctx context.Context, userID uint, keys []models.APIKey) {
+	if s.Access == nil {
+		return
+	}
+	s.Access.InvalidateUser(ctx, userID)
+	for _, k := range keys {
+		s.Access.InvalidateAPIKey(ctx, k.Key)
+	}
+}
@@ -0,0 +1,174 @@
+// Package activity/activity.go
+package activity
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/middleware"
+	"github.com/4cecoder/saas/models"
+)
+
+// Activity types recorded outside of the generic API call categories
+const (
+	TypeLogin  = "auth.login"
+	TypeLogout = "auth.logout"
+)
+
+// Recorder buffers activity log entries and writes them in batches
+type Recorder struct {
+	DB            *gorm.DB
+	FlushInterval time.Duration
+	queue         chan models.ActivityLog
+}
+
+// NewRecorder creates a new activity recorder
+func NewRecorder(db *gorm.DB) *Recorder {
+	return &Recorder{
+		DB:            db,
+		FlushInterval: 2 * time.Second,
+		queue:         make(chan models.ActivityLog, 4096),
+	}
+}
+
+// Record queues an activity entry without blocking the caller
+func (r *Recorder) Record(entry models.ActivityLog) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	select {
+	case r.queue <- entry:
+	default:
+		slog.Warn("activity: queue full, dropping entry", "activity_type", entry.ActivityType, "user_id", entry.UserID)
+	}
+}
+
+// Run writes queued entries until the context is canceled, flushing what remains
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.FlushInterval)
+	defer ticker.Stop()
+
+	var pending []models.ActivityLog
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if err := r.DB.CreateInBatches(pending, 500).Error; err != nil {
+			slog.Error("activity: failed to write entries", "count", len(pending), "error", err)
+		}
+		pending = nil
+	}
+
+	for {
+		select {
+		case entry := <-r.queue:
+			pending = append(pending, entry)
+			if len(pending) >= 500 {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-r.queue:
+					pending = append(pending, entry)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Middleware records an activity entry for every authenticated API call
+func (r *Recorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		userID, ok := c.Get("user_id")
+		if !ok || c.FullPath() == "" {
+			return
+		}
+
+		entry := models.ActivityLog{
+			UserID:       userID.(uint),
+			ActivityType: Category(c.Request.Method, c.FullPath()),
+			Timestamp:    start,
+			Metadata: models.JSONMap{
+				"method":      c.Request.Method,
+				"route":       c.FullPath(),
+				"path":        c.Request.URL.Path,
+				"status":      c.Writer.Status(),
+				"ip":          c.ClientIP(),
+				"user_agent":  c.Request.UserAgent(),
+				"duration_ms": time.Since(start).Milliseconds(),
+			},
+		}
+
+		if orgID, ok := OrganizationID(c); ok {
+			entry.OrganizationID = orgID
+		}
+
+		r.Record(entry)
+	}
+}
+
+// Category derives an activity type like "users.view" from the method and route template
+func Category(method, route string) string {
+	segments := strings.Split(strings.Trim(route, "/"), "/")
+
+	// Use the innermost static segment as the resource, e.g. audit-logs in /organizations/:id/audit-logs
+	resource := segments[0]
+	single := false
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			single = i == len(segments)-1
+			continue
+		}
+		resource = seg
+		single = false
+	}
+
+	var action string
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		action = "list"
+		if single {
+			action = "view"
+		}
+	case http.MethodPost:
+		action = "create"
+	case http.MethodPut, http.MethodPatch:
+		action = "update"
+	case http.MethodDelete:
+		action = "delete"
+	default:
+		action = strings.ToLower(method)
+	}
+
+	return resource + "." + action
+}
+
+// OrganizationID returns the organization a request operates on, taken from the route
+func OrganizationID(c *gin.Context) (uint, bool) {
+	if !strings.HasPrefix(middleware.TrimVersion(c.FullPath()), "/organizations/:id") {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,449 @@
+// Package main/admin.go
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/access"
+	"github.com/4cecoder/saas/cache"
+	"github.com/4cecoder/saas/config"
+	"github.com/4cecoder/saas/encryption"
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+)
+
+// usersCommand creates users and resends their verification emails
+func usersCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{Use: "users", Short: "Create users and resend their verification emails"}
+
+	var opts newUser
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user, printing the password when it was generated",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createUser(cfg, opts)
+		},
+	}
+	flags := create.Flags()
+	flags.StringVar(&opts.Email, "email", "", "email of the user")
+	flags.StringVar(&opts.Name, "name", "", "name of the user")
+	flags.StringVar(&opts.Password, "password", "", "password of the user; generated if empty")
+	flags.BoolVar(&opts.Admin, "admin", false, "grant the admin role")
+	flags.BoolVar(&opts.Verified, "verified", false, "mark the email as verified")
+	create.MarkFlagRequired("email")
+
+	cmd.AddCommand(create, &cobra.Command{
+		Use:   "resend-verification <email>",
+		Short: "Email a user their verification code again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return resendVerification(cfg, args[0])
+		},
+	})
+	return cmd
+}
+
+// newUser is a user to create from the command line
+type newUser struct {
+	Email, Name, Password string
+	Admin, Verified       bool
+}
+
+// createUser creates a user, printing the password when it was generated
+func createUser(cfg *config.Config, opts newUser) error {
+	generated := opts.Password == ""
+	if generated {
+		opts.Password = randomSecret(12)
+	}
+	user := models.User{
+		Email:    opts.Email,
+		Password: opts.Password,
+		Name:     opts.Name,
+		Verified: opts.Verified,
+		Locale:   "en",
+		Timezone: "UTC",
+		Language: "en",
+	}
+	if opts.Admin {
+		role, err := findRole(cfg.DB, models.AdminRole)
+		if err != nil {
+			return err
+		}
+		user.Roles = []models.Role{*role}
+	}
+
+	var count int64
+	if err := cfg.DB.Model(&models.User{}).Where("email = ?", opts.Email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("a user with email %s already exists", opts.Email)
+	}
+	if err := cfg.DB.Create(&user).Error; err != nil {
+		return fmt.Errorf("create user: %w", err)
+	}
+
+	fmt.Printf("Created user %d %s\n", user.ID, user.Email)
+	if generated {
+		fmt.Printf("Password: %s\n", opts.Password)
+	}
+	return nil
+}
+
+// resendVerification emails a user their verification code again
+func resendVerification(cfg *config.Config, email string) error {
+	user, err := findUser(cfg.DB, email)
+	if err != nil {
+		return err
+	}
+	if user.Verified {
+		return fmt.Errorf("%s is already verified", user.Email)
+	}
+	if user.VerificationCode == "" {
+		user.VerificationCode = randomSecret(24)
+		if err := cfg.DB.Model(user).Select("VerificationCode").Updates(user).Error; err != nil {
+			return err
+		}
+	}
+
+	msg := mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Verify your email address",
+		Text:    fmt.Sprintf("Hello %s,\n\nYour verification code is %s\n", user.Name, user.VerificationCode),
+	}
+	if err := mailer.New(cfg.Mail).Send(context.Background(), msg); err != nil {
+		return fmt.Errorf("send verification email: %w", err)
+	}
+	fmt.Printf("Sent the verification email to %s\n", user.Email)
+	return nil
+}
+
+// orgsCommand creates organizations
+func orgsCommand(cfg *config.Config) *cobra.Command {
+	var name, owner string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create an organization, optionally with an owner",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createOrganization(cfg, name, owner)
+		},
+	}
+	create.Flags().StringVar(&name, "name", "", "name of the organization")
+	create.Flags().StringVar(&owner, "owner", "", "email of a user to add as a member with an active seat")
+	create.MarkFlagRequired("name")
+
+	cmd := &cobra.Command{Use: "orgs", Short: "Create organizations"}
+	cmd.AddCommand(create)
+	return cmd
+}
+
+// createOrganization creates an organization, adding owner as a member with a
+// seat unless it's empty
+func createOrganization(cfg *config.Config, name, owner string) error {
+	return cfg.DB.Transaction(func(tx *gorm.DB) error {
+		org := models.Organization{Name: name, Region: cfg.Regions.Region}
+		if err := tx.Create(&org).Error; err != nil {
+			return fmt.Errorf("create organization: %w", err)
+		}
+		if owner != "" {
+			user, err := findUser(tx, owner)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&org).Association("Users").Append(user); err != nil {
+				return fmt.Errorf("add owner: %w", err)
+			}
+			seat := models.Seat{OrganizationID: org.ID, UserID: user.ID, Status: models.SeatStatusActive}
+			if err := tx.Create(&seat).Error; err != nil {
+				return fmt.Errorf("create owner seat: %w", err)
+			}
+		}
+		fmt.Printf("Created organization %d %s\n", org.ID, org.Name)
+		return nil
+	})
+}
+
+// rolesCommand grants roles to users or revokes them
+func rolesCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{Use: "roles", Short: "Grant roles to users or revoke them"}
+	for command, short := range map[string]string{"grant": "Grant a role to a user", "revoke": "Revoke a role from a user"} {
+		cmd.AddCommand(&cobra.Command{
+			Use:   command + " <email> <role>",
+			Short: short,
+			Args:  cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return changeRole(cfg, command, args[0], args[1])
+			},
+		})
+	}
+	return cmd
+}
+
+// changeRole grants or revokes, as command says, the role of a user
+func changeRole(cfg *config.Config, command, email, roleName string) error {
+	user, err := findUser(cfg.DB, email)
+	if err != nil {
+		return err
+	}
+	role, err := findRole(cfg.DB, roleName)
+	if err != nil {
+		return err
+	}
+
+	association, verb := cfg.DB.Model(user).Association("Roles"), "Granted"
+	if command == "grant" {
+		err = association.Append(role)
+	} else {
+		err, verb = association.Delete(role), "Revoked"
+	}
+	if err != nil {
+		return fmt.Errorf("%s role: %w", command, err)
+	}
+	invalidateAccess(cfg, user.ID)
+
+	fmt.Printf("%s role %s for %s\n", verb, role.Name, user.Email)
+	return nil
+}
+
+// jwtCommand generates a new JWT secret to deploy
+func jwtCommand() *cobra.Command {
+	cmd := &cobra.Command{Use: "jwt", Short: "Manage the secret signing tokens"}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "rotate",
+		Short: "Generate a new JWT secret to deploy",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rotateJWT()
+		},
+	})
+	return cmd
+}
+
+// rotateJWT prints a new JWT secret and how to deploy it
+func rotateJWT() error {
+	fmt.Printf(`New JWT secret: %s
+
+Store it in the secret JWT_SECRET refers to; servers watching the secrets
+manager switch to it while still accepting tokens signed with the previous
+secret. Otherwise set JWT_SECRET and restart the servers, which signs out
+every user.
+`, randomSecret(32))
+	return nil
+}
+
+// sessionsCommand expires the sessions of a user, or of every user
+func sessionsCommand(cfg *config.Config) *cobra.Command {
+	var all bool
+	expire := &cobra.Command{
+		Use:   "expire <email>|--all",
+		Short: "Expire the sessions of a user, or of every user",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all != (len(args) == 0) || len(args) > 1 {
+				return fmt.Errorf("expected an email or --all")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			email := ""
+			if len(args) == 1 {
+				email = args[0]
+			}
+			return expireSessions(cfg, email)
+		},
+	}
+	expire.Flags().BoolVar(&all, "all", false, "expire the sessions of every user")
+
+	cmd := &cobra.Command{Use: "sessions", Short: "Expire sessions"}
+	cmd.AddCommand(expire)
+	return cmd
+}
+
+// expireSessions expires the sessions of the user with email, or of every user
+// if it's empty
+func expireSessions(cfg *config.Config, email string) error {
+	now := time.Now()
+	if email == "" {
+		result := cfg.DB.Model(&models.User{}).Where("1 = 1").Update("sessions_revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		invalidateAccess(cfg, 0)
+		fmt.Printf("Expired the sessions of %d users\n", result.RowsAffected)
+		return nil
+	}
+
+	user, err := findUser(cfg.DB, email)
+	if err != nil {
+		return err
+	}
+	if err := cfg.DB.Model(user).Update("sessions_revoked_at", now).Error; err != nil {
+		return err
+	}
+	invalidateAccess(cfg, user.ID)
+	fmt.Printf("Expired the sessions of %s\n", user.Email)
+	return nil
+}
+
+// subscriptionsCommand prints the subscriptions of an organization
+func subscriptionsCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{Use: "subscriptions", Short: "Inspect subscriptions"}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show <organization-id>",
+		Short: "Print the subscriptions of an organization",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return showSubscriptions(cfg, args[0])
+		},
+	})
+	return cmd
+}
+
+// showSubscriptions prints the plan and subscriptions of an organization
+func showSubscriptions(cfg *config.Config, arg string) error {
+	orgID, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("invalid organization ID %q", arg)
+	}
+
+	var org models.Organization
+	if err := cfg.DB.Preload("SubscriptionPlan").First(&org, orgID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("organization %d not found", orgID)
+		}
+		return err
+	}
+	var subs []models.Subscription
+	err = cfg.DB.Preload("SubscriptionPlan").
+		Where("organization_id = ?", org.ID).
+		Order("start_date DESC, id DESC").
+		Find(&subs).Error
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Organization %d %s, plan %s\n\n", org.ID, org.Name, orNone(org.SubscriptionPlan.Name))
+	if len(subs) == 0 {
+		fmt.Println("No subscriptions")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPLAN\tSTATUS\tSTART\tEND\tLAST PAYMENT\tNEXT BILLING\tPAYMENT METHOD")
+	for _, sub := range subs {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", sub.ID, orNone(sub.SubscriptionPlan.Name), sub.Status,
+			formatDate(sub.StartDate), formatDate(sub.EndDate), formatDate(sub.LastPaymentDate),
+			formatDate(sub.NextBillingDate), orNone(sub.PaymentMethod))
+	}
+	return w.Flush()
+}
+
+// encryptedModels are the models with encrypted fields
+var encryptedModels = []interface{}{&models.User{}, &models.SIEMIntegration{}}
+
+// encryptionCommand manages the encryption of sensitive columns
+func encryptionCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{Use: "encryption", Short: "Manage the encryption of sensitive columns"}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "rotate",
+		Short: "Encrypt the encrypted fields with the current key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return encryptionRotate(cfg)
+		},
+	})
+	return cmd
+}
+
+// encryptionRotate encrypts the encrypted fields with the current key, after
+// a key was added or the values were stored in plaintext
+func encryptionRotate(cfg *config.Config) error {
+	for _, model := range encryptedModels {
+		n, err := encryption.Reencrypt(context.Background(), cfg.DB, model)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Re-encrypted %d rows of %T\n", n, model)
+	}
+	return nil
+}
+
+// findUser returns the user with the email
+func findUser(db *gorm.DB, email string) (*models.User, error) {
+	var user models.User
+	err := db.Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("user %s not found", email)
+	}
+	return &user, err
+}
+
+// findRole returns the role with the name, listing the existing ones if there's none
+func findRole(db *gorm.DB, name string) (*models.Role, error) {
+	var role models.Role
+	err := db.Where("name = ?", name).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var names []string
+		db.Model(&models.Role{}).Order("name").Pluck("name", &names)
+		return nil, fmt.Errorf("role %q not found, existing roles: %v", name, names)
+	}
+	return &role, err
+}
+
+// invalidateAccess drops the cached access of a user, or of every user if
+// userID is 0, so servers sharing the cache apply the change at once. Servers
+// with an in-memory cache apply it within access.DefaultTTL.
+func invalidateAccess(cfg *config.Config, userID uint) {
+	c, err := cache.New(cfg.Cache)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cached access not invalidated: %v\n", err)
+		return
+	}
+	if closer, ok := c.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	acc := access.New(cfg.DB, c)
+	if userID == 0 {
+		acc.InvalidateAll(context.Background())
+	} else {
+		acc.InvalidateUser(context.Background(), userID)
+	}
+}
+
+// randomSecret returns n random bytes, hex encoded
+func randomSecret(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// formatDate formats a date of a subscription, leaving unset ones blank
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
+
+// orNone returns s, or "-" if it's empty
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,565 @@
+// Package app/app.go
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/access"
+	"github.com/4cecoder/saas/accounts"
+	"github.com/4cecoder/saas/activity"
+	"github.com/4cecoder/saas/attachments"
+	"github.com/4cecoder/saas/audit"
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/branding"
+	"github.com/4cecoder/saas/cache"
+	"github.com/4cecoder/saas/comments"
+	"github.com/4cecoder/saas/config"
+	"github.com/4cecoder/saas/customfields"
+	"github.com/4cecoder/saas/devices"
+	"github.com/4cecoder/saas/docs"
+	"github.com/4cecoder/saas/encryption"
+	"github.com/4cecoder/saas/errorreport"
+	"github.com/4cecoder/saas/events"
+	"github.com/4cecoder/saas/exports"
+	"github.com/4cecoder/saas/feedback"
+	"github.com/4cecoder/saas/handlers"
+	"github.com/4cecoder/saas/imports"
+	"github.com/4cecoder/saas/invitations"
+	"github.com/4cecoder/saas/logging"
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/middleware"
+	"github.com/4cecoder/saas/onboarding"
+	"github.com/4cecoder/saas/regions"
+	"github.com/4cecoder/saas/reports"
+	"github.com/4cecoder/saas/repository"
+	"github.com/4cecoder/saas/retention"
+	"github.com/4cecoder/saas/rpc"
+	"github.com/4cecoder/saas/scanner"
+	"github.com/4cecoder/saas/scheduler"
+	"github.com/4cecoder/saas/search"
+	"github.com/4cecoder/saas/settings"
+	"github.com/4cecoder/saas/storage"
+	"github.com/4cecoder/saas/support"
+	"github.com/4cecoder/saas/tracing"
+	"github.com/4cecoder/saas/workflow"
+)
+
+// Providers construct the replaceable dependencies of the application. Nil
+// providers fall back to the implementations selected by the configuration, so
+// environments and tests only set the ones they swap out.
+type Providers struct {
+	Mailer   func(cfg *config.Config) mailer.Mailer
+	Storage  func(cfg *config.Config) storage.Storage
+	Searcher func(cfg *config.Config) (search.Searcher, error)
+	Cache    func(cfg *config.Config) (cache.Cache, error)
+	Scanner  func(cfg *config.Config) (scanner.Scanner, error)
+	// WebhookClient calls the URLs of workflow webhook steps, only public
+	// addresses by default
+	WebhookClient func(cfg *config.Config) *http.Client
+	// Stores provides the repositories behind the core resource services
+	Stores func(cfg *config.Config) repository.Stores
+	// Routes mounts the HTTP routes on the router; RegisterRoutes by default
+	Routes func(r *gin.Engine, h *handlers.Handler)
+}
+
+// withDefaults fills the unset providers with the configured implementations
+func (p Providers) withDefaults() Providers {
+	if p.Mailer == nil {
+		p.Mailer = func(cfg *config.Config) mailer.Mailer { return mailer.New(cfg.Mail) }
+	}
+	if p.Storage == nil {
+		p.Storage = func(cfg *config.Config) storage.Storage { return storage.NewLocal(cfg.StorageDir) }
+	}
+	if p.Searcher == nil {
+		p.Searcher = func(cfg *config.Config) (search.Searcher, error) { return search.New(cfg.Search, cfg.DB) }
+	}
+	if p.Cache == nil {
+		p.Cache = func(cfg *config.Config) (cache.Cache, error) { return cache.New(cfg.Cache) }
+	}
+	if p.Scanner == nil {
+		p.Scanner = func(cfg *config.Config) (scanner.Scanner, error) { return scanner.New(cfg.Scan) }
+	}
+	if p.WebhookClient == nil {
+		p.WebhookClient = func(*config.Config) *http.Client { return workflow.NewWebhookClient() }
+	}
+	if p.Stores == nil {
+		p.Stores = func(cfg *config.Config) repository.Stores { return repository.NewGormStores(cfg.DB) }
+	}
+	if p.Routes == nil {
+		p.Routes = RegisterRoutes
+	}
+	return p
+}
+
+// App holds the assembled components of the application
+type App struct {
+	Config   *config.Config
+	DB       *gorm.DB
+	Bus      *events.Bus
+	Mailer   mailer.Mailer
+	Storage  storage.Storage
+	Searcher search.Searcher
+	Cache    cache.Cache
+	// Scanner checks uploads for malware; nil when none is configured
+	Scanner scanner.Scanner
+	// Access caches the authorization data of users and organizations
+	Access *access.Access
+	// Regions keeps the data of organizations in their region
+	Regions *regions.Regions
+	// Scheduler is the queue running background jobs
+	Scheduler   *scheduler.Scheduler
+	Recorder    *activity.Recorder
+	Forwarder   *audit.Forwarder
+	Idempotency *middleware.Idempotency
+	RateLimiter *middleware.RateLimiter
+	CORS        *middleware.CORS
+	// Settings holds the settings changed at runtime, such as rate limits
+	Settings *settings.Store
+	// Reporter sends panics and server errors to the error tracker; nil when none is configured
+	Reporter *errorreport.Client
+	Handler  *handlers.Handler
+	Router   *gin.Engine
+	// Debug serves profiles and runtime metrics on the internal debug port
+	Debug *gin.Engine
+	GRPC  *rpc.Server
+
+	// servers are the HTTP servers started by Start and Run
+	servers []*http.Server
+	// workers tracks the background workers started by Start
+	workers sync.WaitGroup
+	// stopWorkers stops the workers started by Run
+	stopWorkers context.CancelFunc
+}
+
+// New assembles the application from the configuration and providers. It
+// registers callbacks on the database but starts nothing; see Run.
+func New(cfg *config.Config, p Providers) (*App, error) {
+	p = p.withDefaults()
+
+	searcher, err := p.Searcher(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("configure search: %w", err)
+	}
+	c, err := p.Cache(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("configure cache: %w", err)
+	}
+	scan, err := p.Scanner(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("configure scanner: %w", err)
+	}
+	acc := access.New(cfg.DB, c)
+	reporter, err := errorreport.New(cfg.ErrorReporting, logging.NewRedactor(cfg.Log.RedactFields...))
+	if err != nil {
+		return nil, fmt.Errorf("configure error reporting: %w", err)
+	}
+
+	// Allow the configured origins and the custom domains of organizations
+	cors, err := middleware.NewCORS(cfg.CORS, acc.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("configure cors: %w", err)
+	}
+
+	// Keep the files of organizations of other regions out of the bucket
+	regs := regions.New(cfg.DB, cfg.Regions)
+
+	// Share rate limits between instances through Redis when it's the cache
+	var buckets middleware.Buckets = middleware.NewMemoryBuckets()
+	if r, ok := c.(*cache.Redis); ok {
+		buckets = middleware.NewRedisBuckets(r)
+	}
+
+	a := &App{
+		Config:      cfg,
+		DB:          cfg.DB,
+		Bus:         events.NewBus(),
+		Mailer:      p.Mailer(cfg),
+		Storage:     regs.Storage(p.Storage(cfg)),
+		Searcher:    searcher,
+		Cache:       c,
+		Scanner:     scan,
+		Access:      acc,
+		Regions:     regs,
+		Scheduler:   scheduler.New(cfg.DB),
+		Recorder:    activity.NewRecorder(cfg.DB),
+		Forwarder:   audit.NewForwarder(cfg.DB),
+		Idempotency: middleware.NewIdempotency(cfg.DB),
+		RateLimiter: middleware.NewRateLimiter(cfg.RateLimits, buckets, acc),
+		CORS:        cors,
+		Settings:    settings.New(cfg.DB, cfg.SettingsRefresh),
+		Reporter:    reporter,
+		GRPC:        rpc.NewServer(cfg.DB, acc),
+	}
+
+	// Record audit logs for tenant-owned models
+	if err := audit.RegisterCallbacks(a.DB); err != nil {
+		return nil, fmt.Errorf("register audit callbacks: %w", err)
+	}
+
+	// Reject reading and writing the records of organizations of other regions
+	if err := a.Regions.RegisterCallbacks(a.DB); err != nil {
+		return nil, fmt.Errorf("register region callbacks: %w", err)
+	}
+
+	// Publish model changes as domain events
+	audit.OnWrite(a.Bus.PublishAudit)
+	if err := a.Bus.PublishChanges(a.DB, "users", "roles", "permissions", "subscription_plans", "features"); err != nil {
+		return nil, fmt.Errorf("register change events: %w", err)
+	}
+
+	// Drop cached authorization data when the records behind it change
+	a.Access.Subscribe(a.Bus)
+
+	// Reject the tokens issued before the sessions of their user were expired;
+	// the handlers fail anyway when access can't be loaded
+	auth.CheckRevocation(func(ctx context.Context, userID uint, issuedAt time.Time) bool {
+		u, err := a.Access.User(ctx, userID)
+		return err == nil && u.SessionRevoked(issuedAt)
+	})
+
+	a.defineSettings()
+	a.Handler = a.newHandler(p)
+	a.Router = a.newRouter(p)
+	a.Debug = a.newDebugRouter()
+
+	if err := a.registerJobs(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// newHandler creates the HTTP handler with its engines
+func (a *App) newHandler(p Providers) *handlers.Handler {
+	h := handlers.NewHandler(a.DB)
+	h.Cache = a.Cache
+	h.Access = a.Access
+	h.UseStores(p.Stores(a.Config))
+	h.Reports = reports.NewEngine(a.DB, a.Config.ReportDB)
+	h.Exports = reports.NewExporter(a.DB, a.Storage)
+	h.Workflows = workflow.NewEngine(a.DB)
+	h.Workflows.RegisterActions(a.Mailer, p.WebhookClient(a.Config))
+	h.Workflows.Subscribe(a.Bus)
+	h.Events.Subscribe(a.Bus)
+	h.Searcher = a.Searcher
+	h.Settings = a.Settings
+	h.Devices = devices.NewTracker(a.DB, a.Mailer, a.Config.Devices)
+	h.EmailChanges = accounts.NewEmailChanges(a.DB, a.Mailer, a.Config.AppURL)
+	h.Suspensions = accounts.NewSuspensions(a.DB, a.Access)
+	h.Invitations = invitations.New(a.DB, a.Mailer, a.Config.AppURL)
+	h.Imports = imports.NewImporter(a.DB, h.Seats, h.Invitations)
+	h.Branding = branding.NewAssets(a.DB, a.Storage, a.Config.PublicURL)
+	h.Onboarding = onboarding.New(a.DB)
+	h.Feedback = feedback.New(a.DB, a.Config.Feedback)
+	h.Support = support.New(a.DB, a.Mailer, a.Config.AppURL, a.Config.Support)
+	h.CustomFields = customfields.New(a.DB)
+	h.Comments = comments.New(a.DB, a.Mailer, a.Config.AppURL)
+	h.Attachments = attachments.New(a.DB, a.Storage, a.Scanner, a.Mailer, a.Config.PublicURL, a.Config.Auth.Secret, a.Config.Attachments)
+	h.OrganizationExports = exports.New(a.DB, a.Storage, a.Config.PublicURL, a.Config.Auth.Secret, a.Config.Exports)
+	h.OrganizationRestores = exports.NewRestorer(a.DB)
+	h.OrganizationRestores.Region = a.Config.Regions.Region
+	h.Regions = a.Regions
+
+	// Mirror indexed models into an external search engine
+	if idx, ok := a.Searcher.(search.Index); ok {
+		indexer := search.NewIndexer(a.DB, idx)
+		indexer.Subscribe(a.Bus)
+		h.Indexer = indexer
+	}
+	return h
+}
+
+// newRouter creates the Gin router with its middleware and routes
+func (a *App) newRouter(p Providers) *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.Tracing(a.Config.Tracing.Service()), middleware.RequestID(), middleware.SecurityHeaders(a.Config.Security), middleware.Logger(), middleware.Recovery(a.Reporter))
+
+	// Compress large responses, outside the middleware recording them
+	if a.Config.Compression.Enabled {
+		r.Use(middleware.Compress(a.Config.Compression))
+	}
+
+	// Answer preflights before they're authenticated, throttled or turned away
+	// by maintenance, and let browsers read the rejections
+	r.Use(a.CORS.Middleware())
+
+	// Reject bodies over the size limit before anything reads them
+	limits := a.Config.HTTP.Limits
+	limits.RouteTimeouts, limits.RouteBodySizes = routeTimeouts, routeBodySizes
+	r.Use(limits.BodySize())
+	r.Use(auth.Identify())
+
+	// Reject requests changing data with a session cookie but no CSRF token
+	r.Use(middleware.CSRF())
+
+	// Hold users who must change their password to doing so, or signing out
+	r.Use(middleware.PasswordChange(a.passwordChangeRequired, "/me/password", "/sessions", "/healthz", "/readyz"))
+
+	// Answer 503 during maintenance, except to admins, health checks and clients
+	// checking the maintenance mode. Signing in stays open, so that admins whose
+	// token expired can turn maintenance off.
+	r.Use(middleware.Maintenance(a.maintenance, "/sessions", "/healthz", "/readyz", "/health/", "/maintenance"))
+
+	// Send the requests for organizations of other regions to their deployment
+	r.Use(a.Regions.Middleware())
+
+	// Throttle clients, except the health checks of load balancers
+	a.RateLimiter.Exempt = []string{"/healthz", "/readyz"}
+	r.Use(a.RateLimiter.Middleware())
+
+	r.Use(middleware.SparseFieldsets())
+
+	// Replay responses of retried POST requests
+	r.Use(a.Idempotency.Middleware())
+
+	// Record activity for authenticated requests
+	r.Use(a.Recorder.Middleware())
+
+	// Render errors attached by handlers, inside the middleware that records responses
+	r.Use(middleware.Errors())
+
+	// Cancel handlers running too long, rendering the timeout through Errors
+	r.Use(limits.Timeout())
+
+	a.Handler.Router = r
+	p.Routes(r, a.Handler)
+
+	// Serve the API documentation
+	docs.Register(r.Group("", a.Handler.RequireFeature("docs")))
+	return r
+}
+
+// registerJobs registers the background jobs with the scheduler
+func (a *App) registerJobs() error {
+	if err := retention.NewPurger(a.DB, a.Storage).Register(a.Scheduler); err != nil {
+		return fmt.Errorf("register retention job: %w", err)
+	}
+	reports.NewDelivery(a.Handler.Reports, a.Mailer).Register(a.Scheduler)
+	if err := a.Idempotency.Register(a.Scheduler); err != nil {
+		return fmt.Errorf("register idempotency job: %w", err)
+	}
+	if err := a.Handler.Workflows.Register(a.Scheduler); err != nil {
+		return fmt.Errorf("register workflow jobs: %w", err)
+	}
+	if err := a.Handler.OrganizationExports.Register(a.Scheduler); err != nil {
+		return fmt.Errorf("register export job: %w", err)
+	}
+	return nil
+}
+
+// Setup prepares the search indexes
+func (a *App) Setup(ctx context.Context) error {
+	if err := a.Searcher.Setup(ctx); err != nil {
+		return fmt.Errorf("set up search indexes: %w", err)
+	}
+	return nil
+}
+
+// Start starts the background workers and the gRPC and debug servers; they stop with ctx
+func (a *App) Start(ctx context.Context) {
+	// Apply the settings changed at runtime before serving
+	if err := a.Settings.Load(ctx); err != nil {
+		slog.Warn("settings: failed to load settings, using the defaults", "error", err)
+	}
+
+	workers := []func(context.Context){a.Recorder.Run, a.Forwarder.Run, a.Scheduler.Run, a.Settings.Run}
+	if a.Config.Secrets != nil {
+		workers = append(workers, func(ctx context.Context) { a.Config.Secrets.Watch(ctx, a.rotateSecret) })
+	}
+	for _, run := range workers {
+		a.workers.Add(1)
+		go func(run func(context.Context)) {
+			defer a.workers.Done()
+			run(ctx)
+		}(run)
+	}
+
+	// Serve the internal gRPC API to other services
+	if a.Config.GRPC.Addr != "" {
+		go func() {
+			if err := a.GRPC.ListenAndServe(a.Config.GRPC); err != nil {
+				logging.Fatal("failed to start the gRPC server", "error", err)
+			}
+		}()
+	}
+
+	// Serve profiles and runtime metrics on the internal debug port
+	if a.Config.DebugAddr != "" {
+		debug := a.serve(a.Config.DebugAddr, a.Debug)
+		go func() {
+			if err := debug.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				logging.Fatal("failed to start the debug server", "error", err)
+			}
+		}()
+	}
+}
+
+// passwordChangeRequired reports whether a user must change their password
+// before anything else
+func (a *App) passwordChangeRequired(ctx context.Context, userID uint) bool {
+	u, err := a.Access.User(ctx, userID)
+	return err == nil && u.PasswordChangeRequired
+}
+
+// rotateSecret applies a secret rotated in the secrets manager. The JWT secret
+// and encryption keys take effect at once; the others, read into connections and clients at
+// startup, on the next restart.
+func (a *App) rotateSecret(key, value string) {
+	switch key {
+	case "JWT_SECRET":
+		if len(value) < auth.MinSecretLength {
+			slog.Error("secrets: rotated JWT_SECRET is too short, keeping the current one", "min_length", auth.MinSecretLength)
+			return
+		}
+		auth.RotateSecret(value)
+	case "ENCRYPTION_KEYS":
+		keys, err := encryption.ParseKeys(value)
+		if err == nil && len(keys) == 0 {
+			err = errors.New("no keys")
+		}
+		if err == nil {
+			err = encryption.Setup(keys)
+		}
+		if err != nil {
+			slog.Error("secrets: rotated ENCRYPTION_KEYS are invalid, keeping the current ones", "error", err)
+		}
+	default:
+		slog.Warn("secrets: restart to apply the rotated secret", "key", key)
+	}
+}
+
+// serve creates an HTTP server of handler on addr that Shutdown stops
+func (a *App) serve(addr string, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       a.Config.HTTP.ReadTimeout,
+		WriteTimeout:      a.Config.HTTP.WriteTimeout,
+		IdleTimeout:       a.Config.HTTP.IdleTimeout,
+	}
+	a.servers = append(a.servers, srv)
+	return srv
+}
+
+// Run starts the application and serves HTTP on addr until ctx is done, then
+// shuts down within the configured timeout. With TLS configured it serves
+// HTTPS and HTTP/2 on addr, and redirects plain HTTP on the redirect address.
+func (a *App) Run(ctx context.Context, addr string) error {
+	srv := a.serve(addr, a.Router)
+	var redirect *http.Server
+	if a.tlsEnabled() {
+		tlsCfg, challenges, err := a.tlsConfig(redirectHTTPS(addr))
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = tlsCfg
+		if a.Config.TLS.RedirectAddr != "" {
+			redirect = a.serve(a.Config.TLS.RedirectAddr, challenges)
+		}
+	}
+
+	// The workers outlive ctx, to finish their jobs while requests drain
+	workers, stop := context.WithCancel(context.WithoutCancel(ctx))
+	a.stopWorkers = stop
+	a.Start(workers)
+
+	errs := make(chan error, 2)
+	if srv.TLSConfig != nil {
+		go func() { errs <- srv.ListenAndServeTLS("", "") }()
+		slog.Info("serving HTTPS", "addr", addr)
+	} else {
+		go func() { errs <- srv.ListenAndServe() }()
+		slog.Info("serving HTTP", "addr", addr)
+	}
+	if redirect != nil {
+		go func() { errs <- redirect.ListenAndServe() }()
+		slog.Info("redirecting HTTP to HTTPS", "addr", redirect.Addr)
+	}
+
+	select {
+	case err := <-errs:
+		stop()
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", "timeout", a.Config.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+	defer cancel()
+	if err := a.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shut down: %w", err)
+	}
+	slog.Info("shut down")
+	return nil
+}
+
+// Shutdown stops the application gracefully, giving up when ctx is done.
+// Readiness fails first, so load balancers stop sending requests during the
+// configured delay. Then the servers stop accepting connections and finish
+// their requests. The background workers finish their jobs and flush their
+// queues. Finally, the spans are exported and the connections to Redis and the
+// databases are closed.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.Handler.Draining.Store(true)
+	if delay := a.Config.ShutdownDelay; delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	var errs []error
+	for _, srv := range a.servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("drain %s: %w", srv.Addr, err))
+		}
+	}
+	if err := a.GRPC.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("drain gRPC: %w", err))
+	}
+
+	if a.stopWorkers != nil {
+		a.stopWorkers()
+	}
+	done := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		a.Scheduler.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("wait for background workers: %w", ctx.Err()))
+	}
+
+	if err := tracing.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if closer, ok := a.Cache.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close cache: %w", err))
+		}
+	}
+	for _, db := range []*gorm.DB{a.DB, a.Config.ReportDB} {
+		if db == nil {
+			continue
+		}
+		if sqlDB, err := db.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close database: %w", err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
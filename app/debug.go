@@ -0,0 +1,52 @@
+// Package app/debug.go
+package app
+
+import (
+	"expvar"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/middleware"
+	"github.com/4cecoder/saas/models"
+)
+
+// newDebugRouter creates the router of the internal debug port, serving pprof
+// profiles under /debug/pprof and expvar metrics at /debug/vars to admins only.
+// It is meant for an address only reachable inside the cluster, such as
+// 127.0.0.1:6060.
+func (a *App) newDebugRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Logger(), middleware.Recovery(a.Reporter))
+	r.Use(middleware.Errors())
+	r.Use(auth.AuthMiddleware(models.AdminRole))
+
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	r.GET("/debug/pprof/*profile", profile)
+	r.POST("/debug/pprof/symbol", gin.WrapF(pprof.Symbol))
+
+	r.NoRoute(func(c *gin.Context) {
+		c.Error(apperror.NotFound("Route not found"))
+	})
+	return r
+}
+
+// profile serves the pprof index, a CPU profile or execution trace taking
+// ?seconds= to record, or a named profile such as heap or goroutine
+func profile(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("profile"), "/") {
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Index(c.Writer, c.Request)
+	}
+}
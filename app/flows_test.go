@@ -0,0 +1,275 @@
+// Package app/flows_test.go
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/4cecoder/saas/app"
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/config"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/handlers"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/testutil/factories"
+	"github.com/4cecoder/saas/testutil/integration"
+)
+
+// invitationToken finds the token of invitation links
+var invitationToken = regexp.MustCompile(`token=(\S+)`)
+
+// tokenOf returns a user token of the user
+func tokenOf(t *testing.T, user *models.User) string {
+	t.Helper()
+	token, err := auth.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+// adminTokenOf returns an admin token of the user
+func adminTokenOf(t *testing.T, user *models.User) string {
+	t.Helper()
+	token, err := auth.GenerateAdminToken(user)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+// send makes a JSON request to the harness's /v1 API with the bearer token, if
+// any, decodes the response into out, if given, and returns its status
+func send(t *testing.T, h *integration.Harness, token, method, path string, body, out interface{}) int {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("encode %s %s: %v", method, path, err)
+		}
+	}
+	req, err := http.NewRequest(method, h.Server.URL+"/v1"+path, &payload)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := h.Server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode %s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// waitForInvitation returns the token of the invitation emailed to the address,
+// which is sent in the background
+func waitForInvitation(t *testing.T, h *integration.Harness, email string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, msg := range h.Outbox.To(email) {
+			if m := invitationToken.FindStringSubmatch(msg.Text); m != nil {
+				token, err := url.QueryUnescape(m[1])
+				if err != nil {
+					t.Fatalf("invitation token: %v", err)
+				}
+				return token
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("no invitation was emailed to %s", email)
+	return ""
+}
+
+func TestSignupToSubscription(t *testing.T) {
+	// Webhooks of workflows are delivered to the receiver, which the default
+	// client refuses as it listens on loopback
+	deliveries := make(chan []byte, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- body
+	}))
+	defer receiver.Close()
+	h := integration.New(t, app.Providers{
+		WebhookClient: func(*config.Config) *http.Client { return receiver.Client() },
+	})
+	ctx := context.Background()
+
+	// Sign up, then create an organization and run it
+	owner, err := h.Client().CreateUser(ctx, dto.CreateUserRequest{Email: "owner@example.com", Password: "correct horse", Name: "Owner"})
+	if err != nil {
+		t.Fatalf("sign up: %v", err)
+	}
+	ownerClient := h.As(t, owner)
+	org, err := ownerClient.CreateOrganization(ctx, dto.CreateOrganizationRequest{Name: "Acme"})
+	if err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	makeOrgAdmin(t, h, org, owner)
+
+	// Invite a colleague, who accepts by choosing a password. Deployments seed
+	// the role invitations give by default.
+	if err := h.DB.Create(&models.Role{Name: models.UserRole}).Error; err != nil {
+		t.Fatalf("create role: %v", err)
+	}
+	var imp models.UserImport
+	code := send(t, h, tokenOf(t, owner), http.MethodPost, fmt.Sprintf("/organizations/%d/invitations", org.ID),
+		dto.InviteUsersRequest{Emails: []string{"colleague@example.com"}}, &imp)
+	if code != http.StatusAccepted {
+		t.Fatalf("invite = %d, want 202", code)
+	}
+	accept := dto.AcceptInvitationRequest{Token: waitForInvitation(t, h, "colleague@example.com"), Password: "battery staple"}
+	if code := send(t, h, "", http.MethodPost, "/auth/invitations/accept", accept, nil); code != http.StatusOK {
+		t.Fatalf("accept invitation = %d, want 200", code)
+	}
+	if code := send(t, h, "", http.MethodPost, "/auth/invitations/accept", accept, nil); code != http.StatusBadRequest {
+		t.Errorf("accepting again = %d, want 400", code)
+	}
+
+	var colleague models.User
+	if err := h.DB.Where("email = ?", "colleague@example.com").First(&colleague).Error; err != nil {
+		t.Fatalf("load colleague: %v", err)
+	}
+	var me handlers.MeResponse
+	if code := send(t, h, tokenOf(t, &colleague), http.MethodGet, "/me", nil, &me); code != http.StatusOK {
+		t.Fatalf("GET /me = %d, want 200", code)
+	}
+	if len(me.Organizations) != 1 || me.Organizations[0].ID != org.ID {
+		t.Errorf("colleague's organizations = %+v, want only %d", me.Organizations, org.ID)
+	}
+
+	// The colleague works in the organization but doesn't administer it
+	colleagueClient := h.As(t, &colleague)
+	if _, err := colleagueClient.CreateReport(ctx, newReport(org.ID)); err != nil {
+		t.Errorf("create report as the colleague: %v", err)
+	}
+	if _, err := colleagueClient.GetOrganizationSettings(ctx, org.ID); status(t, err) != http.StatusForbidden {
+		t.Errorf("GET settings as the colleague = %d, want 403", status(t, err))
+	}
+
+	// Notify a webhook of subscriptions, then subscribe the organization
+	_, err = ownerClient.CreateWorkflow(ctx, dto.CreateWorkflowRequest{
+		Name:           "Announce subscriptions",
+		OrganizationID: org.ID,
+		Triggers:       []string{"subscription.created"},
+		Steps:          []models.WorkflowStep{{Name: "notify", Type: models.WorkflowActionWebhook, Config: models.JSONMap{"url": receiver.URL}}},
+		Enabled:        true,
+	})
+	if err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	sub, err := ownerClient.CreateSubscription(ctx, dto.CreateSubscriptionRequest{OrganizationID: org.ID, Status: models.SubscriptionStatusActive})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	got, err := ownerClient.GetSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("get subscription: %v", err)
+	}
+	if got.OrganizationID != org.ID || got.Status != models.SubscriptionStatusActive {
+		t.Errorf("subscription = {OrganizationID: %d, Status: %s}, want {%d, active}", got.OrganizationID, got.Status, org.ID)
+	}
+	select {
+	case body := <-deliveries:
+		var delivery struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		if err := json.Unmarshal(body, &delivery); err != nil {
+			t.Fatalf("decode webhook %s: %v", body, err)
+		}
+		if delivery.Input["event"] != "subscription.created" || fmt.Sprint(delivery.Input["resource_id"]) != fmt.Sprint(sub.ID) {
+			t.Errorf("webhook input = %v, want subscription.created of %d", delivery.Input, sub.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the subscription webhook was not delivered")
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	h := integration.New(t)
+	user := factories.CreateUser(t, h.DB)
+	admin, _ := h.Admin(t)
+	adminToken := adminTokenOf(t, admin)
+
+	tests := []struct {
+		name  string
+		token string
+		path  string
+		want  int
+	}{
+		{"no token", "", "/me", http.StatusUnauthorized},
+		{"malformed token", "not-a-token", "/me", http.StatusUnauthorized},
+		{"tampered token", tokenOf(t, user) + "x", "/me", http.StatusUnauthorized},
+		{"user", tokenOf(t, user), "/me", http.StatusOK},
+		{"user on an admin route", tokenOf(t, user), "/users", http.StatusUnauthorized},
+		{"admin on an admin route", adminToken, "/users", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := send(t, h, tt.token, http.MethodGet, tt.path, nil, nil); got != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserAccountsNeedOwnerOrAdmin(t *testing.T) {
+	h := integration.New(t)
+	user := factories.CreateUser(t, h.DB)
+	other := factories.CreateUser(t, h.DB)
+	admin, _ := h.Admin(t)
+	path := fmt.Sprintf("/users/%d", user.ID)
+	rename := map[string]string{"name": "Renamed"}
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		want   int
+	}{
+		{"anonymous update", "", http.MethodPut, http.StatusUnauthorized},
+		{"other user update", tokenOf(t, other), http.MethodPut, http.StatusForbidden},
+		{"other user delete", tokenOf(t, other), http.MethodDelete, http.StatusForbidden},
+		{"self update", tokenOf(t, user), http.MethodPut, http.StatusOK},
+		{"admin update", adminTokenOf(t, admin), http.MethodPut, http.StatusOK},
+		{"anonymous delete", "", http.MethodDelete, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := send(t, h, tt.token, tt.method, path, rename, nil); got != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, path, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("password is not updatable", func(t *testing.T) {
+		var before, after models.User
+		h.DB.First(&before, user.ID)
+		body := map[string]string{"password": "taken-over-123"}
+		if got := send(t, h, tokenOf(t, user), http.MethodPut, path, body, nil); got != http.StatusOK {
+			t.Fatalf("PUT %s = %d, want 200", path, got)
+		}
+		h.DB.First(&after, user.ID)
+		if after.Password != before.Password {
+			t.Error("PUT /users/:id changed the password")
+		}
+	})
+}
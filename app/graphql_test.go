@@ -0,0 +1,142 @@
+// Package app/graphql_test.go
+package app_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/4cecoder/saas/graphql"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/testutil/factories"
+	"github.com/4cecoder/saas/testutil/integration"
+)
+
+// organizationQuery selects an organization's public and members-only fields
+const organizationQuery = `query($id: ID!) {
+  organization(id: $id) {
+    name
+    users { id email }
+    subscriptions { status paymentMethod organization { name } }
+    workflows { name }
+  }
+}`
+
+// queryData runs a GraphQL query and decodes its data, failing on errors
+func queryData(t *testing.T, resp *graphql.Response, err error, out interface{}) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(resp.Errors) > 0 {
+		t.Fatalf("query errors: %s", resp.Errors[0].Message)
+	}
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatalf("encode data: %v", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatalf("decode data %s: %v", data, err)
+	}
+}
+
+func TestGraphQLFollowsAccessRules(t *testing.T) {
+	h := integration.New(t)
+	ctx := context.Background()
+	org := factories.CreateOrganization(t, h.DB)
+	other := factories.CreateOrganization(t, h.DB)
+	user := factories.CreateMember(t, h.DB, org)
+	member := h.As(t, user)
+	outsider := h.As(t, factories.CreateMember(t, h.DB, other))
+	if err := h.DB.Create(&models.Subscription{OrganizationID: org.ID, PaymentMethod: "card"}).Error; err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	if err := h.DB.Create(&models.Workflow{Name: "Onboarding", OrganizationID: org.ID}).Error; err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	vars := map[string]interface{}{"id": org.ID}
+
+	type organization struct {
+		Name  string
+		Users []struct {
+			ID    string
+			Email *string
+		}
+		Subscriptions []struct {
+			Status        string
+			PaymentMethod *string
+			Organization  struct{ Name string }
+		}
+		Workflows []struct{ Name string }
+	}
+
+	t.Run("member", func(t *testing.T) {
+		var data struct{ Organization organization }
+		resp, err := member.GraphQL(ctx, graphql.Request{Query: organizationQuery, Variables: vars})
+		queryData(t, resp, err, &data)
+
+		got := data.Organization
+		if len(got.Users) != 1 || got.Users[0].ID != fmt.Sprint(user.ID) {
+			t.Errorf("users = %+v, want only %d", got.Users, user.ID)
+		} else if got.Users[0].Email == nil || *got.Users[0].Email != user.Email {
+			t.Errorf("own email = %v, want %s", got.Users[0].Email, user.Email)
+		}
+		if len(got.Subscriptions) != 1 || got.Subscriptions[0].PaymentMethod == nil || got.Subscriptions[0].Organization.Name != org.Name {
+			t.Errorf("subscriptions = %+v, want one paid by card of %s", got.Subscriptions, org.Name)
+		}
+		if len(got.Workflows) != 1 {
+			t.Errorf("workflows = %+v, want Onboarding", got.Workflows)
+		}
+	})
+
+	t.Run("outsider", func(t *testing.T) {
+		var data struct{ Organization organization }
+		resp, err := outsider.GraphQL(ctx, graphql.Request{Query: organizationQuery, Variables: vars})
+		queryData(t, resp, err, &data)
+
+		got := data.Organization
+		if got.Name != org.Name {
+			t.Errorf("name = %q, want %q", got.Name, org.Name)
+		}
+		if got.Users != nil || got.Subscriptions != nil || got.Workflows != nil {
+			t.Errorf("members-only fields = %+v, want null", got)
+		}
+	})
+
+	t.Run("admin-only lists", func(t *testing.T) {
+		resp, err := member.GraphQL(ctx, graphql.Request{Query: "{ users { id } }"})
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		if len(resp.Errors) != 1 || resp.Errors[0].Message != graphql.ErrForbidden.Error() {
+			t.Errorf("errors = %+v, want forbidden", resp.Errors)
+		}
+	})
+
+	t.Run("workflow of another organization", func(t *testing.T) {
+		var data struct {
+			Workflows []struct{ Name string }
+		}
+		resp, err := outsider.GraphQL(ctx, graphql.Request{Query: "{ workflows { name } }"})
+		queryData(t, resp, err, &data)
+		if len(data.Workflows) != 0 {
+			t.Errorf("workflows = %+v, want none", data.Workflows)
+		}
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		var resp graphql.Response
+		path := "/graphql?query=" + url.QueryEscape("{ me { email } }")
+		if code := send(t, h, tokenOf(t, user), http.MethodGet, path, nil, &resp); code != http.StatusOK {
+			t.Fatalf("GET /graphql = %d, want 200", code)
+		}
+		var data struct{ Me struct{ Email string } }
+		queryData(t, &resp, nil, &data)
+		if data.Me.Email != user.Email {
+			t.Errorf("me = %+v, want %s", data.Me, user.Email)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+// Package app/maintenance_test.go
+package app_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/testutil/factories"
+	"github.com/4cecoder/saas/testutil/integration"
+)
+
+func TestAdminsSignInDuringMaintenance(t *testing.T) {
+	auth.Setup(auth.Config{Cookies: auth.CookieConfig{Enabled: true}})
+	t.Cleanup(func() { auth.Setup(auth.Config{}) })
+
+	h := integration.New(t)
+	admin, _ := h.Admin(t)
+	user := factories.CreateUser(t, h.DB)
+	full := map[string]string{"mode": "full"}
+	if code := send(t, h, adminTokenOf(t, admin), http.MethodPut, "/maintenance", full, nil); code != http.StatusOK {
+		t.Fatalf("PUT /maintenance = %d, want 200", code)
+	}
+	if code := send(t, h, tokenOf(t, user), http.MethodGet, "/me", nil, nil); code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /me during maintenance = %d, want 503", code)
+	}
+
+	// The admin's token expired: they sign in again and turn maintenance off
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookie jar: %v", err)
+	}
+	browser := &http.Client{Jar: jar}
+	do := func(method, path, csrf string, body interface{}) *http.Response {
+		t.Helper()
+		payload, _ := json.Marshal(body)
+		req, err := http.NewRequest(method, h.Server.URL+"/v1"+path, bytes.NewReader(payload))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if csrf != "" {
+			req.Header.Set("X-CSRF-Token", csrf)
+		}
+		resp, err := browser.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/sessions", "", map[string]string{"email": admin.Email, "password": factories.Password})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /sessions during maintenance = %d, want 200", resp.StatusCode)
+	}
+	var session auth.Session
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+
+	if resp := do(http.MethodPut, "/maintenance", session.CSRFToken, map[string]string{"mode": "off"}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT /maintenance with the new session = %d, want 200", resp.StatusCode)
+	}
+	if code := send(t, h, tokenOf(t, user), http.MethodGet, "/me", nil, nil); code != http.StatusOK {
+		t.Errorf("GET /me after maintenance = %d, want 200", code)
+	}
+}
@@ -0,0 +1,84 @@
+// Package app/reports_test.go
+package app_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/4cecoder/saas/client"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/testutil/factories"
+	"github.com/4cecoder/saas/testutil/integration"
+)
+
+// usersQuery lists the users of a report's organization
+var usersQuery = models.ReportQuery{Entity: "users", Fields: []string{"id", "email"}}
+
+// newReport returns the request creating a report of the organization
+func newReport(orgID uint) dto.CreateReportRequest {
+	return dto.CreateReportRequest{Name: "Users", OrganizationID: orgID, Definition: usersQuery}
+}
+
+func TestReportsAreScopedToOrganizations(t *testing.T) {
+	h := integration.New(t)
+	ctx := context.Background()
+	org := factories.CreateOrganization(t, h.DB)
+	other := factories.CreateOrganization(t, h.DB)
+	member := h.As(t, factories.CreateMember(t, h.DB, org))
+	outsider := h.As(t, factories.CreateMember(t, h.DB, other))
+
+	report, err := member.CreateReport(ctx, newReport(org.ID))
+	if err != nil {
+		t.Fatalf("create report: %v", err)
+	}
+
+	t.Run("create in another organization", func(t *testing.T) {
+		_, err := outsider.CreateReport(ctx, newReport(org.ID))
+		if got := status(t, err); got != http.StatusForbidden {
+			t.Errorf("POST /reports = %d, want 403", got)
+		}
+	})
+
+	t.Run("routes of another organization's report", func(t *testing.T) {
+		calls := map[string]func() error{
+			"get": func() error { _, err := outsider.GetReport(ctx, report.ID); return err },
+			"update": func() error {
+				_, err := outsider.UpdateReport(ctx, report.ID, dto.UpdateReportRequest{Name: "Taken", Definition: usersQuery})
+				return err
+			},
+			"run":    func() error { _, err := outsider.RunReport(ctx, report.ID); return err },
+			"runs":   func() error { _, err := outsider.ListReportRuns(ctx, report.ID, client.ListOptions{}); return err },
+			"delete": func() error { return outsider.DeleteReport(ctx, report.ID) },
+		}
+		for name, call := range calls {
+			if got := status(t, call()); got != http.StatusNotFound {
+				t.Errorf("%s = %d, want 404", name, got)
+			}
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		page, err := outsider.ListReports(ctx, client.ListOptions{})
+		if err != nil {
+			t.Fatalf("list reports: %v", err)
+		}
+		for _, r := range page.Data {
+			if r.ID == report.ID {
+				t.Errorf("listed the report of another organization")
+			}
+		}
+	})
+
+	t.Run("update keeps the record and organization", func(t *testing.T) {
+		updated, err := member.UpdateReport(ctx, report.ID, dto.UpdateReportRequest{Name: "Renamed", Definition: usersQuery})
+		if err != nil {
+			t.Fatalf("update report: %v", err)
+		}
+		if updated.ID != report.ID || updated.OrganizationID != org.ID || updated.Name != "Renamed" {
+			t.Errorf("updated report = {ID: %d, OrganizationID: %d, Name: %q}, want {%d, %d, Renamed}",
+				updated.ID, updated.OrganizationID, updated.Name, report.ID, org.ID)
+		}
+	})
+}
@@ -0,0 +1,290 @@
+// Package app/routes.go
+package app
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/handlers"
+	"github.com/4cecoder/saas/middleware"
+	"github.com/4cecoder/saas/models"
+)
+
+// legacyRoutes deprecates the unversioned paths that were served before /v1
+var legacyRoutes = middleware.Deprecation{
+	Since:     time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+	Sunset:    time.Date(2027, 4, 15, 0, 0, 0, 0, time.UTC),
+	Successor: "/v1",
+}
+
+// Routes needing more time or larger bodies than the configured limits, named
+// without the version prefix
+var (
+	routeTimeouts = map[string]time.Duration{
+		"/batch":                                   2 * time.Minute,
+		"/organizations/:id/audit-logs/export":     10 * time.Minute,
+		"/reports/:id/exports/:export_id/download": 10 * time.Minute,
+		"/attachments/:id/download":                10 * time.Minute,
+		"/workflows/:id/attachments":               10 * time.Minute,
+		"/reports/:id/attachments":                 10 * time.Minute,
+		"/subscriptions/:id/attachments":           10 * time.Minute,
+		"/organization-exports/:id/download":       10 * time.Minute,
+		"/organization-restores":                   10 * time.Minute,
+		"/events":                                  0, // streams last until the client disconnects
+	}
+	routeBodySizes = map[string]int64{
+		"/batch":                          10 << 20,
+		"/users/bulk":                     10 << 20,
+		"/seats/bulk":                     10 << 20,
+		"/organizations/:id/user-imports": 10 << 20,
+		// Plans cap attachments below these
+		"/workflows/:id/attachments":     100 << 20,
+		"/reports/:id/attachments":       100 << 20,
+		"/subscriptions/:id/attachments": 100 << 20,
+		"/organization-restores":         1 << 30,
+	}
+)
+
+// RegisterRoutes mounts every API version on the router. A new version gets its own
+// group and register function next to registerV1, reusing the handlers that didn't change.
+func RegisterRoutes(r *gin.Engine, h *handlers.Handler) {
+	r.GET("/healthz", h.Health)
+	r.GET("/readyz", h.Ready)
+	r.GET("/health/details", auth.AuthMiddleware(models.AdminRole), h.HealthDetails)
+
+	registerV1(r.Group("/v1"), h)
+	registerV1(r.Group("", middleware.Deprecated(legacyRoutes)), h)
+
+	r.NoRoute(func(c *gin.Context) {
+		c.Error(apperror.NotFound("Route not found"))
+	})
+}
+
+// registerV1 defines the routes of version 1 of the API
+func registerV1(api *gin.RouterGroup, h *handlers.Handler) {
+	// Cookie sessions of browser frontends
+	api.POST("/sessions", h.CreateSession)
+	api.DELETE("/sessions", h.DeleteSession)
+	api.POST("/auth/switch-org", auth.IsUserOrAdmin, h.SwitchOrganization)
+	api.POST("/auth/login-alerts/deny", h.DenyLogin)
+	api.POST("/auth/email-change/confirm", h.ConfirmEmailChange)
+	api.POST("/auth/invitations/accept", h.AcceptInvitation)
+
+	api.GET("/users", auth.AuthMiddleware(models.AdminRole), h.ListUsers)
+	api.POST("/users", h.CreateUser)
+	api.GET("/users/:id", h.GetUser)
+	api.PUT("/users/:id", auth.IsUserOrAdmin, h.UpdateUser)
+	api.DELETE("/users/:id", auth.IsUserOrAdmin, h.DeleteUser)
+	api.POST("/users/:id/suspend", auth.AuthMiddleware(models.AdminRole), h.SuspendUser)
+	api.POST("/users/:id/reactivate", auth.AuthMiddleware(models.AdminRole), h.ReactivateUser)
+
+	bulkRoutes := api.Group("", auth.AuthMiddleware(models.AdminRole))
+	bulkRoutes.POST("/users/bulk", h.BulkCreateUsers)
+	bulkRoutes.PATCH("/users/bulk", h.BulkUpdateUsers)
+	bulkRoutes.DELETE("/users/bulk", h.BulkDeleteUsers)
+	bulkRoutes.POST("/seats/bulk", h.BulkCreateSeats)
+	bulkRoutes.PATCH("/seats/bulk", h.BulkUpdateSeats)
+	bulkRoutes.DELETE("/seats/bulk", h.BulkDeleteSeats)
+
+	api.GET("/organizations", auth.AuthMiddleware(models.AdminRole), h.ListOrganizations)
+	api.POST("/organizations", h.CreateOrganization)
+	api.GET("/organizations/:id", h.GetOrganization)
+	api.PUT("/organizations/:id", auth.IsUserOrAdmin, h.RequireOrganizationAdmin(), h.UpdateOrganization)
+	api.DELETE("/organizations/:id", auth.IsUserOrAdmin, h.RequireOrganizationAdmin(), h.DeleteOrganization)
+
+	api.GET("/subscriptions", auth.AuthMiddleware(models.AdminRole), h.ListSubscriptions)
+	api.POST("/subscriptions", h.CreateSubscription)
+	api.GET("/subscriptions/:id", h.GetSubscription)
+	api.PUT("/subscriptions/:id", h.UpdateSubscription)
+	api.DELETE("/subscriptions/:id", h.DeleteSubscription)
+	api.GET("/subscriptions/:id/comments", auth.IsUserOrAdmin, h.ListSubscriptionComments)
+	api.POST("/subscriptions/:id/comments", auth.IsUserOrAdmin, h.CommentOnSubscription)
+	api.GET("/subscriptions/:id/attachments", auth.IsUserOrAdmin, h.ListSubscriptionAttachments)
+	api.POST("/subscriptions/:id/attachments", auth.IsUserOrAdmin, h.AttachToSubscription)
+
+	api.PATCH("/comments/:id", auth.IsUserOrAdmin, h.UpdateComment)
+	api.DELETE("/comments/:id", auth.IsUserOrAdmin, h.DeleteComment)
+
+	api.GET("/attachments/:id", auth.IsUserOrAdmin, h.GetAttachment)
+	api.DELETE("/attachments/:id", auth.IsUserOrAdmin, h.DeleteAttachment)
+	api.POST("/attachments/:id/clear", auth.AuthMiddleware(models.AdminRole), h.ClearAttachment)
+	// Signed URLs authorize downloads, so they open in browsers without a token
+	api.GET("/attachments/:id/download", h.DownloadAttachment)
+	api.GET("/organization-exports/:id/download", h.DownloadOrganizationExport)
+	api.POST("/organization-restores", auth.AuthMiddleware(models.AdminRole), h.RestoreOrganization)
+	api.GET("/organization-restores", auth.AuthMiddleware(models.AdminRole), h.ListOrganizationRestores)
+	api.GET("/organization-restores/:id", auth.AuthMiddleware(models.AdminRole), h.GetOrganizationRestore)
+
+	// Public catalog and branding, cacheable by clients and proxies
+	api.GET("/plans", h.ListPlans)
+	api.GET("/plans/:id", h.GetPlan)
+	api.GET("/features", h.ListFeatures)
+	api.GET("/organizations/:id/branding", h.GetOrganizationBranding)
+	api.GET("/branding", h.GetBranding)
+	api.GET("/branding/assets/:id/:name", h.GetBrandingAsset)
+
+	reportRoutes := api.Group("/reports", auth.IsUserOrAdmin, h.RequireAccess("report"))
+	reportRoutes.GET("", h.ListReports)
+	reportRoutes.POST("", h.CreateReport)
+	reportRoutes.GET("/:id", h.GetReport)
+	reportRoutes.PUT("/:id", h.UpdateReport)
+	reportRoutes.DELETE("/:id", h.DeleteReport)
+	reportRoutes.GET("/:id/tags", h.ListReportTags)
+	reportRoutes.PUT("/:id/tags/:tag_id", h.TagReport)
+	reportRoutes.DELETE("/:id/tags/:tag_id", h.UntagReport)
+	reportRoutes.GET("/:id/comments", h.ListReportComments)
+	reportRoutes.POST("/:id/comments", h.CommentOnReport)
+	reportRoutes.GET("/:id/attachments", h.ListReportAttachments)
+	reportRoutes.POST("/:id/attachments", h.AttachToReport)
+	reportRoutes.POST("/:id/run", h.RunReport)
+	reportRoutes.GET("/:id/runs", h.ListReportRuns)
+	reportRoutes.GET("/:id/runs/:run_id", h.GetReportRun)
+	reportRoutes.GET("/:id/exports/:export_id", h.GetReportExport)
+	reportRoutes.GET("/:id/exports/:export_id/download", h.DownloadReportExport)
+
+	workflowRoutes := api.Group("/workflows", auth.IsUserOrAdmin, h.RequireAccess("workflow"))
+	workflowRoutes.GET("", h.ListWorkflows)
+	workflowRoutes.POST("", h.CreateWorkflow)
+	workflowRoutes.GET("/:id", h.GetWorkflow)
+	workflowRoutes.PUT("/:id", h.UpdateWorkflow)
+	workflowRoutes.DELETE("/:id", h.DeleteWorkflow)
+	workflowRoutes.GET("/:id/tags", h.ListWorkflowTags)
+	workflowRoutes.PUT("/:id/tags/:tag_id", h.TagWorkflow)
+	workflowRoutes.DELETE("/:id/tags/:tag_id", h.UntagWorkflow)
+	workflowRoutes.GET("/:id/comments", h.ListWorkflowComments)
+	workflowRoutes.POST("/:id/comments", h.CommentOnWorkflow)
+	workflowRoutes.GET("/:id/attachments", h.ListWorkflowAttachments)
+	workflowRoutes.POST("/:id/attachments", h.AttachToWorkflow)
+	workflowRoutes.POST("/:id/runs", h.StartWorkflowRun)
+	workflowRoutes.GET("/:id/runs", h.ListWorkflowRuns)
+	workflowRoutes.GET("/:id/versions", h.ListWorkflowVersions)
+	workflowRoutes.GET("/:id/versions/:version", h.GetWorkflowVersion)
+	workflowRoutes.GET("/:id/versions/:version/diff", h.DiffWorkflowVersions)
+	workflowRoutes.POST("/:id/versions/:version/rollback", h.RollbackWorkflow)
+
+	runRoutes := api.Group("/workflow-runs", auth.IsUserOrAdmin, h.RequireAccess("workflow_run"))
+	runRoutes.GET("/:run_id", h.GetWorkflowRun)
+	runRoutes.GET("/:run_id/timeline", h.GetWorkflowRunTimeline)
+	runRoutes.POST("/:run_id/cancel", h.CancelWorkflowRun)
+	runRoutes.POST("/:run_id/retry", h.RetryWorkflowRun)
+
+	api.GET("/organizations/:id/onboarding", auth.IsUserOrAdmin, h.GetOnboarding)
+
+	api.GET("/organizations/:id/teams", auth.IsUserOrAdmin, h.ListTeams)
+	api.GET("/organizations/:id/teams/:team_id", auth.IsUserOrAdmin, h.GetTeam)
+	api.GET("/organizations/:id/custom-fields", auth.IsUserOrAdmin, h.ListCustomFields)
+	api.GET("/organizations/:id/tags", auth.IsUserOrAdmin, h.ListTags)
+	api.GET("/organizations/:id/attachment-limits", auth.IsUserOrAdmin, h.GetAttachmentLimits)
+	api.GET("/organizations/:id/users/:user_id/tags", auth.IsUserOrAdmin, h.ListUserTags)
+
+	metrics := api.Group("/organizations/:id/metrics", auth.IsUserOrAdmin)
+	metrics.GET("/active-users", h.ActiveUsersMetric)
+	metrics.GET("/seats", h.SeatUtilizationMetric)
+	metrics.GET("/api-usage", h.APIUsageMetric)
+	metrics.GET("/workflow-throughput", h.WorkflowThroughputMetric)
+
+	api.POST("/batch", h.RequireFeature("batch"), auth.IsUserOrAdmin, h.Batch)
+	api.GET("/search", auth.IsUserOrAdmin, h.Search)
+	api.GET("/graphql", h.RequireFeature("graphql"), auth.IsUserOrAdmin, h.GraphQL)
+	api.POST("/graphql", h.RequireFeature("graphql"), auth.IsUserOrAdmin, h.GraphQL)
+	api.POST("/search/reindex", auth.AuthMiddleware(models.AdminRole), h.ReindexSearch)
+
+	api.GET("/me", auth.IsUserOrAdmin, h.GetMe)
+	api.PUT("/me/password", auth.IsUserOrAdmin, h.ChangePassword)
+	api.GET("/me/email", auth.IsUserOrAdmin, h.GetEmailChange)
+	api.POST("/me/email", auth.IsUserOrAdmin, h.RequestEmailChange)
+	api.DELETE("/me/email", auth.IsUserOrAdmin, h.CancelEmailChange)
+	api.GET("/me/activity", auth.IsUserOrAdmin, h.ListMyActivity)
+	api.GET("/me/auth-events", auth.IsUserOrAdmin, h.ListMyAuthEvents)
+	api.GET("/me/approvals", auth.IsUserOrAdmin, h.ListMyApprovals)
+	api.GET("/me/announcements", auth.IsUserOrAdmin, h.ListMyAnnouncements)
+	api.POST("/me/announcements/read", auth.IsUserOrAdmin, h.MarkAllAnnouncementsRead)
+	api.POST("/me/announcements/:id/read", auth.IsUserOrAdmin, h.MarkAnnouncementRead)
+	api.GET("/events", auth.IsUserOrAdmin, h.StreamEvents)
+
+	announcementRoutes := api.Group("/announcements", auth.AuthMiddleware(models.AdminRole))
+	announcementRoutes.GET("", h.ListAnnouncements)
+	announcementRoutes.POST("", h.CreateAnnouncement)
+	announcementRoutes.GET("/:id", h.GetAnnouncement)
+	announcementRoutes.PUT("/:id", h.UpdateAnnouncement)
+	announcementRoutes.DELETE("/:id", h.DeleteAnnouncement)
+
+	api.POST("/feedback", auth.IsUserOrAdmin, h.SubmitFeedback)
+	feedbackRoutes := api.Group("/feedback", auth.AuthMiddleware(models.AdminRole))
+	feedbackRoutes.GET("", h.ListFeedback)
+	feedbackRoutes.GET("/nps", h.GetNPS)
+	feedbackRoutes.GET("/nps/organizations", h.GetNPSByOrganization)
+
+	api.POST("/tickets", auth.IsUserOrAdmin, h.OpenTicket)
+	api.GET("/tickets/:id", auth.IsUserOrAdmin, h.GetTicket)
+	api.POST("/tickets/:id/messages", auth.IsUserOrAdmin, h.ReplyToTicket)
+	api.GET("/organizations/:id/tickets", auth.IsUserOrAdmin, h.ListOrganizationTickets)
+	ticketRoutes := api.Group("/tickets", auth.AuthMiddleware(models.AdminRole))
+	ticketRoutes.GET("", h.ListTickets)
+	ticketRoutes.PUT("/:id/status", h.UpdateTicketStatus)
+
+	approvalRoutes := api.Group("/workflow-approvals", auth.IsUserOrAdmin)
+	approvalRoutes.POST("/:id/approve", h.ApproveWorkflowApproval)
+	approvalRoutes.POST("/:id/reject", h.RejectWorkflowApproval)
+	approvalRoutes.POST("/:id/delegate", h.DelegateWorkflowApproval)
+
+	api.GET("/auth-events", auth.AuthMiddleware(models.AdminRole), h.ListAuthEvents)
+
+	api.GET("/maintenance", h.GetMaintenance)
+	api.PUT("/maintenance", auth.AuthMiddleware(models.AdminRole), h.UpdateMaintenance)
+
+	settingRoutes := api.Group("/settings", auth.AuthMiddleware(models.AdminRole))
+	settingRoutes.GET("", h.ListSettings)
+	settingRoutes.GET("/:key", h.GetSetting)
+	settingRoutes.PUT("/:key", h.UpdateSetting)
+	settingRoutes.DELETE("/:key", h.ResetSetting)
+	settingRoutes.GET("/:key/changes", h.ListSettingChanges)
+
+	// Organization admins manage their organization, as do platform admins
+	orgAdmin := api.Group("/organizations/:id", auth.IsUserOrAdmin, h.RequireOrganizationAdmin())
+	orgAdmin.GET("/audit-logs", h.ListAuditLogs)
+	orgAdmin.GET("/audit-logs/export", h.ExportAuditLogs)
+	orgAdmin.GET("/audit-logs/verify", h.VerifyAuditLogs)
+	orgAdmin.GET("/auth-events", h.ListOrganizationAuthEvents)
+	orgAdmin.GET("/siem", h.GetSIEMIntegration)
+	orgAdmin.PUT("/siem", h.PutSIEMIntegration)
+	orgAdmin.DELETE("/siem", h.DeleteSIEMIntegration)
+	orgAdmin.PUT("/retention", h.UpdateRetention)
+	orgAdmin.GET("/retention-rules", h.ListRetentionRules)
+	orgAdmin.PUT("/retention-rules/:resource", h.PutRetentionRule)
+	orgAdmin.DELETE("/retention-rules/:resource", h.DeleteRetentionRule)
+	orgAdmin.GET("/retention-removals", h.ListRetentionRemovals)
+	orgAdmin.GET("/settings", h.GetOrganizationSettings)
+	orgAdmin.PATCH("/settings", h.UpdateOrganizationSettings)
+	orgAdmin.PATCH("/branding", h.UpdateOrganizationBranding)
+	orgAdmin.PUT("/branding/:asset", h.PutBrandingAsset)
+	orgAdmin.DELETE("/branding/:asset", h.DeleteBrandingAsset)
+	orgAdmin.GET("/activity", h.ListOrganizationActivity)
+	orgAdmin.POST("/user-imports", h.ImportUsers)
+	orgAdmin.GET("/user-imports", h.ListUserImports)
+	orgAdmin.GET("/user-imports/:import_id", h.GetUserImport)
+	orgAdmin.POST("/invitations", h.InviteUsers)
+	orgAdmin.GET("/users", h.ListOrganizationUsers)
+	orgAdmin.POST("/teams", h.CreateTeam)
+	orgAdmin.PATCH("/teams/:team_id", h.UpdateTeam)
+	orgAdmin.DELETE("/teams/:team_id", h.DeleteTeam)
+	orgAdmin.PUT("/teams/:team_id/members/:user_id", h.PutTeamMember)
+	orgAdmin.DELETE("/teams/:team_id/members/:user_id", h.DeleteTeamMember)
+	orgAdmin.GET("/attachments", h.ListOrganizationAttachments)
+	orgAdmin.POST("/custom-fields", h.CreateCustomField)
+	orgAdmin.PATCH("/custom-fields/:field_id", h.UpdateCustomField)
+	orgAdmin.DELETE("/custom-fields/:field_id", h.DeleteCustomField)
+	orgAdmin.PUT("/custom-field-values", h.SetOrganizationCustomFields)
+	orgAdmin.PUT("/users/:user_id/custom-field-values", h.SetUserCustomFields)
+	orgAdmin.POST("/tags", h.CreateTag)
+	orgAdmin.PATCH("/tags/:tag_id", h.UpdateTag)
+	orgAdmin.DELETE("/tags/:tag_id", h.DeleteTag)
+	orgAdmin.PUT("/users/:user_id/tags/:tag_id", h.TagUser)
+	orgAdmin.DELETE("/users/:user_id/tags/:tag_id", h.UntagUser)
+	orgAdmin.POST("/exports", h.ExportOrganization)
+	orgAdmin.GET("/exports", h.ListOrganizationExports)
+	orgAdmin.GET("/exports/:export_id", h.GetOrganizationExport)
+}
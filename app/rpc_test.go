@@ -0,0 +1,112 @@
+// Package app/rpc_test.go
+package app_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/4cecoder/saas/rpc"
+	"github.com/4cecoder/saas/testutil/factories"
+	"github.com/4cecoder/saas/testutil/integration"
+)
+
+// internalClient serves the app's internal service in memory, without the mutual
+// TLS ListenAndServe requires
+func internalClient(t *testing.T, h *integration.Harness) rpc.InternalClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	rpc.RegisterInternalServer(srv, h.App.GRPC)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return rpc.NewInternalClient(conn)
+}
+
+func TestInternalRPC(t *testing.T) {
+	h := integration.New(t)
+	ctx := context.Background()
+	internal := internalClient(t, h)
+	org := factories.CreateOrganization(t, h.DB)
+	user := factories.CreateMember(t, h.DB, org)
+
+	t.Run("lookup user", func(t *testing.T) {
+		got, err := internal.LookupUser(ctx, &rpc.LookupUserRequest{Email: user.Email})
+		if err != nil {
+			t.Fatalf("LookupUser: %v", err)
+		}
+		if got.Id != uint64(user.ID) || len(got.OrganizationIds) != 1 || got.OrganizationIds[0] != uint64(org.ID) {
+			t.Errorf("user = %v, want %d in organization %d", got, user.ID, org.ID)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			name string
+			call func() error
+			want codes.Code
+		}{
+			{"no key", func() error {
+				_, err := internal.LookupUser(ctx, &rpc.LookupUserRequest{})
+				return err
+			}, codes.InvalidArgument},
+			{"unknown user", func() error {
+				_, err := internal.LookupUser(ctx, &rpc.LookupUserRequest{Id: 1 << 40})
+				return err
+			}, codes.NotFound},
+			{"unknown organization", func() error {
+				_, err := internal.CheckEntitlement(ctx, &rpc.CheckEntitlementRequest{OrganizationId: 1 << 40})
+				return err
+			}, codes.NotFound},
+		} {
+			if got := grpcstatus.Code(tc.call()); got != tc.want {
+				t.Errorf("%s: code = %s, want %s", tc.name, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("entitlement without subscription", func(t *testing.T) {
+		got, err := internal.CheckEntitlement(ctx, &rpc.CheckEntitlementRequest{OrganizationId: uint64(org.ID), UserId: uint64(user.ID)})
+		if err != nil {
+			t.Fatalf("CheckEntitlement: %v", err)
+		}
+		if got.Entitled || got.Reason == "" {
+			t.Errorf("entitlement = %v, want a denial with a reason", got)
+		}
+	})
+
+	t.Run("introspect token", func(t *testing.T) {
+		got, err := internal.IntrospectToken(ctx, &rpc.IntrospectTokenRequest{Token: tokenOf(t, user)})
+		if err != nil {
+			t.Fatalf("IntrospectToken: %v", err)
+		}
+		if !got.Active || got.UserId != uint64(user.ID) {
+			t.Errorf("token = %v, want active for %d", got, user.ID)
+		}
+
+		got, err = internal.IntrospectToken(ctx, &rpc.IntrospectTokenRequest{Token: "not-a-token"})
+		if err != nil {
+			t.Fatalf("IntrospectToken: %v", err)
+		}
+		if got.Active {
+			t.Errorf("malformed token reported active")
+		}
+	})
+}
@@ -0,0 +1,96 @@
+// Package app/settings.go
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/4cecoder/saas/middleware"
+	"github.com/4cecoder/saas/settings"
+)
+
+// featureNames are the optional endpoints toggled by features.<name> settings
+var featureNames = []string{"batch", "docs", "graphql"}
+
+// defineSettings declares the runtime settings, defaulting to the configuration,
+// and applies their changes
+func (a *App) defineSettings() {
+	limits := a.Config.RateLimits
+	for key, limit := range map[string]struct {
+		value       middleware.Limit
+		description string
+	}{
+		"ratelimit.anonymous":     {limits.Anonymous, "Requests each client IP makes without credentials, such as 60/m, or 0 for no limit"},
+		"ratelimit.authenticated": {limits.Authenticated, "Requests each signed-in user makes, such as 600/m, or 0 for no limit"},
+		"ratelimit.api_key":       {limits.APIKey, "Requests each API key makes, such as 1200/m, or 0 for no limit"},
+		"ratelimit.organization":  {limits.Organization, "Requests the API keys of an organization make together, such as 3000/m, or 0 for no limit"},
+	} {
+		a.Settings.Define(settings.Definition{
+			Key:         key,
+			Description: limit.description,
+			Default:     limit.value.String(),
+			Validate: func(value string) error {
+				_, err := middleware.ParseLimit(value)
+				return err
+			},
+		})
+	}
+
+	for _, name := range featureNames {
+		a.Settings.Define(settings.Definition{
+			Key:         "features." + name,
+			Description: "Serves the " + name + " endpoints when true",
+			Default:     strconv.FormatBool(a.Config.Features.Enabled(name)),
+			Validate: func(value string) error {
+				if _, err := strconv.ParseBool(value); err != nil {
+					return fmt.Errorf("%q is not true or false", value)
+				}
+				return nil
+			},
+		})
+	}
+
+	a.Settings.Define(settings.Definition{
+		Key:         "maintenance.mode",
+		Description: "off, read_only to reject requests changing data, or full to reject every request but those of admins and health checks",
+		Default:     a.Config.Maintenance.Mode,
+		Validate: func(value string) error {
+			if !slices.Contains(middleware.MaintenanceModes, value) {
+				return fmt.Errorf("%q is not off, read_only or full", value)
+			}
+			return nil
+		},
+	})
+	a.Settings.Define(settings.Definition{
+		Key:         "maintenance.message",
+		Description: "Message of the responses rejected during maintenance; empty for the default",
+		Default:     a.Config.Maintenance.Message,
+	})
+
+	a.Settings.OnChange(func(key, _ string) {
+		if strings.HasPrefix(key, "ratelimit.") {
+			a.RateLimiter.SetLimits(a.rateLimits())
+		}
+	})
+}
+
+// maintenance returns the maintenance mode and message of the settings
+func (a *App) maintenance() (string, string) {
+	return a.Settings.Get("maintenance.mode"), a.Settings.Get("maintenance.message")
+}
+
+// rateLimits returns the rate limits of the settings
+func (a *App) rateLimits() middleware.RateLimits {
+	limit := func(key string) middleware.Limit {
+		l, _ := middleware.ParseLimit(a.Settings.Get(key))
+		return l
+	}
+	return middleware.RateLimits{
+		Anonymous:     limit("ratelimit.anonymous"),
+		Authenticated: limit("ratelimit.authenticated"),
+		APIKey:        limit("ratelimit.api_key"),
+		Organization:  limit("ratelimit.organization"),
+	}
+}
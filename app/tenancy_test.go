@@ -0,0 +1,305 @@
+// Package app/tenancy_test.go
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/client"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/testutil/factories"
+	"github.com/4cecoder/saas/testutil/integration"
+)
+
+// status returns the HTTP status of a client error, or 200 for no error
+func status(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		return http.StatusOK
+	}
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return apiErr.StatusCode
+}
+
+// createOrgAdmin inserts a member of the organization whose seat has the admin role
+func createOrgAdmin(t *testing.T, h *integration.Harness, org *models.Organization) *models.User {
+	t.Helper()
+	user := factories.CreateUser(t, h.DB)
+	makeOrgAdmin(t, h, org, user)
+	return user
+}
+
+// makeOrgAdmin adds the user to the organization with a seat having the admin role
+func makeOrgAdmin(t *testing.T, h *integration.Harness, org *models.Organization, user *models.User) {
+	t.Helper()
+	if err := h.DB.Model(org).Association("Users").Append(user); err != nil {
+		t.Fatalf("add %s to organization %d: %v", user.Email, org.ID, err)
+	}
+	factories.CreateSeat(t, h.DB, org.ID, user.ID, func(s *models.Seat) {
+		s.Roles = []models.Role{factories.Role(models.AdminRole)}
+	})
+}
+
+func TestOrganizationAdminRoutes(t *testing.T) {
+	h := integration.New(t)
+	ctx := context.Background()
+	org := factories.CreateOrganization(t, h.DB)
+	other := factories.CreateOrganization(t, h.DB)
+	orgAdmin := createOrgAdmin(t, h, org)
+	member := factories.CreateMember(t, h.DB, org)
+	_, admin := h.Admin(t)
+
+	tests := []struct {
+		name   string
+		client *client.Client
+		orgID  uint
+		want   int
+	}{
+		{"organization admin", h.As(t, orgAdmin), org.ID, http.StatusOK},
+		{"organization admin of another organization", h.As(t, orgAdmin), other.ID, http.StatusForbidden},
+		{"member", h.As(t, member), org.ID, http.StatusForbidden},
+		{"platform admin", admin, other.ID, http.StatusOK},
+		{"anonymous", h.Client(), org.ID, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.client.GetOrganizationSettings(ctx, tt.orgID)
+			if got := status(t, err); got != tt.want {
+				t.Errorf("GET settings = %d, want %d (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMetricsRequireMembership(t *testing.T) {
+	h := integration.New(t)
+	ctx := context.Background()
+	org := factories.CreateOrganization(t, h.DB)
+	other := factories.CreateOrganization(t, h.DB)
+	member := h.As(t, factories.CreateMember(t, h.DB, org))
+	outsider := h.As(t, factories.CreateMember(t, h.DB, other))
+
+	metrics := map[string]func(c *client.Client) error{
+		"active users": func(c *client.Client) error {
+			_, err := c.ActiveUsersMetric(ctx, org.ID, client.MetricOptions{})
+			return err
+		},
+		"api usage": func(c *client.Client) error {
+			_, err := c.APIUsageMetric(ctx, org.ID, client.MetricOptions{})
+			return err
+		},
+		"workflow throughput": func(c *client.Client) error {
+			_, err := c.WorkflowThroughputMetric(ctx, org.ID, client.MetricOptions{})
+			return err
+		},
+		"seats": func(c *client.Client) error {
+			_, err := c.SeatUtilizationMetric(ctx, org.ID)
+			return err
+		},
+	}
+	for name, metric := range metrics {
+		if got := status(t, metric(member)); got != http.StatusOK {
+			t.Errorf("%s as a member = %d, want 200", name, got)
+		}
+		if got := status(t, metric(outsider)); got != http.StatusForbidden {
+			t.Errorf("%s as an outsider = %d, want 403", name, got)
+		}
+	}
+}
+
+func TestOrganizationIncludesRequireMembership(t *testing.T) {
+	h := integration.New(t)
+	ctx := context.Background()
+	org := factories.CreateOrganization(t, h.DB)
+	other := factories.CreateOrganization(t, h.DB)
+	member := h.As(t, factories.CreateMember(t, h.DB, org))
+	outsider := h.As(t, factories.CreateMember(t, h.DB, other))
+
+	tests := []struct {
+		name    string
+		client  *client.Client
+		include []string
+		want    int
+	}{
+		{"anonymous without includes", h.Client(), nil, http.StatusOK},
+		{"anonymous", h.Client(), []string{"users"}, http.StatusUnauthorized},
+		{"outsider", outsider, []string{"users", "subscriptions.transactions"}, http.StatusForbidden},
+		{"member", member, []string{"users", "subscriptions.transactions"}, http.StatusOK},
+		{"api keys", member, []string{"api_keys"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.client.GetOrganization(ctx, org.ID, tt.include...)
+			if got := status(t, err); got != tt.want {
+				t.Errorf("GET /organizations/%d = %d, want %d (%v)", org.ID, got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSIEMEndpointMustBePublic(t *testing.T) {
+	h := integration.New(t)
+	org := factories.CreateOrganization(t, h.DB)
+	token := tokenOf(t, createOrgAdmin(t, h, org))
+	path := fmt.Sprintf("/organizations/%d/siem", org.ID)
+
+	tests := []struct {
+		name     string
+		typ      string
+		endpoint string
+		want     int
+	}{
+		{"metadata service", "http", "http://169.254.169.254/latest/meta-data", http.StatusBadRequest},
+		{"loopback", "http", "http://localhost:8080/collect", http.StatusBadRequest},
+		{"private syslog", "syslog", "udp://10.0.0.5:514", http.StatusBadRequest},
+		{"public", "http", "https://93.184.215.14/collect", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]interface{}{"type": tt.typ, "endpoint": tt.endpoint, "enabled": true}
+			if got := send(t, h, token, http.MethodPut, path, body, nil); got != tt.want {
+				t.Errorf("PUT %s with %s = %d, want %d", path, tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditLogRetentionMinimum(t *testing.T) {
+	h := integration.New(t)
+	org := factories.CreateOrganization(t, h.DB)
+	token := tokenOf(t, createOrgAdmin(t, h, org))
+	retention := fmt.Sprintf("/organizations/%d/retention", org.ID)
+	rule := fmt.Sprintf("/organizations/%d/retention-rules/audit_logs", org.ID)
+
+	tests := []struct {
+		name string
+		path string
+		body map[string]interface{}
+		want int
+	}{
+		{"short audit retention", retention, map[string]interface{}{"audit_log_retention_days": 1}, http.StatusUnprocessableEntity},
+		{"short activity retention", retention, map[string]interface{}{"activity_log_retention_days": 1}, http.StatusOK},
+		{"audit logs kept forever", retention, map[string]interface{}{"audit_log_retention_days": 0}, http.StatusOK},
+		{"audit logs kept a year", retention, map[string]interface{}{"audit_log_retention_days": 365}, http.StatusOK},
+		{"short audit purge rule", rule, map[string]interface{}{"days": 1, "action": "purge"}, http.StatusUnprocessableEntity},
+		{"audit archive rule", rule, map[string]interface{}{"days": 90}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := send(t, h, token, http.MethodPut, tt.path, tt.body, nil); got != tt.want {
+				t.Errorf("PUT %s %v = %d, want %d", tt.path, tt.body, got, tt.want)
+			}
+		})
+	}
+
+	var stored models.Organization
+	if err := h.DB.First(&stored, org.ID).Error; err != nil {
+		t.Fatalf("load organization: %v", err)
+	}
+	if stored.Settings.AuditLogRetentionDays != 365 || stored.Settings.ActivityLogRetentionDays != 1 {
+		t.Errorf("retention = %d audit, %d activity days, want 365 and 1",
+			stored.Settings.AuditLogRetentionDays, stored.Settings.ActivityLogRetentionDays)
+	}
+}
+
+func TestOrganizationChangesNeedOrganizationAdmin(t *testing.T) {
+	h := integration.New(t)
+	org := factories.CreateOrganization(t, h.DB)
+	orgAdmin := tokenOf(t, createOrgAdmin(t, h, org))
+	member := tokenOf(t, factories.CreateMember(t, h.DB, org))
+	path := fmt.Sprintf("/organizations/%d", org.ID)
+	org.Settings.RequireTwoFactor = true
+	org.Settings.AllowedEmailDomains = []string{"example.com"}
+	if err := h.DB.Save(org).Error; err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+
+	relax := map[string]interface{}{
+		"name":     "Renamed",
+		"settings": map[string]interface{}{"require_two_factor": false, "allowed_email_domains": []string{"example.com", "attacker.test"}},
+	}
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		want   int
+	}{
+		{"anonymous update", "", http.MethodPut, http.StatusUnauthorized},
+		{"member update", member, http.MethodPut, http.StatusForbidden},
+		{"anonymous delete", "", http.MethodDelete, http.StatusUnauthorized},
+		{"member delete", member, http.MethodDelete, http.StatusForbidden},
+		{"organization admin update", orgAdmin, http.MethodPut, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := send(t, h, tt.token, tt.method, path, relax, nil); got != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, path, got, tt.want)
+			}
+		})
+	}
+
+	var got models.Organization
+	h.DB.First(&got, org.ID)
+	if got.Name != "Renamed" {
+		t.Errorf("name = %q, want Renamed", got.Name)
+	}
+	if !got.Settings.RequireTwoFactor || len(got.Settings.AllowedEmailDomains) != 1 {
+		t.Errorf("PUT %s changed the security settings to %+v", path, got.Settings)
+	}
+
+	var settings models.OrganizationSettings
+	body := map[string]interface{}{"require_two_factor": false}
+	if code := send(t, h, orgAdmin, http.MethodPatch, path+"/settings", body, &settings); code != http.StatusOK {
+		t.Fatalf("PATCH %s/settings = %d, want 200", path, code)
+	}
+	if settings.RequireTwoFactor {
+		t.Error("PATCH settings didn't turn off the two-factor requirement")
+	}
+}
+
+func TestOrganizationScopedTokens(t *testing.T) {
+	h := integration.New(t)
+	orgA := factories.CreateOrganization(t, h.DB)
+	orgB := factories.CreateOrganization(t, h.DB)
+	user := createOrgAdmin(t, h, orgA)
+	makeOrgAdmin(t, h, orgB, user)
+	report, err := h.As(t, user).CreateReport(context.Background(), newReport(orgB.ID))
+	if err != nil {
+		t.Fatalf("create report: %v", err)
+	}
+
+	scoped, err := auth.IssueToken(user, auth.Grant{Role: models.UserRole, OrganizationID: orgA.ID})
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	unscoped := tokenOf(t, user)
+
+	tests := []struct {
+		name  string
+		token string
+		path  string
+		want  int
+	}{
+		{"membership of the token's organization", scoped, fmt.Sprintf("/organizations/%d/onboarding", orgA.ID), http.StatusOK},
+		{"admin route of the token's organization", scoped, fmt.Sprintf("/organizations/%d/settings", orgA.ID), http.StatusOK},
+		{"membership of another organization", scoped, fmt.Sprintf("/organizations/%d/onboarding", orgB.ID), http.StatusForbidden},
+		{"admin route of another organization", scoped, fmt.Sprintf("/organizations/%d/settings", orgB.ID), http.StatusForbidden},
+		{"resource of another organization", scoped, fmt.Sprintf("/reports/%d", report.ID), http.StatusNotFound},
+		{"unscoped token", unscoped, fmt.Sprintf("/organizations/%d/settings", orgB.ID), http.StatusOK},
+		{"resource with an unscoped token", unscoped, fmt.Sprintf("/reports/%d", report.ID), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := send(t, h, tt.token, http.MethodGet, tt.path, nil, nil); got != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, got, tt.want)
+			}
+		})
+	}
+}
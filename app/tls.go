@@ -0,0 +1,106 @@
+// Package app/tls.go
+package app
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsEnabled reports whether the API serves HTTPS itself
+func (a *App) tlsEnabled() bool {
+	return a.Config.TLS.CertFile != "" || len(a.Config.TLS.AutocertDomains) > 0
+}
+
+// tlsConfig returns the TLS settings of the API server, and the handler of
+// plain HTTP requests wrapping next: ACME challenges with autocert, next
+// otherwise. HTTP/2 is negotiated by the server.
+func (a *App) tlsConfig(next http.Handler) (*tls.Config, http.Handler, error) {
+	cfg := a.Config.TLS
+	if len(cfg.AutocertDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		tlsCfg := m.TLSConfig()
+		tlsCfg.MinVersion = tls.VersionTLS12
+		return tlsCfg, m.HTTPHandler(next), nil
+	}
+
+	certs := &certFiles{certFile: cfg.CertFile, keyFile: cfg.KeyFile}
+	if err := certs.load(); err != nil {
+		return nil, nil, err
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certs.get}, next, nil
+}
+
+// redirectHTTPS redirects plain HTTP requests to the same URL over HTTPS, on
+// the port of addr
+func redirectHTTPS(addr string) http.Handler {
+	_, port, _ := net.SplitHostPort(addr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// certReloadInterval is how often the certificate files are checked for changes
+const certReloadInterval = time.Minute
+
+// certFiles serves the certificate of a pair of files, reloading it when the
+// files change, so renewals apply without a restart
+type certFiles struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// load reads the certificate from the files
+func (c *certFiles) load() error {
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	c.cert, c.modTime, c.checked = &cert, info.ModTime(), time.Now()
+	return nil
+}
+
+// get implements tls.Config.GetCertificate, reloading the certificate when
+// its file changed since the last check. A broken renewal keeps the current one.
+func (c *certFiles) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.checked) >= certReloadInterval {
+		c.checked = time.Now()
+		if info, err := os.Stat(c.certFile); err == nil && !info.ModTime().Equal(c.modTime) {
+			if err := c.load(); err != nil {
+				slog.Error("tls: failed to reload the certificate, keeping the current one", "error", err)
+			} else {
+				slog.Info("tls: reloaded the certificate", "file", c.certFile)
+			}
+		}
+	}
+	return c.cert, nil
+}
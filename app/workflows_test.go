@@ -0,0 +1,80 @@
+// Package app/workflows_test.go
+package app_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/4cecoder/saas/client"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/testutil/factories"
+	"github.com/4cecoder/saas/testutil/integration"
+)
+
+func TestWorkflowsAreScopedToOrganizations(t *testing.T) {
+	h := integration.New(t)
+	ctx := context.Background()
+	org := factories.CreateOrganization(t, h.DB)
+	other := factories.CreateOrganization(t, h.DB)
+	member := h.As(t, factories.CreateMember(t, h.DB, org))
+	outsider := h.As(t, factories.CreateMember(t, h.DB, other))
+
+	wf, err := member.CreateWorkflow(ctx, dto.CreateWorkflowRequest{Name: "Onboarding", OrganizationID: org.ID, Enabled: true})
+	if err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	run, err := member.StartWorkflowRun(ctx, wf.ID, nil)
+	if err != nil {
+		t.Fatalf("start run: %v", err)
+	}
+
+	t.Run("create in another organization", func(t *testing.T) {
+		_, err := outsider.CreateWorkflow(ctx, dto.CreateWorkflowRequest{Name: "Intruder", OrganizationID: org.ID})
+		if got := status(t, err); got != http.StatusForbidden {
+			t.Errorf("POST /workflows = %d, want 403", got)
+		}
+	})
+
+	t.Run("routes of another organization's workflow", func(t *testing.T) {
+		calls := map[string]func() error{
+			"get": func() error { _, err := outsider.GetWorkflow(ctx, wf.ID); return err },
+			"update": func() error {
+				_, err := outsider.UpdateWorkflow(ctx, wf.ID, dto.UpdateWorkflowRequest{Name: "Taken"})
+				return err
+			},
+			"start run": func() error { _, err := outsider.StartWorkflowRun(ctx, wf.ID, nil); return err },
+			"runs":      func() error { _, err := outsider.ListWorkflowRuns(ctx, wf.ID, client.ListOptions{}); return err },
+			"get run":   func() error { _, err := outsider.GetWorkflowRun(ctx, run.ID); return err },
+			"timeline":  func() error { _, err := outsider.GetWorkflowRunTimeline(ctx, run.ID); return err },
+		}
+		for name, call := range calls {
+			if got := status(t, call()); got != http.StatusNotFound {
+				t.Errorf("%s = %d, want 404", name, got)
+			}
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		page, err := outsider.ListWorkflows(ctx, client.ListOptions{})
+		if err != nil {
+			t.Fatalf("list workflows: %v", err)
+		}
+		for _, w := range page.Data {
+			if w.ID == wf.ID {
+				t.Errorf("listed the workflow of another organization")
+			}
+		}
+	})
+
+	t.Run("update keeps the record and organization", func(t *testing.T) {
+		updated, err := member.UpdateWorkflow(ctx, wf.ID, dto.UpdateWorkflowRequest{Name: "Renamed", Enabled: true})
+		if err != nil {
+			t.Fatalf("update workflow: %v", err)
+		}
+		if updated.ID != wf.ID || updated.OrganizationID != org.ID || updated.Name != "Renamed" {
+			t.Errorf("updated workflow = {ID: %d, OrganizationID: %d, Name: %q}, want {%d, %d, Renamed}",
+				updated.ID, updated.OrganizationID, updated.Name, wf.ID, org.ID)
+		}
+	})
+}
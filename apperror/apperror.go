@@ -0,0 +1,182 @@
+// Package apperror/apperror.go
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// Code is a stable, machine-readable identifier of an error kind. Clients should
+// branch on the code rather than on the message, which may change.
+type Code string
+
+const (
+	CodeBadRequest         Code = "bad_request"
+	CodeValidation         Code = "validation_failed"
+	CodeUnauthorized       Code = "unauthorized"
+	CodeForbidden          Code = "forbidden"
+	CodeNotFound           Code = "not_found"
+	CodeConflict           Code = "conflict"
+	CodeGone               Code = "gone"
+	CodeTooLarge           Code = "too_large"
+	CodePreconditionFailed Code = "precondition_failed"
+	CodeUnprocessable      Code = "unprocessable"
+	CodeQuotaExceeded      Code = "quota_exceeded"
+	CodeRateLimited        Code = "rate_limited"
+	CodeUnavailable        Code = "unavailable"
+	CodeTimeout            Code = "timeout"
+	CodeInternal           Code = "internal"
+)
+
+// CodePasswordChangeRequired rejects the requests of users who must change
+// their password first, with 403
+const CodePasswordChangeRequired Code = "password_change_required"
+
+// CodeAccountSuspended rejects the sign-ins of suspended users, with 403
+const CodeAccountSuspended Code = "account_suspended"
+
+// CodeWrongRegion rejects the requests for organizations whose data is kept in
+// another region, with 421 and the URL of the deployment serving it
+const CodeWrongRegion Code = "wrong_region"
+
+// Error is an error that is reported to API clients with an HTTP status and code
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	// Fields are extra members of the response body, such as the invalid fields of a request
+	Fields map[string]interface{}
+	// Err is the underlying cause; it is logged but never sent to clients
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// With returns a copy of the error with an extra member in the response body
+func (e *Error) With(key string, value interface{}) *Error {
+	out := *e
+	out.Fields = make(map[string]interface{}, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		out.Fields[k] = v
+	}
+	out.Fields[key] = value
+	return &out
+}
+
+// Body returns the JSON response body of the error
+func (e *Error) Body() map[string]interface{} {
+	body := map[string]interface{}{"error": e.Message, "code": e.Code}
+	for k, v := range e.Fields {
+		body[k] = v
+	}
+	return body
+}
+
+// New creates an error with the given status, code, and message
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest reports a malformed request
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Validation reports request fields that failed validation
+func Validation(fields interface{}) *Error {
+	return New(http.StatusUnprocessableEntity, CodeValidation, "Validation failed").With("fields", fields)
+}
+
+// Unauthorized reports a missing or invalid credential
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden reports an authenticated caller lacking access
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound reports a missing resource
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict reports a request that conflicts with the resource's current state
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// Gone reports a resource or endpoint that no longer exists
+func Gone(message string) *Error {
+	return New(http.StatusGone, CodeGone, message)
+}
+
+// TooLarge reports a request body over the size limit
+func TooLarge(message string) *Error {
+	return New(http.StatusRequestEntityTooLarge, CodeTooLarge, message)
+}
+
+// PreconditionFailed reports a failed If-Match or similar precondition
+func PreconditionFailed(message string) *Error {
+	return New(http.StatusPreconditionFailed, CodePreconditionFailed, message)
+}
+
+// Unprocessable reports a well-formed request that can't be carried out
+func Unprocessable(message string) *Error {
+	return New(http.StatusUnprocessableEntity, CodeUnprocessable, message)
+}
+
+// QuotaExceeded reports that a plan or usage limit was reached
+func QuotaExceeded(message string) *Error {
+	return New(http.StatusTooManyRequests, CodeQuotaExceeded, message)
+}
+
+// RateLimited reports a client sending requests faster than it's allowed to
+func RateLimited(message string) *Error {
+	return New(http.StatusTooManyRequests, CodeRateLimited, message)
+}
+
+// Unavailable reports a dependency or feature that is currently unavailable
+func Unavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeUnavailable, message)
+}
+
+// Timeout reports a request that ran out of time
+func Timeout(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeTimeout, message)
+}
+
+// Internal wraps an unexpected error, hiding its details from clients
+func Internal(err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "Internal server error", Err: err}
+}
+
+// From converts any error into an *Error. Missing records become NotFound,
+// bodies over the size limit TooLarge, and unknown errors Internal.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFound("Not found")
+	}
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return TooLarge(fmt.Sprintf("Request body is larger than %d bytes", maxBytes.Limit))
+	}
+	return Internal(err)
+}
@@ -0,0 +1,401 @@
+// Package attachments/attachments.go
+//
+// Package attachments stores the files members of an organization attach to
+// its workflows, reports and subscriptions, within the limits of its plan,
+// quarantines those the malware scan flags, and signs the time-limited URLs
+// they're downloaded from.
+package attachments
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/scanner"
+	"github.com/4cecoder/saas/storage"
+)
+
+// DownloadPath is the route of the API downloading attachments, followed by
+// the attachment ID and /download
+const DownloadPath = "/v1/attachments"
+
+// Defaults of the Config
+const (
+	DefaultMaxSize = 10 << 20
+	DefaultURLTTL  = 15 * time.Minute
+)
+
+// DefaultTypes are the content types organizations may attach when their plan
+// sets none
+var DefaultTypes = []string{
+	"image/*",
+	"text/plain",
+	"text/csv",
+	"application/pdf",
+	"application/zip",
+	"application/json",
+	"application/msword",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.ms-excel",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.ms-powerpoint",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// Errors of uploads and downloads
+var (
+	ErrUnsupportedType  = errors.New("unsupported content type")
+	ErrTooLarge         = errors.New("attachment too large")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+	ErrScanFailed       = errors.New("malware scan failed")
+	ErrQuarantined      = errors.New("attachment quarantined")
+	ErrNotQuarantined   = errors.New("attachment not quarantined")
+)
+
+// Config holds the settings of attachments; zero values leave the defaults
+type Config struct {
+	// MaxSize caps the files of organizations whose plan sets no limit, in bytes
+	MaxSize int64
+	// URLTTL is how long signed download URLs are valid
+	URLTTL time.Duration
+}
+
+// Limits are what an organization may attach
+type Limits struct {
+	MaxSize int64    `json:"max_size"`
+	Types   []string `json:"types"`
+}
+
+// Allows reports whether the limits admit files of contentType, matching
+// types such as image/* by their prefix
+func (l Limits) Allows(contentType string) bool {
+	for _, t := range l.Types {
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(contentType, prefix+"/") {
+				return true
+			}
+		} else if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// Store records attachments and keeps their content in the object storage
+type Store struct {
+	DB      *gorm.DB
+	Storage storage.Storage
+	// Scanner checks uploads for malware; nil leaves them unscanned
+	Scanner scanner.Scanner
+	// Mailer tells the admins of organizations about quarantined files
+	Mailer mailer.Mailer
+	Config Config
+	// PublicURL is the URL of the API, which the signed URLs start with
+	PublicURL string
+	// key signs the download URLs
+	key []byte
+}
+
+// New creates the attachment store, scanning uploads with scan and signing the
+// download URLs of the API at publicURL with secret
+func New(db *gorm.DB, store storage.Storage, scan scanner.Scanner, mail mailer.Mailer, publicURL, secret string, cfg Config) *Store {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultMaxSize
+	}
+	if cfg.URLTTL <= 0 {
+		cfg.URLTTL = DefaultURLTTL
+	}
+	return &Store{
+		DB:        db,
+		Storage:   store,
+		Scanner:   scan,
+		Mailer:    mail,
+		Config:    cfg,
+		PublicURL: strings.TrimSuffix(publicURL, "/"),
+		key:       []byte(secret),
+	}
+}
+
+// Limits returns what an organization may attach under the plan of its latest
+// active subscription, falling back to the defaults for what it doesn't set
+func (s *Store) Limits(ctx context.Context, orgID uint) (Limits, error) {
+	limits := Limits{MaxSize: s.Config.MaxSize, Types: DefaultTypes}
+
+	var sub models.Subscription
+	err := s.DB.WithContext(ctx).Preload("SubscriptionPlan").
+		Where("organization_id = ? AND status IN ?", orgID, []models.SubscriptionStatus{models.SubscriptionStatusActive, models.SubscriptionStatusTrialing}).
+		Order("start_date DESC, id DESC").
+		First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return limits, nil
+	}
+	if err != nil {
+		return limits, fmt.Errorf("load subscription: %w", err)
+	}
+
+	if sub.SubscriptionPlan.MaxAttachmentSize > 0 {
+		limits.MaxSize = sub.SubscriptionPlan.MaxAttachmentSize
+	}
+	if len(sub.SubscriptionPlan.AttachmentTypes) > 0 {
+		limits.Types = sub.SubscriptionPlan.AttachmentTypes
+	}
+	return limits, nil
+}
+
+// Upload stores the content of an attachment read from r, scans it and records
+// it, signing its URL. Its size and content type are checked against the
+// limits of its organization first; a missing or generic content type is
+// sniffed. Files the scan flags are recorded quarantined, and the admins of the
+// organization are emailed; ErrScanFailed is returned when the scan can't run.
+func (s *Store) Upload(ctx context.Context, a *models.Attachment, r io.Reader) error {
+	limits, err := s.Limits(ctx, a.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if a.Size > limits.MaxSize {
+		return ErrTooLarge
+	}
+
+	br := bufio.NewReaderSize(r, 512)
+	mediaType, _, _ := mime.ParseMediaType(a.ContentType)
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		head, _ := br.Peek(512)
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(head))
+	}
+	a.ContentType = mediaType
+	if !limits.Allows(mediaType) {
+		return ErrUnsupportedType
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	a.StorageKey = fmt.Sprintf("attachments/%d/%s", a.OrganizationID, hex.EncodeToString(suffix))
+
+	// The size the client declares isn't trusted, so reading stops past the limit
+	counter := &countingReader{r: io.LimitReader(br, limits.MaxSize+1)}
+	if err := s.Storage.Put(ctx, a.StorageKey, counter); err != nil {
+		return fmt.Errorf("store attachment: %w", err)
+	}
+	if counter.n > limits.MaxSize {
+		s.remove(ctx, a.StorageKey)
+		return ErrTooLarge
+	}
+	a.Size = counter.n
+
+	if err := s.scan(ctx, a); err != nil {
+		s.remove(ctx, a.StorageKey)
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Create(a).Error; err != nil {
+		s.remove(ctx, a.StorageKey)
+		return err
+	}
+	if a.ScanStatus == models.AttachmentQuarantined {
+		s.notify(ctx, a)
+	}
+	s.Sign(a)
+	return nil
+}
+
+// Clear releases a quarantined attachment on behalf of an admin, making it
+// downloadable again
+func (s *Store) Clear(ctx context.Context, a *models.Attachment, adminID uint) error {
+	if a.ScanStatus != models.AttachmentQuarantined {
+		return ErrNotQuarantined
+	}
+	a.ScanStatus, a.ClearedByID = models.AttachmentCleared, &adminID
+	if err := s.DB.WithContext(ctx).Model(a).Select("ScanStatus", "ClearedByID").Updates(a).Error; err != nil {
+		return err
+	}
+	s.Sign(a)
+	return nil
+}
+
+// Delete deletes an attachment with its content
+func (s *Store) Delete(ctx context.Context, a *models.Attachment) error {
+	if err := s.DB.WithContext(ctx).Delete(a).Error; err != nil {
+		return err
+	}
+	s.remove(ctx, a.StorageKey)
+	return nil
+}
+
+// Sign sets the URL downloading an attachment until the URL TTL passes,
+// unless it's quarantined
+func (s *Store) Sign(a *models.Attachment) {
+	if !a.Downloadable() {
+		return
+	}
+	expires := time.Now().Add(s.Config.URLTTL).Truncate(time.Second)
+	a.URL = fmt.Sprintf("%s%s/%d/download?expires=%d&signature=%s",
+		s.PublicURL, DownloadPath, a.ID, expires.Unix(), s.signature(a.ID, expires.Unix()))
+	a.URLExpiresAt = &expires
+}
+
+// Open opens the content of the attachment with id for a signed URL, returning
+// ErrInvalidSignature unless expires and signature come from one that's still
+// valid, and ErrQuarantined while the attachment is quarantined
+func (s *Store) Open(ctx context.Context, id uint, expires, signature string) (*models.Attachment, io.ReadCloser, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return nil, nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(id, unix))) {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	var a models.Attachment
+	if err := s.DB.WithContext(ctx).First(&a, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, storage.ErrNotFound
+		}
+		return nil, nil, err
+	}
+	if !a.Downloadable() {
+		return nil, nil, ErrQuarantined
+	}
+	file, err := s.Storage.Get(ctx, a.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &a, file, nil
+}
+
+// scan runs the malware scan on the stored content of an attachment, setting
+// its scan status
+func (s *Store) scan(ctx context.Context, a *models.Attachment) error {
+	if s.Scanner == nil {
+		a.ScanStatus = models.AttachmentUnscanned
+		return nil
+	}
+
+	file, err := s.Storage.Get(ctx, a.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	verdict, err := s.Scanner.Scan(ctx, file)
+	if err != nil {
+		slog.ErrorContext(ctx, "attachments: failed to scan upload", "organization_id", a.OrganizationID, "error", err)
+		return fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+
+	now := time.Now()
+	a.ScannedAt = &now
+	a.ScanStatus = models.AttachmentClean
+	if verdict.Infected {
+		a.ScanStatus, a.Threat = models.AttachmentQuarantined, verdict.Threat
+		slog.WarnContext(ctx, "attachments: quarantined upload", "organization_id", a.OrganizationID, "filename", a.Filename, "threat", verdict.Threat)
+	}
+	return nil
+}
+
+// notify emails the admins of the organization of a quarantined attachment in
+// the background
+func (s *Store) notify(ctx context.Context, a *models.Attachment) {
+	if s.Mailer == nil {
+		return
+	}
+	attachment := *a
+	go func(ctx context.Context) {
+		if err := s.sendQuarantined(ctx, &attachment); err != nil {
+			slog.ErrorContext(ctx, "attachments: failed to email quarantine", "attachment_id", attachment.ID, "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+// sendQuarantined emails the admins of the organization of an attachment that
+// it was quarantined
+func (s *Store) sendQuarantined(ctx context.Context, a *models.Attachment) error {
+	db := s.DB.WithContext(ctx)
+	var org models.Organization
+	if err := db.Select("id", "name", "email_footer").First(&org, a.OrganizationID).Error; err != nil {
+		return err
+	}
+	var uploader models.User
+	if err := db.Select("id", "email", "name").First(&uploader, a.UserID).Error; err != nil {
+		return err
+	}
+	var admins []string
+	err := db.Model(&models.Seat{}).
+		Joins("JOIN seat_roles ON seat_roles.seat_id = seats.id").
+		Joins("JOIN roles ON roles.id = seat_roles.role_id").
+		Joins("JOIN users ON users.id = seats.user_id AND users.deleted_at IS NULL").
+		Where("seats.organization_id = ? AND seats.status = ? AND roles.name = ?", a.OrganizationID, models.SeatStatusActive, models.AdminRole).
+		Distinct().
+		Pluck("users.email", &admins).Error
+	if err != nil {
+		return err
+	}
+	if len(admins) == 0 {
+		return nil
+	}
+
+	uploaderName := uploader.Name
+	if uploaderName == "" {
+		uploaderName = uploader.Email
+	}
+	threat := a.Threat
+	if threat == "" {
+		threat = "malware"
+	}
+	text := fmt.Sprintf("%s attached %s to one of the %s of %s, and the malware scan flagged it as %s.\n\n"+
+		"The file was quarantined: nobody can download it until an admin clears it or deletes attachment %d.\n",
+		uploaderName, a.Filename, a.AttachableType, org.Name, threat, a.ID)
+	if footer := strings.TrimSpace(org.Settings.EmailFooter); footer != "" {
+		text += "\n--\n" + footer + "\n"
+	}
+	for _, email := range admins {
+		msg := mailer.Message{To: []string{email}, Subject: "A file attached in " + org.Name + " was quarantined", Text: text}
+		if err := s.Mailer.Send(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signature signs the download URL of the attachment with id expiring at the
+// Unix time expires
+func (s *Store) signature(id uint, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "attachment:%d:%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// remove deletes stored content that's no longer used; failing only leaves an
+// orphaned file, so it's logged
+func (s *Store) remove(ctx context.Context, key string) {
+	if err := s.Storage.Delete(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "attachments: failed to delete content", "key", key, "error", err)
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,288 @@
+// Package audit/audit.go
+package audit
+
+import (
+	"log/slog"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/requestid"
+)
+
+// beforeKey is the statement setting holding the pre-change snapshot
+const beforeKey = "audit:before"
+
+// skipTables lists tenant-owned tables that are logs themselves and never audited
+var skipTables = map[string]bool{
+	"audit_logs":        true,
+	"audit_chain_heads": true,
+	"activity_logs":     true,
+	"report_runs":       true,
+	"feedbacks":         true,
+}
+
+// ignoredFields are bookkeeping columns left out of change diffs
+var ignoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+}
+
+// RegisterCallbacks installs GORM callbacks that write AuditLog rows for
+// tenant-owned models. Listeners are told about the rows once their
+// transaction commits.
+func RegisterCallbacks(db *gorm.DB) error {
+	if err := registerCommitHooks(db); err != nil {
+		return err
+	}
+	cb := db.Callback()
+
+	if err := cb.Create().After("gorm:create").Register("audit:after_create", afterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("audit:before_update", captureBefore); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("audit:after_update", afterUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("audit:before_delete", captureBefore); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("audit:after_delete", afterDelete)
+}
+
+// afterCreate records every field of newly created records
+func afterCreate(db *gorm.DB) {
+	if !auditable(db) {
+		return
+	}
+
+	eachRecord(db, func(rv reflect.Value) {
+		record := snapshot(db, rv)
+		changes := models.JSONMap{}
+		for field, value := range record {
+			changes[field] = map[string]interface{}{"to": value}
+		}
+		write(db, models.AuditActionCreate, rv, changes, record)
+	})
+}
+
+// captureBefore stores the current database state of the affected record
+func captureBefore(db *gorm.DB) {
+	if !auditable(db) {
+		return
+	}
+
+	rv := singleRecord(db)
+	if !rv.IsValid() {
+		return
+	}
+
+	before, ok := reload(db, rv)
+	if ok {
+		db.Statement.Settings.Store(beforeKey, before)
+	}
+}
+
+// afterUpdate records the fields that differ between the stored and new state
+func afterUpdate(db *gorm.DB) {
+	before, ok := loadBefore(db)
+	if !ok {
+		return
+	}
+
+	rv := singleRecord(db)
+	after, ok := reload(db, rv)
+	if !ok {
+		return
+	}
+
+	changes := models.JSONMap{}
+	for field, value := range after {
+		if !reflect.DeepEqual(before[field], value) {
+			changes[field] = map[string]interface{}{"from": before[field], "to": value}
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	write(db, models.AuditActionUpdate, rv, changes, after)
+}
+
+// afterDelete records the state of the record before it was deleted
+func afterDelete(db *gorm.DB) {
+	before, ok := loadBefore(db)
+	if !ok {
+		return
+	}
+
+	changes := models.JSONMap{}
+	for field, value := range before {
+		changes[field] = map[string]interface{}{"from": value}
+	}
+
+	write(db, models.AuditActionDelete, singleRecord(db), changes, before)
+}
+
+// auditable reports whether the statement targets a tenant-owned model
+func auditable(db *gorm.DB) bool {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return false
+	}
+
+	s := db.Statement.Schema
+	if skipTables[s.Table] {
+		return false
+	}
+
+	return s.Table == "organizations" || s.LookUpField("OrganizationID") != nil
+}
+
+// eachRecord calls fn for every struct the statement operated on
+func eachRecord(db *gorm.DB, fn func(reflect.Value)) {
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			fn(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		fn(rv)
+	}
+}
+
+// singleRecord returns the target struct if the statement operates on one record with a primary key
+func singleRecord(db *gorm.DB) reflect.Value {
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	if rv.Kind() != reflect.Struct || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return reflect.Value{}
+	}
+
+	if _, zero := db.Statement.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, rv); zero {
+		return reflect.Value{}
+	}
+
+	return rv
+}
+
+// reload fetches the stored version of the record and snapshots it
+func reload(db *gorm.DB, rv reflect.Value) (map[string]interface{}, bool) {
+	if !rv.IsValid() {
+		return nil, false
+	}
+
+	pk := db.Statement.Schema.PrioritizedPrimaryField
+	id, _ := pk.ValueOf(db.Statement.Context, rv)
+
+	fresh := reflect.New(db.Statement.Schema.ModelType)
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Unscoped().
+		Where(map[string]interface{}{pk.DBName: id}).
+		First(fresh.Interface()).Error
+	if err != nil {
+		return nil, false
+	}
+
+	return snapshot(db, fresh.Elem()), true
+}
+
+// loadBefore returns the snapshot stored by captureBefore
+func loadBefore(db *gorm.DB) (map[string]interface{}, bool) {
+	if db.Error != nil {
+		return nil, false
+	}
+
+	v, ok := db.Statement.Settings.Load(beforeKey)
+	if !ok {
+		return nil, false
+	}
+	before, ok := v.(map[string]interface{})
+	return before, ok
+}
+
+// snapshot returns the auditable column values of a record, skipping hidden fields
+func snapshot(db *gorm.DB, rv reflect.Value) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, field := range db.Statement.Schema.Fields {
+		if field.DBName == "" || ignoredFields[field.DBName] || hidden(field) {
+			continue
+		}
+		// Serialized fields are wrapped by ValueOf for writing; keep the plain value
+		if field.Serializer != nil {
+			values[field.DBName] = field.ReflectValueOf(db.Statement.Context, rv).Interface()
+			continue
+		}
+		value, _ := field.ValueOf(db.Statement.Context, rv)
+		values[field.DBName] = value
+	}
+	return values
+}
+
+// hidden reports whether a field is excluded from API output, such as password hashes
+func hidden(field *schema.Field) bool {
+	return field.Tag.Get("json") == "-"
+}
+
+// write inserts the AuditLog row using the statement's connection and context, then
+// notifies listeners with the entry and the full record state once it commits
+func write(db *gorm.DB, action string, rv reflect.Value, changes models.JSONMap, record map[string]interface{}) {
+	ctx := db.Statement.Context
+	entry := models.AuditLog{
+		Action:       action,
+		ResourceType: db.Statement.Schema.Table,
+		Timestamp:    time.Now(),
+		Changes:      changes,
+	}
+
+	if userID, ok := auth.UserIDFromContext(ctx); ok {
+		entry.UserID = userID
+	}
+	entry.RequestID = requestid.From(ctx)
+
+	if rv.IsValid() {
+		if pk := db.Statement.Schema.PrioritizedPrimaryField; pk != nil {
+			id, _ := pk.ValueOf(ctx, rv)
+			entry.ResourceID = toUint(id)
+			if db.Statement.Schema.Table == "organizations" {
+				entry.OrganizationID = entry.ResourceID
+			}
+		}
+		if field := db.Statement.Schema.LookUpField("OrganizationID"); field != nil {
+			orgID, _ := field.ValueOf(ctx, rv)
+			entry.OrganizationID = toUint(orgID)
+		}
+	}
+
+	err := appendEntry(db.Session(&gorm.Session{NewDB: true, SkipHooks: true}), &entry)
+	if err != nil {
+		slog.ErrorContext(ctx, "audit: failed to write audit log", "resource_type", entry.ResourceType, "resource_id", entry.ResourceID, "error", err)
+		return
+	}
+
+	change := Change{Entry: entry, Record: record}
+	AfterCommit(db, func() { notify(change) })
+}
+
+// toUint converts an unsigned primary or foreign key value to uint
+func toUint(v interface{}) uint {
+	switch n := v.(type) {
+	case uint:
+		return n
+	case uint64:
+		return uint(n)
+	case uint32:
+		return uint(n)
+	case int:
+		return uint(n)
+	case int64:
+		return uint(n)
+	}
+	return 0
+}
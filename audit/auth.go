@@ -0,0 +1,21 @@
+// Package audit/auth.go
+package audit
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/requestid"
+)
+
+// RecordAuth writes an authentication event, tagged with the ID of the request
+// in ctx. Authentication events aren't tied to an organization, so they're kept
+// apart from the audit logs of resource changes.
+func RecordAuth(ctx context.Context, db *gorm.DB, event *models.AuthEvent) error {
+	if event.RequestID == "" {
+		event.RequestID = requestid.From(ctx)
+	}
+	return db.WithContext(ctx).Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(event).Error
+}
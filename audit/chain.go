@@ -0,0 +1,156 @@
+// Package audit/chain.go
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// VerifyResult describes the outcome of checking an organization's audit chain
+type VerifyResult struct {
+	OrganizationID uint   `json:"organization_id"`
+	Entries        int    `json:"entries"`
+	Valid          bool   `json:"valid"`
+	BrokenEntryID  uint   `json:"broken_entry_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// ComputeHash returns the hash of an entry's content chained to its predecessor.
+// The request ID is only hashed when set, so entries written before it was
+// recorded keep their hashes.
+func ComputeHash(entry *models.AuditLog) string {
+	changes, _ := json.Marshal(entry.Changes)
+
+	h := sha256.New()
+	parts := []string{
+		entry.PrevHash,
+		strconv.FormatUint(uint64(entry.OrganizationID), 10),
+		strconv.FormatUint(uint64(entry.UserID), 10),
+		entry.Action,
+		entry.ResourceType,
+		strconv.FormatUint(uint64(entry.ResourceID), 10),
+		entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		string(changes),
+	}
+	if entry.RequestID != "" {
+		parts = append(parts, entry.RequestID)
+	}
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// appendEntry links the entry to its organization's chain head and inserts it
+func appendEntry(db *gorm.DB, entry *models.AuditLog) error {
+	// Databases store microsecond precision, so hash what will be read back
+	entry.Timestamp = entry.Timestamp.UTC().Truncate(time.Microsecond)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		head := models.AuditChainHead{OrganizationID: entry.OrganizationID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&head).Error; err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("organization_id = ?", entry.OrganizationID).
+			First(&head).Error
+		if err != nil {
+			return err
+		}
+
+		entry.PrevHash = head.Hash
+		entry.Hash = ComputeHash(entry)
+		if err := tx.Omit("Organization").Create(entry).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&head).Updates(map[string]interface{}{
+			"hash":          entry.Hash,
+			"last_entry_id": entry.ID,
+		}).Error
+	})
+}
+
+// Verify walks an organization's audit log in insertion order and reports the
+// first entry that was modified, deleted, or inserted out of chain
+func Verify(db *gorm.DB, orgID uint) (*VerifyResult, error) {
+	result := &VerifyResult{OrganizationID: orgID, Valid: true}
+
+	// Entries purged by retention leave their last hash on the head as the chain start
+	var head models.AuditChainHead
+	err := db.Where("organization_id = ?", orgID).First(&head).Error
+	headMissing := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !headMissing {
+		return nil, err
+	}
+
+	prevHash := head.PrunedHash
+	var lastID uint
+
+	var batch []models.AuditLog
+	err = db.Model(&models.AuditLog{}).
+		Where("organization_id = ?", orgID).
+		Order("id ASC").
+		FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				entry := &batch[i]
+				result.Entries++
+
+				if entry.PrevHash != prevHash {
+					return result.fail(entry.ID, "previous hash does not match, an earlier entry was removed or altered")
+				}
+				if ComputeHash(entry) != entry.Hash {
+					return result.fail(entry.ID, "entry content does not match its hash")
+				}
+
+				prevHash = entry.Hash
+				lastID = entry.ID
+			}
+			return nil
+		}).Error
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return nil, err
+	}
+	if !result.Valid {
+		return result, nil
+	}
+
+	if headMissing {
+		if result.Entries > 0 {
+			result.fail(lastID, "chain head is missing")
+		}
+		return result, nil
+	}
+	if result.Entries == 0 && head.Hash == head.PrunedHash {
+		return result, nil
+	}
+
+	if head.Hash != prevHash || head.LastEntryID != lastID {
+		result.fail(head.LastEntryID, fmt.Sprintf("chain head expects entry %d, latest entry is %d", head.LastEntryID, lastID))
+	}
+
+	return result, nil
+}
+
+// errChainBroken stops batch iteration once verification fails
+var errChainBroken = errors.New("audit chain broken")
+
+// fail marks the result invalid at the given entry
+func (r *VerifyResult) fail(entryID uint, reason string) error {
+	r.Valid = false
+	r.BrokenEntryID = entryID
+	r.Reason = reason
+	return errChainBroken
+}
@@ -0,0 +1,159 @@
+// Package audit/commit.go
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// afterCommitKey is the statement instance setting holding the functions
+// waiting for the statement's own transaction to commit
+const afterCommitKey = "audit:after_commit"
+
+// pool wraps a database's connection pool so that its transactions can run
+// functions once they commit
+type pool struct {
+	gorm.ConnPool
+}
+
+// BeginTx implements gorm.ConnPoolBeginner
+func (p *pool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var (
+		tx  gorm.ConnPool
+		err error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	default:
+		return nil, gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		return nil, err
+	}
+	committer, ok := tx.(gorm.TxCommitter)
+	if !ok {
+		return nil, gorm.ErrInvalidTransaction
+	}
+	return &txPool{ConnPool: tx, committer: committer}, nil
+}
+
+// GetDBConn implements gorm.GetDBConnector, for db.DB()
+func (p *pool) GetDBConn() (*sql.DB, error) {
+	switch conn := p.ConnPool.(type) {
+	case *sql.DB:
+		return conn, nil
+	case gorm.GetDBConnector:
+		return conn.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// txPool is a transaction begun by pool. It runs the functions queued with
+// AfterCommit once it commits and drops them if it rolls back. Rolling back to
+// a savepoint doesn't drop those queued since.
+type txPool struct {
+	gorm.ConnPool
+	committer gorm.TxCommitter
+
+	mu      sync.Mutex
+	pending []func()
+}
+
+// Commit implements gorm.TxCommitter
+func (t *txPool) Commit() error {
+	if err := t.committer.Commit(); err != nil {
+		t.take()
+		return err
+	}
+	for _, fn := range t.take() {
+		fn()
+	}
+	return nil
+}
+
+// Rollback implements gorm.TxCommitter
+func (t *txPool) Rollback() error {
+	t.take()
+	return t.committer.Rollback()
+}
+
+// queue adds a function to run on commit
+func (t *txPool) queue(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, fn)
+}
+
+// take removes and returns the queued functions
+func (t *txPool) take() []func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending := t.pending
+	t.pending = nil
+	return pending
+}
+
+// AfterCommit calls fn once the transaction the statement runs in commits,
+// dropping it if the transaction rolls back. Statements outside transactions
+// call it right away. It's meant for GORM callbacks telling others about
+// writes, which mustn't see writes that are rolled back.
+func AfterCommit(db *gorm.DB, fn func()) {
+	if tx, ok := db.Statement.ConnPool.(*txPool); ok {
+		tx.queue(fn)
+		return
+	}
+	// The statement's own transaction, still open, begun on a connection that
+	// isn't wrapped, such as a replica resolver's source. Once it's committed
+	// the statement is back on the pool and fn runs right away.
+	if _, open := db.Statement.ConnPool.(gorm.TxCommitter); open {
+		if _, ok := db.InstanceGet("gorm:started_transaction"); ok {
+			pending, _ := db.InstanceGet(afterCommitKey)
+			fns, _ := pending.([]func())
+			db.InstanceSet(afterCommitKey, append(fns, fn))
+			return
+		}
+	}
+	fn()
+}
+
+// registerCommitHooks wraps the database's connection pool so its
+// transactions run the functions queued with AfterCommit, and installs the
+// callbacks running those queued in statements' own transactions
+func registerCommitHooks(db *gorm.DB) error {
+	if _, ok := db.ConnPool.(*pool); !ok {
+		db.ConnPool = &pool{ConnPool: db.ConnPool}
+		db.Statement.ConnPool = db.ConnPool
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:commit_or_rollback_transaction").Register("audit:after_commit", runAfterCommit); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:commit_or_rollback_transaction").Register("audit:after_commit", runAfterCommit); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:commit_or_rollback_transaction").Register("audit:after_commit", runAfterCommit)
+}
+
+// runAfterCommit calls the functions queued in the statement's own
+// transaction, unless it was rolled back
+func runAfterCommit(db *gorm.DB) {
+	pending, ok := db.InstanceGet(afterCommitKey)
+	if !ok {
+		return
+	}
+	db.InstanceSet(afterCommitKey, nil)
+	if db.Error != nil {
+		return
+	}
+	fns, _ := pending.([]func())
+	for _, fn := range fns {
+		fn()
+	}
+}
@@ -0,0 +1,88 @@
+// Package audit/commit_test.go
+package audit_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/audit"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/testutil"
+)
+
+// recorder collects the names of the organizations listeners are told about
+type recorder struct {
+	mu    sync.Mutex
+	names map[string]int
+}
+
+func (r *recorder) record(change audit.Change) {
+	if change.Entry.ResourceType != "organizations" {
+		return
+	}
+	name, _ := change.Record["name"].(string)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names[name]++
+}
+
+func (r *recorder) count(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.names[name]
+}
+
+func TestListenersAreToldAfterCommit(t *testing.T) {
+	db := testutil.OpenDB(t)
+	if err := audit.RegisterCallbacks(db); err != nil {
+		t.Fatalf("register callbacks: %v", err)
+	}
+	r := &recorder{names: map[string]int{}}
+	audit.OnWrite(r.record)
+
+	t.Run("committed transaction", func(t *testing.T) {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&models.Organization{Name: "Committed"}).Error; err != nil {
+				return err
+			}
+			if n := r.count("Committed"); n != 0 {
+				t.Errorf("told %d times before commit, want 0", n)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("transaction: %v", err)
+		}
+		if n := r.count("Committed"); n != 1 {
+			t.Errorf("told %d times after commit, want 1", n)
+		}
+	})
+
+	t.Run("rolled back transaction", func(t *testing.T) {
+		rollback := errors.New("roll back")
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&models.Organization{Name: "Rolled back"}).Error; err != nil {
+				return err
+			}
+			return rollback
+		})
+		if !errors.Is(err, rollback) {
+			t.Fatalf("transaction: %v", err)
+		}
+		if n := r.count("Rolled back"); n != 0 {
+			t.Errorf("told %d times, want 0", n)
+		}
+	})
+
+	t.Run("single statement", func(t *testing.T) {
+		if err := db.Create(&models.Organization{Name: "Single"}).Error; err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if n := r.count("Single"); n != 1 {
+			t.Errorf("told %d times, want 1", n)
+		}
+	})
+}
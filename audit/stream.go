@@ -0,0 +1,160 @@
+// Package audit/stream.go
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/netguard"
+)
+
+// Change is a written audit entry together with the affected record's column values,
+// after the change for creates and updates and before it for deletes
+type Change struct {
+	Entry  models.AuditLog
+	Record map[string]interface{}
+}
+
+var (
+	listenersMu sync.RWMutex
+	listeners   []func(Change)
+)
+
+// OnWrite registers a function called for every audit log entry that is written
+func OnWrite(fn func(Change)) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// notify passes a written entry to all registered listeners
+func notify(change Change) {
+	listenersMu.RLock()
+	defer listenersMu.RUnlock()
+	for _, fn := range listeners {
+		fn(change)
+	}
+}
+
+// Forwarder streams audit events to the SIEM integrations configured by each
+// organization. Organization admins choose the endpoints, so both transports
+// only connect to public addresses.
+type Forwarder struct {
+	DB     *gorm.DB
+	Client *http.Client
+	Dialer *net.Dialer
+	queue  chan models.AuditLog
+}
+
+// NewForwarder creates a forwarder and subscribes it to audit writes
+func NewForwarder(db *gorm.DB) *Forwarder {
+	dialer := netguard.Dialer(5 * time.Second)
+	f := &Forwarder{
+		DB: db,
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second},
+		},
+		Dialer: dialer,
+		queue:  make(chan models.AuditLog, 1024),
+	}
+	OnWrite(f.Publish)
+	return f
+}
+
+// Publish queues an entry for forwarding without blocking the writer
+func (f *Forwarder) Publish(change Change) {
+	entry := change.Entry
+	if entry.OrganizationID == 0 {
+		return
+	}
+
+	select {
+	case f.queue <- entry:
+	default:
+		slog.Warn("audit: forward queue full, dropping entry", "entry_id", entry.ID)
+	}
+}
+
+// Run forwards queued entries until the context is canceled
+func (f *Forwarder) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-f.queue:
+			f.forward(ctx, entry)
+		}
+	}
+}
+
+// forward sends one entry to its organization's integration, if enabled
+func (f *Forwarder) forward(ctx context.Context, entry models.AuditLog) {
+	var integration models.SIEMIntegration
+	err := f.DB.WithContext(ctx).
+		Where("organization_id = ? AND enabled = ?", entry.OrganizationID, true).
+		First(&integration).Error
+	if err != nil {
+		return
+	}
+
+	if err := f.Send(ctx, &integration, entry); err != nil {
+		slog.Error("audit: failed to forward entry to SIEM", "entry_id", entry.ID, "error", err)
+		f.DB.Model(&integration).Update("last_error", err.Error())
+		return
+	}
+
+	if integration.LastError != "" {
+		f.DB.Model(&integration).Update("last_error", "")
+	}
+}
+
+// Send delivers a single entry using the integration's transport
+func (f *Forwarder) Send(ctx context.Context, integration *models.SIEMIntegration, entry models.AuditLog) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	switch integration.Type {
+	case models.SIEMTypeSyslog:
+		return f.sendSyslog(ctx, integration.Endpoint, payload)
+	case models.SIEMTypeHTTP:
+		return f.sendHTTP(ctx, integration, payload)
+	default:
+		return fmt.Errorf("unsupported SIEM type %q", integration.Type)
+	}
+}
+
+// sendHTTP posts the entry as JSON to an HTTP collector
+func (f *Forwarder) sendHTTP(ctx context.Context, integration *models.SIEMIntegration, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, integration.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if integration.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+integration.Token)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Package audit/stream_test.go
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/4cecoder/saas/audit"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/netguard"
+)
+
+func TestForwarderRefusesNonPublicEndpoints(t *testing.T) {
+	called := false
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer collector.Close()
+
+	syslog, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer syslog.Close()
+
+	f := audit.NewForwarder(nil)
+	for _, integration := range []models.SIEMIntegration{
+		{Type: models.SIEMTypeHTTP, Endpoint: collector.URL},
+		{Type: models.SIEMTypeSyslog, Endpoint: "udp://" + syslog.LocalAddr().String()},
+		{Type: models.SIEMTypeSyslog, Endpoint: "tcp://169.254.169.254:514"},
+	} {
+		err := f.Send(context.Background(), &integration, models.AuditLog{OrganizationID: 1})
+		if !errors.Is(err, netguard.ErrNonPublicAddress) {
+			t.Errorf("sending to %s: err = %v, want ErrNonPublicAddress", integration.Endpoint, err)
+		}
+	}
+	if called {
+		t.Error("the internal collector was called")
+	}
+}
@@ -0,0 +1,34 @@
+// Package audit/syslog.go
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// syslogPriority is the facility and severity of forwarded entries: auth.info
+const syslogPriority = 4<<3 | 6
+
+// sendSyslog writes the entry as an RFC 3164 message to a syslog endpoint such
+// as udp://host:514, connecting through the public-only dialer
+func (f *Forwarder) sendSyslog(ctx context.Context, endpoint string, payload []byte) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+
+	conn, err := f.Dialer.DialContext(ctx, u.Scheme, u.Host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(f.Dialer.Timeout))
+
+	hostname, _ := os.Hostname()
+	_, err = fmt.Fprintf(conn, "<%d>%s %s saas-audit[%d]: %s\n",
+		syslogPriority, time.Now().Format(time.RFC3339), hostname, os.Getpid(), payload)
+	return err
+}
@@ -2,10 +2,13 @@
 package auth
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/4cecoder/saas/apperror"
 	"github.com/4cecoder/saas/models"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
@@ -13,39 +16,215 @@ import (
 
 var jwtKey = []byte("your-secret-key")
 
+// previousKey still verifies tokens signed before the secret was rotated
+var previousKey []byte
+
+// keyMu guards the keys, which rotate while requests are served
+var keyMu sync.RWMutex
+
+// Defaults of the settings of tokens
+const (
+	DefaultTTL       = 24 * time.Hour
+	DefaultIssuer    = "saas"
+	DefaultAudience  = "saas-api"
+	DefaultClockSkew = 30 * time.Second
+)
+
+// tokenTTL is how long issued tokens are valid
+var tokenTTL = DefaultTTL
+
+// issuer and audience are the iss and aud claims of issued tokens, required
+// of verified ones
+var issuer, audience = DefaultIssuer, DefaultAudience
+
+// clockSkew is the leeway given to the time claims of tokens, for the clocks
+// of the instances issuing and verifying them to differ
+var clockSkew = DefaultClockSkew
+
+// revoked reports whether the tokens issued to a user at a time were revoked;
+// nil revokes none
+var revoked func(ctx context.Context, userID uint, issuedAt time.Time) bool
+
+// MinSecretLength is the shortest JWT secret accepted, in bytes
+const MinSecretLength = 32
+
+// Config holds the settings of the tokens the service issues
+type Config struct {
+	// Secret signs and verifies tokens with HMAC-SHA256
+	Secret string
+	// TTL is how long issued tokens are valid
+	TTL time.Duration
+	// Issuer and Audience are the iss and aud claims of tokens
+	Issuer   string
+	Audience string
+	// ClockSkew is the leeway given to the exp, iat and nbf claims of tokens
+	ClockSkew time.Duration
+	// Cookies holds the settings of cookie sessions
+	Cookies CookieConfig
+}
+
+// Setup signs and verifies tokens with the configured secret; unset settings
+// take their defaults
+func Setup(cfg Config) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	jwtKey, previousKey = []byte(cfg.Secret), nil
+	tokenTTL, issuer, audience, clockSkew = DefaultTTL, DefaultIssuer, DefaultAudience, DefaultClockSkew
+	if cfg.TTL > 0 {
+		tokenTTL = cfg.TTL
+	}
+	if cfg.Issuer != "" {
+		issuer = cfg.Issuer
+	}
+	if cfg.Audience != "" {
+		audience = cfg.Audience
+	}
+	if cfg.ClockSkew > 0 {
+		clockSkew = cfg.ClockSkew
+	}
+	cookies = cfg.Cookies
+}
+
+// RotateSecret signs tokens with a new secret, still accepting those signed
+// with the one it replaces until the next rotation
+func RotateSecret(secret string) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	jwtKey, previousKey = []byte(secret), jwtKey
+}
+
+// CheckRevocation rejects the tokens check reports revoked, such as those
+// issued before the sessions of their user were expired
+func CheckRevocation(check func(ctx context.Context, userID uint, issuedAt time.Time) bool) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	revoked = check
+}
+
+// Revoked reports whether the token carrying claims was revoked. Tokens
+// issued before their issue time was recorded count as issued at the epoch.
+func Revoked(ctx context.Context, claims jwt.MapClaims) bool {
+	keyMu.RLock()
+	check := revoked
+	keyMu.RUnlock()
+	id, ok := claims["id"].(float64)
+	if check == nil || !ok {
+		return false
+	}
+	iat, _ := claims["iat"].(float64)
+	return check(ctx, uint(id), time.Unix(int64(iat), 0))
+}
+
+// TTL returns how long issued tokens are valid
+func TTL() time.Duration {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return tokenTTL
+}
+
+// keys returns the current and the previous signing keys
+func keys() ([]byte, []byte) {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return jwtKey, previousKey
+}
+
+// signClaims signs a token carrying claims, issued now and expiring after
+// tokenTTL, or after maxTTL if that's shorter and not zero
+func signClaims(claims jwt.MapClaims, maxTTL time.Duration) (string, error) {
+	keyMu.RLock()
+	key, iss, aud, ttl := jwtKey, issuer, audience, tokenTTL
+	keyMu.RUnlock()
+	if maxTTL > 0 && maxTTL < ttl {
+		ttl = maxTTL
+	}
+
+	now := time.Now()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
+	claims["iss"] = iss
+	claims["aud"] = aud
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+// userIDKey is the context key for the authenticated user ID
+type userIDKey struct{}
+
+// WithUserID returns a copy of ctx carrying the authenticated user ID
+func WithUserID(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, userIDKey{}, id)
+}
+
+// UserIDFromContext returns the authenticated user ID stored in ctx, if any
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(userIDKey{}).(uint)
+	return id, ok
+}
+
+// GenerateToken issues a user token without an organization or permissions
 func GenerateToken(user *models.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":   user.ID,
-		"role": "user",
-	})
-	return token.SignedString(jwtKey)
+	return IssueToken(user, Grant{Role: models.UserRole})
 }
 
+// GenerateAdminToken issues an admin token without an organization or permissions
 func GenerateAdminToken(user *models.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":   user.ID,
-		"role": "admin",
-	})
-	return token.SignedString(jwtKey)
+	return IssueToken(user, Grant{Role: models.AdminRole})
 }
 
-func VerifyToken(c *gin.Context) (string, error) {
-	tokenString := ExtractToken(c)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+// ParseToken validates the bearer token and returns its claims
+func ParseToken(c *gin.Context) (jwt.MapClaims, error) {
+	claims, err := ParseTokenString(ExtractToken(c))
+	if err != nil {
+		return nil, err
+	}
+	if Revoked(c.Request.Context(), claims) {
+		return nil, fmt.Errorf("token revoked")
+	}
+	return claims, nil
+}
+
+// ParseTokenString validates a token and returns its claims: its signature,
+// then its claims with validateClaims
+func ParseTokenString(tokenString string) (jwt.MapClaims, error) {
+	key, previous := keys()
+	token, err := parseWithKey(tokenString, key)
+	if verr, ok := err.(*jwt.ValidationError); ok && verr.Errors&jwt.ValidationErrorSignatureInvalid != 0 && previous != nil {
+		token, err = parseWithKey(tokenString, previous)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	if err := validateClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// parseWithKey parses a token signed with HMAC under key, leaving the claims
+// to validateClaims
+func parseWithKey(tokenString string, key []byte) (*jwt.Token, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	return parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("invalid signing method")
 		}
-		return jwtKey, nil
+		return key, nil
 	})
+}
+
+// VerifyToken validates the token of the request and returns its role
+func VerifyToken(c *gin.Context) (string, error) {
+	claims, err := ParseToken(c)
 	if err != nil {
 		return "", err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return "", fmt.Errorf("invalid token")
-	}
-
 	role, ok := claims["role"].(string)
 	if !ok {
 		return "", fmt.Errorf("invalid role")
@@ -54,7 +233,17 @@ func VerifyToken(c *gin.Context) (string, error) {
 	return role, nil
 }
 
+// ExtractToken returns the bearer token of the request, or else the token of
+// its session cookie
 func ExtractToken(c *gin.Context) string {
+	if token := bearerToken(c); token != "" {
+		return token
+	}
+	return sessionToken(c)
+}
+
+// bearerToken returns the token of the Authorization header
+func bearerToken(c *gin.Context) string {
 	bearerToken := c.GetHeader("Authorization")
 	if len(strings.Split(bearerToken, " ")) == 2 {
 		return strings.Split(bearerToken, " ")[1]
@@ -65,7 +254,7 @@ func ExtractToken(c *gin.Context) string {
 func IsUserOrAdmin(c *gin.Context) {
 	role, err := VerifyToken(c)
 	if err != nil || (role != models.UserRole && role != models.AdminRole) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Error(apperror.Unauthorized("Unauthorized"))
 		c.Abort()
 		return
 	}
@@ -76,10 +265,36 @@ func AuthMiddleware(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, err := VerifyToken(c)
 		if err != nil || role != requiredRole {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Error(apperror.Unauthorized("Unauthorized"))
 			c.Abort()
 			return
 		}
 		c.Next()
 	}
 }
+
+// Identify attaches the authenticated user, if any, to the request context,
+// along with the organization the token is scoped to as "token_org_id", which
+// the organization guards of the handlers hold the request to.
+// Requests authenticated by a session cookie also get the session's CSRF token
+// as "session_csrf", for middleware.CSRF to check.
+func Identify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims, err := ParseToken(c); err == nil {
+			if id, ok := claims["id"].(float64); ok {
+				c.Set("user_id", uint(id))
+				c.Request = c.Request.WithContext(WithUserID(c.Request.Context(), uint(id)))
+			}
+			if role, ok := claims["role"].(string); ok {
+				c.Set("role", role)
+			}
+			if orgID, ok := claims["org_id"].(float64); ok {
+				c.Set("token_org_id", uint(orgID))
+			}
+			if csrf, ok := claims["csrf"].(string); ok && bearerToken(c) == "" {
+				c.Set("session_csrf", csrf)
+			}
+		}
+		c.Next()
+	}
+}
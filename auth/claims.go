@@ -0,0 +1,97 @@
+// Package auth/claims.go
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// Grant is what a token grants its holder, snapshotted when it's issued; the
+// user's access may change before the token expires
+type Grant struct {
+	Role string
+	// OrganizationID scopes the token to an organization; zero scopes it to none
+	OrganizationID uint
+	// Permissions are the user's permissions when the token was issued
+	Permissions []string
+	// TTL shortens how long the token is valid, e.g. by the session lifetime of
+	// its organization; zero or longer than the configured TTL leaves that
+	TTL time.Duration
+}
+
+// IssueToken signs a token for user carrying grant. Besides id and role, it
+// holds the org_id and perms of grant, and the iat, exp, iss and aud claims.
+func IssueToken(user *models.User, grant Grant) (string, error) {
+	return signClaims(grantClaims(user, grant), grant.TTL)
+}
+
+// grantClaims returns the claims of a token for user carrying grant
+func grantClaims(user *models.User, grant Grant) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"id":   user.ID,
+		"role": grant.Role,
+	}
+	if grant.OrganizationID != 0 {
+		claims["org_id"] = grant.OrganizationID
+	}
+	if len(grant.Permissions) > 0 {
+		claims["perms"] = grant.Permissions
+	}
+	return claims
+}
+
+// Errors of tokens whose claims are invalid
+var (
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not valid yet")
+	ErrTokenClaims      = errors.New("token lacks required claims")
+	ErrTokenIssuer      = errors.New("token issued by another issuer")
+	ErrTokenAudience    = errors.New("token meant for another audience")
+)
+
+// validateClaims checks the claims of a token at now: exp, iat, iss and aud are
+// required, exp, iat and nbf are checked with clockSkew of leeway, and iss and
+// aud must match the issuer and audience
+func validateClaims(claims jwt.MapClaims, now time.Time) error {
+	keyMu.RLock()
+	iss, aud, skew := issuer, audience, clockSkew
+	keyMu.RUnlock()
+
+	exp, hasExp := numericClaim(claims, "exp")
+	iat, hasIat := numericClaim(claims, "iat")
+	if !hasExp || !hasIat {
+		return ErrTokenClaims
+	}
+	if now.After(exp.Add(skew)) {
+		return ErrTokenExpired
+	}
+	if iat.After(now.Add(skew)) {
+		return ErrTokenNotYetValid
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && nbf.After(now.Add(skew)) {
+		return ErrTokenNotYetValid
+	}
+
+	if got, _ := claims["iss"].(string); got != iss {
+		return ErrTokenIssuer
+	}
+	if !claims.VerifyAudience(aud, true) {
+		return ErrTokenAudience
+	}
+	return nil
+}
+
+// numericClaim returns the time of a NumericDate claim
+func numericClaim(claims jwt.MapClaims, name string) (time.Time, bool) {
+	switch v := claims[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case int64:
+		return time.Unix(v, 0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,26 @@
+// Package auth/links.go
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewLinkToken returns a random token for a link emailed to a user, such as a
+// confirmation link, and the hash to store in its place
+func NewLinkToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashLinkToken(token), nil
+}
+
+// HashLinkToken returns the hash a link token is stored as
+func HashLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
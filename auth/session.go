@@ -0,0 +1,108 @@
+// Package auth/session.go
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// Names of the cookies and header of cookie sessions
+const (
+	// SessionCookie holds the token of the session; scripts can't read it
+	SessionCookie = "session"
+	// CSRFCookie holds the CSRF token of the session, for scripts to send back
+	// in CSRFHeader
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// CookieConfig holds the settings of cookie sessions, for browser frontends
+// that shouldn't keep bearer tokens where scripts can read them
+type CookieConfig struct {
+	// Enabled lets browsers sign in with a session cookie
+	Enabled bool
+	// Domain shares the cookies with subdomains; empty limits them to the API's host
+	Domain string
+	// Secure only sends the cookies over HTTPS
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// cookies holds the settings of cookie sessions
+var cookies CookieConfig
+
+// SessionsEnabled reports whether browsers may sign in with a session cookie
+func SessionsEnabled() bool {
+	return cookies.Enabled
+}
+
+// Session describes the cookie session of a browser
+type Session struct {
+	// CSRFToken must be sent in the X-CSRF-Token header of requests changing
+	// data; it's also in the csrf_token cookie
+	CSRFToken string     `json:"csrf_token"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// PasswordChangeRequired is set when the user must change their password
+	// with PUT /v1/me/password before anything else
+	PasswordChangeRequired bool `json:"password_change_required,omitempty"`
+}
+
+// StartSession signs user in with grant through an HttpOnly session cookie,
+// along with the CSRF cookie. The CSRF token is also a claim of the session,
+// so a cookie planted by another site doesn't match.
+func StartSession(c *gin.Context, user *models.User, grant Grant) (*Session, error) {
+	csrf := make([]byte, 32)
+	if _, err := rand.Read(csrf); err != nil {
+		return nil, err
+	}
+	session := &Session{CSRFToken: hex.EncodeToString(csrf), PasswordChangeRequired: user.PasswordChangeRequired}
+	claims := grantClaims(user, grant)
+	claims["csrf"] = session.CSRFToken
+	token, err := signClaims(claims, grant.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cookies expire with the token
+	expires := time.Unix(claims["exp"].(int64), 0)
+	session.ExpiresAt = &expires
+	maxAge := int(time.Until(expires).Seconds())
+	setCookie(c, SessionCookie, token, maxAge, true)
+	setCookie(c, CSRFCookie, session.CSRFToken, maxAge, false)
+	return session, nil
+}
+
+// EndSession signs the browser out, deleting the session cookies
+func EndSession(c *gin.Context) {
+	setCookie(c, SessionCookie, "", -1, true)
+	setCookie(c, CSRFCookie, "", -1, false)
+}
+
+// setCookie sets a cookie of the sessions on the response
+func setCookie(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   cookies.Domain,
+		MaxAge:   maxAge,
+		Secure:   cookies.Secure,
+		HttpOnly: httpOnly,
+		SameSite: cookies.SameSite,
+	})
+}
+
+// sessionToken returns the token of the session cookie, if sessions are enabled
+func sessionToken(c *gin.Context) string {
+	if !cookies.Enabled {
+		return ""
+	}
+	token, _ := c.Cookie(SessionCookie)
+	return token
+}
@@ -0,0 +1,209 @@
+// Package branding/branding.go
+//
+// Package branding stores the logos and favicons organizations upload for
+// their white-label frontends and serves them before users sign in.
+package branding
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/storage"
+)
+
+// MaxSize caps the size of an uploaded asset
+const MaxSize = 512 << 10
+
+// AssetPath is the route of the API serving assets, followed by the
+// organization ID and the asset name
+const AssetPath = "/v1/branding/assets"
+
+// Kind is what an asset is used for
+type Kind string
+
+// Kinds of assets
+const (
+	KindLogo    Kind = "logo"
+	KindFavicon Kind = "favicon"
+)
+
+// extensions lists the content types each kind of asset accepts, with the
+// extension of their files
+var extensions = map[Kind]map[string]string{
+	KindLogo: {
+		"image/png":     ".png",
+		"image/jpeg":    ".jpg",
+		"image/webp":    ".webp",
+		"image/svg+xml": ".svg",
+	},
+	KindFavicon: {
+		"image/png":                ".png",
+		"image/x-icon":             ".ico",
+		"image/vnd.microsoft.icon": ".ico",
+		"image/svg+xml":            ".svg",
+	},
+}
+
+// contentTypes maps the extensions of asset files back to their content type
+var contentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+	".ico":  "image/x-icon",
+}
+
+// Errors of uploads
+var (
+	ErrUnsupportedType = errors.New("unsupported content type")
+	ErrTooLarge        = errors.New("asset too large")
+	ErrInvalidImage    = errors.New("content doesn't match its type")
+)
+
+// Assets stores the branding assets of organizations
+type Assets struct {
+	DB      *gorm.DB
+	Storage storage.Storage
+	// PublicURL is the URL of the API, which the URLs of assets start with
+	PublicURL string
+}
+
+// NewAssets creates the asset store, linking to assets served at publicURL
+func NewAssets(db *gorm.DB, store storage.Storage, publicURL string) *Assets {
+	return &Assets{DB: db, Storage: store, PublicURL: strings.TrimSuffix(publicURL, "/")}
+}
+
+// Put stores the asset of kind of org read from r, replacing the previous one,
+// and points the organization's logo or favicon URL at it. Each upload gets a
+// new name, so the URL of an asset never serves other content and can be
+// cached for good.
+func (a *Assets) Put(ctx context.Context, org *models.Organization, kind Kind, contentType string, r io.Reader) error {
+	ext, ok := extensions[kind][contentType]
+	if !ok {
+		return ErrUnsupportedType
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > MaxSize {
+		return ErrTooLarge
+	}
+	if !matches(contentType, data) {
+		return ErrInvalidImage
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s%s", kind, hex.EncodeToString(suffix), ext)
+	if err := a.Storage.Put(ctx, key(org.ID, name), bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("store asset: %w", err)
+	}
+
+	previous := org.Settings.LogoKey
+	if kind == KindFavicon {
+		previous = org.Settings.FaviconKey
+	}
+	if err := a.set(ctx, org, kind, a.URL(org.ID, name), key(org.ID, name)); err != nil {
+		a.remove(ctx, key(org.ID, name))
+		return err
+	}
+	a.remove(ctx, previous)
+	return nil
+}
+
+// Delete removes the uploaded asset of kind of org, clearing its URL. URLs
+// set to assets hosted elsewhere are left alone.
+func (a *Assets) Delete(ctx context.Context, org *models.Organization, kind Kind) error {
+	previous := org.Settings.LogoKey
+	if kind == KindFavicon {
+		previous = org.Settings.FaviconKey
+	}
+	if previous == "" {
+		return nil
+	}
+	if err := a.set(ctx, org, kind, "", ""); err != nil {
+		return err
+	}
+	a.remove(ctx, previous)
+	return nil
+}
+
+// Open opens the asset called name of an organization, returning its content
+// type, or storage.ErrNotFound if it doesn't exist
+func (a *Assets) Open(ctx context.Context, orgID uint, name string) (io.ReadCloser, string, error) {
+	contentType, ok := contentTypes[path.Ext(name)]
+	if !ok || strings.ContainsAny(name, "/\\") {
+		return nil, "", storage.ErrNotFound
+	}
+	file, err := a.Storage.Get(ctx, key(orgID, name))
+	if err != nil {
+		return nil, "", err
+	}
+	return file, contentType, nil
+}
+
+// URL returns the URL the asset called name of an organization is served at
+func (a *Assets) URL(orgID uint, name string) string {
+	return fmt.Sprintf("%s%s/%d/%s", a.PublicURL, AssetPath, orgID, name)
+}
+
+// set records the URL and storage key of the asset of kind of org
+func (a *Assets) set(ctx context.Context, org *models.Organization, kind Kind, url, key string) error {
+	updates := map[string]interface{}{"logo_url": url, "logo_key": key}
+	if kind == KindFavicon {
+		updates = map[string]interface{}{"favicon_url": url, "favicon_key": key}
+	}
+	if err := a.DB.WithContext(ctx).Model(org).Updates(updates).Error; err != nil {
+		return err
+	}
+	if kind == KindFavicon {
+		org.Settings.FaviconURL, org.Settings.FaviconKey = url, key
+	} else {
+		org.Settings.LogoURL, org.Settings.LogoKey = url, key
+	}
+	return nil
+}
+
+// remove deletes a stored asset that's no longer used; failing only leaves an
+// orphaned file, so it's logged
+func (a *Assets) remove(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := a.Storage.Delete(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "branding: failed to delete asset", "key", key, "error", err)
+	}
+}
+
+// key returns the storage key of the asset called name of an organization
+func key(orgID uint, name string) string {
+	return fmt.Sprintf("branding/%d/%s", orgID, name)
+}
+
+// matches reports whether data looks like content of contentType. SVG isn't
+// sniffed by net/http, so it must merely be XML with an svg element.
+func matches(contentType string, data []byte) bool {
+	sniffed, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	switch contentType {
+	case "image/svg+xml":
+		return (sniffed == "text/xml" || sniffed == "text/plain") && bytes.Contains(data, []byte("<svg"))
+	case "image/vnd.microsoft.icon":
+		return sniffed == "image/x-icon"
+	}
+	return sniffed == contentType
+}
@@ -0,0 +1,89 @@
+// Package cache/cache.go
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache stores short-lived values shared across requests
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Delete(ctx context.Context, key string)
+}
+
+// Config holds cache settings
+type Config struct {
+	// URL is the redis:// or rediss:// URL of a Redis server shared by the
+	// instances of the application; without it values are cached in process
+	URL string
+}
+
+// New returns a Redis cache if one is configured, or an in-process cache
+func New(cfg Config) (Cache, error) {
+	if cfg.URL == "" {
+		return NewMemory(), nil
+	}
+	return NewRedis(cfg.URL)
+}
+
+// entry is a cached value with its expiry
+type entry struct {
+	value   []byte
+	expires time.Time
+}
+
+// Memory is an in-process cache with per-entry expiry
+type Memory struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+// NewMemory creates an in-process cache and starts evicting expired entries
+func NewMemory() *Memory {
+	m := &Memory{entries: make(map[string]entry)}
+	go m.evict(time.Minute)
+	return m
+}
+
+// Get returns a cached value if it exists and hasn't expired
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores a value for the given duration
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry{value: value, expires: time.Now().Add(ttl)}
+}
+
+// Delete removes a cached value
+func (m *Memory) Delete(ctx context.Context, key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+}
+
+// evict periodically drops expired entries so the map doesn't grow unbounded
+func (m *Memory) evict(interval time.Duration) {
+	for range time.Tick(interval) {
+		now := time.Now()
+		m.mu.Lock()
+		for key, e := range m.entries {
+			if now.After(e.expires) {
+				delete(m.entries, key)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,98 @@
+// Package cache/redis.go
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisTimeout bounds a command without a context deadline, so a stalled Redis
+	// slows requests down rather than hanging them
+	redisTimeout = 500 * time.Millisecond
+	// redisIdleConns is the number of connections kept open between commands
+	redisIdleConns = 16
+	// redisLogInterval limits how often failures are logged while Redis is down
+	redisLogInterval = time.Minute
+)
+
+// Redis is a cache kept in Redis and shared by every instance of the
+// application. Failures are logged and treated as misses, so the application
+// keeps working, only slower, while Redis is unavailable.
+type Redis struct {
+	// Client is the go-redis client, also used for the rate limits kept in Redis
+	Client *redis.Client
+
+	mu        sync.Mutex
+	lastLogAt time.Time
+}
+
+// NewRedis creates a cache connecting to a redis://[user:password@]host:port[/db]
+// URL, or rediss:// for TLS. Connections are opened on first use.
+func NewRedis(rawURL string) (*Redis, error) {
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	opts.DialTimeout = redisTimeout
+	opts.ReadTimeout = redisTimeout
+	opts.WriteTimeout = redisTimeout
+	opts.ContextTimeoutEnabled = true
+	opts.MaxIdleConns = redisIdleConns
+	return &Redis{Client: redis.NewClient(opts)}, nil
+}
+
+// Get returns a cached value if it exists
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, err := r.Client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			r.logFailure("GET", err)
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+// Set stores a value for the given duration
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	// A zero TTL would keep the value forever
+	ttl = max(ttl, time.Millisecond)
+	if err := r.Client.Set(ctx, key, value, ttl).Err(); err != nil {
+		r.logFailure("SET", err)
+	}
+}
+
+// Delete removes a cached value
+func (r *Redis) Delete(ctx context.Context, key string) {
+	if err := r.Client.Del(ctx, key).Err(); err != nil {
+		r.logFailure("DEL", err)
+	}
+}
+
+// Ping checks that Redis answers
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.Client.Ping(ctx).Err()
+}
+
+// Close closes the connections to Redis
+func (r *Redis) Close() error {
+	return r.Client.Close()
+}
+
+// logFailure logs a failed command, at most once per interval
+func (r *Redis) logFailure(cmd string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.lastLogAt) < redisLogInterval {
+		return
+	}
+	r.lastLogAt = time.Now()
+	slog.Warn("cache: redis command failed, treating as a miss", "command", cmd, "error", err)
+}
@@ -0,0 +1,322 @@
+// Package main/cli.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/4cecoder/saas/audit"
+	"github.com/4cecoder/saas/config"
+	"github.com/4cecoder/saas/migrate"
+	"github.com/4cecoder/saas/migrations"
+	"github.com/4cecoder/saas/search"
+	"github.com/4cecoder/saas/seed"
+)
+
+// newCommand creates the administrative commands run instead of serving HTTP
+func newCommand(cfg *config.Config) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "saas",
+		Short: "Serve the application, or run an administrative command",
+		// Failures are reported by main, and a usage dump would bury them
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	root.AddCommand(
+		auditCommand(cfg),
+		searchCommand(cfg),
+		migrateCommand(cfg),
+		seedCommand(cfg),
+		usersCommand(cfg),
+		orgsCommand(cfg),
+		rolesCommand(cfg),
+		jwtCommand(),
+		sessionsCommand(cfg),
+		subscriptionsCommand(cfg),
+		encryptionCommand(cfg),
+	)
+	return root
+}
+
+// auditCommand checks the audit log
+func auditCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{Use: "audit", Short: "Check the audit log"}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "verify <organization-id>",
+		Short: "Check an organization's audit hash chain, exiting with 2 if it is broken",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return auditVerify(cfg, args[0])
+		},
+	})
+	return cmd
+}
+
+// auditVerify checks an organization's audit hash chain and fails if it is broken
+func auditVerify(cfg *config.Config, arg string) error {
+	orgID, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("invalid organization ID %q", arg)
+	}
+
+	result, err := audit.Verify(cfg.DB, uint(orgID))
+	if err != nil {
+		return err
+	}
+
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+
+	if !result.Valid {
+		os.Exit(2)
+	}
+	return nil
+}
+
+// searchCommand maintains the external search index
+func searchCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{Use: "search", Short: "Maintain the external search index"}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "reindex [user|organization...]",
+		Short: "Rebuild the search index for the given types, or for all of them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return searchReindex(cfg, args)
+		},
+	})
+	return cmd
+}
+
+// searchReindex rebuilds the external search index for the given types, or for all of them
+func searchReindex(cfg *config.Config, types []string) error {
+	searcher, err := search.New(cfg.Search, cfg.DB)
+	if err != nil {
+		return err
+	}
+	idx, ok := searcher.(search.Index)
+	if !ok {
+		return fmt.Errorf("search engine %q keeps no separate index", cfg.Search.Engine)
+	}
+
+	ctx := context.Background()
+	if err := idx.Setup(ctx); err != nil {
+		return err
+	}
+
+	n, err := search.NewIndexer(cfg.DB, idx).Reindex(ctx, types...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Indexed %d documents\n", n)
+	return nil
+}
+
+// migrateCommand applies, reverts, lists, or creates schema migrations
+func migrateCommand(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{Use: "migrate", Short: "Apply, revert, list, or create schema migrations"}
+
+	var dryRun bool
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply the pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, err := migrations.New(cfg.DB)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			if dryRun {
+				pending, err := migrator.Pending(ctx)
+				if err != nil {
+					return err
+				}
+				printMigrations("Would apply", pending, func(m migrate.Migration) string { return m.Up })
+				return nil
+			}
+			applied, err := migrator.Up(ctx)
+			printMigrations("Applied", applied, nil)
+			return err
+		},
+	}
+	up.Flags().BoolVar(&dryRun, "dry-run", false, "print the SQL that would run instead of running it")
+
+	down := &cobra.Command{
+		Use:   "down [steps]",
+		Short: "Revert the last applied migrations, one by default",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			steps := 1
+			if len(args) > 0 {
+				var err error
+				if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+					return fmt.Errorf("invalid number of steps %q", args[0])
+				}
+			}
+			migrator, err := migrations.New(cfg.DB)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			if dryRun {
+				latest, err := migrator.Latest(ctx, steps)
+				if err != nil {
+					return err
+				}
+				printMigrations("Would revert", latest, func(m migrate.Migration) string { return m.Down })
+				return nil
+			}
+			reverted, err := migrator.Down(ctx, steps)
+			printMigrations("Reverted", reverted, nil)
+			return err
+		},
+	}
+	down.Flags().BoolVar(&dryRun, "dry-run", false, "print the SQL that would run instead of running it")
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "List the migrations and whether they have been applied",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, err := migrations.New(cfg.DB)
+			if err != nil {
+				return err
+			}
+			statuses, err := migrator.Status(cmd.Context())
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+			for _, status := range statuses {
+				state := "pending"
+				if status.Applied {
+					state = "applied"
+				} else if status.Dirty {
+					state = "dirty, fix it and run saas migrate force"
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, state)
+			}
+			return w.Flush()
+		},
+	}
+
+	force := &cobra.Command{
+		Use:   "force <version>",
+		Short: "Record the schema version, clearing the dirty flag a failed migration leaves once it has been fixed by hand",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version, err := strconv.Atoi(args[0])
+			if err != nil || version < 0 {
+				return fmt.Errorf("invalid version %q", args[0])
+			}
+			migrator, err := migrations.New(cfg.DB)
+			if err != nil {
+				return err
+			}
+			if err := migrator.Force(cmd.Context(), version); err != nil {
+				return err
+			}
+			fmt.Printf("Recorded version %d\n", version)
+			return nil
+		},
+	}
+
+	var dir string
+	create := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create empty up and down files of a new migration for every dialect",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					continue
+				}
+				up, down, err := migrate.Create(filepath.Join(dir, entry.Name()), args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Created %s\nCreated %s\n", up, down)
+			}
+			return nil
+		},
+	}
+	create.Flags().StringVar(&dir, "dir", "migrations", "directory holding a migrations directory per database dialect")
+
+	cmd.AddCommand(up, down, status, force, create)
+	return cmd
+}
+
+// printMigrations lists migrations after a verb, followed by their SQL if sql is set
+func printMigrations(verb string, list []migrate.Migration, sql func(migrate.Migration) string) {
+	if len(list) == 0 {
+		fmt.Println("No migrations to run")
+		return
+	}
+	for _, m := range list {
+		fmt.Printf("%s %d_%s\n", verb, m.Version, m.Name)
+		if sql != nil {
+			fmt.Println(sql(m))
+		}
+	}
+}
+
+// seedCommand loads the demo dataset, creating the administrator if needed
+func seedCommand(cfg *config.Config) *cobra.Command {
+	var size string
+	var orgs, users int
+	var opts seed.Options
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Load the demo dataset, creating the administrator if needed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			counts, ok := seed.Sizes[size]
+			if !ok {
+				return fmt.Errorf("unknown size %q, use small, medium, or large", size)
+			}
+			opts.Organizations, opts.UsersPerOrganization = counts[0], counts[1]
+			if orgs >= 0 {
+				opts.Organizations = orgs
+			}
+			if users >= 0 {
+				opts.UsersPerOrganization = users
+			}
+			return runSeed(cmd.Context(), cfg, opts)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&size, "size", "small", "dataset size: small, medium, or large")
+	flags.IntVar(&orgs, "orgs", -1, "number of organizations, overriding the size")
+	flags.IntVar(&users, "users", -1, "number of users per organization, overriding the size")
+	flags.Int64Var(&opts.Seed, "seed", 0, "random seed for a reproducible dataset")
+	flags.StringVar(&opts.AdminEmail, "admin-email", "admin@example.com", "email of the administrator")
+	flags.StringVar(&opts.AdminPassword, "admin-password", "", "password of a new administrator; generated if empty")
+	return cmd
+}
+
+// runSeed loads the demo dataset and prints what it created
+func runSeed(ctx context.Context, cfg *config.Config, opts seed.Options) error {
+	summary, err := seed.Run(ctx, cfg.DB, opts)
+	if err != nil {
+		return err
+	}
+
+	if summary.AdminCreated {
+		fmt.Printf("Created administrator %s with password %s\n", summary.AdminEmail, summary.AdminPassword)
+	}
+	fmt.Printf("Created %d plans, %d organizations, %d users, %d seats, %d subscriptions, %d workflows\n",
+		summary.Plans, summary.Organizations, summary.Users, summary.Seats, summary.Subscriptions, summary.Workflows)
+	return nil
+}
@@ -0,0 +1,302 @@
+// Package client/client.go
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultRetryWait  = 500 * time.Millisecond
+	maxRetryWait      = 30 * time.Second
+)
+
+// Config configures a Client
+type Config struct {
+	// BaseURL is the server address including the API version, e.g. https://api.example.com/v1
+	BaseURL string
+	// Token is sent as a bearer token; it can be replaced later with SetToken
+	Token string
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// MaxRetries is how often a failed request is retried; negative disables retries
+	MaxRetries int
+	// RetryWait is the delay before the first retry, doubled on every further one
+	RetryWait time.Duration
+	UserAgent string
+}
+
+// Client calls the SaaS API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	retryWait  time.Duration
+	userAgent  string
+
+	mu    sync.RWMutex
+	token string
+}
+
+// New creates a new Client
+func New(cfg Config) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		httpClient: cfg.HTTPClient,
+		maxRetries: cfg.MaxRetries,
+		retryWait:  cfg.RetryWait,
+		userAgent:  cfg.UserAgent,
+		token:      cfg.Token,
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = defaultMaxRetries
+	}
+	if c.retryWait <= 0 {
+		c.retryWait = defaultRetryWait
+	}
+	if c.userAgent == "" {
+		c.userAgent = "saas-go-client"
+	}
+	return c
+}
+
+// SetToken replaces the bearer token used for subsequent requests
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+}
+
+// FieldError describes why one field of a request body was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error is a non-2xx response from the API
+type Error struct {
+	StatusCode int
+	// Code is the stable, machine-readable error code, such as not_found
+	Code    string
+	Message string
+	// Fields lists the invalid fields of a rejected request body
+	Fields []FieldError
+	// Body is the raw response body
+	Body []byte
+	// RequestID identifies the failed request in the server's logs
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("saas: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("saas: %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 response
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// request describes one API call
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+}
+
+// do sends a JSON request and decodes the response into out, which may be nil
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// call sends a JSON request and returns the decoded response
+func call[T any](c *Client, ctx context.Context, req request) (*T, error) {
+	var out T
+	if err := c.do(ctx, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// send performs a request with retries and returns a successful response, whose
+// body the caller must close
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+	}
+
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	// Retried POSTs are deduplicated by the server's idempotency middleware
+	var idempotencyKey string
+	if req.method == http.MethodPost {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		httpReq.Header.Set("User-Agent", c.userAgent)
+		if idempotencyKey != "" {
+			httpReq.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		c.mu.RLock()
+		if c.token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		c.mu.RUnlock()
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		var apiErr error
+		var retryAfter time.Duration
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			apiErr = err
+		} else {
+			apiErr = readError(resp)
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+
+		if attempt >= c.maxRetries || !c.retryable(req.method, resp) {
+			return nil, apiErr
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// retryable reports whether a failed attempt may be repeated. A nil response is a
+// transport error. PATCH is only repeated when the server refused to process it.
+func (c *Client) retryable(method string, resp *http.Response) bool {
+	if resp == nil {
+		return method != http.MethodPatch
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusInternalServerError:
+		return method != http.MethodPatch
+	}
+	return false
+}
+
+// backoff returns the exponential delay before a retry, with up to 50% jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.retryWait << attempt
+	if wait <= 0 || wait > maxRetryWait {
+		wait = maxRetryWait
+	}
+	jitter, err := rand.Int(rand.Reader, big.NewInt(int64(wait/2)+1))
+	if err != nil {
+		return wait
+	}
+	return wait/2 + time.Duration(jitter.Int64())
+}
+
+// readError consumes an error response into an *Error
+func readError(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	e := &Error{StatusCode: resp.StatusCode, Body: body, RequestID: resp.Header.Get("X-Request-ID")}
+	var payload struct {
+		Error  string       `json:"error"`
+		Code   string       `json:"code"`
+		Fields []FieldError `json:"fields"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		e.Message = payload.Error
+		e.Code = payload.Code
+		e.Fields = payload.Fields
+	}
+	return e
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// newIdempotencyKey returns a random key identifying one logical POST request
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// idPath formats a resource path with numeric IDs
+func idPath(format string, ids ...uint) string {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,211 @@
+// Package client/organizations.go
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/4cecoder/saas/audit"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// MetricPoint is a single value in a bucketed time series
+type MetricPoint struct {
+	Bucket time.Time `json:"bucket"`
+	Value  int64     `json:"value"`
+}
+
+// SeatMetrics summarizes seat usage within an organization
+type SeatMetrics struct {
+	Total       int64   `json:"total"`
+	Active      int64   `json:"active"`
+	Invited     int64   `json:"invited"`
+	Inactive    int64   `json:"inactive"`
+	Utilization float64 `json:"utilization"`
+}
+
+// MetricOptions select the range and bucket size of a time series. Filters holds
+// metric-specific parameters such as activity_type or status.
+type MetricOptions struct {
+	// Bucket is day, week, or month
+	Bucket  string
+	From    time.Time
+	To      time.Time
+	Filters url.Values
+}
+
+// values encodes the options as query parameters
+func (o MetricOptions) values() url.Values {
+	v := ListOptions{From: o.From, To: o.To, Filters: o.Filters}.values()
+	if o.Bucket != "" {
+		v.Set("bucket", o.Bucket)
+	}
+	return v
+}
+
+// SIEMIntegration is the audit forwarding configuration of an organization
+type SIEMIntegration struct {
+	Type     models.SIEMType `json:"type"`
+	Endpoint string          `json:"endpoint"`
+	Token    string          `json:"token"`
+	Enabled  bool            `json:"enabled"`
+}
+
+// Retention is how many days an organization's logs are kept
+type Retention struct {
+	AuditLogRetentionDays    *int `json:"audit_log_retention_days"`
+	ActivityLogRetentionDays *int `json:"activity_log_retention_days"`
+}
+
+// ListOrganizations returns a page of organizations
+func (c *Client) ListOrganizations(ctx context.Context, opts ListOptions) (*Page[models.Organization], error) {
+	return list[models.Organization](c, ctx, "/organizations", opts)
+}
+
+// Organizations iterates over all organizations
+func (c *Client) Organizations(ctx context.Context, opts ListOptions) *Iterator[models.Organization] {
+	return iterate[models.Organization](c, ctx, "/organizations", opts)
+}
+
+// CreateOrganization creates a new organization
+func (c *Client) CreateOrganization(ctx context.Context, req dto.CreateOrganizationRequest) (*models.Organization, error) {
+	return call[models.Organization](c, ctx, request{method: "POST", path: "/organizations", body: req})
+}
+
+// GetOrganization retrieves an organization by ID, embedding the given relations
+func (c *Client) GetOrganization(ctx context.Context, id uint, include ...string) (*models.Organization, error) {
+	return call[models.Organization](c, ctx, request{method: "GET", path: idPath("/organizations/%d", id), query: ListOptions{Include: include}.values()})
+}
+
+// UpdateOrganization updates an organization
+func (c *Client) UpdateOrganization(ctx context.Context, id uint, req dto.UpdateOrganizationRequest) (*models.Organization, error) {
+	return call[models.Organization](c, ctx, request{method: "PUT", path: idPath("/organizations/%d", id), body: req})
+}
+
+// DeleteOrganization deletes an organization
+func (c *Client) DeleteOrganization(ctx context.Context, id uint) error {
+	return c.do(ctx, request{method: "DELETE", path: idPath("/organizations/%d", id)}, nil)
+}
+
+// ActiveUsersMetric returns the number of distinct active users per bucket
+func (c *Client) ActiveUsersMetric(ctx context.Context, orgID uint, opts MetricOptions) ([]MetricPoint, error) {
+	return c.metric(ctx, orgID, "active-users", opts)
+}
+
+// APIUsageMetric returns the number of API calls per bucket
+func (c *Client) APIUsageMetric(ctx context.Context, orgID uint, opts MetricOptions) ([]MetricPoint, error) {
+	return c.metric(ctx, orgID, "api-usage", opts)
+}
+
+// WorkflowThroughputMetric returns the number of finished workflow runs per bucket
+func (c *Client) WorkflowThroughputMetric(ctx context.Context, orgID uint, opts MetricOptions) ([]MetricPoint, error) {
+	return c.metric(ctx, orgID, "workflow-throughput", opts)
+}
+
+// SeatUtilizationMetric returns seat counts by status and the share of active seats
+func (c *Client) SeatUtilizationMetric(ctx context.Context, orgID uint) (*SeatMetrics, error) {
+	return call[SeatMetrics](c, ctx, request{method: "GET", path: idPath("/organizations/%d/metrics/seats", orgID)})
+}
+
+// metric fetches one of an organization's time series
+func (c *Client) metric(ctx context.Context, orgID uint, name string, opts MetricOptions) ([]MetricPoint, error) {
+	points, err := call[[]MetricPoint](c, ctx, request{method: "GET", path: idPath("/organizations/%d/metrics/", orgID) + name, query: opts.values()})
+	if err != nil {
+		return nil, err
+	}
+	return *points, nil
+}
+
+// ListAuditLogs returns a page of an organization's audit trail
+func (c *Client) ListAuditLogs(ctx context.Context, orgID uint, opts ListOptions) (*Page[models.AuditLog], error) {
+	return list[models.AuditLog](c, ctx, idPath("/organizations/%d/audit-logs", orgID), opts)
+}
+
+// AuditLogs iterates over an organization's audit trail
+func (c *Client) AuditLogs(ctx context.Context, orgID uint, opts ListOptions) *Iterator[models.AuditLog] {
+	return iterate[models.AuditLog](c, ctx, idPath("/organizations/%d/audit-logs", orgID), opts)
+}
+
+// ExportAuditLogs streams an organization's audit logs in the given format, csv or
+// ndjson. The caller must close the returned reader.
+func (c *Client) ExportAuditLogs(ctx context.Context, orgID uint, format string, from, to time.Time) (io.ReadCloser, error) {
+	query := ListOptions{From: from, To: to}.values()
+	if format != "" {
+		query.Set("format", format)
+	}
+
+	resp, err := c.send(ctx, request{method: "GET", path: idPath("/organizations/%d/audit-logs/export", orgID), query: query})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// VerifyAuditLogs checks an organization's audit hash chain for tampering
+func (c *Client) VerifyAuditLogs(ctx context.Context, orgID uint) (*audit.VerifyResult, error) {
+	return call[audit.VerifyResult](c, ctx, request{method: "GET", path: idPath("/organizations/%d/audit-logs/verify", orgID)})
+}
+
+// GetSIEMIntegration returns an organization's audit forwarding configuration
+func (c *Client) GetSIEMIntegration(ctx context.Context, orgID uint) (*models.SIEMIntegration, error) {
+	return call[models.SIEMIntegration](c, ctx, request{method: "GET", path: idPath("/organizations/%d/siem", orgID)})
+}
+
+// PutSIEMIntegration creates or replaces an organization's audit forwarding configuration
+func (c *Client) PutSIEMIntegration(ctx context.Context, orgID uint, integration SIEMIntegration) (*models.SIEMIntegration, error) {
+	return call[models.SIEMIntegration](c, ctx, request{method: "PUT", path: idPath("/organizations/%d/siem", orgID), body: integration})
+}
+
+// DeleteSIEMIntegration removes an organization's audit forwarding configuration
+func (c *Client) DeleteSIEMIntegration(ctx context.Context, orgID uint) error {
+	return c.do(ctx, request{method: "DELETE", path: idPath("/organizations/%d/siem", orgID)}, nil)
+}
+
+// UpdateRetention changes how long an organization's audit and activity logs
+// are kept; nil leaves a value unchanged
+func (c *Client) UpdateRetention(ctx context.Context, orgID uint, req dto.RetentionRequest) (*Retention, error) {
+	return call[Retention](c, ctx, request{method: "PUT", path: idPath("/organizations/%d/retention", orgID), body: req})
+}
+
+// GetOrganizationSettings returns an organization's settings
+func (c *Client) GetOrganizationSettings(ctx context.Context, orgID uint) (*models.OrganizationSettings, error) {
+	return call[models.OrganizationSettings](c, ctx, request{method: "GET", path: idPath("/organizations/%d/settings", orgID)})
+}
+
+// UpdateOrganizationSettings changes the given settings of an organization
+func (c *Client) UpdateOrganizationSettings(ctx context.Context, orgID uint, req dto.UpdateOrganizationSettingsRequest) (*models.OrganizationSettings, error) {
+	return call[models.OrganizationSettings](c, ctx, request{method: "PATCH", path: idPath("/organizations/%d/settings", orgID), body: req})
+}
+
+// UpdateOrganizationBranding changes the theme color, email footer and white-label domain of an organization
+func (c *Client) UpdateOrganizationBranding(ctx context.Context, orgID uint, req dto.UpdateBrandingRequest) (*models.OrganizationSettings, error) {
+	return call[models.OrganizationSettings](c, ctx, request{method: "PATCH", path: idPath("/organizations/%d/branding", orgID), body: req})
+}
+
+// GetBranding returns the branding of the organization whose white-label frontend is served on domain
+func (c *Client) GetBranding(ctx context.Context, domain string) (*dto.Branding, error) {
+	return call[dto.Branding](c, ctx, request{method: "GET", path: "/branding", query: url.Values{"domain": {domain}}})
+}
+
+// ListOrganizationActivity returns a page of the activity of an organization's members
+func (c *Client) ListOrganizationActivity(ctx context.Context, orgID uint, opts ListOptions) (*Page[models.ActivityLog], error) {
+	return list[models.ActivityLog](c, ctx, idPath("/organizations/%d/activity", orgID), opts)
+}
+
+// OrganizationActivity iterates over the activity of an organization's members
+func (c *Client) OrganizationActivity(ctx context.Context, orgID uint, opts ListOptions) *Iterator[models.ActivityLog] {
+	return iterate[models.ActivityLog](c, ctx, idPath("/organizations/%d/activity", orgID), opts)
+}
+
+// ListMyActivity returns a page of the authenticated user's own activity
+func (c *Client) ListMyActivity(ctx context.Context, opts ListOptions) (*Page[models.ActivityLog], error) {
+	return list[models.ActivityLog](c, ctx, "/me/activity", opts)
+}
+
+// MyActivity iterates over the authenticated user's own activity
+func (c *Client) MyActivity(ctx context.Context, opts ListOptions) *Iterator[models.ActivityLog] {
+	return iterate[models.ActivityLog](c, ctx, "/me/activity", opts)
+}
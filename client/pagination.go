@@ -0,0 +1,145 @@
+// Package client/pagination.go
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page is one page of a list endpoint
+type Page[T any] struct {
+	Data   []T   `json:"data"`
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+// ListOptions are the query parameters shared by list endpoints. Filters holds
+// endpoint-specific parameters such as status or user_id.
+type ListOptions struct {
+	Limit   int
+	Offset  int
+	Sort    string
+	Query   string
+	Include []string
+	From    time.Time
+	To      time.Time
+	Filters url.Values
+}
+
+// values encodes the options as query parameters
+func (o ListOptions) values() url.Values {
+	v := url.Values{}
+	for key, values := range o.Filters {
+		v[key] = append([]string(nil), values...)
+	}
+	if o.Limit > 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		v.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if o.Sort != "" {
+		v.Set("sort", o.Sort)
+	}
+	if o.Query != "" {
+		v.Set("q", o.Query)
+	}
+	if len(o.Include) > 0 {
+		v.Set("include", strings.Join(o.Include, ","))
+	}
+	if !o.From.IsZero() {
+		v.Set("from", o.From.Format(time.RFC3339))
+	}
+	if !o.To.IsZero() {
+		v.Set("to", o.To.Format(time.RFC3339))
+	}
+	return v
+}
+
+// Iterator walks every item of a list endpoint, fetching pages as needed:
+//
+//	it := c.Users(ctx, client.ListOptions{})
+//	for it.Next() {
+//		user := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator[T any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, opts ListOptions) (*Page[T], error)
+	opts  ListOptions
+
+	items []T
+	pos   int
+	done  bool
+	err   error
+}
+
+// newIterator creates an iterator starting at the options' offset
+func newIterator[T any](ctx context.Context, opts ListOptions, fetch func(ctx context.Context, opts ListOptions) (*Page[T], error)) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, fetch: fetch, opts: opts, pos: -1}
+}
+
+// Next advances to the next item, reporting false when there are no more or a
+// request failed
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.pos+1 < len(it.items) {
+		it.pos++
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	page, err := it.fetch(it.ctx, it.opts)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.items, it.pos = page.Data, 0
+	it.opts.Offset = page.Offset + len(page.Data)
+	if len(page.Data) == 0 || int64(it.opts.Offset) >= page.Total {
+		it.done = true
+	}
+	return len(it.items) > 0
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	return it.items[it.pos]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All collects the remaining items
+func (it *Iterator[T]) All() ([]T, error) {
+	var all []T
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
+
+// list fetches one page of a list endpoint
+func list[T any](c *Client, ctx context.Context, path string, opts ListOptions) (*Page[T], error) {
+	return call[Page[T]](c, ctx, request{method: "GET", path: path, query: opts.values()})
+}
+
+// iterate returns an iterator over a list endpoint
+func iterate[T any](c *Client, ctx context.Context, path string, opts ListOptions) *Iterator[T] {
+	return newIterator(ctx, opts, func(ctx context.Context, opts ListOptions) (*Page[T], error) {
+		return list[T](c, ctx, path, opts)
+	})
+}
@@ -0,0 +1,126 @@
+// Package client/reports.go
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// ReportOutput is the result of running a report with a file format. Exactly one of
+// Run (the run failed), Export (large results are exported in the background), or
+// File is set; the caller must close File.
+type ReportOutput struct {
+	Run      *models.ReportRun
+	Export   *models.ReportExport
+	File     io.ReadCloser
+	Filename string
+}
+
+// ListReports returns a page of reports
+func (c *Client) ListReports(ctx context.Context, opts ListOptions) (*Page[dto.Report], error) {
+	return list[dto.Report](c, ctx, "/reports", opts)
+}
+
+// Reports iterates over all reports
+func (c *Client) Reports(ctx context.Context, opts ListOptions) *Iterator[dto.Report] {
+	return iterate[dto.Report](c, ctx, "/reports", opts)
+}
+
+// CreateReport creates a new report
+func (c *Client) CreateReport(ctx context.Context, req dto.CreateReportRequest) (*dto.Report, error) {
+	return call[dto.Report](c, ctx, request{method: "POST", path: "/reports", body: req})
+}
+
+// GetReport retrieves a report by ID
+func (c *Client) GetReport(ctx context.Context, id uint) (*dto.Report, error) {
+	return call[dto.Report](c, ctx, request{method: "GET", path: idPath("/reports/%d", id)})
+}
+
+// UpdateReport replaces a report definition
+func (c *Client) UpdateReport(ctx context.Context, id uint, req dto.UpdateReportRequest) (*dto.Report, error) {
+	return call[dto.Report](c, ctx, request{method: "PUT", path: idPath("/reports/%d", id), body: req})
+}
+
+// DeleteReport deletes a report
+func (c *Client) DeleteReport(ctx context.Context, id uint) error {
+	return c.do(ctx, request{method: "DELETE", path: idPath("/reports/%d", id)}, nil)
+}
+
+// RunReport executes a report and returns the stored run
+func (c *Client) RunReport(ctx context.Context, id uint) (*models.ReportRun, error) {
+	return call[models.ReportRun](c, ctx, request{method: "POST", path: idPath("/reports/%d/run", id)})
+}
+
+// RunReportFile executes a report and returns its results in the given format
+func (c *Client) RunReportFile(ctx context.Context, id uint, format string) (*ReportOutput, error) {
+	resp, err := c.send(ctx, request{method: "POST", path: idPath("/reports/%d/run", id), query: url.Values{"format": {format}}})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ReportOutput{}
+	switch {
+	case resp.StatusCode == http.StatusAccepted:
+		defer resp.Body.Close()
+		out.Export = &models.ReportExport{}
+		err = json.NewDecoder(resp.Body).Decode(out.Export)
+	case strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json"):
+		defer resp.Body.Close()
+		out.Run = &models.ReportRun{}
+		err = json.NewDecoder(resp.Body).Decode(out.Run)
+	default:
+		out.File = resp.Body
+		out.Filename = attachmentName(resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListReportRuns returns a page of a report's run history, without result rows
+func (c *Client) ListReportRuns(ctx context.Context, reportID uint, opts ListOptions) (*Page[models.ReportRun], error) {
+	return list[models.ReportRun](c, ctx, idPath("/reports/%d/runs", reportID), opts)
+}
+
+// ReportRuns iterates over a report's run history
+func (c *Client) ReportRuns(ctx context.Context, reportID uint, opts ListOptions) *Iterator[models.ReportRun] {
+	return iterate[models.ReportRun](c, ctx, idPath("/reports/%d/runs", reportID), opts)
+}
+
+// GetReportRun retrieves a single run of a report including its results
+func (c *Client) GetReportRun(ctx context.Context, reportID, runID uint) (*models.ReportRun, error) {
+	return call[models.ReportRun](c, ctx, request{method: "GET", path: idPath("/reports/%d/runs/%d", reportID, runID)})
+}
+
+// GetReportExport returns the status of a background report export
+func (c *Client) GetReportExport(ctx context.Context, reportID, exportID uint) (*models.ReportExport, error) {
+	return call[models.ReportExport](c, ctx, request{method: "GET", path: idPath("/reports/%d/exports/%d", reportID, exportID)})
+}
+
+// DownloadReportExport streams a finished report export. The caller must close the
+// returned reader.
+func (c *Client) DownloadReportExport(ctx context.Context, reportID, exportID uint) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, request{method: "GET", path: idPath("/reports/%d/exports/%d/download", reportID, exportID)})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// attachmentName returns the filename of a Content-Disposition header
+func attachmentName(resp *http.Response) string {
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
@@ -0,0 +1,87 @@
+// Package client/search.go
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/4cecoder/saas/graphql"
+	"github.com/4cecoder/saas/search"
+)
+
+// SearchOptions narrow a search
+type SearchOptions struct {
+	// Types limits results to user and/or organization hits
+	Types          []string
+	OrganizationID uint
+	Limit          int
+}
+
+// BatchRequest is one sub-request of a batch
+type BatchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// BatchResponse is the result of one sub-request
+type BatchResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Search runs a ranked full-text search across users and organizations
+func (c *Client) Search(ctx context.Context, text string, opts SearchOptions) ([]search.Hit, error) {
+	query := url.Values{"q": {text}}
+	if len(opts.Types) > 0 {
+		query.Set("type", strings.Join(opts.Types, ","))
+	}
+	if opts.OrganizationID > 0 {
+		query.Set("organization_id", strconv.FormatUint(uint64(opts.OrganizationID), 10))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	var out struct {
+		Data []search.Hit `json:"data"`
+	}
+	if err := c.do(ctx, request{method: "GET", path: "/search", query: query}, &out); err != nil {
+		return nil, err
+	}
+	return out.Data, nil
+}
+
+// ReindexSearch starts rebuilding the external search index for the given types, or
+// for all of them
+func (c *Client) ReindexSearch(ctx context.Context, types ...string) error {
+	query := url.Values{}
+	if len(types) > 0 {
+		query.Set("type", strings.Join(types, ","))
+	}
+	return c.do(ctx, request{method: "POST", path: "/search/reindex", query: query}, nil)
+}
+
+// GraphQL executes a GraphQL query. Field errors are reported in the response, not
+// as an error.
+func (c *Client) GraphQL(ctx context.Context, req graphql.Request) (*graphql.Response, error) {
+	return call[graphql.Response](c, ctx, request{method: "POST", path: "/graphql", body: req})
+}
+
+// Batch executes sub-requests in order in one round trip. With stopOnError the
+// remaining sub-requests are skipped after the first failure.
+func (c *Client) Batch(ctx context.Context, requests []BatchRequest, stopOnError bool) ([]BatchResponse, error) {
+	body := map[string]interface{}{"requests": requests, "stop_on_error": stopOnError}
+
+	var out struct {
+		Responses []BatchResponse `json:"responses"`
+	}
+	if err := c.do(ctx, request{method: "POST", path: "/batch", body: body}, &out); err != nil {
+		return nil, err
+	}
+	return out.Responses, nil
+}
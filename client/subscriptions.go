@@ -0,0 +1,39 @@
+// Package client/subscriptions.go
+package client
+
+import (
+	"context"
+
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// ListSubscriptions returns a page of subscriptions
+func (c *Client) ListSubscriptions(ctx context.Context, opts ListOptions) (*Page[models.Subscription], error) {
+	return list[models.Subscription](c, ctx, "/subscriptions", opts)
+}
+
+// Subscriptions iterates over all subscriptions
+func (c *Client) Subscriptions(ctx context.Context, opts ListOptions) *Iterator[models.Subscription] {
+	return iterate[models.Subscription](c, ctx, "/subscriptions", opts)
+}
+
+// CreateSubscription creates a new subscription
+func (c *Client) CreateSubscription(ctx context.Context, req dto.CreateSubscriptionRequest) (*models.Subscription, error) {
+	return call[models.Subscription](c, ctx, request{method: "POST", path: "/subscriptions", body: req})
+}
+
+// GetSubscription retrieves a subscription by ID, embedding the given relations
+func (c *Client) GetSubscription(ctx context.Context, id uint, include ...string) (*models.Subscription, error) {
+	return call[models.Subscription](c, ctx, request{method: "GET", path: idPath("/subscriptions/%d", id), query: ListOptions{Include: include}.values()})
+}
+
+// UpdateSubscription updates a subscription
+func (c *Client) UpdateSubscription(ctx context.Context, id uint, req dto.UpdateSubscriptionRequest) (*models.Subscription, error) {
+	return call[models.Subscription](c, ctx, request{method: "PUT", path: idPath("/subscriptions/%d", id), body: req})
+}
+
+// DeleteSubscription deletes a subscription
+func (c *Client) DeleteSubscription(ctx context.Context, id uint) error {
+	return c.do(ctx, request{method: "DELETE", path: idPath("/subscriptions/%d", id)}, nil)
+}
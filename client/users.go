@@ -0,0 +1,110 @@
+// Package client/users.go
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// BulkResult is the outcome of one item of a bulk request
+type BulkResult struct {
+	Index  int             `json:"index"`
+	Status int             `json:"status"`
+	ID     uint            `json:"id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Fields []FieldError    `json:"fields,omitempty"`
+}
+
+// BulkResponse reports the outcome of every item of a bulk request
+type BulkResponse struct {
+	Results   []BulkResult `json:"results"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+}
+
+// BulkOptions control how a bulk request is applied
+type BulkOptions struct {
+	// Atomic rolls back every item when one of them fails
+	Atomic bool
+}
+
+// values encodes the options as query parameters
+func (o BulkOptions) values() url.Values {
+	v := url.Values{}
+	if o.Atomic {
+		v.Set("atomic", "true")
+	}
+	return v
+}
+
+// ListUsers returns a page of users
+func (c *Client) ListUsers(ctx context.Context, opts ListOptions) (*Page[models.User], error) {
+	return list[models.User](c, ctx, "/users", opts)
+}
+
+// Users iterates over all users
+func (c *Client) Users(ctx context.Context, opts ListOptions) *Iterator[models.User] {
+	return iterate[models.User](c, ctx, "/users", opts)
+}
+
+// CreateUser creates a new user
+func (c *Client) CreateUser(ctx context.Context, req dto.CreateUserRequest) (*models.User, error) {
+	return call[models.User](c, ctx, request{method: "POST", path: "/users", body: req})
+}
+
+// GetUser retrieves a user by ID, embedding the given relations
+func (c *Client) GetUser(ctx context.Context, id uint, include ...string) (*models.User, error) {
+	return call[models.User](c, ctx, request{method: "GET", path: idPath("/users/%d", id), query: ListOptions{Include: include}.values()})
+}
+
+// UpdateUser updates a user
+func (c *Client) UpdateUser(ctx context.Context, id uint, req dto.UpdateUserRequest) (*models.User, error) {
+	return call[models.User](c, ctx, request{method: "PUT", path: idPath("/users/%d", id), body: req})
+}
+
+// DeleteUser deletes a user
+func (c *Client) DeleteUser(ctx context.Context, id uint) error {
+	return c.do(ctx, request{method: "DELETE", path: idPath("/users/%d", id)}, nil)
+}
+
+// BulkCreateUsers creates many users in one request
+func (c *Client) BulkCreateUsers(ctx context.Context, users []dto.CreateUserRequest, opts BulkOptions) (*BulkResponse, error) {
+	return c.bulk(ctx, "POST", "/users/bulk", users, opts)
+}
+
+// BulkUpdateUsers applies partial updates to many users in one request; each item
+// names the user by its "id" and only the fields it contains are changed
+func (c *Client) BulkUpdateUsers(ctx context.Context, users []map[string]interface{}, opts BulkOptions) (*BulkResponse, error) {
+	return c.bulk(ctx, "PATCH", "/users/bulk", users, opts)
+}
+
+// BulkDeleteUsers deletes many users in one request
+func (c *Client) BulkDeleteUsers(ctx context.Context, ids []uint, opts BulkOptions) (*BulkResponse, error) {
+	return c.bulk(ctx, "DELETE", "/users/bulk", map[string][]uint{"ids": ids}, opts)
+}
+
+// BulkCreateSeats creates many seats in one request
+func (c *Client) BulkCreateSeats(ctx context.Context, seats []dto.CreateSeatRequest, opts BulkOptions) (*BulkResponse, error) {
+	return c.bulk(ctx, "POST", "/seats/bulk", seats, opts)
+}
+
+// BulkUpdateSeats applies partial updates to many seats in one request; each item
+// names the seat by its "id" and only the fields it contains are changed
+func (c *Client) BulkUpdateSeats(ctx context.Context, seats []map[string]interface{}, opts BulkOptions) (*BulkResponse, error) {
+	return c.bulk(ctx, "PATCH", "/seats/bulk", seats, opts)
+}
+
+// BulkDeleteSeats deletes many seats in one request
+func (c *Client) BulkDeleteSeats(ctx context.Context, ids []uint, opts BulkOptions) (*BulkResponse, error) {
+	return c.bulk(ctx, "DELETE", "/seats/bulk", map[string][]uint{"ids": ids}, opts)
+}
+
+// bulk sends a bulk request
+func (c *Client) bulk(ctx context.Context, method, path string, body interface{}, opts BulkOptions) (*BulkResponse, error) {
+	return call[BulkResponse](c, ctx, request{method: method, path: path, query: opts.values(), body: body})
+}
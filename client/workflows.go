@@ -0,0 +1,165 @@
+// Package client/workflows.go
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/workflow"
+)
+
+// TimelineEvent is one entry in a workflow run's history
+type TimelineEvent struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Step     *int      `json:"step,omitempty"`
+	StepName string    `json:"step_name,omitempty"`
+	UserID   uint      `json:"user_id,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// RunTimeline is a workflow run with its history
+type RunTimeline struct {
+	Run      models.WorkflowRun `json:"run"`
+	Timeline []TimelineEvent    `json:"timeline"`
+}
+
+// ListWorkflows returns a page of workflows
+func (c *Client) ListWorkflows(ctx context.Context, opts ListOptions) (*Page[dto.Workflow], error) {
+	return list[dto.Workflow](c, ctx, "/workflows", opts)
+}
+
+// Workflows iterates over all workflows
+func (c *Client) Workflows(ctx context.Context, opts ListOptions) *Iterator[dto.Workflow] {
+	return iterate[dto.Workflow](c, ctx, "/workflows", opts)
+}
+
+// CreateWorkflow creates a new workflow
+func (c *Client) CreateWorkflow(ctx context.Context, req dto.CreateWorkflowRequest) (*dto.Workflow, error) {
+	return call[dto.Workflow](c, ctx, request{method: "POST", path: "/workflows", body: req})
+}
+
+// GetWorkflow retrieves a workflow by ID
+func (c *Client) GetWorkflow(ctx context.Context, id uint) (*dto.Workflow, error) {
+	return call[dto.Workflow](c, ctx, request{method: "GET", path: idPath("/workflows/%d", id)})
+}
+
+// UpdateWorkflow replaces a workflow's definition
+func (c *Client) UpdateWorkflow(ctx context.Context, id uint, req dto.UpdateWorkflowRequest) (*dto.Workflow, error) {
+	return call[dto.Workflow](c, ctx, request{method: "PUT", path: idPath("/workflows/%d", id), body: req})
+}
+
+// DeleteWorkflow deletes a workflow
+func (c *Client) DeleteWorkflow(ctx context.Context, id uint) error {
+	return c.do(ctx, request{method: "DELETE", path: idPath("/workflows/%d", id)}, nil)
+}
+
+// StartWorkflowRun starts a new run of a workflow with the given input
+func (c *Client) StartWorkflowRun(ctx context.Context, workflowID uint, input models.JSONMap) (*models.WorkflowRun, error) {
+	if input == nil {
+		input = models.JSONMap{}
+	}
+	return call[models.WorkflowRun](c, ctx, request{method: "POST", path: idPath("/workflows/%d/runs", workflowID), body: input})
+}
+
+// ListWorkflowRuns returns a page of a workflow's runs
+func (c *Client) ListWorkflowRuns(ctx context.Context, workflowID uint, opts ListOptions) (*Page[models.WorkflowRun], error) {
+	return list[models.WorkflowRun](c, ctx, idPath("/workflows/%d/runs", workflowID), opts)
+}
+
+// WorkflowRuns iterates over a workflow's runs
+func (c *Client) WorkflowRuns(ctx context.Context, workflowID uint, opts ListOptions) *Iterator[models.WorkflowRun] {
+	return iterate[models.WorkflowRun](c, ctx, idPath("/workflows/%d/runs", workflowID), opts)
+}
+
+// ListWorkflowVersions returns a page of a workflow's versions, newest first
+func (c *Client) ListWorkflowVersions(ctx context.Context, workflowID uint, opts ListOptions) (*Page[models.WorkflowVersion], error) {
+	return list[models.WorkflowVersion](c, ctx, idPath("/workflows/%d/versions", workflowID), opts)
+}
+
+// WorkflowVersions iterates over a workflow's versions, newest first
+func (c *Client) WorkflowVersions(ctx context.Context, workflowID uint, opts ListOptions) *Iterator[models.WorkflowVersion] {
+	return iterate[models.WorkflowVersion](c, ctx, idPath("/workflows/%d/versions", workflowID), opts)
+}
+
+// GetWorkflowVersion retrieves one version of a workflow
+func (c *Client) GetWorkflowVersion(ctx context.Context, workflowID uint, version int) (*models.WorkflowVersion, error) {
+	return call[models.WorkflowVersion](c, ctx, request{method: "GET", path: idPath("/workflows/%d/versions/", workflowID) + strconv.Itoa(version)})
+}
+
+// DiffWorkflowVersions compares a version with an earlier one; from zero compares
+// with the version before it
+func (c *Client) DiffWorkflowVersions(ctx context.Context, workflowID uint, version, from int) (*workflow.VersionDiff, error) {
+	query := url.Values{}
+	if from > 0 {
+		query.Set("from", strconv.Itoa(from))
+	}
+	path := idPath("/workflows/%d/versions/", workflowID) + strconv.Itoa(version) + "/diff"
+	return call[workflow.VersionDiff](c, ctx, request{method: "GET", path: path, query: query})
+}
+
+// RollbackWorkflow restores an earlier version's definition as the workflow's newest version
+func (c *Client) RollbackWorkflow(ctx context.Context, workflowID uint, version int) (*dto.Workflow, error) {
+	path := idPath("/workflows/%d/versions/", workflowID) + strconv.Itoa(version) + "/rollback"
+	return call[dto.Workflow](c, ctx, request{method: "POST", path: path})
+}
+
+// GetWorkflowRun retrieves a workflow run with its step statuses
+func (c *Client) GetWorkflowRun(ctx context.Context, runID uint) (*models.WorkflowRun, error) {
+	return call[models.WorkflowRun](c, ctx, request{method: "GET", path: idPath("/workflow-runs/%d", runID)})
+}
+
+// GetWorkflowRunTimeline returns a run's history as a chronological list of events
+func (c *Client) GetWorkflowRunTimeline(ctx context.Context, runID uint) (*RunTimeline, error) {
+	return call[RunTimeline](c, ctx, request{method: "GET", path: idPath("/workflow-runs/%d/timeline", runID)})
+}
+
+// CancelWorkflowRun cancels a running or waiting workflow run
+func (c *Client) CancelWorkflowRun(ctx context.Context, runID uint) (*models.WorkflowRun, error) {
+	return call[models.WorkflowRun](c, ctx, request{method: "POST", path: idPath("/workflow-runs/%d/cancel", runID)})
+}
+
+// RetryWorkflowRun retries a failed workflow run from its failed step, or from the
+// step at fromStep when it is not nil
+func (c *Client) RetryWorkflowRun(ctx context.Context, runID uint, fromStep *int) (*models.WorkflowRun, error) {
+	query := url.Values{}
+	if fromStep != nil {
+		query.Set("from_step", strconv.Itoa(*fromStep))
+	}
+	return call[models.WorkflowRun](c, ctx, request{method: "POST", path: idPath("/workflow-runs/%d/retry", runID), query: query})
+}
+
+// ListMyApprovals returns a page of the approvals assigned to the authenticated user,
+// pending ones unless a status filter is given
+func (c *Client) ListMyApprovals(ctx context.Context, opts ListOptions) (*Page[models.WorkflowApproval], error) {
+	return list[models.WorkflowApproval](c, ctx, "/me/approvals", opts)
+}
+
+// MyApprovals iterates over the approvals assigned to the authenticated user
+func (c *Client) MyApprovals(ctx context.Context, opts ListOptions) *Iterator[models.WorkflowApproval] {
+	return iterate[models.WorkflowApproval](c, ctx, "/me/approvals", opts)
+}
+
+// ApproveWorkflowApproval approves a pending approval, resuming its workflow run
+func (c *Client) ApproveWorkflowApproval(ctx context.Context, approvalID uint, comment string) (*models.WorkflowApproval, error) {
+	body := map[string]string{"comment": comment}
+	return call[models.WorkflowApproval](c, ctx, request{method: "POST", path: idPath("/workflow-approvals/%d/approve", approvalID), body: body})
+}
+
+// RejectWorkflowApproval rejects a pending approval, failing its workflow run
+func (c *Client) RejectWorkflowApproval(ctx context.Context, approvalID uint, comment string) (*models.WorkflowApproval, error) {
+	body := map[string]string{"comment": comment}
+	return call[models.WorkflowApproval](c, ctx, request{method: "POST", path: idPath("/workflow-approvals/%d/reject", approvalID), body: body})
+}
+
+// DelegateWorkflowApproval hands a pending approval over to another organization
+// member and returns the new approval
+func (c *Client) DelegateWorkflowApproval(ctx context.Context, approvalID, userID uint, comment string) (*models.WorkflowApproval, error) {
+	body := map[string]interface{}{"user_id": userID, "comment": comment}
+	return call[models.WorkflowApproval](c, ctx, request{method: "POST", path: idPath("/workflow-approvals/%d/delegate", approvalID), body: body})
+}
@@ -0,0 +1,279 @@
+// Package main/cmd/openapi/generate.go
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// operationDoc is what a handler's doc comment says about its endpoint
+type operationDoc struct {
+	summary     string
+	description []string
+	body        string
+	success     []string
+	queries     [][]string
+}
+
+// generate builds the OpenAPI document of the module at root, for the API served under
+// the server URL
+func generate(root, server string) (map[string]interface{}, error) {
+	pkgs, err := parsePackages(root)
+	if err != nil {
+		return nil, err
+	}
+
+	register := "register" + strings.ToUpper(strings.TrimPrefix(server, "/"))
+	routes := findRoutes(pkgs["app"], register)
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no routes registered by %s", register)
+	}
+
+	docs := handlerDocs(pkgs["handlers"])
+	types := newSchemas(pkgs)
+	paths := map[string]map[string]interface{}{}
+
+	for _, r := range routes {
+		doc, ok := docs[r.Handler]
+		if !ok {
+			return nil, fmt.Errorf("route %s %s: no handler named %s", r.Method, r.Path, r.Handler)
+		}
+
+		op, err := operation(r, doc, types)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Handler, err)
+		}
+
+		path, _ := openAPIPath(r.Path)
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(r.Method)] = op
+	}
+
+	components := map[string]interface{}{
+		"schemas": types.components,
+		"securitySchemes": map[string]interface{}{
+			"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+		},
+	}
+	types.components["Error"] = schema{
+		"type":       "object",
+		"properties": map[string]schema{
+			"error":      {"type": "string"},
+			"code":       {"type": "string"},
+			"request_id": {"type": "string"},
+		},
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   "SaaS API",
+			"version": "1.0.0",
+		},
+		"servers":    []map[string]string{{"url": server}},
+		"paths":      paths,
+		"components": components,
+	}, nil
+}
+
+// operation builds the OpenAPI operation of a route
+func operation(r route, doc operationDoc, types *schemas) (map[string]interface{}, error) {
+	path, pathParams := openAPIPath(r.Path)
+	tag := strings.Split(strings.TrimPrefix(path, "/"), "/")[0]
+
+	op := map[string]interface{}{
+		"operationId": r.Handler,
+		"summary":     doc.summary,
+		"tags":        []string{tag},
+	}
+	if len(doc.description) > 0 {
+		op["description"] = strings.Join(doc.description, "\n")
+	}
+	if r.Deprecated {
+		op["deprecated"] = true
+	}
+	if r.Secured {
+		op["security"] = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	params := []map[string]interface{}{}
+	for _, p := range pathParams {
+		typ := "string"
+		if p == "id" || strings.HasSuffix(p, "_id") || p == "version" {
+			typ = "integer"
+		}
+		params = append(params, map[string]interface{}{
+			"name": p, "in": "path", "required": true, "schema": schema{"type": typ},
+		})
+	}
+	for _, q := range doc.queries {
+		param := map[string]interface{}{"name": q[0], "in": "query", "schema": basicSchema(q[1])}
+		if param["schema"] == nil {
+			return nil, fmt.Errorf("query parameter %s: unknown type %s", q[0], q[1])
+		}
+		if len(q) > 2 {
+			param["description"] = strings.Join(q[2:], " ")
+		}
+		params = append(params, param)
+	}
+
+	responses := map[string]interface{}{
+		"default": response("Error", schema{"$ref": "#/components/schemas/Error"}),
+	}
+	for _, s := range doc.success {
+		fields := strings.Fields(s)
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			return nil, fmt.Errorf("invalid status in @Success %s", s)
+		}
+		if len(fields) == 1 {
+			responses[fields[0]] = map[string]string{"description": "Success"}
+			continue
+		}
+
+		body, err := types.parse(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		responses[fields[0]] = response("Success", body)
+		if strings.HasPrefix(fields[1], "Page[") {
+			params = append(params, pageParams()...)
+		}
+	}
+	if len(doc.success) == 0 {
+		responses["200"] = map[string]string{"description": "Success"}
+	}
+	op["responses"] = responses
+
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if doc.body != "" && r.Method != "GET" {
+		body, err := types.parse(doc.body)
+		if err != nil {
+			return nil, err
+		}
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": body}},
+		}
+	}
+	return op, nil
+}
+
+// response describes a JSON response
+func response(description string, body schema) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": body}},
+	}
+}
+
+// pageParams are the query parameters every offset-paginated list accepts
+func pageParams() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"name": "limit", "in": "query", "schema": schema{"type": "integer", "maximum": 200}},
+		{"name": "offset", "in": "query", "schema": schema{"type": "integer", "minimum": 0}},
+	}
+}
+
+// handlerDocs parses the doc comments of the Handler methods
+func handlerDocs(files []*ast.File) map[string]operationDoc {
+	docs := map[string]operationDoc{}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || !fn.Name.IsExported() {
+				continue
+			}
+
+			var doc operationDoc
+			var summary []string
+			for _, line := range strings.Split(strings.TrimSpace(fn.Doc.Text()), "\n") {
+				directive, rest, _ := strings.Cut(line, " ")
+				switch {
+				case directive == "@Body":
+					doc.body = rest
+				case directive == "@Success":
+					doc.success = append(doc.success, rest)
+				case directive == "@Query":
+					doc.queries = append(doc.queries, strings.Fields(rest))
+				case len(doc.description) == 0 && line != "":
+					summary = append(summary, line)
+				default:
+					doc.description = append(doc.description, line)
+				}
+			}
+
+			// The first paragraph, minus the leading method name, is the summary
+			doc.summary = strings.TrimPrefix(strings.Join(summary, " "), fn.Name.Name+" ")
+			if doc.summary != "" {
+				doc.summary = strings.ToUpper(doc.summary[:1]) + doc.summary[1:]
+			}
+			doc.description = trimBlank(doc.description)
+			docs[fn.Name.Name] = doc
+		}
+	}
+	return docs
+}
+
+// trimBlank drops leading and trailing empty lines
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// parsePackages parses the non-test Go files of every package in the module, keyed by package name
+func parsePackages(root string) (map[string][]*ast.File, error) {
+	fset := token.NewFileSet()
+	pkgs := map[string][]*ast.File{}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "cmd") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		pkgs[f.Name.Name] = append(pkgs[f.Name.Name], f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, files := range pkgs {
+		sort.Slice(files, func(i, j int) bool { return fset.File(files[i].Pos()).Name() < fset.File(files[j].Pos()).Name() })
+	}
+	return pkgs, nil
+}
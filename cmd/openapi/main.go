@@ -0,0 +1,56 @@
+// Package main/cmd/openapi/main.go
+//
+// Command openapi generates the OpenAPI document served at /docs. Paths come from the
+// route registrations in the register function of the documented API version, such as
+// registerV1 for /v1, summaries and descriptions from the doc
+// comments of the handlers, and request and response schemas from annotations on
+// those comments:
+//
+//	// @Body models.User
+//	// @Success 201 models.User
+//	// @Success 200 Page[models.User]
+//	// @Query q string Text to search for
+//
+// Run it through go generate ./docs; with -check it fails if the document is stale.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	root := flag.String("root", ".", "module root directory")
+	out := flag.String("o", "docs/openapi.json", "output file")
+	server := flag.String("server", "/v1", "path prefix of the documented API version")
+	check := flag.Bool("check", false, "fail if the output file is out of date instead of writing it")
+	flag.Parse()
+
+	spec, err := generate(*root, *server)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	raw, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	raw = append(raw, '\n')
+
+	if *check {
+		current, err := os.ReadFile(*out)
+		if err != nil || !bytes.Equal(current, raw) {
+			fmt.Fprintf(os.Stderr, "%s is out of date, run go generate ./docs\n", *out)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := os.WriteFile(*out, raw, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
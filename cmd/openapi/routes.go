@@ -0,0 +1,163 @@
+// Package main/cmd/openapi/routes.go
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// methods lists the router methods that register routes
+var methods = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+// route is one registered endpoint
+type route struct {
+	Method     string
+	Path       string
+	Handler    string
+	Secured    bool
+	Deprecated bool
+}
+
+// group is a router or route group with its path prefix
+type group struct {
+	prefix     string
+	secured    bool
+	deprecated bool
+}
+
+// findRoutes collects the routes registered by the named function, resolving the
+// prefixes and middleware of route groups
+func findRoutes(files []*ast.File, fn string) []route {
+	groups := map[string]group{}
+	var routes []route
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if d, ok := decl.(*ast.FuncDecl); ok && d.Name.Name == fn {
+				routes = append(routes, inspectRoutes(d.Body, groups)...)
+			}
+		}
+	}
+	return routes
+}
+
+// inspectRoutes collects the routes registered in a function body
+func inspectRoutes(body *ast.BlockStmt, groups map[string]group) []route {
+	var routes []route
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+				return true
+			}
+			name, ok := n.Lhs[0].(*ast.Ident)
+			if !ok {
+				return true
+			}
+			call, ok := n.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			recv, method := selector(call.Fun)
+			if method != "Group" || len(call.Args) == 0 {
+				return true
+			}
+			parent := groups[recv]
+			groups[name.Name] = group{
+				prefix:     parent.prefix + stringLit(call.Args[0]),
+				secured:    parent.secured || authenticated(call.Args[1:]),
+				deprecated: parent.deprecated || deprecated(call.Args[1:]),
+			}
+			return false
+
+		case *ast.CallExpr:
+			recv, method := selector(n.Fun)
+			if !methods[method] || len(n.Args) < 2 {
+				return true
+			}
+			path, ok := n.Args[0].(*ast.BasicLit)
+			if !ok || path.Kind != token.STRING {
+				return true
+			}
+			h, name := selector(n.Args[len(n.Args)-1])
+			if h == "" {
+				return true
+			}
+			g := groups[recv]
+			middleware := n.Args[1 : len(n.Args)-1]
+			routes = append(routes, route{
+				Method:     method,
+				Path:       g.prefix + stringLit(path),
+				Handler:    name,
+				Secured:    g.secured || authenticated(middleware),
+				Deprecated: g.deprecated || deprecated(middleware),
+			})
+		}
+		return true
+	})
+	return routes
+}
+
+// authenticated reports whether the middleware arguments include an auth check
+func authenticated(args []ast.Expr) bool {
+	for _, arg := range args {
+		if call, ok := arg.(*ast.CallExpr); ok {
+			arg = call.Fun
+		}
+		if pkg, name := selector(arg); pkg == "auth" && (name == "IsUserOrAdmin" || name == "AuthMiddleware") {
+			return true
+		}
+	}
+	return false
+}
+
+// deprecated reports whether the middleware arguments include a deprecation notice
+func deprecated(args []ast.Expr) bool {
+	for _, arg := range args {
+		if call, ok := arg.(*ast.CallExpr); ok {
+			if pkg, name := selector(call.Fun); pkg == "middleware" && name == "Deprecated" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selector splits x.Name into its parts, returning empty strings for other expressions
+func selector(e ast.Expr) (string, string) {
+	sel, ok := e.(*ast.SelectorExpr)
+	if !ok {
+		return "", ""
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", ""
+	}
+	return x.Name, sel.Sel.Name
+}
+
+// stringLit returns the value of a string literal, or "" for other expressions
+func stringLit(e ast.Expr) string {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, _ := strconv.Unquote(lit.Value)
+	return s
+}
+
+// openAPIPath converts gin path parameters like :id and *path to {id} and {path}, and
+// returns the parameter names
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			params = append(params, s[1:])
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
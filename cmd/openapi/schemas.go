@@ -0,0 +1,228 @@
+// Package main/cmd/openapi/schemas.go
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// schema is an OpenAPI schema object
+type schema map[string]interface{}
+
+// typeDecl is a named type declared in one of the module's packages
+type typeDecl struct {
+	pkg  string
+	spec *ast.TypeSpec
+}
+
+// schemas converts Go types to OpenAPI schemas, collecting the components they reference
+type schemas struct {
+	types      map[string]typeDecl
+	components map[string]schema
+}
+
+// newSchemas indexes the named types of the given packages by "pkg.Name"
+func newSchemas(pkgs map[string][]*ast.File) *schemas {
+	s := &schemas{types: map[string]typeDecl{}, components: map[string]schema{}}
+	for pkg, files := range pkgs {
+		for _, f := range files {
+			for _, decl := range f.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						s.types[pkg+"."+ts.Name.Name] = typeDecl{pkg: pkg, spec: ts}
+					}
+				}
+			}
+		}
+	}
+	return s
+}
+
+// parse converts an annotation type such as "models.User", "[]models.User" or
+// "Page[models.User]" into a schema
+func (s *schemas) parse(expr string) (schema, error) {
+	switch {
+	case strings.HasPrefix(expr, "[]"):
+		items, err := s.parse(expr[2:])
+		if err != nil {
+			return nil, err
+		}
+		return schema{"type": "array", "items": items}, nil
+	case strings.HasPrefix(expr, "Page[") && strings.HasSuffix(expr, "]"):
+		items, err := s.parse(expr[5 : len(expr)-1])
+		if err != nil {
+			return nil, err
+		}
+		return schema{
+			"type":     "object",
+			"required": []string{"data", "total", "limit", "offset"},
+			"properties": map[string]schema{
+				"data":   {"type": "array", "items": items},
+				"total":  {"type": "integer"},
+				"limit":  {"type": "integer"},
+				"offset": {"type": "integer"},
+			},
+		}, nil
+	}
+
+	if basic := basicSchema(expr); basic != nil {
+		return basic, nil
+	}
+	if _, ok := s.types[expr]; !ok {
+		return nil, fmt.Errorf("unknown type %s", expr)
+	}
+	return s.named(expr), nil
+}
+
+// named returns the schema of a declared type: a reference for structs, which are added
+// to the components, and the underlying schema for everything else
+func (s *schemas) named(name string) schema {
+	decl := s.types[name]
+	st, ok := decl.spec.Type.(*ast.StructType)
+	if !ok {
+		return s.expr(decl.pkg, decl.spec.Type)
+	}
+
+	if _, seen := s.components[name]; !seen {
+		// Register first so self-referencing types terminate
+		s.components[name] = schema{}
+		s.components[name] = s.object(decl.pkg, st)
+	}
+	return schema{"$ref": "#/components/schemas/" + name}
+}
+
+// object converts a struct to an object schema, inlining embedded structs
+func (s *schemas) object(pkg string, st *ast.StructType) schema {
+	props := map[string]schema{}
+	var required []string
+	for _, field := range st.Fields.List {
+		tag, rules := "", ""
+		if field.Tag != nil {
+			raw, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(raw).Get("json")
+			rules = reflect.StructTag(raw).Get("binding")
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			if ident, ok := field.Type.(*ast.Ident); ok && name == "" {
+				if decl, ok := s.types[pkg+"."+ident.Name]; ok {
+					if embedded, ok := decl.spec.Type.(*ast.StructType); ok {
+						for k, v := range s.object(pkg, embedded)["properties"].(map[string]schema) {
+							props[k] = v
+						}
+					}
+				}
+				continue
+			}
+		}
+
+		for _, n := range field.Names {
+			if !n.IsExported() {
+				continue
+			}
+			key := name
+			if key == "" {
+				key = n.Name
+			}
+			props[key] = s.expr(pkg, field.Type)
+			if hasRule(rules, "required") {
+				required = append(required, key)
+			}
+		}
+	}
+
+	obj := schema{"type": "object", "properties": props}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
+
+// hasRule reports whether a binding tag contains the given validation rule
+func hasRule(rules, rule string) bool {
+	for _, r := range strings.Split(rules, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// expr converts a type expression appearing in package pkg to a schema
+func (s *schemas) expr(pkg string, e ast.Expr) schema {
+	switch t := e.(type) {
+	case *ast.Ident:
+		if basic := basicSchema(t.Name); basic != nil {
+			return basic
+		}
+		if _, ok := s.types[pkg+"."+t.Name]; ok {
+			return s.named(pkg + "." + t.Name)
+		}
+	case *ast.SelectorExpr:
+		x, _ := t.X.(*ast.Ident)
+		if x == nil {
+			break
+		}
+		name := x.Name + "." + t.Sel.Name
+		if basic := basicSchema(name); basic != nil {
+			return basic
+		}
+		if _, ok := s.types[name]; ok {
+			return s.named(name)
+		}
+	case *ast.StarExpr:
+		inner := s.expr(pkg, t.X)
+		if _, ref := inner["$ref"]; ref {
+			return schema{"allOf": []schema{inner}, "nullable": true}
+		}
+		nullable := schema{"nullable": true}
+		for k, v := range inner {
+			nullable[k] = v
+		}
+		return nullable
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return schema{"type": "string", "format": "byte"}
+		}
+		return schema{"type": "array", "items": s.expr(pkg, t.Elt)}
+	case *ast.MapType:
+		return schema{"type": "object", "additionalProperties": s.expr(pkg, t.Value)}
+	case *ast.StructType:
+		return s.object(pkg, t)
+	}
+	return schema{}
+}
+
+// basicSchema returns the schema of builtin and well-known library types
+func basicSchema(name string) schema {
+	switch name {
+	case "string":
+		return schema{"type": "string"}
+	case "bool":
+		return schema{"type": "boolean"}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return schema{"type": "integer"}
+	case "float32", "float64":
+		return schema{"type": "number"}
+	case "time.Time":
+		return schema{"type": "string", "format": "date-time"}
+	case "gorm.DeletedAt":
+		return schema{"type": "string", "format": "date-time", "nullable": true}
+	case "time.Duration":
+		return schema{"type": "integer", "description": "nanoseconds"}
+	case "json.RawMessage", "interface{}", "any":
+		return schema{}
+	}
+	return nil
+}
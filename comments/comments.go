@@ -0,0 +1,211 @@
+// Package comments/comments.go
+//
+// Package comments records the threaded notes members of an organization
+// leave on its workflows, reports and subscriptions, and emails the members
+// they mention.
+package comments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+)
+
+// Paths are the pages of the web frontend showing the records comments are
+// left on, followed by their ID
+var Paths = map[string]string{
+	models.CommentableWorkflow:     "/workflows",
+	models.CommentableReport:       "/reports",
+	models.CommentableSubscription: "/subscriptions",
+}
+
+// ErrNotMember is returned for comments mentioning users who aren't members of
+// the organization
+var ErrNotMember = errors.New("mentioned user isn't a member of the organization")
+
+// mentionPattern matches mentions in bodies, written <@ID>
+var mentionPattern = regexp.MustCompile(`<@(\d+)>`)
+
+// Comments records comments and notifies their mentions
+type Comments struct {
+	DB     *gorm.DB
+	Mailer mailer.Mailer
+	// AppURL is the URL of the web frontend serving Paths
+	AppURL string
+}
+
+// New creates the comments, linking to their records at appURL
+func New(db *gorm.DB, mail mailer.Mailer, appURL string) *Comments {
+	return &Comments{DB: db, Mailer: mail, AppURL: strings.TrimSuffix(appURL, "/")}
+}
+
+// Mentions returns the IDs of the users a body mentions, in order
+func Mentions(body string) []uint {
+	ids := []uint{}
+	seen := map[uint]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		id, err := strconv.ParseUint(m[1], 10, 0)
+		if err != nil || id == 0 || seen[uint(id)] {
+			continue
+		}
+		seen[uint(id)] = true
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
+
+// Post records a comment, then emails the users it mentions. They must be
+// members of the comment's organization, or ErrNotMember is returned.
+func (s *Comments) Post(ctx context.Context, comment *models.Comment) error {
+	comment.Mentions = Mentions(comment.Body)
+	if err := s.checkMembers(ctx, comment.OrganizationID, comment.Mentions); err != nil {
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Omit("Replies").Create(comment).Error; err != nil {
+		return err
+	}
+	s.notify(ctx, comment, comment.Mentions)
+	return nil
+}
+
+// Edit changes the body of a comment, emailing only the users it mentions
+// for the first time
+func (s *Comments) Edit(ctx context.Context, comment *models.Comment, body string) error {
+	mentions := Mentions(body)
+	if err := s.checkMembers(ctx, comment.OrganizationID, mentions); err != nil {
+		return err
+	}
+	previous := make(map[uint]bool, len(comment.Mentions))
+	for _, id := range comment.Mentions {
+		previous[id] = true
+	}
+	var added []uint
+	for _, id := range mentions {
+		if !previous[id] {
+			added = append(added, id)
+		}
+	}
+
+	now := time.Now()
+	comment.Body, comment.Mentions, comment.EditedAt = body, mentions, &now
+	if err := s.DB.WithContext(ctx).Omit("Replies").Save(comment).Error; err != nil {
+		return err
+	}
+	s.notify(ctx, comment, added)
+	return nil
+}
+
+// Delete deletes a comment, with its replies when it starts a thread
+func (s *Comments) Delete(ctx context.Context, comment *models.Comment) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("parent_id = ?", comment.ID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Replies").Delete(comment).Error
+	})
+}
+
+// checkMembers checks that users are members of an organization
+func (s *Comments) checkMembers(ctx context.Context, orgID uint, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	var members int64
+	err := s.DB.WithContext(ctx).Table("user_organizations").
+		Where("organization_id = ? AND user_id IN ?", orgID, userIDs).
+		Count(&members).Error
+	if err != nil {
+		return err
+	}
+	if members < int64(len(userIDs)) {
+		return ErrNotMember
+	}
+	return nil
+}
+
+// notify emails the users a comment mentions in the background, except its
+// author
+func (s *Comments) notify(ctx context.Context, comment *models.Comment, userIDs []uint) {
+	var recipients []uint
+	for _, id := range userIDs {
+		if id != comment.UserID {
+			recipients = append(recipients, id)
+		}
+	}
+	if s.Mailer == nil || len(recipients) == 0 {
+		return
+	}
+	c := *comment
+	go func(ctx context.Context) {
+		if err := s.send(ctx, &c, recipients); err != nil {
+			slog.ErrorContext(ctx, "comments: failed to email mentions", "comment_id", c.ID, "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+// send emails the users of userIDs a comment mentions
+func (s *Comments) send(ctx context.Context, comment *models.Comment, userIDs []uint) error {
+	db := s.DB.WithContext(ctx)
+	var org models.Organization
+	if err := db.Select("id", "name", "email_footer").First(&org, comment.OrganizationID).Error; err != nil {
+		return err
+	}
+	var author models.User
+	if err := db.Select("id", "email", "name").First(&author, comment.UserID).Error; err != nil {
+		return err
+	}
+	var users []models.User
+	if err := db.Select("id", "email", "name").Where("id IN ?", comment.Mentions).Find(&users).Error; err != nil {
+		return err
+	}
+
+	// Render mentions as names, which mean more to readers than IDs
+	names := map[string]string{}
+	for _, u := range users {
+		names[strconv.FormatUint(uint64(u.ID), 10)] = displayName(u)
+	}
+	body := mentionPattern.ReplaceAllStringFunc(comment.Body, func(m string) string {
+		if name, ok := names[mentionPattern.FindStringSubmatch(m)[1]]; ok {
+			return "@" + name
+		}
+		return m
+	})
+
+	link := fmt.Sprintf("%s%s/%d#comment-%d", s.AppURL, Paths[comment.CommentableType], comment.CommentableID, comment.ID)
+	text := fmt.Sprintf("%s mentioned you in %s:\n\n%s\n\nReply at:\n\n%s\n", displayName(author), org.Name, body, link)
+	if footer := strings.TrimSpace(org.Settings.EmailFooter); footer != "" {
+		text += "\n--\n" + footer + "\n"
+	}
+	recipients := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		recipients[id] = true
+	}
+	for _, u := range users {
+		if !recipients[u.ID] {
+			continue
+		}
+		msg := mailer.Message{To: []string{u.Email}, Subject: displayName(author) + " mentioned you", Text: text}
+		if err := s.Mailer.Send(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// displayName returns the name of a user, or their email when they have none
+func displayName(u models.User) string {
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Email
+}
@@ -0,0 +1,138 @@
+// Package config/env.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/4cecoder/saas/middleware"
+)
+
+// env reads settings from environment variables, collecting every problem
+// instead of stopping at the first, so they can all be fixed at once
+type env struct {
+	problems []error
+}
+
+// fail records a problem with the setting in key
+func (e *env) fail(key, format string, args ...interface{}) {
+	e.problems = append(e.problems, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+// err returns the problems found, one per line, or nil
+func (e *env) err() error {
+	return errors.Join(e.problems...)
+}
+
+// str returns the variable, or def if it is unset
+func (e *env) str(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// list returns the comma-separated values of the variable
+func (e *env) list(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// override sets value to the variable if it is set
+func (e *env) override(value *string, key string) {
+	if v := os.Getenv(key); v != "" {
+		*value = v
+	}
+}
+
+// bool returns the boolean in the variable, or def if it is unset
+func (e *env) bool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.fail(key, "%q is not a boolean, use true or false", v)
+	}
+	return b
+}
+
+// int returns the non-negative integer in the variable, or def if it is unset
+func (e *env) int(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		e.fail(key, "%q is not a non-negative number", v)
+	}
+	return n
+}
+
+// ratio returns the number from 0 to 1 in the variable, or def if it is unset
+func (e *env) ratio(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f > 1 {
+		e.fail(key, "%q is not a number from 0 to 1", v)
+		return def
+	}
+	return f
+}
+
+// duration returns the non-negative duration in the variable, or def if it is unset
+func (e *env) duration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		e.fail(key, "%q is not a duration, use e.g. 30s or 5m", v)
+	}
+	return d
+}
+
+// size returns the byte size in the variable, such as 512KB, 10MB or 1048576,
+// or def if it is unset
+func (e *env) size(key string, def int64) int64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	digits, unit := v, int64(1)
+	for suffix, n := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if trimmed, ok := strings.CutSuffix(strings.ToUpper(v), suffix); ok {
+			digits, unit = strings.TrimSpace(trimmed), n
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || n < 0 {
+		e.fail(key, "%q is not a size, use e.g. 512KB or 10MB", v)
+		return def
+	}
+	return n * unit
+}
+
+// limit returns the rate limit in the variable, or def if it is unset
+func (e *env) limit(key, def string) middleware.Limit {
+	limit, err := middleware.ParseLimit(e.str(key, def))
+	if err != nil {
+		e.fail(key, "%v", err)
+	}
+	return limit
+}
@@ -0,0 +1,59 @@
+// Package config/features.go
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Features switches optional parts of the API on or off by name; toggles
+// that aren't set keep their default
+type Features map[string]bool
+
+// defaultFeatures lists the known toggles with their defaults
+var defaultFeatures = map[string]bool{
+	// docs serves the API documentation at /docs
+	"docs": true,
+	// graphql serves the GraphQL endpoint at /v1/graphql
+	"graphql": true,
+	// batch serves batch requests at /v1/batch
+	"batch": true,
+}
+
+// Enabled reports whether the feature is switched on
+func (f Features) Enabled(name string) bool {
+	if on, ok := f[name]; ok {
+		return on
+	}
+	return defaultFeatures[name]
+}
+
+// ParseFeatures reads a comma-separated list of toggles, each a feature name to
+// switch it on or a name prefixed with - to switch it off, such as -graphql
+func ParseFeatures(s string) (Features, error) {
+	features := Features{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		on := !strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		if _, ok := defaultFeatures[name]; !ok {
+			return nil, fmt.Errorf("unknown feature %q, use %s", name, strings.Join(featureNames(), ", "))
+		}
+		features[name] = on
+	}
+	return features, nil
+}
+
+// featureNames returns the names of the known toggles in order
+func featureNames() []string {
+	names := make([]string, 0, len(defaultFeatures))
+	for name := range defaultFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
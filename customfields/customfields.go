@@ -0,0 +1,309 @@
+// Package customfields/customfields.go
+//
+// Package customfields lets organizations extend their own record and their
+// members' with fields they define. Values are stored as rows keyed by the
+// record they belong to, so adding a field never changes the schema.
+package customfields
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+)
+
+const (
+	// MaxTextLength bounds the values of text fields, in characters
+	MaxTextLength = 1000
+	// MaxOptions bounds the options of select fields
+	MaxOptions = 100
+	// DateLayout is the format of the values of date fields
+	DateLayout = "2006-01-02"
+)
+
+// keyPattern is the format of field keys, usable as JSON keys and in exports
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// entityTypes lists the records custom fields can extend
+var entityTypes = map[string]bool{
+	models.CustomFieldEntityUser:         true,
+	models.CustomFieldEntityOrganization: true,
+}
+
+// FieldError describes why a field of a definition or a value was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Store keeps the custom field definitions and values of organizations
+type Store struct {
+	DB *gorm.DB
+}
+
+// New creates a store on db
+func New(db *gorm.DB) *Store {
+	return &Store{DB: db}
+}
+
+// Validate checks a definition, returning a validation error if it's invalid
+func Validate(f *models.CustomField) error {
+	var errs []FieldError
+	if !entityTypes[f.EntityType] {
+		errs = append(errs, FieldError{Field: "entity_type", Rule: "oneof", Param: "users organizations", Message: "must be users or organizations"})
+	}
+	if !keyPattern.MatchString(f.Key) {
+		errs = append(errs, FieldError{Field: "key", Rule: "format", Message: "must start with a lowercase letter followed by lowercase letters, digits or underscores"})
+	}
+	switch f.Type {
+	case models.CustomFieldSelect:
+		seen := make(map[string]bool, len(f.Options))
+		for _, o := range f.Options {
+			if seen[o] {
+				errs = append(errs, FieldError{Field: "options", Rule: "unique", Message: fmt.Sprintf("lists %q twice", o)})
+				break
+			}
+			seen[o] = true
+		}
+		if len(f.Options) == 0 {
+			errs = append(errs, FieldError{Field: "options", Rule: "required_if", Param: "type select", Message: "is required for select fields"})
+		}
+		if len(f.Options) > MaxOptions {
+			errs = append(errs, FieldError{Field: "options", Rule: "max", Param: fmt.Sprint(MaxOptions), Message: fmt.Sprintf("must list at most %d options", MaxOptions)})
+		}
+	case models.CustomFieldText, models.CustomFieldNumber, models.CustomFieldDate:
+		if len(f.Options) > 0 {
+			errs = append(errs, FieldError{Field: "options", Rule: "excluded_unless", Param: "type select", Message: "is only allowed for select fields"})
+		}
+	default:
+		errs = append(errs, FieldError{Field: "type", Rule: "oneof", Param: "text number date select", Message: "must be text, number, date or select"})
+	}
+	if len(errs) > 0 {
+		return apperror.Validation(errs)
+	}
+	return nil
+}
+
+// Define creates a field, whose key must be unique among those of the
+// organization for the same entity type
+func (s *Store) Define(ctx context.Context, f *models.CustomField) error {
+	if err := Validate(f); err != nil {
+		return err
+	}
+	db := s.DB.WithContext(ctx)
+	var taken int64
+	// Key is a reserved word in MySQL, which GORM quotes in struct conditions
+	err := db.Model(&models.CustomField{}).
+		Where(&models.CustomField{OrganizationID: f.OrganizationID, EntityType: f.EntityType, Key: f.Key}).
+		Count(&taken).Error
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	if taken > 0 {
+		return apperror.Conflict("Another field of the organization has the key")
+	}
+	if err := db.Create(f).Error; err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// Update saves the changes to a field's name, options or requirement. Values
+// no longer among a select field's options are kept until they're next set.
+func (s *Store) Update(ctx context.Context, f *models.CustomField) error {
+	if err := Validate(f); err != nil {
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Save(f).Error; err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// Delete deletes a field with its values
+func (s *Store) Delete(ctx context.Context, f *models.CustomField) error {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("custom_field_id = ?", f.ID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(f).Error
+	})
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// Fields returns the fields an organization defines for an entity type, or
+// for every entity type when it's empty
+func (s *Store) Fields(ctx context.Context, orgID uint, entityType string) ([]models.CustomField, error) {
+	query := s.DB.WithContext(ctx).Where("organization_id = ?", orgID)
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	fields := []models.CustomField{}
+	err := query.Order("entity_type, id").Find(&fields).Error
+	return fields, err
+}
+
+// Values returns the values an organization set on records of an entity
+// type, by record ID then field key
+func (s *Store) Values(ctx context.Context, orgID uint, entityType string, entityIDs []uint) (map[uint]map[string]interface{}, error) {
+	var values []models.CustomFieldValue
+	err := s.DB.WithContext(ctx).
+		Where("organization_id = ? AND entity_type = ? AND entity_id IN ?", orgID, entityType, entityIDs).
+		Find(&values).Error
+	if err != nil {
+		return nil, err
+	}
+	byEntity := make(map[uint]map[string]interface{}, len(entityIDs))
+	for _, v := range values {
+		if byEntity[v.EntityID] == nil {
+			byEntity[v.EntityID] = map[string]interface{}{}
+		}
+		byEntity[v.EntityID][v.Key] = v.Value
+	}
+	return byEntity, nil
+}
+
+// Set changes the values an organization set on a record, by field key. A nil
+// value clears the field; fields not given are left unchanged. Every required
+// field must have a value afterwards. It returns all the record's values.
+func (s *Store) Set(ctx context.Context, orgID uint, entityType string, entityID uint, values map[string]interface{}) (map[string]interface{}, error) {
+	fields, err := s.Fields(ctx, orgID, entityType)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	current, err := s.Values(ctx, orgID, entityType, []uint{entityID})
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	result := current[entityID]
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+
+	byKey := make(map[string]models.CustomField, len(fields))
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []FieldError
+	var upserts []models.CustomFieldValue
+	var cleared []uint
+	for _, key := range keys {
+		f, ok := byKey[key]
+		if !ok {
+			errs = append(errs, FieldError{Field: "values." + key, Rule: "exists", Message: "isn't a field of the organization"})
+			continue
+		}
+		if values[key] == nil {
+			delete(result, key)
+			cleared = append(cleared, f.ID)
+			continue
+		}
+		v, fe := normalize(f, values[key])
+		if fe != nil {
+			fe.Field = "values." + key
+			errs = append(errs, *fe)
+			continue
+		}
+		result[key] = v
+		upserts = append(upserts, models.CustomFieldValue{
+			OrganizationID: orgID,
+			CustomFieldID:  f.ID,
+			EntityType:     entityType,
+			EntityID:       entityID,
+			Key:            key,
+			Value:          v,
+		})
+	}
+	for _, f := range fields {
+		if _, ok := result[f.Key]; f.Required && !ok && !hasField(errs, "values."+f.Key) {
+			errs = append(errs, FieldError{Field: "values." + f.Key, Rule: "required", Message: "is required"})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, apperror.Validation(errs)
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(cleared) > 0 {
+			err := tx.Unscoped().Where("custom_field_id IN ? AND entity_id = ?", cleared, entityID).Delete(&models.CustomFieldValue{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(upserts) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "custom_field_id"}, {Name: "entity_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&upserts).Error
+	})
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return result, nil
+}
+
+// normalize checks a value against the type of its field, returning it as
+// stored or why it was rejected
+func normalize(f models.CustomField, value interface{}) (interface{}, *FieldError) {
+	switch f.Type {
+	case models.CustomFieldNumber:
+		n, ok := value.(float64)
+		if !ok {
+			return nil, &FieldError{Rule: "number", Message: "must be a number"}
+		}
+		return n, nil
+	case models.CustomFieldDate:
+		s, ok := value.(string)
+		if _, err := time.Parse(DateLayout, s); !ok || err != nil {
+			return nil, &FieldError{Rule: "datetime", Param: DateLayout, Message: "must be a date formatted as " + DateLayout}
+		}
+		return s, nil
+	case models.CustomFieldSelect:
+		s, ok := value.(string)
+		for _, o := range f.Options {
+			if ok && s == o {
+				return s, nil
+			}
+		}
+		return nil, &FieldError{Rule: "oneof", Message: "must be one of the field's options"}
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return nil, &FieldError{Rule: "string", Message: "must be a string"}
+		}
+		if len([]rune(s)) > MaxTextLength {
+			return nil, &FieldError{Rule: "max", Param: fmt.Sprint(MaxTextLength), Message: fmt.Sprintf("must be at most %d characters long", MaxTextLength)}
+		}
+		return s, nil
+	}
+}
+
+// hasField reports whether errs rejects field
+func hasField(errs []FieldError, field string) bool {
+	for _, e := range errs {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
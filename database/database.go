@@ -0,0 +1,347 @@
+// Package database/database.go
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Drivers supported by Open
+const (
+	Postgres = "postgres"
+	MySQL    = "mysql"
+	SQLite   = "sqlite"
+)
+
+// Memory is the SQLite database name of a database kept in memory
+const Memory = ":memory:"
+
+// Pool defaults, sized for a few application instances sharing a database
+// limited to the usual 100 connections
+const (
+	DefaultMaxOpenConns    = 25
+	DefaultMaxIdleConns    = 10
+	DefaultConnMaxLifetime = 30 * time.Minute
+)
+
+// Connection retry defaults; the delay between attempts doubles up to maxConnectBackoff
+const (
+	DefaultConnectMaxWait = 30 * time.Second
+	DefaultConnectBackoff = 500 * time.Millisecond
+	maxConnectBackoff     = 10 * time.Second
+)
+
+// SSL modes, named as in Postgres
+const (
+	SSLDisable    = "disable"
+	SSLPrefer     = "prefer"
+	SSLRequire    = "require"
+	SSLVerifyCA   = "verify-ca"
+	SSLVerifyFull = "verify-full"
+)
+
+// Config holds database connection settings
+type Config struct {
+	// Driver is postgres (the default), mysql, or sqlite
+	Driver   string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	// Name is the database name, or for SQLite the file path or :memory:
+	Name string
+
+	// SSLMode is disable, prefer, require, verify-ca, or verify-full; empty
+	// leaves the driver's default
+	SSLMode string
+	// SSLRootCert is the CA certificate file the server certificate is verified against
+	SSLRootCert string
+	// SSLCert and SSLKey are the files of a client certificate, if the server asks for one
+	SSLCert string
+	SSLKey  string
+	// Params are extra driver parameters, e.g. connect_timeout or application_name
+	Params url.Values
+
+	// MaxOpenConns and MaxIdleConns limit the connections of the pool; zero
+	// means no limit on open connections and the database/sql default of idle ones
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime closes connections after this long, so they are spread
+	// again when replicas or proxies are added; zero keeps them
+	ConnMaxLifetime time.Duration
+	// StatementTimeout cancels statements running longer, on Postgres and, for
+	// SELECTs, MySQL; zero means no limit
+	StatementTimeout time.Duration
+
+	// Replicas are read replicas of the database, used by queries made through Replica
+	Replicas []Config
+
+	// ConnectMaxWait keeps retrying a failed connection for up to this long, so
+	// the application can start before its database; zero fails at once
+	ConnectMaxWait time.Duration
+	// ConnectBackoff is the first delay between attempts, doubled after each
+	ConnectBackoff time.Duration
+
+	// Log selects the queries that are logged
+	Log LogConfig
+}
+
+// Open connects to the configured database, retrying with exponential backoff
+// while the database can't be reached within ConnectMaxWait
+func Open(cfg Config) (*gorm.DB, error) {
+	deadline := time.Now().Add(cfg.ConnectMaxWait)
+	backoff := cfg.ConnectBackoff
+	if backoff <= 0 {
+		backoff = DefaultConnectBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		// The dialector is made anew each time, as a failed open closes its connection
+		dialector, err := dialector(cfg)
+		if err != nil {
+			return nil, err
+		}
+		db, err := open(dialector, cfg)
+		if err == nil {
+			return db, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			if attempt > 1 {
+				return nil, fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+			}
+			return nil, err
+		}
+		delay := min(backoff, remaining.Round(time.Millisecond))
+		slog.Warn("database: connection failed, retrying", "attempt", attempt, "retry_in", delay, "error", err)
+		time.Sleep(delay)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// open makes one attempt at connecting to the database and sets up its pool
+func open(dialector gorm.Dialector, cfg Config) (*gorm.DB, error) {
+	queryLogger, err := NewLogger(cfg.Log)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: queryLogger})
+	if err != nil {
+		return nil, err
+	}
+
+	Queries.SlowThreshold = cfg.Log.SlowThreshold
+	if err := db.Use(Queries); err != nil {
+		return nil, err
+	}
+	if err := db.Use(otelgorm.NewPlugin(otelgorm.WithoutQueryVariables(), otelgorm.WithoutMetrics())); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	// SQLite connections are local and an in-memory database only lives as
+	// long as one of them, so they are never recycled
+	if cfg.Driver != SQLite {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+
+	if len(cfg.Replicas) > 0 {
+		if err := useReplicas(db, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// dialector returns the GORM dialector of the configured driver
+func dialector(cfg Config) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "", Postgres:
+		return postgres.Open(postgresDSN(cfg)), nil
+	case MySQL:
+		connector, err := mysqlConnector(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return mysql.New(mysql.Config{Conn: sql.OpenDB(connector)}), nil
+	case SQLite:
+		return sqlite.Open(sqliteDSN(cfg)), nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q, use postgres, mysql, or sqlite", cfg.Driver)
+	}
+}
+
+// postgresDSN returns the connection URL of a Postgres database; the driver
+// reads the SSL files itself
+func postgresDSN(cfg Config) string {
+	params := url.Values{}
+	for key, values := range cfg.Params {
+		params[key] = values
+	}
+	set := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	if cfg.StatementTimeout > 0 {
+		set("statement_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
+	set("sslmode", cfg.SSLMode)
+	set("sslrootcert", cfg.SSLRootCert)
+	set("sslcert", cfg.SSLCert)
+	set("sslkey", cfg.SSLKey)
+
+	host := cfg.Host
+	if cfg.Port != "" {
+		host = net.JoinHostPort(cfg.Host, cfg.Port)
+	}
+	u := url.URL{Scheme: "postgres", Host: host, Path: "/" + cfg.Name, RawQuery: params.Encode()}
+	if cfg.User != "" {
+		u.User = url.UserPassword(cfg.User, cfg.Password)
+	}
+	return u.String()
+}
+
+// mysqlConnector returns a connector to a MySQL database, with times stored in
+// UTC and several statements allowed at once for migrations
+func mysqlConnector(cfg Config) (driver.Connector, error) {
+	port := cfg.Port
+	if port == "" {
+		port = "3306"
+	}
+
+	dsn := mysqldriver.NewConfig()
+	dsn.User = cfg.User
+	dsn.Passwd = cfg.Password
+	dsn.Net = "tcp"
+	dsn.Addr = net.JoinHostPort(cfg.Host, port)
+	dsn.DBName = cfg.Name
+	dsn.ParseTime = true
+	dsn.Loc = time.UTC
+	dsn.MultiStatements = true
+	dsn.Params = map[string]string{"charset": "utf8mb4"}
+	if cfg.StatementTimeout > 0 {
+		dsn.Params["max_execution_time"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	for key := range cfg.Params {
+		dsn.Params[key] = cfg.Params.Get(key)
+	}
+
+	tlsConfig, err := mysqlTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dsn.TLS = tlsConfig
+	if cfg.SSLMode == SSLPrefer {
+		dsn.AllowFallbackToPlaintext = true
+	}
+
+	return mysqldriver.NewConnector(dsn)
+}
+
+// mysqlTLS returns the TLS settings matching the SSL mode, or nil to connect in plain text
+func mysqlTLS(cfg Config) (*tls.Config, error) {
+	if cfg.SSLMode == "" || cfg.SSLMode == SSLDisable {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	if cfg.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if cfg.SSLRootCert != "" {
+		pem, err := os.ReadFile(cfg.SSLRootCert)
+		if err != nil {
+			return nil, fmt.Errorf("read CA certificate: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + cfg.SSLRootCert)
+		}
+	}
+
+	switch cfg.SSLMode {
+	case SSLPrefer, SSLRequire:
+		// As in Postgres, require only verifies the server when given a CA certificate
+		if cfg.SSLRootCert == "" {
+			tlsConfig.InsecureSkipVerify = true
+			return tlsConfig, nil
+		}
+		return verifyChainOnly(tlsConfig), nil
+	case SSLVerifyCA:
+		return verifyChainOnly(tlsConfig), nil
+	case SSLVerifyFull:
+		return tlsConfig, nil
+	default:
+		return nil, fmt.Errorf("unknown SSL mode %q, use disable, prefer, require, verify-ca, or verify-full", cfg.SSLMode)
+	}
+}
+
+// verifyChainOnly checks the server certificate against the CA without matching
+// its host name
+func verifyChainOnly(tlsConfig *tls.Config) *tls.Config {
+	roots := tlsConfig.RootCAs
+	tlsConfig.InsecureSkipVerify = true
+	tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("server sent no certificate")
+		}
+		opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+		for _, cert := range state.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := state.PeerCertificates[0].Verify(opts)
+		return err
+	}
+	return tlsConfig
+}
+
+// sqliteDSN returns the connection string of a SQLite database file, or of a
+// shared in-memory database for :memory:, with foreign keys enforced
+func sqliteDSN(cfg Config) string {
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	params.Set("_busy_timeout", "5000")
+
+	name := cfg.Name
+	if name == "" || name == Memory {
+		name = "saas"
+		params.Set("mode", "memory")
+		params.Set("cache", "shared")
+	} else {
+		params.Set("_journal_mode", "WAL")
+	}
+	for key, values := range cfg.Params {
+		params[key] = values
+	}
+	return "file:" + name + "?" + params.Encode()
+}
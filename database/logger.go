@@ -0,0 +1,120 @@
+// Package database/logger.go
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DefaultSlowThreshold is the duration above which queries are logged as slow
+const DefaultSlowThreshold = 200 * time.Millisecond
+
+// logLevels maps the configurable log levels to GORM's
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// LogConfig selects the queries that are logged
+type LogConfig struct {
+	// Level is silent, error, warn (the default, failed and slow queries), or
+	// info (every query)
+	Level string
+	// SlowThreshold flags queries running longer as slow; zero disables it
+	SlowThreshold time.Duration
+	// Params logs the values bound to queries, which may hold personal data
+	Params bool
+}
+
+// Logger writes GORM's logs as structured records with the duration of each query
+type Logger struct {
+	Log           *slog.Logger
+	Level         logger.LogLevel
+	SlowThreshold time.Duration
+	Params        bool
+}
+
+// NewLogger creates a query logger writing to the default structured logger, which
+// adds the request, user and organization of the query's context
+func NewLogger(cfg LogConfig) (*Logger, error) {
+	level := logger.Warn
+	if cfg.Level != "" {
+		var ok bool
+		if level, ok = logLevels[cfg.Level]; !ok {
+			return nil, fmt.Errorf("unknown database log level %q, use silent, error, warn, or info", cfg.Level)
+		}
+	}
+	return &Logger{Log: slog.Default(), Level: level, SlowThreshold: cfg.SlowThreshold, Params: cfg.Params}, nil
+}
+
+// ParamsFilter drops the values bound to a query unless Params is set, so logged
+// SQL shows placeholders; it implements gorm.ParamsFilter
+func (l *Logger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.Params {
+		return sql, params
+	}
+	return sql, nil
+}
+
+// LogMode returns a copy of the logger at another level
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.Level = level
+	return &copied
+}
+
+// Info logs a message of GORM at info level
+func (l *Logger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= logger.Info {
+		l.Log.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn logs a message of GORM at warn level
+func (l *Logger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= logger.Warn {
+		l.Log.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error logs a message of GORM at error level
+func (l *Logger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= logger.Error {
+		l.Log.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs a finished query: failures at error level, slow queries at warn
+// level, and the others at info level. Missing records aren't failures.
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.Level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	attrs := func() []any {
+		sql, rows := fc()
+		return []any{
+			slog.String("sql", sql),
+			slog.Int64("rows", rows),
+			slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		}
+	}
+
+	switch {
+	case err != nil && l.Level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.Log.ErrorContext(ctx, "query failed", append(attrs(), slog.String("error", err.Error()))...)
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.Level >= logger.Warn:
+		l.Log.WarnContext(ctx, "slow query", append(attrs(), slog.Duration("threshold", l.SlowThreshold))...)
+	case l.Level >= logger.Info:
+		l.Log.InfoContext(ctx, "query", attrs()...)
+	}
+}
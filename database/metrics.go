@@ -0,0 +1,133 @@
+// Package database/metrics.go
+package database
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Queries collects the latency of the queries of every database opened with
+// Open. It is published with expvar as db_queries.
+var Queries = &Metrics{}
+
+func init() {
+	expvar.Publish("db_queries", expvar.Func(func() any { return Queries.Snapshot() }))
+}
+
+// startKey stores the start time of a statement in its settings
+const startKey = "saas:query_start"
+
+// QueryStats sums up the queries of one operation on one table
+type QueryStats struct {
+	Count   int64   `json:"count"`
+	Errors  int64   `json:"errors"`
+	Slow    int64   `json:"slow"`
+	TotalMS float64 `json:"total_ms"`
+	MaxMS   float64 `json:"max_ms"`
+}
+
+// Metrics records query latency by table and operation. It is a GORM plugin.
+type Metrics struct {
+	// SlowThreshold counts queries running longer as slow; zero disables it
+	SlowThreshold time.Duration
+
+	mu     sync.Mutex
+	tables map[string]map[string]*QueryStats
+}
+
+// Name implements gorm.Plugin
+func (m *Metrics) Name() string {
+	return "saas:query_metrics"
+}
+
+// Initialize times the create, query, update, delete, row and raw operations of db
+func (m *Metrics) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	type register func(name string, fn func(*gorm.DB)) error
+	processors := map[string][2]register{
+		"create": {cb.Create().Before("*").Register, cb.Create().After("*").Register},
+		"query":  {cb.Query().Before("*").Register, cb.Query().After("*").Register},
+		"update": {cb.Update().Before("*").Register, cb.Update().After("*").Register},
+		"delete": {cb.Delete().Before("*").Register, cb.Delete().After("*").Register},
+		"row":    {cb.Row().Before("*").Register, cb.Row().After("*").Register},
+		"raw":    {cb.Raw().Before("*").Register, cb.Raw().After("*").Register},
+	}
+
+	for op, hooks := range processors {
+		op := op
+		if err := hooks[0]("saas:metrics_start", start); err != nil {
+			return err
+		}
+		if err := hooks[1]("saas:metrics_end", func(db *gorm.DB) { m.finish(db, op) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// start stores the time the statement began
+func start(db *gorm.DB) {
+	db.Statement.Settings.Store(startKey, time.Now())
+}
+
+// finish records the duration of the statement under its table
+func (m *Metrics) finish(db *gorm.DB, op string) {
+	v, ok := db.Statement.Settings.Load(startKey)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(v.(time.Time))
+
+	table := db.Statement.Table
+	if table == "" {
+		table = "raw"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tables == nil {
+		m.tables = map[string]map[string]*QueryStats{}
+	}
+	ops := m.tables[table]
+	if ops == nil {
+		ops = map[string]*QueryStats{}
+		m.tables[table] = ops
+	}
+	stats := ops[op]
+	if stats == nil {
+		stats = &QueryStats{}
+		ops[op] = stats
+	}
+
+	ms := float64(elapsed.Microseconds()) / 1000
+	stats.Count++
+	stats.TotalMS += ms
+	if ms > stats.MaxMS {
+		stats.MaxMS = ms
+	}
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		stats.Errors++
+	}
+	if m.SlowThreshold > 0 && elapsed > m.SlowThreshold {
+		stats.Slow++
+	}
+}
+
+// Snapshot returns a copy of the statistics by table and operation
+func (m *Metrics) Snapshot() map[string]map[string]QueryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]map[string]QueryStats, len(m.tables))
+	for table, ops := range m.tables {
+		snapshot[table] = make(map[string]QueryStats, len(ops))
+		for op, stats := range ops {
+			snapshot[table][op] = *stats
+		}
+	}
+	return snapshot
+}
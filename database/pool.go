@@ -0,0 +1,44 @@
+// Package database/pool.go
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PoolStats describes the use of a connection pool
+type PoolStats struct {
+	MaxOpen int `json:"max_open_connections"`
+	Open    int `json:"open_connections"`
+	InUse   int `json:"in_use"`
+	Idle    int `json:"idle"`
+	// WaitCount and WaitDuration total the waits for a free connection
+	WaitCount    int64         `json:"wait_count"`
+	WaitDuration time.Duration `json:"wait_duration_ns"`
+	// Saturation is the share of the maximum connections in use, or zero
+	// when the pool is unlimited
+	Saturation float64 `json:"saturation"`
+}
+
+// Stats returns the connection pool statistics of a database
+func Stats(db *gorm.DB) (PoolStats, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return PoolStats{}, err
+	}
+
+	s := sqlDB.Stats()
+	stats := PoolStats{
+		MaxOpen:      s.MaxOpenConnections,
+		Open:         s.OpenConnections,
+		InUse:        s.InUse,
+		Idle:         s.Idle,
+		WaitCount:    s.WaitCount,
+		WaitDuration: s.WaitDuration,
+	}
+	if s.MaxOpenConnections > 0 {
+		stats.Saturation = float64(s.InUse) / float64(s.MaxOpenConnections)
+	}
+	return stats, nil
+}
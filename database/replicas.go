@@ -0,0 +1,42 @@
+// Package database/replicas.go
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// replicaResolver names the resolver of the read replicas. It isn't the global
+// resolver, so queries stay on the primary unless routed through Replica.
+const replicaResolver = "replicas"
+
+// Replica routes the reads made through db to a read replica picked at random,
+// when replicas are configured. Writes still go to the primary, and a
+// transaction begun on it runs on the replica. Use it for lists, reports and
+// analytics that tolerate replication lag, not to read back what was just written.
+func Replica(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Use(replicaResolver))
+}
+
+// useReplicas registers the replicas of cfg with db, with the same pool limits
+// as the primary
+func useReplicas(db *gorm.DB, cfg Config) error {
+	replicas := make([]gorm.Dialector, len(cfg.Replicas))
+	for i, replica := range cfg.Replicas {
+		d, err := dialector(replica)
+		if err != nil {
+			return fmt.Errorf("replica %d: %w", i+1, err)
+		}
+		replicas[i] = d
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{Replicas: replicas}, replicaResolver).
+		SetMaxOpenConns(cfg.MaxOpenConns).
+		SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	if cfg.MaxIdleConns > 0 {
+		resolver.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	return db.Use(resolver)
+}
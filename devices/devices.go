@@ -0,0 +1,189 @@
+// Package devices/devices.go
+//
+// Package devices recognizes the devices users sign in with and emails them
+// when they sign in with a new device or from a new location, with a link to
+// sign everyone out of the account and choose a new password if it wasn't them.
+package devices
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+)
+
+// DefaultAlertTTL is how long the link of an alert is valid by default
+const DefaultAlertTTL = 72 * time.Hour
+
+// DenyPath is the page of the web frontend the link of an alert opens, with
+// the token in its query; the page posts it to /v1/auth/login-alerts/deny
+const DenyPath = "/security/not-me"
+
+// ErrInvalidToken is returned for unknown, used or expired alert tokens
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Config holds the settings of the alerts
+type Config struct {
+	// AppURL is the URL of the web frontend serving DenyPath
+	AppURL string
+	// LocationHeader names the request header holding the client's location,
+	// such as CF-IPCountry behind Cloudflare; empty leaves locations unknown
+	LocationHeader string
+	// AlertTTL is how long the link of an alert is valid
+	AlertTTL time.Duration
+}
+
+// Tracker records the devices users sign in with and alerts them of new ones
+type Tracker struct {
+	DB     *gorm.DB
+	Mailer mailer.Mailer
+	Config Config
+}
+
+// NewTracker creates a tracker alerting users through mail
+func NewTracker(db *gorm.DB, mail mailer.Mailer, cfg Config) *Tracker {
+	if cfg.AlertTTL <= 0 {
+		cfg.AlertTTL = DefaultAlertTTL
+	}
+	return &Tracker{DB: db, Mailer: mail, Config: cfg}
+}
+
+// versions matches the version numbers of user agents, which change with
+// every update of a browser
+var versions = regexp.MustCompile(`[0-9]+([._][0-9]+)*`)
+
+// Fingerprint identifies the device of a user agent, leaving out its versions
+func Fingerprint(userAgent string) string {
+	sum := sha256.Sum256([]byte(versions.ReplaceAllString(userAgent, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Record records the device user signed in with through r from ip. Users who
+// signed in before are emailed when it's a new device or location.
+func (t *Tracker) Record(ctx context.Context, user *models.User, r *http.Request, ip string) error {
+	device := models.LoginDevice{
+		UserID:      user.ID,
+		Fingerprint: Fingerprint(r.UserAgent()),
+		UserAgent:   r.UserAgent(),
+		IP:          ip,
+		LastSeenAt:  time.Now(),
+	}
+	if t.Config.LocationHeader != "" {
+		device.Location = strings.TrimSpace(r.Header.Get(t.Config.LocationHeader))
+	}
+
+	db := t.DB.WithContext(ctx)
+	var known []models.LoginDevice
+	if err := db.Where("user_id = ?", user.ID).Find(&known).Error; err != nil {
+		return err
+	}
+	knownDevice, knownLocation := false, device.Location == ""
+	for _, d := range known {
+		if d.Fingerprint == device.Fingerprint && d.Location == device.Location {
+			return db.Model(&d).Updates(map[string]interface{}{"ip": ip, "last_seen_at": device.LastSeenAt}).Error
+		}
+		knownDevice = knownDevice || d.Fingerprint == device.Fingerprint
+		knownLocation = knownLocation || d.Location == device.Location
+	}
+
+	// The first device of a user is theirs
+	var reason string
+	switch {
+	case len(known) == 0:
+	case !knownDevice:
+		reason = "a new device"
+	case !knownLocation:
+		reason = "a new location"
+	}
+	var token string
+	if reason != "" {
+		var err error
+		if token, device.AlertTokenHash, err = auth.NewLinkToken(); err != nil {
+			return err
+		}
+		expires := device.LastSeenAt.Add(t.Config.AlertTTL)
+		device.AlertExpiresAt = &expires
+	}
+	if err := db.Create(&device).Error; err != nil {
+		return err
+	}
+	if reason == "" {
+		return nil
+	}
+	return t.alert(ctx, user, &device, reason, token)
+}
+
+// alert emails user about a sign-in with device, with the link denying it
+func (t *Tracker) alert(ctx context.Context, user *models.User, device *models.LoginDevice, reason, token string) error {
+	if t.Mailer == nil || user.Email == "" {
+		return nil
+	}
+	link := strings.TrimSuffix(t.Config.AppURL, "/") + DenyPath + "?token=" + url.QueryEscape(token)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Your account was signed in to from %s on %s.\n\n", reason, device.LastSeenAt.UTC().Format(time.RFC1123))
+	fmt.Fprintf(&body, "Device: %s\n", orUnknown(device.UserAgent))
+	fmt.Fprintf(&body, "Location: %s\n", orUnknown(device.Location))
+	fmt.Fprintf(&body, "IP address: %s\n", orUnknown(device.IP))
+	fmt.Fprintf(&body, "\nIf this was you, you can ignore this email. If it wasn't, sign everyone out of your account and choose a new password at:\n\n%s\n", link)
+	fmt.Fprintf(&body, "\nThe link expires on %s.\n", device.AlertExpiresAt.UTC().Format(time.RFC1123))
+
+	msg := mailer.Message{To: []string{user.Email}, Subject: "New sign-in to your account", Text: body.String()}
+	if err := t.Mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send login alert: %w", err)
+	}
+	slog.InfoContext(ctx, "devices: alerted user of a sign-in", "user_id", user.ID, "device_id", device.ID, "reason", reason)
+	return nil
+}
+
+// Deny handles the link of an alert whose sign-in the user didn't make: the
+// tokens of the user are revoked, their password replaced with password, and
+// the device forgotten so it's alerted of again. It returns the user.
+func (t *Tracker) Deny(ctx context.Context, token, password string) (*models.User, error) {
+	var user models.User
+	err := t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var device models.LoginDevice
+		err := tx.Where("alert_token_hash = ? AND alert_expires_at > ?", auth.HashLinkToken(token), time.Now()).First(&device).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		} else if err != nil {
+			return err
+		}
+		if err := tx.First(&user, device.UserID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		user.Password, user.PasswordChangeRequired, user.SessionsRevokedAt = password, false, &now
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&device).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.WarnContext(ctx, "devices: user denied a sign-in, sessions revoked and password reset", "user_id", user.ID)
+	return &user, nil
+}
+
+// orUnknown returns s, or unknown when it's empty
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+// Package docs/docs.go
+package docs
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:generate go run ../cmd/openapi -root .. -o openapi.json
+
+// Spec is the generated OpenAPI document
+//
+//go:embed openapi.json
+var Spec []byte
+
+// swaggerUI renders the interactive documentation of the spec
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>SaaS API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>` + swaggerUIScript + `</script>
+</body>
+</html>
+`
+
+// swaggerUIScript starts the documentation; its hash allows it in the page's policy
+const swaggerUIScript = `window.ui = SwaggerUIBundle({ url: "/docs/openapi.json", dom_id: "#swagger-ui" });`
+
+// swaggerUIPolicy replaces the API's Content-Security-Policy, which blocks
+// everything, to let the page load Swagger UI
+var swaggerUIPolicy = func() string {
+	sum := sha256.Sum256([]byte(swaggerUIScript))
+	return "default-src 'none'; script-src https://unpkg.com 'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'; " +
+		"style-src https://unpkg.com; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+}()
+
+// Register serves the interactive documentation at /docs and the spec at /docs/openapi.json
+func Register(r gin.IRouter) {
+	r.GET("/docs", func(c *gin.Context) {
+		c.Writer.Header().Del("Content-Security-Policy-Report-Only")
+		c.Header("Content-Security-Policy", swaggerUIPolicy)
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+	})
+	r.GET("/docs/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", Spec)
+	})
+}
@@ -0,0 +1,57 @@
+// Package dto/announcements.go
+package dto
+
+import (
+	"time"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// CreateAnnouncementRequest is the request body for creating an announcement
+type CreateAnnouncementRequest struct {
+	Title string `json:"title" binding:"required,max=200"`
+	Body  string `json:"body" binding:"required,max=20000"`
+	Link  string `json:"link" binding:"omitempty,url,max=2048"`
+	// PublishedAt publishes the announcement at that time, which may be in the
+	// future; without it the announcement is a draft
+	PublishedAt *time.Time `json:"published_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// Model returns the announcement to create
+func (r CreateAnnouncementRequest) Model() models.Announcement {
+	return models.Announcement{
+		Title:       r.Title,
+		Body:        r.Body,
+		Link:        r.Link,
+		PublishedAt: r.PublishedAt,
+		ExpiresAt:   r.ExpiresAt,
+	}
+}
+
+// UpdateAnnouncementRequest is the request body for updating an announcement
+type UpdateAnnouncementRequest struct {
+	Title       *string    `json:"title" binding:"omitempty,min=1,max=200"`
+	Body        *string    `json:"body" binding:"omitempty,min=1,max=20000"`
+	Link        *string    `json:"link" binding:"omitempty,url,max=2048"`
+	PublishedAt *time.Time `json:"published_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	// Unpublish turns the announcement back into a draft, ignoring published_at
+	Unpublish bool `json:"unpublish"`
+}
+
+// Apply copies the given fields onto the announcement
+func (r UpdateAnnouncementRequest) Apply(a *models.Announcement) {
+	set(&a.Title, r.Title)
+	set(&a.Body, r.Body)
+	set(&a.Link, r.Link)
+	if r.PublishedAt != nil {
+		a.PublishedAt = r.PublishedAt
+	}
+	if r.ExpiresAt != nil {
+		a.ExpiresAt = r.ExpiresAt
+	}
+	if r.Unpublish {
+		a.PublishedAt = nil
+	}
+}
@@ -0,0 +1,15 @@
+// Package dto/comments.go
+package dto
+
+// CreateCommentRequest is the request body for commenting on a record
+type CreateCommentRequest struct {
+	// Body may mention members of the organization as <@ID>, who are emailed
+	Body string `json:"body" binding:"required,max=10000"`
+	// ParentID is the comment replied to; replies to replies join its thread
+	ParentID *uint `json:"parent_id"`
+}
+
+// UpdateCommentRequest is the request body for editing a comment
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required,max=10000"`
+}
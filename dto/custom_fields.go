@@ -0,0 +1,51 @@
+// Package dto/custom_fields.go
+package dto
+
+import "github.com/4cecoder/saas/models"
+
+// CreateCustomFieldRequest is the request body for defining a custom field
+type CreateCustomFieldRequest struct {
+	// EntityType is the record the field extends: users or organizations
+	EntityType string                 `json:"entity_type" binding:"required,oneof=users organizations"`
+	Key        string                 `json:"key" binding:"required,max=64"`
+	Name       string                 `json:"name" binding:"required,max=100"`
+	Type       models.CustomFieldType `json:"type" binding:"required,oneof=text number date select"`
+	// Options are the values a select field accepts
+	Options  []string `json:"options" binding:"max=100,dive,required,max=100"`
+	Required bool     `json:"required"`
+}
+
+// Model returns the custom field to create
+func (r CreateCustomFieldRequest) Model() models.CustomField {
+	return models.CustomField{
+		EntityType: r.EntityType,
+		Key:        r.Key,
+		Name:       r.Name,
+		Type:       r.Type,
+		Options:    r.Options,
+		Required:   r.Required,
+	}
+}
+
+// UpdateCustomFieldRequest is the request body for updating a custom field;
+// its key, entity type and type can't be changed
+type UpdateCustomFieldRequest struct {
+	Name     *string   `json:"name" binding:"omitempty,min=1,max=100"`
+	Options  *[]string `json:"options" binding:"omitempty,max=100,dive,required,max=100"`
+	Required *bool     `json:"required"`
+}
+
+// Apply copies the given fields onto the custom field
+func (r UpdateCustomFieldRequest) Apply(f *models.CustomField) {
+	set(&f.Name, r.Name)
+	set(&f.Options, r.Options)
+	set(&f.Required, r.Required)
+}
+
+// SetCustomFieldValuesRequest is the request body for setting the custom field
+// values of a record
+type SetCustomFieldValuesRequest struct {
+	// Values are keyed by field; null clears a field and fields not listed
+	// are left unchanged
+	Values map[string]interface{} `json:"values" binding:"required"`
+}
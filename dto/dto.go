@@ -0,0 +1,24 @@
+// Package dto/dto.go
+package dto
+
+// Request bodies are decoded into the structs of this package instead of into the
+// GORM models, so clients can only set the fields listed here. Their binding tags are
+// checked by gin's validator before they are mapped onto a model.
+
+// Creator maps a create request to a new model
+type Creator[T any] interface {
+	Model() T
+}
+
+// Updater applies an update request to a stored model, leaving fields the request
+// doesn't contain unchanged
+type Updater[T any] interface {
+	Apply(m *T)
+}
+
+// set copies a requested value over the current one when it was given
+func set[V any](dst *V, src *V) {
+	if src != nil {
+		*dst = *src
+	}
+}
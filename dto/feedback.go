@@ -0,0 +1,34 @@
+// Package dto/feedback.go
+package dto
+
+import "github.com/4cecoder/saas/models"
+
+// SubmitFeedbackRequest is the request body for submitting feedback or an NPS
+// response
+type SubmitFeedbackRequest struct {
+	Kind models.FeedbackKind `json:"kind" binding:"required,oneof=nps feedback"`
+	// Score is required for NPS responses, from 0 to 10
+	Score *int `json:"score" binding:"omitempty,min=0,max=10"`
+	// Message is required for feedback, optional for NPS responses
+	Message  string `json:"message" binding:"max=5000"`
+	Category string `json:"category" binding:"max=64"`
+	Page     string `json:"page" binding:"max=2048"`
+	// OrganizationID defaults to the organization of the session
+	OrganizationID *uint                  `json:"organization_id"`
+	Metadata       map[string]interface{} `json:"metadata" binding:"max=50"`
+}
+
+// Model returns the feedback to record
+func (r SubmitFeedbackRequest) Model() models.Feedback {
+	f := models.Feedback{
+		Kind:     r.Kind,
+		Message:  r.Message,
+		Category: r.Category,
+		Page:     r.Page,
+		Metadata: r.Metadata,
+	}
+	if r.Kind == models.FeedbackKindNPS {
+		f.Score = r.Score
+	}
+	return f
+}
@@ -0,0 +1,146 @@
+// Package dto/organizations.go
+package dto
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// OrganizationSettingsRequest holds the organization settings that may change
+// along with the organization. Retention is changed through its own endpoint,
+// and the security policies by the organization's admins only, with
+// UpdateOrganizationSettingsRequest.
+type OrganizationSettingsRequest struct {
+	LogoURL         *string `json:"logo_url" binding:"omitempty,url,max=2048"`
+	ThemeColor      *string `json:"theme_color" binding:"omitempty,hexcolor"`
+	DefaultLocale   *string `json:"default_locale" binding:"omitempty,bcp47_language_tag"`
+	DefaultTimezone *string `json:"default_timezone" binding:"omitempty,timezone"`
+}
+
+// apply copies the given settings onto the organization's
+func (r *OrganizationSettingsRequest) apply(s *models.OrganizationSettings) {
+	if r == nil {
+		return
+	}
+	set(&s.LogoURL, r.LogoURL)
+	set(&s.ThemeColor, r.ThemeColor)
+	set(&s.DefaultLocale, r.DefaultLocale)
+	set(&s.DefaultTimezone, r.DefaultTimezone)
+}
+
+// UpdateOrganizationSettingsRequest is the request body for changing the
+// settings of an organization, its security policies included
+type UpdateOrganizationSettingsRequest struct {
+	OrganizationSettingsRequest
+	RequireTwoFactor       *bool     `json:"require_two_factor"`
+	SessionLifetimeMinutes *int      `json:"session_lifetime_minutes" binding:"omitempty,min=0,max=43200"`
+	AllowedEmailDomains    *[]string `json:"allowed_email_domains" binding:"omitempty,max=100,dive,fqdn,max=253"`
+}
+
+// Apply copies the given settings onto the organization, for updating only them
+func (r *UpdateOrganizationSettingsRequest) Apply(org *models.Organization) {
+	s := &org.Settings
+	r.OrganizationSettingsRequest.apply(s)
+	set(&s.RequireTwoFactor, r.RequireTwoFactor)
+	set(&s.SessionLifetimeMinutes, r.SessionLifetimeMinutes)
+	if r.AllowedEmailDomains != nil {
+		// Domains are compared case-insensitively, so keep each once in lower case
+		domains := []string{}
+		for _, d := range *r.AllowedEmailDomains {
+			d = strings.ToLower(d)
+			if !slices.Contains(domains, d) {
+				domains = append(domains, d)
+			}
+		}
+		s.AllowedEmailDomains = domains
+	}
+}
+
+// CreateOrganizationRequest is the request body for creating an organization
+type CreateOrganizationRequest struct {
+	Name     string                       `json:"name" binding:"required,max=100"`
+	Settings *OrganizationSettingsRequest `json:"settings"`
+	// Region keeps the organization's data in a region, this deployment's by
+	// default. It can't be changed afterwards.
+	Region string `json:"region" binding:"omitempty,max=32"`
+}
+
+// Model returns the organization to create
+func (r CreateOrganizationRequest) Model() models.Organization {
+	org := models.Organization{Name: r.Name, Region: r.Region}
+	r.Settings.apply(&org.Settings)
+	return org
+}
+
+// UpdateOrganizationRequest is the request body for updating an organization
+type UpdateOrganizationRequest struct {
+	Name     *string                      `json:"name" binding:"omitempty,min=1,max=100"`
+	Settings *OrganizationSettingsRequest `json:"settings"`
+}
+
+// Apply copies the given fields onto the organization
+func (r UpdateOrganizationRequest) Apply(org *models.Organization) {
+	set(&org.Name, r.Name)
+	r.Settings.apply(&org.Settings)
+}
+
+// CreateSeatRequest is the request body for creating a seat
+type CreateSeatRequest struct {
+	OrganizationID uint              `json:"organization_id" binding:"required"`
+	UserID         uint              `json:"user_id" binding:"required"`
+	Status         models.SeatStatus `json:"status" binding:"omitempty,oneof=active inactive invited"`
+}
+
+// Model returns the seat to create
+func (r CreateSeatRequest) Model() models.Seat {
+	return models.Seat{OrganizationID: r.OrganizationID, UserID: r.UserID, Status: r.Status}
+}
+
+// UpdateSeatRequest is the request body for updating a seat
+type UpdateSeatRequest struct {
+	Status *models.SeatStatus `json:"status" binding:"omitempty,oneof=active inactive invited"`
+}
+
+// Apply copies the given fields onto the seat
+func (r UpdateSeatRequest) Apply(seat *models.Seat) {
+	set(&seat.Status, r.Status)
+}
+
+// InviteUsersRequest is the request body for inviting users to an organization
+type InviteUsersRequest struct {
+	Emails []string `json:"emails" binding:"required,min=1,max=500,dive,required,email,max=254"`
+	// Role is given to the seats of every invited user, user by default
+	Role string `json:"role" binding:"max=64"`
+}
+
+// UpdateBrandingRequest is the request body for changing the branding of an
+// organization; logos and favicons are uploaded on their own
+type UpdateBrandingRequest struct {
+	ThemeColor  *string `json:"theme_color" binding:"omitempty,hexcolor"`
+	EmailFooter *string `json:"email_footer" binding:"omitempty,max=2000"`
+	// Domain is the white-label domain of the organization's frontend; empty
+	// removes it
+	Domain *string `json:"domain" binding:"omitempty,max=253"`
+}
+
+// Apply copies the given fields onto the organization
+func (r *UpdateBrandingRequest) Apply(org *models.Organization) {
+	set(&org.Settings.ThemeColor, r.ThemeColor)
+	set(&org.Settings.EmailFooter, r.EmailFooter)
+	if r.Domain != nil {
+		org.Settings.Domain = nil
+		if domain := strings.ToLower(*r.Domain); domain != "" {
+			org.Settings.Domain = &domain
+		}
+	}
+}
+
+// Branding is the public look of an organization, shown before users sign in
+type Branding struct {
+	Name       string `json:"name"`
+	LogoURL    string `json:"logo_url"`
+	FaviconURL string `json:"favicon_url"`
+	ThemeColor string `json:"theme_color"`
+}
@@ -0,0 +1,97 @@
+// Package dto/reports.go
+package dto
+
+import (
+	"time"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// CreateReportRequest is the request body for creating a report definition
+type CreateReportRequest struct {
+	Name           string             `json:"name" binding:"required,max=200"`
+	Description    string             `json:"description" binding:"max=2000"`
+	Definition     models.ReportQuery `json:"definition"`
+	OrganizationID uint               `json:"organization_id" binding:"required"`
+	// TeamID scopes the report to a team of its organization; none shares it
+	// with the whole organization
+	TeamID     *uint    `json:"team_id"`
+	Schedule   string   `json:"schedule" binding:"max=100"`
+	Recipients []string `json:"recipients" binding:"max=100,dive,email,max=254"`
+	Format     string   `json:"format" binding:"omitempty,oneof=csv xlsx pdf"`
+}
+
+// Model returns the report to create
+func (r CreateReportRequest) Model() models.Report {
+	return models.Report{
+		Name:           r.Name,
+		Description:    r.Description,
+		Definition:     r.Definition,
+		OrganizationID: r.OrganizationID,
+		TeamID:         r.TeamID,
+		Schedule:       r.Schedule,
+		Recipients:     r.Recipients,
+		Format:         r.Format,
+	}
+}
+
+// UpdateReportRequest is the request body for replacing a report definition.
+// Its organization can't be changed.
+type UpdateReportRequest struct {
+	Name        string             `json:"name" binding:"required,max=200"`
+	Description string             `json:"description" binding:"max=2000"`
+	Definition  models.ReportQuery `json:"definition"`
+	// TeamID scopes the report to a team of its organization; none shares it
+	// with the whole organization
+	TeamID     *uint    `json:"team_id"`
+	Schedule   string   `json:"schedule" binding:"max=100"`
+	Recipients []string `json:"recipients" binding:"max=100,dive,email,max=254"`
+	Format     string   `json:"format" binding:"omitempty,oneof=csv xlsx pdf"`
+}
+
+// Apply replaces the definition of the report with the request's
+func (r UpdateReportRequest) Apply(report *models.Report) {
+	report.Name = r.Name
+	report.Description = r.Description
+	report.Definition = r.Definition
+	report.TeamID = r.TeamID
+	report.Schedule = r.Schedule
+	report.Recipients = r.Recipients
+	report.Format = r.Format
+}
+
+// Report is a report definition as returned to clients
+type Report struct {
+	ID             uint               `json:"id"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Name           string             `json:"name"`
+	Description    string             `json:"description"`
+	Definition     models.ReportQuery `json:"definition"`
+	OrganizationID uint               `json:"organization_id"`
+	TeamID         *uint              `json:"team_id"`
+	CreatorID      uint               `json:"creator_id"`
+	Schedule       string             `json:"schedule"`
+	Recipients     []string           `json:"recipients"`
+	Format         string             `json:"format"`
+	LastRunAt      time.Time          `json:"last_run_at"`
+}
+
+// NewReport returns the response body of a report
+func NewReport(r models.Report) Report {
+	return Report{
+		ID:             r.ID,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+		Name:           r.Name,
+		Description:    r.Description,
+		Definition:     r.Definition,
+		OrganizationID: r.OrganizationID,
+		TeamID:         r.TeamID,
+		CreatorID:      r.CreatorID,
+		Schedule:       r.Schedule,
+		Recipients:     r.Recipients,
+		Format:         r.Format,
+		LastRunAt:      r.LastRunAt,
+	}
+}
@@ -0,0 +1,33 @@
+// Package dto/retention.go
+package dto
+
+import "github.com/4cecoder/saas/models"
+
+// RetentionRequest is the request body for updating how many days an
+// organization keeps its audit and activity logs; zero keeps them forever
+type RetentionRequest struct {
+	AuditLogRetentionDays    *int `json:"audit_log_retention_days" binding:"omitempty,min=0,max=3650"`
+	ActivityLogRetentionDays *int `json:"activity_log_retention_days" binding:"omitempty,min=0,max=3650"`
+}
+
+// Apply copies the fields that were set onto the organization's settings
+func (r RetentionRequest) Apply(org *models.Organization) {
+	set(&org.Settings.AuditLogRetentionDays, r.AuditLogRetentionDays)
+	set(&org.Settings.ActivityLogRetentionDays, r.ActivityLogRetentionDays)
+}
+
+// RetentionRuleRequest is the request body for setting how long an
+// organization keeps the records of a resource
+type RetentionRuleRequest struct {
+	Days int `json:"days" binding:"required,min=1,max=3650"`
+	// Action is archive, the default, or purge
+	Action models.RetentionAction `json:"action" binding:"omitempty,oneof=archive purge"`
+}
+
+// Apply copies the fields onto the rule, archiving unless told to purge
+func (r RetentionRuleRequest) Apply(rule *models.RetentionRule) {
+	rule.Days, rule.Action = r.Days, r.Action
+	if rule.Action == "" {
+		rule.Action = models.RetentionArchive
+	}
+}
@@ -0,0 +1,46 @@
+// Package dto/sessions.go
+package dto
+
+// CreateSessionRequest is the request body for signing in with a session cookie
+type CreateSessionRequest struct {
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+// ChangePasswordRequest is the request body for changing the signed-in user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required,max=72"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// SwitchOrganizationRequest is the request body for scoping the signed-in user's token to an organization
+type SwitchOrganizationRequest struct {
+	OrganizationID uint `json:"organization_id" binding:"required"`
+}
+
+// DenyLoginRequest is the request body for denying a sign-in the user was
+// alerted of, with the token of the alert's link and a new password
+type DenyLoginRequest struct {
+	Token       string `json:"token" binding:"required,max=128"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// ChangeEmailRequest is the request body for changing the signed-in user's
+// email, confirmed by both the current and the new address
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+// ConfirmEmailChangeRequest is the request body for confirming an email change
+// with the token of the link emailed to one of the addresses
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required,max=128"`
+}
+
+// AcceptInvitationRequest is the request body for accepting an invitation with
+// the token of its link; users without a password choose it here
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required,max=128"`
+	Password string `json:"password" binding:"omitempty,min=8,max=72"`
+}
@@ -0,0 +1,15 @@
+// Package dto/settings.go
+package dto
+
+// UpdateSettingRequest is the request body for changing a runtime setting
+type UpdateSettingRequest struct {
+	Value *string `json:"value" binding:"required"`
+}
+
+// Maintenance is the maintenance mode of the service, and the request body for
+// switching it
+type Maintenance struct {
+	Mode string `json:"mode" binding:"required,oneof=off read_only full"`
+	// Message replaces the default message of rejected responses when set
+	Message string `json:"message" binding:"max=500"`
+}
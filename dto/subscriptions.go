@@ -0,0 +1,47 @@
+// Package dto/subscriptions.go
+package dto
+
+import (
+	"time"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// CreateSubscriptionRequest is the request body for creating a subscription
+type CreateSubscriptionRequest struct {
+	OrganizationID  uint                      `json:"organization_id" binding:"required"`
+	Status          models.SubscriptionStatus `json:"status" binding:"omitempty,oneof=active inactive trialing canceled"`
+	PaymentMethod   string                    `json:"payment_method" binding:"max=100"`
+	StartDate       *time.Time                `json:"start_date"`
+	EndDate         *time.Time                `json:"end_date"`
+	NextBillingDate *time.Time                `json:"next_billing_date"`
+}
+
+// Model returns the subscription to create; status and start date default when saved
+func (r CreateSubscriptionRequest) Model() models.Subscription {
+	sub := models.Subscription{
+		OrganizationID: r.OrganizationID,
+		Status:         r.Status,
+		PaymentMethod:  r.PaymentMethod,
+	}
+	set(&sub.StartDate, r.StartDate)
+	set(&sub.EndDate, r.EndDate)
+	set(&sub.NextBillingDate, r.NextBillingDate)
+	return sub
+}
+
+// UpdateSubscriptionRequest is the request body for updating a subscription
+type UpdateSubscriptionRequest struct {
+	Status          *models.SubscriptionStatus `json:"status" binding:"omitempty,oneof=active inactive trialing canceled"`
+	PaymentMethod   *string                    `json:"payment_method" binding:"omitempty,max=100"`
+	EndDate         *time.Time                 `json:"end_date"`
+	NextBillingDate *time.Time                 `json:"next_billing_date"`
+}
+
+// Apply copies the given fields onto the subscription
+func (r UpdateSubscriptionRequest) Apply(sub *models.Subscription) {
+	set(&sub.Status, r.Status)
+	set(&sub.PaymentMethod, r.PaymentMethod)
+	set(&sub.EndDate, r.EndDate)
+	set(&sub.NextBillingDate, r.NextBillingDate)
+}
@@ -0,0 +1,27 @@
+// Package dto/tags.go
+package dto
+
+import "github.com/4cecoder/saas/models"
+
+// CreateTagRequest is the request body for adding a tag to an organization's vocabulary
+type CreateTagRequest struct {
+	Name  string `json:"name" binding:"required,max=50"`
+	Color string `json:"color" binding:"omitempty,hexcolor,max=7"`
+}
+
+// Model returns the tag to create
+func (r CreateTagRequest) Model() models.Tag {
+	return models.Tag{Name: r.Name, Color: r.Color}
+}
+
+// UpdateTagRequest is the request body for renaming or recoloring a tag
+type UpdateTagRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=1,max=50"`
+	Color *string `json:"color" binding:"omitempty,hexcolor,max=7"`
+}
+
+// Apply copies the given fields onto the tag
+func (r UpdateTagRequest) Apply(t *models.Tag) {
+	set(&t.Name, r.Name)
+	set(&t.Color, r.Color)
+}
@@ -0,0 +1,35 @@
+// Package dto/teams.go
+package dto
+
+import "github.com/4cecoder/saas/models"
+
+// CreateTeamRequest is the request body for creating a team
+type CreateTeamRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=2000"`
+}
+
+// Model returns the team to create
+func (r CreateTeamRequest) Model() models.Team {
+	return models.Team{Name: r.Name, Description: r.Description}
+}
+
+// UpdateTeamRequest is the request body for updating a team
+type UpdateTeamRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+}
+
+// Apply copies the given fields onto the team
+func (r UpdateTeamRequest) Apply(t *models.Team) {
+	set(&t.Name, r.Name)
+	set(&t.Description, r.Description)
+}
+
+// PutTeamMemberRequest is the request body for adding a member to a team or
+// changing their roles in it
+type PutTeamMemberRequest struct {
+	// Roles are the names of the roles granting the member's permissions in
+	// the team; none makes them a member without any
+	Roles []string `json:"roles" binding:"max=20,dive,required,max=64"`
+}
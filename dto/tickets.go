@@ -0,0 +1,23 @@
+// Package dto/tickets.go
+package dto
+
+import "github.com/4cecoder/saas/models"
+
+// OpenTicketRequest is the request body for opening a support ticket
+type OpenTicketRequest struct {
+	// OrganizationID defaults to the organization of the session
+	OrganizationID *uint  `json:"organization_id"`
+	Subject        string `json:"subject" binding:"required,max=200"`
+	Message        string `json:"message" binding:"required,max=20000"`
+}
+
+// TicketReplyRequest is the request body for replying to a ticket
+type TicketReplyRequest struct {
+	Body string `json:"body" binding:"required,max=20000"`
+}
+
+// UpdateTicketStatusRequest is the request body for changing the status of a
+// ticket
+type UpdateTicketStatusRequest struct {
+	Status models.TicketStatus `json:"status" binding:"required,oneof=open pending resolved closed"`
+}
@@ -0,0 +1,50 @@
+// Package dto/users.go
+package dto
+
+import "github.com/4cecoder/saas/models"
+
+// CreateUserRequest is the request body for creating a user
+type CreateUserRequest struct {
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+	Name     string `json:"name" binding:"required,max=100"`
+	Locale   string `json:"locale" binding:"omitempty,bcp47_language_tag"`
+	Timezone string `json:"timezone" binding:"omitempty,timezone"`
+	Language string `json:"language" binding:"omitempty,bcp47_language_tag"`
+}
+
+// Model returns the user to create; the password is hashed when it is saved
+func (r CreateUserRequest) Model() models.User {
+	return models.User{
+		Email:    r.Email,
+		Password: r.Password,
+		Name:     r.Name,
+		Locale:   r.Locale,
+		Timezone: r.Timezone,
+		Language: r.Language,
+	}
+}
+
+// UpdateUserRequest is the request body for updating a user. The email is
+// changed through POST /me/email, confirmed by both addresses, and the
+// password through PUT /me/password.
+type UpdateUserRequest struct {
+	Name     *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Locale   *string `json:"locale" binding:"omitempty,bcp47_language_tag"`
+	Timezone *string `json:"timezone" binding:"omitempty,timezone"`
+	Language *string `json:"language" binding:"omitempty,bcp47_language_tag"`
+}
+
+// Apply copies the given fields onto the user
+func (r UpdateUserRequest) Apply(u *models.User) {
+	set(&u.Name, r.Name)
+	set(&u.Locale, r.Locale)
+	set(&u.Timezone, r.Timezone)
+	set(&u.Language, r.Language)
+}
+
+// SuspendUserRequest is the request body for suspending a user
+type SuspendUserRequest struct {
+	// Reason is shown to admins, not to the user
+	Reason string `json:"reason" binding:"max=500"`
+}
@@ -0,0 +1,92 @@
+// Package dto/workflows.go
+package dto
+
+import (
+	"time"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// CreateWorkflowRequest is the request body for creating a workflow
+type CreateWorkflowRequest struct {
+	Name           string                `json:"name" binding:"required,max=200"`
+	Description    string                `json:"description" binding:"max=2000"`
+	Steps          []models.WorkflowStep `json:"steps" binding:"max=100"`
+	Triggers       []string              `json:"triggers" binding:"max=50"`
+	OrganizationID uint                  `json:"organization_id" binding:"required"`
+	// TeamID scopes the workflow to a team of its organization; none shares it
+	// with the whole organization
+	TeamID  *uint `json:"team_id"`
+	Enabled bool  `json:"enabled"`
+}
+
+// Model returns the workflow to create
+func (r CreateWorkflowRequest) Model() models.Workflow {
+	return models.Workflow{
+		Name:           r.Name,
+		Description:    r.Description,
+		Steps:          r.Steps,
+		Triggers:       r.Triggers,
+		OrganizationID: r.OrganizationID,
+		TeamID:         r.TeamID,
+		Enabled:        r.Enabled,
+	}
+}
+
+// UpdateWorkflowRequest is the request body for replacing a workflow's
+// definition. Its organization can't be changed, and its version is bumped by
+// the server.
+type UpdateWorkflowRequest struct {
+	Name        string                `json:"name" binding:"required,max=200"`
+	Description string                `json:"description" binding:"max=2000"`
+	Steps       []models.WorkflowStep `json:"steps" binding:"max=100"`
+	Triggers    []string              `json:"triggers" binding:"max=50"`
+	// TeamID scopes the workflow to a team of its organization; none shares it
+	// with the whole organization
+	TeamID  *uint `json:"team_id"`
+	Enabled bool  `json:"enabled"`
+}
+
+// Apply replaces the definition of the workflow with the request's
+func (r UpdateWorkflowRequest) Apply(wf *models.Workflow) {
+	wf.Name = r.Name
+	wf.Description = r.Description
+	wf.Steps = r.Steps
+	wf.Triggers = r.Triggers
+	wf.TeamID = r.TeamID
+	wf.Enabled = r.Enabled
+}
+
+// Workflow is a workflow as returned to clients
+type Workflow struct {
+	ID             uint                  `json:"id"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	Name           string                `json:"name"`
+	Description    string                `json:"description"`
+	Steps          []models.WorkflowStep `json:"steps"`
+	Triggers       []string              `json:"triggers"`
+	OrganizationID uint                  `json:"organization_id"`
+	TeamID         *uint                 `json:"team_id"`
+	CreatorID      uint                  `json:"creator_id"`
+	Enabled        bool                  `json:"enabled"`
+	Version        int                   `json:"version"`
+}
+
+// NewWorkflow returns the response body of a workflow
+func NewWorkflow(wf models.Workflow) Workflow {
+	return Workflow{
+		ID:             wf.ID,
+		CreatedAt:      wf.CreatedAt,
+		UpdatedAt:      wf.UpdatedAt,
+		Name:           wf.Name,
+		Description:    wf.Description,
+		Steps:          wf.Steps,
+		Triggers:       wf.Triggers,
+		OrganizationID: wf.OrganizationID,
+		TeamID:         wf.TeamID,
+		CreatorID:      wf.CreatorID,
+		Enabled:        wf.Enabled,
+		Version:        wf.Version,
+	}
+}
@@ -0,0 +1,172 @@
+// Package encryption/encryption.go
+//
+// Package encryption encrypts sensitive columns at rest with AES-256-GCM. Fields
+// tagged gorm:"serializer:encrypted" are encrypted with the current key when
+// written and decrypted with the key that encrypted them when read, so keys
+// rotate without downtime: add a key in front, re-encrypt the rows with
+// saas encryption rotate, then drop the old key.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// prefix marks encrypted values, followed by the ID of their key and the
+// base64 of the nonce and ciphertext. Values without it are legacy plaintext.
+const prefix = "enc:"
+
+// Key is an AES-256 key and the ID values encrypted with it are tagged with
+type Key struct {
+	ID  string
+	Key []byte
+}
+
+// Keyring encrypts with its first key and decrypts with any of them
+type Keyring struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring encrypting with the first of keys
+func NewKeyring(keys []Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no keys")
+	}
+	k := &Keyring{current: keys[0].ID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ":") {
+			return nil, fmt.Errorf("invalid key ID %q", key.ID)
+		}
+		if _, ok := k.aeads[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		if len(key.Key) != 32 {
+			return nil, fmt.Errorf("key %s is %d bytes, AES-256 needs 32", key.ID, len(key.Key))
+		}
+		block, err := aes.NewCipher(key.Key)
+		if err != nil {
+			return nil, err
+		}
+		if k.aeads[key.ID], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// ParseKeys parses keys written as id:base64, comma-separated, the current one first
+func ParseKeys(s string) ([]Key, error) {
+	var keys []Key
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("%q is not id:base64-key", part)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s is not base64: %w", id, err)
+		}
+		keys = append(keys, Key{ID: id, Key: key})
+	}
+	return keys, nil
+}
+
+// Encrypt encrypts plaintext with the current key
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	aead := k.aeads[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(k.current))
+	return prefix + k.current + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value returned by Encrypt with any of the keys
+func (k *Keyring) Decrypt(value string) ([]byte, error) {
+	id, sealed, err := split(value)
+	if err != nil {
+		return nil, err
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("decrypt: unknown key %q", id)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("decrypt: value too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt with key %s: %w", id, err)
+	}
+	return plaintext, nil
+}
+
+// Current reports whether value is encrypted with the current key, rather
+// than an older key or not at all
+func (k *Keyring) Current(value string) bool {
+	id, _, err := split(value)
+	return err == nil && id == k.current
+}
+
+// Encrypted reports whether value was encrypted, rather than legacy plaintext
+func Encrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// split returns the key ID and sealed bytes of an encrypted value
+func split(value string) (string, []byte, error) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return "", nil, errors.New("decrypt: value isn't encrypted")
+	}
+	id, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", nil, errors.New("decrypt: malformed value")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return id, sealed, nil
+}
+
+var (
+	// keyring encrypts the fields of models; nil stores them in plaintext
+	keyring   *Keyring
+	keyringMu sync.RWMutex
+)
+
+// Setup encrypts the fields of models with keys; no keys store them in plaintext
+func Setup(keys []Key) error {
+	var k *Keyring
+	if len(keys) > 0 {
+		var err error
+		if k, err = NewKeyring(keys); err != nil {
+			return err
+		}
+	}
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	keyring = k
+	return nil
+}
+
+// Default returns the keyring the fields of models are encrypted with, or nil
+func Default() *Keyring {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+	return keyring
+}
@@ -0,0 +1,96 @@
+// Package encryption/rotate.go
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// rotateBatchSize is how many rows are re-encrypted per query
+const rotateBatchSize = 500
+
+// Reencrypt encrypts the encrypted fields of the rows of model with the
+// current key where they're encrypted with an older key or not at all,
+// soft-deleted rows included. It returns the number of rows updated.
+func Reencrypt(ctx context.Context, db *gorm.DB, model interface{}) (int, error) {
+	k := Default()
+	if k == nil {
+		return 0, errors.New("no encryption keys are set up")
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return 0, err
+	}
+	pk := stmt.Schema.PrioritizedPrimaryField
+	var columns []string
+	for _, field := range stmt.Schema.Fields {
+		if field.TagSettings["SERIALIZER"] == "encrypted" {
+			columns = append(columns, field.DBName)
+		}
+	}
+	if pk == nil || len(columns) == 0 {
+		return 0, nil
+	}
+
+	// The columns are read and written raw, bypassing the serializer
+	db = db.WithContext(ctx)
+	table := stmt.Schema.Table
+	updated := 0
+	var last interface{} = 0
+	for {
+		var rows []map[string]interface{}
+		err := db.Table(table).
+			Select(append([]string{pk.DBName}, columns...)).
+			Where(fmt.Sprintf("%s > ?", stmt.Quote(pk.DBName)), last).
+			Order(pk.DBName).
+			Limit(rotateBatchSize).
+			Find(&rows).Error
+		if err != nil {
+			return updated, err
+		}
+
+		for _, row := range rows {
+			last = row[pk.DBName]
+			changes := map[string]interface{}{}
+			for _, column := range columns {
+				value := rawString(row[column])
+				if value == "" || k.Current(value) {
+					continue
+				}
+				plaintext := []byte(value)
+				if Encrypted(value) {
+					if plaintext, err = k.Decrypt(value); err != nil {
+						return updated, fmt.Errorf("%s %v: %s: %w", table, last, column, err)
+					}
+				}
+				if changes[column], err = k.Encrypt(plaintext); err != nil {
+					return updated, err
+				}
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			if err := db.Table(table).Where(map[string]interface{}{pk.DBName: last}).Updates(changes).Error; err != nil {
+				return updated, err
+			}
+			updated++
+		}
+		if len(rows) < rotateBatchSize {
+			return updated, nil
+		}
+	}
+}
+
+// rawString returns a text column value read without a model
+func rawString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return ""
+}
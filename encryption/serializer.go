@@ -0,0 +1,61 @@
+// Package encryption/serializer.go
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
+
+// Serializer encrypts string fields tagged gorm:"serializer:encrypted" with
+// the keyring of Setup. Empty strings are stored as they are, and plaintext
+// values written before encryption was set up are read as they are. GORM only
+// serializes values set through the model, so update such fields with
+// Select(field).Updates(model) rather than Update(column, value).
+type Serializer struct{}
+
+// Scan implements schema.SerializerInterface, decrypting the column
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("encryption: unsupported column value %T of %s", dbValue, field.Name)
+	}
+
+	if Encrypted(value) {
+		k := Default()
+		if k == nil {
+			return fmt.Errorf("encryption: %s is encrypted but no keys are set up", field.Name)
+		}
+		plaintext, err := k.Decrypt(value)
+		if err != nil {
+			return fmt.Errorf("encryption: %s: %w", field.Name, err)
+		}
+		value = string(plaintext)
+	}
+	return field.Set(ctx, dst, value)
+}
+
+// Value implements schema.SerializerInterface, encrypting the field
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encryption: %s is a %T, not a string", field.Name, fieldValue)
+	}
+	k := Default()
+	if value == "" || k == nil {
+		return value, nil
+	}
+	return k.Encrypt([]byte(value))
+}
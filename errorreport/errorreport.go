@@ -0,0 +1,228 @@
+// Package errorreport/errorreport.go
+//
+// Package errorreport sends panics and server errors, with their stack and the
+// request they happened in, to an error tracker such as Sentry or Rollbar.
+package errorreport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand"
+	"runtime"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/logging"
+	"github.com/4cecoder/saas/requestid"
+)
+
+// Config selects the error tracker; reporting is off without a Sentry DSN or
+// Rollbar token
+type Config struct {
+	SentryDSN    string
+	RollbarToken string
+	// Environment tags reports, such as production or staging
+	Environment string
+	// Release tags reports with the deployed version
+	Release string
+	// SampleRate is the share of errors reported, from 0 to 1
+	SampleRate float64
+}
+
+// LevelError is the level of events unless they set another
+const LevelError = "error"
+
+// Frame is a function call of a stack
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Request describes the HTTP request an error happened in
+type Request struct {
+	Method   string
+	URL      string
+	Route    string
+	ClientIP string
+	Headers  map[string]string
+}
+
+// Event is an error to report
+type Event struct {
+	ID    string
+	Time  time.Time
+	Level string
+	// Type is the kind of error, such as panic or the Go type of the error
+	Type    string
+	Message string
+	// Stack lists the calls leading to the error, innermost first
+	Stack          []Frame
+	Request        *Request
+	UserID         uint
+	OrganizationID uint
+	Tags           map[string]string
+	Extra          map[string]interface{}
+}
+
+// Reporter sends events to an error tracker
+type Reporter interface {
+	Report(ctx context.Context, e Event) error
+}
+
+// Client samples, tags and redacts events before handing them to its Reporter
+// in the background. Its methods do nothing on a nil client, which New returns
+// while reporting is off.
+type Client struct {
+	Reporter    Reporter
+	Environment string
+	Release     string
+	SampleRate  float64
+	// Redactor scrubs personal data and credentials from events
+	Redactor *logging.Redactor
+}
+
+// New creates a client reporting to the configured tracker, or nil if none is
+func New(cfg Config, redactor *logging.Redactor) (*Client, error) {
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		return nil, fmt.Errorf("invalid sample rate %v, expected a number from 0 to 1", cfg.SampleRate)
+	}
+
+	var reporter Reporter
+	switch {
+	case cfg.SentryDSN != "":
+		sentry, err := NewSentry(cfg.SentryDSN)
+		if err != nil {
+			return nil, err
+		}
+		reporter = sentry
+	case cfg.RollbarToken != "":
+		reporter = NewRollbar(cfg.RollbarToken)
+	default:
+		return nil, nil
+	}
+
+	return &Client{
+		Reporter:    reporter,
+		Environment: cfg.Environment,
+		Release:     cfg.Release,
+		SampleRate:  cfg.SampleRate,
+		Redactor:    redactor,
+	}, nil
+}
+
+// Report sends e unless it's sampled out, adding the request, user,
+// organization and trace of ctx
+func (c *Client) Report(ctx context.Context, e Event) {
+	if c == nil || mathrand.Float64() >= c.SampleRate {
+		return
+	}
+
+	if e.ID == "" {
+		var id [16]byte
+		rand.Read(id[:])
+		e.ID = hex.EncodeToString(id[:])
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Level == "" {
+		e.Level = LevelError
+	}
+
+	tags := map[string]string{}
+	for k, v := range e.Tags {
+		tags[k] = v
+	}
+	if c.Environment != "" {
+		tags["environment"] = c.Environment
+	}
+	if c.Release != "" {
+		tags["release"] = c.Release
+	}
+	if id := requestid.From(ctx); id != "" {
+		tags["request_id"] = id
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		tags["trace_id"] = sc.TraceID().String()
+	}
+	e.Tags = tags
+	if id, ok := auth.UserIDFromContext(ctx); ok && e.UserID == 0 {
+		e.UserID = id
+	}
+	if id, ok := logging.OrganizationID(ctx); ok && e.OrganizationID == 0 {
+		e.OrganizationID = id
+	}
+
+	c.redact(&e)
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		if err := c.Reporter.Report(ctx, e); err != nil {
+			slog.WarnContext(ctx, "errorreport: failed to report error", "event_id", e.ID, "error", err)
+		}
+	}()
+}
+
+// redact scrubs the message, request and extra data of e
+func (c *Client) redact(e *Event) {
+	if c.Redactor == nil {
+		return
+	}
+	e.Message = c.Redactor.String(e.Message)
+	if e.Extra != nil {
+		e.Extra = c.Redactor.Map(e.Extra)
+	}
+	if e.Request != nil {
+		req := *e.Request
+		req.URL = c.Redactor.String(req.URL)
+		headers := make(map[string]interface{}, len(req.Headers))
+		for k, v := range req.Headers {
+			headers[k] = v
+		}
+		req.Headers = map[string]string{}
+		for k, v := range c.Redactor.Map(headers) {
+			req.Headers[k] = fmt.Sprint(v)
+		}
+		e.Request = &req
+	}
+}
+
+// PanicStack returns the stack of the goroutine panicking, called from the
+// function deferred to recover
+func PanicStack() []Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []Frame
+	for {
+		f, more := frames.Next()
+		stack = append(stack, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		// Drop the recovery machinery above the panic
+		if f.Function == "runtime.gopanic" {
+			stack = stack[:0]
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// function splits a qualified function name into its package and name
+func function(qualified string) (string, string) {
+	slash := strings.LastIndex(qualified, "/")
+	if dot := strings.Index(qualified[slash+1:], "."); dot >= 0 {
+		return qualified[:slash+1+dot], qualified[slash+1+dot+1:]
+	}
+	return "", qualified
+}
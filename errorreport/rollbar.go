@@ -0,0 +1,92 @@
+// Package errorreport/rollbar.go
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RollbarURL is the item endpoint of Rollbar's API
+const RollbarURL = "https://api.rollbar.com/api/1/item/"
+
+// Rollbar reports events to Rollbar
+type Rollbar struct {
+	URL string
+	// Token is a project access token with the post_server_item scope
+	Token  string
+	Client *http.Client
+}
+
+// NewRollbar creates a reporter posting with a project access token
+func NewRollbar(token string) *Rollbar {
+	return &Rollbar{URL: RollbarURL, Token: token, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Report implements Reporter
+func (r *Rollbar) Report(ctx context.Context, e Event) error {
+	// Rollbar lists frames outermost first
+	frames := make([]map[string]interface{}, 0, len(e.Stack))
+	for i := len(e.Stack) - 1; i >= 0; i-- {
+		f := e.Stack[i]
+		frames = append(frames, map[string]interface{}{
+			"filename": f.File,
+			"lineno":   f.Line,
+			"method":   f.Function,
+		})
+	}
+
+	custom := map[string]interface{}{}
+	for k, v := range e.Extra {
+		custom[k] = v
+	}
+	for k, v := range e.Tags {
+		custom[k] = v
+	}
+	if e.OrganizationID != 0 {
+		custom["org_id"] = e.OrganizationID
+	}
+
+	data := map[string]interface{}{
+		"uuid":        e.ID,
+		"timestamp":   e.Time.Unix(),
+		"level":       e.Level,
+		"platform":    "go",
+		"language":    "go",
+		"environment": e.Tags["environment"],
+		"body": map[string]interface{}{"trace": map[string]interface{}{
+			"frames":    frames,
+			"exception": map[string]interface{}{"class": e.Type, "message": e.Message},
+		}},
+		"custom": custom,
+	}
+	if release, ok := e.Tags["release"]; ok {
+		data["code_version"] = release
+	}
+	if e.Request != nil {
+		data["request"] = map[string]interface{}{
+			"method":  e.Request.Method,
+			"url":     e.Request.URL,
+			"headers": e.Request.Headers,
+			"user_ip": e.Request.ClientIP,
+		}
+	}
+	if e.UserID != 0 {
+		data["person"] = map[string]string{"id": strconv.FormatUint(uint64(e.UserID), 10)}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Rollbar-Access-Token", r.Token)
+	return send(r.Client, req)
+}
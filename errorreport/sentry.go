@@ -0,0 +1,136 @@
+// Package errorreport/sentry.go
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// appModule marks the frames of this application, which trackers highlight
+const appModule = "github.com/4cecoder/saas"
+
+// Sentry reports events to Sentry's store endpoint
+type Sentry struct {
+	// URL is the store endpoint of the project
+	URL string
+	// Auth is the X-Sentry-Auth header carrying the project keys
+	Auth   string
+	Client *http.Client
+}
+
+// NewSentry creates a reporter from a DSN such as https://key@o1.ingest.sentry.io/123
+func NewSentry(dsn string) (*Sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN, expected e.g. https://key@o1.ingest.sentry.io/123")
+	}
+	dir, project := path.Split(strings.TrimSuffix(u.Path, "/"))
+	if project == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN, it has no project ID")
+	}
+
+	auth := "Sentry sentry_version=7, sentry_client=saas/1.0, sentry_key=" + u.User.Username()
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	return &Sentry{
+		URL:    u.Scheme + "://" + u.Host + dir + "api/" + project + "/store/",
+		Auth:   auth,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Report implements Reporter
+func (s *Sentry) Report(ctx context.Context, e Event) error {
+	// Sentry lists frames outermost first
+	frames := make([]map[string]interface{}, 0, len(e.Stack))
+	for i := len(e.Stack) - 1; i >= 0; i-- {
+		f := e.Stack[i]
+		module, name := function(f.Function)
+		frames = append(frames, map[string]interface{}{
+			"function": name,
+			"module":   module,
+			"abs_path": f.File,
+			"filename": path.Base(f.File),
+			"lineno":   f.Line,
+			"in_app":   strings.HasPrefix(module, appModule),
+		})
+	}
+
+	tags := map[string]string{}
+	for k, v := range e.Tags {
+		tags[k] = v
+	}
+	if e.OrganizationID != 0 {
+		tags["org_id"] = strconv.FormatUint(uint64(e.OrganizationID), 10)
+	}
+
+	event := map[string]interface{}{
+		"event_id":  e.ID,
+		"timestamp": e.Time.UTC().Format(time.RFC3339Nano),
+		"level":     e.Level,
+		"platform":  "go",
+		"exception": map[string]interface{}{"values": []interface{}{map[string]interface{}{
+			"type":       e.Type,
+			"value":      e.Message,
+			"stacktrace": map[string]interface{}{"frames": frames},
+		}}},
+		"tags":  tags,
+		"extra": e.Extra,
+	}
+	if env, ok := e.Tags["environment"]; ok {
+		event["environment"] = env
+	}
+	if release, ok := e.Tags["release"]; ok {
+		event["release"] = release
+	}
+	if e.Request != nil {
+		event["request"] = map[string]interface{}{
+			"method":  e.Request.Method,
+			"url":     e.Request.URL,
+			"headers": e.Request.Headers,
+			"env":     map[string]string{"REMOTE_ADDR": e.Request.ClientIP},
+		}
+		if e.Request.Route != "" {
+			event["transaction"] = e.Request.Method + " " + e.Request.Route
+		}
+	}
+	if e.UserID != 0 {
+		event["user"] = map[string]string{"id": strconv.FormatUint(uint64(e.UserID), 10)}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", s.Auth)
+	return send(s.Client, req)
+}
+
+// send sends a report and checks that the tracker accepted it
+func send(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("tracker responded %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Package events/changes.go
+package events
+
+import (
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/audit"
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/models"
+)
+
+// PublishChanges registers callbacks publishing "<resource>.created", "<resource>.updated"
+// and "<resource>.deleted" events for writes to the given tables. It's meant for models
+// that aren't audited, such as users, and so get no events from PublishAudit. The payload
+// only identifies the record; subscribers load its current state themselves. Events are
+// published once the write commits, with audit.AfterCommit.
+func (b *Bus) PublishChanges(db *gorm.DB, tables ...string) error {
+	watched := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		watched[t] = true
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("events:after_create", b.changed(watched, models.AuditActionCreate)); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("events:after_update", b.changed(watched, models.AuditActionUpdate)); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("events:after_delete", b.changed(watched, models.AuditActionDelete))
+}
+
+// changed returns a callback publishing an event for every record with a known primary key
+func (b *Bus) changed(watched map[string]bool, action string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.Statement.Schema == nil || !watched[db.Statement.Schema.Table] {
+			return
+		}
+
+		s := db.Statement.Schema
+		pk := s.PrioritizedPrimaryField
+		if pk == nil {
+			return
+		}
+
+		eventType := strings.TrimSuffix(s.Table, "s") + "." + pastTense[action]
+		publish := func(rv reflect.Value) {
+			id, zero := pk.ValueOf(db.Statement.Context, rv)
+			if zero {
+				return
+			}
+			userID, _ := auth.UserIDFromContext(db.Statement.Context)
+			ctx, e := db.Statement.Context, Event{
+				Type:   eventType,
+				UserID: userID,
+				Payload: models.JSONMap{
+					"resource_type": s.Table,
+					"resource_id":   id,
+					"action":        action,
+				},
+			}
+			audit.AfterCommit(db, func() { b.Publish(ctx, e) })
+		}
+
+		rv := reflect.Indirect(db.Statement.ReflectValue)
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				publish(reflect.Indirect(rv.Index(i)))
+			}
+		case reflect.Struct:
+			publish(rv)
+		}
+	}
+}
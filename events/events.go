@@ -0,0 +1,122 @@
+// Package events/events.go
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/4cecoder/saas/audit"
+	"github.com/4cecoder/saas/models"
+)
+
+// Event is a domain event such as "seat.invited" or "subscription.canceled"
+type Event struct {
+	Type           string         `json:"type"`
+	OrganizationID uint           `json:"organization_id"`
+	UserID         uint           `json:"user_id"`
+	Payload        models.JSONMap `json:"payload"`
+	OccurredAt     time.Time      `json:"occurred_at"`
+}
+
+// pastTense maps audit actions to the suffix of their change events
+var pastTense = map[string]string{
+	models.AuditActionCreate: "created",
+	models.AuditActionUpdate: "updated",
+	models.AuditActionDelete: "deleted",
+}
+
+// Handler processes a published event
+type Handler func(ctx context.Context, e Event)
+
+// Bus dispatches events to in-process subscribers
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[string][]Handler
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{subs: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for an event type, or for every event with "*"
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[eventType] = append(b.subs[eventType], h)
+}
+
+// Publish delivers an event to its subscribers in the background
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.subs[e.Type]...), b.subs["*"]...)
+	b.mu.RUnlock()
+
+	ctx = context.WithoutCancel(ctx)
+	for _, h := range handlers {
+		go func(h Handler) {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.ErrorContext(ctx, "events: handler panicked", "event", e.Type, "panic", r)
+				}
+			}()
+			h(ctx, e)
+		}(h)
+	}
+}
+
+// PublishAudit turns an audit change into model change events: "<resource>.created",
+// "<resource>.updated", or "<resource>.deleted", plus "<resource>.<status>" when the
+// record's status changes, e.g. "subscription.canceled" or "seat.invited". The payload
+// carries the record's column values and the names of the changed fields.
+func (b *Bus) PublishAudit(change audit.Change) {
+	entry := change.Entry
+	resource := strings.TrimSuffix(entry.ResourceType, "s")
+
+	payload := models.JSONMap{}
+	for field, value := range change.Record {
+		payload[field] = value
+	}
+
+	changed := make([]string, 0, len(entry.Changes))
+	for field := range entry.Changes {
+		changed = append(changed, field)
+	}
+	sort.Strings(changed)
+
+	payload["resource_type"] = entry.ResourceType
+	payload["resource_id"] = entry.ResourceID
+	payload["action"] = entry.Action
+	payload["changed"] = changed
+
+	base := Event{
+		OrganizationID: entry.OrganizationID,
+		UserID:         entry.UserID,
+		Payload:        payload,
+		OccurredAt:     entry.Timestamp,
+	}
+
+	e := base
+	e.Type = resource + "." + pastTense[entry.Action]
+	b.Publish(context.Background(), e)
+
+	if entry.Action == models.AuditActionDelete {
+		return
+	}
+	if status, ok := entry.Changes["status"].(map[string]interface{}); ok {
+		if to := fmt.Sprint(status["to"]); status["to"] != nil && to != "" {
+			e := base
+			e.Type = resource + "." + to
+			b.Publish(context.Background(), e)
+		}
+	}
+}
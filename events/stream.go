@@ -0,0 +1,161 @@
+// Package events/stream.go
+package events
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Categories of the events pushed to users
+const (
+	CategoryNotification = "notification"
+	CategoryWorkflow     = "workflow"
+	CategoryBilling      = "billing"
+)
+
+// categories are the categories of the streamed events, by their resource
+var categories = map[string]string{
+	"comment":           CategoryNotification,
+	"workflow_approval": CategoryNotification,
+	"workflow_run":      CategoryWorkflow,
+	"subscription":      CategoryBilling,
+}
+
+const (
+	// historySize is the number of recent events kept for clients resuming
+	historySize = 1000
+	// listenerBuffer is the number of events a listener may fall behind by
+	// before it's dropped
+	listenerBuffer = 64
+)
+
+// Streamed is an event pushed to users, with its ID in the stream
+type Streamed struct {
+	Event
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	seq      uint64
+}
+
+// Addressed reports whether the event is meant for the user. Events are for
+// the members of their organization, as reported by memberOf, and
+// notifications only for those they name: the approver of an approval and the
+// users a comment mentions.
+func (e Streamed) Addressed(userID uint, memberOf func(orgID uint) bool) bool {
+	if e.OrganizationID == 0 || !memberOf(e.OrganizationID) {
+		return false
+	}
+	if e.Category != CategoryNotification {
+		return true
+	}
+	if id, ok := e.Payload["approver_id"].(uint); ok && id == userID {
+		return true
+	}
+	mentions, _ := e.Payload["mentions"].([]uint)
+	return slices.Contains(mentions, userID)
+}
+
+// Stream keeps the recent notification, workflow and billing events and pushes
+// them to listeners, so clients reconnecting can resume where they left off.
+// IDs are only meaningful to the process that issued them: clients of another
+// instance, or from before a restart, can't resume.
+type Stream struct {
+	mu sync.Mutex
+	// epoch prefixes the IDs, telling apart those of other processes
+	epoch     string
+	seq       uint64
+	history   []Streamed
+	listeners map[chan Streamed]struct{}
+}
+
+// NewStream creates an empty stream
+func NewStream() *Stream {
+	return &Stream{
+		epoch:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		listeners: map[chan Streamed]struct{}{},
+	}
+}
+
+// Subscribe streams the events of the bus that have a category
+func (s *Stream) Subscribe(bus *Bus) {
+	bus.Subscribe("*", s.handleEvent)
+}
+
+// handleEvent adds a published event to the stream
+func (s *Stream) handleEvent(_ context.Context, e Event) {
+	resource, _, _ := strings.Cut(e.Type, ".")
+	category, ok := categories[resource]
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	streamed := Streamed{Event: e, ID: fmt.Sprintf("%s-%d", s.epoch, s.seq), Category: category, seq: s.seq}
+	s.history = append(s.history, streamed)
+	if len(s.history) > historySize {
+		s.history = slices.Delete(s.history, 0, len(s.history)-historySize)
+	}
+
+	for ch := range s.listeners {
+		select {
+		case ch <- streamed:
+		default:
+			// Drop listeners falling behind; their clients reconnect and resume
+			delete(s.listeners, ch)
+			close(ch)
+		}
+	}
+}
+
+// Listen returns the events after lastEventID and a channel receiving the
+// next ones, closed when the listener falls behind. resumed is false when the
+// events since lastEventID are no longer kept, or weren't streamed by this
+// process; clients should then reload what they show. stop ends listening.
+func (s *Stream) Listen(lastEventID string) (missed []Streamed, resumed bool, updates <-chan Streamed, stop func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan Streamed, listenerBuffer)
+	s.listeners[ch] = struct{}{}
+	stop = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.listeners[ch]; ok {
+			delete(s.listeners, ch)
+			close(ch)
+		}
+	}
+
+	missed, resumed = s.since(lastEventID)
+	return missed, resumed, ch, stop
+}
+
+// since returns the kept events after the one with the ID, and whether none
+// were missed
+func (s *Stream) since(lastEventID string) ([]Streamed, bool) {
+	if lastEventID == "" {
+		return nil, true
+	}
+	epoch, n, _ := strings.Cut(lastEventID, "-")
+	last, err := strconv.ParseUint(n, 10, 64)
+	if err != nil || epoch != s.epoch || last > s.seq {
+		return nil, false
+	}
+
+	i, _ := slices.BinarySearchFunc(s.history, last+1, func(e Streamed, seq uint64) int {
+		return cmp.Compare(e.seq, seq)
+	})
+	if last < s.seq && (i == len(s.history) || s.history[i].seq != last+1) {
+		return nil, false
+	}
+	return slices.Clone(s.history[i:]), true
+}
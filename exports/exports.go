@@ -0,0 +1,351 @@
+// Package exports/exports.go
+//
+// Package exports archives all the data of an organization in the background,
+// for backups or offboarding, and serves the archives through signed URLs
+// until they expire.
+package exports
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/scheduler"
+	"github.com/4cecoder/saas/storage"
+)
+
+// DefaultTTL is how long archives are kept when the configuration sets nothing
+const DefaultTTL = 7 * 24 * time.Hour
+
+// DownloadPath is the route of the API downloading archives, followed by the
+// export ID and /download
+const DownloadPath = "/v1/organization-exports"
+
+// FormatVersion is the version of the layout of archives, written to their
+// manifest
+const FormatVersion = 1
+
+// Errors of exports
+var (
+	ErrInProgress       = errors.New("an export of the organization is in progress")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+	ErrNotReady         = errors.New("export not ready")
+)
+
+// Config holds the settings of exports
+type Config struct {
+	// TTL is how long archives can be downloaded before they're deleted
+	TTL time.Duration
+}
+
+// Member is a user of an organization as archived, with their seat in it and
+// the values of the custom fields it defines
+type Member struct {
+	ID           uint                      `json:"id"`
+	Email        string                    `json:"email"`
+	Name         string                    `json:"name"`
+	Verified     bool                      `json:"verified"`
+	Locale       string                    `json:"locale"`
+	Timezone     string                    `json:"timezone"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	SuspendedAt  *time.Time                `json:"suspended_at"`
+	Seat         *models.Seat              `json:"seat"`
+	CustomFields []models.CustomFieldValue `json:"custom_fields"`
+}
+
+// Manifest describes an archive; it's written last, as manifest.json
+type Manifest struct {
+	FormatVersion  int       `json:"format_version"`
+	OrganizationID uint      `json:"organization_id"`
+	ExportID       uint      `json:"export_id"`
+	ExportedAt     time.Time `json:"exported_at"`
+	Files          []string  `json:"files"`
+}
+
+// section writes one file of an archive
+type section struct {
+	name, file string
+	write      func(ctx context.Context, db *gorm.DB, orgID uint, w io.Writer) error
+}
+
+// sections are the files of an archive, in the order they're written
+var sections = []section{
+	{"organization", "organization.json", writeOrganization},
+	{"members", "members.json", writeMembers},
+	{"teams", "teams.json", writeTeams},
+	{"custom fields", "custom_fields.json", writeCustomFields},
+	{"tags", "tags.json", writeTags},
+	{"workflows", "workflows.json", writeWorkflows},
+	{"reports", "reports.json", writeReports},
+	{"audit logs", "audit_logs.ndjson", writeAuditLogs},
+}
+
+// Exporter builds the archives of organizations and keeps them in the object
+// storage until they expire
+type Exporter struct {
+	DB      *gorm.DB
+	Storage storage.Storage
+	Config  Config
+	// PublicURL is the URL of the API, which the signed URLs start with
+	PublicURL string
+	// key signs the download URLs
+	key []byte
+}
+
+// New creates the exporter, signing the download URLs of the API at publicURL
+// with secret
+func New(db *gorm.DB, store storage.Storage, publicURL, secret string, cfg Config) *Exporter {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	return &Exporter{
+		DB:        db,
+		Storage:   store,
+		Config:    cfg,
+		PublicURL: strings.TrimSuffix(publicURL, "/"),
+		key:       []byte(secret),
+	}
+}
+
+// Register schedules the hourly job deleting expired archives
+func (e *Exporter) Register(s *scheduler.Scheduler) error {
+	s.Register("exports.expire", func(ctx context.Context, _ *models.ScheduledJob) error {
+		return e.Expire(ctx)
+	})
+	return s.Ensure("exports-expire", "exports.expire", "0 * * * *", nil)
+}
+
+// Start records a pending export of an organization and builds its archive in
+// the background. Only one export of an organization runs at a time; starting
+// another returns ErrInProgress.
+func (e *Exporter) Start(ctx context.Context, org *models.Organization, requestedBy uint) (*models.OrganizationExport, error) {
+	export := &models.OrganizationExport{
+		OrganizationID: org.ID,
+		RequestedBy:    requestedBy,
+		Status:         models.OrganizationExportPending,
+		Filename:       fmt.Sprintf("organization-%d-%s.zip", org.ID, time.Now().UTC().Format("20060102-150405")),
+	}
+	err := e.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var running int64
+		err := tx.Model(&models.OrganizationExport{}).
+			Where("organization_id = ? AND status IN ?", org.ID, []models.OrganizationExportStatus{models.OrganizationExportPending, models.OrganizationExportRunning}).
+			Count(&running).Error
+		if err != nil {
+			return err
+		}
+		if running > 0 {
+			return ErrInProgress
+		}
+		return tx.Create(export).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Detach from the request so the export outlives it
+	go e.run(context.WithoutCancel(ctx), *export)
+
+	return export, nil
+}
+
+// run builds and stores the archive of an export, recording its progress and
+// outcome
+func (e *Exporter) run(ctx context.Context, export models.OrganizationExport) {
+	db := e.DB.WithContext(ctx)
+	key := fmt.Sprintf("exports/%d/organization/%d-%s", export.OrganizationID, export.ID, export.Filename)
+
+	updates := map[string]interface{}{}
+	size, err := e.build(ctx, &export, key)
+	if err != nil {
+		slog.ErrorContext(ctx, "exports: export failed", "export_id", export.ID, "organization_id", export.OrganizationID, "error", err)
+		e.remove(ctx, key)
+		updates["status"] = models.OrganizationExportFailed
+		updates["error"] = err.Error()
+	} else {
+		updates["status"] = models.OrganizationExportReady
+		updates["progress"] = 100
+		updates["section"] = ""
+		updates["storage_key"] = key
+		updates["size"] = size
+		updates["expires_at"] = time.Now().Add(e.Config.TTL)
+	}
+
+	if err := db.Model(&export).Updates(updates).Error; err != nil {
+		slog.ErrorContext(ctx, "exports: failed to update export", "export_id", export.ID, "error", err)
+	}
+}
+
+// build writes the archive of an export to key as a zip of the sections and a
+// manifest, returning its size
+func (e *Exporter) build(ctx context.Context, export *models.OrganizationExport, key string) (int64, error) {
+	db := e.DB.WithContext(ctx)
+	if err := db.Model(export).Updates(map[string]interface{}{"status": models.OrganizationExportRunning}).Error; err != nil {
+		return 0, err
+	}
+
+	// The archive is streamed to the storage as it's written
+	pr, pw := io.Pipe()
+	counter := &countingReader{r: pr}
+	stored := make(chan error, 1)
+	go func() {
+		err := e.Storage.Put(ctx, key, counter)
+		pr.CloseWithError(err)
+		stored <- err
+	}()
+
+	err := e.write(ctx, export, pw)
+	pw.CloseWithError(err)
+	if serr := <-stored; err == nil {
+		err = serr
+	}
+	return counter.n, err
+}
+
+// write writes the zip of an export to w, saving the progress after each
+// section
+func (e *Exporter) write(ctx context.Context, export *models.OrganizationExport, w io.Writer) error {
+	db := e.DB.WithContext(ctx)
+	zw := zip.NewWriter(w)
+	manifest := Manifest{
+		FormatVersion:  FormatVersion,
+		OrganizationID: export.OrganizationID,
+		ExportID:       export.ID,
+		ExportedAt:     time.Now().UTC(),
+	}
+
+	for i, s := range sections {
+		progress := map[string]interface{}{"section": s.name, "progress": i * 100 / (len(sections) + 1)}
+		if err := db.Model(export).Updates(progress).Error; err != nil {
+			return err
+		}
+		f, err := zw.Create(s.file)
+		if err != nil {
+			return err
+		}
+		if err := s.write(ctx, db, export.OrganizationID, f); err != nil {
+			return fmt.Errorf("write %s: %w", s.name, err)
+		}
+		manifest.Files = append(manifest.Files, s.file)
+	}
+
+	f, err := zw.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(f, manifest); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Expire deletes the archives past their expiry, marking their exports expired
+func (e *Exporter) Expire(ctx context.Context) error {
+	db := e.DB.WithContext(ctx)
+	var expired []models.OrganizationExport
+	err := db.Where("status = ? AND expires_at < ?", models.OrganizationExportReady, time.Now()).Find(&expired).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range expired {
+		export := &expired[i]
+		if err := e.Storage.Delete(ctx, export.StorageKey); err != nil {
+			return fmt.Errorf("delete archive of export %d: %w", export.ID, err)
+		}
+		err := db.Model(export).Updates(map[string]interface{}{"status": models.OrganizationExportExpired, "storage_key": ""}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if len(expired) > 0 {
+		slog.InfoContext(ctx, "exports: deleted expired archives", "count", len(expired))
+	}
+	return nil
+}
+
+// Sign sets the URL downloading the archive of a ready export until it expires
+func (e *Exporter) Sign(export *models.OrganizationExport) {
+	if export.Status != models.OrganizationExportReady || export.ExpiresAt == nil {
+		return
+	}
+	expires := export.ExpiresAt.Unix()
+	export.URL = fmt.Sprintf("%s%s/%d/download?expires=%d&signature=%s",
+		e.PublicURL, DownloadPath, export.ID, expires, e.signature(export.ID, expires))
+}
+
+// Open opens the archive of the export with id for a signed URL, returning
+// ErrInvalidSignature unless expires and signature come from one that's still
+// valid
+func (e *Exporter) Open(ctx context.Context, id uint, expires, signature string) (*models.OrganizationExport, io.ReadCloser, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return nil, nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(e.signature(id, unix))) {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	var export models.OrganizationExport
+	if err := e.DB.WithContext(ctx).First(&export, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, storage.ErrNotFound
+		}
+		return nil, nil, err
+	}
+	if export.Status != models.OrganizationExportReady {
+		return nil, nil, ErrNotReady
+	}
+	file, err := e.Storage.Get(ctx, export.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &export, file, nil
+}
+
+// signature signs the download URL of the export with id expiring at the Unix
+// time expires
+func (e *Exporter) signature(id uint, expires int64) string {
+	mac := hmac.New(sha256.New, e.key)
+	fmt.Fprintf(mac, "organization-export:%d:%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// remove deletes the partial archive of a failed export; failing only leaves
+// an orphaned file, so it's logged
+func (e *Exporter) remove(ctx context.Context, key string) {
+	if err := e.Storage.Delete(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "exports: failed to delete archive", "key", key, "error", err)
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,541 @@
+// Package exports/restore.go
+package exports
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/reports"
+)
+
+// ErrInvalidArchive is returned for files that aren't archives of exports
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// Restorer restores the archives of exports into new organizations, such as
+// to clone an environment or move a tenant between regions
+type Restorer struct {
+	DB *gorm.DB
+	// Region is the region the restored organizations are kept in, the
+	// deployment's
+	Region string
+}
+
+// NewRestorer creates a restorer writing to db
+func NewRestorer(db *gorm.DB) *Restorer {
+	return &Restorer{DB: db}
+}
+
+// Restore reads an archive and records a pending restore of it into a new
+// organization named name, or after the archived one when empty, which is
+// processed in the background. Archives that can't be read fail with
+// ErrInvalidArchive before anything is recorded.
+func (r *Restorer) Restore(ctx context.Context, requestedBy uint, name, filename string, archive io.Reader) (*models.OrganizationRestore, error) {
+	// Zips are read from their end, so the archive is kept in a file until
+	// it's restored
+	f, err := os.CreateTemp("", "organization-restore-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	discard := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	size, err := io.Copy(f, archive)
+	if err != nil {
+		discard()
+		return nil, err
+	}
+	zr, manifest, org, err := open(f, size)
+	if err != nil {
+		discard()
+		return nil, err
+	}
+	if name == "" {
+		name = org.Name
+	}
+
+	restore := &models.OrganizationRestore{
+		SourceOrganizationID: manifest.OrganizationID,
+		RequestedBy:          requestedBy,
+		Name:                 name,
+		Filename:             filename,
+		Status:               models.OrganizationRestorePending,
+		Restored:             map[string]int{},
+		Warnings:             []string{},
+	}
+	if err := r.DB.WithContext(ctx).Create(restore).Error; err != nil {
+		discard()
+		return nil, err
+	}
+
+	// Detach from the request so the restore outlives it
+	go func() {
+		defer discard()
+		r.run(context.WithoutCancel(ctx), restore, zr)
+	}()
+
+	return restore, nil
+}
+
+// open reads the manifest and organization of an archive, checking it has the
+// files of its format
+func open(f io.ReaderAt, size int64) (*zip.Reader, *Manifest, *models.Organization, error) {
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: the file isn't a zip", ErrInvalidArchive)
+	}
+
+	var manifest Manifest
+	if err := readJSON(zr, "manifest.json", &manifest); err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: the manifest can't be read: %v", ErrInvalidArchive, err)
+	}
+	if manifest.FormatVersion != FormatVersion {
+		return nil, nil, nil, fmt.Errorf("%w: the format version %d isn't supported", ErrInvalidArchive, manifest.FormatVersion)
+	}
+	for _, s := range sections {
+		if _, err := fs.Stat(zr, s.file); err != nil {
+			return nil, nil, nil, fmt.Errorf("%w: the archive lacks %s", ErrInvalidArchive, s.file)
+		}
+	}
+
+	var org models.Organization
+	if err := readJSON(zr, "organization.json", &org); err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: organization.json can't be read: %v", ErrInvalidArchive, err)
+	}
+	return zr, &manifest, &org, nil
+}
+
+// run restores an archive in one transaction, so a failed restore leaves
+// nothing behind, and records its outcome
+func (r *Restorer) run(ctx context.Context, restore *models.OrganizationRestore, zr *zip.Reader) {
+	db := r.DB.WithContext(ctx)
+	if err := db.Model(restore).Update("status", models.OrganizationRestoreRunning).Error; err != nil {
+		slog.ErrorContext(ctx, "exports: failed to start restore", "restore_id", restore.ID, "error", err)
+		return
+	}
+
+	var rs *restoration
+	err := db.Transaction(func(tx *gorm.DB) error {
+		rs = &restoration{
+			tx:        tx,
+			zr:        zr,
+			restored:  map[string]int{},
+			warnings:  []string{},
+			requested: restore.RequestedBy,
+			region:    r.Region,
+			users:     map[uint]uint{},
+			teams:     map[uint]uint{},
+			fields:    map[uint]uint{},
+			workflows: map[uint]uint{},
+			reports:   map[uint]uint{},
+			roles:     map[string]*models.Role{},
+		}
+		return rs.run(restore.Name)
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "exports: restore failed", "restore_id", restore.ID, "error", err)
+		restore.Status, restore.Error = models.OrganizationRestoreFailed, err.Error()
+	} else {
+		restore.Status, restore.TargetOrganizationID = models.OrganizationRestoreCompleted, &rs.org.ID
+		restore.UsersCreated, restore.UsersMatched = rs.usersCreated, rs.usersMatched
+		restore.Restored, restore.Warnings = rs.restored, rs.warnings
+		slog.InfoContext(ctx, "exports: restore completed", "restore_id", restore.ID, "organization_id", rs.org.ID, "source_organization_id", restore.SourceOrganizationID)
+	}
+	err = db.Model(restore).Select("Status", "Error", "TargetOrganizationID", "UsersCreated", "UsersMatched", "Restored", "Warnings").Updates(restore).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "exports: failed to update restore", "restore_id", restore.ID, "error", err)
+	}
+}
+
+// readJSON decodes the file of an archive named name into v
+func readJSON(zr *zip.Reader, name string, v interface{}) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(v)
+}
+
+// restoration is the state of a restore in progress, mapping the IDs of the
+// archive to those of the records restored
+type restoration struct {
+	tx        *gorm.DB
+	zr        *zip.Reader
+	requested uint
+	region    string
+	org       *models.Organization
+
+	usersCreated, usersMatched int
+	restored                   map[string]int
+	warnings                   []string
+
+	users, teams, fields, workflows, reports map[uint]uint
+	// roles caches the roles by name, nil for those that don't exist
+	roles map[string]*models.Role
+}
+
+// run restores the sections of the archive into a new organization named name.
+// The audit logs are left out: they record the history of the source
+// organization, and the restore is audited in the new one instead.
+func (rs *restoration) run(name string) error {
+	var org models.Organization
+	if err := readJSON(rs.zr, "organization.json", &org); err != nil {
+		return fmt.Errorf("read organization.json: %w", err)
+	}
+	steps := []struct {
+		file    string
+		restore func() error
+	}{
+		{"organization.json", func() error { return rs.restoreOrganization(&org, name) }},
+		{"custom_fields.json", rs.restoreCustomFields},
+		{"organization.json", func() error {
+			return rs.restoreValues(org.CustomFields, models.CustomFieldEntityOrganization, rs.org.ID)
+		}},
+		{"members.json", rs.restoreMembers},
+		{"teams.json", rs.restoreTeams},
+		{"workflows.json", rs.restoreWorkflows},
+		{"reports.json", rs.restoreReports},
+		{"tags.json", rs.restoreTags},
+	}
+	for _, step := range steps {
+		if err := step.restore(); err != nil {
+			return fmt.Errorf("restore %s: %w", step.file, err)
+		}
+	}
+	return nil
+}
+
+// restoreOrganization creates the organization with the archived settings and
+// domains. Domains are left unverified, and those taken are left out, as is
+// the white-label domain; subscriptions aren't restored.
+func (rs *restoration) restoreOrganization(archived *models.Organization, name string) error {
+	settings := archived.Settings
+	if settings.Domain != nil {
+		var taken int64
+		if err := rs.tx.Model(&models.Organization{}).Unscoped().Where("domain = ?", *settings.Domain).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			rs.warn("the white-label domain %s is taken", *settings.Domain)
+			settings.Domain = nil
+		}
+	}
+
+	rs.org = &models.Organization{Name: name, Settings: settings, Region: rs.region}
+	if err := rs.tx.Create(rs.org).Error; err != nil {
+		return err
+	}
+
+	for _, d := range archived.Domains {
+		var taken int64
+		if err := rs.tx.Model(&models.Domain{}).Unscoped().Where("domain = ?", d.Domain).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			rs.warn("the domain %s is taken", d.Domain)
+			continue
+		}
+		if err := rs.tx.Create(&models.Domain{OrganizationID: rs.org.ID, Domain: d.Domain}).Error; err != nil {
+			return err
+		}
+		rs.restored["domains"]++
+	}
+	if len(archived.Subscriptions) > 0 {
+		rs.warn("subscriptions aren't restored; subscribe the organization to a plan again")
+	}
+	return nil
+}
+
+// restoreCustomFields creates the custom fields the organization defines
+func (rs *restoration) restoreCustomFields() error {
+	var fields []models.CustomField
+	if err := readJSON(rs.zr, "custom_fields.json", &fields); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		field := models.CustomField{
+			OrganizationID: rs.org.ID,
+			EntityType:     f.EntityType,
+			Key:            f.Key,
+			Name:           f.Name,
+			Type:           f.Type,
+			Options:        f.Options,
+			Required:       f.Required,
+		}
+		if err := rs.tx.Create(&field).Error; err != nil {
+			return err
+		}
+		rs.fields[f.ID] = field.ID
+		rs.restored["custom_fields"]++
+	}
+	return nil
+}
+
+// restoreValues sets the custom field values of the record of entityType with
+// entityID
+func (rs *restoration) restoreValues(values []models.CustomFieldValue, entityType string, entityID uint) error {
+	for _, v := range values {
+		fieldID, ok := rs.fields[v.CustomFieldID]
+		if !ok {
+			continue
+		}
+		value := models.CustomFieldValue{
+			OrganizationID: rs.org.ID,
+			CustomFieldID:  fieldID,
+			EntityType:     entityType,
+			EntityID:       entityID,
+			Key:            v.Key,
+			Value:          v.Value,
+		}
+		if err := rs.tx.Create(&value).Error; err != nil {
+			return err
+		}
+		rs.restored["custom_field_values"]++
+	}
+	return nil
+}
+
+// restoreMembers makes the members users of the organization with their seat,
+// matching existing users by email and creating the others. Created users have
+// no password, so they sign in after resetting it.
+func (rs *restoration) restoreMembers() error {
+	var members []Member
+	if err := readJSON(rs.zr, "members.json", &members); err != nil {
+		return err
+	}
+	for _, m := range members {
+		var user models.User
+		err := rs.tx.Where("email = ?", m.Email).First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			user = models.User{
+				Email:       m.Email,
+				Name:        m.Name,
+				Verified:    m.Verified,
+				Locale:      m.Locale,
+				Timezone:    m.Timezone,
+				SuspendedAt: m.SuspendedAt,
+			}
+			if err := rs.tx.Create(&user).Error; err != nil {
+				return err
+			}
+			rs.usersCreated++
+		case err != nil:
+			return err
+		default:
+			rs.usersMatched++
+		}
+		rs.users[m.ID] = user.ID
+
+		if err := rs.tx.Model(rs.org).Omit("Users.*").Association("Users").Append(&user); err != nil {
+			return err
+		}
+		if m.Seat != nil {
+			seat := models.Seat{
+				OrganizationID: rs.org.ID,
+				UserID:         user.ID,
+				Roles:          rs.rolesOf(m.Seat.Roles),
+				Status:         m.Seat.Status,
+				SuspendedAt:    m.Seat.SuspendedAt,
+			}
+			if err := rs.tx.Omit("Roles.*").Create(&seat).Error; err != nil {
+				return err
+			}
+			rs.restored["seats"]++
+		}
+		if err := rs.restoreValues(m.CustomFields, models.CustomFieldEntityUser, user.ID); err != nil {
+			return err
+		}
+		rs.restored["members"]++
+	}
+	return nil
+}
+
+// restoreTeams creates the teams with their members
+func (rs *restoration) restoreTeams() error {
+	var teams []models.Team
+	if err := readJSON(rs.zr, "teams.json", &teams); err != nil {
+		return err
+	}
+	for _, t := range teams {
+		team := models.Team{OrganizationID: rs.org.ID, Name: t.Name, Description: t.Description}
+		if err := rs.tx.Create(&team).Error; err != nil {
+			return err
+		}
+		rs.teams[t.ID] = team.ID
+		rs.restored["teams"]++
+
+		for _, m := range t.Members {
+			userID, ok := rs.users[m.UserID]
+			if !ok {
+				continue
+			}
+			member := models.TeamMember{OrganizationID: rs.org.ID, TeamID: team.ID, UserID: userID, Roles: rs.rolesOf(m.Roles)}
+			if err := rs.tx.Omit("Roles.*").Create(&member).Error; err != nil {
+				return err
+			}
+			rs.restored["team_members"]++
+		}
+	}
+	return nil
+}
+
+// restoreWorkflows creates the workflows
+func (rs *restoration) restoreWorkflows() error {
+	var workflows []models.Workflow
+	if err := readJSON(rs.zr, "workflows.json", &workflows); err != nil {
+		return err
+	}
+	for _, w := range workflows {
+		workflow := models.Workflow{
+			Name:           w.Name,
+			Description:    w.Description,
+			Steps:          w.Steps,
+			Triggers:       w.Triggers,
+			OrganizationID: rs.org.ID,
+			TeamID:         rs.team(w.TeamID),
+			CreatorID:      rs.user(w.CreatorID),
+			Enabled:        w.Enabled,
+			Version:        w.Version,
+		}
+		if err := rs.tx.Create(&workflow).Error; err != nil {
+			return err
+		}
+		rs.workflows[w.ID] = workflow.ID
+		rs.restored["workflows"]++
+	}
+	return nil
+}
+
+// restoreReports creates the report definitions, scheduling their delivery
+func (rs *restoration) restoreReports() error {
+	var archived []models.Report
+	if err := readJSON(rs.zr, "reports.json", &archived); err != nil {
+		return err
+	}
+	for _, r := range archived {
+		report := models.Report{
+			Name:           r.Name,
+			Description:    r.Description,
+			Definition:     r.Definition,
+			OrganizationID: rs.org.ID,
+			TeamID:         rs.team(r.TeamID),
+			CreatorID:      rs.user(r.CreatorID),
+			Schedule:       r.Schedule,
+			Recipients:     r.Recipients,
+			Format:         r.Format,
+		}
+		if err := rs.tx.Create(&report).Error; err != nil {
+			return err
+		}
+		if err := reports.SyncSchedule(rs.tx, &report); err != nil {
+			return err
+		}
+		rs.reports[r.ID] = report.ID
+		rs.restored["reports"]++
+	}
+	return nil
+}
+
+// restoreTags creates the tags and attaches them to the restored records
+func (rs *restoration) restoreTags() error {
+	var archived struct {
+		Tags     []models.Tag     `json:"tags"`
+		Taggings []models.Tagging `json:"taggings"`
+	}
+	if err := readJSON(rs.zr, "tags.json", &archived); err != nil {
+		return err
+	}
+
+	tags := map[uint]uint{}
+	for _, t := range archived.Tags {
+		tag := models.Tag{OrganizationID: rs.org.ID, Name: t.Name, Color: t.Color}
+		if err := rs.tx.Create(&tag).Error; err != nil {
+			return err
+		}
+		tags[t.ID] = tag.ID
+		rs.restored["tags"]++
+	}
+
+	records := map[string]map[uint]uint{
+		models.TaggableUser:     rs.users,
+		models.TaggableWorkflow: rs.workflows,
+		models.TaggableReport:   rs.reports,
+	}
+	for _, t := range archived.Taggings {
+		tagID, ok := tags[t.TagID]
+		if !ok {
+			continue
+		}
+		taggableID, ok := records[t.TaggableType][t.TaggableID]
+		if !ok {
+			continue
+		}
+		tagging := models.Tagging{OrganizationID: rs.org.ID, TagID: tagID, TaggableType: t.TaggableType, TaggableID: taggableID}
+		if err := rs.tx.Create(&tagging).Error; err != nil {
+			return err
+		}
+		rs.restored["taggings"]++
+	}
+	return nil
+}
+
+// rolesOf returns the roles named like the archived ones, warning about those
+// that don't exist
+func (rs *restoration) rolesOf(archived []models.Role) []models.Role {
+	var roles []models.Role
+	for _, a := range archived {
+		role, cached := rs.roles[a.Name]
+		if !cached {
+			var found models.Role
+			if err := rs.tx.Where("name = ?", a.Name).Limit(1).Find(&found).Error; err == nil && found.ID != 0 {
+				role = &found
+			} else {
+				rs.warn("the role %s doesn't exist", a.Name)
+			}
+			rs.roles[a.Name] = role
+		}
+		if role != nil {
+			roles = append(roles, *role)
+		}
+	}
+	return roles
+}
+
+// user returns the ID of the restored user with the archived id, or the
+// requester's when they weren't a member
+func (rs *restoration) user(id uint) uint {
+	if userID, ok := rs.users[id]; ok {
+		return userID
+	}
+	return rs.requested
+}
+
+// team returns the ID of the restored team with the archived id, if any
+func (rs *restoration) team(id *uint) *uint {
+	if id == nil {
+		return nil
+	}
+	if teamID, ok := rs.teams[*id]; ok {
+		return &teamID
+	}
+	return nil
+}
+
+// warn notes something the restore left out
+func (rs *restoration) warn(format string, args ...interface{}) {
+	rs.warnings = append(rs.warnings, fmt.Sprintf(format, args...))
+}
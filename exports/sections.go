@@ -0,0 +1,140 @@
+// Package exports/sections.go
+package exports
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// batchSize is the number of audit logs read at a time
+const batchSize = 500
+
+// writeOrganization writes the organization with its settings, domains,
+// subscriptions and custom field values
+func writeOrganization(ctx context.Context, db *gorm.DB, orgID uint, w io.Writer) error {
+	var org models.Organization
+	err := db.Preload("Domains").
+		Preload("Subscriptions.SubscriptionPlan").
+		Preload("CustomFields").
+		First(&org, orgID).Error
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, org)
+}
+
+// writeMembers writes the members of the organization and the users holding a
+// seat in it
+func writeMembers(ctx context.Context, db *gorm.DB, orgID uint, w io.Writer) error {
+	members := db.Table("user_organizations").Select("user_id").Where("organization_id = ?", orgID)
+	seated := db.Model(&models.Seat{}).Select("user_id").Where("organization_id = ?", orgID)
+	var users []models.User
+	err := db.Where("id IN (?) OR id IN (?)", members, seated).
+		Preload("CustomFields", "organization_id = ?", orgID).
+		Order("id").
+		Find(&users).Error
+	if err != nil {
+		return err
+	}
+
+	var seats []models.Seat
+	if err := db.Preload("Roles").Where("organization_id = ?", orgID).Find(&seats).Error; err != nil {
+		return err
+	}
+	seatOf := make(map[uint]*models.Seat, len(seats))
+	for i := range seats {
+		seatOf[seats[i].UserID] = &seats[i]
+	}
+
+	out := make([]Member, len(users))
+	for i, u := range users {
+		out[i] = Member{
+			ID:           u.ID,
+			Email:        u.Email,
+			Name:         u.Name,
+			Verified:     u.Verified,
+			Locale:       u.Locale,
+			Timezone:     u.Timezone,
+			CreatedAt:    u.CreatedAt,
+			SuspendedAt:  u.SuspendedAt,
+			Seat:         seatOf[u.ID],
+			CustomFields: u.CustomFields,
+		}
+	}
+	return writeJSON(w, out)
+}
+
+// writeTeams writes the teams of the organization with their members
+func writeTeams(ctx context.Context, db *gorm.DB, orgID uint, w io.Writer) error {
+	teams := []models.Team{}
+	if err := db.Preload("Members.Roles").Where("organization_id = ?", orgID).Order("id").Find(&teams).Error; err != nil {
+		return err
+	}
+	return writeJSON(w, teams)
+}
+
+// writeCustomFields writes the custom fields the organization defines
+func writeCustomFields(ctx context.Context, db *gorm.DB, orgID uint, w io.Writer) error {
+	fields := []models.CustomField{}
+	if err := db.Where("organization_id = ?", orgID).Order("id").Find(&fields).Error; err != nil {
+		return err
+	}
+	return writeJSON(w, fields)
+}
+
+// writeTags writes the tags of the organization and the records carrying them
+func writeTags(ctx context.Context, db *gorm.DB, orgID uint, w io.Writer) error {
+	tags := []models.Tag{}
+	if err := db.Where("organization_id = ?", orgID).Order("id").Find(&tags).Error; err != nil {
+		return err
+	}
+	taggings := []models.Tagging{}
+	if err := db.Where("organization_id = ?", orgID).Order("id").Find(&taggings).Error; err != nil {
+		return err
+	}
+	return writeJSON(w, struct {
+		Tags     []models.Tag     `json:"tags"`
+		Taggings []models.Tagging `json:"taggings"`
+	}{tags, taggings})
+}
+
+// writeWorkflows writes the workflows of the organization
+func writeWorkflows(ctx context.Context, db *gorm.DB, orgID uint, w io.Writer) error {
+	workflows := []models.Workflow{}
+	if err := db.Where("organization_id = ?", orgID).Order("id").Find(&workflows).Error; err != nil {
+		return err
+	}
+	return writeJSON(w, workflows)
+}
+
+// writeReports writes the report definitions of the organization
+func writeReports(ctx context.Context, db *gorm.DB, orgID uint, w io.Writer) error {
+	reports := []models.Report{}
+	if err := db.Where("organization_id = ?", orgID).Order("id").Find(&reports).Error; err != nil {
+		return err
+	}
+	return writeJSON(w, reports)
+}
+
+// writeAuditLogs writes the audit logs of the organization as newline
+// delimited JSON, oldest first
+func writeAuditLogs(ctx context.Context, db *gorm.DB, orgID uint, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	var batch []models.AuditLog
+	return db.Model(&models.AuditLog{}).
+		Where("organization_id = ?", orgID).
+		Order("timestamp ASC, id ASC").
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			for _, entry := range batch {
+				if err := enc.Encode(entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
@@ -0,0 +1,203 @@
+// Package feedback/feedback.go
+//
+// Package feedback records the feedback and NPS responses users submit in the
+// app, forwarding them to Slack when configured, and sums up NPS scores.
+package feedback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/requestid"
+)
+
+// Config holds the settings of feedback
+type Config struct {
+	// SlackWebhookURL is the Slack incoming webhook submissions are posted to;
+	// empty forwards none
+	SlackWebhookURL string
+}
+
+// Collector records feedback and forwards it
+type Collector struct {
+	DB              *gorm.DB
+	SlackWebhookURL string
+	Client          *http.Client
+}
+
+// New creates a collector with cfg
+func New(db *gorm.DB, cfg Config) *Collector {
+	return &Collector{DB: db, SlackWebhookURL: cfg.SlackWebhookURL, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Submit records feedback, then forwards it to Slack in the background
+func (c *Collector) Submit(ctx context.Context, f *models.Feedback) error {
+	if err := c.DB.WithContext(ctx).Create(f).Error; err != nil {
+		return err
+	}
+	if c.SlackWebhookURL != "" {
+		// Detach from the request so a slow webhook doesn't hold it
+		go c.forward(context.WithoutCancel(ctx), f)
+	}
+	return nil
+}
+
+// forward posts feedback to the Slack webhook, logging failures
+func (c *Collector) forward(ctx context.Context, f *models.Feedback) {
+	if err := c.postSlack(ctx, slackText(c.DB.WithContext(ctx), f)); err != nil {
+		slog.ErrorContext(ctx, "feedback: failed to forward to Slack", "feedback_id", f.ID, "error", err)
+	}
+}
+
+// postSlack posts a message to the Slack webhook
+func (c *Collector) postSlack(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.SlackWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	requestid.Propagate(req)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("slack returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// slackText renders feedback as a Slack message, naming its user and
+// organization when they can be read
+func slackText(db *gorm.DB, f *models.Feedback) string {
+	from := fmt.Sprintf("user %d", f.UserID)
+	var user models.User
+	if db.Select("email").First(&user, f.UserID).Error == nil {
+		from = user.Email
+	}
+	if f.OrganizationID != 0 {
+		var org models.Organization
+		if db.Select("name").First(&org, f.OrganizationID).Error == nil {
+			from += " (" + org.Name + ")"
+		}
+	}
+
+	var b strings.Builder
+	if f.Kind == models.FeedbackKindNPS && f.Score != nil {
+		fmt.Fprintf(&b, "*NPS %d/10* from %s", *f.Score, from)
+	} else {
+		fmt.Fprintf(&b, "*Feedback* from %s", from)
+	}
+	if f.Category != "" {
+		fmt.Fprintf(&b, " about %s", f.Category)
+	}
+	if f.Message != "" {
+		b.WriteString("\n>" + strings.ReplaceAll(f.Message, "\n", "\n>"))
+	}
+	if f.Page != "" {
+		b.WriteString("\nPage: " + f.Page)
+	}
+	return b.String()
+}
+
+// NPSSummary sums up NPS responses: promoters scored 9 or 10, passives 7 or 8
+// and detractors 0 to 6
+type NPSSummary struct {
+	Responses  int64   `json:"responses"`
+	Promoters  int64   `json:"promoters"`
+	Passives   int64   `json:"passives"`
+	Detractors int64   `json:"detractors"`
+	Average    float64 `json:"average"`
+	// Score is the share of promoters minus that of detractors, from -100 to 100
+	Score float64 `json:"score"`
+}
+
+// npsColumns selects the sums an NPSSummary is computed from
+const npsColumns = "COUNT(*) AS responses, " +
+	"SUM(CASE WHEN score >= 9 THEN 1 ELSE 0 END) AS promoters, " +
+	"SUM(CASE WHEN score BETWEEN 7 AND 8 THEN 1 ELSE 0 END) AS passives, " +
+	"SUM(CASE WHEN score <= 6 THEN 1 ELSE 0 END) AS detractors, " +
+	"AVG(score) AS average"
+
+// NPS sums up the NPS responses query selects
+func NPS(query *gorm.DB) (NPSSummary, error) {
+	var s NPSSummary
+	var row npsRow
+	if err := query.Where("kind = ? AND score IS NOT NULL", models.FeedbackKindNPS).Select(npsColumns).Scan(&row).Error; err != nil {
+		return s, err
+	}
+	return row.summary(), nil
+}
+
+// OrganizationNPS is the NPS summary of an organization
+type OrganizationNPS struct {
+	OrganizationID uint `json:"organization_id"`
+	NPSSummary
+}
+
+// NPSByOrganization sums up the NPS responses query selects per organization,
+// those with the most responses first
+func NPSByOrganization(query *gorm.DB, limit, offset int) ([]OrganizationNPS, error) {
+	var rows []npsRow
+	err := query.Where("kind = ? AND score IS NOT NULL AND organization_id <> 0", models.FeedbackKindNPS).
+		Select("organization_id, " + npsColumns).
+		Group("organization_id").
+		Order("responses DESC, organization_id").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	list := make([]OrganizationNPS, len(rows))
+	for i, r := range rows {
+		list[i] = OrganizationNPS{OrganizationID: r.OrganizationID, NPSSummary: r.summary()}
+	}
+	return list, nil
+}
+
+// npsRow is a row of npsColumns, per organization when grouped; sums of no
+// rows are NULL
+type npsRow struct {
+	OrganizationID uint
+	Responses      int64
+	Promoters      *int64
+	Passives       *int64
+	Detractors     *int64
+	Average        *float64
+}
+
+// summary computes the NPS of the row
+func (r npsRow) summary() NPSSummary {
+	s := NPSSummary{Responses: r.Responses}
+	if r.Responses == 0 {
+		return s
+	}
+	deref := func(v *int64) int64 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	}
+	s.Promoters, s.Passives, s.Detractors = deref(r.Promoters), deref(r.Passives), deref(r.Detractors)
+	if r.Average != nil {
+		s.Average = *r.Average
+	}
+	s.Score = float64(s.Promoters-s.Detractors) * 100 / float64(s.Responses)
+	return s
+}
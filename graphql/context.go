@@ -0,0 +1,90 @@
+// Package graphql/context.go
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/access"
+)
+
+// callerKey is the context key of the Caller
+type callerKey struct{}
+
+// Caller is who runs a query, with the database and the loaders of the request
+type Caller struct {
+	DB     *gorm.DB
+	Access *access.Access
+	UserID uint
+	Admin  bool
+
+	loaders *loaders
+
+	once sync.Once
+	user *access.User
+	err  error
+}
+
+// NewContext returns the context executing the queries of a request by the
+// given user, with its own loaders so batches and caches aren't shared
+func NewContext(ctx context.Context, db *gorm.DB, acc *access.Access, userID uint, admin bool) context.Context {
+	c := &Caller{DB: db.WithContext(ctx), Access: acc, UserID: userID, Admin: admin}
+	c.loaders = newLoaders()
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+// callerFrom returns the caller of a context made by NewContext
+func callerFrom(ctx context.Context) *Caller {
+	return ctx.Value(callerKey{}).(*Caller)
+}
+
+// access loads the caller's access on first use; fields resolve concurrently
+func (c *Caller) access(ctx context.Context) (*access.User, error) {
+	c.once.Do(func() {
+		c.user, c.err = c.Access.User(ctx, c.UserID)
+	})
+	return c.user, c.err
+}
+
+// MemberOf reports whether the caller belongs to the organization
+func (c *Caller) MemberOf(ctx context.Context, orgID uint) bool {
+	user, err := c.access(ctx)
+	return err == nil && user.MemberOf(orgID)
+}
+
+// CanAccess reports whether the caller may see a workflow of the organization
+// scoped to teamID, as RequireAccess does for REST: admins, and members of the
+// organization and of the team, if any
+func (c *Caller) CanAccess(ctx context.Context, orgID uint, teamID *uint) bool {
+	if c.Admin {
+		return true
+	}
+	user, err := c.access(ctx)
+	return err == nil && user.MemberOf(orgID) && (teamID == nil || user.MemberOfTeam(*teamID))
+}
+
+// scopeToAccessible restricts a query of workflows to those the caller may
+// see, as scopeToAccessible does for REST lists
+func (c *Caller) scopeToAccessible(ctx context.Context, query *gorm.DB) (*gorm.DB, error) {
+	if c.Admin {
+		return query, nil
+	}
+	user, err := c.access(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.OrganizationIDs) == 0 {
+		return query.Where("1 = 0"), nil
+	}
+	query = query.Where("organization_id IN ?", user.OrganizationIDs)
+	teamIDs := make([]uint, len(user.Teams))
+	for i, t := range user.Teams {
+		teamIDs[i] = t.ID
+	}
+	if len(teamIDs) == 0 {
+		return query.Where("team_id IS NULL"), nil
+	}
+	return query.Where("(team_id IS NULL OR team_id IN ?)", teamIDs), nil
+}
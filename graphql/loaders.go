@@ -0,0 +1,170 @@
+// Package graphql/loaders.go
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/vikstrous/dataloadgen"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// loaderWait is how long loaders collect keys before querying them together
+const loaderWait = time.Millisecond
+
+// loaders batch the relations resolved for many parents of a response level
+// into one query each, and cache them for the request
+type loaders struct {
+	organization        *dataloadgen.Loader[uint, *models.Organization]
+	organizationsOfUser *dataloadgen.Loader[uint, []*models.Organization]
+	usersOfOrganization *dataloadgen.Loader[uint, []*models.User]
+	subscriptionsOfOrg  *dataloadgen.Loader[uint, []*models.Subscription]
+	workflowsOfOrg      *dataloadgen.Loader[uint, []*models.Workflow]
+}
+
+// newLoaders creates the loaders of one request
+func newLoaders() *loaders {
+	wait := dataloadgen.WithWait(loaderWait)
+	return &loaders{
+		organization:        dataloadgen.NewLoader(loadOrganizations, wait),
+		organizationsOfUser: dataloadgen.NewLoader(loadOrganizationsOfUsers, wait),
+		usersOfOrganization: dataloadgen.NewLoader(loadUsersOfOrganizations, wait),
+		subscriptionsOfOrg:  dataloadgen.NewLoader(loadSubscriptionsOfOrganizations, wait),
+		workflowsOfOrg:      dataloadgen.NewLoader(loadWorkflowsOfOrganizations, wait),
+	}
+}
+
+// loadOrganizations loads organizations by ID, nil for those that don't exist
+func loadOrganizations(ctx context.Context, ids []uint) ([]*models.Organization, []error) {
+	var found []models.Organization
+	if err := callerFrom(ctx).DB.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, []error{err}
+	}
+	byID := make(map[uint]*models.Organization, len(found))
+	for i := range found {
+		byID[found[i].ID] = &found[i]
+	}
+	orgs := make([]*models.Organization, len(ids))
+	for i, id := range ids {
+		orgs[i] = byID[id]
+	}
+	return orgs, nil
+}
+
+// loadOrganizationsOfUsers loads the organizations of users through their memberships
+func loadOrganizationsOfUsers(ctx context.Context, userIDs []uint) ([][]*models.Organization, []error) {
+	db := callerFrom(ctx).DB
+	var pairs []struct {
+		UserID         uint
+		OrganizationID uint
+	}
+	err := db.Table("user_organizations").
+		Select("user_id, organization_id").
+		Where("user_id IN ?", userIDs).
+		Order("organization_id").
+		Scan(&pairs).Error
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	orgIDs := make([]uint, len(pairs))
+	for i, p := range pairs {
+		orgIDs[i] = p.OrganizationID
+	}
+	var orgs []models.Organization
+	if err := db.Where("id IN ?", orgIDs).Find(&orgs).Error; err != nil {
+		return nil, []error{err}
+	}
+	byID := make(map[uint]*models.Organization, len(orgs))
+	for i := range orgs {
+		byID[orgs[i].ID] = &orgs[i]
+	}
+
+	groups := map[uint][]*models.Organization{}
+	for _, p := range pairs {
+		if org, ok := byID[p.OrganizationID]; ok {
+			groups[p.UserID] = append(groups[p.UserID], org)
+		}
+	}
+	return grouped(userIDs, groups), nil
+}
+
+// loadUsersOfOrganizations loads the members of organizations
+func loadUsersOfOrganizations(ctx context.Context, orgIDs []uint) ([][]*models.User, []error) {
+	db := callerFrom(ctx).DB
+	var pairs []struct {
+		OrganizationID uint
+		UserID         uint
+	}
+	err := db.Table("user_organizations").
+		Select("organization_id, user_id").
+		Where("organization_id IN ?", orgIDs).
+		Order("user_id").
+		Scan(&pairs).Error
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	userIDs := make([]uint, len(pairs))
+	for i, p := range pairs {
+		userIDs[i] = p.UserID
+	}
+	var users []models.User
+	if err := db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, []error{err}
+	}
+	byID := make(map[uint]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+
+	groups := map[uint][]*models.User{}
+	for _, p := range pairs {
+		if user, ok := byID[p.UserID]; ok {
+			groups[p.OrganizationID] = append(groups[p.OrganizationID], user)
+		}
+	}
+	return grouped(orgIDs, groups), nil
+}
+
+// loadSubscriptionsOfOrganizations loads the subscriptions of organizations
+func loadSubscriptionsOfOrganizations(ctx context.Context, orgIDs []uint) ([][]*models.Subscription, []error) {
+	var subs []models.Subscription
+	if err := callerFrom(ctx).DB.Where("organization_id IN ?", orgIDs).Order("id").Find(&subs).Error; err != nil {
+		return nil, []error{err}
+	}
+	groups := map[uint][]*models.Subscription{}
+	for i := range subs {
+		groups[subs[i].OrganizationID] = append(groups[subs[i].OrganizationID], &subs[i])
+	}
+	return grouped(orgIDs, groups), nil
+}
+
+// loadWorkflowsOfOrganizations loads the workflows of organizations the caller
+// may see, leaving out those of teams they aren't in
+func loadWorkflowsOfOrganizations(ctx context.Context, orgIDs []uint) ([][]*models.Workflow, []error) {
+	caller := callerFrom(ctx)
+	query, err := caller.scopeToAccessible(ctx, caller.DB.Where("organization_id IN ?", orgIDs))
+	if err != nil {
+		return nil, []error{err}
+	}
+	var workflows []models.Workflow
+	if err := query.Order("id").Find(&workflows).Error; err != nil {
+		return nil, []error{err}
+	}
+	groups := map[uint][]*models.Workflow{}
+	for i := range workflows {
+		groups[workflows[i].OrganizationID] = append(groups[workflows[i].OrganizationID], &workflows[i])
+	}
+	return grouped(orgIDs, groups), nil
+}
+
+// grouped returns the group of every key, empty for keys without records
+func grouped[T any](keys []uint, groups map[uint][]*T) [][]*T {
+	values := make([][]*T, len(keys))
+	for i, k := range keys {
+		values[i] = append([]*T{}, groups[k]...)
+	}
+	return values
+}
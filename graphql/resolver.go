@@ -0,0 +1,160 @@
+// Package graphql/resolver.go
+package graphql
+
+//go:generate go run github.com/99designs/gqlgen generate --config gqlgen.yml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// ErrForbidden is returned for fields the caller may not query at all
+var ErrForbidden = errors.New("forbidden")
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+	// maxComplexity bounds the number of fields a query may select, counting
+	// those of list items once
+	maxComplexity = 500
+)
+
+// Resolver is the root of the resolvers gqlgen generates the stubs of. It has
+// no state: the caller, database and loaders come with the context.
+type Resolver struct{}
+
+// NewServer returns the handler executing the queries of requests whose
+// context was made by NewContext, sent as JSON or in GET parameters
+func NewServer() *handler.Server {
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers:  &Resolver{},
+		Directives: DirectiveRoot{Restricted: restricted},
+	}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(maxComplexity))
+	return srv
+}
+
+// Request is a GraphQL request body
+type Request struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Response is a GraphQL response body
+type Response struct {
+	Data   interface{} `json:"data"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a request or field error
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Audience is who may see a restricted field besides admins
+type Audience string
+
+const (
+	// AudienceSelf is the user the field belongs to
+	AudienceSelf Audience = "SELF"
+	// AudienceMembers are the members of the organization the field belongs to
+	AudienceMembers Audience = "MEMBERS"
+)
+
+// UnmarshalGQL implements graphql.Unmarshaler
+func (a *Audience) UnmarshalGQL(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("audience must be a string")
+	}
+	switch Audience(s) {
+	case AudienceSelf, AudienceMembers:
+		*a = Audience(s)
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid Audience", s)
+}
+
+// MarshalGQL implements graphql.Marshaler
+func (a Audience) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(string(a)))
+}
+
+// restricted implements the @restricted directive: the field resolves to null
+// unless the caller is an admin or in the audience of the parent record
+func restricted(ctx context.Context, obj interface{}, next graphql.Resolver, to Audience) (interface{}, error) {
+	caller := callerFrom(ctx)
+	if caller.Admin {
+		return next(ctx)
+	}
+
+	var allowed bool
+	switch parent := obj.(type) {
+	case *models.User:
+		allowed = to == AudienceSelf && caller.UserID != 0 && parent.ID == caller.UserID
+	case *models.Organization:
+		allowed = to == AudienceMembers && caller.MemberOf(ctx, parent.ID)
+	case *models.Subscription:
+		allowed = to == AudienceMembers && caller.MemberOf(ctx, parent.OrganizationID)
+	}
+	if !allowed {
+		return nil, nil
+	}
+	return next(ctx)
+}
+
+// find loads one record, resolving to null if it doesn't exist
+func find[T any](ctx context.Context, id uint) (*T, error) {
+	if id == 0 {
+		return nil, nil
+	}
+
+	var record T
+	err := callerFrom(ctx).DB.First(&record, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// list loads a page of the records matched by query
+func list[T any](query *gorm.DB, limit, offset *int) ([]*T, error) {
+	n := defaultListLimit
+	if limit != nil && *limit > 0 {
+		n = min(*limit, maxListLimit)
+	}
+	skip := 0
+	if offset != nil && *offset > 0 {
+		skip = *offset
+	}
+
+	var records []T
+	if err := query.Order("id").Limit(n).Offset(skip).Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	values := make([]*T, len(records))
+	for i := range records {
+		values[i] = &records[i]
+	}
+	return values, nil
+}
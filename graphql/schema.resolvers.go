@@ -0,0 +1,140 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// Users is the resolver for the users field.
+func (r *organizationResolver) Users(ctx context.Context, obj *models.Organization) ([]*models.User, error) {
+	return callerFrom(ctx).loaders.usersOfOrganization.Load(ctx, obj.ID)
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *organizationResolver) Subscriptions(ctx context.Context, obj *models.Organization) ([]*models.Subscription, error) {
+	return callerFrom(ctx).loaders.subscriptionsOfOrg.Load(ctx, obj.ID)
+}
+
+// Workflows is the resolver for the workflows field.
+func (r *organizationResolver) Workflows(ctx context.Context, obj *models.Organization) ([]*models.Workflow, error) {
+	return callerFrom(ctx).loaders.workflowsOfOrg.Load(ctx, obj.ID)
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*models.User, error) {
+	return find[models.User](ctx, callerFrom(ctx).UserID)
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id uint) (*models.User, error) {
+	return find[models.User](ctx, id)
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, limit *int, offset *int) ([]*models.User, error) {
+	caller := callerFrom(ctx)
+	if !caller.Admin {
+		return nil, ErrForbidden
+	}
+	return list[models.User](caller.DB, limit, offset)
+}
+
+// Organization is the resolver for the organization field.
+func (r *queryResolver) Organization(ctx context.Context, id uint) (*models.Organization, error) {
+	return find[models.Organization](ctx, id)
+}
+
+// Organizations is the resolver for the organizations field.
+func (r *queryResolver) Organizations(ctx context.Context, limit *int, offset *int) ([]*models.Organization, error) {
+	caller := callerFrom(ctx)
+	if !caller.Admin {
+		return nil, ErrForbidden
+	}
+	return list[models.Organization](caller.DB, limit, offset)
+}
+
+// Subscription is the resolver for the subscription field.
+func (r *queryResolver) Subscription(ctx context.Context, id uint) (*models.Subscription, error) {
+	return find[models.Subscription](ctx, id)
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *queryResolver) Subscriptions(ctx context.Context, organizationID *uint, status *string, limit *int, offset *int) ([]*models.Subscription, error) {
+	caller := callerFrom(ctx)
+	if !caller.Admin {
+		return nil, ErrForbidden
+	}
+	query := caller.DB
+	if organizationID != nil {
+		query = query.Where("organization_id = ?", *organizationID)
+	}
+	if status != nil {
+		query = query.Where("status = ?", *status)
+	}
+	return list[models.Subscription](query, limit, offset)
+}
+
+// Workflow is the resolver for the workflow field.
+func (r *queryResolver) Workflow(ctx context.Context, id uint) (*models.Workflow, error) {
+	workflow, err := find[models.Workflow](ctx, id)
+	if err != nil || workflow == nil {
+		return nil, err
+	}
+	// Like REST, workflows the caller may not use don't exist for them
+	if !callerFrom(ctx).CanAccess(ctx, workflow.OrganizationID, workflow.TeamID) {
+		return nil, nil
+	}
+	return workflow, nil
+}
+
+// Workflows is the resolver for the workflows field.
+func (r *queryResolver) Workflows(ctx context.Context, organizationID *uint, limit *int, offset *int) ([]*models.Workflow, error) {
+	caller := callerFrom(ctx)
+	query, err := caller.scopeToAccessible(ctx, caller.DB)
+	if err != nil {
+		return nil, err
+	}
+	if organizationID != nil {
+		query = query.Where("organization_id = ?", *organizationID)
+	}
+	return list[models.Workflow](query, limit, offset)
+}
+
+// Status is the resolver for the status field.
+func (r *subscriptionResolver) Status(ctx context.Context, obj *models.Subscription) (string, error) {
+	return string(obj.Status), nil
+}
+
+// Organization is the resolver for the organization field.
+func (r *subscriptionResolver) Organization(ctx context.Context, obj *models.Subscription) (*models.Organization, error) {
+	return callerFrom(ctx).loaders.organization.Load(ctx, obj.OrganizationID)
+}
+
+// Organizations is the resolver for the organizations field.
+func (r *userResolver) Organizations(ctx context.Context, obj *models.User) ([]*models.Organization, error) {
+	return callerFrom(ctx).loaders.organizationsOfUser.Load(ctx, obj.ID)
+}
+
+// Organization is the resolver for the organization field.
+func (r *workflowResolver) Organization(ctx context.Context, obj *models.Workflow) (*models.Organization, error) {
+	return callerFrom(ctx).loaders.organization.Load(ctx, obj.OrganizationID)
+}
+
+func (r *Resolver) Organization() OrganizationResolver { return &organizationResolver{r} }
+
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+func (r *Resolver) Workflow() WorkflowResolver { return &workflowResolver{r} }
+
+type (
+	organizationResolver struct{ *Resolver }
+	queryResolver        struct{ *Resolver }
+	subscriptionResolver struct{ *Resolver }
+	userResolver         struct{ *Resolver }
+	workflowResolver     struct{ *Resolver }
+)
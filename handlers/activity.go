@@ -0,0 +1,85 @@
+// Package handlers/activity.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+)
+
+// activitySorts lists the columns activity logs can be sorted by
+var activitySorts = map[string]bool{
+	"timestamp":     true,
+	"activity_type": true,
+	"user_id":       true,
+}
+
+// ListMyActivity returns the authenticated user's own activity
+func (h *Handler) ListMyActivity(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(apperror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	query := h.replica(c).Model(&models.ActivityLog{}).Where("user_id = ?", userID)
+	h.listActivity(c, query)
+}
+
+// ListOrganizationActivity returns the activity of an organization's members
+func (h *Handler) ListOrganizationActivity(c *gin.Context) {
+	orgID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	query := h.replica(c).Model(&models.ActivityLog{}).Where("organization_id = ?", orgID)
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	h.listActivity(c, query)
+}
+
+// listActivity applies the shared activity filters and writes a page of results
+func (h *Handler) listActivity(c *gin.Context, query *gorm.DB) {
+	if activityType := c.Query("activity_type"); activityType != "" {
+		query = query.Where("activity_type = ?", activityType)
+	}
+
+	query, err := parseTimeRange(c, query, "timestamp")
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid date range, expected RFC3339 timestamps"))
+		return
+	}
+
+	if cursorRequested(c) {
+		cursorPage(c, query, func(l models.ActivityLog) cursor { return cursor{CreatedAt: l.CreatedAt, ID: l.ID} })
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	var logs []models.ActivityLog
+	err = query.Order(parseSort(c, activitySorts, "timestamp DESC, id DESC")).
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Page{Data: logs, Total: total, Limit: limit, Offset: offset})
+}
@@ -0,0 +1,266 @@
+// Package handlers/announcements.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// UserAnnouncement is an announcement as a user sees it, with when they read it
+type UserAnnouncement struct {
+	models.Announcement
+	// ReadAt is nil while the user hasn't read the announcement
+	ReadAt *time.Time `json:"read_at"`
+}
+
+// ListAnnouncements returns every announcement, drafts and expired ones
+// included, newest first
+// @Success 200 Page[models.Announcement]
+func (h *Handler) ListAnnouncements(c *gin.Context) {
+	query := h.db(c).Model(&models.Announcement{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	var list []models.Announcement
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Page{Data: list, Total: total, Limit: limit, Offset: offset})
+}
+
+// CreateAnnouncement creates an announcement, published at once or later when
+// given a publication time, or a draft
+// @Body dto.CreateAnnouncementRequest
+// @Success 201 models.Announcement
+func (h *Handler) CreateAnnouncement(c *gin.Context) {
+	var req dto.CreateAnnouncementRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	announcement := req.Model()
+	announcement.CreatedBy = currentUserID(c)
+	if !validAnnouncementWindow(c, &announcement) {
+		return
+	}
+	if err := h.db(c).Create(&announcement).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusCreated, announcement)
+}
+
+// GetAnnouncement returns an announcement
+// @Success 200 models.Announcement
+func (h *Handler) GetAnnouncement(c *gin.Context) {
+	announcement, ok := h.findAnnouncement(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, announcement)
+}
+
+// UpdateAnnouncement updates an announcement
+// @Body dto.UpdateAnnouncementRequest
+// @Success 200 models.Announcement
+func (h *Handler) UpdateAnnouncement(c *gin.Context) {
+	announcement, ok := h.findAnnouncement(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateAnnouncementRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.Apply(announcement)
+	if !validAnnouncementWindow(c, announcement) {
+		return
+	}
+	if err := h.db(c).Save(announcement).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, announcement)
+}
+
+// DeleteAnnouncement deletes an announcement, which users no longer see
+// @Success 204
+func (h *Handler) DeleteAnnouncement(c *gin.Context) {
+	announcement, ok := h.findAnnouncement(c)
+	if !ok {
+		return
+	}
+
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("announcement_id = ?", announcement.ID).Delete(&models.AnnouncementRead{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(announcement).Error
+	})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListMyAnnouncements returns the published announcements that didn't expire,
+// newest first, with when the authenticated user read them
+// @Query unread boolean Only the announcements the user hasn't read
+// @Success 200 Page[handlers.UserAnnouncement]
+func (h *Handler) ListMyAnnouncements(c *gin.Context) {
+	userID := currentUserID(c)
+	query := visibleAnnouncements(h.replica(c), time.Now())
+	if unread := c.Query("unread"); unread != "" {
+		v, err := strconv.ParseBool(unread)
+		if err != nil {
+			c.Error(apperror.BadRequest("Invalid unread, expected true or false"))
+			return
+		}
+		read := h.replica(c).Model(&models.AnnouncementRead{}).Select("announcement_id").Where("user_id = ?", userID)
+		if v {
+			query = query.Where("announcements.id NOT IN (?)", read)
+		} else {
+			query = query.Where("announcements.id IN (?)", read)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	var announcements []models.Announcement
+	if err := query.Order("published_at DESC, id DESC").Limit(limit).Offset(offset).Find(&announcements).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	ids := make([]uint, len(announcements))
+	for i, a := range announcements {
+		ids[i] = a.ID
+	}
+	var reads []models.AnnouncementRead
+	if err := h.replica(c).Where("user_id = ? AND announcement_id IN ?", userID, ids).Find(&reads).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	readAt := make(map[uint]time.Time, len(reads))
+	for _, r := range reads {
+		readAt[r.AnnouncementID] = r.ReadAt
+	}
+
+	list := make([]UserAnnouncement, len(announcements))
+	for i, a := range announcements {
+		list[i] = UserAnnouncement{Announcement: a}
+		if t, ok := readAt[a.ID]; ok {
+			list[i].ReadAt = &t
+		}
+	}
+	c.JSON(http.StatusOK, Page{Data: list, Total: total, Limit: limit, Offset: offset})
+}
+
+// MarkAnnouncementRead marks a published announcement read by the
+// authenticated user; marking it again keeps the first time
+// @Success 204
+func (h *Handler) MarkAnnouncementRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid announcement ID"))
+		return
+	}
+
+	var announcement models.Announcement
+	if err := visibleAnnouncements(h.db(c), time.Now()).First(&announcement, id).Error; err != nil {
+		c.Error(apperror.NotFound("Announcement not found"))
+		return
+	}
+
+	read := models.AnnouncementRead{UserID: currentUserID(c), AnnouncementID: announcement.ID, ReadAt: time.Now()}
+	if err := h.db(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&read).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// MarkAllAnnouncementsRead marks every published announcement read by the
+// authenticated user
+// @Success 204
+func (h *Handler) MarkAllAnnouncementsRead(c *gin.Context) {
+	userID := currentUserID(c)
+	now := time.Now()
+
+	var ids []uint
+	read := h.db(c).Model(&models.AnnouncementRead{}).Select("announcement_id").Where("user_id = ?", userID)
+	if err := visibleAnnouncements(h.db(c), now).Where("announcements.id NOT IN (?)", read).Pluck("id", &ids).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if len(ids) > 0 {
+		reads := make([]models.AnnouncementRead, len(ids))
+		for i, id := range ids {
+			reads[i] = models.AnnouncementRead{UserID: userID, AnnouncementID: id, ReadAt: now}
+		}
+		if err := h.db(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&reads).Error; err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findAnnouncement loads the announcement named in the route, writing an error
+// response if missing
+func (h *Handler) findAnnouncement(c *gin.Context) (*models.Announcement, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid announcement ID"))
+		return nil, false
+	}
+
+	var announcement models.Announcement
+	if err := h.db(c).First(&announcement, id).Error; err != nil {
+		c.Error(apperror.NotFound("Announcement not found"))
+		return nil, false
+	}
+	return &announcement, true
+}
+
+// validAnnouncementWindow checks that an announcement expires after it's
+// published, writing an error response if not
+func validAnnouncementWindow(c *gin.Context, a *models.Announcement) bool {
+	if a.PublishedAt != nil && a.ExpiresAt != nil && !a.ExpiresAt.After(*a.PublishedAt) {
+		c.Error(apperror.Validation([]FieldError{{Field: "expires_at", Rule: "gtfield", Param: "published_at", Message: "must be after published_at"}}))
+		return false
+	}
+	return true
+}
+
+// visibleAnnouncements scopes db to the announcements users see at now: those
+// published and not expired
+func visibleAnnouncements(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Model(&models.Announcement{}).
+		Where("announcements.published_at IS NOT NULL AND announcements.published_at <= ?", now).
+		Where("(announcements.expires_at IS NULL OR announcements.expires_at > ?)", now)
+}
@@ -0,0 +1,154 @@
+// Package handlers/approvals.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/workflow"
+)
+
+// approvalSorts lists the columns approvals can be sorted by
+var approvalSorts = map[string]bool{
+	"created_at": true,
+	"decided_at": true,
+}
+
+// approvalDecision is the request body for approving or rejecting an approval
+type approvalDecision struct {
+	Comment string `json:"comment"`
+}
+
+// approvalDelegation is the request body for delegating an approval
+type approvalDelegation struct {
+	UserID  uint   `json:"user_id" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// ListMyApprovals returns the approvals assigned to the authenticated user, pending ones by default
+func (h *Handler) ListMyApprovals(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(apperror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	status := c.DefaultQuery("status", string(models.WorkflowApprovalStatusPending))
+	query := h.db(c).Model(&models.WorkflowApproval{}).Where("approver_id = ? AND status = ?", userID, status)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	var approvals []models.WorkflowApproval
+	err := query.Order(parseSort(c, approvalSorts, "created_at ASC, id ASC")).
+		Limit(limit).
+		Offset(offset).
+		Find(&approvals).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Page{Data: approvals, Total: total, Limit: limit, Offset: offset})
+}
+
+// ApproveWorkflowApproval approves a pending approval, resuming its workflow run
+func (h *Handler) ApproveWorkflowApproval(c *gin.Context) {
+	h.decideApproval(c, h.Workflows.Approve)
+}
+
+// RejectWorkflowApproval rejects a pending approval, failing its workflow run
+func (h *Handler) RejectWorkflowApproval(c *gin.Context) {
+	h.decideApproval(c, h.Workflows.Reject)
+}
+
+// DelegateWorkflowApproval hands a pending approval over to another organization member
+func (h *Handler) DelegateWorkflowApproval(c *gin.Context) {
+	id, userID, ok := approvalParams(c)
+	if !ok {
+		return
+	}
+
+	var req approvalDelegation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+
+	approval, err := h.Workflows.Delegate(c.Request.Context(), id, userID, req.UserID, req.Comment)
+	if err != nil {
+		approvalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, approval)
+}
+
+// decideApproval applies an approve or reject decision and responds with the updated approval
+func (h *Handler) decideApproval(c *gin.Context, decide func(ctx context.Context, approvalID, userID uint, comment string) error) {
+	id, userID, ok := approvalParams(c)
+	if !ok {
+		return
+	}
+
+	var req approvalDecision
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+	}
+
+	if err := decide(c.Request.Context(), id, userID, req.Comment); err != nil {
+		approvalError(c, err)
+		return
+	}
+
+	var approval models.WorkflowApproval
+	h.db(c).First(&approval, id)
+	c.JSON(http.StatusOK, approval)
+}
+
+// approvalParams reads the approval ID and the authenticated user, responding on failure
+func approvalParams(c *gin.Context) (uint, uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid approval ID"))
+		return 0, 0, false
+	}
+
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(apperror.Unauthorized("Unauthorized"))
+		return 0, 0, false
+	}
+
+	return uint(id), userID.(uint), true
+}
+
+// approvalError maps approval errors to HTTP responses
+func approvalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(apperror.NotFound("Approval not found"))
+	case errors.Is(err, workflow.ErrNotApprover):
+		c.Error(apperror.Forbidden(err.Error()))
+	case errors.Is(err, workflow.ErrApprovalNotPending):
+		c.Error(apperror.Conflict(err.Error()))
+	case errors.Is(err, workflow.ErrInvalidDelegate):
+		c.Error(apperror.BadRequest(err.Error()))
+	default:
+		c.Error(apperror.Internal(err))
+	}
+}
@@ -0,0 +1,283 @@
+// Package handlers/attachments.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/attachments"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/storage"
+)
+
+// ListWorkflowAttachments returns the files attached to a workflow, newest
+// first, with signed URLs to download them
+// @Success 200 Page[models.Attachment]
+func (h *Handler) ListWorkflowAttachments(c *gin.Context) {
+	h.listAttachments(c, models.AttachableWorkflow, "Workflow not found")
+}
+
+// AttachToWorkflow attaches the file uploaded as the multipart form field
+// "file" to a workflow. Files the malware scan flags are quarantined.
+// @Success 201 models.Attachment
+func (h *Handler) AttachToWorkflow(c *gin.Context) {
+	h.uploadAttachment(c, models.AttachableWorkflow, "Workflow not found")
+}
+
+// ListReportAttachments returns the files attached to a report, newest first,
+// with signed URLs to download them
+// @Success 200 Page[models.Attachment]
+func (h *Handler) ListReportAttachments(c *gin.Context) {
+	h.listAttachments(c, models.AttachableReport, "Report not found")
+}
+
+// AttachToReport attaches the file uploaded as the multipart form field "file"
+// to a report. Files the malware scan flags are quarantined.
+// @Success 201 models.Attachment
+func (h *Handler) AttachToReport(c *gin.Context) {
+	h.uploadAttachment(c, models.AttachableReport, "Report not found")
+}
+
+// ListSubscriptionAttachments returns the files attached to a subscription,
+// newest first, with signed URLs to download them
+// @Success 200 Page[models.Attachment]
+func (h *Handler) ListSubscriptionAttachments(c *gin.Context) {
+	h.listAttachments(c, models.AttachableSubscription, "Subscription not found")
+}
+
+// AttachToSubscription attaches the file uploaded as the multipart form field
+// "file" to a subscription. Files the malware scan flags are quarantined.
+// @Success 201 models.Attachment
+func (h *Handler) AttachToSubscription(c *gin.Context) {
+	h.uploadAttachment(c, models.AttachableSubscription, "Subscription not found")
+}
+
+// GetAttachmentLimits returns the size and content types of the files an
+// organization's plan lets its members attach
+// @Success 200 attachments.Limits
+func (h *Handler) GetAttachmentLimits(c *gin.Context) {
+	orgID, ok := h.organizationMember(c)
+	if !ok {
+		return
+	}
+
+	limits, err := h.Attachments.Limits(c.Request.Context(), orgID)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, limits)
+}
+
+// ListOrganizationAttachments returns a page of the files attached to an
+// organization's records, such as those quarantined
+// @Query scan_status string Only the files unscanned, clean, quarantined or cleared
+// @Query attachable_type string Only the files attached to workflows, reports or subscriptions
+// @Query user_id integer Only the files a member uploaded
+// @Query q string Text to search filenames for
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[models.Attachment]
+func (h *Handler) ListOrganizationAttachments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	listPage[models.Attachment](c, h.replica(c).Model(&models.Attachment{}).Where("organization_id = ?", id), attachmentList)
+}
+
+// ClearAttachment releases a file from quarantine once an admin has checked
+// it, making it downloadable again
+// @Success 200 models.Attachment
+func (h *Handler) ClearAttachment(c *gin.Context) {
+	attachment, ok := h.findAttachment(c)
+	if !ok {
+		return
+	}
+
+	err := h.Attachments.Clear(c.Request.Context(), attachment, currentUserID(c))
+	if errors.Is(err, attachments.ErrNotQuarantined) {
+		c.Error(apperror.Conflict("The attachment isn't quarantined").With("scan_status", attachment.ScanStatus))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, attachment)
+}
+
+// GetAttachment returns an attachment with a fresh signed URL to download it,
+// unless it's quarantined
+// @Success 200 models.Attachment
+func (h *Handler) GetAttachment(c *gin.Context) {
+	attachment, ok := h.findAttachment(c)
+	if !ok || !h.requireMember(c, attachment.OrganizationID) {
+		return
+	}
+
+	h.Attachments.Sign(attachment)
+	c.JSON(http.StatusOK, attachment)
+}
+
+// DeleteAttachment deletes an attachment with its file. Only its uploader and
+// admins may delete it.
+// @Success 204
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	attachment, ok := h.findAttachment(c)
+	if !ok {
+		return
+	}
+	if attachment.UserID != currentUserID(c) {
+		acc, err := h.Access.User(c.Request.Context(), currentUserID(c))
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		if !acc.HasRole(models.AdminRole) {
+			c.Error(apperror.Forbidden("Only the uploader may delete the attachment"))
+			return
+		}
+	}
+
+	if err := h.Attachments.Delete(c.Request.Context(), attachment); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DownloadAttachment streams the file of an attachment to anyone holding a
+// signed URL that hasn't expired, unless it's quarantined
+// @Query expires integer Unix time the URL expires at
+// @Query signature string Signature of the URL
+func (h *Handler) DownloadAttachment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid attachment ID"))
+		return
+	}
+
+	attachment, file, err := h.Attachments.Open(c.Request.Context(), uint(id), c.Query("expires"), c.Query("signature"))
+	if errors.Is(err, attachments.ErrInvalidSignature) {
+		c.Error(apperror.Forbidden("The download URL is invalid or has expired"))
+		return
+	}
+	if errors.Is(err, attachments.ErrQuarantined) {
+		c.Error(apperror.Forbidden("The file was quarantined by the malware scan"))
+		return
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		c.Error(apperror.NotFound("Attachment not found"))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	defer file.Close()
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	// Files may be HTML or SVG; their scripts never run when opened directly
+	c.Header("Content-Security-Policy", "default-src 'none'; sandbox")
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, file, nil)
+}
+
+// listAttachments writes a page of the attachments of the record of a table
+// named in the route, which the user must be an admin or a member of the
+// organization of
+func (h *Handler) listAttachments(c *gin.Context, attachableType, notFound string) {
+	id, orgID, ok := h.findRecord(c, attachableType, notFound)
+	if !ok || !h.requireMember(c, orgID) {
+		return
+	}
+
+	query := h.replica(c).Model(&models.Attachment{}).
+		Where("attachable_type = ? AND attachable_id = ?", attachableType, id)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	list := []models.Attachment{}
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	for i := range list {
+		h.Attachments.Sign(&list[i])
+	}
+	c.JSON(http.StatusOK, Page{Data: list, Total: total, Limit: limit, Offset: offset})
+}
+
+// uploadAttachment attaches the uploaded file to the record of a table named
+// in the route, which the user must be an admin or a member of the
+// organization of
+func (h *Handler) uploadAttachment(c *gin.Context, attachableType, notFound string) {
+	id, orgID, ok := h.findRecord(c, attachableType, notFound)
+	if !ok || !h.requireMember(c, orgID) {
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.Error(apperror.BadRequest("Upload the file as the multipart form field \"file\""))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	defer file.Close()
+
+	attachment := models.Attachment{
+		OrganizationID: orgID,
+		AttachableType: attachableType,
+		AttachableID:   id,
+		UserID:         currentUserID(c),
+		Filename:       header.Filename,
+		ContentType:    header.Header.Get("Content-Type"),
+		Size:           header.Size,
+	}
+	err = h.Attachments.Upload(c.Request.Context(), &attachment, file)
+	switch {
+	case errors.Is(err, attachments.ErrTooLarge):
+		limits, _ := h.Attachments.Limits(c.Request.Context(), orgID)
+		c.Error(apperror.TooLarge(fmt.Sprintf("The file is larger than the %d bytes the plan allows", limits.MaxSize)))
+	case errors.Is(err, attachments.ErrUnsupportedType):
+		c.Error(apperror.Unprocessable(fmt.Sprintf("The plan doesn't allow attaching %s files", attachment.ContentType)))
+	case errors.Is(err, attachments.ErrScanFailed):
+		c.Error(apperror.Unavailable("Files can't be scanned for malware right now, try again later"))
+	case err != nil:
+		c.Error(apperror.Internal(err))
+	default:
+		c.JSON(http.StatusCreated, attachment)
+	}
+}
+
+// findAttachment loads the attachment named in the route, writing an error
+// response if missing
+func (h *Handler) findAttachment(c *gin.Context) (*models.Attachment, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid attachment ID"))
+		return nil, false
+	}
+
+	var attachment models.Attachment
+	if err := h.db(c).First(&attachment, id).Error; err != nil {
+		c.Error(apperror.NotFound("Attachment not found"))
+		return nil, false
+	}
+	return &attachment, true
+}
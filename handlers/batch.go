@@ -0,0 +1,118 @@
+// Package handlers/batch.go
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/middleware"
+	"github.com/4cecoder/saas/requestid"
+)
+
+// maxBatchRequests caps the number of sub-requests in one batch
+const maxBatchRequests = 20
+
+// forwardedHeaders are copied from the batch request to each sub-request
+var forwardedHeaders = []string{"Authorization", "Cookie", "Accept-Language", "X-Request-ID", "X-CSRF-Token"}
+
+// BatchRequest is one sub-request of a batch
+type BatchRequest struct {
+	Method  string            `json:"method" binding:"required"`
+	Path    string            `json:"path" binding:"required"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// BatchResponse is the result of one sub-request
+type BatchResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// batchPayload is the request body of POST /batch
+type batchPayload struct {
+	Requests []BatchRequest `json:"requests" binding:"required,dive"`
+	// StopOnError skips the remaining sub-requests after the first failure
+	StopOnError bool `json:"stop_on_error"`
+}
+
+// Batch executes sub-requests in order through the router, each as its own request
+// with the caller's credentials, and returns their individual status codes and bodies
+func (h *Handler) Batch(c *gin.Context) {
+	var payload batchPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+	if len(payload.Requests) > maxBatchRequests {
+		c.Error(apperror.BadRequest(fmt.Sprintf("At most %d requests are allowed per batch", maxBatchRequests)))
+		return
+	}
+	for i, sub := range payload.Requests {
+		if !strings.HasPrefix(sub.Path, "/") || strings.HasPrefix(middleware.TrimVersion(sub.Path), "/batch") {
+			c.Error(apperror.BadRequest(fmt.Sprintf("Request %d has an invalid path", i)))
+			return
+		}
+	}
+
+	responses := make([]BatchResponse, 0, len(payload.Requests))
+	for _, sub := range payload.Requests {
+		resp := h.dispatch(c, sub)
+		responses = append(responses, resp)
+		if payload.StopOnError && resp.Status >= http.StatusBadRequest {
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"responses": responses})
+}
+
+// dispatch runs one sub-request through the router and captures its response
+func (h *Handler) dispatch(c *gin.Context, sub BatchRequest) BatchResponse {
+	// Unversioned paths resolve against the API version of the batch request itself
+	path := sub.Path
+	if version := strings.TrimSuffix(c.FullPath(), "/batch"); middleware.TrimVersion(path) == path {
+		path = version + path
+	}
+
+	req, err := http.NewRequestWithContext(c.Request.Context(), strings.ToUpper(sub.Method), path, bytes.NewReader(sub.Body))
+	if err != nil {
+		msg, _ := json.Marshal(apperror.BadRequest(err.Error()).Body())
+		return BatchResponse{Status: http.StatusBadRequest, Body: msg}
+	}
+
+	for _, name := range forwardedHeaders {
+		if v := c.GetHeader(name); v != "" {
+			req.Header.Set(name, v)
+		}
+	}
+	// Sub-requests are logged under the ID of the batch
+	requestid.Propagate(req)
+	for name, v := range sub.Headers {
+		req.Header.Set(name, v)
+	}
+	if len(sub.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.RemoteAddr = c.Request.RemoteAddr
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+
+	resp := BatchResponse{Status: rec.Code}
+	if raw := rec.Body.Bytes(); len(raw) > 0 {
+		if json.Valid(raw) {
+			resp.Body = raw
+		} else {
+			resp.Body, _ = json.Marshal(string(raw))
+		}
+	}
+	return resp
+}
@@ -0,0 +1,190 @@
+// Package handlers/branding.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/branding"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// assetFormats names the file formats each kind of branding asset may be
+var assetFormats = map[branding.Kind]string{
+	branding.KindLogo:    "PNG, JPEG, WebP or SVG",
+	branding.KindFavicon: "PNG, ICO or SVG",
+}
+
+// UpdateOrganizationBranding changes the theme color, email footer and
+// white-label domain of an organization
+// @Body dto.UpdateBrandingRequest
+// @Success 200 models.OrganizationSettings
+func (h *Handler) UpdateOrganizationBranding(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	var req dto.UpdateBrandingRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	// An empty domain removes it, so it's only checked when given
+	if req.Domain != nil && *req.Domain != "" {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok && v.Var(*req.Domain, "fqdn") != nil {
+			c.Error(apperror.Validation([]FieldError{{Field: "domain", Rule: "fqdn", Message: "must be a domain name such as example.com"}}))
+			return
+		}
+	}
+
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if req.Domain != nil && *req.Domain != "" {
+		var taken int64
+		err := h.db(c).Model(&models.Organization{}).Where("domain = ? AND id <> ?", strings.ToLower(*req.Domain), org.ID).Count(&taken).Error
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		} else if taken > 0 {
+			c.Error(apperror.Conflict("Another organization uses the domain"))
+			return
+		}
+	}
+
+	if err := h.Organizations.Update(c.Request.Context(), org, &req, readAt(c, org.UpdatedAt)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, org.Settings)
+}
+
+// PutBrandingAsset uploads the logo or favicon of an organization from the
+// request body, replacing the previous one. Logos may be PNG, JPEG, WebP or
+// SVG; favicons PNG, ICO or SVG; either at most 512 KiB.
+// @Success 200 models.OrganizationSettings
+func (h *Handler) PutBrandingAsset(c *gin.Context) {
+	org, kind, ok := h.findBrandingAsset(c)
+	if !ok {
+		return
+	}
+
+	mediaType, _, _ := strings.Cut(c.ContentType(), ";")
+	err := h.Branding.Put(c.Request.Context(), org, kind, strings.TrimSpace(mediaType), c.Request.Body)
+	switch {
+	case errors.Is(err, branding.ErrUnsupportedType):
+		c.Error(apperror.BadRequest(fmt.Sprintf("Send the %s as %s", kind, assetFormats[kind])))
+	case errors.Is(err, branding.ErrTooLarge):
+		c.Error(apperror.TooLarge(fmt.Sprintf("The %s is larger than %d bytes", kind, branding.MaxSize)))
+	case errors.Is(err, branding.ErrInvalidImage):
+		c.Error(apperror.Unprocessable(fmt.Sprintf("The %s isn't a valid %s file", kind, mediaType)))
+	case err != nil:
+		c.Error(apperror.From(err))
+	default:
+		c.JSON(http.StatusOK, org.Settings)
+	}
+}
+
+// DeleteBrandingAsset removes the uploaded logo or favicon of an organization
+// @Success 204
+func (h *Handler) DeleteBrandingAsset(c *gin.Context) {
+	org, kind, ok := h.findBrandingAsset(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Branding.Delete(c.Request.Context(), org, kind); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findBrandingAsset loads the organization and asset kind named in the route,
+// writing an error response if either is invalid
+func (h *Handler) findBrandingAsset(c *gin.Context) (*models.Organization, branding.Kind, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return nil, "", false
+	}
+	kind := branding.Kind(c.Param("asset"))
+	if kind != branding.KindLogo && kind != branding.KindFavicon {
+		c.Error(apperror.NotFound("Route not found"))
+		return nil, "", false
+	}
+
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return nil, "", false
+	}
+	return org, kind, true
+}
+
+// GetBranding returns the branding of the organization whose white-label
+// frontend is served on a domain, for it to style its pages before users sign in
+// @Query domain string Domain the frontend is served on
+// @Success 200 dto.Branding
+func (h *Handler) GetBranding(c *gin.Context) {
+	domain := strings.ToLower(strings.TrimSpace(c.Query("domain")))
+	if domain == "" {
+		c.Error(apperror.BadRequest("The domain is required"))
+		return
+	}
+
+	var org models.Organization
+	err := h.replica(c).Select("id", "name", "logo_url", "favicon_url", "theme_color", "updated_at").
+		Where("domain = ?", domain).
+		First(&org).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperror.NotFound("No organization uses the domain"))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	respondCacheable(c, catalogMaxAge, org.UpdatedAt, dto.Branding{
+		Name:       org.Name,
+		LogoURL:    org.Settings.LogoURL,
+		FaviconURL: org.Settings.FaviconURL,
+		ThemeColor: org.Settings.ThemeColor,
+	})
+}
+
+// GetBrandingAsset serves an uploaded logo or favicon. Their names change with
+// every upload, so they're cached for a year.
+func (h *Handler) GetBrandingAsset(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	file, contentType, err := h.Branding.Open(c.Request.Context(), uint(id), c.Param("name"))
+	if err != nil {
+		c.Error(apperror.NotFound("Asset not found"))
+		return
+	}
+	defer file.Close()
+
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	// SVG may carry scripts; they never run when the asset is opened directly
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
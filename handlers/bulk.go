@@ -0,0 +1,246 @@
+// Package handlers/bulk.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/repository"
+)
+
+// maxBulkItems caps the number of items accepted by one bulk request
+const maxBulkItems = 500
+
+// errBulkFailed rolls back an atomic bulk request after an item failed
+var errBulkFailed = errors.New("bulk request failed")
+
+// BulkResult is the outcome of one item of a bulk request
+type BulkResult struct {
+	Index  int         `json:"index"`
+	Status int         `json:"status"`
+	ID     uint        `json:"id,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	// Fields lists the validation failures of a rejected item
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// BulkResponse reports the outcome of every item of a bulk request
+type BulkResponse struct {
+	Results   []BulkResult `json:"results"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+}
+
+// bulkDeleteRequest is the request body of bulk deletes
+type bulkDeleteRequest struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+// bulkService is the part of a resource service that bulk requests use
+type bulkService[T any] interface {
+	Create(ctx context.Context, req dto.Creator[T]) (*T, error)
+	Get(ctx context.Context, id uint, preload ...string) (*T, error)
+	Update(ctx context.Context, record *T, req dto.Updater[T], readAt time.Time) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// BulkCreateUsers creates users from an array
+func (h *Handler) BulkCreateUsers(c *gin.Context) {
+	bulkCreate[dto.CreateUserRequest, models.User](h, c, h.Users)
+}
+
+// BulkUpdateUsers updates users from an array of objects with their id
+func (h *Handler) BulkUpdateUsers(c *gin.Context) {
+	bulkUpdate[dto.UpdateUserRequest, models.User](h, c, h.Users)
+}
+
+// BulkDeleteUsers deletes the users with the given ids
+func (h *Handler) BulkDeleteUsers(c *gin.Context) { bulkDelete[models.User](h, c, h.Users) }
+
+// BulkCreateSeats creates seats from an array
+func (h *Handler) BulkCreateSeats(c *gin.Context) {
+	bulkCreate[dto.CreateSeatRequest, models.Seat](h, c, h.Seats)
+}
+
+// BulkUpdateSeats updates seats from an array of objects with their id
+func (h *Handler) BulkUpdateSeats(c *gin.Context) {
+	bulkUpdate[dto.UpdateSeatRequest, models.Seat](h, c, h.Seats)
+}
+
+// BulkDeleteSeats deletes the seats with the given ids
+func (h *Handler) BulkDeleteSeats(c *gin.Context) { bulkDelete[models.Seat](h, c, h.Seats) }
+
+// bulkCreate decodes each array element into the create request R and creates the T it maps to
+func bulkCreate[R dto.Creator[T], T any](h *Handler, c *gin.Context, svc bulkService[T]) {
+	var items []json.RawMessage
+	if !bindBulk(c, &items, countItems) {
+		return
+	}
+
+	h.runBulk(c, len(items), func(ctx context.Context, i int) BulkResult {
+		var req R
+		if err := json.Unmarshal(items[i], &req); err != nil {
+			return BulkResult{Status: http.StatusBadRequest, Error: err.Error()}
+		}
+		if fields := validate(&req); fields != nil {
+			return BulkResult{Status: http.StatusUnprocessableEntity, Error: summarize(fields), Fields: fields}
+		}
+
+		item, err := svc.Create(ctx, req)
+		if err != nil {
+			return bulkFailure(err, 0)
+		}
+		return BulkResult{Status: http.StatusCreated, ID: primaryKey(item), Data: item}
+	})
+}
+
+// bulkUpdate decodes each array element into the update request R and applies it to
+// the stored T with the element's id
+func bulkUpdate[R dto.Updater[T], T any](h *Handler, c *gin.Context, svc bulkService[T]) {
+	var items []json.RawMessage
+	if !bindBulk(c, &items, countItems) {
+		return
+	}
+
+	h.runBulk(c, len(items), func(ctx context.Context, i int) BulkResult {
+		var ref struct {
+			ID uint `json:"id"`
+		}
+		if err := json.Unmarshal(items[i], &ref); err != nil || ref.ID == 0 {
+			return BulkResult{Status: http.StatusBadRequest, Error: "Each item needs an id"}
+		}
+
+		var req R
+		if err := json.Unmarshal(items[i], &req); err != nil {
+			return BulkResult{Status: http.StatusBadRequest, ID: ref.ID, Error: err.Error()}
+		}
+		if fields := validate(&req); fields != nil {
+			return BulkResult{Status: http.StatusUnprocessableEntity, ID: ref.ID, Error: summarize(fields), Fields: fields}
+		}
+
+		item, err := svc.Get(ctx, ref.ID)
+		if err != nil {
+			return bulkFailure(err, ref.ID)
+		}
+		if err := svc.Update(ctx, item, req, time.Time{}); err != nil {
+			return bulkFailure(err, ref.ID)
+		}
+		return BulkResult{Status: http.StatusOK, ID: ref.ID, Data: item}
+	})
+}
+
+// bulkDelete deletes every T whose id is listed
+func bulkDelete[T any](h *Handler, c *gin.Context, svc bulkService[T]) {
+	var req bulkDeleteRequest
+	if !bindBulk(c, &req, func(r bulkDeleteRequest) int { return len(r.IDs) }) {
+		return
+	}
+
+	h.runBulk(c, len(req.IDs), func(ctx context.Context, i int) BulkResult {
+		id := req.IDs[i]
+		if err := svc.Delete(ctx, id); err != nil {
+			return bulkFailure(err, id)
+		}
+		return BulkResult{Status: http.StatusNoContent, ID: id}
+	})
+}
+
+// bulkFailure reports a service error as the result of one item
+func bulkFailure(err error, id uint) BulkResult {
+	appErr := apperror.From(err)
+	return BulkResult{Status: appErr.Status, ID: id, Error: appErr.Message}
+}
+
+// countItems returns the number of items of an array request body
+func countItems(items []json.RawMessage) int {
+	return len(items)
+}
+
+// bindBulk decodes a bulk request body and enforces the item limit, responding on failure
+func bindBulk[B any](c *gin.Context, body *B, count func(B) int) bool {
+	if err := c.ShouldBindJSON(body); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return false
+	}
+
+	n := count(*body)
+	if n == 0 {
+		c.Error(apperror.BadRequest("No items given"))
+		return false
+	}
+	if n > maxBulkItems {
+		c.Error(apperror.BadRequest(fmt.Sprintf("At most %d items are allowed per request", maxBulkItems)))
+		return false
+	}
+	return true
+}
+
+// runBulk processes items in one transaction, each inside its own savepoint so a failing
+// item is rolled back alone. With ?atomic=true any failure rolls back the whole batch.
+func (h *Handler) runBulk(c *gin.Context, n int, fn func(ctx context.Context, i int) BulkResult) {
+	atomic := c.Query("atomic") == "true"
+	resp := BulkResponse{Results: make([]BulkResult, n)}
+
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < n; i++ {
+			var result BulkResult
+			tx.Transaction(func(sp *gorm.DB) error {
+				result = fn(repository.WithTx(c.Request.Context(), sp), i)
+				if result.Status >= http.StatusBadRequest {
+					return errBulkFailed
+				}
+				return nil
+			})
+
+			result.Index = i
+			resp.Results[i] = result
+			if result.Status >= http.StatusBadRequest {
+				resp.Failed++
+			} else {
+				resp.Succeeded++
+			}
+		}
+
+		if atomic && resp.Failed > 0 {
+			return errBulkFailed
+		}
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, errBulkFailed):
+		// Nothing was kept, so report successful items as rolled back
+		for i := range resp.Results {
+			if resp.Results[i].Status < http.StatusBadRequest {
+				resp.Results[i] = BulkResult{Index: i, Status: http.StatusFailedDependency, ID: resp.Results[i].ID, Error: "Rolled back"}
+			}
+		}
+		resp.Failed, resp.Succeeded = n, 0
+		c.JSON(http.StatusUnprocessableEntity, resp)
+	case err != nil:
+		c.Error(apperror.Internal(err))
+	default:
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// primaryKey returns the ID of a model embedding models.Base
+func primaryKey(item interface{}) uint {
+	id := reflect.Indirect(reflect.ValueOf(item)).FieldByName("ID")
+	if id.IsValid() && id.CanUint() {
+		return uint(id.Uint())
+	}
+	return 0
+}
@@ -0,0 +1,123 @@
+// Package handlers/catalog.go
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// catalogMaxAge is how long clients and proxies may reuse the public catalog
+// and branding before revalidating them
+const catalogMaxAge = 5 * time.Minute
+
+// ListPlans returns the subscription plans with their features
+// @Success 200 []models.SubscriptionPlan
+func (h *Handler) ListPlans(c *gin.Context) {
+	var plans []models.SubscriptionPlan
+	err := h.replica(c).Preload("Features", func(db *gorm.DB) *gorm.DB {
+		return db.Order("features.name")
+	}).Order("price, id").Find(&plans).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	var lastModified time.Time
+	for _, plan := range plans {
+		lastModified = latest(lastModified, plan.UpdatedAt)
+		for _, feature := range plan.Features {
+			lastModified = latest(lastModified, feature.UpdatedAt)
+		}
+	}
+	respondCacheable(c, catalogMaxAge, lastModified, plans)
+}
+
+// GetPlan returns a subscription plan with its features
+// @Success 200 models.SubscriptionPlan
+func (h *Handler) GetPlan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid plan ID"))
+		return
+	}
+
+	var plan models.SubscriptionPlan
+	err = h.replica(c).Preload("Features", func(db *gorm.DB) *gorm.DB {
+		return db.Order("features.name")
+	}).First(&plan, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperror.NotFound("Plan not found"))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	lastModified := plan.UpdatedAt
+	for _, feature := range plan.Features {
+		lastModified = latest(lastModified, feature.UpdatedAt)
+	}
+	respondCacheable(c, catalogMaxAge, lastModified, plan)
+}
+
+// ListFeatures returns the features plans may include
+// @Success 200 []models.Feature
+func (h *Handler) ListFeatures(c *gin.Context) {
+	var features []models.Feature
+	if err := h.replica(c).Order("name, id").Find(&features).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	var lastModified time.Time
+	for _, feature := range features {
+		lastModified = latest(lastModified, feature.UpdatedAt)
+	}
+	respondCacheable(c, catalogMaxAge, lastModified, features)
+}
+
+// GetOrganizationBranding returns the name, logo, favicon and colors of an organization,
+// for sign-in pages shown before the user is authenticated
+// @Success 200 dto.Branding
+func (h *Handler) GetOrganizationBranding(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	var org models.Organization
+	err = h.replica(c).Select("id", "name", "logo_url", "favicon_url", "theme_color", "updated_at").First(&org, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperror.NotFound("Organization not found"))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	respondCacheable(c, catalogMaxAge, org.UpdatedAt, dto.Branding{
+		Name:       org.Name,
+		LogoURL:    org.Settings.LogoURL,
+		FaviconURL: org.Settings.FaviconURL,
+		ThemeColor: org.Settings.ThemeColor,
+	})
+}
+
+// latest returns the later of two times
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
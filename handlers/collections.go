@@ -0,0 +1,139 @@
+// Package handlers/collections.go
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// userList is the collection spec of GET /users
+var userList = listSpec{
+	filters:  map[string]string{"email": "email", "verified": "verified", "locale": "locale"},
+	search:   []string{"email", "name"},
+	sorts:    map[string]bool{"created_at": true, "email": true, "name": true},
+	order:    "id ASC",
+	includes: userIncludes,
+	tags:     models.TaggableUser,
+}
+
+// organizationList is the collection spec of GET /organizations
+var organizationList = listSpec{
+	search:   []string{"name"},
+	sorts:    map[string]bool{"created_at": true, "name": true},
+	order:    "id ASC",
+	includes: organizationIncludes,
+}
+
+// subscriptionList is the collection spec of GET /subscriptions
+var subscriptionList = listSpec{
+	filters: map[string]string{"organization_id": "organization_id", "status": "status", "payment_method": "payment_method"},
+	sorts: map[string]bool{
+		"created_at":        true,
+		"start_date":        true,
+		"end_date":          true,
+		"next_billing_date": true,
+		"status":            true,
+	},
+	order:    "id ASC",
+	includes: subscriptionIncludes,
+}
+
+// reportList is the collection spec of GET /reports
+var reportList = listSpec{
+	filters: map[string]string{"organization_id": "organization_id", "team_id": "team_id", "creator_id": "creator_id", "format": "format"},
+	search:  []string{"name", "description"},
+	sorts:   map[string]bool{"created_at": true, "name": true},
+	order:   "id ASC",
+	tags:    models.TaggableReport,
+}
+
+// workflowList is the collection spec of GET /workflows
+var workflowList = listSpec{
+	filters: map[string]string{"organization_id": "organization_id", "team_id": "team_id", "creator_id": "creator_id", "enabled": "enabled"},
+	search:  []string{"name", "description"},
+	sorts:   map[string]bool{"created_at": true, "name": true, "version": true},
+	order:   "id ASC",
+	tags:    models.TaggableWorkflow,
+}
+
+// tagList is the collection spec of GET /organizations/:id/tags
+var tagList = listSpec{
+	search: []string{"name"},
+	sorts:  map[string]bool{"created_at": true, "name": true},
+	order:  "name ASC, id ASC",
+}
+
+// attachmentList is the collection spec of GET /organizations/:id/attachments
+var attachmentList = listSpec{
+	filters: map[string]string{"scan_status": "scan_status", "attachable_type": "attachable_type", "user_id": "user_id"},
+	search:  []string{"filename"},
+	sorts:   map[string]bool{"created_at": true, "filename": true, "size": true},
+	order:   "id DESC",
+}
+
+// retentionRemovalList is the collection spec of GET /organizations/:id/retention-removals
+var retentionRemovalList = listSpec{
+	filters: map[string]string{"resource": "resource", "action": "action"},
+	sorts:   map[string]bool{"created_at": true, "count": true},
+	order:   "id DESC",
+}
+
+// ListUsers returns a page of users
+// @Query q string Text to search for
+// @Query tag string Only the records carrying the tag, repeatable to require several
+// @Query include string Comma-separated relations to embed
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[models.User]
+func (h *Handler) ListUsers(c *gin.Context) {
+	listPage[models.User](c, h.replica(c).Model(&models.User{}), userList)
+}
+
+// ListOrganizations returns a page of organizations
+// @Query q string Text to search for
+// @Query include string Comma-separated relations to embed
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[models.Organization]
+func (h *Handler) ListOrganizations(c *gin.Context) {
+	listPage[models.Organization](c, h.replica(c).Model(&models.Organization{}), organizationList)
+}
+
+// ListSubscriptions returns a page of subscriptions
+// @Query include string Comma-separated relations to embed
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[models.Subscription]
+func (h *Handler) ListSubscriptions(c *gin.Context) {
+	listPage[models.Subscription](c, h.replica(c).Model(&models.Subscription{}), subscriptionList)
+}
+
+// ListReports returns a page of report definitions, leaving out those of
+// organizations and teams the user isn't a member of
+// @Query q string Text to search for
+// @Query tag string Only the records carrying the tag, repeatable to require several
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[dto.Report]
+func (h *Handler) ListReports(c *gin.Context) {
+	query, err := h.scopeToAccessible(c, h.replica(c).Model(&models.Report{}))
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	listPageAs(c, query, reportList, dto.NewReport)
+}
+
+// ListWorkflows returns a page of workflows, leaving out those of
+// organizations and teams the user isn't a member of
+// @Query q string Text to search for
+// @Query tag string Only the records carrying the tag, repeatable to require several
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[dto.Workflow]
+func (h *Handler) ListWorkflows(c *gin.Context) {
+	query, err := h.scopeToAccessible(c, h.replica(c).Model(&models.Workflow{}))
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	listPageAs(c, query, workflowList, dto.NewWorkflow)
+}
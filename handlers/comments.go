@@ -0,0 +1,208 @@
+// Package handlers/comments.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/comments"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// ListWorkflowComments returns the threads of comments on a workflow, oldest
+// first, each with its replies
+// @Success 200 Page[models.Comment]
+func (h *Handler) ListWorkflowComments(c *gin.Context) {
+	h.listComments(c, models.CommentableWorkflow, "Workflow not found")
+}
+
+// CommentOnWorkflow comments on a workflow, emailing the members mentioned
+// @Body dto.CreateCommentRequest
+// @Success 201 models.Comment
+func (h *Handler) CommentOnWorkflow(c *gin.Context) {
+	h.postComment(c, models.CommentableWorkflow, "Workflow not found")
+}
+
+// ListReportComments returns the threads of comments on a report, oldest
+// first, each with its replies
+// @Success 200 Page[models.Comment]
+func (h *Handler) ListReportComments(c *gin.Context) {
+	h.listComments(c, models.CommentableReport, "Report not found")
+}
+
+// CommentOnReport comments on a report, emailing the members mentioned
+// @Body dto.CreateCommentRequest
+// @Success 201 models.Comment
+func (h *Handler) CommentOnReport(c *gin.Context) {
+	h.postComment(c, models.CommentableReport, "Report not found")
+}
+
+// ListSubscriptionComments returns the threads of comments on a subscription,
+// oldest first, each with its replies
+// @Success 200 Page[models.Comment]
+func (h *Handler) ListSubscriptionComments(c *gin.Context) {
+	h.listComments(c, models.CommentableSubscription, "Subscription not found")
+}
+
+// CommentOnSubscription comments on a subscription, emailing the members
+// mentioned
+// @Body dto.CreateCommentRequest
+// @Success 201 models.Comment
+func (h *Handler) CommentOnSubscription(c *gin.Context) {
+	h.postComment(c, models.CommentableSubscription, "Subscription not found")
+}
+
+// UpdateComment edits a comment, emailing the members it newly mentions. Only
+// its author may edit it.
+// @Body dto.UpdateCommentRequest
+// @Success 200 models.Comment
+func (h *Handler) UpdateComment(c *gin.Context) {
+	comment, ok := h.findComment(c)
+	if !ok {
+		return
+	}
+	if comment.UserID != currentUserID(c) {
+		c.Error(apperror.Forbidden("Only the author may edit the comment"))
+		return
+	}
+
+	var req dto.UpdateCommentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.Comments.Edit(c.Request.Context(), comment, req.Body); err != nil {
+		c.Error(commentError(err))
+		return
+	}
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment deletes a comment, with its replies when it starts a thread.
+// Only its author and admins may delete it.
+// @Success 204
+func (h *Handler) DeleteComment(c *gin.Context) {
+	comment, ok := h.findComment(c)
+	if !ok {
+		return
+	}
+	if comment.UserID != currentUserID(c) {
+		acc, err := h.Access.User(c.Request.Context(), currentUserID(c))
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		if !acc.HasRole(models.AdminRole) {
+			c.Error(apperror.Forbidden("Only the author may delete the comment"))
+			return
+		}
+	}
+
+	if err := h.Comments.Delete(c.Request.Context(), comment); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// listComments writes a page of the threads on the record of a table named in
+// the route, which the user must be an admin or a member of the organization of
+func (h *Handler) listComments(c *gin.Context, commentableType, notFound string) {
+	id, orgID, ok := h.findRecord(c, commentableType, notFound)
+	if !ok || !h.requireMember(c, orgID) {
+		return
+	}
+
+	query := h.replica(c).Model(&models.Comment{}).
+		Where("commentable_type = ? AND commentable_id = ? AND parent_id IS NULL", commentableType, id)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	threads := []models.Comment{}
+	err := query.Preload("Replies", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Order("id").
+		Limit(limit).
+		Offset(offset).
+		Find(&threads).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, Page{Data: threads, Total: total, Limit: limit, Offset: offset})
+}
+
+// postComment comments on the record of a table named in the route, which the
+// user must be an admin or a member of the organization of
+func (h *Handler) postComment(c *gin.Context, commentableType, notFound string) {
+	id, orgID, ok := h.findRecord(c, commentableType, notFound)
+	if !ok || !h.requireMember(c, orgID) {
+		return
+	}
+
+	var req dto.CreateCommentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	comment := models.Comment{
+		OrganizationID:  orgID,
+		CommentableType: commentableType,
+		CommentableID:   id,
+		UserID:          currentUserID(c),
+		Body:            req.Body,
+	}
+	if req.ParentID != nil {
+		var parent models.Comment
+		err := h.db(c).Where("commentable_type = ? AND commentable_id = ?", commentableType, id).First(&parent, *req.ParentID).Error
+		if err != nil {
+			c.Error(apperror.Validation([]FieldError{{Field: "parent_id", Rule: "exists", Message: "must be a comment on the same record"}}))
+			return
+		}
+		// Replies are one level deep, so replies to replies join their thread
+		comment.ParentID = &parent.ID
+		if parent.ParentID != nil {
+			comment.ParentID = parent.ParentID
+		}
+	}
+
+	if err := h.Comments.Post(c.Request.Context(), &comment); err != nil {
+		c.Error(commentError(err))
+		return
+	}
+	c.JSON(http.StatusCreated, comment)
+}
+
+// findComment loads the comment named in the route, writing an error response
+// if missing
+func (h *Handler) findComment(c *gin.Context) (*models.Comment, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid comment ID"))
+		return nil, false
+	}
+
+	var comment models.Comment
+	if err := h.db(c).First(&comment, id).Error; err != nil {
+		c.Error(apperror.NotFound("Comment not found"))
+		return nil, false
+	}
+	return &comment, true
+}
+
+// commentError maps an error posting or editing a comment to a response
+func commentError(err error) error {
+	if errors.Is(err, comments.ErrNotMember) {
+		return apperror.Validation([]FieldError{{Field: "body", Rule: "mentions", Message: "mentions a user who isn't a member of the organization"}})
+	}
+	return apperror.Internal(err)
+}
@@ -0,0 +1,182 @@
+// Package handlers/custom_fields.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// CustomFieldValues are the values of a record's custom fields
+type CustomFieldValues struct {
+	// Values are keyed by field
+	Values map[string]interface{} `json:"values"`
+}
+
+// ListCustomFields returns the custom fields an organization defines. Only its
+// members and admins may see them.
+// @Query entity_type string Only the fields of users or of organizations
+// @Success 200 Page[models.CustomField]
+func (h *Handler) ListCustomFields(c *gin.Context) {
+	orgID, ok := h.organizationMember(c)
+	if !ok {
+		return
+	}
+	entityType := c.Query("entity_type")
+	if entityType != "" && entityType != models.CustomFieldEntityUser && entityType != models.CustomFieldEntityOrganization {
+		c.Error(apperror.BadRequest("Invalid entity_type, expected users or organizations"))
+		return
+	}
+
+	fields, err := h.CustomFields.Fields(c.Request.Context(), orgID, entityType)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, Page{Data: fields, Total: int64(len(fields)), Limit: len(fields)})
+}
+
+// CreateCustomField defines a custom field of an organization
+// @Body dto.CreateCustomFieldRequest
+// @Success 201 models.CustomField
+func (h *Handler) CreateCustomField(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req dto.CreateCustomFieldRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	field := req.Model()
+	field.OrganizationID = org.ID
+	if err := h.CustomFields.Define(c.Request.Context(), &field); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, field)
+}
+
+// UpdateCustomField renames a custom field, changes its options or whether
+// it's required
+// @Body dto.UpdateCustomFieldRequest
+// @Success 200 models.CustomField
+func (h *Handler) UpdateCustomField(c *gin.Context) {
+	field, ok := h.findCustomField(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateCustomFieldRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.Apply(field)
+	if err := h.CustomFields.Update(c.Request.Context(), field); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, field)
+}
+
+// DeleteCustomField deletes a custom field with its values
+// @Success 204
+func (h *Handler) DeleteCustomField(c *gin.Context) {
+	field, ok := h.findCustomField(c)
+	if !ok {
+		return
+	}
+	if err := h.CustomFields.Delete(c.Request.Context(), field); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetOrganizationCustomFields sets the values of an organization's custom
+// fields, returning all of them
+// @Body dto.SetCustomFieldValuesRequest
+// @Success 200 handlers.CustomFieldValues
+func (h *Handler) SetOrganizationCustomFields(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req dto.SetCustomFieldValuesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	values, err := h.CustomFields.Set(c.Request.Context(), org.ID, models.CustomFieldEntityOrganization, org.ID, req.Values)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, CustomFieldValues{Values: values})
+}
+
+// SetUserCustomFields sets the values of the custom fields an organization
+// defines on one of its members, returning all of them
+// @Body dto.SetCustomFieldValuesRequest
+// @Success 200 handlers.CustomFieldValues
+func (h *Handler) SetUserCustomFields(c *gin.Context) {
+	orgID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	userID, ok := h.findMember(c, uint(orgID))
+	if !ok {
+		return
+	}
+
+	var req dto.SetCustomFieldValuesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	values, err := h.CustomFields.Set(c.Request.Context(), uint(orgID), models.CustomFieldEntityUser, userID, req.Values)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, CustomFieldValues{Values: values})
+}
+
+// findCustomField loads the custom field of the organization named in the
+// route, writing an error response if missing
+func (h *Handler) findCustomField(c *gin.Context) (*models.CustomField, bool) {
+	fieldID, err := strconv.Atoi(c.Param("field_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid custom field ID"))
+		return nil, false
+	}
+
+	var field models.CustomField
+	if err := h.db(c).Where("organization_id = ?", c.Param("id")).First(&field, fieldID).Error; err != nil {
+		c.Error(apperror.NotFound("Custom field not found"))
+		return nil, false
+	}
+	return &field, true
+}
@@ -0,0 +1,254 @@
+// Package handlers/directory.go
+package handlers
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+)
+
+// memberSorts lists the columns the member directory can be sorted by
+var memberSorts = map[string]bool{
+	"created_at":     true,
+	"email":          true,
+	"name":           true,
+	"last_active_at": true,
+}
+
+// seatStatuses lists the statuses the member directory can be filtered by
+var seatStatuses = map[models.SeatStatus]bool{
+	models.SeatStatusActive:   true,
+	models.SeatStatusInactive: true,
+	models.SeatStatusInvited:  true,
+}
+
+// OrganizationMember is a user of an organization's member directory: a member
+// or a user holding a seat, such as one invited who didn't join yet
+type OrganizationMember struct {
+	ID          uint       `json:"id"`
+	Email       string     `json:"email"`
+	Name        string     `json:"name"`
+	Verified    bool       `json:"verified"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SuspendedAt *time.Time `json:"suspended_at"`
+	// Member is set once the user joined the organization
+	Member     bool              `json:"member"`
+	SeatID     uint              `json:"seat_id,omitempty"`
+	SeatStatus models.SeatStatus `json:"seat_status,omitempty"`
+	// Roles are the roles of the user's seat
+	Roles []string `json:"roles"`
+	// LastActiveAt is the time of the user's latest activity in the organization
+	LastActiveAt *time.Time `json:"last_active_at"`
+	// Tags are the names of the organization's tags attached to the user
+	Tags []string `json:"tags"`
+	// CustomFields are the values of the fields the organization defines on its members
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+// ListOrganizationUsers returns the member directory of an organization
+// @Query q string Text to search the emails and names for
+// @Query tag string Only the users carrying the organization's tag, repeatable to require several
+// @Query role string Only the users whose seat has the role
+// @Query seat_status string Only the users whose seat is active, inactive or invited
+// @Query verified boolean Only the users who verified their email, or who didn't
+// @Query active_since string Only the users active in the organization since the RFC3339 time
+// @Query inactive_since string Only the users not active in the organization since the RFC3339 time
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[handlers.OrganizationMember]
+func (h *Handler) ListOrganizationUsers(c *gin.Context) {
+	orgID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	members := h.replica(c).Table("user_organizations").Select("user_id").Where("organization_id = ?", orgID)
+	seated := h.replica(c).Model(&models.Seat{}).Select("user_id").Where("organization_id = ?", orgID)
+	query := h.replica(c).Model(&models.User{}).Where("(users.id IN (?) OR users.id IN (?))", members, seated)
+
+	if role := c.Query("role"); role != "" {
+		withRole := h.replica(c).Model(&models.Seat{}).
+			Select("seats.user_id").
+			Joins("JOIN seat_roles ON seat_roles.seat_id = seats.id").
+			Joins("JOIN roles ON roles.id = seat_roles.role_id").
+			Where("seats.organization_id = ? AND roles.name = ?", orgID, role)
+		query = query.Where("users.id IN (?)", withRole)
+	}
+	if status := models.SeatStatus(c.Query("seat_status")); status != "" {
+		if !seatStatuses[status] {
+			c.Error(apperror.BadRequest("Invalid seat_status, expected active, inactive or invited"))
+			return
+		}
+		query = query.Where("users.id IN (?)", h.replica(c).Model(&models.Seat{}).Select("user_id").Where("organization_id = ? AND status = ?", orgID, status))
+	}
+	if verified := c.Query("verified"); verified != "" {
+		v, err := strconv.ParseBool(verified)
+		if err != nil {
+			c.Error(apperror.BadRequest("Invalid verified, expected true or false"))
+			return
+		}
+		query = query.Where("users.verified = ?", v)
+	}
+	for param, exists := range map[string]bool{"active_since": true, "inactive_since": false} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.Error(apperror.BadRequest(fmt.Sprintf("Invalid %s, expected an RFC3339 timestamp", param)))
+			return
+		}
+		active := h.replica(c).Model(&models.ActivityLog{}).
+			Select("1").
+			Where("activity_logs.user_id = users.id AND activity_logs.organization_id = ? AND activity_logs.timestamp >= ?", orgID, since)
+		if exists {
+			query = query.Where("EXISTS (?)", active)
+		} else {
+			query = query.Where("NOT EXISTS (?)", active)
+		}
+	}
+	query = taggedWith(c, query, models.TaggableUser, "users.id")
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(q)) + "%"
+		query = query.Where("(LOWER(users.email) LIKE ? ESCAPE '!' OR LOWER(users.name) LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	lastActive := h.replica(c).Model(&models.ActivityLog{}).
+		Select("MAX(activity_logs.timestamp)").
+		Where("activity_logs.user_id = users.id AND activity_logs.organization_id = ?", orgID)
+	var rows []struct {
+		ID           uint
+		Email        string
+		Name         string
+		Verified     bool
+		CreatedAt    time.Time
+		SuspendedAt  *time.Time
+		LastActiveAt activityTime
+	}
+	err = query.Select("users.id, users.email, users.name, users.verified, users.created_at, users.suspended_at, (?) AS last_active_at", lastActive).
+		Order(parseSort(c, memberSorts, "id ASC")).
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	ids := make([]uint, len(rows))
+	for i, r := range rows {
+		ids[i] = r.ID
+	}
+	var joined []uint
+	err = h.replica(c).Table("user_organizations").Where("organization_id = ? AND user_id IN ?", orgID, ids).Pluck("user_id", &joined).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	var seats []models.Seat
+	if err := h.replica(c).Preload("Roles").Where("organization_id = ? AND user_id IN ?", orgID, ids).Order("id").Find(&seats).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	customFields, err := h.CustomFields.Values(c.Request.Context(), uint(orgID), models.CustomFieldEntityUser, ids)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	tags, err := tagsOf(h.replica(c), uint(orgID), models.TaggableUser, ids)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	seatOf := make(map[uint]models.Seat, len(seats))
+	for _, s := range seats {
+		if _, ok := seatOf[s.UserID]; !ok {
+			seatOf[s.UserID] = s
+		}
+	}
+
+	list := make([]OrganizationMember, len(rows))
+	for i, r := range rows {
+		m := OrganizationMember{
+			ID:           r.ID,
+			Email:        r.Email,
+			Name:         r.Name,
+			Verified:     r.Verified,
+			CreatedAt:    r.CreatedAt,
+			SuspendedAt:  r.SuspendedAt,
+			Member:       containsID(joined, r.ID),
+			Roles:        []string{},
+			Tags:         []string{},
+			LastActiveAt: r.LastActiveAt.Time,
+			CustomFields: customFields[r.ID],
+		}
+		for _, tag := range tags[r.ID] {
+			m.Tags = append(m.Tags, tag.Name)
+		}
+		if m.CustomFields == nil {
+			m.CustomFields = map[string]interface{}{}
+		}
+		if seat, ok := seatOf[r.ID]; ok {
+			m.SeatID, m.SeatStatus = seat.ID, seat.Status
+			for _, role := range seat.Roles {
+				m.Roles = append(m.Roles, role.Name)
+			}
+		}
+		list[i] = m
+	}
+
+	c.JSON(http.StatusOK, Page{Data: list, Total: total, Limit: limit, Offset: offset})
+}
+
+// activityTime scans the time of a latest activity, which SQLite returns as
+// text and is NULL for users never active
+type activityTime struct {
+	Time *time.Time
+}
+
+// Scan implements sql.Scanner
+func (a *activityTime) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		a.Time = nil
+	case time.Time:
+		a.Time = &v
+	case string:
+		for _, layout := range []string{"2006-01-02 15:04:05.999999999-07:00", time.RFC3339Nano, time.DateTime} {
+			if t, err := time.Parse(layout, v); err == nil {
+				a.Time = &t
+				return nil
+			}
+		}
+		return fmt.Errorf("unsupported activity time %q", v)
+	case []byte:
+		return a.Scan(string(v))
+	default:
+		return fmt.Errorf("unsupported activity time %T", src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer, which GORM requires of scanned fields
+func (a activityTime) Value() (driver.Value, error) {
+	if a.Time == nil {
+		return nil, nil
+	}
+	return *a.Time, nil
+}
@@ -0,0 +1,112 @@
+// Package handlers/email.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/accounts"
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// ConfirmEmailChangeResponse tells whether confirming an email change changed
+// the email, or whether the other address has yet to confirm
+type ConfirmEmailChangeResponse struct {
+	// Status is completed once both addresses confirmed, pending before
+	Status   string `json:"status"`
+	NewEmail string `json:"new_email"`
+}
+
+// RequestEmailChange starts changing the signed-in user's email, replacing the
+// change pending. Both addresses are emailed a link to confirm it; the email
+// only changes once both did.
+// @Body dto.ChangeEmailRequest
+// @Success 202 models.EmailChange
+func (h *Handler) RequestEmailChange(c *gin.Context) {
+	var req dto.ChangeEmailRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var user models.User
+	if err := h.db(c).First(&user, c.GetUint("user_id")).Error; err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		c.Error(apperror.Validation([]FieldError{{Field: "password", Rule: "password", Message: "is incorrect"}}))
+		return
+	}
+
+	change, err := h.EmailChanges.Request(c.Request.Context(), &user, req.NewEmail)
+	switch {
+	case errors.Is(err, accounts.ErrEmailUnchanged):
+		c.Error(apperror.Validation([]FieldError{{Field: "new_email", Rule: "nefield", Message: "must differ from the current email"}}))
+	case errors.Is(err, accounts.ErrEmailTaken):
+		c.Error(apperror.Conflict("The email is used by another account"))
+	case err != nil:
+		c.Error(apperror.Internal(err))
+	default:
+		c.JSON(http.StatusAccepted, change)
+	}
+}
+
+// GetEmailChange returns the signed-in user's email change awaiting confirmation
+// @Success 200 models.EmailChange
+func (h *Handler) GetEmailChange(c *gin.Context) {
+	change, err := h.EmailChanges.Pending(c.Request.Context(), c.GetUint("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperror.NotFound("No email change is pending"))
+		return
+	} else if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, change)
+}
+
+// CancelEmailChange drops the signed-in user's email change awaiting confirmation
+// @Success 204
+func (h *Handler) CancelEmailChange(c *gin.Context) {
+	if err := h.EmailChanges.Cancel(c.Request.Context(), c.GetUint("user_id")); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ConfirmEmailChange confirms an email change with the token of the link
+// emailed to the current or the new address
+// @Body dto.ConfirmEmailChangeRequest
+// @Success 200 handlers.ConfirmEmailChangeResponse
+func (h *Handler) ConfirmEmailChange(c *gin.Context) {
+	var req dto.ConfirmEmailChangeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	change, changed, err := h.EmailChanges.Confirm(c.Request.Context(), req.Token)
+	switch {
+	case errors.Is(err, accounts.ErrInvalidToken):
+		c.Error(apperror.BadRequest("The link is invalid or has expired"))
+		return
+	case errors.Is(err, accounts.ErrEmailTaken):
+		c.Error(apperror.Conflict("The email is used by another account"))
+		return
+	case err != nil:
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	resp := ConfirmEmailChangeResponse{Status: "pending", NewEmail: change.NewEmail}
+	if changed {
+		resp.Status = "completed"
+		h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionEmailChange, UserID: change.UserID, Details: models.JSONMap{"email": change.NewEmail}})
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,119 @@
+// Package handlers/etag.go
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/repository"
+)
+
+// etagOf returns the entity tag of a record version, derived from its stored update time
+func etagOf(kind string, base models.Base) string {
+	version := fmt.Sprintf("%s:%d:%d", kind, base.ID, base.UpdatedAt.UnixNano())
+	sum := sha256.Sum256([]byte(version))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// matchesTag reports whether an If-Match or If-None-Match header value lists the tag
+func matchesTag(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithETag writes the record with its ETag, or 304 if the client's copy is current
+func respondWithETag(c *gin.Context, tag string, record interface{}) {
+	c.Header("ETag", tag)
+	if header := c.GetHeader("If-None-Match"); header != "" && matchesTag(header, tag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// respondCacheable writes a public response that clients and proxies may cache for
+// maxAge and then revalidate. Its ETag is derived from the body, so it also changes
+// with related records that don't touch lastModified; 304 is returned if the client's
+// copy is current by If-None-Match or, without it, If-Modified-Since.
+func respondCacheable(c *gin.Context, maxAge time.Duration, lastModified time.Time, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	// The query selects the representation, e.g. through sparse fieldsets
+	sum := sha256.Sum256(append([]byte(c.Request.URL.RawQuery+"\n"), data...))
+	tag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	c.Header("ETag", tag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		if matchesTag(header, tag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	} else if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !lastModified.IsZero() {
+		// HTTP dates have a resolution of a second
+		if !lastModified.Truncate(time.Second).After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// checkIfMatch rejects the request with 412 if it carries an If-Match header that
+// doesn't list the record's current tag
+func checkIfMatch(c *gin.Context, tag string) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || matchesTag(header, tag) {
+		return true
+	}
+	c.Error(apperror.PreconditionFailed("Resource has been modified, fetch it again before updating"))
+	return false
+}
+
+// readAt returns the update time a save must still find when the request carried
+// If-Match, so a concurrent write in between is detected instead of silently
+// overwritten, or zero to save unconditionally
+func readAt(c *gin.Context, updatedAt time.Time) time.Time {
+	if c.GetHeader("If-Match") == "" {
+		return time.Time{}
+	}
+	return updatedAt
+}
+
+// conditionalSave saves the record, conditionally on the If-Match header, and reloads
+// it so its update time, and therefore its ETag, matches what later reads return
+func conditionalSave(c *gin.Context, db *gorm.DB, record interface{}, updatedAt time.Time) error {
+	return repository.SaveConditional(db, record, readAt(c, updatedAt))
+}
+
+// saveFailed responds to a failed save, with 412 for stale writes
+func saveFailed(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrStale) {
+		c.Error(apperror.PreconditionFailed("Resource has been modified, fetch it again before updating"))
+		return
+	}
+	c.Error(apperror.Internal(err))
+}
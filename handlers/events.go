@@ -0,0 +1,82 @@
+// Package handlers/events.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/events"
+)
+
+// streamHeartbeat is how often an idle stream sends a comment, so proxies
+// don't close it
+const streamHeartbeat = 25 * time.Second
+
+// StreamEvents pushes the events meant for the authenticated user as
+// server-sent events until they disconnect, named after their category:
+// notification for the approvals asked of them and the comments mentioning
+// them, workflow for the runs of their organizations and billing for their
+// subscriptions. Reconnecting with the Last-Event-ID header resumes after that
+// event; when the events since can't be replayed, a reset event asks the
+// client to reload what it shows instead.
+// @Query last_event_id string ID of the last event received, for clients that can't send Last-Event-ID
+func (h *Handler) StreamEvents(c *gin.Context) {
+	userID := currentUserID(c)
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	missed, resumed, updates, stop := h.Events.Listen(lastEventID)
+	defer stop()
+
+	// Streams last longer than the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(e events.Streamed) {
+		u, err := h.Access.User(c.Request.Context(), userID)
+		if err != nil || !e.Addressed(userID, u.MemberOf) {
+			return
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Category, data)
+	}
+
+	if !resumed {
+		io.WriteString(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range missed {
+		send(e)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			io.WriteString(c.Writer, ": heartbeat\n\n")
+		case e, ok := <-updates:
+			if !ok {
+				// The client fell behind; it reconnects and resumes
+				return
+			}
+			send(e)
+		}
+		c.Writer.Flush()
+	}
+}
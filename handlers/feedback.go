@@ -0,0 +1,142 @@
+// Package handlers/feedback.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/feedback"
+	"github.com/4cecoder/saas/models"
+)
+
+// feedbackList is the collection spec of GET /feedback
+var feedbackList = listSpec{
+	filters: map[string]string{"kind": "kind", "organization_id": "organization_id", "user_id": "user_id", "category": "category", "score": "score"},
+	search:  []string{"message"},
+	sorts:   map[string]bool{"created_at": true, "score": true},
+	order:   "id DESC",
+}
+
+// SubmitFeedback records feedback or an NPS response of the authenticated
+// user, for the organization of the session unless another one is given
+// @Body dto.SubmitFeedbackRequest
+// @Success 201 models.Feedback
+func (h *Handler) SubmitFeedback(c *gin.Context) {
+	var req dto.SubmitFeedbackRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	switch {
+	case req.Kind == models.FeedbackKindNPS && req.Score == nil:
+		c.Error(apperror.Validation([]FieldError{{Field: "score", Rule: "required", Message: "is required for NPS responses"}}))
+		return
+	case req.Kind == models.FeedbackKindFeedback && req.Message == "":
+		c.Error(apperror.Validation([]FieldError{{Field: "message", Rule: "required", Message: "is required for feedback"}}))
+		return
+	}
+
+	f := req.Model()
+	f.UserID = currentUserID(c)
+	f.OrganizationID = c.GetUint("token_org_id")
+	if req.OrganizationID != nil {
+		acc, err := h.Access.User(c.Request.Context(), f.UserID)
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		if *req.OrganizationID != 0 && !acc.HasRole(models.AdminRole) && !acc.MemberOf(*req.OrganizationID) {
+			c.Error(apperror.Forbidden("You are not a member of the organization"))
+			return
+		}
+		f.OrganizationID = *req.OrganizationID
+	}
+	if ua := c.Request.UserAgent(); len(ua) > 512 {
+		f.UserAgent = ua[:512]
+	} else {
+		f.UserAgent = ua
+	}
+
+	if err := h.Feedback.Submit(c.Request.Context(), &f); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusCreated, f)
+}
+
+// ListFeedback returns a page of the feedback and NPS responses of every user,
+// newest first
+// @Query kind string nps or feedback
+// @Query organization_id integer Organization the feedback was submitted for
+// @Query q string Text to search the messages for
+// @Query from string Submitted at or after this RFC3339 time
+// @Query to string Submitted before this RFC3339 time
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[models.Feedback]
+func (h *Handler) ListFeedback(c *gin.Context) {
+	listPage[models.Feedback](c, h.replica(c).Model(&models.Feedback{}), feedbackList)
+}
+
+// GetNPS sums up the NPS responses, of an organization when given
+// @Query organization_id integer Only the responses submitted for this organization
+// @Query from string Submitted at or after this RFC3339 time
+// @Query to string Submitted before this RFC3339 time
+// @Success 200 feedback.NPSSummary
+func (h *Handler) GetNPS(c *gin.Context) {
+	query, ok := h.npsResponses(c)
+	if !ok {
+		return
+	}
+	if orgID := c.Query("organization_id"); orgID != "" {
+		query = query.Where("organization_id = ?", orgID)
+	}
+
+	summary, err := feedback.NPS(query)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetNPSByOrganization sums up the NPS responses of each organization, those
+// with the most responses first
+// @Query from string Submitted at or after this RFC3339 time
+// @Query to string Submitted before this RFC3339 time
+// @Success 200 Page[feedback.OrganizationNPS]
+func (h *Handler) GetNPSByOrganization(c *gin.Context) {
+	responded, ok := h.npsResponses(c)
+	if !ok {
+		return
+	}
+	responded = responded.Where("kind = ? AND score IS NOT NULL AND organization_id <> 0", models.FeedbackKindNPS).Distinct("organization_id")
+
+	var total int64
+	if err := h.replica(c).Table("(?) AS orgs", responded).Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	query, _ := h.npsResponses(c)
+	limit, offset := parsePagination(c)
+	list, err := feedback.NPSByOrganization(query, limit, offset)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, Page{Data: list, Total: total, Limit: limit, Offset: offset})
+}
+
+// npsResponses returns the query of the feedback submitted in the requested
+// time range, writing an error response if it's invalid
+func (h *Handler) npsResponses(c *gin.Context) (*gorm.DB, bool) {
+	query, err := parseTimeRange(c, h.replica(c).Model(&models.Feedback{}), "created_at")
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid date range, expected RFC3339 timestamps"))
+		return nil, false
+	}
+	return query, true
+}
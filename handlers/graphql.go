@@ -0,0 +1,23 @@
+// Package handlers/graphql.go
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/graphql"
+	"github.com/4cecoder/saas/models"
+)
+
+// graphqlServer is built once and shared by all requests
+var graphqlServer = graphql.NewServer()
+
+// GraphQL executes a GraphQL query, sent as a JSON body or, for GET requests, in the
+// query, operationName and variables parameters
+// @Body graphql.Request
+// @Success 200 graphql.Response
+func (h *Handler) GraphQL(c *gin.Context) {
+	role, _ := auth.VerifyToken(c)
+	ctx := graphql.NewContext(c.Request.Context(), h.DB, h.Access, currentUserID(c), role == models.AdminRole)
+	graphqlServer.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
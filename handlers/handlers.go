@@ -4,33 +4,165 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/4cecoder/saas/access"
+	"github.com/4cecoder/saas/accounts"
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/attachments"
+	"github.com/4cecoder/saas/branding"
+	"github.com/4cecoder/saas/cache"
+	"github.com/4cecoder/saas/comments"
+	"github.com/4cecoder/saas/customfields"
+	"github.com/4cecoder/saas/database"
+	"github.com/4cecoder/saas/devices"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/events"
+	"github.com/4cecoder/saas/exports"
+	"github.com/4cecoder/saas/feedback"
+	"github.com/4cecoder/saas/imports"
+	"github.com/4cecoder/saas/invitations"
 	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/onboarding"
+	"github.com/4cecoder/saas/regions"
+	"github.com/4cecoder/saas/reports"
+	"github.com/4cecoder/saas/repository"
+	"github.com/4cecoder/saas/search"
+	"github.com/4cecoder/saas/service"
+	"github.com/4cecoder/saas/settings"
+	"github.com/4cecoder/saas/support"
+	"github.com/4cecoder/saas/workflow"
 )
 
 // Handler is a struct that holds the database connection
 type Handler struct {
-	DB *gorm.DB
+	DB        *gorm.DB
+	Cache     cache.Cache
+	Access    *access.Access
+	Reports   *reports.Engine
+	Exports   *reports.Exporter
+	Workflows *workflow.Engine
+	Searcher  search.Searcher
+	// Services holding the business logic of the core resources
+	Users         *service.Users
+	Organizations *service.Organizations
+	Subscriptions *service.Subscriptions
+	Seats         *service.Seats
+	// Indexer is set when searches run against an external engine
+	Indexer *search.Indexer
+	// Router serves the sub-requests of batch requests
+	Router http.Handler
+	// Settings holds the runtime settings, such as the feature toggles
+	Settings *settings.Store
+	// Devices alerts users of sign-ins with new devices; nil disables the alerts
+	Devices *devices.Tracker
+	// EmailChanges changes the emails of users once both addresses confirm
+	EmailChanges *accounts.EmailChanges
+	// Suspensions disables the accounts of users without deleting them
+	Suspensions *accounts.Suspensions
+	// Imports imports the users of organizations from CSV files
+	Imports *imports.Importer
+	// Invitations accepts the invitations emailed to users
+	Invitations *invitations.Invitations
+	// Branding stores the logos and favicons of organizations
+	Branding *branding.Assets
+	// Onboarding computes the setup checklists of organizations
+	Onboarding *onboarding.Engine
+	// Feedback records the feedback and NPS responses of users
+	Feedback *feedback.Collector
+	// Support records support tickets and emails their messages
+	Support *support.Desk
+	// Attachments stores the files attached to records
+	Attachments *attachments.Store
+	// OrganizationExports archives all the data of organizations
+	OrganizationExports *exports.Exporter
+	// OrganizationRestores restores those archives into new organizations
+	OrganizationRestores *exports.Restorer
+	// Regions pins new organizations to the deployment's region
+	Regions *regions.Regions
+	// Events streams the notification, workflow and billing events to users
+	Events *events.Stream
+	// CustomFields keeps the fields organizations define on users and themselves
+	CustomFields *customfields.Store
+	// Comments records the comments on workflows, reports and subscriptions
+	Comments *comments.Comments
+	// Draining is set while the application shuts down, failing readiness
+	Draining atomic.Bool
 }
 
 // NewHandler creates a new instance of the Handler struct
 func NewHandler(db *gorm.DB) *Handler {
-	return &Handler{DB: db}
+	h := &Handler{
+		DB:    db,
+		Cache: cache.NewMemory(),
+	}
+	h.Access = access.New(db, h.Cache)
+	h.Regions = regions.New(db, regions.Config{})
+	h.Events = events.NewStream()
+	h.UseStores(repository.NewGormStores(db))
+	return h
+}
+
+// UseStores backs the core resource services with the given repositories
+func (h *Handler) UseStores(stores repository.Stores) {
+	h.Users = service.NewUsers(stores.Users)
+	h.Organizations = service.NewOrganizations(stores.Organizations)
+	h.Subscriptions = service.NewSubscriptions(stores.Subscriptions)
+	h.Seats = service.NewSeats(stores.Seats, stores.Organizations)
+}
+
+// db returns the database handle bound to the request context, so hooks can see the acting user
+func (h *Handler) db(c *gin.Context) *gorm.DB {
+	return h.DB.WithContext(c.Request.Context())
+}
+
+// replica returns the request-bound handle reading from a read replica, for lists
+// and analytics that tolerate replication lag
+func (h *Handler) replica(c *gin.Context) *gorm.DB {
+	return database.Replica(h.db(c))
+}
+
+// currentUserID returns the authenticated user's ID, or zero for anonymous requests
+func currentUserID(c *gin.Context) uint {
+	if userID, ok := c.Get("user_id"); ok {
+		return userID.(uint)
+	}
+	return 0
+}
+
+// selfOrAdmin reports whether the caller is the user with the given ID or an
+// admin, answering 403 otherwise
+func (h *Handler) selfOrAdmin(c *gin.Context, userID uint) bool {
+	if userID == currentUserID(c) {
+		return true
+	}
+	acc, err := h.Access.User(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return false
+	}
+	if !acc.HasRole(models.AdminRole) {
+		c.Error(apperror.Forbidden("You may only change your own account"))
+		return false
+	}
+	return true
 }
 
 // CreateUser creates a new user
+// @Body dto.CreateUserRequest
+// @Success 201 models.User
 func (h *Handler) CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.CreateUserRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := h.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	user, err := h.Users.Create(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -38,147 +170,195 @@ func (h *Handler) CreateUser(c *gin.Context) {
 }
 
 // GetUser retrieves a user by ID
+// @Query include string Comma-separated relations to embed
+// @Success 200 models.User
 func (h *Handler) GetUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apperror.BadRequest("Invalid user ID"))
 		return
 	}
 
-	var user models.User
-	if err := h.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	preload, err := parseIncludes(c, userIncludes)
+	if err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	user, err := h.Users.Get(c.Request.Context(), uint(id), preload...)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondWithETag(c, etagOf("user", user.Base), user)
 }
 
 // UpdateUser updates a user
+// @Body dto.UpdateUserRequest
+// @Success 200 models.User
 func (h *Handler) UpdateUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apperror.BadRequest("Invalid user ID"))
+		return
+	}
+	if !h.selfOrAdmin(c, uint(id)) {
 		return
 	}
 
-	var user models.User
-	if err := h.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	user, err := h.Users.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !checkIfMatch(c, etagOf("user", user.Base)) {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.UpdateUserRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := h.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Users.Update(c.Request.Context(), user, req, readAt(c, user.UpdatedAt)); err != nil {
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", etagOf("user", user.Base))
 	c.JSON(http.StatusOK, user)
 }
 
 // DeleteUser deletes a user
+// @Success 204
 func (h *Handler) DeleteUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apperror.BadRequest("Invalid user ID"))
 		return
 	}
-
-	var user models.User
-	if err := h.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	if !h.selfOrAdmin(c, uint(id)) {
 		return
 	}
 
-	if err := h.DB.Delete(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Users.Delete(c.Request.Context(), uint(id)); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
 
-// CreateOrganization creates a new organization
+// CreateOrganization creates a new organization, kept in this deployment's
+// region. Organizations of another region are created by its deployment, so
+// those requests are answered with 421 and its URL.
+// @Body dto.CreateOrganizationRequest
+// @Success 201 models.Organization
 func (h *Handler) CreateOrganization(c *gin.Context) {
-	var org models.Organization
-	if err := c.ShouldBindJSON(&org); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.CreateOrganizationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	switch {
+	case req.Region == "":
+		req.Region = h.Regions.Region
+	case !h.Regions.Known(req.Region):
+		c.Error(apperror.Validation([]FieldError{{Field: "region", Rule: "region", Message: "isn't a configured region"}}))
+		return
+	case req.Region != h.Regions.Region:
+		c.Error(h.Regions.Misdirected(req.Region))
 		return
 	}
 
-	if err := h.DB.Create(&org).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	org, err := h.Organizations.Create(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, org)
 }
 
-// GetOrganization retrieves an organization by ID
+// GetOrganization retrieves an organization by ID. Only its members and admins
+// may include related records.
+// @Query include string Comma-separated relations to embed
+// @Success 200 models.Organization
 func (h *Handler) GetOrganization(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		c.Error(apperror.BadRequest("Invalid organization ID"))
 		return
 	}
 
-	var org models.Organization
-	if err := h.DB.First(&org, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+	preload, err := parseIncludes(c, organizationIncludes)
+	if err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
 		return
 	}
+	if len(preload) > 0 {
+		if _, ok := c.Get("user_id"); !ok {
+			c.Error(apperror.Unauthorized("Authentication is required to include related records"))
+			return
+		}
+		if !h.requireMember(c, uint(id)) {
+			return
+		}
+	}
 
-	c.JSON(http.StatusOK, org)
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id), preload...)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondWithETag(c, etagOf("organization", org.Base), org)
 }
 
 // UpdateOrganization updates an organization
+// @Body dto.UpdateOrganizationRequest
+// @Success 200 models.Organization
 func (h *Handler) UpdateOrganization(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		c.Error(apperror.BadRequest("Invalid organization ID"))
 		return
 	}
 
-	var org models.Organization
-	if err := h.DB.First(&org, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !checkIfMatch(c, etagOf("organization", org.Base)) {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&org); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.UpdateOrganizationRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := h.DB.Save(&org).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Organizations.Update(c.Request.Context(), org, req, readAt(c, org.UpdatedAt)); err != nil {
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", etagOf("organization", org.Base))
 	c.JSON(http.StatusOK, org)
 }
 
 // DeleteOrganization deletes an organization
+// @Success 204
 func (h *Handler) DeleteOrganization(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		c.Error(apperror.BadRequest("Invalid organization ID"))
 		return
 	}
 
-	var org models.Organization
-	if err := h.DB.First(&org, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
-		return
-	}
-
-	if err := h.DB.Delete(&org).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Organizations.Delete(c.Request.Context(), uint(id)); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -186,15 +366,17 @@ func (h *Handler) DeleteOrganization(c *gin.Context) {
 }
 
 // CreateSubscription creates a new subscription
+// @Body dto.CreateSubscriptionRequest
+// @Success 201 models.Subscription
 func (h *Handler) CreateSubscription(c *gin.Context) {
-	var sub models.Subscription
-	if err := c.ShouldBindJSON(&sub); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.CreateSubscriptionRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := h.DB.Create(&sub).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	sub, err := h.Subscriptions.Create(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -202,65 +384,74 @@ func (h *Handler) CreateSubscription(c *gin.Context) {
 }
 
 // GetSubscription retrieves a subscription by ID
+// @Query include string Comma-separated relations to embed
+// @Success 200 models.Subscription
 func (h *Handler) GetSubscription(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		c.Error(apperror.BadRequest("Invalid subscription ID"))
 		return
 	}
 
-	var sub models.Subscription
-	if err := h.DB.First(&sub, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+	preload, err := parseIncludes(c, subscriptionIncludes)
+	if err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, sub)
+	sub, err := h.Subscriptions.Get(c.Request.Context(), uint(id), preload...)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondWithETag(c, etagOf("subscription", sub.Base), sub)
 }
 
 // UpdateSubscription updates a subscription
+// @Body dto.UpdateSubscriptionRequest
+// @Success 200 models.Subscription
 func (h *Handler) UpdateSubscription(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		c.Error(apperror.BadRequest("Invalid subscription ID"))
 		return
 	}
 
-	var sub models.Subscription
-	if err := h.DB.First(&sub, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+	sub, err := h.Subscriptions.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !checkIfMatch(c, etagOf("subscription", sub.Base)) {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&sub); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.UpdateSubscriptionRequest
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if err := h.DB.Save(&sub).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Subscriptions.Update(c.Request.Context(), sub, req, readAt(c, sub.UpdatedAt)); err != nil {
+		c.Error(err)
 		return
 	}
 
+	c.Header("ETag", etagOf("subscription", sub.Base))
 	c.JSON(http.StatusOK, sub)
 }
 
 // DeleteSubscription deletes a subscription
+// @Success 204
 func (h *Handler) DeleteSubscription(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
-		return
-	}
-
-	var sub models.Subscription
-	if err := h.DB.First(&sub, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		c.Error(apperror.BadRequest("Invalid subscription ID"))
 		return
 	}
 
-	if err := h.DB.Delete(&sub).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Subscriptions.Delete(c.Request.Context(), uint(id)); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -0,0 +1,196 @@
+// Package handlers/health.go
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/cache"
+	"github.com/4cecoder/saas/database"
+	"github.com/4cecoder/saas/migrations"
+	"github.com/4cecoder/saas/models"
+)
+
+// healthTimeout bounds each check of a readiness probe
+const healthTimeout = 2 * time.Second
+
+// startedAt is when the process started, for the uptime of the health details
+var startedAt = time.Now()
+
+// healthCheck is a dependency the service needs to serve requests
+type healthCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// checkResult is the outcome of a health check
+type checkResult struct {
+	Status     string  `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// healthChecks returns the checks of the service's dependencies: the database,
+// its migrations, the job queue kept in it, and Redis when it's the cache
+func (h *Handler) healthChecks() []healthCheck {
+	checks := []healthCheck{
+		{name: "database", run: h.pingDatabase},
+		{name: "migrations", run: h.checkMigrations},
+		{name: "jobs", run: h.checkJobs},
+	}
+	if r, ok := h.Cache.(*cache.Redis); ok {
+		checks = append(checks, healthCheck{name: "cache", run: r.Ping})
+	}
+	return checks
+}
+
+// pingDatabase checks that the primary database answers
+func (h *Handler) pingDatabase(ctx context.Context) error {
+	sqlDB, err := h.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// checkMigrations checks that the schema is up to date
+func (h *Handler) checkMigrations(ctx context.Context) error {
+	migrator, err := migrations.New(h.DB)
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d migrations pending, from version %d", len(pending), pending[0].Version)
+	}
+	return nil
+}
+
+// checkJobs checks that the job queue can be read
+func (h *Handler) checkJobs(ctx context.Context) error {
+	var count int64
+	return h.DB.WithContext(ctx).Model(&models.ScheduledJob{}).Count(&count).Error
+}
+
+// runChecks runs the health checks concurrently, reporting whether all passed
+func (h *Handler) runChecks(ctx context.Context) (map[string]checkResult, bool) {
+	checks := h.healthChecks()
+	results := make(map[string]checkResult, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check healthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.run(ctx)
+			result := checkResult{Status: "ok", DurationMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				result.Status, result.Error = "unavailable", err.Error()
+			}
+			mu.Lock()
+			results[check.name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Error != "" {
+			return results, false
+		}
+	}
+	return results, true
+}
+
+// Health reports that the process is up, for liveness probes. It checks no
+// dependencies, so an outage of one doesn't get every instance restarted.
+func (h *Handler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready reports whether the service can serve requests, for readiness probes
+// and load balancers: the database answers, its migrations are applied, the job
+// queue is readable and Redis, when used, answers. It responds 503 otherwise,
+// naming the failing checks without their errors, and while shutting down.
+func (h *Handler) Ready(c *gin.Context) {
+	if h.Draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+		return
+	}
+
+	results, ok := h.runChecks(c.Request.Context())
+	checks := make(gin.H, len(results))
+	for name, result := range results {
+		checks[name] = result.Status
+	}
+
+	status, code := "ok", http.StatusOK
+	if !ok {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+// HealthDetails reports the readiness checks with their errors and durations,
+// the use of the connection pool, the migrations applied and the state of the
+// runtime, for operators
+func (h *Handler) HealthDetails(c *gin.Context) {
+	ctx := c.Request.Context()
+	results, ok := h.runChecks(ctx)
+	status := "ok"
+	if !ok {
+		status = "unavailable"
+	}
+
+	pool, _ := database.Stats(h.DB)
+	details := gin.H{
+		"status": status,
+		"checks": results,
+		"database": gin.H{
+			"dialect": h.DB.Dialector.Name(),
+			"pool":    pool,
+		},
+	}
+
+	if migrator, err := migrations.New(h.DB); err == nil {
+		if statuses, err := migrator.Status(ctx); err == nil {
+			applied, latest := 0, 0
+			for _, s := range statuses {
+				if s.Applied {
+					applied++
+					latest = max(latest, s.Version)
+				}
+			}
+			details["migrations"] = gin.H{
+				"applied": applied,
+				"pending": len(statuses) - applied,
+				"version": latest,
+			}
+		}
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	details["runtime"] = gin.H{
+		"go_version":     runtime.Version(),
+		"goroutines":     runtime.NumGoroutine(),
+		"heap_alloc":     mem.HeapAlloc,
+		"gc_cycles":      mem.NumGC,
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	}
+
+	c.JSON(http.StatusOK, details)
+}
@@ -0,0 +1,168 @@
+// Package handlers/imports.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/imports"
+	"github.com/4cecoder/saas/invitations"
+	"github.com/4cecoder/saas/models"
+)
+
+// ImportUsers imports an organization's users from the CSV file of the request
+// body, with a header naming the columns email, name and role. The rows are
+// processed in the background; poll the returned import for their outcomes.
+// @Query invite boolean Email the users an invitation instead of making them members at once
+// @Query filename string Name of the file, kept with the import
+// @Success 202 models.UserImport
+func (h *Handler) ImportUsers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	if mediaType, _, _ := strings.Cut(c.ContentType(), ";"); mediaType != "text/csv" {
+		c.Error(apperror.BadRequest("Send the file as text/csv"))
+		return
+	}
+
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	imp, err := h.Imports.Start(c.Request.Context(), org, c.GetUint("user_id"), c.Query("filename"), c.Request.Body, c.Query("invite") == "true")
+	if errors.Is(err, imports.ErrInvalidFile) {
+		c.Error(apperror.Unprocessable(err.Error()))
+		return
+	} else if err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, imp)
+}
+
+// InviteUsers invites up to 500 emails to an organization with a shared role,
+// creating the users who have no account. Emails of members, of pending
+// invitations or repeated are skipped. The invitations are sent in the
+// background; poll the returned import for the outcome of every email.
+// @Body dto.InviteUsersRequest
+// @Success 202 models.UserImport
+func (h *Handler) InviteUsers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	var req dto.InviteUsersRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.UserRole
+	}
+	var roles int64
+	if err := h.db(c).Model(&models.Role{}).Where("name = ?", req.Role).Count(&roles).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	} else if roles == 0 {
+		c.Error(apperror.Validation([]FieldError{{Field: "role", Rule: "exists", Message: "doesn't exist"}}))
+		return
+	}
+
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	imp, err := h.Imports.Invite(c.Request.Context(), org, c.GetUint("user_id"), req.Emails, req.Role)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, imp)
+}
+
+// ListUserImports returns the user imports and bulk invitations of an
+// organization, newest first, without their rows
+// @Success 200 Page[models.UserImport]
+func (h *Handler) ListUserImports(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	query := h.db(c).Model(&models.UserImport{}).Where("organization_id = ?", id)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	var list []models.UserImport
+	err = query.Omit("rows").Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Page{Data: list, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetUserImport returns the progress of a user import and the outcome of its
+// rows processed so far
+// @Success 200 models.UserImport
+func (h *Handler) GetUserImport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	importID, err := strconv.Atoi(c.Param("import_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid import ID"))
+		return
+	}
+
+	var imp models.UserImport
+	if err := h.db(c).Where("organization_id = ?", id).First(&imp, importID).Error; err != nil {
+		c.Error(apperror.NotFound("User import not found"))
+		return
+	}
+	c.JSON(http.StatusOK, imp)
+}
+
+// AcceptInvitation accepts the invitation of the link emailed to a user,
+// activating their seat. Users who have no password yet must choose one.
+// @Body dto.AcceptInvitationRequest
+// @Success 200 models.Invitation
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	var req dto.AcceptInvitationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	inv, err := h.Invitations.Accept(c.Request.Context(), req.Token, req.Password)
+	switch {
+	case errors.Is(err, invitations.ErrInvalidToken):
+		c.Error(apperror.BadRequest("The link is invalid or has expired"))
+	case errors.Is(err, invitations.ErrPasswordRequired):
+		c.Error(apperror.Validation([]FieldError{{Field: "password", Rule: "required", Message: "is required"}}))
+	case err != nil:
+		c.Error(apperror.Internal(err))
+	default:
+		c.JSON(http.StatusOK, inv)
+	}
+}
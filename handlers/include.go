@@ -0,0 +1,73 @@
+// Package handlers/include.go
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxIncludeDepth bounds how many relations deep the include parameter may reach
+const maxIncludeDepth = 2
+
+// userIncludes maps the relations users can include to their preload paths
+var userIncludes = map[string]string{
+	"roles":             "Roles",
+	"roles.permissions": "Roles.Permissions",
+	"organizations":     "Organizations",
+	"permissions":       "Permissions",
+	"custom_fields":     "CustomFields",
+}
+
+// organizationIncludes maps the relations organizations can include to their
+// preload paths. API keys are never included: their keys are credentials.
+var organizationIncludes = map[string]string{
+	"users":                      "Users",
+	"users.roles":                "Users.Roles",
+	"subscriptions":              "Subscriptions",
+	"subscriptions.transactions": "Subscriptions.Transactions",
+	"domains":                    "Domains",
+	"workflows":                  "Workflows",
+	"custom_fields":              "CustomFields",
+}
+
+// subscriptionIncludes maps the relations subscriptions can include to their preload paths
+var subscriptionIncludes = map[string]string{
+	"transactions": "Transactions",
+}
+
+// applyIncludes preloads the relations listed in the include parameter, e.g.
+// ?include=users,subscriptions,domains, rejecting unknown or too deep relations
+func applyIncludes(c *gin.Context, query *gorm.DB, allowed map[string]string) (*gorm.DB, error) {
+	paths, err := parseIncludes(c, allowed)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		query = query.Preload(path)
+	}
+	return query, nil
+}
+
+// parseIncludes returns the preload paths of the relations listed in the include parameter
+func parseIncludes(c *gin.Context, allowed map[string]string) ([]string, error) {
+	var paths []string
+	for _, name := range strings.Split(c.Query("include"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if strings.Count(name, ".")+1 > maxIncludeDepth {
+			return nil, fmt.Errorf("include %q is nested deeper than %d levels", name, maxIncludeDepth)
+		}
+
+		path, ok := allowed[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown include %q", name)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,71 @@
+// Package handlers/me.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/access"
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+)
+
+// MeResponse describes the signed-in user and the organizations they can
+// switch to
+type MeResponse struct {
+	User        models.User `json:"user"`
+	Roles       []string    `json:"roles"`
+	Permissions []string    `json:"permissions"`
+	// OrganizationID is the organization the token is scoped to; zero if none
+	OrganizationID uint `json:"organization_id,omitempty"`
+	// Organizations are those the user belongs to, for POST /auth/switch-org
+	Organizations []MeOrganization `json:"organizations"`
+	// Teams are those the user belongs to, with the permissions of their roles in each
+	Teams []access.Team `json:"teams"`
+}
+
+// MeOrganization is an organization the signed-in user belongs to
+type MeOrganization struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	// Current is set on the organization the token is scoped to
+	Current bool `json:"current"`
+}
+
+// GetMe returns the signed-in user with their access and organizations
+// @Success 200 handlers.MeResponse
+func (h *Handler) GetMe(c *gin.Context) {
+	var user models.User
+	if err := h.db(c).First(&user, c.GetUint("user_id")).Error; err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+	acc, err := h.Access.User(c.Request.Context(), user.ID)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	resp := MeResponse{
+		User:           user,
+		Roles:          acc.Roles,
+		Permissions:    acc.Permissions,
+		OrganizationID: c.GetUint("token_org_id"),
+		Organizations:  []MeOrganization{},
+		Teams:          acc.Teams,
+	}
+	err = h.db(c).Model(&models.Organization{}).
+		Select("id", "name").
+		Where("id IN ?", acc.OrganizationIDs).
+		Order("name").
+		Find(&resp.Organizations).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	for i := range resp.Organizations {
+		resp.Organizations[i].Current = resp.Organizations[i].ID == resp.OrganizationID
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,253 @@
+// Package handlers/metrics.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+)
+
+// metricsTTL is how long computed dashboard metrics are cached
+const metricsTTL = 5 * time.Minute
+
+// buckets lists the supported date bucket sizes
+var buckets = map[string]bool{"day": true, "week": true, "month": true}
+
+// MetricPoint is a single value in a bucketed time series
+type MetricPoint struct {
+	Bucket time.Time `json:"bucket"`
+	Value  int64     `json:"value"`
+}
+
+// SeatMetrics summarizes seat usage within an organization
+type SeatMetrics struct {
+	Total       int64   `json:"total"`
+	Active      int64   `json:"active"`
+	Invited     int64   `json:"invited"`
+	Inactive    int64   `json:"inactive"`
+	Utilization float64 `json:"utilization"`
+}
+
+// ActiveUsersMetric returns the number of distinct active users per bucket
+func (h *Handler) ActiveUsersMetric(c *gin.Context) {
+	h.timeSeries(c, "activity_logs", "timestamp", "COUNT(DISTINCT user_id)", nil)
+}
+
+// APIUsageMetric returns the number of API calls per bucket, optionally for one activity type
+func (h *Handler) APIUsageMetric(c *gin.Context) {
+	h.timeSeries(c, "activity_logs", "timestamp", "COUNT(*)", func(q *gorm.DB) *gorm.DB {
+		if activityType := c.Query("activity_type"); activityType != "" {
+			q = q.Where("activity_type = ?", activityType)
+		}
+		return q
+	})
+}
+
+// WorkflowThroughputMetric returns the number of finished workflow runs per bucket
+func (h *Handler) WorkflowThroughputMetric(c *gin.Context) {
+	h.timeSeries(c, "workflow_runs", "finished_at", "COUNT(*)", func(q *gorm.DB) *gorm.DB {
+		status := c.DefaultQuery("status", string(models.WorkflowRunStatusCompleted))
+		return q.Where("status = ?", status)
+	})
+}
+
+// SeatUtilizationMetric returns seat counts by status and the share of active
+// seats to the organization's members and admins
+func (h *Handler) SeatUtilizationMetric(c *gin.Context) {
+	orgID, ok := h.organizationMember(c)
+	if !ok {
+		return
+	}
+
+	h.cached(c, func() (interface{}, error) {
+		var rows []struct {
+			Status models.SeatStatus
+			Count  int64
+		}
+		err := h.replica(c).Model(&models.Seat{}).
+			Select("status, COUNT(*) AS count").
+			Where("organization_id = ?", orgID).
+			Group("status").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		var m SeatMetrics
+		for _, r := range rows {
+			m.Total += r.Count
+			switch r.Status {
+			case models.SeatStatusActive:
+				m.Active = r.Count
+			case models.SeatStatusInvited:
+				m.Invited = r.Count
+			case models.SeatStatusInactive:
+				m.Inactive = r.Count
+			}
+		}
+		if m.Total > 0 {
+			m.Utilization = float64(m.Active) / float64(m.Total)
+		}
+		return m, nil
+	})
+}
+
+// timeSeries computes an aggregate over an organization's table grouped by date
+// bucket, for its members and admins
+func (h *Handler) timeSeries(c *gin.Context, table, column, aggregate string, filter func(*gorm.DB) *gorm.DB) {
+	orgID, ok := h.organizationMember(c)
+	if !ok {
+		return
+	}
+
+	bucket := c.DefaultQuery("bucket", "day")
+	if !buckets[bucket] {
+		c.Error(apperror.BadRequest("Invalid bucket, expected day, week, or month"))
+		return
+	}
+
+	from, to, err := metricsRange(c)
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid date range, expected RFC3339 timestamps"))
+		return
+	}
+
+	h.cached(c, func() (interface{}, error) {
+		expr, err := bucketExpr(h.DB, bucket, column)
+		if err != nil {
+			return nil, err
+		}
+
+		query := h.replica(c).Table(table).
+			Select(fmt.Sprintf("%s AS bucket, %s AS value", expr, aggregate)).
+			Where("organization_id = ? AND deleted_at IS NULL", orgID).
+			Where(column+" >= ? AND "+column+" < ?", from, to)
+		if filter != nil {
+			query = filter(query)
+		}
+
+		var rows []struct {
+			Bucket bucketTime
+			Value  int64
+		}
+		if err := query.Group("bucket").Order("bucket").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		points := make([]MetricPoint, len(rows))
+		for i, r := range rows {
+			points[i] = MetricPoint{Bucket: time.Time(r.Bucket), Value: r.Value}
+		}
+		return points, nil
+	})
+}
+
+// bucketTime scans a date bucket, which SQLite returns as text
+type bucketTime time.Time
+
+// Scan implements sql.Scanner
+func (b *bucketTime) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*b = bucketTime(v)
+	case string:
+		t, err := time.Parse(time.DateTime, v)
+		if err != nil {
+			return err
+		}
+		*b = bucketTime(t)
+	case []byte:
+		return b.Scan(string(v))
+	default:
+		return fmt.Errorf("unsupported bucket value %T", src)
+	}
+	return nil
+}
+
+// metricsRange reads the from/to parameters, defaulting to the last 30 days
+func metricsRange(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, err
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, err
+		}
+		to = t
+	}
+
+	return from, to, nil
+}
+
+// bucketExpr returns the SQL expression truncating a timestamp column to the bucket size
+func bucketExpr(db *gorm.DB, bucket, column string) (string, error) {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return fmt.Sprintf("date_trunc('%s', %s)", bucket, column), nil
+	case "mysql":
+		switch bucket {
+		case "day":
+			return fmt.Sprintf("CAST(DATE(%s) AS DATETIME)", column), nil
+		case "week":
+			// Weeks start on Monday, as with date_trunc
+			return fmt.Sprintf("CAST(DATE_SUB(DATE(%s), INTERVAL WEEKDAY(%s) DAY) AS DATETIME)", column, column), nil
+		case "month":
+			return fmt.Sprintf("CAST(DATE_FORMAT(%s, '%%Y-%%m-01') AS DATETIME)", column), nil
+		}
+		return "", fmt.Errorf("unknown bucket %q", bucket)
+	case "sqlite":
+		switch bucket {
+		case "day":
+			return fmt.Sprintf("datetime(%s, 'start of day')", column), nil
+		case "week":
+			// Move to the coming Sunday, then back to its Monday
+			return fmt.Sprintf("datetime(%s, 'weekday 0', '-6 days', 'start of day')", column), nil
+		case "month":
+			return fmt.Sprintf("datetime(%s, 'start of month')", column), nil
+		}
+		return "", fmt.Errorf("unknown bucket %q", bucket)
+	default:
+		return "", fmt.Errorf("date bucketing is not supported on %s", db.Dialector.Name())
+	}
+}
+
+// cached serves a JSON result from the cache, computing and storing it on a miss
+func (h *Handler) cached(c *gin.Context, compute func() (interface{}, error)) {
+	key := "metrics:" + c.Request.URL.RequestURI()
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(metricsTTL.Seconds())))
+
+	if data, ok := h.Cache.Get(c.Request.Context(), key); ok {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+		return
+	}
+
+	result, err := compute()
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	h.Cache.Set(c.Request.Context(), key, data, metricsTTL)
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,28 @@
+// Package handlers/onboarding.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+)
+
+// GetOnboarding returns the setup checklist of an organization, each step
+// completed or not by what the organization set up so far. Only its members and
+// admins may see it.
+// @Success 200 onboarding.Checklist
+func (h *Handler) GetOnboarding(c *gin.Context) {
+	id, ok := h.organizationMember(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.Onboarding.Checklist(c.Request.Context(), id)
+	if err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
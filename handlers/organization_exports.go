@@ -0,0 +1,187 @@
+// Package handlers/organization_exports.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/exports"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/storage"
+)
+
+// ExportOrganization starts archiving all the data of an organization: its
+// settings, members, teams, custom fields, tags, workflows, reports and audit
+// logs. The archive is built in the background; poll the returned export for
+// its progress and, once ready, the URL downloading it until it expires.
+// @Success 202 models.OrganizationExport
+func (h *Handler) ExportOrganization(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	export, err := h.OrganizationExports.Start(c.Request.Context(), org, currentUserID(c))
+	if errors.Is(err, exports.ErrInProgress) {
+		c.Error(apperror.Conflict("An export of the organization is already in progress"))
+		return
+	} else if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, export)
+}
+
+// ListOrganizationExports returns the exports of an organization, newest first
+// @Success 200 Page[models.OrganizationExport]
+func (h *Handler) ListOrganizationExports(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	query := h.db(c).Model(&models.OrganizationExport{}).Where("organization_id = ?", id)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	list := []models.OrganizationExport{}
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	for i := range list {
+		h.OrganizationExports.Sign(&list[i])
+	}
+	c.JSON(http.StatusOK, Page{Data: list, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetOrganizationExport returns the progress of an organization export and,
+// once ready, the URL downloading its archive
+// @Success 200 models.OrganizationExport
+func (h *Handler) GetOrganizationExport(c *gin.Context) {
+	exportID, err := strconv.Atoi(c.Param("export_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid export ID"))
+		return
+	}
+
+	var export models.OrganizationExport
+	if err := h.db(c).Where("organization_id = ?", c.Param("id")).First(&export, exportID).Error; err != nil {
+		c.Error(apperror.NotFound("Organization export not found"))
+		return
+	}
+	h.OrganizationExports.Sign(&export)
+	c.JSON(http.StatusOK, export)
+}
+
+// DownloadOrganizationExport streams the archive of an organization export to
+// anyone holding a signed URL that hasn't expired
+// @Query expires integer Unix time the URL expires at
+// @Query signature string Signature of the URL
+func (h *Handler) DownloadOrganizationExport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid export ID"))
+		return
+	}
+
+	export, file, err := h.OrganizationExports.Open(c.Request.Context(), uint(id), c.Query("expires"), c.Query("signature"))
+	switch {
+	case errors.Is(err, exports.ErrInvalidSignature):
+		c.Error(apperror.Forbidden("The download URL is invalid or has expired"))
+		return
+	case errors.Is(err, exports.ErrNotReady):
+		c.Error(apperror.Gone("The export is no longer available"))
+		return
+	case errors.Is(err, storage.ErrNotFound):
+		c.Error(apperror.NotFound("Organization export not found"))
+		return
+	case err != nil:
+		c.Error(apperror.Internal(err))
+		return
+	}
+	defer file.Close()
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Disposition", "attachment; filename="+export.Filename)
+	c.DataFromReader(http.StatusOK, export.Size, "application/zip", file, nil)
+}
+
+// RestoreOrganization restores the archive of an organization export in the
+// request body into a new organization, matching its members to users by
+// email and creating the others. The archive is restored in the background;
+// poll the returned restore for its outcome. Audit logs and subscriptions
+// aren't restored. The organization is kept in this deployment's region, so
+// restoring in another region's deployment moves a tenant there.
+// @Query name string Name of the new organization, the archived one's by default
+// @Query filename string Name of the file, kept with the restore
+// @Success 202 models.OrganizationRestore
+func (h *Handler) RestoreOrganization(c *gin.Context) {
+	if mediaType, _, _ := strings.Cut(c.ContentType(), ";"); mediaType != "application/zip" {
+		c.Error(apperror.BadRequest("Send the archive as application/zip"))
+		return
+	}
+
+	restore, err := h.OrganizationRestores.Restore(c.Request.Context(), c.GetUint("user_id"), c.Query("name"), c.Query("filename"), c.Request.Body)
+	if errors.Is(err, exports.ErrInvalidArchive) {
+		c.Error(apperror.Unprocessable(err.Error()))
+		return
+	} else if err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, restore)
+}
+
+// ListOrganizationRestores returns the organization restores, newest first
+// @Success 200 Page[models.OrganizationRestore]
+func (h *Handler) ListOrganizationRestores(c *gin.Context) {
+	query := h.db(c).Model(&models.OrganizationRestore{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	list := []models.OrganizationRestore{}
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, Page{Data: list, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetOrganizationRestore returns the outcome of an organization restore and,
+// once completed, the organization it created
+// @Success 200 models.OrganizationRestore
+func (h *Handler) GetOrganizationRestore(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid restore ID"))
+		return
+	}
+
+	var restore models.OrganizationRestore
+	if err := h.db(c).First(&restore, id).Error; err != nil {
+		c.Error(apperror.NotFound("Organization restore not found"))
+		return
+	}
+	c.JSON(http.StatusOK, restore)
+}
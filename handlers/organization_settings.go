@@ -0,0 +1,65 @@
+// Package handlers/organization_settings.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+)
+
+// GetOrganizationSettings returns the settings of an organization: its
+// branding, defaults for its users, security policies and log retention
+// @Success 200 models.OrganizationSettings
+func (h *Handler) GetOrganizationSettings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondWithETag(c, etagOf("organization_settings", org.Base), org.Settings)
+}
+
+// UpdateOrganizationSettings changes the given settings of an organization,
+// leaving the others unchanged. Retention and the seat limit aren't changed here.
+// @Body dto.UpdateOrganizationSettingsRequest
+// @Success 200 models.OrganizationSettings
+func (h *Handler) UpdateOrganizationSettings(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !checkIfMatch(c, etagOf("organization_settings", org.Base)) {
+		return
+	}
+
+	var req dto.UpdateOrganizationSettingsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.Organizations.Update(c.Request.Context(), org, &req, readAt(c, org.UpdatedAt)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etagOf("organization_settings", org.Base))
+	c.JSON(http.StatusOK, org.Settings)
+}
@@ -0,0 +1,241 @@
+// Package handlers/pagination.go
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// Page is the envelope returned by list endpoints
+type Page struct {
+	Data   interface{} `json:"data"`
+	Total  int64       `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// parsePagination reads limit and offset query parameters with sane bounds
+func parsePagination(c *gin.Context) (limit, offset int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	offset, err = strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
+
+// parseSort converts a sort parameter like "-timestamp" into an ORDER BY clause,
+// accepting only the whitelisted columns
+func parseSort(c *gin.Context, allowed map[string]bool, fallback string) string {
+	sort := c.Query("sort")
+	if sort == "" {
+		return fallback
+	}
+
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = sort[1:]
+	}
+
+	if !allowed[sort] {
+		return fallback
+	}
+
+	return sort + " " + direction + ", id " + direction
+}
+
+// parseTimeRange applies from/to RFC3339 query parameters to the given column
+func parseTimeRange(c *gin.Context, query *gorm.DB, column string) (*gorm.DB, error) {
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(column+" >= ?", t)
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(column+" < ?", t)
+	}
+
+	return query, nil
+}
+
+// listSpec describes the query parameters a collection endpoint accepts
+type listSpec struct {
+	// filters maps query parameters to the columns they match exactly
+	filters map[string]string
+	// search lists the columns matched case-insensitively by the q parameter
+	search []string
+	// sorts whitelists the columns accepted by the sort parameter
+	sorts map[string]bool
+	// order is used when no valid sort is given
+	order string
+	// includes maps the relations accepted by the include parameter to preload paths
+	includes map[string]string
+	// tags is the taggable type of the records, filtered by the tag parameter
+	tags string
+}
+
+// listPage applies a collection's filters, search, created_at range, sort, and
+// pagination to the query and writes a page of T
+func listPage[T any](c *gin.Context, query *gorm.DB, spec listSpec) {
+	listPageAs(c, query, spec, func(item T) T { return item })
+}
+
+// listPageAs is listPage writing each record of type T as the response body
+// convert returns for it
+func listPageAs[T, R any](c *gin.Context, query *gorm.DB, spec listSpec, convert func(T) R) {
+	for param, column := range spec.filters {
+		if value, ok := c.GetQuery(param); ok {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" && len(spec.search) > 0 {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(q)) + "%"
+		conditions := make([]string, len(spec.search))
+		args := make([]interface{}, len(spec.search))
+		for i, column := range spec.search {
+			conditions[i] = "LOWER(" + column + ") LIKE ? ESCAPE '!'"
+			args[i] = pattern
+		}
+		query = query.Where(strings.Join(conditions, " OR "), args...)
+	}
+
+	if spec.tags != "" {
+		query = taggedWith(c, query, spec.tags, "id")
+	}
+
+	query, err := parseTimeRange(c, query, "created_at")
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid date range, expected RFC3339 timestamps"))
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	query, err = applyIncludes(c, query, spec.includes)
+	if err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	items := []T{}
+	err = query.Order(parseSort(c, spec.sorts, spec.order)).
+		Limit(limit).
+		Offset(offset).
+		Find(&items).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	data := make([]R, len(items))
+	for i, item := range items {
+		data[i] = convert(item)
+	}
+	c.JSON(http.StatusOK, Page{Data: data, Total: total, Limit: limit, Offset: offset})
+}
+
+// likeEscaper escapes LIKE wildcards in user-supplied search terms, using an escape
+// character that needs no quoting in any supported database
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// CursorPage is the envelope returned by list endpoints in cursor mode
+type CursorPage struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Limit      int         `json:"limit"`
+}
+
+// cursor is the position after the last row of a page, ordered by (created_at, id)
+type cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"id"`
+}
+
+// encodeCursor turns a position into an opaque token
+func encodeCursor(cur cursor) string {
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor parses a token produced by encodeCursor
+func decodeCursor(token string) (cursor, error) {
+	var cur cursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cur, err
+	}
+	err = json.Unmarshal(raw, &cur)
+	return cur, err
+}
+
+// cursorRequested reports whether the client asked for cursor pagination by passing
+// a cursor parameter, which is empty for the first page
+func cursorRequested(c *gin.Context) bool {
+	_, ok := c.GetQuery("cursor")
+	return ok
+}
+
+// cursorPage writes a page of T newest first, continuing after the cursor parameter.
+// Seeking on (created_at, id) keeps deep pages as cheap as the first one.
+func cursorPage[T any](c *gin.Context, query *gorm.DB, key func(T) cursor) {
+	if token := c.Query("cursor"); token != "" {
+		cur, err := decodeCursor(token)
+		if err != nil {
+			c.Error(apperror.BadRequest("Invalid cursor"))
+			return
+		}
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", cur.CreatedAt, cur.CreatedAt, cur.ID)
+	}
+
+	limit, _ := parsePagination(c)
+	items := []T{}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&items).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	page := CursorPage{Limit: limit}
+	if len(items) > limit {
+		items = items[:limit]
+		page.NextCursor = encodeCursor(key(items[limit-1]))
+	}
+	page.Data = items
+
+	c.JSON(http.StatusOK, page)
+}
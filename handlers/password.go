@@ -0,0 +1,50 @@
+// Package handlers/password.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// ChangePassword replaces the password of the signed-in user, lifting the
+// restriction of users who must change it, such as the bootstrap administrator
+// @Body dto.ChangePasswordRequest
+// @Success 204
+func (h *Handler) ChangePassword(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		c.Error(apperror.Unauthorized("Unauthorized"))
+		return
+	}
+	var req dto.ChangePasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var user models.User
+	if err := h.db(c).First(&user, userID).Error; err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+	if !user.CheckPassword(req.CurrentPassword) {
+		c.Error(apperror.Validation([]FieldError{{Field: "current_password", Rule: "password", Message: "is incorrect"}}))
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		c.Error(apperror.Validation([]FieldError{{Field: "new_password", Rule: "nefield", Param: "current_password", Message: "must differ from the current password"}}))
+		return
+	}
+
+	user.Password, user.PasswordChangeRequired = req.NewPassword, false
+	if err := h.db(c).Save(&user).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionPasswordChange, UserID: user.ID})
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,318 @@
+// Package handlers/reports.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/reports"
+)
+
+// CreateReport creates a new report definition
+// @Body dto.CreateReportRequest
+// @Success 201 dto.Report
+func (h *Handler) CreateReport(c *gin.Context) {
+	var req dto.CreateReportRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	report := req.Model()
+
+	if err := reports.Validate(report.Definition); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+	if err := reports.ValidateSchedule(report.Schedule); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+	if !h.requireMember(c, report.OrganizationID) || !h.validTeam(c, report.TeamID, report.OrganizationID) {
+		return
+	}
+
+	if userID, ok := c.Get("user_id"); ok {
+		report.CreatorID = userID.(uint)
+	}
+
+	if err := h.db(c).Create(&report).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	if err := reports.SyncSchedule(h.db(c), &report); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewReport(report))
+}
+
+// GetReport retrieves a report by ID
+// @Success 200 dto.Report
+func (h *Handler) GetReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid report ID"))
+		return
+	}
+
+	var report models.Report
+	if err := h.db(c).First(&report, id).Error; err != nil {
+		c.Error(apperror.NotFound("Report not found"))
+		return
+	}
+
+	respondWithETag(c, etagOf("report", report.Base), dto.NewReport(report))
+}
+
+// UpdateReport replaces a report definition
+// @Body dto.UpdateReportRequest
+// @Success 200 dto.Report
+func (h *Handler) UpdateReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid report ID"))
+		return
+	}
+
+	var report models.Report
+	if err := h.db(c).First(&report, id).Error; err != nil {
+		c.Error(apperror.NotFound("Report not found"))
+		return
+	}
+	if !checkIfMatch(c, etagOf("report", report.Base)) {
+		return
+	}
+	readAt := report.UpdatedAt
+
+	var req dto.UpdateReportRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	req.Apply(&report)
+
+	if err := reports.Validate(report.Definition); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+	if err := reports.ValidateSchedule(report.Schedule); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+	if !h.validTeam(c, report.TeamID, report.OrganizationID) {
+		return
+	}
+
+	if err := conditionalSave(c, h.db(c), &report, readAt); err != nil {
+		saveFailed(c, err)
+		return
+	}
+
+	if err := reports.SyncSchedule(h.db(c), &report); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.Header("ETag", etagOf("report", report.Base))
+	c.JSON(http.StatusOK, dto.NewReport(report))
+}
+
+// DeleteReport deletes a report definition
+// @Success 204
+func (h *Handler) DeleteReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid report ID"))
+		return
+	}
+
+	var report models.Report
+	if err := h.db(c).First(&report, id).Error; err != nil {
+		c.Error(apperror.NotFound("Report not found"))
+		return
+	}
+
+	if err := h.db(c).Delete(&report).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	// Stop scheduled deliveries of the deleted report
+	report.Schedule = ""
+	if err := reports.SyncSchedule(h.db(c), &report); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RunReport executes a report and returns the stored run. With a format parameter the
+// results are returned as a file, or exported in the background when they are large.
+func (h *Handler) RunReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid report ID"))
+		return
+	}
+
+	formatName, wantFile := c.GetQuery("format")
+	format, err := reports.ParseFormat(formatName)
+	if err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+
+	var report models.Report
+	if err := h.db(c).First(&report, id).Error; err != nil {
+		c.Error(apperror.NotFound("Report not found"))
+		return
+	}
+
+	var triggeredBy uint
+	if userID, ok := c.Get("user_id"); ok {
+		triggeredBy = userID.(uint)
+	}
+
+	run, err := h.Reports.Run(c.Request.Context(), &report, triggeredBy)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	if !wantFile || run.Status != models.ReportRunStatusSucceeded {
+		c.JSON(http.StatusOK, run)
+		return
+	}
+
+	if run.RowCount > h.Exports.AsyncThreshold {
+		export, err := h.Exports.Start(c.Request.Context(), &report, run, format, triggeredBy)
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		c.JSON(http.StatusAccepted, export)
+		return
+	}
+
+	data, err := reports.Render(&report, run, format)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+reports.Filename(&report, run, format))
+	c.Data(http.StatusOK, format.ContentType(), data)
+}
+
+// GetReportExport returns the status of a background report export
+func (h *Handler) GetReportExport(c *gin.Context) {
+	export, ok := h.findReportExport(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// DownloadReportExport streams a finished report export
+func (h *Handler) DownloadReportExport(c *gin.Context) {
+	export, ok := h.findReportExport(c)
+	if !ok {
+		return
+	}
+
+	if export.Status != models.ReportExportStatusReady {
+		c.Error(apperror.Conflict("Export is not ready").With("status", export.Status))
+		return
+	}
+
+	file, err := h.Exports.Storage.Get(c.Request.Context(), export.StorageKey)
+	if err != nil {
+		c.Error(apperror.NotFound("Export file not found"))
+		return
+	}
+	defer file.Close()
+
+	format := reports.Format(export.Format)
+	c.Header("Content-Disposition", "attachment; filename="+export.Filename)
+	c.DataFromReader(http.StatusOK, int64(export.Size), format.ContentType(), file, nil)
+}
+
+// findReportExport loads the export named in the route, writing an error response if missing
+func (h *Handler) findReportExport(c *gin.Context) (*models.ReportExport, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid report ID"))
+		return nil, false
+	}
+	exportID, err := strconv.Atoi(c.Param("export_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid export ID"))
+		return nil, false
+	}
+
+	var export models.ReportExport
+	if err := h.db(c).Where("report_id = ?", id).First(&export, exportID).Error; err != nil {
+		c.Error(apperror.NotFound("Report export not found"))
+		return nil, false
+	}
+
+	return &export, true
+}
+
+// ListReportRuns returns the run history of a report, newest first, without result rows
+// @Success 200 Page[models.ReportRun]
+func (h *Handler) ListReportRuns(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid report ID"))
+		return
+	}
+
+	query := h.db(c).Model(&models.ReportRun{}).Where("report_id = ?", id)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	var runs []models.ReportRun
+	err = query.Omit("rows").Order("id DESC").Limit(limit).Offset(offset).Find(&runs).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Page{Data: runs, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetReportRun retrieves a single run of a report including its results
+// @Success 200 models.ReportRun
+func (h *Handler) GetReportRun(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid report ID"))
+		return
+	}
+	runID, err := strconv.Atoi(c.Param("run_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid run ID"))
+		return
+	}
+
+	var run models.ReportRun
+	if err := h.db(c).Where("report_id = ?", id).First(&run, runID).Error; err != nil {
+		c.Error(apperror.NotFound("Report run not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,175 @@
+// Package handlers/retention.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/retention"
+)
+
+// UpdateRetention updates how long an organization's audit and activity logs are kept,
+// unless a retention rule on them overrides it. Audit logs are kept forever or
+// for at least retention.MinAuditLogDays.
+// @Body dto.RetentionRequest
+func (h *Handler) UpdateRetention(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	var req dto.RetentionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.AuditLogRetentionDays != nil && *req.AuditLogRetentionDays != 0 &&
+		shortAuditRetention(c, "audit_log_retention_days", *req.AuditLogRetentionDays) {
+		return
+	}
+
+	var org models.Organization
+	if err := h.db(c).First(&org, id).Error; err != nil {
+		c.Error(apperror.NotFound("Organization not found"))
+		return
+	}
+
+	req.Apply(&org)
+	err = h.db(c).Model(&org).
+		Select("audit_log_retention_days", "activity_log_retention_days").
+		Updates(&org).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audit_log_retention_days":    org.Settings.AuditLogRetentionDays,
+		"activity_log_retention_days": org.Settings.ActivityLogRetentionDays,
+	})
+}
+
+// ListRetentionRules returns the retention rules in effect for an
+// organization, including those implied by the retention days of its audit and
+// activity logs, which have no ID
+// @Success 200 []models.RetentionRule
+func (h *Handler) ListRetentionRules(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var rules []models.RetentionRule
+	if err := h.db(c).Where("organization_id = ?", org.ID).Order("resource").Find(&rules).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, retention.Policy(*org, rules))
+}
+
+// PutRetentionRule sets how many days an organization keeps the records of a
+// resource (activity_logs, audit_logs, attachments or report_runs) before the
+// nightly retention job archives or purges them
+// @Body dto.RetentionRuleRequest
+// @Success 200 models.RetentionRule
+func (h *Handler) PutRetentionRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	resource := c.Param("resource")
+	if !slices.Contains(models.RetentionResources, resource) {
+		c.Error(apperror.NotFound("Unknown retention resource"))
+		return
+	}
+	var req dto.RetentionRuleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if resource == models.RetentionAuditLogs && shortAuditRetention(c, "days", req.Days) {
+		return
+	}
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	rule := models.RetentionRule{OrganizationID: org.ID, Resource: resource}
+	if err := h.db(c).Where("organization_id = ? AND resource = ?", org.ID, resource).Limit(1).Find(&rule).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	req.Apply(&rule)
+	if err := h.db(c).Save(&rule).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRetentionRule removes the retention rule of a resource, keeping its
+// records forever, or for the retention days of the organization's settings
+// for audit and activity logs
+// @Success 204
+func (h *Handler) DeleteRetentionRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+
+	result := h.db(c).Unscoped().Where("organization_id = ? AND resource = ?", id, c.Param("resource")).Delete(&models.RetentionRule{})
+	if result.Error != nil {
+		c.Error(apperror.Internal(result.Error))
+		return
+	} else if result.RowsAffected == 0 {
+		c.Error(apperror.NotFound("Retention rule not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListRetentionRemovals returns a page of the records an organization's
+// retention policy removed, newest first
+// @Query resource string Only the removals of activity_logs, audit_logs, attachments or report_runs
+// @Query action string Only the removals that archived or purged the records
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[models.RetentionRemoval]
+func (h *Handler) ListRetentionRemovals(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	listPage[models.RetentionRemoval](c, h.replica(c).Model(&models.RetentionRemoval{}).Where("organization_id = ?", id), retentionRemovalList)
+}
+
+// shortAuditRetention responds with 422 and reports true when days is fewer
+// than the days audit logs must be kept
+func shortAuditRetention(c *gin.Context, field string, days int) bool {
+	if days >= retention.MinAuditLogDays {
+		return false
+	}
+	c.Error(apperror.Validation([]FieldError{{
+		Field:   field,
+		Rule:    "min",
+		Param:   strconv.Itoa(retention.MinAuditLogDays),
+		Message: fmt.Sprintf("must be at least %d for audit logs", retention.MinAuditLogDays),
+	}}))
+	return true
+}
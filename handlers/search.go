@@ -0,0 +1,142 @@
+// Package handlers/search.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/search"
+)
+
+const (
+	minSearchLength    = 2
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// Search returns users and organizations matching the q parameter, best matches first.
+// Non-admins only see their own organizations and the members of those organizations.
+// @Query q string Text to search for, at least 2 characters
+// @Query type string Comma-separated types: user, organization
+// @Query organization_id int Only return results of this organization
+// @Query limit int Maximum number of results, at most 50
+func (h *Handler) Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(text) < minSearchLength {
+		c.Error(apperror.BadRequest("Search query must be at least 2 characters"))
+		return
+	}
+
+	var types []string
+	if t := c.Query("type"); t != "" {
+		types = strings.Split(t, ",")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	orgIDs, err := h.searchScope(c)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	if o := c.Query("organization_id"); o != "" {
+		orgID, err := strconv.Atoi(o)
+		if err != nil {
+			c.Error(apperror.BadRequest("Invalid organization ID"))
+			return
+		}
+		if orgIDs != nil && !containsID(orgIDs, uint(orgID)) {
+			c.Error(apperror.Forbidden("Not a member of this organization"))
+			return
+		}
+		orgIDs = []uint{uint(orgID)}
+	}
+
+	hits, err := h.Searcher.Search(c.Request.Context(), search.Query{
+		Text:            text,
+		Types:           types,
+		OrganizationIDs: orgIDs,
+		Limit:           limit,
+	})
+	if errors.Is(err, search.ErrUnknownType) {
+		c.Error(apperror.BadRequest("Invalid type; expected a list of user, organization"))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": hits})
+}
+
+// ReindexSearch rebuilds the external search index in the background, for the types
+// given in the type parameter or for all of them
+// @Query type string Comma-separated types to rebuild
+// @Success 202
+func (h *Handler) ReindexSearch(c *gin.Context) {
+	if h.Indexer == nil {
+		c.Error(apperror.Conflict("No external search engine is configured"))
+		return
+	}
+
+	var types []string
+	if t := c.Query("type"); t != "" {
+		types = strings.Split(t, ",")
+	}
+	if err := search.ValidateTypes(types); err != nil {
+		c.Error(apperror.BadRequest("Invalid type; expected a list of user, organization"))
+		return
+	}
+
+	go func() {
+		n, err := h.Indexer.Reindex(context.Background(), types...)
+		if err != nil {
+			slog.Error("search: reindex failed", "documents", n, "error", err)
+			return
+		}
+		slog.Info("search: reindexed", "documents", n)
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "started"})
+}
+
+// searchScope returns the organizations the caller may search, or nil for admins
+func (h *Handler) searchScope(c *gin.Context) ([]uint, error) {
+	if role, err := auth.VerifyToken(c); err == nil && role == models.AdminRole {
+		return nil, nil
+	}
+
+	acc, err := h.Access.User(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		return nil, err
+	}
+	return acc.OrganizationIDs, nil
+}
+
+// containsID reports whether ids contains id
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+// Package handlers/sessions.go
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/access"
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/devices"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// dummyPasswordHash is checked against when the email is unknown, so both
+// failures take as long
+const dummyPasswordHash = "$2a$10$DD6SLzUfUA/KPZrzX9lgL.t06CBsWyfJ7d7LjuwWL4B6KxebPaTvi"
+
+// CreateSession signs a browser in with an HttpOnly session cookie; requests
+// changing data must then send the returned CSRF token in the X-CSRF-Token header
+// @Body dto.CreateSessionRequest
+// @Success 200 auth.Session
+func (h *Handler) CreateSession(c *gin.Context) {
+	if !auth.SessionsEnabled() {
+		c.Error(apperror.NotFound("Route not found"))
+		return
+	}
+	var req dto.CreateSessionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var user models.User
+	err := h.db(c).Where("email = ?", req.Email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user.PasswordHash = dummyPasswordHash
+	} else if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if !user.CheckPassword(req.Password) || user.ID == 0 {
+		h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionLoginFailed, UserID: user.ID, Email: req.Email})
+		c.Error(apperror.Unauthorized("Invalid email or password"))
+		return
+	}
+	// Only told once the password proved the account is theirs
+	if user.SuspendedAt != nil {
+		h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionLoginFailed, UserID: user.ID, Email: req.Email, Details: models.JSONMap{"reason": "suspended"}})
+		c.Error(apperror.New(http.StatusForbidden, apperror.CodeAccountSuspended, "Your account is suspended"))
+		return
+	}
+
+	acc, err := h.Access.User(c.Request.Context(), user.ID)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	grant := sessionGrant(acc)
+	if grant.TTL, err = h.sessionLifetime(c, grant.OrganizationID); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	session, err := auth.StartSession(c, &user, grant)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionLogin, UserID: user.ID})
+
+	// Alert the user of sign-ins with new devices, without failing this one
+	if h.Devices != nil {
+		if err := h.Devices.Record(c.Request.Context(), &user, c.Request, c.ClientIP()); err != nil {
+			slog.ErrorContext(c.Request.Context(), "devices: failed to record sign-in", "user_id", user.ID, "error", err)
+		}
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// DenyLogin handles the "this wasn't me" link of a sign-in alert: every token
+// of the user is revoked and their password replaced with the new one
+// @Body dto.DenyLoginRequest
+// @Success 204
+func (h *Handler) DenyLogin(c *gin.Context) {
+	if h.Devices == nil {
+		c.Error(apperror.NotFound("Route not found"))
+		return
+	}
+	var req dto.DenyLoginRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	user, err := h.Devices.Deny(c.Request.Context(), req.Token, req.NewPassword)
+	if errors.Is(err, devices.ErrInvalidToken) {
+		c.Error(apperror.BadRequest("The link is invalid or has expired"))
+		return
+	} else if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionPasswordReset, UserID: user.ID, Details: models.JSONMap{"reason": "login_denied"}})
+	c.Status(http.StatusNoContent)
+}
+
+// sessionGrant returns what the session of a user with acc grants: their role
+// and permissions, scoped to their organization if they belong to only one
+func sessionGrant(acc *access.User) auth.Grant {
+	grant := auth.Grant{Role: models.UserRole, Permissions: acc.Permissions}
+	if acc.HasRole(models.AdminRole) {
+		grant.Role = models.AdminRole
+	}
+	if len(acc.OrganizationIDs) == 1 {
+		grant.OrganizationID = acc.OrganizationIDs[0]
+	}
+	return grant
+}
+
+// sessionLifetime returns the session lifetime of an organization, or zero
+// when it sets none or orgID is zero
+func (h *Handler) sessionLifetime(c *gin.Context, orgID uint) (time.Duration, error) {
+	if orgID == 0 {
+		return 0, nil
+	}
+	var minutes int
+	err := h.db(c).Model(&models.Organization{}).Where("id = ?", orgID).Select("session_lifetime_minutes").Scan(&minutes).Error
+	return time.Duration(minutes) * time.Minute, err
+}
+
+// SwitchOrganizationResponse is the token scoped to the organization switched to
+type SwitchOrganizationResponse struct {
+	OrganizationID uint `json:"organization_id"`
+	// Token is the new bearer token; requests authenticated by a session cookie
+	// get a new cookie instead, and its CSRFToken
+	Token     string    `json:"token,omitempty"`
+	CSRFToken string    `json:"csrf_token,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SwitchOrganization issues the signed-in user a token scoped to one of their
+// organizations, replacing the session cookie if they signed in with one
+// @Body dto.SwitchOrganizationRequest
+// @Success 200 handlers.SwitchOrganizationResponse
+func (h *Handler) SwitchOrganization(c *gin.Context) {
+	var req dto.SwitchOrganizationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	var user models.User
+	if err := h.db(c).First(&user, c.GetUint("user_id")).Error; err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+	acc, err := h.Access.User(ctx, user.ID)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if !acc.MemberOf(req.OrganizationID) {
+		c.Error(apperror.Forbidden("You are not a member of the organization"))
+		return
+	}
+	if _, err := h.Access.Organization(ctx, req.OrganizationID); err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+
+	grant := sessionGrant(acc)
+	grant.OrganizationID = req.OrganizationID
+	if grant.TTL, err = h.sessionLifetime(c, req.OrganizationID); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	resp := SwitchOrganizationResponse{OrganizationID: req.OrganizationID}
+	if c.GetString("session_csrf") != "" {
+		session, err := auth.StartSession(c, &user, grant)
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		resp.CSRFToken, resp.ExpiresAt = session.CSRFToken, *session.ExpiresAt
+	} else {
+		if resp.Token, err = auth.IssueToken(&user, grant); err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		ttl := auth.TTL()
+		if grant.TTL > 0 && grant.TTL < ttl {
+			ttl = grant.TTL
+		}
+		resp.ExpiresAt = time.Now().Add(ttl).Truncate(time.Second)
+	}
+	h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionTokenRefresh, UserID: user.ID, Details: models.JSONMap{"organization_id": req.OrganizationID}})
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteSession signs the browser out, deleting its session cookies
+func (h *Handler) DeleteSession(c *gin.Context) {
+	auth.EndSession(c)
+	if userID := currentUserID(c); userID != 0 {
+		h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionLogout, UserID: userID})
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,116 @@
+// Package handlers/settings.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+)
+
+// ListSettings returns the runtime settings with their current values and defaults
+// @Success 200 []settings.Value
+func (h *Handler) ListSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Settings.List())
+}
+
+// GetSetting returns a runtime setting
+// @Success 200 settings.Value
+func (h *Handler) GetSetting(c *gin.Context) {
+	value, err := h.Settings.Lookup(c.Param("key"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, value)
+}
+
+// UpdateSetting changes a runtime setting on every instance, without a restart
+// @Body dto.UpdateSettingRequest
+// @Success 200 settings.Value
+func (h *Handler) UpdateSetting(c *gin.Context) {
+	var req dto.UpdateSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+
+	value, err := h.Settings.Set(c.Request.Context(), c.Param("key"), *req.Value, currentUserID(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, value)
+}
+
+// ResetSetting restores the default of a runtime setting
+// @Success 200 settings.Value
+func (h *Handler) ResetSetting(c *gin.Context) {
+	value, err := h.Settings.Reset(c.Request.Context(), c.Param("key"), currentUserID(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, value)
+}
+
+// ListSettingChanges returns the recorded changes of a runtime setting, newest first
+// @Query limit integer Maximum number of changes to return
+// @Success 200 []models.RuntimeSettingChange
+func (h *Handler) ListSettingChanges(c *gin.Context) {
+	limit, _ := parsePagination(c)
+	changes, err := h.Settings.History(c.Request.Context(), c.Param("key"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// RequireFeature answers 404 to requests for the endpoints of a feature
+// switched off with its features.<name> setting
+func (h *Handler) RequireFeature(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.Settings != nil && !h.Settings.Bool("features."+name) {
+			c.Error(apperror.NotFound("Route not found"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetMaintenance returns the maintenance mode, for clients to show a notice
+// @Success 200 dto.Maintenance
+func (h *Handler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.Maintenance{
+		Mode:    h.Settings.Get("maintenance.mode"),
+		Message: h.Settings.Get("maintenance.message"),
+	})
+}
+
+// UpdateMaintenance switches maintenance mode on every instance: read_only
+// rejects requests changing data and full rejects every request, but those of
+// admins and health checks
+// @Body dto.Maintenance
+// @Success 200 dto.Maintenance
+func (h *Handler) UpdateMaintenance(c *gin.Context) {
+	var req dto.Maintenance
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+
+	ctx, userID := c.Request.Context(), currentUserID(c)
+	if _, err := h.Settings.Set(ctx, "maintenance.message", req.Message, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	if _, err := h.Settings.Set(ctx, "maintenance.mode", req.Mode, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	h.GetMaintenance(c)
+}
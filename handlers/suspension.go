@@ -0,0 +1,70 @@
+// Package handlers/suspension.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/accounts"
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// SuspendUser suspends a user: their sessions and API keys stop working at
+// once, their seats become inactive and they can't sign in until reactivated.
+// Unlike deleting, nothing of the user is removed.
+// @Body dto.SuspendUserRequest
+// @Success 200 models.User
+func (h *Handler) SuspendUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid user ID"))
+		return
+	}
+	// The reason is optional, and so is the body
+	var req dto.SuspendUserRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+	if uint(id) == c.GetUint("user_id") {
+		c.Error(apperror.Conflict("You can't suspend yourself"))
+		return
+	}
+
+	user, err := h.Suspensions.Suspend(c.Request.Context(), uint(id), req.Reason)
+	if errors.Is(err, accounts.ErrSuspended) {
+		c.Error(apperror.Conflict("The user is already suspended"))
+		return
+	} else if err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+	h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionSuspend, UserID: user.ID, Details: models.JSONMap{"by": c.GetUint("user_id"), "reason": req.Reason}})
+	c.JSON(http.StatusOK, user)
+}
+
+// ReactivateUser lifts the suspension of a user and reactivates the seats it
+// made inactive; they sign in again to get new tokens
+// @Success 200 models.User
+func (h *Handler) ReactivateUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid user ID"))
+		return
+	}
+
+	user, err := h.Suspensions.Reactivate(c.Request.Context(), uint(id))
+	if errors.Is(err, accounts.ErrNotSuspended) {
+		c.Error(apperror.Conflict("The user is not suspended"))
+		return
+	} else if err != nil {
+		c.Error(apperror.From(err))
+		return
+	}
+	h.recordAuth(c, &models.AuthEvent{Action: models.AuthActionReactivate, UserID: user.ID, Details: models.JSONMap{"by": c.GetUint("user_id")}})
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,374 @@
+// Package handlers/tags.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// ListTags returns the tag vocabulary of an organization by name. Only its
+// members and admins may see it.
+// @Query q string Text to search the names for
+// @Success 200 Page[models.Tag]
+func (h *Handler) ListTags(c *gin.Context) {
+	orgID, ok := h.organizationMember(c)
+	if !ok {
+		return
+	}
+	listPage[models.Tag](c, h.replica(c).Model(&models.Tag{}).Where("organization_id = ?", orgID), tagList)
+}
+
+// CreateTag adds a tag to an organization's vocabulary
+// @Body dto.CreateTagRequest
+// @Success 201 models.Tag
+func (h *Handler) CreateTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req dto.CreateTagRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tag := req.Model()
+	tag.OrganizationID = org.ID
+	if !h.uniqueTagName(c, &tag) {
+		return
+	}
+	if err := h.db(c).Create(&tag).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusCreated, tag)
+}
+
+// UpdateTag renames or recolors a tag, wherever it's attached
+// @Body dto.UpdateTagRequest
+// @Success 200 models.Tag
+func (h *Handler) UpdateTag(c *gin.Context) {
+	tag, ok := h.findTag(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateTagRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.Apply(tag)
+	if !h.uniqueTagName(c, tag) {
+		return
+	}
+	if err := h.db(c).Save(tag).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag removes a tag from an organization's vocabulary and from every
+// record it's attached to
+// @Success 204
+func (h *Handler) DeleteTag(c *gin.Context) {
+	tag, ok := h.findTag(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("tag_id = ?", tag.ID).Delete(&models.Tagging{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListWorkflowTags returns the tags of a workflow by name
+// @Success 200 []models.Tag
+func (h *Handler) ListWorkflowTags(c *gin.Context) {
+	id, orgID, ok := h.findRecord(c, models.TaggableWorkflow, "Workflow not found")
+	if !ok {
+		return
+	}
+	h.respondWithTags(c, models.TaggableWorkflow, id, orgID)
+}
+
+// TagWorkflow attaches a tag of its organization to a workflow, returning the
+// workflow's tags
+// @Success 200 []models.Tag
+func (h *Handler) TagWorkflow(c *gin.Context) {
+	id, orgID, ok := h.findRecord(c, models.TaggableWorkflow, "Workflow not found")
+	if !ok {
+		return
+	}
+	h.attachTag(c, models.TaggableWorkflow, id, orgID)
+}
+
+// UntagWorkflow detaches a tag from a workflow
+// @Success 204
+func (h *Handler) UntagWorkflow(c *gin.Context) {
+	id, orgID, ok := h.findRecord(c, models.TaggableWorkflow, "Workflow not found")
+	if !ok {
+		return
+	}
+	h.detachTag(c, models.TaggableWorkflow, id, orgID)
+}
+
+// ListReportTags returns the tags of a report by name
+// @Success 200 []models.Tag
+func (h *Handler) ListReportTags(c *gin.Context) {
+	id, orgID, ok := h.findRecord(c, models.TaggableReport, "Report not found")
+	if !ok {
+		return
+	}
+	h.respondWithTags(c, models.TaggableReport, id, orgID)
+}
+
+// TagReport attaches a tag of its organization to a report, returning the
+// report's tags
+// @Success 200 []models.Tag
+func (h *Handler) TagReport(c *gin.Context) {
+	id, orgID, ok := h.findRecord(c, models.TaggableReport, "Report not found")
+	if !ok {
+		return
+	}
+	h.attachTag(c, models.TaggableReport, id, orgID)
+}
+
+// UntagReport detaches a tag from a report
+// @Success 204
+func (h *Handler) UntagReport(c *gin.Context) {
+	id, orgID, ok := h.findRecord(c, models.TaggableReport, "Report not found")
+	if !ok {
+		return
+	}
+	h.detachTag(c, models.TaggableReport, id, orgID)
+}
+
+// ListUserTags returns the tags an organization attached to one of its
+// members, by name. Only the organization's members and admins may see them.
+// @Success 200 []models.Tag
+func (h *Handler) ListUserTags(c *gin.Context) {
+	orgID, ok := h.organizationMember(c)
+	if !ok {
+		return
+	}
+	userID, ok := h.findMember(c, orgID)
+	if !ok {
+		return
+	}
+	h.respondWithTags(c, models.TaggableUser, userID, orgID)
+}
+
+// TagUser attaches a tag of an organization to one of its members, returning
+// the member's tags
+// @Success 200 []models.Tag
+func (h *Handler) TagUser(c *gin.Context) {
+	orgID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	userID, ok := h.findMember(c, uint(orgID))
+	if !ok {
+		return
+	}
+	h.attachTag(c, models.TaggableUser, userID, uint(orgID))
+}
+
+// UntagUser detaches a tag from a member of an organization
+// @Success 204
+func (h *Handler) UntagUser(c *gin.Context) {
+	orgID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	userID, ok := h.findMember(c, uint(orgID))
+	if !ok {
+		return
+	}
+	h.detachTag(c, models.TaggableUser, userID, uint(orgID))
+}
+
+// taggedWith restricts a query of records of a type to those carrying every
+// tag named by the tag parameter, e.g. ?tag=urgent&tag=billing
+func taggedWith(c *gin.Context, query *gorm.DB, taggableType, column string) *gorm.DB {
+	for _, name := range c.QueryArray("tag") {
+		tagged := query.Session(&gorm.Session{NewDB: true}).Model(&models.Tagging{}).
+			Select("taggings.taggable_id").
+			Joins("JOIN tags ON tags.id = taggings.tag_id AND tags.deleted_at IS NULL").
+			Where("taggings.taggable_type = ? AND tags.name = ?", taggableType, name)
+		query = query.Where(column+" IN (?)", tagged)
+	}
+	return query
+}
+
+// tagsOf returns the tags of an organization attached to records of a type,
+// by record ID then name
+func tagsOf(db *gorm.DB, orgID uint, taggableType string, ids []uint) (map[uint][]models.Tag, error) {
+	var rows []struct {
+		models.Tag
+		TaggableID uint
+	}
+	err := db.Model(&models.Tag{}).
+		Select("tags.*, taggings.taggable_id").
+		Joins("JOIN taggings ON taggings.tag_id = tags.id").
+		Where("tags.organization_id = ? AND taggings.taggable_type = ? AND taggings.taggable_id IN ?", orgID, taggableType, ids).
+		Order("tags.name, tags.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[uint][]models.Tag, len(ids))
+	for _, r := range rows {
+		tags[r.TaggableID] = append(tags[r.TaggableID], r.Tag)
+	}
+	return tags, nil
+}
+
+// respondWithTags writes the tags an organization attached to a record
+func (h *Handler) respondWithTags(c *gin.Context, taggableType string, id, orgID uint) {
+	tags, err := tagsOf(h.db(c), orgID, taggableType, []uint{id})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	list := tags[id]
+	if list == nil {
+		list = []models.Tag{}
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// attachTag attaches the tag named in the route to a record of an
+// organization, then writes the record's tags. Attaching it again does nothing.
+func (h *Handler) attachTag(c *gin.Context, taggableType string, id, orgID uint) {
+	tag, ok := h.findTag(c, strconv.FormatUint(uint64(orgID), 10))
+	if !ok {
+		return
+	}
+	tagging := models.Tagging{OrganizationID: orgID, TagID: tag.ID, TaggableType: taggableType, TaggableID: id}
+	if err := h.db(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&tagging).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	h.respondWithTags(c, taggableType, id, orgID)
+}
+
+// detachTag detaches the tag named in the route from a record of an organization
+func (h *Handler) detachTag(c *gin.Context, taggableType string, id, orgID uint) {
+	result := h.db(c).Unscoped().
+		Where("organization_id = ? AND tag_id = ? AND taggable_type = ? AND taggable_id = ?", orgID, c.Param("tag_id"), taggableType, id).
+		Delete(&models.Tagging{})
+	if result.Error != nil {
+		c.Error(apperror.Internal(result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperror.NotFound("Tag not found"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// findRecord loads the organization of the record of a table named in the
+// route, such as a workflow or report, writing an error response if missing
+func (h *Handler) findRecord(c *gin.Context, taggableType, notFound string) (uint, uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid ID"))
+		return 0, 0, false
+	}
+	var record struct {
+		ID             uint
+		OrganizationID uint
+	}
+	err = h.db(c).Table(taggableType).
+		Select("id", "organization_id").
+		Where("id = ? AND deleted_at IS NULL", id).
+		Take(&record).Error
+	if err != nil {
+		c.Error(apperror.NotFound(notFound))
+		return 0, 0, false
+	}
+	return record.ID, record.OrganizationID, true
+}
+
+// findMember checks that the user named in the route is a member of the
+// organization, writing an error response if not
+func (h *Handler) findMember(c *gin.Context, orgID uint) (uint, bool) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid user ID"))
+		return 0, false
+	}
+	var members int64
+	err = h.db(c).Table("user_organizations").Where("organization_id = ? AND user_id = ?", orgID, userID).Count(&members).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return 0, false
+	}
+	if members == 0 {
+		c.Error(apperror.NotFound("Member not found"))
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// findTag loads the tag named in the route, of the organization orgID,
+// writing an error response if missing
+func (h *Handler) findTag(c *gin.Context, orgID string) (*models.Tag, bool) {
+	tagID, err := strconv.Atoi(c.Param("tag_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid tag ID"))
+		return nil, false
+	}
+
+	var tag models.Tag
+	if err := h.db(c).Where("organization_id = ?", orgID).First(&tag, tagID).Error; err != nil {
+		c.Error(apperror.NotFound("Tag not found"))
+		return nil, false
+	}
+	return &tag, true
+}
+
+// uniqueTagName checks that no other tag of the organization has the name of
+// tag, writing an error response if one does
+func (h *Handler) uniqueTagName(c *gin.Context, tag *models.Tag) bool {
+	var taken int64
+	err := h.db(c).Model(&models.Tag{}).
+		Where("organization_id = ? AND name = ? AND id <> ?", tag.OrganizationID, tag.Name, tag.ID).
+		Count(&taken).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return false
+	}
+	if taken > 0 {
+		c.Error(apperror.Conflict("Another tag of the organization has the name"))
+		return false
+	}
+	return true
+}
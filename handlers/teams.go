@@ -0,0 +1,511 @@
+// Package handlers/teams.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// resourceScope reads the organization and team the resources of a kind
+// belong to
+type resourceScope struct {
+	// param names the route parameter holding the ID of the resource
+	param    string
+	notFound string
+	ownerOf  func(db *gorm.DB, id int) (orgID uint, teamID *uint, err error)
+}
+
+// resourceScopes lists the kinds of resources RequireAccess guards
+var resourceScopes = map[string]resourceScope{
+	"workflow": {param: "id", notFound: "Workflow not found", ownerOf: func(db *gorm.DB, id int) (uint, *uint, error) {
+		var wf models.Workflow
+		err := db.Select("id", "organization_id", "team_id").First(&wf, id).Error
+		return wf.OrganizationID, wf.TeamID, err
+	}},
+	"workflow_run": {param: "run_id", notFound: "Workflow run not found", ownerOf: func(db *gorm.DB, id int) (uint, *uint, error) {
+		var wf models.Workflow
+		err := db.Select("workflows.id", "workflows.organization_id", "workflows.team_id").
+			Joins("JOIN workflow_runs ON workflow_runs.workflow_id = workflows.id").
+			Where("workflow_runs.id = ?", id).
+			Take(&wf).Error
+		return wf.OrganizationID, wf.TeamID, err
+	}},
+	"report": {param: "id", notFound: "Report not found", ownerOf: func(db *gorm.DB, id int) (uint, *uint, error) {
+		var report models.Report
+		err := db.Select("id", "organization_id", "team_id").First(&report, id).Error
+		return report.OrganizationID, report.TeamID, err
+	}},
+}
+
+// RequireAccess hides the resources of kind from those who are neither admins
+// nor members of their organization, and of their team when they're scoped to
+// one, as if they didn't exist. Routes without the resource's ID pass through.
+func (h *Handler) RequireAccess(kind string) gin.HandlerFunc {
+	scope := resourceScopes[kind]
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param(scope.param))
+		if err != nil {
+			c.Next()
+			return
+		}
+		orgID, teamID, err := scope.ownerOf(h.db(c), id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Next()
+			return
+		}
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			c.Abort()
+			return
+		}
+		if ok, err := h.canAccess(c, orgID, teamID); err != nil {
+			c.Error(apperror.Internal(err))
+			c.Abort()
+			return
+		} else if !ok {
+			c.Error(apperror.NotFound(scope.notFound))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// canAccess reports whether the authenticated user may use a resource of the
+// organization scoped to teamID: admins may, and the organization's members
+// when teamID is nil or they're in the team
+func (h *Handler) canAccess(c *gin.Context, orgID uint, teamID *uint) (bool, error) {
+	if scopedElsewhere(c, orgID) {
+		return false, nil
+	}
+	acc, err := h.Access.User(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		return false, err
+	}
+	if acc.HasRole(models.AdminRole) {
+		return true, nil
+	}
+	return acc.MemberOf(orgID) && (teamID == nil || acc.MemberOfTeam(*teamID)), nil
+}
+
+// inTeam reports whether the authenticated user may use a resource scoped to
+// teamID: anyone may when it's nil, otherwise admins and the team's members
+func (h *Handler) inTeam(c *gin.Context, teamID *uint) (bool, error) {
+	if teamID == nil {
+		return true, nil
+	}
+	acc, err := h.Access.User(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		return false, err
+	}
+	return acc.HasRole(models.AdminRole) || acc.MemberOfTeam(*teamID), nil
+}
+
+// scopeToAccessible restricts a query of workflows or reports to those the
+// authenticated user may see: those of their organizations that are unscoped
+// or of their teams
+func (h *Handler) scopeToAccessible(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	acc, err := h.Access.User(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		return nil, err
+	}
+	if acc.HasRole(models.AdminRole) {
+		return query, nil
+	}
+	if len(acc.OrganizationIDs) == 0 {
+		return query.Where("1 = 0"), nil
+	}
+	query = query.Where("organization_id IN ?", acc.OrganizationIDs)
+	teamIDs := make([]uint, len(acc.Teams))
+	for i, t := range acc.Teams {
+		teamIDs[i] = t.ID
+	}
+	if len(teamIDs) == 0 {
+		return query.Where("team_id IS NULL"), nil
+	}
+	return query.Where("(team_id IS NULL OR team_id IN ?)", teamIDs), nil
+}
+
+// validTeam checks that a workflow or report of an organization may be scoped
+// to teamID, writing an error response if not: the team must belong to the
+// organization, and the authenticated user to the team unless they're an admin
+func (h *Handler) validTeam(c *gin.Context, teamID *uint, orgID uint) bool {
+	if teamID == nil {
+		return true
+	}
+	var team models.Team
+	err := h.db(c).Where("organization_id = ?", orgID).First(&team, *teamID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperror.Validation([]FieldError{{Field: "team_id", Rule: "exists", Message: "must be a team of the organization"}}))
+		return false
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return false
+	}
+	if ok, err := h.inTeam(c, teamID); err != nil {
+		c.Error(apperror.Internal(err))
+		return false
+	} else if !ok {
+		c.Error(apperror.Forbidden("You are not a member of the team"))
+		return false
+	}
+	return true
+}
+
+// ListTeams returns the teams of an organization by name. Only its members and
+// admins may see them.
+// @Success 200 Page[models.Team]
+func (h *Handler) ListTeams(c *gin.Context) {
+	orgID, ok := h.organizationMember(c)
+	if !ok {
+		return
+	}
+
+	query := h.replica(c).Model(&models.Team{}).Where("organization_id = ?", orgID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	teams := []models.Team{}
+	if err := query.Order("name, id").Limit(limit).Offset(offset).Find(&teams).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, Page{Data: teams, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetTeam returns a team of an organization with its members and their roles.
+// Only the organization's members and admins may see it.
+// @Success 200 models.Team
+func (h *Handler) GetTeam(c *gin.Context) {
+	if _, ok := h.organizationMember(c); !ok {
+		return
+	}
+	team, ok := h.findTeam(c)
+	if !ok {
+		return
+	}
+	if err := h.db(c).Preload("Roles").Where("team_id = ?", team.ID).Order("id").Find(&team.Members).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, team)
+}
+
+// CreateTeam creates a team in an organization
+// @Body dto.CreateTeamRequest
+// @Success 201 models.Team
+func (h *Handler) CreateTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	org, err := h.Organizations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req dto.CreateTeamRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	team := req.Model()
+	team.OrganizationID = org.ID
+	if !h.uniqueTeamName(c, &team) {
+		return
+	}
+	if err := h.db(c).Create(&team).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusCreated, team)
+}
+
+// UpdateTeam renames a team or changes its description
+// @Body dto.UpdateTeamRequest
+// @Success 200 models.Team
+func (h *Handler) UpdateTeam(c *gin.Context) {
+	team, ok := h.findTeam(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateTeamRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	req.Apply(team)
+	if !h.uniqueTeamName(c, team) {
+		return
+	}
+	if err := h.db(c).Omit("Members").Save(team).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, team)
+}
+
+// DeleteTeam deletes a team and its memberships. Teams still scoping workflows
+// or reports can't be deleted, which would leave those to admins alone.
+// @Success 204
+func (h *Handler) DeleteTeam(c *gin.Context) {
+	team, ok := h.findTeam(c)
+	if !ok {
+		return
+	}
+
+	var workflows, reports int64
+	if err := h.db(c).Model(&models.Workflow{}).Where("team_id = ?", team.ID).Count(&workflows).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if err := h.db(c).Model(&models.Report{}).Where("team_id = ?", team.ID).Count(&reports).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if workflows+reports > 0 {
+		c.Error(apperror.Conflict("Move or delete the workflows and reports of the team first"))
+		return
+	}
+
+	var members []models.TeamMember
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", team.ID).Find(&members).Error; err != nil {
+			return err
+		}
+		for i := range members {
+			if err := deleteTeamMember(tx, &members[i]); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(team).Error
+	})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	for _, m := range members {
+		h.Access.InvalidateUser(c.Request.Context(), m.UserID)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PutTeamMember adds a member of the organization to a team, or changes their
+// roles in it
+// @Body dto.PutTeamMemberRequest
+// @Success 200 models.TeamMember
+func (h *Handler) PutTeamMember(c *gin.Context) {
+	team, ok := h.findTeam(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid user ID"))
+		return
+	}
+
+	var req dto.PutTeamMemberRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var members int64
+	err = h.db(c).Table("user_organizations").Where("organization_id = ? AND user_id = ?", team.OrganizationID, userID).Count(&members).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if members == 0 {
+		c.Error(apperror.Unprocessable("The user isn't a member of the organization"))
+		return
+	}
+
+	roles := []models.Role{}
+	if len(req.Roles) > 0 {
+		if err := h.db(c).Where("name IN ?", req.Roles).Find(&roles).Error; err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		for i, name := range req.Roles {
+			if !hasRoleNamed(roles, name) {
+				c.Error(apperror.Validation([]FieldError{{Field: "roles[" + strconv.Itoa(i) + "]", Rule: "exists", Message: "doesn't exist"}}))
+				return
+			}
+		}
+	}
+
+	member := models.TeamMember{OrganizationID: team.OrganizationID, TeamID: team.ID, UserID: uint(userID)}
+	err = h.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ? AND user_id = ?", team.ID, userID).FirstOrCreate(&member).Error; err != nil {
+			return err
+		}
+		return tx.Model(&member).Omit("Roles.*").Association("Roles").Replace(roles)
+	})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	h.Access.InvalidateUser(c.Request.Context(), member.UserID)
+	c.JSON(http.StatusOK, member)
+}
+
+// DeleteTeamMember removes a member from a team
+// @Success 204
+func (h *Handler) DeleteTeamMember(c *gin.Context) {
+	team, ok := h.findTeam(c)
+	if !ok {
+		return
+	}
+
+	var member models.TeamMember
+	if err := h.db(c).Where("team_id = ? AND user_id = ?", team.ID, c.Param("user_id")).First(&member).Error; err != nil {
+		c.Error(apperror.NotFound("Team member not found"))
+		return
+	}
+	if err := h.db(c).Transaction(func(tx *gorm.DB) error { return deleteTeamMember(tx, &member) }); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	h.Access.InvalidateUser(c.Request.Context(), member.UserID)
+	c.Status(http.StatusNoContent)
+}
+
+// organizationMember checks that the authenticated user is an admin or a member
+// of the organization in the route, returning its ID or writing an error
+// response if not
+func (h *Handler) organizationMember(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return 0, false
+	}
+	return uint(id), h.requireMember(c, uint(id))
+}
+
+// requireMember checks that the authenticated user is an admin or a member of
+// an organization, writing an error response if not
+func (h *Handler) requireMember(c *gin.Context, orgID uint) bool {
+	if scopedElsewhere(c, orgID) {
+		c.Error(apperror.Forbidden("The token is scoped to another organization"))
+		return false
+	}
+	acc, err := h.Access.User(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return false
+	}
+	if !acc.HasRole(models.AdminRole) && !acc.MemberOf(orgID) {
+		c.Error(apperror.Forbidden("You are not a member of the organization"))
+		return false
+	}
+	return true
+}
+
+// scopedElsewhere reports whether the request's token is scoped to an
+// organization other than orgID, which it can't be used for
+func scopedElsewhere(c *gin.Context, orgID uint) bool {
+	scoped := c.GetUint("token_org_id")
+	return scoped != 0 && scoped != orgID
+}
+
+// RequireOrganizationAdmin lets through the admins of the organization in the
+// route: platform admins and the users whose seat in it has the admin role.
+// Others get 403. It runs after auth.IsUserOrAdmin.
+func (h *Handler) RequireOrganizationAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(apperror.BadRequest("Invalid organization ID"))
+			c.Abort()
+			return
+		}
+		if scopedElsewhere(c, uint(id)) {
+			c.Error(apperror.Forbidden("The token is scoped to another organization"))
+			c.Abort()
+			return
+		}
+		acc, err := h.Access.User(c.Request.Context(), c.GetUint("user_id"))
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			c.Abort()
+			return
+		}
+		if !acc.AdministersOrganization(uint(id)) {
+			c.Error(apperror.Forbidden("You are not an admin of the organization"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// findTeam loads the team of the organization named in the route, writing an
+// error response if missing
+func (h *Handler) findTeam(c *gin.Context) (*models.Team, bool) {
+	teamID, err := strconv.Atoi(c.Param("team_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid team ID"))
+		return nil, false
+	}
+
+	var team models.Team
+	if err := h.db(c).Where("organization_id = ?", c.Param("id")).First(&team, teamID).Error; err != nil {
+		c.Error(apperror.NotFound("Team not found"))
+		return nil, false
+	}
+	return &team, true
+}
+
+// uniqueTeamName checks that no other team of the organization has the name of
+// team, writing an error response if one does
+func (h *Handler) uniqueTeamName(c *gin.Context, team *models.Team) bool {
+	var taken int64
+	err := h.db(c).Model(&models.Team{}).
+		Where("organization_id = ? AND name = ? AND id <> ?", team.OrganizationID, team.Name, team.ID).
+		Count(&taken).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return false
+	}
+	if taken > 0 {
+		c.Error(apperror.Conflict("Another team of the organization has the name"))
+		return false
+	}
+	return true
+}
+
+// deleteTeamMember deletes a membership with its roles. Memberships aren't
+// kept once deleted, so the user can be added to the team again.
+func deleteTeamMember(tx *gorm.DB, member *models.TeamMember) error {
+	if err := tx.Model(member).Association("Roles").Clear(); err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(member).Error
+}
+
+// hasRoleNamed reports whether roles contains one called name
+func hasRoleNamed(roles []models.Role, name string) bool {
+	for _, r := range roles {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+// Package handlers/tickets.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/support"
+)
+
+// ticketList is the collection spec of GET /tickets
+var ticketList = listSpec{
+	filters: map[string]string{"status": "status", "organization_id": "organization_id", "user_id": "user_id"},
+	search:  []string{"subject"},
+	sorts:   map[string]bool{"created_at": true, "last_message_at": true},
+	order:   "last_message_at DESC, id DESC",
+}
+
+// organizationTicketList is the collection spec of GET /organizations/:id/tickets
+var organizationTicketList = listSpec{
+	filters: map[string]string{"status": "status", "user_id": "user_id"},
+	search:  []string{"subject"},
+	sorts:   map[string]bool{"created_at": true, "last_message_at": true},
+	order:   "last_message_at DESC, id DESC",
+}
+
+// OpenTicket opens a support ticket for the organization of the session unless
+// another one is given, which the authenticated user must be a member of
+// @Body dto.OpenTicketRequest
+// @Success 201 models.Ticket
+func (h *Handler) OpenTicket(c *gin.Context) {
+	var req dto.OpenTicketRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ticket := models.Ticket{UserID: currentUserID(c), OrganizationID: c.GetUint("token_org_id"), Subject: req.Subject}
+	if req.OrganizationID != nil {
+		ticket.OrganizationID = *req.OrganizationID
+	}
+	if ticket.OrganizationID == 0 {
+		c.Error(apperror.Validation([]FieldError{{Field: "organization_id", Rule: "required", Message: "is required"}}))
+		return
+	}
+	acc, err := h.Access.User(c.Request.Context(), ticket.UserID)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if !acc.HasRole(models.AdminRole) && !acc.MemberOf(ticket.OrganizationID) {
+		c.Error(apperror.Forbidden("You are not a member of the organization"))
+		return
+	}
+
+	if err := h.Support.Open(c.Request.Context(), &ticket, req.Message); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusCreated, ticket)
+}
+
+// ListTickets returns a page of the tickets of every organization, those with
+// the latest messages first
+// @Query status string open, pending, resolved or closed
+// @Query q string Text to search the subjects for
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[models.Ticket]
+func (h *Handler) ListTickets(c *gin.Context) {
+	listPage[models.Ticket](c, h.replica(c).Model(&models.Ticket{}), ticketList)
+}
+
+// ListOrganizationTickets returns a page of the tickets of an organization,
+// those with the latest messages first. Only its members and admins may see them.
+// @Query status string open, pending, resolved or closed
+// @Query q string Text to search the subjects for
+// @Query sort string Column to sort by, prefixed with - for descending order
+// @Success 200 Page[models.Ticket]
+func (h *Handler) ListOrganizationTickets(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid organization ID"))
+		return
+	}
+	acc, err := h.Access.User(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if !acc.HasRole(models.AdminRole) && !acc.MemberOf(uint(id)) {
+		c.Error(apperror.Forbidden("You are not a member of the organization"))
+		return
+	}
+
+	query := h.replica(c).Model(&models.Ticket{}).Where("organization_id = ?", id)
+	listPage[models.Ticket](c, query, organizationTicketList)
+}
+
+// GetTicket returns a ticket with its messages, oldest first
+// @Success 200 models.Ticket
+func (h *Handler) GetTicket(c *gin.Context) {
+	ticket, _, ok := h.findTicket(c)
+	if !ok {
+		return
+	}
+	if err := h.db(c).Where("ticket_id = ?", ticket.ID).Order("id ASC").Find(&ticket.Messages).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, ticket)
+}
+
+// ReplyToTicket posts a message to a ticket, emailing the other side. Replies of
+// admins leave the ticket pending on the user, those of members reopen it.
+// @Body dto.TicketReplyRequest
+// @Success 201 models.TicketMessage
+func (h *Handler) ReplyToTicket(c *gin.Context) {
+	ticket, admin, ok := h.findTicket(c)
+	if !ok {
+		return
+	}
+
+	var req dto.TicketReplyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	msg, err := h.Support.Reply(c.Request.Context(), ticket, currentUserID(c), admin, req.Body)
+	if errors.Is(err, support.ErrTicketClosed) {
+		c.Error(apperror.Conflict("The ticket is closed"))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusCreated, msg)
+}
+
+// UpdateTicketStatus changes the status of a ticket
+// @Body dto.UpdateTicketStatusRequest
+// @Success 200 models.Ticket
+func (h *Handler) UpdateTicketStatus(c *gin.Context) {
+	ticket, _, ok := h.findTicket(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateTicketStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.Support.SetStatus(c.Request.Context(), ticket, req.Status); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, ticket)
+}
+
+// findTicket loads the ticket named in the route and whether the authenticated
+// user is an admin, writing an error response if it's missing or the user is
+// neither an admin nor a member of its organization
+func (h *Handler) findTicket(c *gin.Context) (*models.Ticket, bool, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid ticket ID"))
+		return nil, false, false
+	}
+
+	var ticket models.Ticket
+	if err := h.db(c).First(&ticket, id).Error; err != nil {
+		c.Error(apperror.NotFound("Ticket not found"))
+		return nil, false, false
+	}
+	acc, err := h.Access.User(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return nil, false, false
+	}
+	if !acc.HasRole(models.AdminRole) && !acc.MemberOf(ticket.OrganizationID) {
+		c.Error(apperror.NotFound("Ticket not found"))
+		return nil, false, false
+	}
+	return &ticket, acc.HasRole(models.AdminRole), true
+}
@@ -0,0 +1,84 @@
+// Package handlers/timeline.go
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// TimelineEvent is one entry in a workflow run's history
+type TimelineEvent struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Step     *int      `json:"step,omitempty"`
+	StepName string    `json:"step_name,omitempty"`
+	UserID   uint      `json:"user_id,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// buildTimeline orders the run's start and finish, each step's progress, and approval
+// decisions by time
+func buildTimeline(run *models.WorkflowRun, approvals []models.WorkflowApproval) []TimelineEvent {
+	events := []TimelineEvent{{Time: run.StartedAt, Event: "run.started", UserID: run.TriggeredBy}}
+
+	names := map[int]string{}
+	for _, s := range run.Steps {
+		position := s.Position
+		names[position] = s.Name
+
+		if s.StartedAt != nil {
+			events = append(events, TimelineEvent{
+				Time:     *s.StartedAt,
+				Event:    "step.started",
+				Step:     &position,
+				StepName: s.Name,
+				Detail:   fmt.Sprintf("attempt %d", s.Attempts),
+			})
+		}
+		if s.FinishedAt != nil {
+			events = append(events, TimelineEvent{
+				Time:     *s.FinishedAt,
+				Event:    "step." + string(s.Status),
+				Step:     &position,
+				StepName: s.Name,
+				Error:    s.Error,
+			})
+		}
+	}
+
+	for _, a := range approvals {
+		position := a.Position
+		events = append(events, TimelineEvent{
+			Time:     a.CreatedAt,
+			Event:    "approval.requested",
+			Step:     &position,
+			StepName: names[position],
+			UserID:   a.ApproverID,
+		})
+		if a.DecidedAt != nil {
+			events = append(events, TimelineEvent{
+				Time:     *a.DecidedAt,
+				Event:    "approval." + string(a.Status),
+				Step:     &position,
+				StepName: names[position],
+				UserID:   a.ApproverID,
+				Detail:   a.Comment,
+			})
+		}
+	}
+
+	if run.FinishedAt != nil {
+		events = append(events, TimelineEvent{
+			Time:  *run.FinishedAt,
+			Event: "run." + string(run.Status),
+			Error: run.Error,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
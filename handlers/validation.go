@@ -0,0 +1,133 @@
+// Package handlers/validation.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/4cecoder/saas/apperror"
+)
+
+// FieldError describes why one field of a request body was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func init() {
+	// Report fields by their JSON names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// bindJSON decodes and validates the request body into req. It responds with 400 for
+// malformed JSON, with 413 for bodies over the size limit, and with 422 and the
+// failing fields for invalid values.
+func bindJSON(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.Error(apperror.From(err))
+	} else if fields := fieldErrors(err); fields != nil {
+		c.Error(apperror.Validation(fields))
+	} else {
+		c.Error(apperror.BadRequest(err.Error()))
+	}
+	return false
+}
+
+// validate checks a decoded request against its binding rules
+func validate(req interface{}) []FieldError {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		if fields := fieldErrors(err); fields != nil {
+			return fields
+		}
+		return []FieldError{{Message: err.Error()}}
+	}
+	return nil
+}
+
+// fieldErrors converts validator errors into field errors, or returns nil for other errors
+func fieldErrors(err error) []FieldError {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		// Drop the request struct's name from the namespace, keeping nested paths
+		field := fe.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		fields = append(fields, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return fields
+}
+
+// fieldMessage returns a readable explanation of a failed rule
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "fqdn":
+		return "must be a domain name such as example.com"
+	case "hexcolor":
+		return "must be a hex color such as #1a2b3c"
+	case "timezone":
+		return "must be an IANA time zone such as Europe/Berlin"
+	case "bcp47_language_tag":
+		return "must be a language tag such as en-US"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min", "max":
+		bound := "at least"
+		if fe.Tag() == "max" {
+			bound = "at most"
+		}
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be %s %s characters long", bound, fe.Param())
+		}
+		return fmt.Sprintf("must be %s %s", bound, fe.Param())
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
+
+// summarize joins field errors into one line
+func summarize(fields []FieldError) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = strings.TrimPrefix(f.Field+" "+f.Message, " ")
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,468 @@
+// Package handlers/workflows.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/workflow"
+)
+
+// workflowRunSorts lists the columns workflow runs can be sorted by
+var workflowRunSorts = map[string]bool{
+	"started_at":  true,
+	"finished_at": true,
+	"status":      true,
+}
+
+// validateWorkflow checks a workflow's triggers and that every step has a valid action and condition
+func (h *Handler) validateWorkflow(wf *models.Workflow) error {
+	if err := workflow.ValidateTriggers(wf.Triggers); err != nil {
+		return err
+	}
+	for _, step := range wf.Steps {
+		if err := h.Workflows.ValidateStep(step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateWorkflow creates a new workflow
+// @Body dto.CreateWorkflowRequest
+// @Success 201 dto.Workflow
+func (h *Handler) CreateWorkflow(c *gin.Context) {
+	var req dto.CreateWorkflowRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	wf := req.Model()
+
+	if err := h.validateWorkflow(&wf); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+	if !h.requireMember(c, wf.OrganizationID) || !h.validTeam(c, wf.TeamID, wf.OrganizationID) {
+		return
+	}
+
+	if userID, ok := c.Get("user_id"); ok {
+		wf.CreatorID = userID.(uint)
+	}
+
+	wf.Version = 0
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&wf).Error; err != nil {
+			return err
+		}
+		if err := workflow.SaveVersion(tx, &models.Workflow{}, &wf, wf.CreatorID); err != nil {
+			return err
+		}
+		return tx.Model(&wf).Update("version", wf.Version).Error
+	})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.NewWorkflow(wf))
+}
+
+// GetWorkflow retrieves a workflow by ID
+// @Success 200 dto.Workflow
+func (h *Handler) GetWorkflow(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid workflow ID"))
+		return
+	}
+
+	var wf models.Workflow
+	if err := h.db(c).First(&wf, id).Error; err != nil {
+		c.Error(apperror.NotFound("Workflow not found"))
+		return
+	}
+
+	respondWithETag(c, etagOf("workflow", wf.Base), dto.NewWorkflow(wf))
+}
+
+// UpdateWorkflow replaces a workflow's definition
+// @Body dto.UpdateWorkflowRequest
+// @Success 200 dto.Workflow
+func (h *Handler) UpdateWorkflow(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid workflow ID"))
+		return
+	}
+
+	var wf models.Workflow
+	if err := h.db(c).First(&wf, id).Error; err != nil {
+		c.Error(apperror.NotFound("Workflow not found"))
+		return
+	}
+
+	if !checkIfMatch(c, etagOf("workflow", wf.Base)) {
+		return
+	}
+
+	before := wf
+	var req dto.UpdateWorkflowRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	req.Apply(&wf)
+
+	if err := h.validateWorkflow(&wf); err != nil {
+		c.Error(apperror.BadRequest(err.Error()))
+		return
+	}
+	if !h.validTeam(c, wf.TeamID, wf.OrganizationID) {
+		return
+	}
+
+	// Runs in flight keep the definition they started with, so edits only affect new runs
+	err = h.db(c).Transaction(func(tx *gorm.DB) error {
+		if workflow.DefinitionChanged(&before, &wf) {
+			if err := workflow.SaveVersion(tx, &before, &wf, currentUserID(c)); err != nil {
+				return err
+			}
+		}
+		return conditionalSave(c, tx, &wf, before.UpdatedAt)
+	})
+	if err != nil {
+		saveFailed(c, err)
+		return
+	}
+
+	c.Header("ETag", etagOf("workflow", wf.Base))
+	c.JSON(http.StatusOK, dto.NewWorkflow(wf))
+}
+
+// DeleteWorkflow deletes a workflow
+// @Success 204
+func (h *Handler) DeleteWorkflow(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid workflow ID"))
+		return
+	}
+
+	var wf models.Workflow
+	if err := h.db(c).First(&wf, id).Error; err != nil {
+		c.Error(apperror.NotFound("Workflow not found"))
+		return
+	}
+
+	if err := h.db(c).Delete(&wf).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ListWorkflowVersions returns a workflow's versions, newest first
+func (h *Handler) ListWorkflowVersions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid workflow ID"))
+		return
+	}
+
+	query := h.db(c).Model(&models.WorkflowVersion{}).Where("workflow_id = ?", id)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	var versions []models.WorkflowVersion
+	if err := query.Order("version DESC").Limit(limit).Offset(offset).Find(&versions).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Page{Data: versions, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetWorkflowVersion retrieves one version of a workflow
+// @Success 200 models.WorkflowVersion
+func (h *Handler) GetWorkflowVersion(c *gin.Context) {
+	version, ok := h.loadWorkflowVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// DiffWorkflowVersions compares a version with the one given by the from query
+// parameter, or with the version before it
+// @Query from int Version to compare against, the previous one by default
+// @Success 200 workflow.VersionDiff
+func (h *Handler) DiffWorkflowVersions(c *gin.Context) {
+	to, ok := h.loadWorkflowVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	from := c.Query("from")
+	if from == "" {
+		from = strconv.Itoa(to.Version - 1)
+	}
+	previous, ok := h.loadWorkflowVersion(c, from)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow.Diff(previous, to))
+}
+
+// RollbackWorkflow restores an earlier version's definition as the workflow's newest version
+// @Success 200 dto.Workflow
+func (h *Handler) RollbackWorkflow(c *gin.Context) {
+	version, ok := h.loadWorkflowVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	var wf models.Workflow
+	if err := h.db(c).First(&wf, version.WorkflowID).Error; err != nil {
+		c.Error(apperror.NotFound("Workflow not found"))
+		return
+	}
+	if wf.Version == version.Version {
+		c.Error(apperror.Conflict("Version is already the current version"))
+		return
+	}
+
+	restored := wf
+	restored.Steps = version.Steps
+	restored.Triggers = version.Triggers
+	if err := h.validateWorkflow(&restored); err != nil {
+		c.Error(apperror.Unprocessable("Version can no longer be restored: " + err.Error()))
+		return
+	}
+
+	err := h.db(c).Transaction(func(tx *gorm.DB) error {
+		if err := workflow.Rollback(tx, &wf, version, currentUserID(c)); err != nil {
+			return err
+		}
+		return tx.Save(&wf).Error
+	})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewWorkflow(wf))
+}
+
+// loadWorkflowVersion fetches a version of the workflow in the route, responding on failure
+func (h *Handler) loadWorkflowVersion(c *gin.Context, number string) (*models.WorkflowVersion, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid workflow ID"))
+		return nil, false
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid version"))
+		return nil, false
+	}
+
+	var version models.WorkflowVersion
+	if err := h.db(c).Where("workflow_id = ? AND version = ?", id, n).First(&version).Error; err != nil {
+		c.Error(apperror.NotFound("Workflow version not found"))
+		return nil, false
+	}
+	return &version, true
+}
+
+// StartWorkflowRun starts a new run of a workflow with the request body as input
+func (h *Handler) StartWorkflowRun(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid workflow ID"))
+		return
+	}
+
+	var wf models.Workflow
+	if err := h.db(c).First(&wf, id).Error; err != nil {
+		c.Error(apperror.NotFound("Workflow not found"))
+		return
+	}
+
+	input := models.JSONMap{}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.Error(apperror.BadRequest(err.Error()))
+			return
+		}
+	}
+
+	var triggeredBy uint
+	if userID, ok := c.Get("user_id"); ok {
+		triggeredBy = userID.(uint)
+	}
+
+	run, err := h.Workflows.Start(c.Request.Context(), &wf, input, triggeredBy)
+	if errors.Is(err, workflow.ErrWorkflowDisabled) {
+		c.Error(apperror.Conflict(err.Error()))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// GetWorkflowRun retrieves a workflow run with its step statuses
+// @Success 200 models.WorkflowRun
+func (h *Handler) GetWorkflowRun(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("run_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid run ID"))
+		return
+	}
+
+	var run models.WorkflowRun
+	if err := h.db(c).Preload("Steps", orderByPosition).First(&run, id).Error; err != nil {
+		c.Error(apperror.NotFound("Workflow run not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// ListWorkflowRuns returns a workflow's runs, optionally filtered by status and start time
+func (h *Handler) ListWorkflowRuns(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid workflow ID"))
+		return
+	}
+
+	query := h.db(c).Model(&models.WorkflowRun{}).Where("workflow_id = ?", id)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query, err = parseTimeRange(c, query, "started_at")
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid date range, expected RFC3339 timestamps"))
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	var runs []models.WorkflowRun
+	err = query.Order(parseSort(c, workflowRunSorts, "started_at DESC, id DESC")).
+		Limit(limit).
+		Offset(offset).
+		Find(&runs).Error
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Page{Data: runs, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetWorkflowRunTimeline returns a run's history as a chronological list of events
+func (h *Handler) GetWorkflowRunTimeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("run_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid run ID"))
+		return
+	}
+
+	var run models.WorkflowRun
+	if err := h.db(c).Preload("Steps", orderByPosition).First(&run, id).Error; err != nil {
+		c.Error(apperror.NotFound("Workflow run not found"))
+		return
+	}
+
+	var approvals []models.WorkflowApproval
+	if err := h.db(c).Where("run_id = ?", run.ID).Find(&approvals).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"run": run, "timeline": buildTimeline(&run, approvals)})
+}
+
+// CancelWorkflowRun cancels a running or waiting workflow run
+func (h *Handler) CancelWorkflowRun(c *gin.Context) {
+	h.transitionRun(c, h.Workflows.Cancel)
+}
+
+// RetryWorkflowRun retries a failed workflow run from its failed step, or from an
+// earlier step given by the from_step query parameter
+func (h *Handler) RetryWorkflowRun(c *gin.Context) {
+	from := c.Query("from_step")
+	if from == "" {
+		h.transitionRun(c, h.Workflows.Retry)
+		return
+	}
+
+	position, err := strconv.Atoi(from)
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid from_step"))
+		return
+	}
+	h.transitionRun(c, func(ctx context.Context, runID uint) error {
+		return h.Workflows.RetryFrom(ctx, runID, position)
+	})
+}
+
+// transitionRun applies a run state change and responds with the updated run
+func (h *Handler) transitionRun(c *gin.Context, transition func(ctx context.Context, runID uint) error) {
+	id, err := strconv.Atoi(c.Param("run_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid run ID"))
+		return
+	}
+
+	var run models.WorkflowRun
+	if err := h.db(c).First(&run, id).Error; err != nil {
+		c.Error(apperror.NotFound("Workflow run not found"))
+		return
+	}
+
+	err = transition(c.Request.Context(), run.ID)
+	if errors.Is(err, workflow.ErrNotCancelable) || errors.Is(err, workflow.ErrNotRetryable) || errors.Is(err, workflow.ErrInvalidRetryStep) {
+		c.Error(apperror.Conflict(err.Error()))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	h.db(c).Preload("Steps", orderByPosition).First(&run, run.ID)
+	c.JSON(http.StatusOK, run)
+}
+
+// orderByPosition sorts preloaded step runs in execution order
+func orderByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
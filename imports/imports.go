@@ -0,0 +1,323 @@
+// Package imports/imports.go
+//
+// Package imports creates the users of an organization from CSV files in the
+// background, recording the outcome of every row.
+package imports
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/invitations"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/repository"
+	"github.com/4cecoder/saas/service"
+)
+
+// MaxRows caps the number of users one file may import
+const MaxRows = 5000
+
+// progressEvery is how many rows are processed between saving the progress
+const progressEvery = 100
+
+// ErrInvalidFile is returned for files that aren't CSV with an email column
+var ErrInvalidFile = errors.New("invalid file")
+
+// errSkipped rolls back a row that's left out rather than failed, with why
+type errSkipped string
+
+func (e errSkipped) Error() string { return string(e) }
+
+// Importer imports the users of CSV files with the columns email, name and
+// role, in any order; only email is required. Each row gives the user a seat
+// with the role, creating the user unless one has the email.
+type Importer struct {
+	DB    *gorm.DB
+	Seats *service.Seats
+	// Invitations emails the users of imports that invite them
+	Invitations *invitations.Invitations
+}
+
+// NewImporter creates an importer taking up seats through seats
+func NewImporter(db *gorm.DB, seats *service.Seats, inv *invitations.Invitations) *Importer {
+	return &Importer{DB: db, Seats: seats, Invitations: inv}
+}
+
+// Start reads a CSV file and records a pending import of its users into org,
+// which is processed in the background. Files that can't be read fail with
+// ErrInvalidFile before anything is recorded.
+func (im *Importer) Start(ctx context.Context, org *models.Organization, requestedBy uint, filename string, file io.Reader, invite bool) (*models.UserImport, error) {
+	rows, err := parse(file)
+	if err != nil {
+		return nil, err
+	}
+	imp := &models.UserImport{OrganizationID: org.ID, RequestedBy: requestedBy, Filename: filename, Invite: invite}
+	return im.start(ctx, org, imp, rows)
+}
+
+// Invite records a pending import inviting the users of emails into org with
+// role, which is processed in the background like the imports of files
+func (im *Importer) Invite(ctx context.Context, org *models.Organization, requestedBy uint, emails []string, role string) (*models.UserImport, error) {
+	rows := make([]models.UserImportRow, len(emails))
+	for i, email := range emails {
+		rows[i] = models.UserImportRow{Line: i + 1, Email: strings.TrimSpace(email), Role: role}
+	}
+	imp := &models.UserImport{OrganizationID: org.ID, RequestedBy: requestedBy, Invite: true}
+	return im.start(ctx, org, imp, rows)
+}
+
+// start records a pending import of rows and processes it in the background
+func (im *Importer) start(ctx context.Context, org *models.Organization, imp *models.UserImport, rows []models.UserImportRow) (*models.UserImport, error) {
+	imp.Status, imp.Total, imp.Rows = models.UserImportStatusPending, len(rows), []models.UserImportRow{}
+	if err := im.DB.WithContext(ctx).Create(imp).Error; err != nil {
+		return nil, err
+	}
+
+	// Detach from the request so the import outlives it
+	go im.run(context.WithoutCancel(ctx), org, imp, rows)
+
+	return imp, nil
+}
+
+// parse reads the rows of a CSV file, checking its header and size
+func parse(file io.Reader) ([]models.UserImportRow, error) {
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidFile)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, dup := columns[name]; dup && name != "" {
+			return nil, fmt.Errorf("%w: the column %s is repeated", ErrInvalidFile, name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, fmt.Errorf("%w: the header lacks an email column", ErrInvalidFile)
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []models.UserImportRow
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("%w: at most %d users can be imported at once", ErrInvalidFile, MaxRows)
+		}
+		line, _ := r.FieldPos(0)
+		rows = append(rows, models.UserImportRow{
+			Line:  line,
+			Email: field(record, "email"),
+			Name:  field(record, "name"),
+			Role:  field(record, "role"),
+		})
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file has no users", ErrInvalidFile)
+	}
+	return rows, nil
+}
+
+// run imports the rows, saving the progress as it goes
+func (im *Importer) run(ctx context.Context, org *models.Organization, imp *models.UserImport, rows []models.UserImportRow) {
+	db := im.DB.WithContext(ctx)
+	if err := db.Model(imp).Update("status", models.UserImportStatusRunning).Error; err != nil {
+		slog.Error("imports: failed to start import", "import_id", imp.ID, "error", err)
+		return
+	}
+
+	roles := map[string]*models.Role{}
+	seen := map[string]int{}
+	for i, row := range rows {
+		key := strings.ToLower(row.Email)
+		if line, dup := seen[key]; dup && key != "" {
+			row = skipped(row, fmt.Sprintf("repeats the email of line %d", line))
+		} else {
+			seen[key] = row.Line
+			row = im.importRow(ctx, org, imp, row, roles)
+		}
+
+		imp.Rows = append(imp.Rows, row)
+		switch row.Status {
+		case models.UserImportRowFailed:
+			imp.Failed++
+		case models.UserImportRowSkipped:
+			imp.Skipped++
+		default:
+			imp.Succeeded++
+		}
+		if (i+1)%progressEvery == 0 {
+			im.save(ctx, imp)
+		}
+	}
+
+	imp.Status = models.UserImportStatusCompleted
+	im.save(ctx, imp)
+	slog.InfoContext(ctx, "imports: import completed", "import_id", imp.ID, "organization_id", org.ID, "succeeded", imp.Succeeded, "failed", imp.Failed, "skipped", imp.Skipped)
+}
+
+// save records the progress of an import
+func (im *Importer) save(ctx context.Context, imp *models.UserImport) {
+	err := im.DB.WithContext(ctx).Model(imp).Select("Status", "Succeeded", "Failed", "Skipped", "Rows").Updates(imp).Error
+	if err != nil {
+		slog.Error("imports: failed to save import progress", "import_id", imp.ID, "error", err)
+	}
+}
+
+// importRow gives the user of a row a seat in org, creating the user if needed,
+// and invites them when the import does
+func (im *Importer) importRow(ctx context.Context, org *models.Organization, imp *models.UserImport, row models.UserImportRow, roles map[string]*models.Role) models.UserImportRow {
+	if msg := validate(row); msg != "" {
+		return failed(row, msg)
+	}
+	if !org.Settings.AllowsEmail(row.Email) {
+		return failed(row, "the organization doesn't allow the domain of the email")
+	}
+	if row.Role == "" {
+		row.Role = models.UserRole
+	}
+	role, err := im.role(ctx, row.Role, roles)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return failed(row, fmt.Sprintf("the role %s doesn't exist", row.Role))
+	} else if err != nil {
+		return failed(row, apperror.From(err).Message)
+	}
+
+	var user models.User
+	var inv *models.Invitation
+	var token string
+	err = im.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("email = ?", row.Email).First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// Without a password they can't sign in until they accept an invitation
+			user = models.User{Email: row.Email, Name: row.Name, Locale: org.Settings.DefaultLocale, Timezone: org.Settings.DefaultTimezone}
+			if user.Name == "" {
+				user.Name, _, _ = strings.Cut(row.Email, "@")
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			row.Status = models.UserImportRowCreated
+		case err != nil:
+			return err
+		default:
+			// Members and pending invitations hold a seat, invited in the latter case
+			var held models.Seat
+			if err := tx.Where("organization_id = ? AND user_id = ?", org.ID, user.ID).Limit(1).Find(&held).Error; err != nil {
+				return err
+			}
+			if held.Status == models.SeatStatusInvited {
+				return errSkipped("the user is already invited")
+			} else if held.ID != 0 {
+				return errSkipped("the user already holds a seat in the organization")
+			}
+			row.Status = models.UserImportRowAdded
+		}
+		row.UserID = user.ID
+
+		status := models.SeatStatusActive
+		if imp.Invite {
+			status = models.SeatStatusInvited
+		}
+		seat, err := im.Seats.Create(repository.WithTx(ctx, tx), dto.CreateSeatRequest{OrganizationID: org.ID, UserID: user.ID, Status: status})
+		if err != nil {
+			return err
+		}
+		row.SeatID = seat.ID
+		if err := tx.Model(seat).Omit("Roles.*").Association("Roles").Append(role); err != nil {
+			return err
+		}
+
+		if imp.Invite {
+			inv, token, err = im.Invitations.Create(tx, &user, seat, imp.RequestedBy)
+			return err
+		}
+		return tx.Model(org).Omit("Users.*").Association("Users").Append(&user)
+	})
+	var skip errSkipped
+	if errors.As(err, &skip) {
+		row.Status, row.UserID, row.SeatID, row.Error = models.UserImportRowSkipped, user.ID, 0, string(skip)
+		return row
+	} else if err != nil {
+		row.UserID, row.SeatID = 0, 0
+		return failed(row, apperror.From(err).Message)
+	}
+
+	if inv != nil {
+		if err := im.Invitations.Send(ctx, inv, org, token); err != nil {
+			slog.ErrorContext(ctx, "imports: failed to send invitation", "import_id", imp.ID, "user_id", user.ID, "error", err)
+			row.Error = "the invitation couldn't be emailed"
+		} else {
+			row.Invited = true
+		}
+	}
+	return row
+}
+
+// role returns the role named name, caching the roles of an import
+func (im *Importer) role(ctx context.Context, name string, roles map[string]*models.Role) (*models.Role, error) {
+	if role, ok := roles[name]; ok {
+		return role, nil
+	}
+	var role models.Role
+	if err := im.DB.WithContext(ctx).Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	roles[name] = &role
+	return &role, nil
+}
+
+// validate returns what's wrong with the fields of a row, if anything
+func validate(row models.UserImportRow) string {
+	switch {
+	case row.Email == "":
+		return "the email is missing"
+	case len(row.Email) > 254:
+		return "the email is longer than 254 characters"
+	case len(row.Name) > 100:
+		return "the name is longer than 100 characters"
+	}
+	if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email {
+		return "the email is invalid"
+	}
+	return ""
+}
+
+// skipped marks a row as left out, with why
+func skipped(row models.UserImportRow, reason string) models.UserImportRow {
+	row.Status, row.Error = models.UserImportRowSkipped, reason
+	return row
+}
+
+// failed marks a row as failed with msg
+func failed(row models.UserImportRow, msg string) models.UserImportRow {
+	row.Status, row.Error = models.UserImportRowFailed, msg
+	return row
+}
@@ -0,0 +1,145 @@
+// Package invitations/invitations.go
+//
+// Package invitations invites users to organizations through links emailed to
+// them; accepting one activates their seat and makes them a member.
+package invitations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+)
+
+// DefaultTTL is how long the link of an invitation is valid
+const DefaultTTL = 7 * 24 * time.Hour
+
+// AcceptPath is the page of the web frontend the link of an invitation opens,
+// with the token in its query; the page posts it to /v1/auth/invitations/accept
+const AcceptPath = "/invitations/accept"
+
+// Errors of invitations
+var (
+	ErrInvalidToken     = errors.New("invalid, used or expired token")
+	ErrPasswordRequired = errors.New("password required")
+)
+
+// Invitations records invitations, emails them and accepts them
+type Invitations struct {
+	DB     *gorm.DB
+	Mailer mailer.Mailer
+	// AppURL is the URL of the web frontend serving AcceptPath
+	AppURL string
+	TTL    time.Duration
+}
+
+// New creates the invitation flow, emailing links to appURL
+func New(db *gorm.DB, mail mailer.Mailer, appURL string) *Invitations {
+	return &Invitations{DB: db, Mailer: mail, AppURL: appURL, TTL: DefaultTTL}
+}
+
+// Create records in tx an invitation of user to the invited seat, returning it
+// with the token of its link
+func (i *Invitations) Create(tx *gorm.DB, user *models.User, seat *models.Seat, invitedBy uint) (*models.Invitation, string, error) {
+	token, hash, err := auth.NewLinkToken()
+	if err != nil {
+		return nil, "", err
+	}
+	inv := &models.Invitation{
+		OrganizationID: seat.OrganizationID,
+		UserID:         user.ID,
+		SeatID:         seat.ID,
+		Email:          user.Email,
+		InvitedBy:      invitedBy,
+		TokenHash:      hash,
+		ExpiresAt:      time.Now().Add(i.TTL),
+	}
+	if err := tx.Create(inv).Error; err != nil {
+		return nil, "", err
+	}
+	return inv, token, nil
+}
+
+// Send emails an invitation to org with the token of its link, ending with the
+// organization's email footer
+func (i *Invitations) Send(ctx context.Context, inv *models.Invitation, org *models.Organization, token string) error {
+	link := strings.TrimSuffix(i.AppURL, "/") + AcceptPath + "?token=" + url.QueryEscape(token)
+	text := fmt.Sprintf("You were invited to join %s. Accept the invitation by %s at:\n\n%s\n\n"+
+		"If you don't know %s, you can ignore this email.\n",
+		org.Name, inv.ExpiresAt.UTC().Format(time.RFC1123), link, org.Name)
+	if footer := strings.TrimSpace(org.Settings.EmailFooter); footer != "" {
+		text += "\n--\n" + footer + "\n"
+	}
+	msg := mailer.Message{
+		To:      []string{inv.Email},
+		Subject: fmt.Sprintf("You're invited to join %s", org.Name),
+		Text:    text,
+	}
+	if err := i.Mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send invitation: %w", err)
+	}
+	return nil
+}
+
+// Accept accepts the invitation of a token: the seat becomes active, the user
+// a member of the organization and their email verified. Users without a
+// password, such as those created by imports, choose it here.
+func (i *Invitations) Accept(ctx context.Context, token, password string) (*models.Invitation, error) {
+	var inv models.Invitation
+	err := i.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND accepted_at IS NULL AND expires_at > ?", auth.HashLinkToken(token), time.Now()).
+			First(&inv).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		} else if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, inv.UserID).Error; err != nil {
+			return err
+		}
+		if user.PasswordHash == "" && password == "" {
+			return ErrPasswordRequired
+		}
+		if user.PasswordHash == "" {
+			user.Password = password
+		}
+		user.Verified = true
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+
+		var seat models.Seat
+		if err := tx.First(&seat, inv.SeatID).Error; err != nil {
+			return err
+		}
+		if seat.Status == models.SeatStatusInvited {
+			if err := tx.Model(&seat).Update("status", models.SeatStatusActive).Error; err != nil {
+				return err
+			}
+		}
+		org := models.Organization{Base: models.Base{ID: inv.OrganizationID}}
+		if err := tx.Model(&org).Omit("Users.*").Association("Users").Append(&user); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		inv.AcceptedAt = &now
+		return tx.Model(&inv).Update("accepted_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
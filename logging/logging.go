@@ -0,0 +1,135 @@
+// Package logging/logging.go
+//
+// Package logging sets up the structured logger of the application. Records
+// logged with a context carry the request, user and organization it concerns,
+// and personal data and credentials are redacted from them.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/requestid"
+)
+
+// Formats of the log output
+const (
+	// JSON writes one JSON object per record, for log collectors
+	JSON = "json"
+	// Console writes key=value lines, for reading in a terminal
+	Console = "console"
+)
+
+// Config selects the level and format of the logs
+type Config struct {
+	// Level is debug, info (the default), warn or error
+	Level string
+	// Format is json or console (the default)
+	Format string
+	// RedactFields are masked in addition to DefaultRedactFields
+	RedactFields []string
+}
+
+// levels maps the configurable levels to slog's
+var levels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// New creates a logger writing to w. Values of sensitive fields are masked and
+// emails, tokens and password hashes are scrubbed from every record.
+func New(cfg Config, w io.Writer) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	if cfg.Level != "" {
+		var ok bool
+		if level, ok = levels[strings.ToLower(cfg.Level)]; !ok {
+			return nil, fmt.Errorf("unknown log level %q, use debug, info, warn, or error", cfg.Level)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: NewRedactor(cfg.RedactFields...).Attr}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "", Console:
+		handler = slog.NewTextHandler(w, opts)
+	case JSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use json or console", cfg.Format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Setup makes a logger writing to stderr the default, for slog and the log package
+func Setup(cfg Config) error {
+	logger, err := New(cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// Fatal logs an error and exits, for failures the application can't start with
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// organizationIDKey is the context key of the organization a request concerns
+type organizationIDKey struct{}
+
+// WithOrganizationID returns a copy of ctx whose records carry the organization ID
+func WithOrganizationID(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, organizationIDKey{}, id)
+}
+
+// OrganizationID returns the organization ID ctx carries, if any
+func OrganizationID(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(organizationIDKey{}).(uint)
+	return id, ok
+}
+
+// contextHandler adds the request, user, organization and trace IDs of the context to records
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implements slog.Handler
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := requestid.From(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if id, ok := auth.UserIDFromContext(ctx); ok {
+			r.AddAttrs(slog.Uint64("user_id", uint64(id)))
+		}
+		if id, ok := OrganizationID(ctx); ok {
+			r.AddAttrs(slog.Uint64("org_id", uint64(id)))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()),
+				slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
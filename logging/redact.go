@@ -0,0 +1,148 @@
+// Package logging/redact.go
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Redacted replaces the values kept out of logs
+const Redacted = "[REDACTED]"
+
+// DefaultRedactFields are the fields whose values are never logged. A field
+// matches keys it is a whole part of, so token also masks access_token and
+// X-Refresh-Token.
+var DefaultRedactFields = []string{
+	"password", "passwd", "secret", "token", "api_key", "private_key",
+	"authorization", "cookie", "credential", "otp", "email",
+}
+
+// Patterns of personal data and credentials scrubbed from any logged text
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	schemePattern = regexp.MustCompile(`(?i)\b(Bearer|Basic|ApiKey)\s+[A-Za-z0-9._~+/=-]+`)
+	bcryptPattern = regexp.MustCompile(`\$2[aby]?\$\d{2}\$[./A-Za-z0-9]{53}`)
+)
+
+// Redactor masks the values of sensitive fields and scrubs emails, tokens and
+// password hashes from text
+type Redactor struct {
+	fields []string
+	// assignment matches name=value, name: value and "name":"value" pairs of the fields
+	assignment *regexp.Regexp
+}
+
+// NewRedactor creates a redactor masking DefaultRedactFields and fields
+func NewRedactor(fields ...string) *Redactor {
+	r := &Redactor{}
+	names := make([]string, 0, len(DefaultRedactFields)+len(fields))
+	for _, f := range append(append([]string{}, DefaultRedactFields...), fields...) {
+		f = normalizeKey(f)
+		if f == "" {
+			continue
+		}
+		r.fields = append(r.fields, f)
+		names = append(names, strings.ReplaceAll(regexp.QuoteMeta(f), "_", "[_-]?"))
+	}
+	r.assignment = regexp.MustCompile(`(?i)(\b[A-Za-z0-9_-]*(?:` + strings.Join(names, "|") +
+		`)[A-Za-z0-9_-]*["'` + "`" + `]?\s*[:=]\s*["']?)[^\s&"',;)]+`)
+	return r
+}
+
+// Field reports whether the values of key are masked
+func (r *Redactor) Field(key string) bool {
+	key = "_" + normalizeKey(key) + "_"
+	for _, f := range r.fields {
+		if strings.Contains(key, "_"+f+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// String scrubs emails, tokens, password hashes and the values assigned to
+// masked fields from s
+func (r *Redactor) String(s string) string {
+	s = r.assignment.ReplaceAllString(s, "${1}"+Redacted)
+	s = schemePattern.ReplaceAllString(s, "$1 "+Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bcryptPattern.ReplaceAllString(s, Redacted)
+	return emailPattern.ReplaceAllString(s, Redacted)
+}
+
+// Map returns a copy of m with masked fields replaced and text scrubbed, for
+// payloads sent to error reporting
+func (r *Redactor) Map(m map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if r.Field(k) {
+			redacted[k] = Redacted
+			continue
+		}
+		redacted[k] = r.value(v)
+	}
+	return redacted
+}
+
+// value redacts a value of a map
+func (r *Redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return r.String(v)
+	case error:
+		return r.String(v.Error())
+	case map[string]interface{}:
+		return r.Map(v)
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return r.Map(m)
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, e := range v {
+			values[i] = r.value(e)
+		}
+		return values
+	}
+	return v
+}
+
+// Attr redacts an attribute; it is a slog.HandlerOptions.ReplaceAttr function
+func (r *Redactor) Attr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindGroup {
+		return a
+	}
+	if r.Field(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.String(a.Value.String()))
+	case slog.KindAny:
+		return slog.Any(a.Key, r.value(a.Value.Any()))
+	}
+	return a
+}
+
+// normalizeKey lowercases a key and separates its words with underscores, so
+// apiKey, API-Key and api_key compare equal
+func normalizeKey(key string) string {
+	var b strings.Builder
+	prev := rune(0)
+	for _, c := range strings.TrimSpace(key) {
+		switch {
+		case c == '-' || c == ' ' || c == '.':
+			c = '_'
+		case unicode.IsUpper(c) && unicode.IsLower(prev):
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(c))
+		prev = c
+	}
+	return b.String()
+}
@@ -2,95 +2,74 @@
 package main
 
 import (
-	"gorm.io/gorm"
-	"log"
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/4cecoder/saas/app"
 	"github.com/4cecoder/saas/config"
-	"github.com/4cecoder/saas/handlers"
-	"github.com/4cecoder/saas/models"
-	"github.com/gin-gonic/gin"
+	"github.com/4cecoder/saas/logging"
+	"github.com/4cecoder/saas/migrations"
+	"github.com/4cecoder/saas/seed"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.Load()
 
-	// Auto-migrate models
-	err := cfg.DB.AutoMigrate(
-		&models.User{},
-		&models.Organization{},
-		&models.Subscription{},
-		&models.Role{},
-		&models.Permission{},
-		&models.Domain{},
-		&models.AuditLog{},
-		&models.PaymentTransaction{},
-		&models.NotificationPreference{},
-		&models.ActivityLog{},
-		&models.APIKey{},
-		&models.Workflow{},
-		&models.Report{},
-	)
-	if err != nil {
-		log.Fatalf("Failed to auto-migrate models: %v", err)
+	// Run an administrative command if one was given
+	if len(os.Args) > 1 {
+		if err := newCommand(cfg).Execute(); err != nil {
+			logging.Fatal("command failed", "error", err)
+		}
+		return
 	}
 
-	// Create a new Gin router
-	r := gin.Default()
-
-	// Create a new handler instance
-	h := handlers.NewHandler(cfg.DB)
-
-	// Define routes
-	r.POST("/users", h.CreateUser)
-	r.GET("/users/:id", h.GetUser)
-	r.PUT("/users/:id", h.UpdateUser)
-	r.DELETE("/users/:id", h.DeleteUser)
-
-	r.POST("/organizations", h.CreateOrganization)
-	r.GET("/organizations/:id", h.GetOrganization)
-	r.PUT("/organizations/:id", h.UpdateOrganization)
-	r.DELETE("/organizations/:id", h.DeleteOrganization)
-
-	r.POST("/subscriptions", h.CreateSubscription)
-	r.GET("/subscriptions/:id", h.GetSubscription)
-	r.PUT("/subscriptions/:id", h.UpdateSubscription)
-	r.DELETE("/subscriptions/:id", h.DeleteSubscription)
-
-	// Add more routes for other handlers
-
-	// Create the default admin user
-	createDefaultAdmin(cfg.DB)
-
-	// Start the server
-	err = r.Run(":8080")
+	// Bring the schema up to date, or refuse to serve a database that is behind
+	migrator, err := migrations.New(cfg.DB)
 	if err != nil {
-		log.Fatalf("Failed to start the server: %v", err)
+		logging.Fatal("failed to load migrations", "error", err)
+	}
+	if cfg.MigrateOnStart {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			logging.Fatal("failed to migrate the database", "error", err)
+		}
+	} else {
+		pending, err := migrator.Pending(context.Background())
+		if err != nil {
+			logging.Fatal("failed to check migrations", "error", err)
+		}
+		if len(pending) > 0 {
+			logging.Fatal("the database has pending migrations; run saas migrate up or set MIGRATE_ON_START=true", "pending", len(pending))
+		}
 	}
-}
-
-func createDefaultAdmin(db *gorm.DB) {
-	// Check if the admin user already exists
-	var count int64
-	db.Model(&models.User{}).Where("email = ?", "admin").Count(&count)
 
-	if count == 0 {
-		// Create the admin user
+	// Create the first administrator of a fresh deployment
+	created, err := seed.Bootstrap(context.Background(), cfg.DB, cfg.Bootstrap)
+	if err != nil {
+		logging.Fatal("failed to bootstrap the administrator", "error", err)
+	}
+	if created {
+		slog.Info("created the bootstrap administrator, who must change the password on first login; set BOOTSTRAP_ADMIN=false once they have", "email", cfg.Bootstrap.Email)
+	}
 
-		admin := &models.User{
-			Email:        "admin",
-			PasswordHash: "password",
-			Name:         "Admin User",
-			Roles: []models.Role{
-				{Name: "admin"},
-			},
-			Verified: true,
-		}
+	// Assemble the application
+	a, err := app.New(cfg, app.Providers{})
+	if err != nil {
+		logging.Fatal("failed to assemble the application", "error", err)
+	}
 
-		if err := db.Create(admin).Error; err != nil {
-			log.Fatalf("Failed to create admin user: %v", err)
-		}
+	// Prepare the indexes backing search
+	if err := a.Setup(context.Background()); err != nil {
+		logging.Fatal("failed to prepare search", "error", err)
+	}
 
-		log.Println("Default admin user created")
+	// Serve until SIGTERM or SIGINT, then shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := a.Run(ctx, cfg.Addr); err != nil {
+		logging.Fatal("failed to run the server", "error", err)
 	}
 }
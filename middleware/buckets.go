@@ -0,0 +1,157 @@
+// Package middleware/buckets.go
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/4cecoder/saas/cache"
+)
+
+// Take is the outcome of taking a token from a bucket
+type Take struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left
+	Remaining int
+	// RetryAfter is how long until a token is available again, when not allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Buckets holds token buckets by key
+type Buckets interface {
+	// Take removes a token from the bucket of key, which holds up to limit.Requests
+	// tokens and is refilled at limit.Requests per limit.Per
+	Take(ctx context.Context, key string, limit Limit) (Take, error)
+}
+
+// take computes the outcome of taking a token from a bucket holding tokens
+func take(tokens float64, limit Limit, allowed bool) Take {
+	perToken := limit.Per / time.Duration(limit.Requests)
+	t := Take{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		t.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return t
+}
+
+// bucket is the state of one in-process token bucket
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// MemoryBuckets keeps token buckets in process; each instance of the
+// application then limits clients on its own
+type MemoryBuckets struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryBuckets creates in-process buckets and starts dropping idle ones
+func NewMemoryBuckets() *MemoryBuckets {
+	b := &MemoryBuckets{buckets: make(map[string]*bucket)}
+	go b.evict(time.Minute)
+	return b
+}
+
+// Take implements Buckets
+func (b *MemoryBuckets) Take(ctx context.Context, key string, limit Limit) (Take, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	capacity := float64(limit.Requests)
+	bk, ok := b.buckets[key]
+	if !ok {
+		bk = &bucket{tokens: capacity, at: now}
+		b.buckets[key] = bk
+	}
+	bk.tokens = math.Min(capacity, bk.tokens+now.Sub(bk.at).Seconds()*capacity/limit.Per.Seconds())
+	bk.at = now
+
+	allowed := bk.tokens >= 1
+	if allowed {
+		bk.tokens--
+	}
+	return take(bk.tokens, limit, allowed), nil
+}
+
+// evict periodically drops buckets untouched for an hour, which are full again
+// for any limit up to that period
+func (b *MemoryBuckets) evict(interval time.Duration) {
+	for range time.Tick(interval) {
+		cutoff := time.Now().Add(-time.Hour)
+		b.mu.Lock()
+		for key, bk := range b.buckets {
+			if bk.at.Before(cutoff) {
+				delete(b.buckets, key)
+			}
+		}
+		b.mu.Unlock()
+	}
+}
+
+// takeScript refills and takes from a bucket atomically, on the Redis clock so
+// the instances' clocks don't need to agree. It returns whether a token was
+// taken and the tokens left.
+var takeScript = redis.NewScript(`
+redis.replicate_commands()
+local capacity = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1]) or capacity
+local at = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - at) * capacity / period)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', now)
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisBuckets keeps token buckets in Redis, shared by every instance of the application
+type RedisBuckets struct {
+	Redis *cache.Redis
+}
+
+// NewRedisBuckets creates buckets kept in Redis
+func NewRedisBuckets(r *cache.Redis) *RedisBuckets {
+	return &RedisBuckets{Redis: r}
+}
+
+// Take implements Buckets
+func (b *RedisBuckets) Take(ctx context.Context, key string, limit Limit) (Take, error) {
+	reply, err := takeScript.Run(ctx, b.Redis.Client, []string{key},
+		limit.Requests, limit.Per.Milliseconds()).Slice()
+	if err != nil {
+		return Take{}, err
+	}
+
+	if len(reply) != 2 {
+		return Take{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+	allowed, _ := reply[0].(int64)
+	raw, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Take{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+	return take(tokens, limit, allowed == 1), nil
+}
@@ -0,0 +1,221 @@
+// Package middleware/compress.go
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// CompressionConfig selects the responses compressed
+type CompressionConfig struct {
+	Enabled bool
+	// MinSize is the size from which responses are compressed, in bytes;
+	// smaller ones gain too little to be worth the CPU
+	MinSize int
+	// ContentTypes are the media types compressed, such as application/json
+	ContentTypes []string
+}
+
+// DefaultCompressionTypes are the media types compressed by default
+var DefaultCompressionTypes = []string{"application/json", "application/x-ndjson", "text/csv"}
+
+// encoders compress with the supported encodings, in order of preference
+var encoders = []struct {
+	name string
+	pool *sync.Pool
+}{
+	{"br", &sync.Pool{New: func() interface{} { return brotli.NewWriterLevel(io.Discard, 4) }}},
+	{"gzip", &sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}},
+}
+
+// resettableWriter is an encoder that can be reused for another response
+type resettableWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compress encodes the responses of allowed content types with brotli or gzip,
+// as the client accepts, once they reach the minimum size. It runs before the
+// middleware recording responses, so they keep the uncompressed bodies.
+func Compress(cfg CompressionConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if encoding < 0 || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		// After a panic, Recovery responds on the original writer
+		w := &compressWriter{ResponseWriter: c.Writer, cfg: cfg, encoding: encoding}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+		c.Next()
+		w.finish()
+	}
+}
+
+// negotiateEncoding returns the index in encoders of the preferred encoding
+// the client accepts, or -1 if it accepts none
+func negotiateEncoding(header string) int {
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	best, bestQ := -1, 0.0
+	for i, enc := range encoders {
+		q, ok := accepted[enc.name]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a response until it knows whether to
+// compress it: once the body reaches the minimum size, or at its end
+type compressWriter struct {
+	gin.ResponseWriter
+	cfg      CompressionConfig
+	encoding int
+
+	buf     bytes.Buffer
+	decided bool
+	encoder resettableWriter
+	size    int
+}
+
+// Write implements io.Writer
+func (w *compressWriter) Write(b []byte) (int, error) {
+	w.size += len(b)
+	if w.decided {
+		return w.write(b)
+	}
+	w.buf.Write(b)
+	if w.buf.Len() >= w.cfg.MinSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// WriteString implements io.StringWriter
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports whether the handler wrote a response, even if buffered
+func (w *compressWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Size returns the uncompressed size of the body written
+func (w *compressWriter) Size() int {
+	if w.size == 0 {
+		return w.ResponseWriter.Size()
+	}
+	return w.size
+}
+
+// Flush sends what was written so far, deciding on compression first
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack implements http.Hijacker, sending nothing buffered
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// write passes b to the encoder, or through when not compressing
+func (w *compressWriter) write(b []byte) (int, error) {
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide starts compressing if the response qualifies, then writes the buffer
+func (w *compressWriter) decide() error {
+	w.decided = true
+	if w.compressible() {
+		h := w.Header()
+		h.Set("Content-Encoding", encoders[w.encoding].name)
+		h.Del("Content-Length")
+		if tag := h.Get("ETag"); tag != "" && !strings.HasPrefix(tag, "W/") {
+			// The compressed bytes differ, so the tag no longer is a strong validator
+			h.Set("ETag", "W/"+tag)
+		}
+		w.encoder = encoders[w.encoding].pool.Get().(resettableWriter)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// compressible reports whether the buffered response should be compressed
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if w.buf.Len() == 0 || w.buf.Len() < w.cfg.MinSize || h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if status := w.Status(); status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	return err == nil && slices.Contains(w.cfg.ContentTypes, mediaType)
+}
+
+// finish writes what is still buffered and ends the compressed stream
+func (w *compressWriter) finish() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		w.encoder.Reset(io.Discard)
+		encoders[w.encoding].pool.Put(w.encoder)
+		w.encoder = nil
+	}
+}
@@ -0,0 +1,170 @@
+// Package middleware/cors.go
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/requestid"
+)
+
+// CORSConfig selects the browser origins allowed to call the API
+type CORSConfig struct {
+	// AllowedOrigins are origins such as https://app.example.com. A * stands for
+	// any subdomain or port, as in https://*.example.com or http://localhost:*,
+	// and alone for any origin.
+	AllowedOrigins []string
+	// AllowCredentials lets browsers send cookies and credentials
+	AllowCredentials bool
+	// CustomDomains allows the verified custom domains of organizations, over HTTPS
+	CustomDomains bool
+	// MaxAge is how long browsers cache preflight responses
+	MaxAge time.Duration
+}
+
+// corsHeaders are the request headers clients may send
+var corsHeaders = []string{
+	"Authorization", "Content-Type", APIKeyHeader, IdempotencyHeader,
+	"If-Match", "If-None-Match", requestid.Header, "traceparent", auth.CSRFHeader,
+}
+
+// corsExposedHeaders are the response headers clients may read
+var corsExposedHeaders = []string{
+	requestid.Header, "ETag", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining",
+	"X-RateLimit-Reset", "Idempotent-Replayed", "Deprecation", "Sunset", "Link",
+}
+
+// corsMethods are the methods of the API
+var corsMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// originPattern is an allowed origin; host and port may be wildcards
+type originPattern struct {
+	scheme, host, port string
+}
+
+// CORS answers cross-origin requests and their preflights from allowed origins
+type CORS struct {
+	Config CORSConfig
+	// Domain returns the organization that verified a host as its custom domain, 0 if none
+	Domain func(ctx context.Context, host string) (uint, error)
+
+	any      bool
+	patterns []originPattern
+}
+
+// NewCORS creates the middleware of cfg, looking up custom domains with domain
+func NewCORS(cfg CORSConfig, domain func(ctx context.Context, host string) (uint, error)) (*CORS, error) {
+	c := &CORS{Config: cfg, Domain: domain}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			c.any = true
+			continue
+		}
+		scheme, hostport, ok := strings.Cut(strings.TrimSuffix(origin, "/"), "://")
+		if !ok || (scheme != "http" && scheme != "https") || hostport == "" || strings.ContainsAny(hostport, "/?#@") {
+			return nil, fmt.Errorf("invalid origin %q, expected e.g. https://app.example.com", origin)
+		}
+		host, port, hasPort := strings.Cut(hostport, ":")
+		if strings.Contains(strings.TrimPrefix(host, "*."), "*") || (hasPort && port != "*" && !isPort(port)) {
+			return nil, fmt.Errorf("invalid origin %q, only a leading *. or a :* port may be a wildcard", origin)
+		}
+		c.patterns = append(c.patterns, originPattern{scheme: scheme, host: strings.ToLower(host), port: port})
+	}
+	return c, nil
+}
+
+// isPort reports whether s is a port number
+func isPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// Middleware adds the CORS headers to the responses of allowed origins and
+// answers their preflight requests. Preflights of other origins are refused;
+// their other requests are served without the headers, so browsers hide the
+// responses. It runs before authentication and rate limiting.
+func (m *CORS) Middleware() gin.HandlerFunc {
+	methods := strings.Join(corsMethods, ", ")
+	headers := strings.Join(corsHeaders, ", ")
+	exposed := strings.Join(corsExposedHeaders, ", ")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !m.allowed(c.Request.Context(), origin) {
+			if preflight {
+				Abort(c, apperror.Forbidden("Origin not allowed"))
+				return
+			}
+			c.Next()
+			return
+		}
+
+		h.Set("Access-Control-Allow-Origin", origin)
+		if m.Config.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			h.Set("Access-Control-Expose-Headers", exposed)
+			c.Next()
+			return
+		}
+
+		h.Add("Vary", "Access-Control-Request-Method, Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", methods)
+		h.Set("Access-Control-Allow-Headers", headers)
+		if m.Config.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(m.Config.MaxAge.Seconds())))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// allowed reports whether requests from origin are allowed
+func (m *CORS) allowed(ctx context.Context, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if m.any {
+		return true
+	}
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	for _, p := range m.patterns {
+		if p.scheme == u.Scheme && matchHost(p.host, host) && (p.port == "*" || p.port == port) {
+			return true
+		}
+	}
+
+	if m.Config.CustomDomains && m.Domain != nil && u.Scheme == "https" && port == "" {
+		orgID, err := m.Domain(ctx, host)
+		return err == nil && orgID != 0
+	}
+	return false
+}
+
+// matchHost reports whether host matches pattern, where *. stands for any subdomain
+func matchHost(pattern, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return pattern == host
+}
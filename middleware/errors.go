@@ -0,0 +1,42 @@
+// Package middleware/errors.go
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+)
+
+// Errors renders the last error a handler attached with c.Error as a JSON response
+// with its status and code. Register it after middleware that records responses, so
+// they see the rendered error.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+		Abort(c, last.Err)
+	}
+}
+
+// Abort writes an error response and stops the handler chain. Middleware that runs
+// before Errors uses it to respond directly. The body carries the request ID, so
+// clients can quote it to support.
+func Abort(c *gin.Context, err error) {
+	appErr := apperror.From(err)
+	id := c.GetString("request_id")
+	if appErr.Err != nil && appErr.Status >= 500 {
+		slog.ErrorContext(c.Request.Context(), "request failed",
+			"method", c.Request.Method, "path", c.Request.URL.Path, "error", appErr.Err)
+	}
+	body := appErr.Body()
+	if id != "" {
+		body["request_id"] = id
+	}
+	c.AbortWithStatusJSON(appErr.Status, body)
+}
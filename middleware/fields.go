@@ -0,0 +1,191 @@
+// Package middleware/fields.go
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fieldTree is a parsed fields parameter; a nil subtree keeps the whole value
+type fieldTree map[string]fieldTree
+
+// fieldsWriter holds back the body of successful JSON responses so it can be
+// trimmed. Other responses, such as downloads and event streams, and responses
+// flushed while streaming are passed straight through.
+type fieldsWriter struct {
+	gin.ResponseWriter
+	body    bytes.Buffer
+	decided bool
+	buffer  bool
+}
+
+// Write buffers JSON bodies and sends the others
+func (w *fieldsWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.decide()
+	}
+	if w.buffer {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString buffers JSON bodies and sends the others
+func (w *fieldsWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports whether the handler wrote a response, even if buffered
+func (w *fieldsWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush sends what was written so far untrimmed, since the response streams
+func (w *fieldsWriter) Flush() {
+	w.decided = true
+	if w.buffer {
+		w.buffer = false
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack implements http.Hijacker, sending nothing buffered
+func (w *fieldsWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	w.buffer = false
+	return w.ResponseWriter.Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController
+func (w *fieldsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide buffers the body if the response is a successful JSON one, as known
+// from its headers once the handler starts writing
+func (w *fieldsWriter) decide() {
+	w.decided = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	status := w.Status()
+	w.buffer = status >= 200 && status < 300 && mediaType == "application/json"
+	if w.buffer {
+		// The trimmed body is shorter
+		w.Header().Del("Content-Length")
+	}
+}
+
+// SparseFieldsets trims JSON responses of GET requests to the fields listed in the
+// fields query parameter, e.g. ?fields=id,name,users.email. List envelopes keep their
+// paging keys and apply the selection to each item in data. The id is always kept.
+// Other responses, such as downloads and event streams, are sent as they are.
+func SparseFieldsets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		param := c.Query("fields")
+		if c.Request.Method != "GET" || param == "" {
+			c.Next()
+			return
+		}
+
+		// After a panic, Recovery responds on the original writer
+		w := &fieldsWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+		c.Next()
+
+		if !w.buffer {
+			return
+		}
+		body := w.body.Bytes()
+		if trimmed, err := trim(body, parseFields(param)); err == nil {
+			body = trimmed
+		}
+		_, _ = w.ResponseWriter.Write(body)
+	}
+}
+
+// parseFields turns "id,name,users.email" into a field tree
+func parseFields(param string) fieldTree {
+	tree := fieldTree{"id": nil}
+	for _, path := range strings.Split(param, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		node := tree
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			child, seen := node[part]
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if child == nil {
+				if seen {
+					// The whole parent was already requested
+					break
+				}
+				child = fieldTree{"id": nil}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// trim decodes a JSON body, applies the selection, and re-encodes it
+func trim(body []byte, fields fieldTree) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	// List envelopes keep their paging metadata
+	if m, ok := v.(map[string]interface{}); ok {
+		if _, isPage := m["limit"]; isPage {
+			if data, ok := m["data"]; ok {
+				m["data"] = selectFields(data, fields)
+				return json.Marshal(m)
+			}
+		}
+	}
+
+	return json.Marshal(selectFields(v, fields))
+}
+
+// selectFields keeps only the selected keys of objects, applied to each element of arrays
+func selectFields(v interface{}, fields fieldTree) interface{} {
+	if fields == nil {
+		return v
+	}
+
+	switch t := v.(type) {
+	case []interface{}:
+		for i := range t {
+			t[i] = selectFields(t[i], fields)
+		}
+		return t
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(fields))
+		for name, sub := range fields {
+			if value, ok := t[name]; ok {
+				out[name] = selectFields(value, sub)
+			}
+		}
+		return out
+	}
+	return v
+}
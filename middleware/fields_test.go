@@ -0,0 +1,65 @@
+// Package middleware/fields_test.go
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/middleware"
+)
+
+// get serves GET path through SparseFieldsets and the handler
+func get(path string, handler func(c *gin.Context, w *httptest.ResponseRecorder)) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	r := gin.New()
+	r.Use(middleware.SparseFieldsets())
+	r.GET("/thing", func(c *gin.Context) { handler(c, w) })
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestSparseFieldsetsTrimsJSON(t *testing.T) {
+	w := get("/thing?fields=name", func(c *gin.Context, _ *httptest.ResponseRecorder) {
+		c.JSON(http.StatusOK, gin.H{"id": 1, "name": "Thing", "secret": "hidden"})
+	})
+	if got, want := w.Body.String(), `{"id":1,"name":"Thing"}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestSparseFieldsetsPassesOtherResponsesThrough(t *testing.T) {
+	csv := "id,name\n1,Thing\n"
+	w := get("/thing?fields=name", func(c *gin.Context, _ *httptest.ResponseRecorder) {
+		c.Data(http.StatusOK, "text/csv", []byte(csv))
+	})
+	if got := w.Body.String(); got != csv {
+		t.Errorf("body = %q, want %q", got, csv)
+	}
+}
+
+func TestSparseFieldsetsStreamsFlushedResponses(t *testing.T) {
+	get("/thing?fields=name", func(c *gin.Context, w *httptest.ResponseRecorder) {
+		c.SSEvent("thing", gin.H{"id": 1, "name": "Thing"})
+		c.Writer.Flush()
+		if !strings.Contains(w.Body.String(), "event:thing") {
+			t.Errorf("event wasn't sent when flushed, body = %q", w.Body.String())
+		}
+	})
+
+	// JSON written before flushing is sent untrimmed rather than held back
+	w := get("/thing?fields=name", func(c *gin.Context, w *httptest.ResponseRecorder) {
+		c.JSON(http.StatusOK, gin.H{"id": 1, "secret": "kept"})
+		c.Writer.Flush()
+		if w.Body.Len() == 0 {
+			t.Errorf("flushed JSON was held back")
+		}
+	})
+	if !strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("body = %s, want it untrimmed", w.Body)
+	}
+}
@@ -0,0 +1,167 @@
+// Package middleware/idempotency.go
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/scheduler"
+)
+
+// IdempotencyHeader is the request header carrying the client's idempotency key
+const IdempotencyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the accepted key length
+const maxIdempotencyKeyLength = 255
+
+// Idempotency replays the stored response of POST requests retried with the same key
+type Idempotency struct {
+	DB  *gorm.DB
+	TTL time.Duration
+}
+
+// NewIdempotency creates idempotency handling that remembers responses for a day
+func NewIdempotency(db *gorm.DB) *Idempotency {
+	return &Idempotency{DB: db, TTL: 24 * time.Hour}
+}
+
+// Register schedules the hourly removal of expired keys
+func (i *Idempotency) Register(s *scheduler.Scheduler) error {
+	s.Register("idempotency.purge", func(ctx context.Context, _ *models.ScheduledJob) error {
+		return i.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{}).Error
+	})
+	return s.Ensure("idempotency-purge", "idempotency.purge", "@hourly", nil)
+}
+
+// recordingWriter passes the response through while keeping a copy of the body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write sends and records the body
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString sends and records the body
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware handles POST requests that carry an Idempotency-Key header. Keys are
+// scoped to the authenticated user, so anonymous requests, which would share keys
+// with every other anonymous caller, are passed through. The first request runs
+// normally and its response is stored; retries with the same key and body get the
+// stored response, retries with a different body are rejected, and retries while the
+// first is still running get 409. Server errors, panics and abandoned requests aren't
+// stored, so the request can be retried.
+func (i *Idempotency) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		userID := c.GetUint("user_id")
+		if c.Request.Method != http.MethodPost || key == "" || userID == 0 {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			Abort(c, apperror.BadRequest("Idempotency-Key is too long"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			Abort(c, apperror.From(err))
+			return
+		}
+		if err != nil {
+			Abort(c, apperror.BadRequest("Failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.RequestURI()+"\n"), body...))
+		hash := hex.EncodeToString(sum[:])
+		db := i.DB.WithContext(c.Request.Context())
+
+		// Expired keys are free to be reused
+		db.Where("idempotency_key = ? AND user_id = ? AND expires_at < ?", key, userID, time.Now()).Delete(&models.IdempotencyKey{})
+
+		record := models.IdempotencyKey{
+			Key:         key,
+			UserID:      userID,
+			RequestHash: hash,
+			ExpiresAt:   time.Now().Add(i.TTL),
+		}
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if res.Error != nil {
+			Abort(c, apperror.Internal(res.Error))
+			return
+		}
+
+		if res.RowsAffected == 0 {
+			i.replay(c, db, key, userID, hash)
+			return
+		}
+
+		// Release the key unless the response was stored, including when the
+		// handler panics or the client goes away
+		stored := false
+		defer func() {
+			if !stored {
+				i.DB.WithContext(context.WithoutCancel(c.Request.Context())).Delete(&record)
+			}
+		}()
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		stored = db.Model(&record).Updates(map[string]interface{}{
+			"completed":    true,
+			"status":       status,
+			"content_type": c.Writer.Header().Get("Content-Type"),
+			"body":         w.body.Bytes(),
+		}).Error == nil
+	}
+}
+
+// replay answers a retried request from the stored first response
+func (i *Idempotency) replay(c *gin.Context, db *gorm.DB, key string, userID uint, hash string) {
+	var stored models.IdempotencyKey
+	if err := db.Where("idempotency_key = ? AND user_id = ?", key, userID).First(&stored).Error; err != nil {
+		Abort(c, apperror.Conflict("A request with this Idempotency-Key is in progress"))
+		return
+	}
+
+	switch {
+	case stored.RequestHash != hash:
+		Abort(c, apperror.Unprocessable("Idempotency-Key was already used for a different request"))
+	case !stored.Completed:
+		Abort(c, apperror.Conflict("A request with this Idempotency-Key is in progress"))
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(stored.Status, stored.ContentType, stored.Body)
+		c.Abort()
+	}
+}
@@ -0,0 +1,90 @@
+// Package middleware/idempotency_test.go
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/middleware"
+	"github.com/4cecoder/saas/testutil"
+)
+
+// idempotentRouter serves POST /things as the given user, 0 for anonymous,
+// counting the calls and panicking on the first one if asked to
+func idempotentRouter(t *testing.T, userID uint, panicFirst bool) (*gin.Engine, *int) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+		}
+	})
+	r.Use(middleware.Recovery(nil), middleware.Errors())
+	r.Use(middleware.NewIdempotency(testutil.OpenDB(t)).Middleware())
+	r.POST("/things", func(c *gin.Context) {
+		calls++
+		if panicFirst && calls == 1 {
+			panic("failed")
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+	return r, &calls
+}
+
+// post sends POST /things with the idempotency key and returns the recorded response
+func post(r http.Handler, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(`{"name":"thing"}`))
+	req.Header.Set(middleware.IdempotencyHeader, key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysRetries(t *testing.T) {
+	r, calls := idempotentRouter(t, 9101, false)
+
+	first := post(r, "replay")
+	retry := post(r, "replay")
+	if *calls != 1 {
+		t.Errorf("handler ran %d times, want 1", *calls)
+	}
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry isn't marked as replayed")
+	}
+}
+
+func TestIdempotencyIgnoresAnonymousRequests(t *testing.T) {
+	r, calls := idempotentRouter(t, 0, false)
+
+	post(r, "anonymous")
+	retry := post(r, "anonymous")
+	if *calls != 2 {
+		t.Errorf("handler ran %d times, want 2", *calls)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("anonymous request was replayed")
+	}
+}
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	r, calls := idempotentRouter(t, 9102, true)
+
+	if w := post(r, "panic"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first request = %d, want 500", w.Code)
+	}
+	if w := post(r, "panic"); w.Code != http.StatusCreated {
+		t.Errorf("retry = %d %s, want 201", w.Code, w.Body)
+	}
+	if *calls != 2 {
+		t.Errorf("handler ran %d times, want 2", *calls)
+	}
+}
@@ -0,0 +1,88 @@
+// Package middleware/limits.go
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+)
+
+// RequestLimits bounds the resources one request may take, so a slow or huge
+// request can't exhaust the server. Routes are named by their pattern without
+// the /v1 prefix, such as /reports/:id/exports/:export_id/download.
+type RequestLimits struct {
+	// HandlerTimeout bounds how long handlers run, through the request context; 0 disables it
+	HandlerTimeout time.Duration
+	// MaxBodySize is the largest request body accepted, in bytes; 0 disables it
+	MaxBodySize int64
+	// RouteTimeouts override HandlerTimeout, e.g. for routes streaming large responses
+	RouteTimeouts map[string]time.Duration
+	// RouteBodySizes override MaxBodySize, e.g. for uploads
+	RouteBodySizes map[string]int64
+}
+
+// route returns the pattern of the route matched by c without the version prefix
+func route(c *gin.Context) string {
+	return strings.TrimPrefix(c.FullPath(), "/v1")
+}
+
+// Timeout cancels the request context of handlers running longer than their
+// route's timeout, answering 503 unless they already responded. Handlers stop
+// at their next database query or outbound call. Routes with their own
+// timeout get a write deadline to match, as they may respond for longer than
+// the server's write timeout allows. It runs after Errors, so the timeout
+// replaces the error the cancellation caused.
+func (l *RequestLimits) Timeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := l.RouteTimeouts[route(c)]
+		if !ok {
+			timeout = l.HandlerTimeout
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		if ok {
+			// Best effort: writers that don't support deadlines keep the server's
+			_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(timeout + 5*time.Second))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.Error(apperror.Timeout(fmt.Sprintf("Request took longer than %s", timeout)))
+		}
+	}
+}
+
+// BodySize rejects request bodies larger than their route's limit with 413:
+// at once when Content-Length announces one, otherwise once handlers read past
+// the limit. It runs before the middleware reading bodies.
+func (l *RequestLimits) BodySize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, ok := l.RouteBodySizes[route(c)]
+		if !ok {
+			limit = l.MaxBodySize
+		}
+		if limit <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			Abort(c, apperror.TooLarge(fmt.Sprintf("Request body is larger than %d bytes", limit)))
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
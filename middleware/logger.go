@@ -0,0 +1,51 @@
+// Package middleware/logger.go
+package middleware
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/logging"
+)
+
+// Logger logs each request once it's served, at error level for server errors
+// and warn level for client errors. Requests under /organizations/:id carry the
+// organization ID in their context, so everything logged while serving them
+// does too. It runs after RequestID.
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.FullPath(), "/organizations/:id") {
+			if id, err := strconv.ParseUint(c.Param("id"), 10, 64); err == nil {
+				c.Request = c.Request.WithContext(logging.WithOrganizationID(c.Request.Context(), uint(id)))
+			}
+		}
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		attrs := []any{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if last := c.Errors.Last(); last != nil {
+			attrs = append(attrs, slog.String("error", last.Error()))
+		}
+		slog.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
@@ -0,0 +1,67 @@
+// Package middleware/maintenance.go
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+)
+
+// Maintenance modes
+const (
+	// MaintenanceOff serves every request
+	MaintenanceOff = "off"
+	// MaintenanceReadOnly serves reads, rejecting requests that change data
+	MaintenanceReadOnly = "read_only"
+	// MaintenanceFull rejects every request
+	MaintenanceFull = "full"
+)
+
+// MaintenanceModes lists the maintenance modes
+var MaintenanceModes = []string{MaintenanceOff, MaintenanceReadOnly, MaintenanceFull}
+
+// Default messages of the maintenance modes
+const (
+	maintenanceReadOnlyMessage = "The service is read-only during maintenance, try again later"
+	maintenanceFullMessage     = "The service is down for maintenance, try again later"
+)
+
+// Maintenance answers 503 while the API is under maintenance, with the message
+// of state or a default one: to every request in full mode, and to those that
+// change data in read-only mode. Admins, and paths starting with one of exempt,
+// such as health checks, are served regardless. It runs after auth.Identify.
+func Maintenance(state func() (mode, message string), exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mode, message := state()
+		if mode == "" || mode == MaintenanceOff || c.GetString("role") == models.AdminRole {
+			c.Next()
+			return
+		}
+		path := strings.TrimPrefix(c.Request.URL.Path, "/v1")
+		for _, prefix := range exempt {
+			if strings.HasPrefix(path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if mode == MaintenanceReadOnly {
+				c.Next()
+				return
+			}
+		}
+		if message == "" {
+			message = maintenanceFullMessage
+			if mode == MaintenanceReadOnly {
+				message = maintenanceReadOnlyMessage
+			}
+		}
+		Abort(c, apperror.Unavailable(message).With("maintenance", mode))
+	}
+}
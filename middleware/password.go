@@ -0,0 +1,34 @@
+// Package middleware/password.go
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+)
+
+// PasswordChange answers 403 to users who must change their password, such as
+// the bootstrap administrator, until they have. Paths starting with one of
+// exempt, such as the one changing the password, are served regardless. It
+// runs after auth.Identify.
+func PasswordChange(required func(ctx context.Context, userID uint) bool, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetUint("user_id")
+		if userID == 0 || !required(c.Request.Context(), userID) {
+			c.Next()
+			return
+		}
+		path := strings.TrimPrefix(c.Request.URL.Path, "/v1")
+		for _, prefix := range exempt {
+			if strings.HasPrefix(path, prefix) {
+				c.Next()
+				return
+			}
+		}
+		Abort(c, apperror.New(http.StatusForbidden, apperror.CodePasswordChangeRequired, "Change your password with PUT /v1/me/password first"))
+	}
+}
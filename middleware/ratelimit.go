@@ -0,0 +1,236 @@
+// Package middleware/ratelimit.go
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/access"
+	"github.com/4cecoder/saas/apperror"
+)
+
+// APIKeyHeader is the request header carrying an API key
+const APIKeyHeader = "X-API-Key"
+
+// Limit allows bursts of up to Requests requests, refilled evenly over Per. The
+// zero Limit doesn't limit.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Enabled reports whether the limit applies
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+// String formats the limit as ParseLimit reads it
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "0"
+	}
+	for unit, per := range limitUnits {
+		if l.Per == per {
+			return fmt.Sprintf("%d/%s", l.Requests, unit)
+		}
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// limitUnits are the shorthand periods of ParseLimit
+var limitUnits = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// ParseLimit reads a limit such as 100/m, 20/s or 1000/15m; 0 or off disable it
+func ParseLimit(s string) (Limit, error) {
+	if s == "0" || s == "off" {
+		return Limit{}, nil
+	}
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected e.g. 100/m", s)
+	}
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected a positive number of requests", s)
+	}
+	per, ok := limitUnits[period]
+	if !ok {
+		if per, err = time.ParseDuration(period); err != nil || per <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit period %q, use s, m, h or a duration such as 15m", period)
+		}
+	}
+	return Limit{Requests: requests, Per: per}, nil
+}
+
+// RateLimits are the limits of each kind of traffic
+type RateLimits struct {
+	// Anonymous limits each client IP without credentials
+	Anonymous Limit
+	// Authenticated limits each signed-in user
+	Authenticated Limit
+	// APIKey limits each API key
+	APIKey Limit
+	// Organization limits the API keys of an organization together
+	Organization Limit
+}
+
+// RateLimiter throttles clients with token buckets, answering 429 once a bucket is empty
+type RateLimiter struct {
+	Buckets Buckets
+	Access  *access.Access
+	// Exempt lists paths that are never limited, such as health checks
+	Exempt []string
+
+	mu        sync.Mutex
+	limits    RateLimits
+	lastLogAt time.Time
+}
+
+// NewRateLimiter creates a rate limiter identifying API keys through acc
+func NewRateLimiter(limits RateLimits, buckets Buckets, acc *access.Access) *RateLimiter {
+	return &RateLimiter{limits: limits, Buckets: buckets, Access: acc}
+}
+
+// Limits returns the limits in effect
+func (l *RateLimiter) Limits() RateLimits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limits
+}
+
+// SetLimits changes the limits of the requests that follow
+func (l *RateLimiter) SetLimits(limits RateLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+}
+
+// rateBucket is a bucket a request takes a token from
+type rateBucket struct {
+	key   string
+	limit Limit
+}
+
+// rateOutcome is the most constrained of the takes of a request
+type rateOutcome struct {
+	take  *Take
+	limit Limit
+}
+
+// Middleware limits requests by API key and its organization, by authenticated
+// user, or by client IP, in that order. Responses carry the X-RateLimit-Limit,
+// -Remaining and -Reset headers of the most constrained bucket; rejected ones
+// also carry Retry-After. It runs after auth.Identify. Requests are let through
+// when the buckets can't be reached.
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, path := range l.Exempt {
+			if c.Request.URL.Path == path {
+				c.Next()
+				return
+			}
+		}
+
+		var out rateOutcome
+		for _, b := range l.buckets(c, &out) {
+			if !l.take(c, b, &out) {
+				break
+			}
+		}
+		limited, limit := out.take, out.limit
+		if limited == nil {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(limited.Remaining))
+		h.Set("X-RateLimit-Reset", strconv.Itoa(seconds(limited.Reset)))
+		if !limited.Allowed {
+			h.Set("Retry-After", strconv.Itoa(max(seconds(limited.RetryAfter), 1)))
+			Abort(c, apperror.RateLimited("Too many requests, retry later"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// take takes a token from b, keeping the most constrained take in out, and
+// reports whether the request may go on
+func (l *RateLimiter) take(c *gin.Context, b rateBucket, out *rateOutcome) bool {
+	if !b.limit.Enabled() {
+		return true
+	}
+	t, err := l.Buckets.Take(c.Request.Context(), "ratelimit:"+b.key, b.limit)
+	if err != nil {
+		l.logFailure(err)
+		return true
+	}
+	if out.take == nil || !t.Allowed || t.Remaining < out.take.Remaining {
+		out.take, out.limit = &t, b.limit
+	}
+	return t.Allowed
+}
+
+// buckets returns the buckets limiting the request. An API key missing from
+// the access cache is looked up only after a token of the client IP's bucket,
+// so clients sending made-up keys are throttled before they reach the database;
+// that token is recorded in out, and a request it rejects gets no buckets.
+func (l *RateLimiter) buckets(c *gin.Context, out *rateOutcome) []rateBucket {
+	limits := l.Limits()
+	ip := rateBucket{key: "ip:" + c.ClientIP(), limit: limits.Anonymous}
+	if raw := c.GetHeader(APIKeyHeader); raw != "" {
+		ctx := c.Request.Context()
+		key, ok := l.Access.CachedAPIKey(ctx, raw)
+		charged := false
+		if !ok {
+			if !l.take(c, ip, out) {
+				return nil
+			}
+			charged = true
+			found, err := l.Access.APIKey(ctx, raw)
+			key, ok = found, err == nil
+		}
+		if ok && !key.Expired() {
+			// Valid keys are limited by their own buckets alone
+			*out = rateOutcome{}
+			return []rateBucket{
+				{key: fmt.Sprintf("key:%d", key.ID), limit: limits.APIKey},
+				{key: fmt.Sprintf("org:%d", key.OrganizationID), limit: limits.Organization},
+			}
+		}
+		// Unknown and expired keys are limited as anonymous traffic
+		if charged {
+			return nil
+		}
+		return []rateBucket{ip}
+	}
+	if userID, ok := c.Get("user_id"); ok {
+		return []rateBucket{{key: fmt.Sprintf("user:%d", userID), limit: limits.Authenticated}}
+	}
+	return []rateBucket{ip}
+}
+
+// logFailure logs that the buckets can't be reached, at most once a minute
+func (l *RateLimiter) logFailure(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if time.Since(l.lastLogAt) < time.Minute {
+		return
+	}
+	l.lastLogAt = time.Now()
+	slog.Warn("ratelimit: letting requests through, buckets unavailable", "error", err)
+}
+
+// seconds rounds a duration up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,81 @@
+// Package middleware/ratelimit_test.go
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/access"
+	"github.com/4cecoder/saas/cache"
+	"github.com/4cecoder/saas/middleware"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/testutil"
+	"github.com/4cecoder/saas/testutil/factories"
+)
+
+// keyLookups counts the queries of the api_keys table
+var keyLookups atomic.Int64
+
+func TestRateLimitThrottlesUnknownAPIKeysBeforeLookup(t *testing.T) {
+	db := testutil.OpenDB(t)
+	db.Callback().Query().After("gorm:query").Register("test:count_key_lookups", func(tx *gorm.DB) {
+		if tx.Statement.Table == "api_keys" {
+			keyLookups.Add(1)
+		}
+	})
+	org := factories.CreateOrganization(t, db)
+	user := factories.CreateMember(t, db, org)
+	valid := models.APIKey{UserID: user.ID, OrganizationID: org.ID, Name: "ci"}
+	if err := db.Create(&valid).Error; err != nil {
+		t.Fatalf("create API key: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	limits := middleware.RateLimits{
+		Anonymous: middleware.Limit{Requests: 3, Per: time.Minute},
+		APIKey:    middleware.Limit{Requests: 100, Per: time.Minute},
+	}
+	limiter := middleware.NewRateLimiter(limits, middleware.NewMemoryBuckets(), access.New(db, cache.NewMemory()))
+	r := gin.New()
+	r.Use(middleware.Errors(), limiter.Middleware())
+	r.GET("/things", func(c *gin.Context) { c.Status(http.StatusOK) })
+	get := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/things", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set(middleware.APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	before := keyLookups.Load()
+	for i := range 10 {
+		want := http.StatusOK
+		if i >= 3 {
+			want = http.StatusTooManyRequests
+		}
+		if got := get(fmt.Sprintf("made-up-%d", i)); got != want {
+			t.Errorf("request %d with a made-up key = %d, want %d", i, got, want)
+		}
+	}
+	if n := keyLookups.Load() - before; n != 3 {
+		t.Errorf("looked up %d made-up keys, want 3", n)
+	}
+
+	// A cached key is limited by its own bucket, not the exhausted one of the client IP
+	if _, err := limiter.Access.APIKey(t.Context(), valid.Key); err != nil {
+		t.Fatalf("look up valid key: %v", err)
+	}
+	for i := range 5 {
+		if got := get(valid.Key); got != http.StatusOK {
+			t.Fatalf("request %d with a valid key = %d, want 200", i, got)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package middleware/recovery.go
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/errorreport"
+)
+
+// Recovery turns a panicking handler into a 500 response, logging the panic with
+// its stack and reporting it to reporter along with the request. Server errors
+// handlers attach with c.Error are reported too. reporter may be nil. It runs
+// after Logger and before Errors.
+func Recovery(reporter *errorreport.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "handler panicked", "panic", r, "stack", string(debug.Stack()))
+			reporter.Report(c.Request.Context(), errorreport.Event{
+				Type:    "panic",
+				Message: fmt.Sprint(r),
+				Stack:   errorreport.PanicStack(),
+				Request: reportedRequest(c),
+			})
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			Abort(c, apperror.Internal(nil))
+		}()
+
+		c.Next()
+
+		if reporter == nil || c.Writer.Status() < 500 {
+			return
+		}
+		if last := c.Errors.Last(); last != nil {
+			if appErr := apperror.From(last.Err); appErr.Err != nil {
+				reporter.Report(c.Request.Context(), errorreport.Event{
+					Type:    fmt.Sprintf("%T", appErr.Err),
+					Message: appErr.Err.Error(),
+					Request: reportedRequest(c),
+				})
+			}
+		}
+	}
+}
+
+// reportedRequest describes the request of c for an error report
+func reportedRequest(c *gin.Context) *errorreport.Request {
+	headers := make(map[string]string, len(c.Request.Header))
+	for k := range c.Request.Header {
+		headers[k] = c.Request.Header.Get(k)
+	}
+	return &errorreport.Request{
+		Method:   c.Request.Method,
+		URL:      c.Request.URL.String(),
+		Route:    c.FullPath(),
+		ClientIP: c.ClientIP(),
+		Headers:  headers,
+	}
+}
@@ -0,0 +1,28 @@
+// Package middleware/requestid.go
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/4cecoder/saas/requestid"
+)
+
+// RequestID gives every request an ID, kept from the X-Request-ID header when the
+// client or a proxy sent a valid one. The ID is echoed in the response, stored as
+// "request_id" and carried by the request context to logs, audit entries and
+// outbound calls, and tagged on the span of the request.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		c.Set("request_id", id)
+		c.Request = c.Request.WithContext(requestid.With(c.Request.Context(), id))
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request_id", id))
+		c.Header(requestid.Header, id)
+		c.Next()
+	}
+}
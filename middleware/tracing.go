@@ -0,0 +1,14 @@
+// Package middleware/tracing.go
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Tracing records a server span for each request, continuing the trace of the
+// caller's traceparent header. It runs before RequestID, which tags the span,
+// and Logger, so request logs carry the trace ID.
+func Tracing(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service)
+}
@@ -0,0 +1,56 @@
+// Package middleware/version.go
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/4cecoder/saas/apperror"
+)
+
+// versionPrefix matches the API version prefix of a path, such as /v1
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+(/|$)`)
+
+// TrimVersion strips the API version prefix from a path or route, so /v1/users/:id
+// becomes /users/:id
+func TrimVersion(path string) string {
+	if loc := versionPrefix.FindStringIndex(path); loc != nil {
+		return "/" + path[loc[1]:]
+	}
+	return path
+}
+
+// Deprecation describes an endpoint or API version slated for removal
+type Deprecation struct {
+	// Since is when the endpoint was deprecated
+	Since time.Time
+	// Sunset is when the endpoint stops working; zero if no date has been set
+	Sunset time.Time
+	// Successor is the path prefix of the replacement, such as /v1; the request path is
+	// appended to it to link to the successor of each endpoint
+	Successor string
+}
+
+// Deprecated announces the deprecation through the Deprecation and Sunset headers, and
+// answers 410 Gone once the sunset date has passed
+func Deprecated(d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != "" {
+			c.Header("Link", "<"+d.Successor+c.Request.URL.Path+`>; rel="successor-version"`)
+		}
+
+		if !d.Sunset.IsZero() && time.Now().After(d.Sunset) {
+			Abort(c, apperror.Gone("This endpoint has been removed"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,351 @@
+// Package migrate/migrate.go
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"gorm.io/gorm"
+)
+
+// versionTable records the schema version golang-migrate reached. The runner
+// this package had before kept a row per migration in schema_migrations, which
+// adopt carries over.
+const versionTable = "schema_versions"
+
+// Migration is one version of the schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and whether it has been applied
+type Status struct {
+	Migration
+	Applied bool
+	// Dirty is set on the migration that failed halfway; it must be fixed by
+	// hand and the version forced before migrating again
+	Dirty bool
+}
+
+// Migrator applies versioned SQL migrations to a database with golang-migrate
+type Migrator struct {
+	DB *gorm.DB
+	// FS holds the migrations in golang-migrate's file format
+	FS         fs.FS
+	Migrations []Migration
+}
+
+// New loads the migrations in fsys for the database
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, FS: fsys, Migrations: migrations}, nil
+}
+
+// Load reads the <version>_<name>.up.sql and .down.sql files in the root of fsys,
+// ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	defer src.Close()
+
+	var migrations []Migration
+	version, err := src.First()
+	for err == nil {
+		m := Migration{Version: int(version)}
+		up, name, upErr := src.ReadUp(version)
+		if upErr != nil {
+			return nil, fmt.Errorf("migration %d has no up file", version)
+		}
+		m.Name = name
+		if m.Up, upErr = readAll(up); upErr != nil {
+			return nil, upErr
+		}
+		if down, _, downErr := src.ReadDown(version); downErr == nil {
+			if m.Down, downErr = readAll(down); downErr != nil {
+				return nil, downErr
+			}
+		}
+		migrations = append(migrations, m)
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+	return migrations, nil
+}
+
+// readAll reads and closes a migration file
+func readAll(r io.ReadCloser) (string, error) {
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	return string(b), err
+}
+
+// Status returns every migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var current int
+	var dirty bool
+	err := m.run(ctx, func(mg *migrate.Migrate) (err error) {
+		current, dirty, err = version(mg)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.Migrations))
+	for i, migration := range m.Migrations {
+		statuses[i] = Status{Migration: migration}
+		switch {
+		case migration.Version < current:
+			statuses[i].Applied = true
+		case migration.Version == current:
+			statuses[i].Applied, statuses[i].Dirty = !dirty, dirty
+		}
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that haven't been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations in order and returns the ones it applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.run(ctx, func(mg *migrate.Migrate) error {
+		before, _, err := version(mg)
+		if err != nil {
+			return err
+		}
+		upErr := mg.Up()
+		if errors.Is(upErr, migrate.ErrNoChange) {
+			upErr = nil
+		}
+
+		after, dirty, err := version(mg)
+		if err != nil {
+			return errors.Join(upErr, err)
+		}
+		if dirty {
+			after--
+		}
+		for _, migration := range m.Migrations {
+			if migration.Version > before && migration.Version <= after {
+				done = append(done, migration)
+			}
+		}
+		if upErr != nil {
+			return fmt.Errorf("apply migrations: %w", upErr)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns the
+// ones it reverted
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.run(ctx, func(mg *migrate.Migrate) error {
+		current, _, err := version(mg)
+		if err != nil {
+			return err
+		}
+		latest := m.latest(current, steps)
+		for _, migration := range latest {
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s can't be reverted, it has no down file", migration.Version, migration.Name)
+			}
+		}
+		if len(latest) == 0 {
+			return nil
+		}
+
+		downErr := mg.Steps(-len(latest))
+		after, _, err := version(mg)
+		if err != nil {
+			return errors.Join(downErr, err)
+		}
+		for _, migration := range latest {
+			if migration.Version > after {
+				done = append(done, migration)
+			}
+		}
+		if downErr != nil {
+			return fmt.Errorf("revert migrations: %w", downErr)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Latest returns the last steps applied migrations, newest first, which Down would revert
+func (m *Migrator) Latest(ctx context.Context, steps int) ([]Migration, error) {
+	var latest []Migration
+	err := m.run(ctx, func(mg *migrate.Migrate) error {
+		current, _, err := version(mg)
+		latest = m.latest(current, steps)
+		return err
+	})
+	return latest, err
+}
+
+// Force records version as the current one and clears the dirty flag, once a
+// failed migration has been fixed by hand
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.run(ctx, func(mg *migrate.Migrate) error {
+		return mg.Force(version)
+	})
+}
+
+// latest returns the last steps migrations up to version, newest first
+func (m *Migrator) latest(version, steps int) []Migration {
+	var latest []Migration
+	for i := len(m.Migrations) - 1; i >= 0 && len(latest) < steps; i-- {
+		if m.Migrations[i].Version <= version {
+			latest = append(latest, m.Migrations[i])
+		}
+	}
+	return latest
+}
+
+// version returns the current schema version, 0 before the first migration
+func version(mg *migrate.Migrate) (int, bool, error) {
+	v, dirty, err := mg.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return int(v), dirty, err
+}
+
+// run connects golang-migrate to the database and the migrations for fn. The
+// Postgres and MySQL drivers lock the database while migrating, so instances
+// starting at the same time don't apply the same migration twice.
+func (m *Migrator) run(ctx context.Context, fn func(mg *migrate.Migrate) error) error {
+	sqlDB, err := m.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	// The Postgres and MySQL drivers get a connection of their own, which
+	// closing them releases; the SQLite driver would close the whole database
+	var driver database.Driver
+	var conn *sql.Conn
+	dialect := m.DB.Dialector.Name()
+	switch dialect {
+	case "postgres", "mysql":
+		if conn, err = sqlDB.Conn(ctx); err != nil {
+			return err
+		}
+		defer conn.Close()
+		if dialect == "postgres" {
+			driver, err = postgres.WithConnection(ctx, conn, &postgres.Config{MigrationsTable: versionTable})
+		} else {
+			driver, err = mysql.WithConnection(ctx, conn, &mysql.Config{MigrationsTable: versionTable})
+		}
+	case "sqlite":
+		driver, err = sqlite3.WithInstance(sqlDB, &sqlite3.Config{MigrationsTable: versionTable})
+	default:
+		return fmt.Errorf("can't migrate %s databases", dialect)
+	}
+	if err != nil {
+		return fmt.Errorf("prepare %s: %w", versionTable, err)
+	}
+
+	src, err := iofs.New(m.FS, ".")
+	if err != nil {
+		return fmt.Errorf("read migrations: %w", err)
+	}
+	defer src.Close()
+
+	mg, err := migrate.NewWithInstance("iofs", src, dialect, driver)
+	if err != nil {
+		return err
+	}
+	if err := m.adopt(ctx, mg); err != nil {
+		return err
+	}
+	return fn(mg)
+}
+
+// adopt carries the version of a database migrated before golang-migrate over
+// to it, from the highest version recorded in schema_migrations
+func (m *Migrator) adopt(ctx context.Context, mg *migrate.Migrate) error {
+	if v, _, err := version(mg); err != nil || v != 0 {
+		return err
+	}
+	db := m.DB.WithContext(ctx)
+	if !db.Migrator().HasColumn("schema_migrations", "applied_at") {
+		return nil
+	}
+
+	var legacy int
+	if err := db.Table("schema_migrations").Select("COALESCE(MAX(version), 0)").Scan(&legacy).Error; err != nil {
+		return fmt.Errorf("read schema_migrations: %w", err)
+	}
+	if legacy == 0 {
+		return nil
+	}
+	return mg.Force(legacy)
+}
+
+// Create writes empty up and down files for a new migration to dir, numbered
+// after the last migration there, and returns their paths
+func Create(dir, name string) (up, down string, err error) {
+	if name == "" || strings.ContainsAny(name, " /.") {
+		return "", "", fmt.Errorf("invalid migration name %q, use e.g. add_user_phone", name)
+	}
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	version := 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+	prefix := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, name))
+	up, down = prefix+".up.sql", prefix+".down.sql"
+
+	if err := os.WriteFile(up, []byte("-- "+name+"\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- Revert "+name+"\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
@@ -0,0 +1,39 @@
+// Package migrations/migrations.go
+//
+// Package migrations holds the versioned SQL migrations of the database schema,
+// one directory per database dialect, in golang-migrate's file format. Each
+// version has a <version>_<name>.up.sql file and a matching .down.sql file that
+// reverts it; versions are applied in numeric order by the migrate package.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/migrate"
+)
+
+// files holds the migration files of every dialect
+//
+//go:embed postgres/*.sql mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// For returns the migrations of a database dialect, as named by gorm.Dialector.Name
+func For(dialect string) (fs.FS, error) {
+	if matches, _ := fs.Glob(files, dialect+"/*.sql"); len(matches) == 0 {
+		return nil, fmt.Errorf("no migrations for %s databases", dialect)
+	}
+	return fs.Sub(files, dialect)
+}
+
+// New returns a migrator applying the migrations of the database's dialect
+func New(db *gorm.DB) (*migrate.Migrator, error) {
+	fsys, err := For(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, fsys)
+}
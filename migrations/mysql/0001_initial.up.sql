@@ -0,0 +1,521 @@
+-- Schema of the application at the introduction of versioned migrations
+
+CREATE TABLE `users` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `email` varchar(191),
+    `password` longtext,
+    `password_hash` longtext,
+    `name` longtext,
+    `verification_code` longtext,
+    `verified` boolean,
+    `locale` longtext,
+    `timezone` longtext,
+    `language` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_users_deleted_at` (`deleted_at`),
+    CONSTRAINT `uni_users_email` UNIQUE (`email`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `subscription_plans` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext,
+    `description` longtext,
+    `price` double,
+    `currency` longtext,
+    `interval` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_subscription_plans_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `organizations` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext,
+    `subscription_plan_id` bigint unsigned,
+    `logo_url` longtext,
+    `theme_color` longtext,
+    `audit_log_retention_days` bigint,
+    `activity_log_retention_days` bigint,
+    `seat_limit` bigint,
+    PRIMARY KEY (`id`),
+    INDEX `idx_organizations_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_organizations_subscription_plan` FOREIGN KEY (`subscription_plan_id`) REFERENCES `subscription_plans`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `user_organizations` (
+    `organization_id` bigint unsigned,
+    `user_id` bigint unsigned,
+    PRIMARY KEY (`organization_id`,`user_id`),
+    CONSTRAINT `fk_user_organizations_organization` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`),
+    CONSTRAINT `fk_user_organizations_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `roles` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_roles_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `user_roles` (
+    `user_id` bigint unsigned,
+    `role_id` bigint unsigned,
+    PRIMARY KEY (`user_id`,`role_id`),
+    CONSTRAINT `fk_user_roles_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `fk_user_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `permissions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext,
+    `description` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_permissions_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `user_permissions` (
+    `user_id` bigint unsigned,
+    `permission_id` bigint unsigned,
+    PRIMARY KEY (`user_id`,`permission_id`),
+    CONSTRAINT `fk_user_permissions_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `fk_user_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `subscriptions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `subscription_plan_id` bigint unsigned,
+    `status` longtext,
+    `start_date` datetime(3) NULL,
+    `end_date` datetime(3) NULL,
+    `payment_method` longtext,
+    `last_payment_date` datetime(3) NULL,
+    `next_billing_date` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_subscriptions_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_subscriptions_subscription_plan` FOREIGN KEY (`subscription_plan_id`) REFERENCES `subscription_plans`(`id`),
+    CONSTRAINT `fk_organizations_subscriptions` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `features` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext,
+    `description` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_features_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `subscription_plan_features` (
+    `subscription_plan_id` bigint unsigned,
+    `feature_id` bigint unsigned,
+    PRIMARY KEY (`subscription_plan_id`,`feature_id`),
+    CONSTRAINT `fk_subscription_plan_features_subscription_plan` FOREIGN KEY (`subscription_plan_id`) REFERENCES `subscription_plans`(`id`),
+    CONSTRAINT `fk_subscription_plan_features_feature` FOREIGN KEY (`feature_id`) REFERENCES `features`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `role_permissions` (
+    `role_id` bigint unsigned,
+    `permission_id` bigint unsigned,
+    PRIMARY KEY (`role_id`,`permission_id`),
+    CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`),
+    CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `domains` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `domain` varchar(191),
+    `verified` boolean,
+    PRIMARY KEY (`id`),
+    INDEX `idx_domains_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_organizations_domains` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`) ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT `uni_domains_domain` UNIQUE (`domain`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `seats` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `user_id` bigint unsigned,
+    `status` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_seats_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_users_seats` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `fk_organizations_seats` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`) ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `seat_roles` (
+    `seat_id` bigint unsigned,
+    `role_id` bigint unsigned,
+    PRIMARY KEY (`seat_id`,`role_id`),
+    CONSTRAINT `fk_seat_roles_seat` FOREIGN KEY (`seat_id`) REFERENCES `seats`(`id`),
+    CONSTRAINT `fk_seat_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `idempotency_keys` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `idempotency_key` varchar(255),
+    `user_id` bigint unsigned,
+    `request_hash` longtext,
+    `completed` boolean,
+    `status` bigint,
+    `content_type` longtext,
+    `body` longblob,
+    `created_at` datetime(3) NULL,
+    `expires_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_idempotency_keys_expires_at` (`expires_at`),
+    UNIQUE INDEX `idx_idempotency_scope` (`idempotency_key`,`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `audit_logs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `organization_id` bigint unsigned,
+    `action` longtext,
+    `resource_type` longtext,
+    `resource_id` bigint unsigned,
+    `timestamp` datetime(3) NULL,
+    `changes` json,
+    `prev_hash` longtext,
+    `hash` varchar(191),
+    PRIMARY KEY (`id`),
+    INDEX `idx_audit_logs_deleted_at` (`deleted_at`),
+    INDEX `idx_audit_logs_hash` (`hash`),
+    INDEX `idx_audit_logs_organization_id` (`organization_id`),
+    INDEX `idx_audit_logs_timestamp` (`timestamp`),
+    INDEX `idx_audit_logs_user_id` (`user_id`),
+    CONSTRAINT `fk_organizations_audit_logs` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `payment_transactions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `subscription_id` bigint unsigned,
+    `amount` double,
+    `currency` longtext,
+    `status` longtext,
+    `gateway` longtext,
+    `gateway_id` longtext,
+    `timestamp` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_payment_transactions_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_subscriptions_transactions` FOREIGN KEY (`subscription_id`) REFERENCES `subscriptions`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `notification_preferences` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `email_enabled` boolean,
+    `sms_enabled` boolean,
+    `in_app_enabled` boolean,
+    `billing_emails` boolean,
+    `product_emails` boolean,
+    `marketing_emails` boolean,
+    PRIMARY KEY (`id`),
+    INDEX `idx_notification_preferences_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_users_notification_prefs` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `activity_logs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `organization_id` bigint unsigned,
+    `activity_type` varchar(191),
+    `timestamp` datetime(3) NULL,
+    `metadata` json,
+    PRIMARY KEY (`id`),
+    INDEX `idx_activity_logs_activity_type` (`activity_type`),
+    INDEX `idx_activity_logs_deleted_at` (`deleted_at`),
+    INDEX `idx_activity_logs_organization_id` (`organization_id`),
+    INDEX `idx_activity_logs_timestamp` (`timestamp`),
+    INDEX `idx_activity_logs_user_id` (`user_id`),
+    CONSTRAINT `fk_organizations_activity_logs` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`),
+    CONSTRAINT `fk_users_activity_logs` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `api_keys` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `organization_id` bigint unsigned,
+    `key` varchar(191),
+    `name` longtext,
+    `permissions` json,
+    `expires_at` datetime(3) NULL,
+    `last_used_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_api_keys_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_organizations_api_keys` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`),
+    CONSTRAINT `uni_api_keys_key` UNIQUE (`key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `workflows` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext,
+    `description` longtext,
+    `steps` json,
+    `triggers` json,
+    `organization_id` bigint unsigned,
+    `creator_id` bigint unsigned,
+    `enabled` boolean,
+    `version` bigint,
+    PRIMARY KEY (`id`),
+    INDEX `idx_workflows_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_organizations_workflows` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `reports` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext,
+    `description` longtext,
+    `definition` json,
+    `organization_id` bigint unsigned,
+    `creator_id` bigint unsigned,
+    `schedule` longtext,
+    `recipients` json,
+    `format` longtext,
+    `last_run_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_reports_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `scheduled_jobs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext,
+    `handler` varchar(191),
+    `schedule` longtext,
+    `timezone` longtext,
+    `misfire_policy` longtext,
+    `enabled` boolean,
+    `payload` longtext,
+    `last_run_at` datetime(3) NULL,
+    `next_run_at` datetime(3) NULL,
+    `last_error` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_scheduled_jobs_deleted_at` (`deleted_at`),
+    INDEX `idx_scheduled_jobs_handler` (`handler`),
+    INDEX `idx_scheduled_jobs_next_run_at` (`next_run_at`),
+    UNIQUE INDEX `idx_scheduled_jobs_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `scheduler_leases` (
+    `name` varchar(191),
+    `holder` longtext,
+    `expires_at` datetime(3) NULL,
+    PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `siem_integrations` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `type` longtext,
+    `endpoint` longtext,
+    `token` longtext,
+    `enabled` boolean,
+    `last_error` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_siem_integrations_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_siem_integrations_organization_id` (`organization_id`),
+    CONSTRAINT `fk_siem_integrations_organization` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `audit_chain_heads` (
+    `organization_id` bigint unsigned AUTO_INCREMENT,
+    `hash` longtext,
+    `last_entry_id` bigint unsigned,
+    `pruned_hash` longtext,
+    PRIMARY KEY (`organization_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `report_runs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `report_id` bigint unsigned,
+    `organization_id` bigint unsigned,
+    `triggered_by` bigint unsigned,
+    `status` longtext,
+    `started_at` datetime(3) NULL,
+    `finished_at` datetime(3) NULL,
+    `row_count` bigint,
+    `truncated` boolean,
+    `columns` json,
+    `rows` json,
+    `error` longtext,
+    `scheduled` boolean,
+    `delivered_at` datetime(3) NULL,
+    `delivery_error` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_report_runs_deleted_at` (`deleted_at`),
+    INDEX `idx_report_runs_organization_id` (`organization_id`),
+    INDEX `idx_report_runs_report_id` (`report_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `report_exports` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `report_id` bigint unsigned,
+    `run_id` bigint unsigned,
+    `organization_id` bigint unsigned,
+    `requested_by` bigint unsigned,
+    `format` longtext,
+    `filename` longtext,
+    `status` longtext,
+    `storage_key` longtext,
+    `size` bigint,
+    `error` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_report_exports_deleted_at` (`deleted_at`),
+    INDEX `idx_report_exports_organization_id` (`organization_id`),
+    INDEX `idx_report_exports_report_id` (`report_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `workflow_runs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `workflow_id` bigint unsigned,
+    `workflow_version` bigint,
+    `organization_id` bigint unsigned,
+    `triggered_by` bigint unsigned,
+    `status` varchar(191),
+    `current_step` bigint,
+    `input` json,
+    `definition` json,
+    `error` longtext,
+    `started_at` datetime(3) NULL,
+    `finished_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_workflow_runs_deleted_at` (`deleted_at`),
+    INDEX `idx_workflow_runs_organization_id` (`organization_id`),
+    INDEX `idx_workflow_runs_started_at` (`started_at`),
+    INDEX `idx_workflow_runs_status` (`status`),
+    INDEX `idx_workflow_runs_workflow_id` (`workflow_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `workflow_step_runs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `run_id` bigint unsigned,
+    `position` bigint,
+    `name` longtext,
+    `status` longtext,
+    `attempts` bigint,
+    `output` json,
+    `error` longtext,
+    `started_at` datetime(3) NULL,
+    `finished_at` datetime(3) NULL,
+    `resume_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_workflow_step_runs_deleted_at` (`deleted_at`),
+    INDEX `idx_workflow_step_runs_position` (`position`),
+    INDEX `idx_workflow_step_runs_resume_at` (`resume_at`),
+    INDEX `idx_workflow_step_runs_run_id` (`run_id`),
+    CONSTRAINT `fk_workflow_runs_steps` FOREIGN KEY (`run_id`) REFERENCES `workflow_runs`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `workflow_approvals` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `run_id` bigint unsigned,
+    `step_run_id` bigint unsigned,
+    `position` bigint,
+    `organization_id` bigint unsigned,
+    `approver_id` bigint unsigned,
+    `delegated_from_id` bigint unsigned,
+    `status` varchar(191),
+    `message` longtext,
+    `comment` longtext,
+    `decided_at` datetime(3) NULL,
+    `reminded_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_workflow_approvals_approver_id` (`approver_id`),
+    INDEX `idx_workflow_approvals_deleted_at` (`deleted_at`),
+    INDEX `idx_workflow_approvals_organization_id` (`organization_id`),
+    INDEX `idx_workflow_approvals_run_id` (`run_id`),
+    INDEX `idx_workflow_approvals_status` (`status`),
+    INDEX `idx_workflow_approvals_step_run_id` (`step_run_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `workflow_versions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `workflow_id` bigint unsigned,
+    `version` bigint,
+    `organization_id` bigint unsigned,
+    `name` longtext,
+    `description` longtext,
+    `steps` json,
+    `triggers` json,
+    `created_by` bigint unsigned,
+    `rolled_back_from` bigint,
+    PRIMARY KEY (`id`),
+    INDEX `idx_workflow_versions_deleted_at` (`deleted_at`),
+    INDEX `idx_workflow_versions_organization_id` (`organization_id`),
+    UNIQUE INDEX `idx_workflow_version` (`workflow_id`,`version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Composite indexes backing cursor pagination of the log tables
+CREATE INDEX `idx_audit_logs_created_at_id` ON `audit_logs` (`created_at`, `id`);
+CREATE INDEX `idx_activity_logs_created_at_id` ON `activity_logs` (`created_at`, `id`);
@@ -0,0 +1 @@
+ALTER TABLE `audit_logs` ADD COLUMN `request_id` varchar(128), ADD INDEX `idx_audit_logs_request_id` (`request_id`);
@@ -0,0 +1,20 @@
+CREATE TABLE `runtime_settings` (
+    `setting_key` varchar(191),
+    `value` longtext,
+    `updated_by` bigint unsigned,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`setting_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `runtime_setting_changes` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `setting_key` varchar(191),
+    `old_value` longtext,
+    `new_value` longtext,
+    `user_id` bigint unsigned,
+    `request_id` varchar(128),
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_runtime_setting_changes_setting_key` (`setting_key`),
+    INDEX `idx_runtime_setting_changes_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
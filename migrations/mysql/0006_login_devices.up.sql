@@ -0,0 +1,15 @@
+CREATE TABLE `login_devices` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `fingerprint` varchar(64),
+    `location` varchar(64),
+    `user_agent` longtext,
+    `ip` varchar(64),
+    `last_seen_at` datetime(3) NULL,
+    `alert_token_hash` varchar(64),
+    `alert_expires_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_login_devices_user_id` (`user_id`),
+    INDEX `idx_login_devices_alert_token_hash` (`alert_token_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
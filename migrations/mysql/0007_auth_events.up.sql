@@ -0,0 +1,15 @@
+CREATE TABLE `auth_events` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned,
+    `action` varchar(64),
+    `email` longtext,
+    `ip` varchar(64),
+    `user_agent` longtext,
+    `details` json,
+    `request_id` varchar(128),
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_auth_events_user_id` (`user_id`),
+    INDEX `idx_auth_events_action` (`action`),
+    INDEX `idx_auth_events_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
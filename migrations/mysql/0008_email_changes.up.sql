@@ -0,0 +1,15 @@
+CREATE TABLE `email_changes` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `user_id` bigint unsigned,
+    `new_email` varchar(254),
+    `created_at` datetime(3) NULL,
+    `expires_at` datetime(3) NULL,
+    `old_token_hash` varchar(64),
+    `new_token_hash` varchar(64),
+    `old_confirmed_at` datetime(3) NULL,
+    `new_confirmed_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_email_changes_user_id` (`user_id`),
+    INDEX `idx_email_changes_old_token_hash` (`old_token_hash`),
+    INDEX `idx_email_changes_new_token_hash` (`new_token_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE `users` ADD COLUMN `suspended_at` datetime(3) NULL;
+ALTER TABLE `users` ADD COLUMN `suspension_reason` longtext;
+ALTER TABLE `seats` ADD COLUMN `suspended_at` datetime(3) NULL;
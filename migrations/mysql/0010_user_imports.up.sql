@@ -0,0 +1,38 @@
+CREATE TABLE `invitations` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `user_id` bigint unsigned,
+    `seat_id` bigint unsigned,
+    `email` varchar(254),
+    `invited_by` bigint unsigned,
+    `token_hash` varchar(64),
+    `expires_at` datetime(3) NULL,
+    `accepted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_invitations_deleted_at` (`deleted_at`),
+    INDEX `idx_invitations_organization_id` (`organization_id`),
+    INDEX `idx_invitations_user_id` (`user_id`),
+    INDEX `idx_invitations_token_hash` (`token_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `user_imports` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `requested_by` bigint unsigned,
+    `filename` longtext,
+    `invite` boolean,
+    `status` longtext,
+    `total` bigint,
+    `succeeded` bigint,
+    `failed` bigint,
+    `rows` json,
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_imports_deleted_at` (`deleted_at`),
+    INDEX `idx_user_imports_organization_id` (`organization_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
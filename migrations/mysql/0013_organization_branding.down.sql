@@ -0,0 +1,5 @@
+ALTER TABLE `organizations` DROP INDEX `idx_organizations_domain`, DROP COLUMN `domain`;
+ALTER TABLE `organizations` DROP COLUMN `email_footer`;
+ALTER TABLE `organizations` DROP COLUMN `favicon_key`;
+ALTER TABLE `organizations` DROP COLUMN `logo_key`;
+ALTER TABLE `organizations` DROP COLUMN `favicon_url`;
@@ -0,0 +1,5 @@
+ALTER TABLE `organizations` ADD COLUMN `favicon_url` longtext;
+ALTER TABLE `organizations` ADD COLUMN `logo_key` longtext;
+ALTER TABLE `organizations` ADD COLUMN `favicon_key` longtext;
+ALTER TABLE `organizations` ADD COLUMN `email_footer` longtext;
+ALTER TABLE `organizations` ADD COLUMN `domain` varchar(253), ADD UNIQUE INDEX `idx_organizations_domain` (`domain`);
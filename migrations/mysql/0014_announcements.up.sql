@@ -0,0 +1,22 @@
+CREATE TABLE `announcements` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `title` varchar(200),
+    `body` longtext,
+    `link` varchar(2048),
+    `published_at` datetime(3) NULL,
+    `expires_at` datetime(3) NULL,
+    `created_by` bigint unsigned,
+    PRIMARY KEY (`id`),
+    INDEX `idx_announcements_deleted_at` (`deleted_at`),
+    INDEX `idx_announcements_published_at` (`published_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `announcement_reads` (
+    `user_id` bigint unsigned,
+    `announcement_id` bigint unsigned,
+    `read_at` datetime(3) NULL,
+    PRIMARY KEY (`user_id`,`announcement_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,20 @@
+CREATE TABLE `feedbacks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `organization_id` bigint unsigned,
+    `kind` varchar(16),
+    `score` bigint,
+    `message` longtext,
+    `category` varchar(64),
+    `page` varchar(2048),
+    `user_agent` varchar(512),
+    `metadata` json,
+    PRIMARY KEY (`id`),
+    INDEX `idx_feedbacks_deleted_at` (`deleted_at`),
+    INDEX `idx_feedbacks_user_id` (`user_id`),
+    INDEX `idx_feedbacks_organization_id` (`organization_id`),
+    INDEX `idx_feedbacks_kind` (`kind`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,31 @@
+CREATE TABLE `tickets` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `user_id` bigint unsigned,
+    `subject` varchar(200),
+    `status` varchar(16),
+    `last_message_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_tickets_deleted_at` (`deleted_at`),
+    INDEX `idx_tickets_organization_id` (`organization_id`),
+    INDEX `idx_tickets_user_id` (`user_id`),
+    INDEX `idx_tickets_status` (`status`),
+    INDEX `idx_tickets_last_message_at` (`last_message_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `ticket_messages` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `ticket_id` bigint unsigned,
+    `user_id` bigint unsigned,
+    `body` longtext,
+    `staff` boolean DEFAULT false,
+    PRIMARY KEY (`id`),
+    INDEX `idx_ticket_messages_deleted_at` (`deleted_at`),
+    INDEX `idx_ticket_messages_ticket_id` (`ticket_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+ALTER TABLE `reports` DROP INDEX `idx_reports_team_id`, DROP COLUMN `team_id`;
+ALTER TABLE `workflows` DROP INDEX `idx_workflows_team_id`, DROP COLUMN `team_id`;
+DROP TABLE IF EXISTS `team_member_roles`;
+DROP TABLE IF EXISTS `team_members`;
+DROP TABLE IF EXISTS `teams`;
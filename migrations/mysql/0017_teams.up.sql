@@ -0,0 +1,39 @@
+CREATE TABLE `teams` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `name` varchar(100),
+    `description` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_teams_deleted_at` (`deleted_at`),
+    INDEX `idx_teams_organization_id` (`organization_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `team_members` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `team_id` bigint unsigned,
+    `user_id` bigint unsigned,
+    PRIMARY KEY (`id`),
+    INDEX `idx_team_members_deleted_at` (`deleted_at`),
+    INDEX `idx_team_members_organization_id` (`organization_id`),
+    INDEX `idx_team_members_user_id` (`user_id`),
+    UNIQUE INDEX `idx_team_members_team_user` (`team_id`,`user_id`),
+    CONSTRAINT `fk_teams_members` FOREIGN KEY (`team_id`) REFERENCES `teams`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `team_member_roles` (
+    `team_member_id` bigint unsigned,
+    `role_id` bigint unsigned,
+    PRIMARY KEY (`team_member_id`,`role_id`),
+    CONSTRAINT `fk_team_member_roles_team_member` FOREIGN KEY (`team_member_id`) REFERENCES `team_members`(`id`),
+    CONSTRAINT `fk_team_member_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE `workflows` ADD COLUMN `team_id` bigint unsigned, ADD INDEX `idx_workflows_team_id` (`team_id`);
+ALTER TABLE `reports` ADD COLUMN `team_id` bigint unsigned, ADD INDEX `idx_reports_team_id` (`team_id`);
@@ -0,0 +1,34 @@
+CREATE TABLE `custom_fields` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `entity_type` varchar(32),
+    `key` varchar(64),
+    `name` varchar(100),
+    `type` varchar(16),
+    `options` longtext,
+    `required` boolean,
+    PRIMARY KEY (`id`),
+    INDEX `idx_custom_fields_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_custom_fields_org_entity_key` (`organization_id`,`entity_type`,`key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `custom_field_values` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `custom_field_id` bigint unsigned,
+    `entity_type` varchar(32),
+    `entity_id` bigint unsigned,
+    `key` varchar(64),
+    `value` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_custom_field_values_deleted_at` (`deleted_at`),
+    INDEX `idx_custom_field_values_organization_id` (`organization_id`),
+    INDEX `idx_custom_field_values_entity` (`entity_type`,`entity_id`),
+    UNIQUE INDEX `idx_custom_field_values_field_entity` (`custom_field_id`,`entity_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
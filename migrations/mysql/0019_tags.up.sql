@@ -0,0 +1,28 @@
+CREATE TABLE `tags` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `name` varchar(50),
+    `color` varchar(7),
+    PRIMARY KEY (`id`),
+    INDEX `idx_tags_deleted_at` (`deleted_at`),
+    INDEX `idx_tags_organization_id` (`organization_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `taggings` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `tag_id` bigint unsigned,
+    `taggable_type` varchar(32),
+    `taggable_id` bigint unsigned,
+    PRIMARY KEY (`id`),
+    INDEX `idx_taggings_deleted_at` (`deleted_at`),
+    INDEX `idx_taggings_organization_id` (`organization_id`),
+    INDEX `idx_taggings_taggable` (`taggable_type`,`taggable_id`),
+    UNIQUE INDEX `idx_taggings_tag_taggable` (`tag_id`,`taggable_type`,`taggable_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,20 @@
+CREATE TABLE `comments` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `commentable_type` varchar(32),
+    `commentable_id` bigint unsigned,
+    `parent_id` bigint unsigned,
+    `user_id` bigint unsigned,
+    `body` longtext,
+    `mentions` longtext,
+    `edited_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_comments_deleted_at` (`deleted_at`),
+    INDEX `idx_comments_organization_id` (`organization_id`),
+    INDEX `idx_comments_commentable` (`commentable_type`,`commentable_id`),
+    INDEX `idx_comments_parent_id` (`parent_id`),
+    INDEX `idx_comments_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE `subscription_plans` DROP COLUMN `attachment_types`;
+ALTER TABLE `subscription_plans` DROP COLUMN `max_attachment_size`;
+DROP TABLE IF EXISTS `attachments`;
@@ -0,0 +1,21 @@
+CREATE TABLE `attachments` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `attachable_type` varchar(32),
+    `attachable_id` bigint unsigned,
+    `user_id` bigint unsigned,
+    `filename` longtext,
+    `content_type` longtext,
+    `size` bigint,
+    `storage_key` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_attachments_deleted_at` (`deleted_at`),
+    INDEX `idx_attachments_organization_id` (`organization_id`),
+    INDEX `idx_attachments_attachable` (`attachable_type`,`attachable_id`),
+    INDEX `idx_attachments_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+ALTER TABLE `subscription_plans` ADD COLUMN `max_attachment_size` bigint;
+ALTER TABLE `subscription_plans` ADD COLUMN `attachment_types` longtext;
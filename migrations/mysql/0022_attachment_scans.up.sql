@@ -0,0 +1,5 @@
+ALTER TABLE `attachments` ADD COLUMN `scan_status` varchar(16);
+ALTER TABLE `attachments` ADD COLUMN `threat` longtext;
+ALTER TABLE `attachments` ADD COLUMN `scanned_at` datetime(3) NULL;
+ALTER TABLE `attachments` ADD COLUMN `cleared_by_id` bigint unsigned;
+UPDATE `attachments` SET `scan_status` = 'unscanned';
@@ -0,0 +1,19 @@
+CREATE TABLE `organization_exports` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `requested_by` bigint unsigned,
+    `status` varchar(16),
+    `progress` bigint,
+    `section` longtext,
+    `filename` longtext,
+    `storage_key` longtext,
+    `size` bigint,
+    `error` longtext,
+    `expires_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_organization_exports_deleted_at` (`deleted_at`),
+    INDEX `idx_organization_exports_organization_id` (`organization_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
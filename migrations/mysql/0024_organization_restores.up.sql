@@ -0,0 +1,20 @@
+CREATE TABLE `organization_restores` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `target_organization_id` bigint unsigned,
+    `source_organization_id` bigint unsigned,
+    `requested_by` bigint unsigned,
+    `name` longtext,
+    `filename` longtext,
+    `status` varchar(16),
+    `error` longtext,
+    `users_created` bigint,
+    `users_matched` bigint,
+    `restored` longtext,
+    `warnings` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_organization_restores_deleted_at` (`deleted_at`),
+    INDEX `idx_organization_restores_target_organization_id` (`target_organization_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,30 @@
+CREATE TABLE `retention_rules` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `resource` varchar(32),
+    `days` bigint,
+    `action` varchar(16),
+    PRIMARY KEY (`id`),
+    INDEX `idx_retention_rules_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_retention_rules_org_resource` (`organization_id`,`resource`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `retention_removals` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `organization_id` bigint unsigned,
+    `resource` varchar(32),
+    `action` varchar(16),
+    `days` bigint,
+    `cutoff` datetime(3) NULL,
+    `count` bigint,
+    `archive_keys` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_retention_removals_deleted_at` (`deleted_at`),
+    INDEX `idx_retention_removals_organization_id` (`organization_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
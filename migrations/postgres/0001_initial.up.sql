@@ -0,0 +1,522 @@
+-- Schema of the application as created by AutoMigrate before versioned migrations.
+-- IF NOT EXISTS lets it run over databases that AutoMigrate already set up.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "email" text,
+    "password" text,
+    "password_hash" text,
+    "name" text,
+    "verification_code" text,
+    "verified" boolean,
+    "locale" text,
+    "timezone" text,
+    "language" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "roles" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_roles_deleted_at" ON "roles" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "user_roles" (
+    "user_id" bigint,
+    "role_id" bigint,
+    PRIMARY KEY ("user_id","role_id"),
+    CONSTRAINT "fk_user_roles_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_user_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "permissions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "description" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_permissions_deleted_at" ON "permissions" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "user_permissions" (
+    "user_id" bigint,
+    "permission_id" bigint,
+    PRIMARY KEY ("user_id","permission_id"),
+    CONSTRAINT "fk_user_permissions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_user_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "subscription_plans" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "description" text,
+    "price" decimal,
+    "currency" text,
+    "interval" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_subscription_plans_deleted_at" ON "subscription_plans" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "organizations" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "subscription_plan_id" bigint,
+    "logo_url" text,
+    "theme_color" text,
+    "audit_log_retention_days" bigint,
+    "activity_log_retention_days" bigint,
+    "seat_limit" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_organizations_subscription_plan" FOREIGN KEY ("subscription_plan_id") REFERENCES "subscription_plans"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_organizations_deleted_at" ON "organizations" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "user_organizations" (
+    "organization_id" bigint,
+    "user_id" bigint,
+    PRIMARY KEY ("organization_id","user_id"),
+    CONSTRAINT "fk_user_organizations_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id"),
+    CONSTRAINT "fk_user_organizations_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "subscriptions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "subscription_plan_id" bigint,
+    "status" text,
+    "start_date" timestamptz,
+    "end_date" timestamptz,
+    "payment_method" text,
+    "last_payment_date" timestamptz,
+    "next_billing_date" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_subscriptions_subscription_plan" FOREIGN KEY ("subscription_plan_id") REFERENCES "subscription_plans"("id"),
+    CONSTRAINT "fk_organizations_subscriptions" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_subscriptions_deleted_at" ON "subscriptions" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "features" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "description" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_features_deleted_at" ON "features" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "subscription_plan_features" (
+    "subscription_plan_id" bigint,
+    "feature_id" bigint,
+    PRIMARY KEY ("subscription_plan_id","feature_id"),
+    CONSTRAINT "fk_subscription_plan_features_subscription_plan" FOREIGN KEY ("subscription_plan_id") REFERENCES "subscription_plans"("id"),
+    CONSTRAINT "fk_subscription_plan_features_feature" FOREIGN KEY ("feature_id") REFERENCES "features"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+    "role_id" bigint,
+    "permission_id" bigint,
+    PRIMARY KEY ("role_id","permission_id"),
+    CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions"("id"),
+    CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "domains" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "domain" text,
+    "verified" boolean,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_organizations_domains" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "uni_domains_domain" UNIQUE ("domain")
+);
+CREATE INDEX IF NOT EXISTS "idx_domains_deleted_at" ON "domains" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "seats" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "user_id" bigint,
+    "status" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_organizations_seats" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "fk_users_seats" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_seats_deleted_at" ON "seats" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "seat_roles" (
+    "seat_id" bigint,
+    "role_id" bigint,
+    PRIMARY KEY ("seat_id","role_id"),
+    CONSTRAINT "fk_seat_roles_seat" FOREIGN KEY ("seat_id") REFERENCES "seats"("id"),
+    CONSTRAINT "fk_seat_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+    "id" bigserial,
+    "idempotency_key" varchar(255),
+    "user_id" bigint,
+    "request_hash" text,
+    "completed" boolean,
+    "status" bigint,
+    "content_type" text,
+    "body" bytea,
+    "created_at" timestamptz,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_expires_at" ON "idempotency_keys" ("expires_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_idempotency_scope" ON "idempotency_keys" ("idempotency_key","user_id");
+
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "organization_id" bigint,
+    "action" text,
+    "resource_type" text,
+    "resource_id" bigint,
+    "timestamp" timestamptz,
+    "changes" jsonb,
+    "prev_hash" text,
+    "hash" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_organizations_audit_logs" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_deleted_at" ON "audit_logs" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_hash" ON "audit_logs" ("hash");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_organization_id" ON "audit_logs" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_timestamp" ON "audit_logs" ("timestamp");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_user_id" ON "audit_logs" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "payment_transactions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "subscription_id" bigint,
+    "amount" decimal,
+    "currency" text,
+    "status" text,
+    "gateway" text,
+    "gateway_id" text,
+    "timestamp" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_subscriptions_transactions" FOREIGN KEY ("subscription_id") REFERENCES "subscriptions"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_payment_transactions_deleted_at" ON "payment_transactions" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "notification_preferences" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "email_enabled" boolean,
+    "sms_enabled" boolean,
+    "in_app_enabled" boolean,
+    "billing_emails" boolean,
+    "product_emails" boolean,
+    "marketing_emails" boolean,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_notification_prefs" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notification_preferences_deleted_at" ON "notification_preferences" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "activity_logs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "organization_id" bigint,
+    "activity_type" text,
+    "timestamp" timestamptz,
+    "metadata" jsonb,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_activity_logs" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_organizations_activity_logs" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_activity_logs_activity_type" ON "activity_logs" ("activity_type");
+CREATE INDEX IF NOT EXISTS "idx_activity_logs_deleted_at" ON "activity_logs" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_activity_logs_organization_id" ON "activity_logs" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_activity_logs_timestamp" ON "activity_logs" ("timestamp");
+CREATE INDEX IF NOT EXISTS "idx_activity_logs_user_id" ON "activity_logs" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "api_keys" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "organization_id" bigint,
+    "key" text,
+    "name" text,
+    "permissions" jsonb,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_organizations_api_keys" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id"),
+    CONSTRAINT "uni_api_keys_key" UNIQUE ("key")
+);
+CREATE INDEX IF NOT EXISTS "idx_api_keys_deleted_at" ON "api_keys" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "workflows" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "description" text,
+    "steps" jsonb,
+    "triggers" jsonb,
+    "organization_id" bigint,
+    "creator_id" bigint,
+    "enabled" boolean,
+    "version" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_organizations_workflows" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workflows_deleted_at" ON "workflows" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "reports" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "description" text,
+    "definition" jsonb,
+    "organization_id" bigint,
+    "creator_id" bigint,
+    "schedule" text,
+    "recipients" jsonb,
+    "format" text,
+    "last_run_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_reports_deleted_at" ON "reports" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "scheduled_jobs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "handler" text,
+    "schedule" text,
+    "timezone" text,
+    "misfire_policy" text,
+    "enabled" boolean,
+    "payload" text,
+    "last_run_at" timestamptz,
+    "next_run_at" timestamptz,
+    "last_error" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_scheduled_jobs_deleted_at" ON "scheduled_jobs" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_scheduled_jobs_handler" ON "scheduled_jobs" ("handler");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_scheduled_jobs_name" ON "scheduled_jobs" ("name");
+CREATE INDEX IF NOT EXISTS "idx_scheduled_jobs_next_run_at" ON "scheduled_jobs" ("next_run_at");
+
+CREATE TABLE IF NOT EXISTS "scheduler_leases" (
+    "name" text,
+    "holder" text,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "siem_integrations" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "type" text,
+    "endpoint" text,
+    "token" text,
+    "enabled" boolean,
+    "last_error" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_siem_integrations_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_siem_integrations_deleted_at" ON "siem_integrations" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_siem_integrations_organization_id" ON "siem_integrations" ("organization_id");
+
+CREATE TABLE IF NOT EXISTS "audit_chain_heads" (
+    "organization_id" bigserial,
+    "hash" text,
+    "last_entry_id" bigint,
+    "pruned_hash" text,
+    PRIMARY KEY ("organization_id")
+);
+
+CREATE TABLE IF NOT EXISTS "report_runs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "report_id" bigint,
+    "organization_id" bigint,
+    "triggered_by" bigint,
+    "status" text,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "row_count" bigint,
+    "truncated" boolean,
+    "columns" jsonb,
+    "rows" jsonb,
+    "error" text,
+    "scheduled" boolean,
+    "delivered_at" timestamptz,
+    "delivery_error" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_report_runs_deleted_at" ON "report_runs" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_report_runs_organization_id" ON "report_runs" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_report_runs_report_id" ON "report_runs" ("report_id");
+
+CREATE TABLE IF NOT EXISTS "report_exports" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "report_id" bigint,
+    "run_id" bigint,
+    "organization_id" bigint,
+    "requested_by" bigint,
+    "format" text,
+    "filename" text,
+    "status" text,
+    "storage_key" text,
+    "size" bigint,
+    "error" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_report_exports_deleted_at" ON "report_exports" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_report_exports_organization_id" ON "report_exports" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_report_exports_report_id" ON "report_exports" ("report_id");
+
+CREATE TABLE IF NOT EXISTS "workflow_runs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "workflow_id" bigint,
+    "workflow_version" bigint,
+    "organization_id" bigint,
+    "triggered_by" bigint,
+    "status" text,
+    "current_step" bigint,
+    "input" jsonb,
+    "definition" jsonb,
+    "error" text,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workflow_runs_deleted_at" ON "workflow_runs" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_workflow_runs_organization_id" ON "workflow_runs" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_runs_started_at" ON "workflow_runs" ("started_at");
+CREATE INDEX IF NOT EXISTS "idx_workflow_runs_status" ON "workflow_runs" ("status");
+CREATE INDEX IF NOT EXISTS "idx_workflow_runs_workflow_id" ON "workflow_runs" ("workflow_id");
+
+CREATE TABLE IF NOT EXISTS "workflow_step_runs" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "run_id" bigint,
+    "position" bigint,
+    "name" text,
+    "status" text,
+    "attempts" bigint,
+    "output" jsonb,
+    "error" text,
+    "started_at" timestamptz,
+    "finished_at" timestamptz,
+    "resume_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_workflow_runs_steps" FOREIGN KEY ("run_id") REFERENCES "workflow_runs"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workflow_step_runs_deleted_at" ON "workflow_step_runs" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_workflow_step_runs_position" ON "workflow_step_runs" ("position");
+CREATE INDEX IF NOT EXISTS "idx_workflow_step_runs_resume_at" ON "workflow_step_runs" ("resume_at");
+CREATE INDEX IF NOT EXISTS "idx_workflow_step_runs_run_id" ON "workflow_step_runs" ("run_id");
+
+CREATE TABLE IF NOT EXISTS "workflow_approvals" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "run_id" bigint,
+    "step_run_id" bigint,
+    "position" bigint,
+    "organization_id" bigint,
+    "approver_id" bigint,
+    "delegated_from_id" bigint,
+    "status" text,
+    "message" text,
+    "comment" text,
+    "decided_at" timestamptz,
+    "reminded_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_workflow_approvals_approver_id" ON "workflow_approvals" ("approver_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_approvals_deleted_at" ON "workflow_approvals" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_workflow_approvals_organization_id" ON "workflow_approvals" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_approvals_run_id" ON "workflow_approvals" ("run_id");
+CREATE INDEX IF NOT EXISTS "idx_workflow_approvals_status" ON "workflow_approvals" ("status");
+CREATE INDEX IF NOT EXISTS "idx_workflow_approvals_step_run_id" ON "workflow_approvals" ("step_run_id");
+
+CREATE TABLE IF NOT EXISTS "workflow_versions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "workflow_id" bigint,
+    "version" bigint,
+    "organization_id" bigint,
+    "name" text,
+    "description" text,
+    "steps" jsonb,
+    "triggers" jsonb,
+    "created_by" bigint,
+    "rolled_back_from" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_workflow_version" ON "workflow_versions" ("workflow_id","version");
+CREATE INDEX IF NOT EXISTS "idx_workflow_versions_deleted_at" ON "workflow_versions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_workflow_versions_organization_id" ON "workflow_versions" ("organization_id");
+
+-- Composite indexes backing cursor pagination of the log tables
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_created_at_id" ON "audit_logs" ("created_at", "id");
+CREATE INDEX IF NOT EXISTS "idx_activity_logs_created_at_id" ON "activity_logs" ("created_at", "id");
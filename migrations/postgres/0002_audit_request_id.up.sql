@@ -0,0 +1,2 @@
+ALTER TABLE "audit_logs" ADD COLUMN "request_id" varchar(128);
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_request_id" ON "audit_logs" ("request_id");
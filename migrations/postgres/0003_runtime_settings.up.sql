@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "runtime_settings" (
+    "setting_key" varchar(191),
+    "value" text,
+    "updated_by" bigint,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("setting_key")
+);
+
+CREATE TABLE IF NOT EXISTS "runtime_setting_changes" (
+    "id" bigserial,
+    "setting_key" varchar(191),
+    "old_value" text,
+    "new_value" text,
+    "user_id" bigint,
+    "request_id" varchar(128),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_runtime_setting_changes_setting_key" ON "runtime_setting_changes" ("setting_key");
+CREATE INDEX IF NOT EXISTS "idx_runtime_setting_changes_created_at" ON "runtime_setting_changes" ("created_at");
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "login_devices" (
+    "id" bigserial,
+    "user_id" bigint,
+    "created_at" timestamptz,
+    "fingerprint" varchar(64),
+    "location" varchar(64),
+    "user_agent" text,
+    "ip" varchar(64),
+    "last_seen_at" timestamptz,
+    "alert_token_hash" varchar(64),
+    "alert_expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_login_devices_user_id" ON "login_devices" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_login_devices_alert_token_hash" ON "login_devices" ("alert_token_hash");
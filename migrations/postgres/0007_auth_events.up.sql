@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "auth_events" (
+    "id" bigserial,
+    "user_id" bigint,
+    "action" varchar(64),
+    "email" text,
+    "ip" varchar(64),
+    "user_agent" text,
+    "details" jsonb,
+    "request_id" varchar(128),
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_auth_events_user_id" ON "auth_events" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_auth_events_action" ON "auth_events" ("action");
+CREATE INDEX IF NOT EXISTS "idx_auth_events_created_at" ON "auth_events" ("created_at");
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "email_changes" (
+    "id" bigserial,
+    "user_id" bigint,
+    "new_email" varchar(254),
+    "created_at" timestamptz,
+    "expires_at" timestamptz,
+    "old_token_hash" varchar(64),
+    "new_token_hash" varchar(64),
+    "old_confirmed_at" timestamptz,
+    "new_confirmed_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_email_changes_user_id" ON "email_changes" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_email_changes_old_token_hash" ON "email_changes" ("old_token_hash");
+CREATE INDEX IF NOT EXISTS "idx_email_changes_new_token_hash" ON "email_changes" ("new_token_hash");
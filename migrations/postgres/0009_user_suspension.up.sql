@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "suspended_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN "suspension_reason" text;
+ALTER TABLE "seats" ADD COLUMN "suspended_at" timestamptz;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS "invitations" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "user_id" bigint,
+    "seat_id" bigint,
+    "email" varchar(254),
+    "invited_by" bigint,
+    "token_hash" varchar(64),
+    "expires_at" timestamptz,
+    "accepted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invitations_deleted_at" ON "invitations" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_invitations_organization_id" ON "invitations" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_invitations_user_id" ON "invitations" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_invitations_token_hash" ON "invitations" ("token_hash");
+
+CREATE TABLE IF NOT EXISTS "user_imports" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "requested_by" bigint,
+    "filename" text,
+    "invite" boolean,
+    "status" text,
+    "total" bigint,
+    "succeeded" bigint,
+    "failed" bigint,
+    "rows" jsonb,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_imports_deleted_at" ON "user_imports" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_user_imports_organization_id" ON "user_imports" ("organization_id");
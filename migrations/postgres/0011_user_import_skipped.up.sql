@@ -0,0 +1 @@
+ALTER TABLE "user_imports" ADD COLUMN "skipped" bigint DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE "organizations" DROP COLUMN "allowed_email_domains";
+ALTER TABLE "organizations" DROP COLUMN "session_lifetime_minutes";
+ALTER TABLE "organizations" DROP COLUMN "require_two_factor";
+ALTER TABLE "organizations" DROP COLUMN "default_timezone";
+ALTER TABLE "organizations" DROP COLUMN "default_locale";
@@ -0,0 +1,5 @@
+ALTER TABLE "organizations" ADD COLUMN "default_locale" text;
+ALTER TABLE "organizations" ADD COLUMN "default_timezone" text;
+ALTER TABLE "organizations" ADD COLUMN "require_two_factor" boolean DEFAULT false;
+ALTER TABLE "organizations" ADD COLUMN "session_lifetime_minutes" bigint DEFAULT 0;
+ALTER TABLE "organizations" ADD COLUMN "allowed_email_domains" jsonb;
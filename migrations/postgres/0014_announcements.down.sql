@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "announcement_reads";
+DROP TABLE IF EXISTS "announcements";
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS "announcements" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "title" varchar(200),
+    "body" text,
+    "link" varchar(2048),
+    "published_at" timestamptz,
+    "expires_at" timestamptz,
+    "created_by" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_announcements_deleted_at" ON "announcements" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_announcements_published_at" ON "announcements" ("published_at");
+
+CREATE TABLE IF NOT EXISTS "announcement_reads" (
+    "user_id" bigint,
+    "announcement_id" bigint,
+    "read_at" timestamptz,
+    PRIMARY KEY ("user_id","announcement_id")
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "feedbacks" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "organization_id" bigint,
+    "kind" varchar(16),
+    "score" bigint,
+    "message" text,
+    "category" varchar(64),
+    "page" varchar(2048),
+    "user_agent" varchar(512),
+    "metadata" jsonb,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_feedbacks_deleted_at" ON "feedbacks" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_feedbacks_user_id" ON "feedbacks" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_feedbacks_organization_id" ON "feedbacks" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_feedbacks_kind" ON "feedbacks" ("kind");
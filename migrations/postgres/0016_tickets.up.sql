@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS "tickets" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "user_id" bigint,
+    "subject" varchar(200),
+    "status" varchar(16),
+    "last_message_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tickets_deleted_at" ON "tickets" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_tickets_organization_id" ON "tickets" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_tickets_user_id" ON "tickets" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_tickets_status" ON "tickets" ("status");
+CREATE INDEX IF NOT EXISTS "idx_tickets_last_message_at" ON "tickets" ("last_message_at");
+
+CREATE TABLE IF NOT EXISTS "ticket_messages" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "ticket_id" bigint,
+    "user_id" bigint,
+    "body" text,
+    "staff" boolean DEFAULT false,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_ticket_messages_deleted_at" ON "ticket_messages" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_ticket_messages_ticket_id" ON "ticket_messages" ("ticket_id");
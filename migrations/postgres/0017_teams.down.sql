@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "idx_reports_team_id";
+ALTER TABLE "reports" DROP COLUMN "team_id";
+DROP INDEX IF EXISTS "idx_workflows_team_id";
+ALTER TABLE "workflows" DROP COLUMN "team_id";
+DROP TABLE IF EXISTS "team_member_roles";
+DROP TABLE IF EXISTS "team_members";
+DROP TABLE IF EXISTS "teams";
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS "teams" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "name" varchar(100),
+    "description" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_teams_deleted_at" ON "teams" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_teams_organization_id" ON "teams" ("organization_id");
+
+CREATE TABLE IF NOT EXISTS "team_members" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "team_id" bigint,
+    "user_id" bigint,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_teams_members" FOREIGN KEY ("team_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_team_members_deleted_at" ON "team_members" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_team_members_organization_id" ON "team_members" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_team_members_user_id" ON "team_members" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_team_members_team_user" ON "team_members" ("team_id","user_id");
+
+CREATE TABLE IF NOT EXISTS "team_member_roles" (
+    "team_member_id" bigint,
+    "role_id" bigint,
+    PRIMARY KEY ("team_member_id","role_id"),
+    CONSTRAINT "fk_team_member_roles_team_member" FOREIGN KEY ("team_member_id") REFERENCES "team_members"("id"),
+    CONSTRAINT "fk_team_member_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id")
+);
+
+ALTER TABLE "workflows" ADD COLUMN "team_id" bigint;
+CREATE INDEX IF NOT EXISTS "idx_workflows_team_id" ON "workflows" ("team_id");
+ALTER TABLE "reports" ADD COLUMN "team_id" bigint;
+CREATE INDEX IF NOT EXISTS "idx_reports_team_id" ON "reports" ("team_id");
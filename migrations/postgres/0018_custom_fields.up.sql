@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS "custom_fields" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "entity_type" varchar(32),
+    "key" varchar(64),
+    "name" varchar(100),
+    "type" varchar(16),
+    "options" text,
+    "required" boolean,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_custom_fields_deleted_at" ON "custom_fields" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_custom_fields_org_entity_key" ON "custom_fields" ("organization_id","entity_type","key");
+
+CREATE TABLE IF NOT EXISTS "custom_field_values" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "custom_field_id" bigint,
+    "entity_type" varchar(32),
+    "entity_id" bigint,
+    "key" varchar(64),
+    "value" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_custom_field_values_deleted_at" ON "custom_field_values" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_custom_field_values_organization_id" ON "custom_field_values" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_custom_field_values_entity" ON "custom_field_values" ("entity_type","entity_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_custom_field_values_field_entity" ON "custom_field_values" ("custom_field_id","entity_id");
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS "tags" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "name" varchar(50),
+    "color" varchar(7),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tags_deleted_at" ON "tags" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_tags_organization_id" ON "tags" ("organization_id");
+
+CREATE TABLE IF NOT EXISTS "taggings" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "tag_id" bigint,
+    "taggable_type" varchar(32),
+    "taggable_id" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_taggings_deleted_at" ON "taggings" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_taggings_organization_id" ON "taggings" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_taggings_taggable" ON "taggings" ("taggable_type","taggable_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_taggings_tag_taggable" ON "taggings" ("tag_id","taggable_type","taggable_id");
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "comments" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "commentable_type" varchar(32),
+    "commentable_id" bigint,
+    "parent_id" bigint,
+    "user_id" bigint,
+    "body" text,
+    "mentions" text,
+    "edited_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_deleted_at" ON "comments" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_comments_organization_id" ON "comments" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_commentable" ON "comments" ("commentable_type","commentable_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_parent_id" ON "comments" ("parent_id");
+CREATE INDEX IF NOT EXISTS "idx_comments_user_id" ON "comments" ("user_id");
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS "attachments" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "attachable_type" varchar(32),
+    "attachable_id" bigint,
+    "user_id" bigint,
+    "filename" text,
+    "content_type" text,
+    "size" bigint,
+    "storage_key" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_attachments_deleted_at" ON "attachments" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_attachments_organization_id" ON "attachments" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_attachments_attachable" ON "attachments" ("attachable_type","attachable_id");
+CREATE INDEX IF NOT EXISTS "idx_attachments_user_id" ON "attachments" ("user_id");
+ALTER TABLE "subscription_plans" ADD COLUMN "max_attachment_size" bigint;
+ALTER TABLE "subscription_plans" ADD COLUMN "attachment_types" text;
@@ -0,0 +1,4 @@
+ALTER TABLE "attachments" DROP COLUMN "cleared_by_id";
+ALTER TABLE "attachments" DROP COLUMN "scanned_at";
+ALTER TABLE "attachments" DROP COLUMN "threat";
+ALTER TABLE "attachments" DROP COLUMN "scan_status";
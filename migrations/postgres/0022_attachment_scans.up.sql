@@ -0,0 +1,5 @@
+ALTER TABLE "attachments" ADD COLUMN "scan_status" varchar(16);
+ALTER TABLE "attachments" ADD COLUMN "threat" text;
+ALTER TABLE "attachments" ADD COLUMN "scanned_at" timestamptz;
+ALTER TABLE "attachments" ADD COLUMN "cleared_by_id" bigint;
+UPDATE "attachments" SET "scan_status" = 'unscanned';
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "organization_exports" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "requested_by" bigint,
+    "status" varchar(16),
+    "progress" bigint,
+    "section" text,
+    "filename" text,
+    "storage_key" text,
+    "size" bigint,
+    "error" text,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_organization_exports_deleted_at" ON "organization_exports" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_organization_exports_organization_id" ON "organization_exports" ("organization_id");
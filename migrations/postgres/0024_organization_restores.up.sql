@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "organization_restores" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "target_organization_id" bigint,
+    "source_organization_id" bigint,
+    "requested_by" bigint,
+    "name" text,
+    "filename" text,
+    "status" varchar(16),
+    "error" text,
+    "users_created" bigint,
+    "users_matched" bigint,
+    "restored" text,
+    "warnings" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_organization_restores_deleted_at" ON "organization_restores" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_organization_restores_target_organization_id" ON "organization_restores" ("target_organization_id");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "retention_removals";
+DROP TABLE IF EXISTS "retention_rules";
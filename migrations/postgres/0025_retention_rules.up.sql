@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS "retention_rules" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "resource" varchar(32),
+    "days" bigint,
+    "action" varchar(16),
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_retention_rules_deleted_at" ON "retention_rules" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_retention_rules_org_resource" ON "retention_rules" ("organization_id","resource");
+
+CREATE TABLE IF NOT EXISTS "retention_removals" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "organization_id" bigint,
+    "resource" varchar(32),
+    "action" varchar(16),
+    "days" bigint,
+    "cutoff" timestamptz,
+    "count" bigint,
+    "archive_keys" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_retention_removals_deleted_at" ON "retention_removals" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_retention_removals_organization_id" ON "retention_removals" ("organization_id");
@@ -0,0 +1,33 @@
+DROP TABLE IF EXISTS `workflow_versions`;
+DROP TABLE IF EXISTS `workflow_approvals`;
+DROP TABLE IF EXISTS `workflow_step_runs`;
+DROP TABLE IF EXISTS `workflow_runs`;
+DROP TABLE IF EXISTS `report_exports`;
+DROP TABLE IF EXISTS `report_runs`;
+DROP TABLE IF EXISTS `audit_chain_heads`;
+DROP TABLE IF EXISTS `siem_integrations`;
+DROP TABLE IF EXISTS `scheduler_leases`;
+DROP TABLE IF EXISTS `scheduled_jobs`;
+DROP TABLE IF EXISTS `reports`;
+DROP TABLE IF EXISTS `workflows`;
+DROP TABLE IF EXISTS `api_keys`;
+DROP TABLE IF EXISTS `activity_logs`;
+DROP TABLE IF EXISTS `notification_preferences`;
+DROP TABLE IF EXISTS `payment_transactions`;
+DROP TABLE IF EXISTS `audit_logs`;
+DROP TABLE IF EXISTS `idempotency_keys`;
+DROP TABLE IF EXISTS `seat_roles`;
+DROP TABLE IF EXISTS `seats`;
+DROP TABLE IF EXISTS `domains`;
+DROP TABLE IF EXISTS `subscriptions`;
+DROP TABLE IF EXISTS `subscription_plan_features`;
+DROP TABLE IF EXISTS `features`;
+DROP TABLE IF EXISTS `role_permissions`;
+DROP TABLE IF EXISTS `user_roles`;
+DROP TABLE IF EXISTS `roles`;
+DROP TABLE IF EXISTS `user_organizations`;
+DROP TABLE IF EXISTS `organizations`;
+DROP TABLE IF EXISTS `subscription_plans`;
+DROP TABLE IF EXISTS `user_permissions`;
+DROP TABLE IF EXISTS `permissions`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,495 @@
+-- Schema of the application at the introduction of versioned migrations
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `email` text,
+    `password` text,
+    `password_hash` text,
+    `name` text,
+    `verification_code` text,
+    `verified` numeric,
+    `locale` text,
+    `timezone` text,
+    `language` text,
+    CONSTRAINT `uni_users_email` UNIQUE (`email`)
+);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `permissions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text,
+    `description` text
+);
+CREATE INDEX IF NOT EXISTS `idx_permissions_deleted_at` ON `permissions`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `user_permissions` (
+    `user_id` integer,
+    `permission_id` integer,
+    PRIMARY KEY (`user_id`,`permission_id`),
+    CONSTRAINT `fk_user_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`),
+    CONSTRAINT `fk_user_permissions_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `subscription_plans` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text,
+    `description` text,
+    `price` real,
+    `currency` text,
+    `interval` text
+);
+CREATE INDEX IF NOT EXISTS `idx_subscription_plans_deleted_at` ON `subscription_plans`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `organizations` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text,
+    `subscription_plan_id` integer,
+    `logo_url` text,
+    `theme_color` text,
+    `audit_log_retention_days` integer,
+    `activity_log_retention_days` integer,
+    `seat_limit` integer,
+    CONSTRAINT `fk_organizations_subscription_plan` FOREIGN KEY (`subscription_plan_id`) REFERENCES `subscription_plans`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_organizations_deleted_at` ON `organizations`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `user_organizations` (
+    `organization_id` integer,
+    `user_id` integer,
+    PRIMARY KEY (`organization_id`,`user_id`),
+    CONSTRAINT `fk_user_organizations_organization` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`),
+    CONSTRAINT `fk_user_organizations_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `roles` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text
+);
+CREATE INDEX IF NOT EXISTS `idx_roles_deleted_at` ON `roles`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `user_roles` (
+    `user_id` integer,
+    `role_id` integer,
+    PRIMARY KEY (`user_id`,`role_id`),
+    CONSTRAINT `fk_user_roles_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `fk_user_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `role_permissions` (
+    `role_id` integer,
+    `permission_id` integer,
+    PRIMARY KEY (`role_id`,`permission_id`),
+    CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`),
+    CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `features` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text,
+    `description` text
+);
+CREATE INDEX IF NOT EXISTS `idx_features_deleted_at` ON `features`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `subscription_plan_features` (
+    `subscription_plan_id` integer,
+    `feature_id` integer,
+    PRIMARY KEY (`subscription_plan_id`,`feature_id`),
+    CONSTRAINT `fk_subscription_plan_features_subscription_plan` FOREIGN KEY (`subscription_plan_id`) REFERENCES `subscription_plans`(`id`),
+    CONSTRAINT `fk_subscription_plan_features_feature` FOREIGN KEY (`feature_id`) REFERENCES `features`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `subscriptions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `subscription_plan_id` integer,
+    `status` text,
+    `start_date` datetime,
+    `end_date` datetime,
+    `payment_method` text,
+    `last_payment_date` datetime,
+    `next_billing_date` datetime,
+    CONSTRAINT `fk_organizations_subscriptions` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`),
+    CONSTRAINT `fk_subscriptions_subscription_plan` FOREIGN KEY (`subscription_plan_id`) REFERENCES `subscription_plans`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_subscriptions_deleted_at` ON `subscriptions`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `domains` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `domain` text,
+    `verified` numeric,
+    CONSTRAINT `fk_organizations_domains` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`) ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT `uni_domains_domain` UNIQUE (`domain`)
+);
+CREATE INDEX IF NOT EXISTS `idx_domains_deleted_at` ON `domains`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `seats` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `user_id` integer,
+    `status` text,
+    CONSTRAINT `fk_organizations_seats` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`) ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT `fk_users_seats` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_seats_deleted_at` ON `seats`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `seat_roles` (
+    `seat_id` integer,
+    `role_id` integer,
+    PRIMARY KEY (`seat_id`,`role_id`),
+    CONSTRAINT `fk_seat_roles_seat` FOREIGN KEY (`seat_id`) REFERENCES `seats`(`id`),
+    CONSTRAINT `fk_seat_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `idempotency_key` text,
+    `user_id` integer,
+    `request_hash` text,
+    `completed` numeric,
+    `status` integer,
+    `content_type` text,
+    `body` blob,
+    `created_at` datetime,
+    `expires_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_idempotency_keys_expires_at` ON `idempotency_keys`(`expires_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_idempotency_scope` ON `idempotency_keys`(`idempotency_key`,`user_id`);
+
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer,
+    `organization_id` integer,
+    `action` text,
+    `resource_type` text,
+    `resource_id` integer,
+    `timestamp` datetime,
+    `changes` text,
+    `prev_hash` text,
+    `hash` text,
+    CONSTRAINT `fk_organizations_audit_logs` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_audit_logs_deleted_at` ON `audit_logs`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_audit_logs_hash` ON `audit_logs`(`hash`);
+CREATE INDEX IF NOT EXISTS `idx_audit_logs_organization_id` ON `audit_logs`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_audit_logs_timestamp` ON `audit_logs`(`timestamp`);
+CREATE INDEX IF NOT EXISTS `idx_audit_logs_user_id` ON `audit_logs`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `payment_transactions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `subscription_id` integer,
+    `amount` real,
+    `currency` text,
+    `status` text,
+    `gateway` text,
+    `gateway_id` text,
+    `timestamp` datetime,
+    CONSTRAINT `fk_subscriptions_transactions` FOREIGN KEY (`subscription_id`) REFERENCES `subscriptions`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_payment_transactions_deleted_at` ON `payment_transactions`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `notification_preferences` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer,
+    `email_enabled` numeric,
+    `sms_enabled` numeric,
+    `in_app_enabled` numeric,
+    `billing_emails` numeric,
+    `product_emails` numeric,
+    `marketing_emails` numeric,
+    CONSTRAINT `fk_users_notification_prefs` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_notification_preferences_deleted_at` ON `notification_preferences`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `activity_logs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer,
+    `organization_id` integer,
+    `activity_type` text,
+    `timestamp` datetime,
+    `metadata` text,
+    CONSTRAINT `fk_organizations_activity_logs` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`),
+    CONSTRAINT `fk_users_activity_logs` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_activity_logs_activity_type` ON `activity_logs`(`activity_type`);
+CREATE INDEX IF NOT EXISTS `idx_activity_logs_deleted_at` ON `activity_logs`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_activity_logs_organization_id` ON `activity_logs`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_activity_logs_timestamp` ON `activity_logs`(`timestamp`);
+CREATE INDEX IF NOT EXISTS `idx_activity_logs_user_id` ON `activity_logs`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer,
+    `organization_id` integer,
+    `key` text,
+    `name` text,
+    `permissions` text,
+    `expires_at` datetime,
+    `last_used_at` datetime,
+    CONSTRAINT `fk_organizations_api_keys` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`),
+    CONSTRAINT `uni_api_keys_key` UNIQUE (`key`)
+);
+CREATE INDEX IF NOT EXISTS `idx_api_keys_deleted_at` ON `api_keys`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `workflows` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text,
+    `description` text,
+    `steps` text,
+    `triggers` text,
+    `organization_id` integer,
+    `creator_id` integer,
+    `enabled` numeric,
+    `version` integer,
+    CONSTRAINT `fk_organizations_workflows` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_workflows_deleted_at` ON `workflows`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `reports` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text,
+    `description` text,
+    `definition` text,
+    `organization_id` integer,
+    `creator_id` integer,
+    `schedule` text,
+    `recipients` text,
+    `format` text,
+    `last_run_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_reports_deleted_at` ON `reports`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `scheduled_jobs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text,
+    `handler` text,
+    `schedule` text,
+    `timezone` text,
+    `misfire_policy` text,
+    `enabled` numeric,
+    `payload` text,
+    `last_run_at` datetime,
+    `next_run_at` datetime,
+    `last_error` text
+);
+CREATE INDEX IF NOT EXISTS `idx_scheduled_jobs_deleted_at` ON `scheduled_jobs`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_scheduled_jobs_handler` ON `scheduled_jobs`(`handler`);
+CREATE INDEX IF NOT EXISTS `idx_scheduled_jobs_next_run_at` ON `scheduled_jobs`(`next_run_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_scheduled_jobs_name` ON `scheduled_jobs`(`name`);
+
+CREATE TABLE IF NOT EXISTS `scheduler_leases` (
+    `name` text,
+    `holder` text,
+    `expires_at` datetime,
+    PRIMARY KEY (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `siem_integrations` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `type` text,
+    `endpoint` text,
+    `token` text,
+    `enabled` numeric,
+    `last_error` text,
+    CONSTRAINT `fk_siem_integrations_organization` FOREIGN KEY (`organization_id`) REFERENCES `organizations`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_siem_integrations_deleted_at` ON `siem_integrations`(`deleted_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_siem_integrations_organization_id` ON `siem_integrations`(`organization_id`);
+
+CREATE TABLE IF NOT EXISTS `audit_chain_heads` (
+    `organization_id` integer PRIMARY KEY AUTOINCREMENT,
+    `hash` text,
+    `last_entry_id` integer,
+    `pruned_hash` text
+);
+
+CREATE TABLE IF NOT EXISTS `report_runs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `report_id` integer,
+    `organization_id` integer,
+    `triggered_by` integer,
+    `status` text,
+    `started_at` datetime,
+    `finished_at` datetime,
+    `row_count` integer,
+    `truncated` numeric,
+    `columns` text,
+    `rows` text,
+    `error` text,
+    `scheduled` numeric,
+    `delivered_at` datetime,
+    `delivery_error` text
+);
+CREATE INDEX IF NOT EXISTS `idx_report_runs_deleted_at` ON `report_runs`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_report_runs_organization_id` ON `report_runs`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_report_runs_report_id` ON `report_runs`(`report_id`);
+
+CREATE TABLE IF NOT EXISTS `report_exports` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `report_id` integer,
+    `run_id` integer,
+    `organization_id` integer,
+    `requested_by` integer,
+    `format` text,
+    `filename` text,
+    `status` text,
+    `storage_key` text,
+    `size` integer,
+    `error` text
+);
+CREATE INDEX IF NOT EXISTS `idx_report_exports_deleted_at` ON `report_exports`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_report_exports_organization_id` ON `report_exports`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_report_exports_report_id` ON `report_exports`(`report_id`);
+
+CREATE TABLE IF NOT EXISTS `workflow_runs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `workflow_id` integer,
+    `workflow_version` integer,
+    `organization_id` integer,
+    `triggered_by` integer,
+    `status` text,
+    `current_step` integer,
+    `input` text,
+    `definition` text,
+    `error` text,
+    `started_at` datetime,
+    `finished_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_workflow_runs_deleted_at` ON `workflow_runs`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_runs_organization_id` ON `workflow_runs`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_runs_started_at` ON `workflow_runs`(`started_at`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_runs_status` ON `workflow_runs`(`status`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_runs_workflow_id` ON `workflow_runs`(`workflow_id`);
+
+CREATE TABLE IF NOT EXISTS `workflow_step_runs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `run_id` integer,
+    `position` integer,
+    `name` text,
+    `status` text,
+    `attempts` integer,
+    `output` text,
+    `error` text,
+    `started_at` datetime,
+    `finished_at` datetime,
+    `resume_at` datetime,
+    CONSTRAINT `fk_workflow_runs_steps` FOREIGN KEY (`run_id`) REFERENCES `workflow_runs`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_workflow_step_runs_deleted_at` ON `workflow_step_runs`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_step_runs_position` ON `workflow_step_runs`(`position`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_step_runs_resume_at` ON `workflow_step_runs`(`resume_at`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_step_runs_run_id` ON `workflow_step_runs`(`run_id`);
+
+CREATE TABLE IF NOT EXISTS `workflow_approvals` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `run_id` integer,
+    `step_run_id` integer,
+    `position` integer,
+    `organization_id` integer,
+    `approver_id` integer,
+    `delegated_from_id` integer,
+    `status` text,
+    `message` text,
+    `comment` text,
+    `decided_at` datetime,
+    `reminded_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_workflow_approvals_approver_id` ON `workflow_approvals`(`approver_id`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_approvals_deleted_at` ON `workflow_approvals`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_approvals_organization_id` ON `workflow_approvals`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_approvals_run_id` ON `workflow_approvals`(`run_id`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_approvals_status` ON `workflow_approvals`(`status`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_approvals_step_run_id` ON `workflow_approvals`(`step_run_id`);
+
+CREATE TABLE IF NOT EXISTS `workflow_versions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `workflow_id` integer,
+    `version` integer,
+    `organization_id` integer,
+    `name` text,
+    `description` text,
+    `steps` text,
+    `triggers` text,
+    `created_by` integer,
+    `rolled_back_from` integer
+);
+CREATE INDEX IF NOT EXISTS `idx_workflow_versions_deleted_at` ON `workflow_versions`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_workflow_versions_organization_id` ON `workflow_versions`(`organization_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_workflow_version` ON `workflow_versions`(`workflow_id`,`version`);
+
+-- Composite indexes backing cursor pagination of the log tables
+CREATE INDEX IF NOT EXISTS `idx_audit_logs_created_at_id` ON `audit_logs`(`created_at`, `id`);
+CREATE INDEX IF NOT EXISTS `idx_activity_logs_created_at_id` ON `activity_logs`(`created_at`, `id`);
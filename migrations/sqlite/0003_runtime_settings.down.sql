@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `runtime_setting_changes`;
+DROP TABLE IF EXISTS `runtime_settings`;
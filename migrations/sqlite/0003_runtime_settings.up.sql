@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `runtime_settings` (
+    `setting_key` text,
+    `value` text,
+    `updated_by` integer,
+    `updated_at` datetime,
+    PRIMARY KEY (`setting_key`)
+);
+
+CREATE TABLE IF NOT EXISTS `runtime_setting_changes` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `setting_key` text,
+    `old_value` text,
+    `new_value` text,
+    `user_id` integer,
+    `request_id` text,
+    `created_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_runtime_setting_changes_setting_key` ON `runtime_setting_changes`(`setting_key`);
+CREATE INDEX IF NOT EXISTS `idx_runtime_setting_changes_created_at` ON `runtime_setting_changes`(`created_at`);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `login_devices` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer,
+    `created_at` datetime,
+    `fingerprint` text,
+    `location` text,
+    `user_agent` text,
+    `ip` text,
+    `last_seen_at` datetime,
+    `alert_token_hash` text,
+    `alert_expires_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_login_devices_user_id` ON `login_devices`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_login_devices_alert_token_hash` ON `login_devices`(`alert_token_hash`);
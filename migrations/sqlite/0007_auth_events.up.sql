@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `auth_events` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer,
+    `action` text,
+    `email` text,
+    `ip` text,
+    `user_agent` text,
+    `details` text,
+    `request_id` text,
+    `created_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_auth_events_user_id` ON `auth_events`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_auth_events_action` ON `auth_events`(`action`);
+CREATE INDEX IF NOT EXISTS `idx_auth_events_created_at` ON `auth_events`(`created_at`);
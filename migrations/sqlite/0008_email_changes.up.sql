@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `email_changes` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer,
+    `new_email` text,
+    `created_at` datetime,
+    `expires_at` datetime,
+    `old_token_hash` text,
+    `new_token_hash` text,
+    `old_confirmed_at` datetime,
+    `new_confirmed_at` datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_email_changes_user_id` ON `email_changes`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_email_changes_old_token_hash` ON `email_changes`(`old_token_hash`);
+CREATE INDEX IF NOT EXISTS `idx_email_changes_new_token_hash` ON `email_changes`(`new_token_hash`);
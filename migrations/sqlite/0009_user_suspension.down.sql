@@ -0,0 +1,3 @@
+ALTER TABLE `seats` DROP COLUMN `suspended_at`;
+ALTER TABLE `users` DROP COLUMN `suspension_reason`;
+ALTER TABLE `users` DROP COLUMN `suspended_at`;
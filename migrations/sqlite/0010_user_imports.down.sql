@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `user_imports`;
+DROP TABLE IF EXISTS `invitations`;
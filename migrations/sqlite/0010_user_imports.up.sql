@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS `invitations` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `user_id` integer,
+    `seat_id` integer,
+    `email` text,
+    `invited_by` integer,
+    `token_hash` text,
+    `expires_at` datetime,
+    `accepted_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_invitations_deleted_at` ON `invitations`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_invitations_organization_id` ON `invitations`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_invitations_user_id` ON `invitations`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_invitations_token_hash` ON `invitations`(`token_hash`);
+
+CREATE TABLE IF NOT EXISTS `user_imports` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `requested_by` integer,
+    `filename` text,
+    `invite` numeric,
+    `status` text,
+    `total` integer,
+    `succeeded` integer,
+    `failed` integer,
+    `rows` text
+);
+CREATE INDEX IF NOT EXISTS `idx_user_imports_deleted_at` ON `user_imports`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_user_imports_organization_id` ON `user_imports`(`organization_id`);
@@ -0,0 +1 @@
+ALTER TABLE `user_imports` DROP COLUMN `skipped`;
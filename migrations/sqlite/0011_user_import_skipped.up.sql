@@ -0,0 +1 @@
+ALTER TABLE `user_imports` ADD COLUMN `skipped` integer DEFAULT 0;
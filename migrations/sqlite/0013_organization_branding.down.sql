@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS `idx_organizations_domain`;
+ALTER TABLE `organizations` DROP COLUMN `domain`;
+ALTER TABLE `organizations` DROP COLUMN `email_footer`;
+ALTER TABLE `organizations` DROP COLUMN `favicon_key`;
+ALTER TABLE `organizations` DROP COLUMN `logo_key`;
+ALTER TABLE `organizations` DROP COLUMN `favicon_url`;
@@ -0,0 +1,6 @@
+ALTER TABLE `organizations` ADD COLUMN `favicon_url` text;
+ALTER TABLE `organizations` ADD COLUMN `logo_key` text;
+ALTER TABLE `organizations` ADD COLUMN `favicon_key` text;
+ALTER TABLE `organizations` ADD COLUMN `email_footer` text;
+ALTER TABLE `organizations` ADD COLUMN `domain` text;
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_organizations_domain` ON `organizations`(`domain`);
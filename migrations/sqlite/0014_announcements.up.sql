@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `announcements` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `title` text,
+    `body` text,
+    `link` text,
+    `published_at` datetime,
+    `expires_at` datetime,
+    `created_by` integer
+);
+CREATE INDEX IF NOT EXISTS `idx_announcements_deleted_at` ON `announcements`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_announcements_published_at` ON `announcements`(`published_at`);
+
+CREATE TABLE IF NOT EXISTS `announcement_reads` (
+    `user_id` integer,
+    `announcement_id` integer,
+    `read_at` datetime,
+    PRIMARY KEY (`user_id`,`announcement_id`)
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `feedbacks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer,
+    `organization_id` integer,
+    `kind` text,
+    `score` integer,
+    `message` text,
+    `category` text,
+    `page` text,
+    `user_agent` text,
+    `metadata` text
+);
+CREATE INDEX IF NOT EXISTS `idx_feedbacks_deleted_at` ON `feedbacks`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_feedbacks_user_id` ON `feedbacks`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_feedbacks_organization_id` ON `feedbacks`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_feedbacks_kind` ON `feedbacks`(`kind`);
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS `tickets` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `user_id` integer,
+    `subject` text,
+    `status` text,
+    `last_message_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_tickets_deleted_at` ON `tickets`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_tickets_organization_id` ON `tickets`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_tickets_user_id` ON `tickets`(`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_tickets_status` ON `tickets`(`status`);
+CREATE INDEX IF NOT EXISTS `idx_tickets_last_message_at` ON `tickets`(`last_message_at`);
+
+CREATE TABLE IF NOT EXISTS `ticket_messages` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `ticket_id` integer,
+    `user_id` integer,
+    `body` text,
+    `staff` numeric DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS `idx_ticket_messages_deleted_at` ON `ticket_messages`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_ticket_messages_ticket_id` ON `ticket_messages`(`ticket_id`);
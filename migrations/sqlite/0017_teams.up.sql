@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS `teams` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `name` text,
+    `description` text
+);
+CREATE INDEX IF NOT EXISTS `idx_teams_deleted_at` ON `teams`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_teams_organization_id` ON `teams`(`organization_id`);
+
+CREATE TABLE IF NOT EXISTS `team_members` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `team_id` integer,
+    `user_id` integer,
+    CONSTRAINT `fk_teams_members` FOREIGN KEY (`team_id`) REFERENCES `teams`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_team_members_deleted_at` ON `team_members`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_team_members_organization_id` ON `team_members`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_team_members_user_id` ON `team_members`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_team_members_team_user` ON `team_members`(`team_id`,`user_id`);
+
+CREATE TABLE IF NOT EXISTS `team_member_roles` (
+    `team_member_id` integer,
+    `role_id` integer,
+    PRIMARY KEY (`team_member_id`,`role_id`),
+    CONSTRAINT `fk_team_member_roles_team_member` FOREIGN KEY (`team_member_id`) REFERENCES `team_members`(`id`),
+    CONSTRAINT `fk_team_member_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`)
+);
+
+ALTER TABLE `workflows` ADD COLUMN `team_id` integer;
+CREATE INDEX IF NOT EXISTS `idx_workflows_team_id` ON `workflows`(`team_id`);
+ALTER TABLE `reports` ADD COLUMN `team_id` integer;
+CREATE INDEX IF NOT EXISTS `idx_reports_team_id` ON `reports`(`team_id`);
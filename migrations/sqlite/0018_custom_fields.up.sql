@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS `custom_fields` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `entity_type` text,
+    `key` text,
+    `name` text,
+    `type` text,
+    `options` text,
+    `required` numeric
+);
+CREATE INDEX IF NOT EXISTS `idx_custom_fields_deleted_at` ON `custom_fields`(`deleted_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_custom_fields_org_entity_key` ON `custom_fields`(`organization_id`,`entity_type`,`key`);
+
+CREATE TABLE IF NOT EXISTS `custom_field_values` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `custom_field_id` integer,
+    `entity_type` text,
+    `entity_id` integer,
+    `key` text,
+    `value` text
+);
+CREATE INDEX IF NOT EXISTS `idx_custom_field_values_deleted_at` ON `custom_field_values`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_custom_field_values_organization_id` ON `custom_field_values`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_custom_field_values_entity` ON `custom_field_values`(`entity_type`,`entity_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_custom_field_values_field_entity` ON `custom_field_values`(`custom_field_id`,`entity_id`);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS `tags` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `name` text,
+    `color` text
+);
+CREATE INDEX IF NOT EXISTS `idx_tags_deleted_at` ON `tags`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_tags_organization_id` ON `tags`(`organization_id`);
+
+CREATE TABLE IF NOT EXISTS `taggings` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `tag_id` integer,
+    `taggable_type` text,
+    `taggable_id` integer
+);
+CREATE INDEX IF NOT EXISTS `idx_taggings_deleted_at` ON `taggings`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_taggings_organization_id` ON `taggings`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_taggings_taggable` ON `taggings`(`taggable_type`,`taggable_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_taggings_tag_taggable` ON `taggings`(`tag_id`,`taggable_type`,`taggable_id`);
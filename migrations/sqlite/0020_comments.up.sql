@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `comments` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `commentable_type` text,
+    `commentable_id` integer,
+    `parent_id` integer,
+    `user_id` integer,
+    `body` text,
+    `mentions` text,
+    `edited_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_comments_deleted_at` ON `comments`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_comments_organization_id` ON `comments`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_commentable` ON `comments`(`commentable_type`,`commentable_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_parent_id` ON `comments`(`parent_id`);
+CREATE INDEX IF NOT EXISTS `idx_comments_user_id` ON `comments`(`user_id`);
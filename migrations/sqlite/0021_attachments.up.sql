@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS `attachments` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `attachable_type` text,
+    `attachable_id` integer,
+    `user_id` integer,
+    `filename` text,
+    `content_type` text,
+    `size` integer,
+    `storage_key` text
+);
+CREATE INDEX IF NOT EXISTS `idx_attachments_deleted_at` ON `attachments`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_attachments_organization_id` ON `attachments`(`organization_id`);
+CREATE INDEX IF NOT EXISTS `idx_attachments_attachable` ON `attachments`(`attachable_type`,`attachable_id`);
+CREATE INDEX IF NOT EXISTS `idx_attachments_user_id` ON `attachments`(`user_id`);
+ALTER TABLE `subscription_plans` ADD COLUMN `max_attachment_size` integer;
+ALTER TABLE `subscription_plans` ADD COLUMN `attachment_types` text;
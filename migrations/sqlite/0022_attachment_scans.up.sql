@@ -0,0 +1,5 @@
+ALTER TABLE `attachments` ADD COLUMN `scan_status` text;
+ALTER TABLE `attachments` ADD COLUMN `threat` text;
+ALTER TABLE `attachments` ADD COLUMN `scanned_at` datetime;
+ALTER TABLE `attachments` ADD COLUMN `cleared_by_id` integer;
+UPDATE `attachments` SET `scan_status` = 'unscanned';
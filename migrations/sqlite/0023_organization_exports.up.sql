@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS `organization_exports` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `requested_by` integer,
+    `status` text,
+    `progress` integer,
+    `section` text,
+    `filename` text,
+    `storage_key` text,
+    `size` integer,
+    `error` text,
+    `expires_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_organization_exports_deleted_at` ON `organization_exports`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_organization_exports_organization_id` ON `organization_exports`(`organization_id`);
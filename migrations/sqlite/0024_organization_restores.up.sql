@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `organization_restores` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `target_organization_id` integer,
+    `source_organization_id` integer,
+    `requested_by` integer,
+    `name` text,
+    `filename` text,
+    `status` text,
+    `error` text,
+    `users_created` integer,
+    `users_matched` integer,
+    `restored` text,
+    `warnings` text
+);
+CREATE INDEX IF NOT EXISTS `idx_organization_restores_deleted_at` ON `organization_restores`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_organization_restores_target_organization_id` ON `organization_restores`(`target_organization_id`);
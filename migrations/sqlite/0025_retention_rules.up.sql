@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS `retention_rules` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `resource` text,
+    `days` integer,
+    `action` text
+);
+CREATE INDEX IF NOT EXISTS `idx_retention_rules_deleted_at` ON `retention_rules`(`deleted_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_retention_rules_org_resource` ON `retention_rules`(`organization_id`,`resource`);
+
+CREATE TABLE IF NOT EXISTS `retention_removals` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `organization_id` integer,
+    `resource` text,
+    `action` text,
+    `days` integer,
+    `cutoff` datetime,
+    `count` integer,
+    `archive_keys` text
+);
+CREATE INDEX IF NOT EXISTS `idx_retention_removals_deleted_at` ON `retention_removals`(`deleted_at`);
+CREATE INDEX IF NOT EXISTS `idx_retention_removals_organization_id` ON `retention_removals`(`organization_id`);
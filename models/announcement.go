@@ -0,0 +1,26 @@
+// Package models/announcement.go
+package models
+
+import "time"
+
+// Announcement is a product announcement, such as a changelog entry, shown to
+// every user in the app's "what's new" surfaces. Platform admins manage them.
+type Announcement struct {
+	Base
+	Title string `gorm:"size:200" json:"title"`
+	Body  string `json:"body"`
+	// Link points at the full story, such as a blog post
+	Link string `gorm:"size:2048" json:"link"`
+	// PublishedAt is when users start seeing the announcement; nil keeps it a draft
+	PublishedAt *time.Time `gorm:"index" json:"published_at"`
+	// ExpiresAt is when users stop seeing it; nil never
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedBy uint       `json:"created_by"`
+}
+
+// AnnouncementRead records that a user read an announcement
+type AnnouncementRead struct {
+	UserID         uint      `gorm:"primaryKey" json:"user_id"`
+	AnnouncementID uint      `gorm:"primaryKey" json:"announcement_id"`
+	ReadAt         time.Time `json:"read_at"`
+}
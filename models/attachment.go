@@ -0,0 +1,54 @@
+// Package models/attachment.go
+package models
+
+import "time"
+
+// Types of the records files can be attached to, named after their tables
+const (
+	AttachableWorkflow     = "workflows"
+	AttachableReport       = "reports"
+	AttachableSubscription = "subscriptions"
+)
+
+// AttachmentScanStatus is the outcome of the malware scan of an attachment
+type AttachmentScanStatus string
+
+const (
+	// AttachmentUnscanned files were uploaded while no scanner was configured
+	AttachmentUnscanned AttachmentScanStatus = "unscanned"
+	AttachmentClean     AttachmentScanStatus = "clean"
+	// AttachmentQuarantined files were flagged, and can't be downloaded until
+	// an admin clears them
+	AttachmentQuarantined AttachmentScanStatus = "quarantined"
+	AttachmentCleared     AttachmentScanStatus = "cleared"
+)
+
+// Attachment is a file a member of an organization attaches to one of its
+// records. Its content is kept in the object storage and downloaded through
+// signed URLs.
+type Attachment struct {
+	Base
+	OrganizationID uint                 `gorm:"index" json:"organization_id"`
+	AttachableType string               `gorm:"size:32;index:idx_attachments_attachable" json:"attachable_type"`
+	AttachableID   uint                 `gorm:"index:idx_attachments_attachable" json:"attachable_id"`
+	UserID         uint                 `gorm:"index" json:"user_id"`
+	Filename       string               `json:"filename"`
+	ContentType    string               `json:"content_type"`
+	Size           int64                `json:"size"`
+	StorageKey     string               `json:"-"`
+	ScanStatus     AttachmentScanStatus `gorm:"size:16" json:"scan_status"`
+	// Threat names what the scan flagged the file as
+	Threat    string     `json:"threat,omitempty"`
+	ScannedAt *time.Time `json:"scanned_at"`
+	// ClearedByID is the admin who released the file from quarantine
+	ClearedByID *uint `json:"cleared_by_id,omitempty"`
+	// URL downloads the file until URLExpiresAt without authentication; it's
+	// signed whenever the attachment is returned, unless it's quarantined
+	URL          string     `gorm:"-" json:"url,omitempty"`
+	URLExpiresAt *time.Time `gorm:"-" json:"url_expires_at,omitempty"`
+}
+
+// Downloadable reports whether the attachment may be downloaded
+func (a *Attachment) Downloadable() bool {
+	return a.ScanStatus != AttachmentQuarantined
+}
@@ -0,0 +1,34 @@
+// Package models/auth_event.go
+package models
+
+import "time"
+
+// AuthEvent is an authentication event of a user, such as a login or a password
+// change, audited apart from the changes of resources
+type AuthEvent struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	// UserID is zero for failed logins with an unknown email
+	UserID uint   `gorm:"index" json:"user_id"`
+	Action string `gorm:"index;size:64" json:"action"`
+	// Email is the email a failed login was attempted with
+	Email     string  `json:"email,omitempty"`
+	IP        string  `gorm:"size:64" json:"ip"`
+	UserAgent string  `json:"user_agent"`
+	Details   JSONMap `json:"details,omitempty" gorm:"serializer:json"`
+	// RequestID is the ID of the API request the event happened in
+	RequestID string    `gorm:"size:128" json:"request_id,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// Actions of authentication events
+const (
+	AuthActionLogin          = "login"
+	AuthActionLoginFailed    = "login_failed"
+	AuthActionLogout         = "logout"
+	AuthActionPasswordChange = "password_change"
+	AuthActionPasswordReset  = "password_reset"
+	AuthActionEmailChange    = "email_change"
+	AuthActionTokenRefresh   = "token_refresh"
+	AuthActionSuspend        = "suspend"
+	AuthActionReactivate     = "reactivate"
+)
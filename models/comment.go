@@ -0,0 +1,27 @@
+// Package models/comment.go
+package models
+
+import "time"
+
+// Types of the records comments can be left on, named after their tables
+const (
+	CommentableWorkflow     = "workflows"
+	CommentableReport       = "reports"
+	CommentableSubscription = "subscriptions"
+)
+
+// Comment is a note a member of an organization leaves on one of its records.
+// Comments starting a thread have no parent; replies are one level deep.
+type Comment struct {
+	Base
+	OrganizationID  uint   `gorm:"index" json:"organization_id"`
+	CommentableType string `gorm:"size:32;index:idx_comments_commentable" json:"commentable_type"`
+	CommentableID   uint   `gorm:"index:idx_comments_commentable" json:"commentable_id"`
+	ParentID        *uint  `gorm:"index" json:"parent_id"`
+	UserID          uint   `gorm:"index" json:"user_id"`
+	Body            string `json:"body"`
+	// Mentions are the IDs of the users mentioned in the body as <@ID>
+	Mentions []uint     `gorm:"serializer:json" json:"mentions"`
+	EditedAt *time.Time `json:"edited_at"`
+	Replies  []Comment  `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
+}
@@ -0,0 +1,46 @@
+// Package models/custom_field.go
+package models
+
+// CustomFieldType is the type of the values of a custom field
+type CustomFieldType string
+
+const (
+	CustomFieldText   CustomFieldType = "text"
+	CustomFieldNumber CustomFieldType = "number"
+	// CustomFieldDate values are dates formatted as 2006-01-02
+	CustomFieldDate CustomFieldType = "date"
+	// CustomFieldSelect values are one of the field's options
+	CustomFieldSelect CustomFieldType = "select"
+)
+
+// Entity types custom fields can extend, named after the tables of their records
+const (
+	CustomFieldEntityUser         = "users"
+	CustomFieldEntityOrganization = "organizations"
+)
+
+// CustomField is a field an organization defines on its own record or on its
+// members', stored as CustomFieldValue rows rather than columns
+type CustomField struct {
+	Base
+	OrganizationID uint   `gorm:"uniqueIndex:idx_custom_fields_org_entity_key" json:"organization_id"`
+	EntityType     string `gorm:"size:32;uniqueIndex:idx_custom_fields_org_entity_key" json:"entity_type"`
+	// Key names the field in values; it can't be changed once created
+	Key      string          `gorm:"size:64;uniqueIndex:idx_custom_fields_org_entity_key" json:"key"`
+	Name     string          `gorm:"size:100" json:"name"`
+	Type     CustomFieldType `gorm:"size:16" json:"type"`
+	Options  []string        `gorm:"serializer:json" json:"options,omitempty"`
+	Required bool            `json:"required"`
+}
+
+// CustomFieldValue is the value of a custom field on a user or organization
+type CustomFieldValue struct {
+	Base
+	OrganizationID uint   `gorm:"index" json:"organization_id"`
+	CustomFieldID  uint   `gorm:"uniqueIndex:idx_custom_field_values_field_entity" json:"custom_field_id"`
+	EntityType     string `gorm:"size:32;index:idx_custom_field_values_entity" json:"-"`
+	EntityID       uint   `gorm:"uniqueIndex:idx_custom_field_values_field_entity;index:idx_custom_field_values_entity" json:"-"`
+	Key            string `gorm:"size:64" json:"key"`
+	// Value is a string, or a number for number fields
+	Value interface{} `gorm:"serializer:json" json:"value"`
+}
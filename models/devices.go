@@ -0,0 +1,23 @@
+// Package models/devices.go
+package models
+
+import "time"
+
+// LoginDevice is a device a user signed in with from a location, the location
+// being empty when unknown; the same device signing in from another location
+// is another LoginDevice
+type LoginDevice struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Fingerprint is the hash of the device's user agent, versions left out
+	Fingerprint string    `gorm:"size:64" json:"-"`
+	Location    string    `gorm:"size:64" json:"location"`
+	UserAgent   string    `json:"user_agent"`
+	IP          string    `gorm:"size:64" json:"ip"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	// AlertTokenHash is the hash of the token of the "this wasn't me" link
+	// the user was emailed about the device, until AlertExpiresAt
+	AlertTokenHash string     `gorm:"size:64;index" json:"-"`
+	AlertExpiresAt *time.Time `json:"-"`
+}
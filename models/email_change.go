@@ -0,0 +1,20 @@
+// Package models/email_change.go
+package models
+
+import "time"
+
+// EmailChange is a pending change of a user's email, made once both the
+// current and the new address confirmed it through the links emailed to them
+type EmailChange struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"uniqueIndex" json:"-"`
+	NewEmail  string    `gorm:"size:254" json:"new_email"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// The hashes of the tokens of the links sent to the current and new address
+	OldTokenHash string `gorm:"size:64;index" json:"-"`
+	NewTokenHash string `gorm:"size:64;index" json:"-"`
+	// OldConfirmedAt and NewConfirmedAt are set when the addresses confirm
+	OldConfirmedAt *time.Time `json:"old_confirmed_at"`
+	NewConfirmedAt *time.Time `json:"new_confirmed_at"`
+}
@@ -0,0 +1,29 @@
+// Package models/feedback.go
+package models
+
+// FeedbackKind tells feedback and NPS responses apart
+type FeedbackKind string
+
+// Kinds of feedback
+const (
+	// FeedbackKindNPS answers how likely the user is to recommend the product, from 0 to 10
+	FeedbackKindNPS      FeedbackKind = "nps"
+	FeedbackKindFeedback FeedbackKind = "feedback"
+)
+
+// Feedback is feedback or an NPS response a user submitted in the app
+type Feedback struct {
+	Base
+	UserID uint `gorm:"index" json:"user_id"`
+	// OrganizationID is the organization the user submitted it for; zero for none
+	OrganizationID uint         `gorm:"index" json:"organization_id"`
+	Kind           FeedbackKind `gorm:"size:16;index" json:"kind"`
+	// Score is the NPS score, set for NPS responses only
+	Score    *int   `json:"score"`
+	Message  string `json:"message"`
+	Category string `gorm:"size:64" json:"category"`
+	// Page is where in the app the user submitted it
+	Page      string  `gorm:"size:2048" json:"page"`
+	UserAgent string  `gorm:"size:512" json:"user_agent"`
+	Metadata  JSONMap `gorm:"serializer:json" json:"metadata"`
+}
@@ -0,0 +1,19 @@
+// Package models/idempotency.go
+package models
+
+import "time"
+
+// IdempotencyKey stores the first response to a request made with an Idempotency-Key
+// header, so retries of the same request are answered without running it again
+type IdempotencyKey struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Key         string    `gorm:"column:idempotency_key;uniqueIndex:idx_idempotency_scope;size:255" json:"key"`
+	UserID      uint      `gorm:"uniqueIndex:idx_idempotency_scope" json:"user_id"`
+	RequestHash string    `json:"request_hash"`
+	Completed   bool      `json:"completed"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `gorm:"index" json:"expires_at"`
+}
@@ -0,0 +1,19 @@
+// Package models/invitation.go
+package models
+
+import "time"
+
+// Invitation invites a user to an organization through a link emailed to them.
+// Their seat stays invited until they accept it, which makes them a member.
+type Invitation struct {
+	Base
+	OrganizationID uint   `gorm:"index" json:"organization_id"`
+	UserID         uint   `gorm:"index" json:"user_id"`
+	SeatID         uint   `json:"seat_id"`
+	Email          string `gorm:"size:254" json:"email"`
+	InvitedBy      uint   `json:"invited_by"`
+	// TokenHash is the hash of the token of the emailed link
+	TokenHash  string     `gorm:"size:64;index" json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+}
@@ -4,10 +4,14 @@ package models
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+
+	// Registers the serializer of encrypted fields
+	_ "github.com/4cecoder/saas/encryption"
 )
 
 // Base contains common fields for all models
@@ -29,13 +33,24 @@ type User struct {
 	Organizations     []Organization         `gorm:"many2many:user_organizations;" json:"organizations"`
 	Seats             []Seat                 `json:"seats"`
 	Permissions       []Permission           `gorm:"many2many:user_permissions;" json:"permissions"`
-	VerificationCode  string                 `json:"-"`
+	VerificationCode  string                 `gorm:"serializer:encrypted" json:"-"`
 	Verified          bool                   `json:"verified"`
 	ActivityLogs      []ActivityLog          `json:"activity_logs"`
 	NotificationPrefs NotificationPreference `gorm:"foreignKey:UserID" json:"notification_prefs"`
 	Locale            string                 `json:"locale"`
 	Timezone          string                 `json:"timezone"`
 	Language          string                 `json:"language"`
+	// SessionsRevokedAt invalidates the tokens issued to the user up to then
+	SessionsRevokedAt *time.Time `json:"-"`
+	// PasswordChangeRequired restricts the user to changing their password,
+	// e.g. after signing in with the bootstrap credentials
+	PasswordChangeRequired bool `json:"password_change_required"`
+	// SuspendedAt is set while the user is suspended: they can't sign in and
+	// their tokens, API keys and seats are disabled, but nothing is deleted
+	SuspendedAt      *time.Time `json:"suspended_at"`
+	SuspensionReason string     `json:"suspension_reason,omitempty"`
+	// CustomFields are the values of the fields the user's organizations define
+	CustomFields []CustomFieldValue `gorm:"polymorphic:Entity" json:"custom_fields,omitempty"`
 }
 
 // BeforeCreate is a GORM hook that runs before creating a new user
@@ -54,15 +69,9 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 
 // BeforeUpdate is a GORM hook that runs before updating a user
 func (u *User) BeforeUpdate(tx *gorm.DB) error {
-	// Hash the password if it's being updated
-	if tx.Statement.Changed("Password") {
-		err := u.hashPassword()
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	// Hash the password if a new one was set; Statement.Changed doesn't see
+	// fields assigned before Save
+	return u.hashPassword()
 }
 
 // hashPassword hashes the user's password using bcrypt
@@ -79,6 +88,11 @@ func (u *User) hashPassword() error {
 	return nil
 }
 
+// CheckPassword reports whether password is the user's
+func (u *User) CheckPassword(password string) bool {
+	return u.PasswordHash != "" && bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
 // Role defines the access level and permissions for a user
 type Role struct {
 	Base
@@ -96,38 +110,84 @@ type Permission struct {
 // Organization represents a company or group
 type Organization struct {
 	Base
-	Name             string               `json:"name"`
-	Users            []User               `gorm:"many2many:user_organizations;" json:"users"`
-	Subscriptions    []Subscription       `json:"subscriptions"`
-	SubscriptionPlan SubscriptionPlan     `json:"subscription_plan"`
-	Seats            []Seat               `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"seats"`
-	Domains          []Domain             `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"domains"`
-	Settings         OrganizationSettings `gorm:"embedded" json:"settings"`
-	AuditLogs        []AuditLog           `json:"audit_logs"`
-	ActivityLogs     []ActivityLog        `json:"activity_logs"`
-	APIKeys          []APIKey             `json:"api_keys"`
-	Workflows        []Workflow           `json:"workflows"`
+	Name               string               `json:"name"`
+	Users              []User               `gorm:"many2many:user_organizations;" json:"users"`
+	Subscriptions      []Subscription       `json:"subscriptions"`
+	SubscriptionPlanID *uint                `json:"subscription_plan_id"`
+	SubscriptionPlan   SubscriptionPlan     `json:"subscription_plan"`
+	Seats              []Seat               `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"seats"`
+	Domains            []Domain             `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"domains"`
+	Settings           OrganizationSettings `gorm:"embedded" json:"settings"`
+	AuditLogs          []AuditLog           `json:"audit_logs"`
+	ActivityLogs       []ActivityLog        `json:"activity_logs"`
+	APIKeys            []APIKey             `json:"api_keys"`
+	Workflows          []Workflow           `json:"workflows"`
+	CustomFields       []CustomFieldValue   `gorm:"polymorphic:Entity" json:"custom_fields,omitempty"`
+	// Region is where the organization's data is kept, set when it's created.
+	// Organizations from before regions were configured have none and belong
+	// to the region of the deployment.
+	Region string `gorm:"size:32" json:"region"`
 }
 
 // OrganizationSettings represents the settings for an organization
 type OrganizationSettings struct {
 	LogoURL    string `json:"logo_url"`
+	FaviconURL string `json:"favicon_url"`
 	ThemeColor string `json:"theme_color"`
-	// Add more settings fields as needed
+	// LogoKey and FaviconKey are the storage keys of the uploaded logo and favicon
+	LogoKey    string `json:"-"`
+	FaviconKey string `json:"-"`
+	// EmailFooter ends the emails sent on behalf of the organization
+	EmailFooter string `json:"email_footer"`
+	// Domain is the white-label domain the organization's frontend is served on
+	Domain *string `gorm:"uniqueIndex" json:"domain"`
+	// AuditLogRetentionDays and ActivityLogRetentionDays of zero keep logs forever
+	AuditLogRetentionDays    int `json:"audit_log_retention_days"`
+	ActivityLogRetentionDays int `json:"activity_log_retention_days"`
+	// SeatLimit caps the seats that aren't inactive; zero is unlimited. It is set by operators.
+	SeatLimit int `json:"seat_limit"`
+	// DefaultLocale and DefaultTimezone are given to the users the organization
+	// creates, such as those of imports
+	DefaultLocale   string `json:"default_locale"`
+	DefaultTimezone string `json:"default_timezone"`
+	// RequireTwoFactor asks members to sign in with a second factor. The API
+	// doesn't verify second factors yet, so clients enforce it.
+	RequireTwoFactor bool `json:"require_two_factor"`
+	// SessionLifetimeMinutes caps how long the tokens scoped to the organization
+	// are valid; zero leaves the configured lifetime
+	SessionLifetimeMinutes int `json:"session_lifetime_minutes"`
+	// AllowedEmailDomains restricts the users imported or invited to those with
+	// an email of the domains; empty allows any
+	AllowedEmailDomains []string `gorm:"serializer:json" json:"allowed_email_domains"`
+}
+
+// AllowsEmail reports whether the allowed email domains admit email
+func (s OrganizationSettings) AllowsEmail(email string) bool {
+	if len(s.AllowedEmailDomains) == 0 {
+		return true
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	for _, d := range s.AllowedEmailDomains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
 }
 
 // Subscription represents a subscription for an organization
 type Subscription struct {
 	Base
-	OrganizationID   uint                 `json:"organization_id"`
-	SubscriptionPlan SubscriptionPlan     `json:"subscription_plan"`
-	Status           SubscriptionStatus   `json:"status"`
-	StartDate        time.Time            `json:"start_date"`
-	EndDate          time.Time            `json:"end_date"`
-	Transactions     []PaymentTransaction `json:"transactions"`
-	PaymentMethod    string               `json:"payment_method"`
-	LastPaymentDate  time.Time            `json:"last_payment_date"`
-	NextBillingDate  time.Time            `json:"next_billing_date"`
+	OrganizationID     uint                 `json:"organization_id"`
+	SubscriptionPlanID *uint                `json:"subscription_plan_id"`
+	SubscriptionPlan   SubscriptionPlan     `json:"subscription_plan"`
+	Status             SubscriptionStatus   `json:"status"`
+	StartDate          time.Time            `json:"start_date"`
+	EndDate            time.Time            `json:"end_date"`
+	Transactions       []PaymentTransaction `json:"transactions"`
+	PaymentMethod      string               `json:"payment_method"`
+	LastPaymentDate    time.Time            `json:"last_payment_date"`
+	NextBillingDate    time.Time            `json:"next_billing_date"`
 }
 
 // BeforeCreate is a GORM hook that runs before creating a new subscription
@@ -164,6 +224,13 @@ type SubscriptionPlan struct {
 	Currency    string    `json:"currency"`
 	Interval    string    `json:"interval"`
 	Features    []Feature `gorm:"many2many:subscription_plan_features;" json:"features"`
+	// MaxAttachmentSize caps the files organizations on the plan attach, in
+	// bytes; zero leaves the configured default
+	MaxAttachmentSize int64 `json:"max_attachment_size"`
+	// AttachmentTypes are the content types of the files organizations on the
+	// plan may attach, such as application/pdf or image/*; empty leaves the
+	// default ones
+	AttachmentTypes []string `gorm:"serializer:json" json:"attachment_types"`
 }
 
 // Feature represents a specific feature of a subscription plan
@@ -180,6 +247,9 @@ type Seat struct {
 	UserID         uint       `json:"user_id"`
 	Roles          []Role     `gorm:"many2many:seat_roles;" json:"roles"`
 	Status         SeatStatus `json:"status"`
+	// SuspendedAt is set on the seats made inactive by suspending their user,
+	// which reactivating the user makes active again
+	SuspendedAt *time.Time `json:"suspended_at,omitempty"`
 }
 
 // SeatStatus represents the status of a seat
@@ -204,15 +274,35 @@ type Domain struct {
 // AuditLog represents an audit log entry
 type AuditLog struct {
 	Base
-	UserID       uint         `json:"user_id"`
-	Action       string       `json:"action"`
-	ResourceType string       `json:"resource_type"`
-	ResourceID   uint         `json:"resource_id"`
-	Timestamp    time.Time    `json:"timestamp"`
-	Changes      JSONMap      `json:"changes" gorm:"type:jsonb"`
+	UserID         uint      `gorm:"index" json:"user_id"`
+	OrganizationID uint      `gorm:"index" json:"organization_id"`
+	Action         string    `json:"action"`
+	ResourceType   string    `json:"resource_type"`
+	ResourceID     uint      `json:"resource_id"`
+	Timestamp      time.Time `gorm:"index" json:"timestamp"`
+	Changes        JSONMap   `json:"changes" gorm:"serializer:json"`
+	// RequestID is the ID of the API request that made the change, if any
+	RequestID    string       `gorm:"index;size:128" json:"request_id,omitempty"`
+	PrevHash     string       `json:"prev_hash"`
+	Hash         string       `gorm:"index" json:"hash"`
 	Organization Organization `gorm:"foreignKey:OrganizationID" json:"organization"`
 }
 
+// AuditChainHead tracks the hash of the latest audit log entry of an organization
+type AuditChainHead struct {
+	OrganizationID uint   `gorm:"primaryKey" json:"organization_id"`
+	Hash           string `json:"hash"`
+	LastEntryID    uint   `json:"last_entry_id"`
+	PrunedHash     string `json:"pruned_hash"`
+}
+
+// Audit actions recorded for resource changes
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
 // PaymentTransaction represents a payment transaction
 type PaymentTransaction struct {
 	Base
@@ -240,11 +330,11 @@ type NotificationPreference struct {
 // ActivityLog represents user activity log
 type ActivityLog struct {
 	Base
-	UserID         uint      `json:"user_id"`
-	OrganizationID uint      `json:"organization_id"`
-	ActivityType   string    `json:"activity_type"`
-	Timestamp      time.Time `json:"timestamp"`
-	Metadata       JSONMap   `json:"metadata" gorm:"type:jsonb"`
+	UserID         uint      `gorm:"index" json:"user_id"`
+	OrganizationID uint      `gorm:"index" json:"organization_id"`
+	ActivityType   string    `gorm:"index" json:"activity_type"`
+	Timestamp      time.Time `gorm:"index" json:"timestamp"`
+	Metadata       JSONMap   `json:"metadata" gorm:"serializer:json"`
 }
 
 // APIKey represents an API key for authentication
@@ -254,7 +344,7 @@ type APIKey struct {
 	OrganizationID uint      `json:"organization_id"`
 	Key            string    `gorm:"unique" json:"key"`
 	Name           string    `json:"name"`
-	Permissions    []string  `json:"permissions" gorm:"type:jsonb"`
+	Permissions    []string  `json:"permissions" gorm:"serializer:json"`
 	ExpiresAt      time.Time `json:"expires_at"`
 	LastUsedAt     time.Time `json:"last_used_at"`
 }
@@ -272,34 +362,97 @@ type Workflow struct {
 	Base
 	Name           string         `json:"name"`
 	Description    string         `json:"description"`
-	Steps          []WorkflowStep `json:"steps" gorm:"type:jsonb"`
+	Steps          []WorkflowStep `json:"steps" gorm:"serializer:json"`
+	Triggers       []string       `json:"triggers" gorm:"serializer:json"`
 	OrganizationID uint           `json:"organization_id"`
-	CreatorID      uint           `json:"creator_id"`
-	Enabled        bool           `json:"enabled"`
+	// TeamID scopes the workflow to a team of its organization; nil shares it
+	// with the whole organization
+	TeamID    *uint `gorm:"index" json:"team_id"`
+	CreatorID uint  `json:"creator_id"`
+	Enabled   bool  `json:"enabled"`
+	Version   int   `json:"version"`
 }
 
 // WorkflowStep represents a step in a workflow process
 type WorkflowStep struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Order       int    `json:"order"`
-	Approver    string `json:"approver"`
-	Conditions  string `json:"conditions"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Order       int                `json:"order"`
+	Approver    string             `json:"approver"`
+	Conditions  string             `json:"conditions"`
+	Type        WorkflowActionType `json:"type"`
+	Config      JSONMap            `json:"config,omitempty"`
 }
 
 // Report represents a report definition
 type Report struct {
 	Base
-	Name           string    `json:"name"`
-	Description    string    `json:"description"`
-	Query          string    `json:"query"`
-	OrganizationID uint      `json:"organization_id"`
-	CreatorID      uint      `json:"creator_id"`
-	Schedule       string    `json:"schedule"`
-	Recipients     []string  `json:"recipients" gorm:"type:jsonb"`
-	LastRunAt      time.Time `json:"last_run_at"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	Definition     ReportQuery `json:"definition" gorm:"serializer:json"`
+	OrganizationID uint        `json:"organization_id"`
+	// TeamID scopes the report to a team of its organization; nil shares it
+	// with the whole organization
+	TeamID     *uint     `gorm:"index" json:"team_id"`
+	CreatorID  uint      `json:"creator_id"`
+	Schedule   string    `json:"schedule"`
+	Recipients []string  `json:"recipients" gorm:"serializer:json"`
+	Format     string    `json:"format"`
+	LastRunAt  time.Time `json:"last_run_at"`
+}
+
+// ReportRun represents a single execution of a report and its results
+type ReportRun struct {
+	Base
+	ReportID       uint            `gorm:"index" json:"report_id"`
+	OrganizationID uint            `gorm:"index" json:"organization_id"`
+	TriggeredBy    uint            `json:"triggered_by"`
+	Status         ReportRunStatus `json:"status"`
+	StartedAt      time.Time       `json:"started_at"`
+	FinishedAt     *time.Time      `json:"finished_at"`
+	RowCount       int             `json:"row_count"`
+	Truncated      bool            `json:"truncated"`
+	Columns        []string        `json:"columns" gorm:"serializer:json"`
+	Rows           [][]interface{} `json:"rows,omitempty" gorm:"serializer:json"`
+	Error          string          `json:"error,omitempty"`
+	Scheduled      bool            `json:"scheduled"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	DeliveryError  string          `json:"delivery_error,omitempty"`
+}
+
+// ReportExport represents a rendered report file generated in the background
+type ReportExport struct {
+	Base
+	ReportID       uint               `gorm:"index" json:"report_id"`
+	RunID          uint               `json:"run_id"`
+	OrganizationID uint               `gorm:"index" json:"organization_id"`
+	RequestedBy    uint               `json:"requested_by"`
+	Format         string             `json:"format"`
+	Filename       string             `json:"filename"`
+	Status         ReportExportStatus `json:"status"`
+	StorageKey     string             `json:"-"`
+	Size           int                `json:"size"`
+	Error          string             `json:"error,omitempty"`
 }
 
+// ReportExportStatus represents the state of a report export
+type ReportExportStatus string
+
+const (
+	ReportExportStatusPending ReportExportStatus = "pending"
+	ReportExportStatusReady   ReportExportStatus = "ready"
+	ReportExportStatusFailed  ReportExportStatus = "failed"
+)
+
+// ReportRunStatus represents the state of a report run
+type ReportRunStatus string
+
+const (
+	ReportRunStatusRunning   ReportRunStatus = "running"
+	ReportRunStatusSucceeded ReportRunStatus = "succeeded"
+	ReportRunStatusFailed    ReportRunStatus = "failed"
+)
+
 // JSONMap is a type for storing JSON data in the database
 type JSONMap map[string]interface{}
 
@@ -0,0 +1,38 @@
+// Package models/organization_export.go
+package models
+
+import "time"
+
+// OrganizationExportStatus is the state of an organization export
+type OrganizationExportStatus string
+
+const (
+	OrganizationExportPending OrganizationExportStatus = "pending"
+	OrganizationExportRunning OrganizationExportStatus = "running"
+	OrganizationExportReady   OrganizationExportStatus = "ready"
+	OrganizationExportFailed  OrganizationExportStatus = "failed"
+	// OrganizationExportExpired exports had their archive deleted
+	OrganizationExportExpired OrganizationExportStatus = "expired"
+)
+
+// OrganizationExport is an archive of all the data of an organization, built
+// in the background for backups or offboarding
+type OrganizationExport struct {
+	Base
+	OrganizationID uint                     `gorm:"index" json:"organization_id"`
+	RequestedBy    uint                     `json:"requested_by"`
+	Status         OrganizationExportStatus `gorm:"size:16" json:"status"`
+	// Progress is the percentage of the archive written
+	Progress int `json:"progress"`
+	// Section is the part of the data being written, such as members
+	Section    string `json:"section,omitempty"`
+	Filename   string `json:"filename"`
+	StorageKey string `json:"-"`
+	Size       int64  `json:"size"`
+	Error      string `json:"error,omitempty"`
+	// ExpiresAt is when the archive of a ready export is deleted
+	ExpiresAt *time.Time `json:"expires_at"`
+	// URL downloads the archive without authentication until it expires; it's
+	// signed whenever a ready export is returned
+	URL string `gorm:"-" json:"url,omitempty"`
+}
@@ -0,0 +1,36 @@
+// Package models/organization_restore.go
+package models
+
+// OrganizationRestoreStatus is the state of an organization restore
+type OrganizationRestoreStatus string
+
+const (
+	OrganizationRestorePending   OrganizationRestoreStatus = "pending"
+	OrganizationRestoreRunning   OrganizationRestoreStatus = "running"
+	OrganizationRestoreCompleted OrganizationRestoreStatus = "completed"
+	OrganizationRestoreFailed    OrganizationRestoreStatus = "failed"
+)
+
+// OrganizationRestore is the restore of an organization export archive into a
+// new organization, processed in the background
+type OrganizationRestore struct {
+	Base
+	// TargetOrganizationID is the organization created, once the restore
+	// completes
+	TargetOrganizationID *uint `gorm:"index" json:"target_organization_id"`
+	// SourceOrganizationID is the organization the archive was exported from
+	SourceOrganizationID uint                      `json:"source_organization_id"`
+	RequestedBy          uint                      `json:"requested_by"`
+	Name                 string                    `json:"name"`
+	Filename             string                    `json:"filename"`
+	Status               OrganizationRestoreStatus `gorm:"size:16" json:"status"`
+	Error                string                    `json:"error,omitempty"`
+	// UsersCreated counts the members created as users; the others matched
+	// existing users by email
+	UsersCreated int `json:"users_created"`
+	UsersMatched int `json:"users_matched"`
+	// Restored counts the records restored by section, such as workflows
+	Restored map[string]int `gorm:"serializer:json" json:"restored"`
+	// Warnings note what was left out, such as domains already taken
+	Warnings []string `gorm:"serializer:json" json:"warnings"`
+}
@@ -0,0 +1,33 @@
+// Package models/report_query.go
+package models
+
+// ReportQuery is a structured report definition compiled to SQL against an allowlisted schema
+type ReportQuery struct {
+	Entity     string            `json:"entity"`
+	Fields     []string          `json:"fields"`
+	Filters    []ReportFilter    `json:"filters"`
+	GroupBy    []string          `json:"group_by"`
+	Aggregates []ReportAggregate `json:"aggregates"`
+	OrderBy    []ReportOrder     `json:"order_by"`
+	Limit      int               `json:"limit"`
+}
+
+// ReportFilter restricts the rows of a report query
+type ReportFilter struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+// ReportAggregate computes an aggregate value over the grouped rows
+type ReportAggregate struct {
+	Func  string `json:"func"`
+	Field string `json:"field"`
+	Alias string `json:"alias"`
+}
+
+// ReportOrder sorts the results of a report query
+type ReportOrder struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
@@ -0,0 +1,53 @@
+// Package models/retention.go
+package models
+
+import "time"
+
+// Resources retention rules apply to, named after their tables
+const (
+	RetentionActivityLogs = "activity_logs"
+	RetentionAuditLogs    = "audit_logs"
+	RetentionAttachments  = "attachments"
+	RetentionReportRuns   = "report_runs"
+)
+
+// RetentionResources lists the resources retention rules can be set on
+var RetentionResources = []string{RetentionActivityLogs, RetentionAuditLogs, RetentionAttachments, RetentionReportRuns}
+
+// RetentionAction is what happens to records past their retention period
+type RetentionAction string
+
+const (
+	// RetentionArchive writes the records to the object storage before
+	// deleting them
+	RetentionArchive RetentionAction = "archive"
+	// RetentionPurge deletes the records without keeping a copy
+	RetentionPurge RetentionAction = "purge"
+)
+
+// RetentionRule is how long an organization keeps the records of a resource.
+// Rules on audit and activity logs override the retention days of the
+// organization's settings.
+type RetentionRule struct {
+	Base
+	OrganizationID uint            `gorm:"uniqueIndex:idx_retention_rules_org_resource" json:"organization_id"`
+	Resource       string          `gorm:"size:32;uniqueIndex:idx_retention_rules_org_resource" json:"resource"`
+	Days           int             `json:"days"`
+	Action         RetentionAction `gorm:"size:16" json:"action"`
+}
+
+// RetentionRemoval records the records of a resource an organization's
+// retention policy removed in one run
+type RetentionRemoval struct {
+	Base
+	OrganizationID uint            `gorm:"index" json:"organization_id"`
+	Resource       string          `gorm:"size:32" json:"resource"`
+	Action         RetentionAction `gorm:"size:16" json:"action"`
+	Days           int             `json:"days"`
+	// Cutoff is the time the records removed were created before
+	Cutoff time.Time `json:"cutoff"`
+	Count  int       `json:"count"`
+	// ArchiveKeys are the storage keys of the archives of the records, as
+	// gzipped NDJSON
+	ArchiveKeys []string `gorm:"serializer:json" json:"archive_keys"`
+}
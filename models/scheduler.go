@@ -0,0 +1,36 @@
+// Package models/scheduler.go
+package models
+
+import "time"
+
+// ScheduledJob represents a recurring job definition run by the scheduler
+type ScheduledJob struct {
+	Base
+	Name          string        `gorm:"uniqueIndex" json:"name"`
+	Handler       string        `gorm:"index" json:"handler"`
+	Schedule      string        `json:"schedule"`
+	Timezone      string        `json:"timezone"`
+	MisfirePolicy MisfirePolicy `json:"misfire_policy"`
+	Enabled       bool          `json:"enabled"`
+	Payload       JSONMap       `gorm:"serializer:json" json:"payload"`
+	LastRunAt     *time.Time    `json:"last_run_at"`
+	NextRunAt     time.Time     `gorm:"index" json:"next_run_at"`
+	LastError     string        `json:"last_error"`
+}
+
+// MisfirePolicy determines what happens when a job missed its scheduled time
+type MisfirePolicy string
+
+const (
+	// MisfirePolicyFireOnce runs a missed job once and then resumes the schedule
+	MisfirePolicyFireOnce MisfirePolicy = "fire_once"
+	// MisfirePolicySkip drops missed runs and waits for the next scheduled time
+	MisfirePolicySkip MisfirePolicy = "skip"
+)
+
+// SchedulerLease represents the leadership lease held by one scheduler instance
+type SchedulerLease struct {
+	Name      string    `gorm:"primaryKey" json:"name"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,26 @@
+// Package models/settings.go
+package models
+
+import "time"
+
+// RuntimeSetting is a platform setting changed at runtime, overriding its default
+type RuntimeSetting struct {
+	Key   string `gorm:"primaryKey;column:setting_key;size:191" json:"key"`
+	Value string `json:"value"`
+	// UpdatedBy is the admin who last changed the setting
+	UpdatedBy uint      `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RuntimeSettingChange records a change of a runtime setting. A nil value is
+// the default, before the setting was first changed or after it was reset.
+type RuntimeSettingChange struct {
+	ID       uint    `gorm:"primaryKey" json:"id"`
+	Key      string  `gorm:"column:setting_key;index;size:191" json:"key"`
+	OldValue *string `json:"old_value"`
+	NewValue *string `json:"new_value"`
+	UserID   uint    `json:"user_id"`
+	// RequestID is the ID of the API request that made the change
+	RequestID string    `gorm:"size:128" json:"request_id,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
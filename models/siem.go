@@ -0,0 +1,22 @@
+// Package models/siem.go
+package models
+
+// SIEMIntegration represents an organization's audit event forwarding target
+type SIEMIntegration struct {
+	Base
+	OrganizationID uint         `gorm:"uniqueIndex" json:"organization_id"`
+	Type           SIEMType     `json:"type"`
+	Endpoint       string       `json:"endpoint"`
+	Token          string       `gorm:"serializer:encrypted" json:"-"`
+	Enabled        bool         `json:"enabled"`
+	LastError      string       `json:"last_error"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+}
+
+// SIEMType represents the transport used to forward audit events
+type SIEMType string
+
+const (
+	SIEMTypeSyslog SIEMType = "syslog"
+	SIEMTypeHTTP   SIEMType = "http"
+)
@@ -0,0 +1,28 @@
+// Package models/tag.go
+package models
+
+// Types of the records tags can be attached to, named after their tables
+const (
+	TaggableUser     = "users"
+	TaggableWorkflow = "workflows"
+	TaggableReport   = "reports"
+)
+
+// Tag is a label of an organization's vocabulary, attached to its members,
+// workflows and reports through taggings
+type Tag struct {
+	Base
+	OrganizationID uint   `gorm:"index" json:"organization_id"`
+	Name           string `gorm:"size:50" json:"name"`
+	// Color is a hex color such as #1f6feb, empty for the default one
+	Color string `gorm:"size:7" json:"color"`
+}
+
+// Tagging attaches a tag to a record, named by its type and ID
+type Tagging struct {
+	Base
+	OrganizationID uint   `gorm:"index" json:"organization_id"`
+	TagID          uint   `gorm:"uniqueIndex:idx_taggings_tag_taggable" json:"tag_id"`
+	TaggableType   string `gorm:"size:32;uniqueIndex:idx_taggings_tag_taggable;index:idx_taggings_taggable" json:"taggable_type"`
+	TaggableID     uint   `gorm:"uniqueIndex:idx_taggings_tag_taggable;index:idx_taggings_taggable" json:"taggable_id"`
+}
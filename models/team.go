@@ -0,0 +1,22 @@
+// Package models/team.go
+package models
+
+// Team is a group of an organization's members that workflows and reports can
+// be scoped to
+type Team struct {
+	Base
+	OrganizationID uint         `gorm:"index" json:"organization_id"`
+	Name           string       `gorm:"size:100" json:"name"`
+	Description    string       `json:"description"`
+	Members        []TeamMember `json:"members,omitempty"`
+}
+
+// TeamMember is the membership of a user in a team, with the roles granting
+// their permissions within it
+type TeamMember struct {
+	Base
+	OrganizationID uint   `gorm:"index" json:"organization_id"`
+	TeamID         uint   `gorm:"uniqueIndex:idx_team_members_team_user" json:"team_id"`
+	UserID         uint   `gorm:"uniqueIndex:idx_team_members_team_user;index" json:"user_id"`
+	Roles          []Role `gorm:"many2many:team_member_roles;" json:"roles"`
+}
@@ -0,0 +1,41 @@
+// Package models/ticket.go
+package models
+
+import "time"
+
+// TicketStatus is where a support ticket stands
+type TicketStatus string
+
+// Statuses of tickets
+const (
+	// TicketStatusOpen awaits a reply of support
+	TicketStatusOpen TicketStatus = "open"
+	// TicketStatusPending awaits a reply of the user
+	TicketStatusPending  TicketStatus = "pending"
+	TicketStatusResolved TicketStatus = "resolved"
+	// TicketStatusClosed takes no more replies
+	TicketStatusClosed TicketStatus = "closed"
+)
+
+// Ticket is a support request a user opened for an organization
+type Ticket struct {
+	Base
+	OrganizationID uint `gorm:"index" json:"organization_id"`
+	// UserID is the user who opened the ticket
+	UserID  uint         `gorm:"index" json:"user_id"`
+	Subject string       `gorm:"size:200" json:"subject"`
+	Status  TicketStatus `gorm:"size:16;index" json:"status"`
+	// LastMessageAt is when the latest message was posted, which tickets are listed by
+	LastMessageAt time.Time       `gorm:"index" json:"last_message_at"`
+	Messages      []TicketMessage `json:"messages,omitempty"`
+}
+
+// TicketMessage is a message of a ticket's conversation
+type TicketMessage struct {
+	Base
+	TicketID uint   `gorm:"index" json:"ticket_id"`
+	UserID   uint   `json:"user_id"`
+	Body     string `json:"body"`
+	// Staff is set on the replies of platform admins
+	Staff bool `json:"staff"`
+}
@@ -0,0 +1,62 @@
+// Package models/user_import.go
+package models
+
+// UserImport is an import of an organization's users from a CSV file or a
+// list of emails to invite, processed in the background with the outcome of
+// every row
+type UserImport struct {
+	Base
+	OrganizationID uint   `gorm:"index" json:"organization_id"`
+	RequestedBy    uint   `json:"requested_by"`
+	Filename       string `json:"filename"`
+	// Invite emails the users an invitation, leaving their seats invited until
+	// they accept it; otherwise they become members with an active seat at once
+	Invite    bool             `json:"invite"`
+	Status    UserImportStatus `json:"status"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	// Skipped counts the rows left out as their user already holds a seat or
+	// repeats an earlier row
+	Skipped int             `json:"skipped"`
+	Rows    []UserImportRow `gorm:"serializer:json" json:"rows"`
+}
+
+// UserImportRow is the outcome of one row of a user import
+type UserImportRow struct {
+	// Line is the line of the row in the file, the header being line 1, or
+	// the position of the email in a list of invitations, from 1
+	Line   int                 `json:"line"`
+	Email  string              `json:"email"`
+	Name   string              `json:"name,omitempty"`
+	Role   string              `json:"role,omitempty"`
+	Status UserImportRowStatus `json:"status"`
+	UserID uint                `json:"user_id,omitempty"`
+	SeatID uint                `json:"seat_id,omitempty"`
+	// Invited is set once the invitation was emailed
+	Invited bool   `json:"invited,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// UserImportStatus represents the state of a user import
+type UserImportStatus string
+
+const (
+	UserImportStatusPending   UserImportStatus = "pending"
+	UserImportStatusRunning   UserImportStatus = "running"
+	UserImportStatusCompleted UserImportStatus = "completed"
+)
+
+// UserImportRowStatus represents the outcome of a row of a user import
+type UserImportRowStatus string
+
+const (
+	// UserImportRowCreated created the user and their seat
+	UserImportRowCreated UserImportRowStatus = "created"
+	// UserImportRowAdded gave an existing user a seat
+	UserImportRowAdded UserImportRowStatus = "added"
+	// UserImportRowSkipped left the row out, as its user already holds a
+	// seat, possibly invited, or an earlier row has the email
+	UserImportRowSkipped UserImportRowStatus = "skipped"
+	UserImportRowFailed  UserImportRowStatus = "failed"
+)
@@ -0,0 +1,149 @@
+// Package models/workflow.go
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkflowRun represents a single execution of a workflow
+type WorkflowRun struct {
+	Base
+	WorkflowID      uint              `gorm:"index" json:"workflow_id"`
+	WorkflowVersion int               `json:"workflow_version"`
+	OrganizationID  uint              `gorm:"index" json:"organization_id"`
+	TriggeredBy     uint              `json:"triggered_by"`
+	Status          WorkflowRunStatus `gorm:"index" json:"status"`
+	CurrentStep     int               `json:"current_step"`
+	Input           JSONMap           `json:"input" gorm:"serializer:json"`
+	Definition      []WorkflowStep    `json:"-" gorm:"serializer:json"`
+	Error           string            `json:"error,omitempty"`
+	StartedAt       time.Time         `gorm:"index" json:"started_at"`
+	FinishedAt      *time.Time        `json:"finished_at"`
+	Steps           []WorkflowStepRun `gorm:"foreignKey:RunID" json:"steps,omitempty"`
+	// DurationMS is how long the run took, or has been running so far
+	DurationMS int64 `gorm:"-" json:"duration_ms"`
+}
+
+// AfterFind is a GORM hook that computes the run's duration
+func (r *WorkflowRun) AfterFind(tx *gorm.DB) error {
+	r.DurationMS = elapsed(&r.StartedAt, r.FinishedAt)
+	return nil
+}
+
+// WorkflowRunStatus represents the state of a workflow run
+type WorkflowRunStatus string
+
+const (
+	WorkflowRunStatusRunning   WorkflowRunStatus = "running"
+	WorkflowRunStatusWaiting   WorkflowRunStatus = "waiting"
+	WorkflowRunStatusCompleted WorkflowRunStatus = "completed"
+	WorkflowRunStatusFailed    WorkflowRunStatus = "failed"
+	WorkflowRunStatusCanceled  WorkflowRunStatus = "canceled"
+)
+
+// WorkflowStepRun records the execution of one step within a workflow run
+type WorkflowStepRun struct {
+	Base
+	RunID      uint               `gorm:"index" json:"run_id"`
+	Position   int                `gorm:"index" json:"position"`
+	Name       string             `json:"name"`
+	Status     WorkflowStepStatus `json:"status"`
+	Attempts   int                `json:"attempts"`
+	Output     JSONMap            `json:"output" gorm:"serializer:json"`
+	Error      string             `json:"error,omitempty"`
+	StartedAt  *time.Time         `json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at"`
+	// ResumeAt is when a waiting step completes on its own, such as the end of a delay
+	ResumeAt *time.Time `gorm:"index" json:"resume_at,omitempty"`
+	// DurationMS is how long the step took, or has been running so far
+	DurationMS int64 `gorm:"-" json:"duration_ms"`
+}
+
+// AfterFind is a GORM hook that computes the step's duration
+func (s *WorkflowStepRun) AfterFind(tx *gorm.DB) error {
+	s.DurationMS = elapsed(s.StartedAt, s.FinishedAt)
+	return nil
+}
+
+// elapsed returns the milliseconds between start and finish, or until now if unfinished
+func elapsed(start, finish *time.Time) int64 {
+	if start == nil || start.IsZero() {
+		return 0
+	}
+	end := time.Now()
+	if finish != nil {
+		end = *finish
+	}
+	return end.Sub(*start).Milliseconds()
+}
+
+// WorkflowStepStatus represents the state of a step within a run
+type WorkflowStepStatus string
+
+const (
+	WorkflowStepStatusPending   WorkflowStepStatus = "pending"
+	WorkflowStepStatusRunning   WorkflowStepStatus = "running"
+	WorkflowStepStatusWaiting   WorkflowStepStatus = "waiting"
+	WorkflowStepStatusCompleted WorkflowStepStatus = "completed"
+	WorkflowStepStatusSkipped   WorkflowStepStatus = "skipped"
+	WorkflowStepStatusFailed    WorkflowStepStatus = "failed"
+	WorkflowStepStatusCanceled  WorkflowStepStatus = "canceled"
+)
+
+// WorkflowActionType identifies what a workflow step does when executed
+type WorkflowActionType string
+
+const (
+	// WorkflowActionNone marks a plain step that completes immediately
+	WorkflowActionNone         WorkflowActionType = ""
+	WorkflowActionEmail        WorkflowActionType = "email"
+	WorkflowActionWebhook      WorkflowActionType = "webhook"
+	WorkflowActionWait         WorkflowActionType = "wait"
+	WorkflowActionApproval     WorkflowActionType = "approval"
+	WorkflowActionUpdateRecord WorkflowActionType = "update_record"
+)
+
+// WorkflowApproval asks one user to approve or reject a waiting approval step
+type WorkflowApproval struct {
+	Base
+	RunID           uint                   `gorm:"index" json:"run_id"`
+	StepRunID       uint                   `gorm:"index" json:"step_run_id"`
+	Position        int                    `json:"position"`
+	OrganizationID  uint                   `gorm:"index" json:"organization_id"`
+	ApproverID      uint                   `gorm:"index" json:"approver_id"`
+	DelegatedFromID *uint                  `json:"delegated_from_id,omitempty"`
+	Status          WorkflowApprovalStatus `gorm:"index" json:"status"`
+	Message         string                 `json:"message"`
+	Comment         string                 `json:"comment"`
+	DecidedAt       *time.Time             `json:"decided_at"`
+	RemindedAt      *time.Time             `json:"reminded_at"`
+}
+
+// WorkflowApprovalStatus represents the state of an approval request
+type WorkflowApprovalStatus string
+
+const (
+	WorkflowApprovalStatusPending   WorkflowApprovalStatus = "pending"
+	WorkflowApprovalStatusApproved  WorkflowApprovalStatus = "approved"
+	WorkflowApprovalStatusRejected  WorkflowApprovalStatus = "rejected"
+	WorkflowApprovalStatusDelegated WorkflowApprovalStatus = "delegated"
+	WorkflowApprovalStatusCanceled  WorkflowApprovalStatus = "canceled"
+)
+
+// WorkflowVersion is a snapshot of a workflow's definition. Runs keep the definition
+// of the version they started on, so editing a workflow never changes runs in flight.
+type WorkflowVersion struct {
+	Base
+	WorkflowID     uint           `gorm:"uniqueIndex:idx_workflow_version" json:"workflow_id"`
+	Version        int            `gorm:"uniqueIndex:idx_workflow_version" json:"version"`
+	OrganizationID uint           `gorm:"index" json:"organization_id"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	Steps          []WorkflowStep `json:"steps" gorm:"serializer:json"`
+	Triggers       []string       `json:"triggers" gorm:"serializer:json"`
+	CreatedBy      uint           `json:"created_by"`
+	// RolledBackFrom is the earlier version this one restored, if any
+	RolledBackFrom *int `json:"rolled_back_from,omitempty"`
+}
@@ -0,0 +1,92 @@
+// Package netguard/netguard.go
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is returned when a host resolves to an address that
+// isn't reachable from the internet, such as a loopback or private one
+var ErrNonPublicAddress = errors.New("address is not public")
+
+// nonPublicPrefixes are the special-purpose ranges netip doesn't classify:
+// "this network", carrier-grade NAT, IETF protocol assignments, benchmarking,
+// reserved and NAT64 ranges
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// Dialer returns a dialer that only connects to public addresses. The check
+// applies to the address of every connection rather than to a URL, so neither
+// redirects nor DNS rebinding reach internal services.
+func Dialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{Timeout: timeout, Control: refuseNonPublic}
+}
+
+// refuseNonPublic is a net.Dialer Control function rejecting connections to
+// non-public addresses, after the host name was resolved
+func refuseNonPublic(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !PublicAddr(ip) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, ip)
+	}
+	return nil
+}
+
+// PublicAddr reports whether an address is reachable from the internet
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves a host name or address and fails unless all its addresses
+// are public, to reject internal endpoints when they are configured. Dial with
+// Dialer as well, since the host may resolve differently later.
+func CheckHost(ctx context.Context, host string) error {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !PublicAddr(ip) {
+			return fmt.Errorf("%w: %s", ErrNonPublicAddress, ip)
+		}
+		return nil
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !PublicAddr(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrNonPublicAddress, host, ip)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package netguard/netguard_test.go
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.215.14":      true,
+		"2606:4700::6810:1":  true,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"10.1.2.3":           false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"169.254.169.254":    false,
+		"fe80::1":            false,
+		"fd00::1":            false,
+		"0.0.0.0":            false,
+		"100.64.0.1":         false,
+		"::ffff:127.0.0.1":   false,
+		"::ffff:10.0.0.1":    false,
+		"64:ff9b::a00:1":     false,
+		"::ffff:93.184.1.14": true,
+	}
+	for addr, want := range tests {
+		if got := PublicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "169.254.169.254", "localhost", "::1"} {
+		if err := CheckHost(context.Background(), host); !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("CheckHost(%s) = %v, want ErrNonPublicAddress", host, err)
+		}
+	}
+	if err := CheckHost(context.Background(), "93.184.215.14"); err != nil {
+		t.Errorf("CheckHost(93.184.215.14) = %v, want nil", err)
+	}
+}
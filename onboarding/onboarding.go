@@ -0,0 +1,148 @@
+// Package onboarding/onboarding.go
+//
+// Package onboarding computes the setup checklist of organizations from their
+// data, so a step is done once what it asks for exists rather than when it's
+// ticked off.
+package onboarding
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// Step is a setup step of organizations
+type Step struct {
+	Key         string
+	Title       string
+	Description string
+	// Done reports whether the organization completed the step
+	Done func(db *gorm.DB, orgID uint) (bool, error)
+}
+
+// StepStatus is a step of an organization's checklist
+type StepStatus struct {
+	Key         string `json:"key"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Completed   bool   `json:"completed"`
+}
+
+// Checklist is the setup progress of an organization, its steps in order
+type Checklist struct {
+	OrganizationID uint         `json:"organization_id"`
+	Steps          []StepStatus `json:"steps"`
+	Completed      int          `json:"completed"`
+	Total          int          `json:"total"`
+	// Done is set once every step is completed, for the frontend to hide the checklist
+	Done bool `json:"done"`
+}
+
+// DefaultSteps are the steps every organization goes through
+var DefaultSteps = []Step{
+	{
+		Key:         "verify_email",
+		Title:       "Verify your email",
+		Description: "Every member of the organization confirmed their email address.",
+		Done:        membersVerified,
+	},
+	{
+		Key:         "invite_teammates",
+		Title:       "Invite your teammates",
+		Description: "Someone besides the first member joined or was invited.",
+		Done:        teammatesInvited,
+	},
+	{
+		Key:         "add_payment_method",
+		Title:       "Add a payment method",
+		Description: "A subscription of the organization has a payment method.",
+		Done:        paymentMethodAdded,
+	},
+	{
+		Key:         "configure_domain",
+		Title:       "Configure your domain",
+		Description: "The organization's white-label domain is set.",
+		Done:        domainConfigured,
+	},
+}
+
+// Engine computes the checklists of organizations
+type Engine struct {
+	DB    *gorm.DB
+	Steps []Step
+}
+
+// New creates an engine checking the default steps
+func New(db *gorm.DB) *Engine {
+	return &Engine{DB: db, Steps: DefaultSteps}
+}
+
+// Checklist returns the checklist of an organization, or gorm.ErrRecordNotFound
+// if it doesn't exist
+func (e *Engine) Checklist(ctx context.Context, orgID uint) (*Checklist, error) {
+	db := e.DB.WithContext(ctx)
+	if err := db.Select("id").First(&models.Organization{}, orgID).Error; err != nil {
+		return nil, err
+	}
+
+	list := &Checklist{OrganizationID: orgID, Steps: make([]StepStatus, len(e.Steps)), Total: len(e.Steps)}
+	for i, step := range e.Steps {
+		done, err := step.Done(db.Session(&gorm.Session{NewDB: true}), orgID)
+		if err != nil {
+			return nil, fmt.Errorf("check step %s: %w", step.Key, err)
+		}
+		list.Steps[i] = StepStatus{Key: step.Key, Title: step.Title, Description: step.Description, Completed: done}
+		if done {
+			list.Completed++
+		}
+	}
+	list.Done = list.Completed == list.Total
+	return list, nil
+}
+
+// members returns the query of the IDs of an organization's members
+func members(db *gorm.DB, orgID uint) *gorm.DB {
+	return db.Table("user_organizations").Select("user_id").Where("organization_id = ?", orgID)
+}
+
+// membersVerified checks that the organization has members, all verified
+func membersVerified(db *gorm.DB, orgID uint) (bool, error) {
+	var total, unverified int64
+	if err := db.Table("user_organizations").Where("organization_id = ?", orgID).Count(&total).Error; err != nil {
+		return false, err
+	}
+	err := db.Model(&models.User{}).Where("id IN (?) AND verified = ?", members(db, orgID), false).Count(&unverified).Error
+	return total > 0 && unverified == 0, err
+}
+
+// teammatesInvited checks that the organization has more than one member, or
+// invited someone
+func teammatesInvited(db *gorm.DB, orgID uint) (bool, error) {
+	var total, invited int64
+	if err := db.Table("user_organizations").Where("organization_id = ?", orgID).Count(&total).Error; err != nil {
+		return false, err
+	}
+	if total > 1 {
+		return true, nil
+	}
+	err := db.Model(&models.Invitation{}).Where("organization_id = ?", orgID).Count(&invited).Error
+	return invited > 0, err
+}
+
+// paymentMethodAdded checks that a subscription of the organization has a
+// payment method
+func paymentMethodAdded(db *gorm.DB, orgID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.Subscription{}).Where("organization_id = ? AND payment_method <> ?", orgID, "").Count(&count).Error
+	return count > 0, err
+}
+
+// domainConfigured checks that the organization set its white-label domain
+func domainConfigured(db *gorm.DB, orgID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.Organization{}).Where("id = ? AND domain IS NOT NULL AND domain <> ?", orgID, "").Count(&count).Error
+	return count > 0, err
+}
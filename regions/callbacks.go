@@ -0,0 +1,115 @@
+// Package regions/callbacks.go
+package regions
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// RegisterCallbacks installs GORM callbacks rejecting the statements that
+// write or read the records of organizations kept in another region, so a
+// misrouted request or job fails rather than mixing data between regions.
+// Records are checked by the organization they carry: statements filtering on
+// an organization alone, such as bulk updates, are left to the middleware.
+func (r *Regions) RegisterCallbacks(db *gorm.DB) error {
+	cb := db.Callback()
+
+	if err := cb.Create().Before("gorm:create").Register("regions:before_create", r.checkWrite); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("regions:before_update", r.checkWrite); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("regions:before_delete", r.checkWrite); err != nil {
+		return err
+	}
+	return cb.Query().After("gorm:query").Register("regions:after_query", r.checkRead)
+}
+
+// checkWrite rejects writing records of organizations kept in another region,
+// and organizations set in another region
+func (r *Regions) checkWrite(db *gorm.DB) {
+	if !r.Enabled() || db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+
+	organizations := db.Statement.Schema.Table == "organizations"
+	eachRecord(db, func(rv reflect.Value) bool {
+		if region := rv.FieldByName("Region"); organizations && region.String() != "" && region.String() != r.Region {
+			misdirected := r.Misdirected(region.String())
+			misdirected.Err = fmt.Errorf("%w: organization set in %s", ErrCrossRegion, region.String())
+			db.AddError(misdirected)
+			return false
+		}
+		if err := r.check(db, owner(db, rv)); err != nil {
+			db.AddError(err)
+			return false
+		}
+		return true
+	})
+}
+
+// checkRead rejects the results holding records of organizations kept in
+// another region, clearing them. Organizations themselves can be read, as a
+// directory of where each one is.
+func (r *Regions) checkRead(db *gorm.DB) {
+	if !r.Enabled() || db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.Table == "organizations" {
+		return
+	}
+
+	eachRecord(db, func(rv reflect.Value) bool {
+		if err := r.check(db, owner(db, rv)); err != nil {
+			db.AddError(err)
+			if dest := reflect.Indirect(db.Statement.ReflectValue); dest.CanSet() {
+				dest.Set(reflect.Zero(dest.Type()))
+			}
+			return false
+		}
+		return true
+	})
+}
+
+// eachRecord calls fn for every record of the statement's model, until it
+// returns false
+func eachRecord(db *gorm.DB, fn func(reflect.Value) bool) {
+	model := db.Statement.Schema.ModelType
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if record := reflect.Indirect(rv.Index(i)); record.Type() == model && !fn(record) {
+				return
+			}
+		}
+	case reflect.Struct:
+		if rv.Type() == model {
+			fn(rv)
+		}
+	}
+}
+
+// owner returns the organization a record belongs to: its own ID for
+// organizations, or else its OrganizationID. It's zero for records of no
+// organization.
+func owner(db *gorm.DB, rv reflect.Value) uint {
+	s := db.Statement.Schema
+	field := s.LookUpField("OrganizationID")
+	if s.Table == "organizations" {
+		field = s.PrioritizedPrimaryField
+	}
+	if field == nil {
+		return 0
+	}
+
+	switch id, _ := field.ValueOf(db.Statement.Context, rv); v := id.(type) {
+	case uint:
+		return v
+	case *uint:
+		if v != nil {
+			return *v
+		}
+	}
+	return 0
+}
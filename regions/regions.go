@@ -0,0 +1,146 @@
+// Package regions/regions.go
+package regions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/activity"
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/middleware"
+	"github.com/4cecoder/saas/models"
+)
+
+// ErrCrossRegion is returned when the data of an organization kept in another
+// region is read or written
+var ErrCrossRegion = errors.New("organization data is kept in another region")
+
+// Config pins a deployment to a region. Each region runs its own deployment,
+// with its own database and storage bucket.
+type Config struct {
+	// Region is the region of this deployment; residency isn't enforced without it
+	Region string
+	// URLs are the API URLs of the deployments of every region, including this
+	// one, by region
+	URLs map[string]string
+}
+
+// Enabled reports whether the deployment is pinned to a region
+func (c Config) Enabled() bool {
+	return c.Region != ""
+}
+
+// Known reports whether region is one of the configured regions
+func (c Config) Known(region string) bool {
+	_, ok := c.URLs[region]
+	return ok
+}
+
+// Regions resolves the regions of organizations and rejects the requests,
+// queries and files of those kept in another region
+type Regions struct {
+	Config
+	DB *gorm.DB
+	// regions caches the region of organizations by ID; it never changes
+	regions sync.Map
+}
+
+// New creates the regions of the deployment, looking organizations up in db
+func New(db *gorm.DB, cfg Config) *Regions {
+	return &Regions{Config: cfg, DB: db}
+}
+
+// Of returns the region of an organization. Organizations without one, and
+// those that don't exist, belong to this deployment's region.
+func (r *Regions) Of(ctx context.Context, orgID uint) (string, error) {
+	return r.lookup(r.DB.WithContext(ctx), orgID)
+}
+
+// lookup returns the region of an organization, reading it with db so the
+// organizations created in its transaction are seen
+func (r *Regions) lookup(db *gorm.DB, orgID uint) (string, error) {
+	if region, ok := r.regions.Load(orgID); ok {
+		return region.(string), nil
+	}
+
+	var regions []string
+	err := db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Organization{}).
+		Unscoped().
+		Where("id = ?", orgID).
+		Pluck("region", &regions).Error
+	if err != nil {
+		return "", err
+	}
+	if len(regions) == 0 {
+		return r.Region, nil
+	}
+
+	region := regions[0]
+	if region == "" {
+		region = r.Region
+	}
+	r.regions.Store(orgID, region)
+	return region, nil
+}
+
+// Check returns an error wrapping ErrCrossRegion if the organization's data is
+// kept in another region
+func (r *Regions) Check(ctx context.Context, orgID uint) error {
+	return r.check(r.DB.WithContext(ctx), orgID)
+}
+
+func (r *Regions) check(db *gorm.DB, orgID uint) error {
+	if !r.Enabled() || orgID == 0 {
+		return nil
+	}
+	region, err := r.lookup(db, orgID)
+	if err != nil {
+		return err
+	}
+	if region == r.Region {
+		return nil
+	}
+	misdirected := r.Misdirected(region)
+	misdirected.Err = fmt.Errorf("%w: organization %d is in %s", ErrCrossRegion, orgID, region)
+	return misdirected
+}
+
+// Misdirected returns the error sending clients to the deployment of region
+func (r *Regions) Misdirected(region string) *apperror.Error {
+	err := apperror.New(http.StatusMisdirectedRequest, apperror.CodeWrongRegion,
+		fmt.Sprintf("The organization's data is kept in region %s, send the request there", region)).
+		With("region", region)
+	if url, ok := r.URLs[region]; ok {
+		err = err.With("url", url)
+	}
+	return err
+}
+
+// Middleware answers 421 to the requests for organizations kept in another
+// region, with the URL of the deployment serving them. The organization is
+// the one in the path of /organizations/:id routes, or else the one the token
+// is scoped to. It runs after auth.Identify.
+func (r *Regions) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !r.Enabled() {
+			c.Next()
+			return
+		}
+		orgID, ok := activity.OrganizationID(c)
+		if !ok {
+			orgID = c.GetUint("token_org_id")
+		}
+		if err := r.Check(c.Request.Context(), orgID); err != nil {
+			middleware.Abort(c, err)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+// Package regions/storage.go
+package regions
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/4cecoder/saas/storage"
+)
+
+// Storage rejects the objects of organizations kept in another region, so
+// their files stay in their region's bucket. Keys name the organization after
+// their prefix, as in attachments/<organization ID>/...; other keys pass.
+type Storage struct {
+	storage.Storage
+	Regions *Regions
+}
+
+// Storage wraps store to keep the objects of other regions out of it
+func (r *Regions) Storage(store storage.Storage) *Storage {
+	return &Storage{Storage: store, Regions: r}
+}
+
+// check rejects keys of organizations kept in another region
+func (s *Storage) check(ctx context.Context, key string) error {
+	_, rest, _ := strings.Cut(key, "/")
+	segment, _, _ := strings.Cut(rest, "/")
+	orgID, err := strconv.ParseUint(segment, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return s.Regions.Check(ctx, uint(orgID))
+}
+
+// Put writes an object of this region
+func (s *Storage) Put(ctx context.Context, key string, r io.Reader) error {
+	if err := s.check(ctx, key); err != nil {
+		return err
+	}
+	return s.Storage.Put(ctx, key, r)
+}
+
+// Get opens an object of this region
+func (s *Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := s.check(ctx, key); err != nil {
+		return nil, err
+	}
+	return s.Storage.Get(ctx, key)
+}
+
+// Delete removes an object of this region
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	if err := s.check(ctx, key); err != nil {
+		return err
+	}
+	return s.Storage.Delete(ctx, key)
+}
@@ -0,0 +1,161 @@
+// Package reports/delivery.go
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/scheduler"
+)
+
+// deliverHandler is the scheduler handler name for report delivery jobs
+const deliverHandler = "reports.deliver"
+
+// Delivery runs scheduled reports and emails the results to their recipients
+type Delivery struct {
+	Engine *Engine
+	Mailer mailer.Mailer
+}
+
+// NewDelivery creates a new report delivery service
+func NewDelivery(engine *Engine, m mailer.Mailer) *Delivery {
+	return &Delivery{Engine: engine, Mailer: m}
+}
+
+// Register adds the delivery handler to the scheduler
+func (d *Delivery) Register(s *scheduler.Scheduler) {
+	s.Register(deliverHandler, d.handle)
+}
+
+// jobName returns the scheduled job name for a report
+func jobName(reportID uint) string {
+	return fmt.Sprintf("report:%d", reportID)
+}
+
+// SyncSchedule creates, updates, or disables the scheduled job for a report so it runs
+// on the report's cron schedule in its creator's timezone
+func SyncSchedule(db *gorm.DB, report *models.Report) error {
+	var job models.ScheduledJob
+	err := db.Unscoped().Where("name = ?", jobName(report.ID)).First(&job).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if report.Schedule == "" || len(report.Recipients) == 0 || report.DeletedAt.Valid {
+		if job.ID == 0 {
+			return nil
+		}
+		return db.Model(&job).Update("enabled", false).Error
+	}
+
+	var creator models.User
+	db.Select("timezone").First(&creator, report.CreatorID)
+
+	next, err := scheduler.NextRun(report.Schedule, creator.Timezone, time.Now())
+	if err != nil {
+		return err
+	}
+
+	job.Name = jobName(report.ID)
+	job.Handler = deliverHandler
+	job.Schedule = report.Schedule
+	job.Timezone = creator.Timezone
+	job.MisfirePolicy = models.MisfirePolicySkip
+	job.Enabled = true
+	job.Payload = models.JSONMap{"report_id": report.ID}
+	job.NextRunAt = next
+	job.DeletedAt = gorm.DeletedAt{}
+
+	return db.Unscoped().Save(&job).Error
+}
+
+// ValidateSchedule reports whether a cron schedule can be parsed
+func ValidateSchedule(schedule string) error {
+	if schedule == "" {
+		return nil
+	}
+	_, err := scheduler.NextRun(schedule, "", time.Now())
+	return err
+}
+
+// handle runs the report referenced by the job payload and delivers the results
+func (d *Delivery) handle(ctx context.Context, job *models.ScheduledJob) error {
+	reportID, err := strconv.ParseUint(fmt.Sprint(job.Payload["report_id"]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid report_id in job payload: %v", job.Payload["report_id"])
+	}
+
+	var report models.Report
+	if err := d.Engine.DB.WithContext(ctx).First(&report, reportID).Error; err != nil {
+		return err
+	}
+
+	return d.Deliver(ctx, &report)
+}
+
+// Deliver runs a report, emails the results to its recipients, and records the outcome on the run
+func (d *Delivery) Deliver(ctx context.Context, report *models.Report) error {
+	run, err := d.Engine.Run(ctx, report, 0)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"scheduled": true}
+	deliveryErr := d.send(ctx, report, run)
+	if deliveryErr != nil {
+		updates["delivery_error"] = deliveryErr.Error()
+	} else {
+		updates["delivered_at"] = time.Now()
+	}
+
+	if err := d.Engine.DB.WithContext(ctx).Model(run).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if run.Status == models.ReportRunStatusFailed {
+		return fmt.Errorf("report %d failed: %s", report.ID, run.Error)
+	}
+	return deliveryErr
+}
+
+// send emails the run results, or the failure reason, to the report's recipients
+func (d *Delivery) send(ctx context.Context, report *models.Report, run *models.ReportRun) error {
+	msg := mailer.Message{
+		To:      report.Recipients,
+		Subject: fmt.Sprintf("Report: %s", report.Name),
+	}
+
+	if run.Status == models.ReportRunStatusFailed {
+		msg.Subject = fmt.Sprintf("Report failed: %s", report.Name)
+		msg.Text = fmt.Sprintf("The scheduled report %q could not be generated:\n\n%s\n", report.Name, run.Error)
+		return d.Mailer.Send(ctx, msg)
+	}
+
+	format, err := ParseFormat(report.Format)
+	if err != nil {
+		return err
+	}
+	data, err := Render(report, run, format)
+	if err != nil {
+		return err
+	}
+
+	msg.Text = fmt.Sprintf("%s\n\n%d rows generated at %s.\n", report.Description, run.RowCount, run.StartedAt.Format(time.RFC1123))
+	if run.Truncated {
+		msg.Text += fmt.Sprintf("Results were truncated to the first %d rows.\n", d.Engine.MaxRows)
+	}
+	msg.Attachments = []mailer.Attachment{{
+		Filename:    Filename(report, run, format),
+		ContentType: format.ContentType(),
+		Data:        data,
+	}}
+
+	return d.Mailer.Send(ctx, msg)
+}
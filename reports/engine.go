@@ -0,0 +1,140 @@
+// Package reports/engine.go
+package reports
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/database"
+	"github.com/4cecoder/saas/models"
+)
+
+// Engine executes report definitions with tenant scoping, timeouts, and row limits
+type Engine struct {
+	DB      *gorm.DB
+	ReadDB  *gorm.DB
+	Timeout time.Duration
+	MaxRows int
+}
+
+// NewEngine creates a report engine; readDB should connect with a read-only role
+func NewEngine(db, readDB *gorm.DB) *Engine {
+	return &Engine{
+		DB:      db,
+		ReadDB:  readDB,
+		Timeout: 30 * time.Second,
+		MaxRows: 10000,
+	}
+}
+
+// Run executes the report and stores the run with its results
+func (e *Engine) Run(ctx context.Context, report *models.Report, triggeredBy uint) (*models.ReportRun, error) {
+	run := &models.ReportRun{
+		ReportID:       report.ID,
+		OrganizationID: report.OrganizationID,
+		TriggeredBy:    triggeredBy,
+		Status:         models.ReportRunStatusRunning,
+		StartedAt:      time.Now(),
+	}
+	if err := e.DB.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, err
+	}
+
+	columns, rows, truncated, err := e.execute(ctx, report)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	if err != nil {
+		run.Status = models.ReportRunStatusFailed
+		run.Error = err.Error()
+	} else {
+		run.Status = models.ReportRunStatusSucceeded
+		run.Columns = columns
+		run.Rows = rows
+		run.RowCount = len(rows)
+		run.Truncated = truncated
+	}
+
+	if saveErr := e.DB.WithContext(ctx).Save(run).Error; saveErr != nil {
+		return run, saveErr
+	}
+	if err := e.DB.WithContext(ctx).Model(report).Update("last_run_at", run.StartedAt).Error; err != nil {
+		return run, err
+	}
+
+	return run, nil
+}
+
+// execute runs the query in a read-only transaction, on a replica if there are any,
+// and collects at most MaxRows rows
+func (e *Engine) execute(ctx context.Context, report *models.Report) ([]string, [][]interface{}, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+
+	var (
+		columns   []string
+		rows      [][]interface{}
+		truncated bool
+	)
+
+	err := database.Replica(e.ReadDB.WithContext(ctx)).Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			stmts := []string{
+				"SET TRANSACTION READ ONLY",
+				fmt.Sprintf("SET LOCAL statement_timeout = %d", e.Timeout.Milliseconds()),
+				fmt.Sprintf("SET LOCAL app.organization_id = '%d'", report.OrganizationID),
+			}
+			for _, stmt := range stmts {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		query, err := Build(tx, report.Definition, report.OrganizationID, e.MaxRows)
+		if err != nil {
+			return err
+		}
+
+		result, err := query.Rows()
+		if err != nil {
+			return err
+		}
+		defer result.Close()
+
+		columns, err = result.Columns()
+		if err != nil {
+			return err
+		}
+
+		for result.Next() {
+			if len(rows) == e.MaxRows {
+				truncated = true
+				break
+			}
+
+			values := make([]interface{}, len(columns))
+			ptrs := make([]interface{}, len(columns))
+			for i := range values {
+				ptrs[i] = &values[i]
+			}
+			if err := result.Scan(ptrs...); err != nil {
+				return err
+			}
+
+			for i, v := range values {
+				if b, ok := v.([]byte); ok {
+					values[i] = string(b)
+				}
+			}
+			rows = append(rows, values)
+		}
+
+		return result.Err()
+	})
+
+	return columns, rows, truncated, err
+}
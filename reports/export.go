@@ -0,0 +1,72 @@
+// Package reports/export.go
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/storage"
+)
+
+// Exporter renders large report results in the background and stores them for download
+type Exporter struct {
+	DB      *gorm.DB
+	Storage storage.Storage
+	// AsyncThreshold is the row count above which exports are generated in the background
+	AsyncThreshold int
+}
+
+// NewExporter creates a new report exporter
+func NewExporter(db *gorm.DB, store storage.Storage) *Exporter {
+	return &Exporter{DB: db, Storage: store, AsyncThreshold: 1000}
+}
+
+// Start records a pending export for the run and renders it in the background
+func (e *Exporter) Start(ctx context.Context, report *models.Report, run *models.ReportRun, format Format, requestedBy uint) (*models.ReportExport, error) {
+	export := &models.ReportExport{
+		ReportID:       report.ID,
+		RunID:          run.ID,
+		OrganizationID: report.OrganizationID,
+		RequestedBy:    requestedBy,
+		Format:         string(format),
+		Filename:       Filename(report, run, format),
+		Status:         models.ReportExportStatusPending,
+	}
+	if err := e.DB.WithContext(ctx).Create(export).Error; err != nil {
+		return nil, err
+	}
+
+	// Detach from the request so the export outlives it
+	go e.generate(context.WithoutCancel(ctx), report, run, export)
+
+	return export, nil
+}
+
+// generate renders and stores the export, recording the outcome
+func (e *Exporter) generate(ctx context.Context, report *models.Report, run *models.ReportRun, export *models.ReportExport) {
+	key := fmt.Sprintf("exports/%d/reports/%d/%d-%s", report.OrganizationID, report.ID, export.ID, export.Filename)
+
+	updates := map[string]interface{}{}
+	data, err := Render(report, run, Format(export.Format))
+	if err == nil {
+		err = e.Storage.Put(ctx, key, bytes.NewReader(data))
+	}
+	if err != nil {
+		slog.Error("reports: export failed", "export_id", export.ID, "error", err)
+		updates["status"] = models.ReportExportStatusFailed
+		updates["error"] = err.Error()
+	} else {
+		updates["status"] = models.ReportExportStatusReady
+		updates["storage_key"] = key
+		updates["size"] = len(data)
+	}
+
+	if err := e.DB.WithContext(ctx).Model(export).Updates(updates).Error; err != nil {
+		slog.Error("reports: failed to update export", "export_id", export.ID, "error", err)
+	}
+}
@@ -0,0 +1,241 @@
+// Package reports/query.go
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// maxQueryLimit caps the limit a report definition may request
+const maxQueryLimit = 100000
+
+// aliasPattern matches the names allowed for aggregate aliases
+var aliasPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// entity describes a table reports may read and how it is scoped to a tenant
+type entity struct {
+	table  string
+	scope  string
+	fields map[string]bool
+}
+
+// entities is the allowlisted schema report definitions are validated against
+var entities = map[string]entity{
+	"users": {
+		table:  "users",
+		scope:  "users.id IN (SELECT user_id FROM user_organizations WHERE organization_id = ?)",
+		fields: set("id", "email", "name", "verified", "locale", "timezone", "language", "created_at"),
+	},
+	"subscriptions": {
+		table:  "subscriptions",
+		scope:  "subscriptions.organization_id = ?",
+		fields: set("id", "status", "payment_method", "start_date", "end_date", "last_payment_date", "next_billing_date", "created_at"),
+	},
+	"seats": {
+		table:  "seats",
+		scope:  "seats.organization_id = ?",
+		fields: set("id", "user_id", "status", "created_at"),
+	},
+	"domains": {
+		table:  "domains",
+		scope:  "domains.organization_id = ?",
+		fields: set("id", "domain", "verified", "created_at"),
+	},
+	"payment_transactions": {
+		table:  "payment_transactions",
+		scope:  "payment_transactions.subscription_id IN (SELECT id FROM subscriptions WHERE organization_id = ?)",
+		fields: set("id", "subscription_id", "amount", "currency", "status", "gateway", "timestamp"),
+	},
+	"audit_logs": {
+		table:  "audit_logs",
+		scope:  "audit_logs.organization_id = ?",
+		fields: set("id", "user_id", "action", "resource_type", "resource_id", "timestamp"),
+	},
+	"activity_logs": {
+		table:  "activity_logs",
+		scope:  "activity_logs.organization_id = ?",
+		fields: set("id", "user_id", "activity_type", "timestamp"),
+	},
+	"workflows": {
+		table:  "workflows",
+		scope:  "workflows.organization_id = ?",
+		fields: set("id", "name", "creator_id", "team_id", "enabled", "created_at"),
+	},
+	"api_keys": {
+		table:  "api_keys",
+		scope:  "api_keys.organization_id = ?",
+		fields: set("id", "user_id", "name", "expires_at", "last_used_at", "created_at"),
+	},
+}
+
+// operators maps filter operators to their SQL form
+var operators = map[string]string{
+	"eq":       "= ?",
+	"ne":       "<> ?",
+	"gt":       "> ?",
+	"gte":      ">= ?",
+	"lt":       "< ?",
+	"lte":      "<= ?",
+	"in":       "IN ?",
+	"like":     "LIKE ?",
+	"is_null":  "IS NULL",
+	"not_null": "IS NOT NULL",
+}
+
+// aggregates lists the supported aggregate functions
+var aggregates = set("count", "sum", "avg", "min", "max")
+
+// ErrEmptyQuery is returned for a definition without an entity
+var ErrEmptyQuery = errors.New("report definition requires an entity")
+
+// set builds a lookup map from a list of names
+func set(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// Validate checks a report definition against the allowlisted schema
+func Validate(q models.ReportQuery) error {
+	if q.Entity == "" {
+		return ErrEmptyQuery
+	}
+
+	e, ok := entities[q.Entity]
+	if !ok {
+		return fmt.Errorf("unknown entity %q", q.Entity)
+	}
+
+	if len(q.Fields) == 0 && len(q.Aggregates) == 0 {
+		return errors.New("report definition requires fields or aggregates")
+	}
+
+	for _, f := range q.Fields {
+		if !e.fields[f] {
+			return fmt.Errorf("unknown field %q on %s", f, q.Entity)
+		}
+	}
+
+	for _, f := range q.Filters {
+		if !e.fields[f.Field] {
+			return fmt.Errorf("unknown filter field %q on %s", f.Field, q.Entity)
+		}
+		if _, ok := operators[f.Op]; !ok {
+			return fmt.Errorf("unknown filter operator %q", f.Op)
+		}
+		if f.Op == "in" {
+			if _, ok := f.Value.([]interface{}); !ok {
+				return fmt.Errorf("filter on %q with operator in requires a list", f.Field)
+			}
+		}
+	}
+
+	grouped := set(q.GroupBy...)
+	for _, g := range q.GroupBy {
+		if !e.fields[g] {
+			return fmt.Errorf("unknown group_by field %q on %s", g, q.Entity)
+		}
+	}
+
+	aliases := map[string]bool{}
+	for _, a := range q.Aggregates {
+		if !aggregates[a.Func] {
+			return fmt.Errorf("unknown aggregate %q", a.Func)
+		}
+		if a.Field != "*" || a.Func != "count" {
+			if !e.fields[a.Field] {
+				return fmt.Errorf("unknown aggregate field %q on %s", a.Field, q.Entity)
+			}
+		}
+		if !aliasPattern.MatchString(a.Alias) {
+			return fmt.Errorf("invalid aggregate alias %q", a.Alias)
+		}
+		aliases[a.Alias] = true
+	}
+
+	// With aggregates, every plain field must be grouped
+	if len(q.Aggregates) > 0 {
+		for _, f := range q.Fields {
+			if !grouped[f] {
+				return fmt.Errorf("field %q must be listed in group_by when aggregating", f)
+			}
+		}
+	}
+
+	for _, o := range q.OrderBy {
+		if !e.fields[o.Field] && !aliases[o.Field] {
+			return fmt.Errorf("unknown order_by field %q", o.Field)
+		}
+	}
+
+	if q.Limit < 0 || q.Limit > maxQueryLimit {
+		return fmt.Errorf("limit must be between 0 and %d", maxQueryLimit)
+	}
+
+	return nil
+}
+
+// Build compiles a validated definition into a query scoped to the organization.
+// Identifiers come only from the allowlist; values are always bound parameters.
+func Build(db *gorm.DB, q models.ReportQuery, orgID uint, maxRows int) (*gorm.DB, error) {
+	if err := Validate(q); err != nil {
+		return nil, err
+	}
+	e := entities[q.Entity]
+
+	columns := make([]string, 0, len(q.Fields)+len(q.Aggregates))
+	for _, f := range q.Fields {
+		columns = append(columns, e.table+"."+f)
+	}
+	for _, a := range q.Aggregates {
+		arg := "*"
+		if a.Field != "*" {
+			arg = e.table + "." + a.Field
+		}
+		columns = append(columns, fmt.Sprintf("%s(%s) AS %s", strings.ToUpper(a.Func), arg, a.Alias))
+	}
+
+	query := db.Table(e.table).
+		Select(strings.Join(columns, ", ")).
+		Where(e.scope, orgID).
+		Where(e.table + ".deleted_at IS NULL")
+
+	for _, f := range q.Filters {
+		op := operators[f.Op]
+		if strings.Contains(op, "?") {
+			query = query.Where(e.table+"."+f.Field+" "+op, f.Value)
+		} else {
+			query = query.Where(e.table + "." + f.Field + " " + op)
+		}
+	}
+
+	for _, g := range q.GroupBy {
+		query = query.Group(e.table + "." + g)
+	}
+
+	for _, o := range q.OrderBy {
+		column := o.Field
+		if e.fields[o.Field] {
+			column = e.table + "." + o.Field
+		}
+		if o.Desc {
+			column += " DESC"
+		}
+		query = query.Order(column)
+	}
+
+	limit := maxRows + 1
+	if q.Limit > 0 && q.Limit < limit {
+		limit = q.Limit
+	}
+
+	return query.Limit(limit), nil
+}
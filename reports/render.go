@@ -0,0 +1,208 @@
+// Package reports/render.go
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// Format is an export format for report results
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+	FormatPDF  Format = "pdf"
+)
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "text/csv"
+	}
+}
+
+// ParseFormat validates a format name, defaulting to CSV when empty
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX, FormatPDF:
+		return Format(name), nil
+	default:
+		return "", fmt.Errorf("unsupported format %q, expected csv, xlsx, or pdf", name)
+	}
+}
+
+// Render renders a run's results in the given format
+func Render(report *models.Report, run *models.ReportRun, format Format) ([]byte, error) {
+	switch format {
+	case FormatXLSX:
+		return RenderXLSX(run)
+	case FormatPDF:
+		return RenderPDF(report, run)
+	default:
+		return RenderCSV(run)
+	}
+}
+
+// Filename returns the download name for a rendered run
+func Filename(report *models.Report, run *models.ReportRun, format Format) string {
+	return fmt.Sprintf("report-%d-%s.%s", report.ID, run.StartedAt.Format("20060102"), format)
+}
+
+// RenderCSV renders a run's results as CSV with a header row
+func RenderCSV(run *models.ReportRun) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(run.Columns); err != nil {
+		return nil, err
+	}
+	for _, row := range run.Rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = formatValue(v)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// RenderXLSX renders a run's results as a single-sheet Excel workbook
+func RenderXLSX(run *models.ReportRun) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Sheet1"
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]interface{}, len(run.Columns))
+	for i, col := range run.Columns {
+		header[i] = col
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+
+	for r, row := range run.Rows {
+		cell, err := excelize.CoordinatesToCellName(1, r+2)
+		if err != nil {
+			return nil, err
+		}
+		if err := sw.SetRow(cell, row); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		return nil, err
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPDF renders a run's results as a landscape table
+func RenderPDF(report *models.Report, run *models.ReportRun) ([]byte, error) {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetAutoPageBreak(true, 10)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 10, report.Name, "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Generated %s - %d rows", run.StartedAt.Format(time.RFC1123), run.RowCount), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	if len(run.Columns) == 0 {
+		return output(pdf)
+	}
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width := (pageWidth - left - right) / float64(len(run.Columns))
+
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetFillColor(230, 230, 230)
+		for _, col := range run.Columns {
+			pdf.CellFormat(width, 6, truncate(pdf, col, width), "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 8)
+	}
+	pdf.SetHeaderFunc(func() {
+		if pdf.PageNo() > 1 {
+			header()
+		}
+	})
+	header()
+
+	for _, row := range run.Rows {
+		for _, v := range row {
+			pdf.CellFormat(width, 5, truncate(pdf, formatValue(v), width), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	return output(pdf)
+}
+
+// output writes the PDF document to a byte slice
+func output(pdf *fpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// truncate shortens text so it fits in a table cell of the given width
+func truncate(pdf *fpdf.Fpdf, text string, width float64) string {
+	max := width - 2
+	if pdf.GetStringWidth(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > max {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// formatValue converts a result cell to its text representation
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case string:
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,146 @@
+// Package memory/memory.go
+package memory
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/repository"
+)
+
+// beforeCreate and beforeUpdate match the GORM hooks of models that have them
+type beforeCreate interface{ BeforeCreate(tx *gorm.DB) error }
+type beforeUpdate interface{ BeforeUpdate(tx *gorm.DB) error }
+
+// Repository is a Repository kept in memory, for tests and tools that run without
+// a database. It runs the models' before hooks, but doesn't load relations or
+// enforce constraints. T must embed models.Base.
+type Repository[T any] struct {
+	mu      sync.Mutex
+	records map[uint]T
+	nextID  uint
+}
+
+// New creates an empty in-memory repository of T
+func New[T any]() *Repository[T] {
+	return &Repository[T]{records: make(map[uint]T)}
+}
+
+// Find returns a copy of the record with the given ID; relations aren't preloaded
+func (r *Repository[T]) Find(ctx context.Context, id uint, preload ...string) (*T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &record, nil
+}
+
+// Create assigns the record an ID and timestamps and stores a copy of it
+func (r *Repository[T]) Create(ctx context.Context, record *T) error {
+	if hook, ok := any(record).(beforeCreate); ok {
+		if err := hook.BeforeCreate(nil); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	b := base(record)
+	b.ID, b.CreatedAt, b.UpdatedAt = r.nextID, now, now
+	r.records[b.ID] = *record
+	return nil
+}
+
+// Update stores a copy of the record. With a non-zero readAt the stored record
+// must still have that update time, otherwise repository.ErrStale is returned.
+func (r *Repository[T]) Update(ctx context.Context, record *T, readAt time.Time) error {
+	if hook, ok := any(record).(beforeUpdate); ok {
+		if err := hook.BeforeUpdate(nil); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b := base(record)
+	stored, ok := r.records[b.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if !readAt.IsZero() && !base(&stored).UpdatedAt.Equal(readAt) {
+		return repository.ErrStale
+	}
+
+	b.UpdatedAt = time.Now()
+	r.records[b.ID] = *record
+	return nil
+}
+
+// Delete removes the record
+func (r *Repository[T]) Delete(ctx context.Context, record *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, base(record).ID)
+	return nil
+}
+
+// All returns copies of the stored records in no particular order
+func (r *Repository[T]) All() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := make([]T, 0, len(r.records))
+	for _, record := range r.records {
+		records = append(records, record)
+	}
+	return records
+}
+
+// base returns the models.Base embedded in a record
+func base(record interface{}) *models.Base {
+	return reflect.ValueOf(record).Elem().FieldByName("Base").Addr().Interface().(*models.Base)
+}
+
+// Seats is a Seats repository kept in memory
+type Seats struct {
+	*Repository[models.Seat]
+}
+
+// NewSeats creates an empty in-memory seat repository
+func NewSeats() *Seats {
+	return &Seats{Repository: New[models.Seat]()}
+}
+
+// CountOccupied returns the number of an organization's seats that aren't inactive
+func (r *Seats) CountOccupied(ctx context.Context, orgID uint) (int64, error) {
+	var count int64
+	for _, seat := range r.All() {
+		if seat.OrganizationID == orgID && seat.Status != models.SeatStatusInactive {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// NewStores returns empty in-memory repositories of the core resources
+func NewStores() repository.Stores {
+	return repository.Stores{
+		Users:         New[models.User](),
+		Organizations: New[models.Organization](),
+		Subscriptions: New[models.Subscription](),
+		Seats:         NewSeats(),
+	}
+}
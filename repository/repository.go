@@ -0,0 +1,147 @@
+// Package repository/repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// ErrStale is returned when a conditional update finds the record changed since it was read
+var ErrStale = errors.New("record was modified by another request")
+
+// Repository stores one model type
+type Repository[T any] interface {
+	// Find returns the record with the given ID, preloading the given relations
+	Find(ctx context.Context, id uint, preload ...string) (*T, error)
+	Create(ctx context.Context, record *T) error
+	// Update saves the record and reloads it. With a non-zero readAt the row must still
+	// have that update time, otherwise ErrStale is returned.
+	Update(ctx context.Context, record *T, readAt time.Time) error
+	Delete(ctx context.Context, record *T) error
+}
+
+// Seats stores seats
+type Seats interface {
+	Repository[models.Seat]
+	// CountOccupied returns the number of an organization's seats that aren't inactive
+	CountOccupied(ctx context.Context, orgID uint) (int64, error)
+}
+
+// txKey is the context key of a transaction the repositories should run in
+type txKey struct{}
+
+// WithTx returns a context whose repository calls run inside the given transaction
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// conn returns the transaction stored in the context, or db, bound to the context
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+	return db.WithContext(ctx)
+}
+
+// Gorm is a Repository backed by GORM
+type Gorm[T any] struct {
+	DB *gorm.DB
+}
+
+// NewGorm creates a new GORM repository of T
+func NewGorm[T any](db *gorm.DB) *Gorm[T] {
+	return &Gorm[T]{DB: db}
+}
+
+// Find returns the record with the given ID, preloading the given relations
+func (r *Gorm[T]) Find(ctx context.Context, id uint, preload ...string) (*T, error) {
+	query := conn(ctx, r.DB)
+	for _, path := range preload {
+		query = query.Preload(path)
+	}
+
+	var record T
+	if err := query.First(&record, id).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Create inserts a new record
+func (r *Gorm[T]) Create(ctx context.Context, record *T) error {
+	return conn(ctx, r.DB).Create(record).Error
+}
+
+// Update saves the record, conditionally on its update time when readAt is set
+func (r *Gorm[T]) Update(ctx context.Context, record *T, readAt time.Time) error {
+	return SaveConditional(conn(ctx, r.DB), record, readAt)
+}
+
+// Delete soft-deletes a record
+func (r *Gorm[T]) Delete(ctx context.Context, record *T) error {
+	return conn(ctx, r.DB).Delete(record).Error
+}
+
+// SaveConditional saves the record. With a non-zero readAt the row must still have
+// the update time it was read with, so a concurrent write in between is detected
+// instead of silently overwritten. The record is reloaded afterwards so its update
+// time matches what later reads return.
+func SaveConditional(db *gorm.DB, record interface{}, readAt time.Time) error {
+	if readAt.IsZero() {
+		if err := db.Save(record).Error; err != nil {
+			return err
+		}
+	} else {
+		res := db.Select("*").Where("updated_at = ?", readAt).Save(record)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrStale
+		}
+	}
+
+	return db.Session(&gorm.Session{NewDB: true}).First(record).Error
+}
+
+// GormSeats is a Seats repository backed by GORM
+type GormSeats struct {
+	*Gorm[models.Seat]
+}
+
+// NewGormSeats creates a new GORM seat repository
+func NewGormSeats(db *gorm.DB) *GormSeats {
+	return &GormSeats{Gorm: NewGorm[models.Seat](db)}
+}
+
+// CountOccupied returns the number of an organization's seats that aren't inactive
+func (r *GormSeats) CountOccupied(ctx context.Context, orgID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.DB).Model(&models.Seat{}).
+		Where("organization_id = ? AND status <> ?", orgID, models.SeatStatusInactive).
+		Count(&count).Error
+	return count, err
+}
+
+// Stores groups the repositories of the core resources
+type Stores struct {
+	Users         Repository[models.User]
+	Organizations Repository[models.Organization]
+	Subscriptions Repository[models.Subscription]
+	Seats         Seats
+}
+
+// NewGormStores returns GORM repositories of the core resources
+func NewGormStores(db *gorm.DB) Stores {
+	return Stores{
+		Users:         NewGorm[models.User](db),
+		Organizations: NewGorm[models.Organization](db),
+		Subscriptions: NewGorm[models.Subscription](db),
+		Seats:         NewGormSeats(db),
+	}
+}
@@ -0,0 +1,61 @@
+// Package requestid/requestid.go
+//
+// Package requestid carries the ID of the API request being served through
+// contexts, logs and outbound calls, so one request can be traced across the
+// subsystems it touches.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header is the request and response header carrying the request ID
+const Header = "X-Request-ID"
+
+// maxLength bounds the IDs accepted from clients
+const maxLength = 128
+
+// key is the context key of the request ID
+type key struct{}
+
+// New returns a random request ID
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether an ID sent by a client can be kept: short and made of
+// printable ASCII other than spaces, so it can't forge log lines
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// With returns a copy of ctx carrying the request ID
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// From returns the request ID carried by ctx, or an empty string
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
+
+// Propagate sets the request ID of the request's context on an outbound request
+func Propagate(req *http.Request) {
+	if id := From(req.Context()); id != "" {
+		req.Header.Set(Header, id)
+	}
+}
@@ -0,0 +1,296 @@
+// Package retention/retention.go
+package retention
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/scheduler"
+	"github.com/4cecoder/saas/storage"
+)
+
+// batchSize is the number of rows archived and deleted at a time
+const batchSize = 1000
+
+// MinAuditLogDays is the fewest days an organization keeps its audit log, so
+// its admins can't erase their recent actions from it
+const MinAuditLogDays = 90
+
+// Purger applies the retention policies of organizations, archiving or
+// deleting the records past their retention period and recording what it
+// removed
+type Purger struct {
+	DB      *gorm.DB
+	Storage storage.Storage
+}
+
+// NewPurger creates a new purger
+func NewPurger(db *gorm.DB, store storage.Storage) *Purger {
+	return &Purger{DB: db, Storage: store}
+}
+
+// Register schedules the nightly purge job
+func (p *Purger) Register(s *scheduler.Scheduler) error {
+	s.Register("retention.purge", func(ctx context.Context, _ *models.ScheduledJob) error {
+		return p.Run(ctx)
+	})
+	return s.Ensure("retention-purge", "retention.purge", "0 3 * * *", nil)
+}
+
+// Policy returns the retention rules in effect for an organization: its rules,
+// then archiving the audit and activity logs past the retention days of its
+// settings unless a rule covers them. Audit logs are kept for at least
+// MinAuditLogDays, whatever was stored before the minimum existed.
+func Policy(org models.Organization, rules []models.RetentionRule) []models.RetentionRule {
+	policy := append([]models.RetentionRule{}, rules...)
+	covered := func(resource string) bool {
+		return slices.ContainsFunc(rules, func(r models.RetentionRule) bool { return r.Resource == resource })
+	}
+	settings := []struct {
+		resource string
+		days     int
+	}{
+		{models.RetentionAuditLogs, org.Settings.AuditLogRetentionDays},
+		{models.RetentionActivityLogs, org.Settings.ActivityLogRetentionDays},
+	}
+	for _, s := range settings {
+		if s.days > 0 && !covered(s.resource) {
+			policy = append(policy, models.RetentionRule{
+				OrganizationID: org.ID,
+				Resource:       s.resource,
+				Days:           s.days,
+				Action:         models.RetentionArchive,
+			})
+		}
+	}
+	for i := range policy {
+		if policy[i].Resource == models.RetentionAuditLogs {
+			policy[i].Days = max(policy[i].Days, MinAuditLogDays)
+		}
+	}
+	return policy
+}
+
+// Run applies every organization's retention policy
+func (p *Purger) Run(ctx context.Context) error {
+	db := p.DB.WithContext(ctx)
+	var rules []models.RetentionRule
+	if err := db.Order("organization_id, id").Find(&rules).Error; err != nil {
+		return err
+	}
+	ruled := map[uint][]models.RetentionRule{}
+	for _, r := range rules {
+		ruled[r.OrganizationID] = append(ruled[r.OrganizationID], r)
+	}
+
+	var orgs []models.Organization
+	err := db.Where("audit_log_retention_days > 0 OR activity_log_retention_days > 0 OR id IN (?)", db.Model(&models.RetentionRule{}).Select("organization_id")).
+		Order("id").
+		Find(&orgs).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, org := range orgs {
+		for _, rule := range Policy(org, ruled[org.ID]) {
+			if _, err := p.Apply(ctx, rule, now); err != nil {
+				return fmt.Errorf("apply %s retention for organization %d: %w", rule.Resource, org.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply removes the records of the rule's resource created more than its days
+// before now, recording the removal when there were any. Records removed
+// before failing are recorded too.
+func (p *Purger) Apply(ctx context.Context, rule models.RetentionRule, now time.Time) (*models.RetentionRemoval, error) {
+	cutoff := now.AddDate(0, 0, -rule.Days)
+	keep := rule.Action != models.RetentionPurge
+
+	var n int
+	var keys []string
+	var err error
+	switch rule.Resource {
+	case models.RetentionAuditLogs:
+		n, keys, err = p.PurgeAuditLogs(ctx, rule.OrganizationID, cutoff, keep)
+	case models.RetentionActivityLogs:
+		n, keys, err = purge[models.ActivityLog](ctx, p, rule.OrganizationID, rule.Resource, "timestamp", cutoff, keep, nil)
+	case models.RetentionAttachments:
+		n, keys, err = purge(ctx, p, rule.OrganizationID, rule.Resource, "created_at", cutoff, keep, p.removeFiles)
+	case models.RetentionReportRuns:
+		n, keys, err = purge[models.ReportRun](ctx, p, rule.OrganizationID, rule.Resource, "created_at", cutoff, keep, nil)
+	default:
+		return nil, fmt.Errorf("unknown resource %s", rule.Resource)
+	}
+	if n == 0 {
+		return nil, err
+	}
+
+	removal := &models.RetentionRemoval{
+		OrganizationID: rule.OrganizationID,
+		Resource:       rule.Resource,
+		Action:         rule.Action,
+		Days:           rule.Days,
+		Cutoff:         cutoff,
+		Count:          n,
+		ArchiveKeys:    keys,
+	}
+	if removal.ArchiveKeys == nil {
+		removal.ArchiveKeys = []string{}
+	}
+	if cerr := p.DB.WithContext(ctx).Create(removal).Error; cerr != nil && err == nil {
+		err = cerr
+	}
+	slog.Info("retention: removed records past retention", "resource", rule.Resource, "action", rule.Action, "count", n, "org_id", rule.OrganizationID)
+	return removal, err
+}
+
+// PurgeAuditLogs deletes the oldest audit logs recorded before the cutoff,
+// archiving them first if keep is set, and returns how many it deleted with
+// the keys of the archives. Only a contiguous prefix of the hash chain is
+// removed, and the hash of the last removed entry is kept on the chain head so
+// verification still succeeds.
+func (p *Purger) PurgeAuditLogs(ctx context.Context, orgID uint, cutoff time.Time, keep bool) (int, []string, error) {
+	db := p.DB.WithContext(ctx)
+	purged := 0
+	var keys []string
+
+	for {
+		var entries []models.AuditLog
+		err := db.Where("organization_id = ?", orgID).Order("id ASC").Limit(batchSize).Find(&entries).Error
+		if err != nil {
+			return purged, keys, err
+		}
+
+		// Stop at the first entry that is still within the retention period
+		n := 0
+		for n < len(entries) && entries[n].Timestamp.Before(cutoff) {
+			n++
+		}
+		if n == 0 {
+			return purged, keys, nil
+		}
+		entries = entries[:n]
+
+		if keep {
+			key, err := archive(ctx, p.Storage, orgID, models.RetentionAuditLogs, entries)
+			if err != nil {
+				return purged, keys, err
+			}
+			keys = append(keys, key)
+		}
+
+		ids := make([]uint, len(entries))
+		for i, e := range entries {
+			ids[i] = e.ID
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.AuditLog{}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.AuditChainHead{}).
+				Where("organization_id = ?", orgID).
+				Update("pruned_hash", entries[len(entries)-1].Hash).Error
+		})
+		if err != nil {
+			return purged, keys, err
+		}
+
+		purged += len(entries)
+		if len(entries) < batchSize {
+			return purged, keys, nil
+		}
+	}
+}
+
+// purge deletes the records of an organization in table whose column is before
+// the cutoff, archiving them first if keep is set, then passes them to after
+// if given. It returns how many it deleted with the keys of the archives.
+func purge[T any](ctx context.Context, p *Purger, orgID uint, table, column string, cutoff time.Time, keep bool, after func(context.Context, []T)) (int, []string, error) {
+	db := p.DB.WithContext(ctx)
+	purged := 0
+	var keys []string
+
+	for {
+		var ids []uint
+		err := db.Model(new(T)).
+			Where("organization_id = ? AND "+column+" < ?", orgID, cutoff).
+			Order("id ASC").
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return purged, keys, err
+		}
+		if len(ids) == 0 {
+			return purged, keys, nil
+		}
+
+		var rows []T
+		if keep || after != nil {
+			if err := db.Order("id ASC").Find(&rows, ids).Error; err != nil {
+				return purged, keys, err
+			}
+		}
+		if keep {
+			key, err := archive(ctx, p.Storage, orgID, table, rows)
+			if err != nil {
+				return purged, keys, err
+			}
+			keys = append(keys, key)
+		}
+
+		if err := db.Unscoped().Where("id IN ?", ids).Delete(new(T)).Error; err != nil {
+			return purged, keys, err
+		}
+		if after != nil {
+			after(ctx, rows)
+		}
+
+		purged += len(ids)
+		if len(ids) < batchSize {
+			return purged, keys, nil
+		}
+	}
+}
+
+// removeFiles deletes the files of purged attachments; failing only leaves
+// orphaned files, so it's logged
+func (p *Purger) removeFiles(ctx context.Context, attachments []models.Attachment) {
+	for _, a := range attachments {
+		if err := p.Storage.Delete(ctx, a.StorageKey); err != nil {
+			slog.ErrorContext(ctx, "retention: failed to delete attachment file", "attachment_id", a.ID, "key", a.StorageKey, "error", err)
+		}
+	}
+}
+
+// archive writes rows as gzipped NDJSON to object storage before they are
+// deleted, returning its key
+func archive[T any](ctx context.Context, store storage.Storage, orgID uint, table string, rows []T) (string, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return "", err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("archives/%d/%s/%s.ndjson.gz", orgID, table, time.Now().UTC().Format("20060102T150405.000000000"))
+	return key, store.Put(ctx, key, &buf)
+}
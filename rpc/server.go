@@ -0,0 +1,151 @@
+// Package rpc/server.go
+package rpc
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/4cecoder/saas --go-grpc_out=.. --go-grpc_opt=module=github.com/4cecoder/saas saas/internal/v1/internal.proto
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/access"
+)
+
+// maxMessageSize caps the size of request messages
+const maxMessageSize = 4 << 20
+
+// Config holds the listener and mutual TLS settings of the gRPC server
+type Config struct {
+	// Addr is the listen address; the server is disabled when it's empty
+	Addr     string
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the CA certificates client certificates must chain to
+	ClientCAFile string
+}
+
+// Server implements the saas.internal.v1.Internal service
+type Server struct {
+	UnimplementedInternalServer
+
+	DB *gorm.DB
+	// Access answers the membership and entitlement checks from the cache
+	Access *access.Access
+
+	mu     sync.Mutex
+	grpc   *grpc.Server
+	closed bool
+}
+
+// NewServer creates the internal gRPC server
+func NewServer(db *gorm.DB, acc *access.Access) *Server {
+	return &Server{DB: db, Access: acc}
+}
+
+// ListenAndServe serves gRPC on cfg.Addr, accepting only clients presenting a
+// certificate signed by the configured CA. It returns nil once Shutdown is called.
+func (s *Server) ListenAndServe(cfg Config) error {
+	tlsConfig, err := mutualTLS(cfg)
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return err
+	}
+
+	srv := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.UnaryInterceptor(hideInternalErrors),
+	)
+	RegisterInternalServer(srv, s)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		lis.Close()
+		return nil
+	}
+	s.grpc = srv
+	s.mu.Unlock()
+
+	if err := srv.Serve(lis); !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting calls and waits for the running ones to finish, until
+// ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.grpc
+	s.closed = true
+	s.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		srv.Stop()
+		return ctx.Err()
+	}
+}
+
+// mutualTLS builds a TLS configuration requiring verified client certificates
+func mutualTLS(cfg Config) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading certificate: %w", err)
+	}
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("client CA file contains no certificates")
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}, nil
+}
+
+// hideInternalErrors logs the errors of failed calls that don't carry a status
+// and answers them with a generic Internal status
+func hideInternalErrors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if _, ok := status.FromError(err); !ok {
+		slog.Error("rpc: call failed", "method", info.FullMethod, "error", err)
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	return nil, err
+}
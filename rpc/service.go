@@ -0,0 +1,109 @@
+// Package rpc/service.go
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/models"
+)
+
+// LookupUser finds a user by ID or, when no ID is given, by email
+func (s *Server) LookupUser(ctx context.Context, req *LookupUserRequest) (*User, error) {
+	query := s.DB.WithContext(ctx)
+	switch {
+	case req.Id != 0:
+		query = query.Where("id = ?", req.Id)
+	case req.Email != "":
+		query = query.Where("email = ?", req.Email)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "id or email is required")
+	}
+
+	var user models.User
+	if err := query.First(&user).Error; err != nil {
+		return nil, notFound(err, "user")
+	}
+
+	acc, err := s.Access.User(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &User{
+		Id:       uint64(user.ID),
+		Email:    user.Email,
+		Name:     user.Name,
+		Verified: user.Verified,
+		Roles:    acc.Roles,
+	}
+	for _, id := range acc.OrganizationIDs {
+		resp.OrganizationIds = append(resp.OrganizationIds, uint64(id))
+	}
+	return resp, nil
+}
+
+// CheckEntitlement reports whether an organization has an active or trialing
+// subscription and, when a user is given, whether that user holds an active seat in it
+func (s *Server) CheckEntitlement(ctx context.Context, req *CheckEntitlementRequest) (*CheckEntitlementResponse, error) {
+	if req.OrganizationId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "organization_id is required")
+	}
+
+	orgID := uint(req.OrganizationId)
+	entitlement, err := s.Access.Organization(ctx, orgID)
+	if err != nil {
+		return nil, notFound(err, "organization")
+	}
+
+	resp := &CheckEntitlementResponse{SubscriptionStatus: entitlement.SubscriptionStatus}
+	if !entitlement.Entitled {
+		resp.Reason = entitlement.Reason
+		return resp, nil
+	}
+
+	if req.UserId != 0 {
+		user, err := s.Access.User(ctx, uint(req.UserId))
+		if err != nil {
+			return nil, err
+		}
+		if !user.HasSeat(orgID) {
+			resp.Reason = "no active seat"
+			return resp, nil
+		}
+	}
+
+	resp.Entitled = true
+	return resp, nil
+}
+
+// IntrospectToken validates an API token; invalid tokens are reported as inactive
+// rather than as an error
+func (s *Server) IntrospectToken(ctx context.Context, req *IntrospectTokenRequest) (*IntrospectTokenResponse, error) {
+	if req.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	claims, err := auth.ParseTokenString(req.Token)
+	if err != nil || auth.Revoked(ctx, claims) {
+		return &IntrospectTokenResponse{}, nil
+	}
+
+	id, _ := claims["id"].(float64)
+	role, _ := claims["role"].(string)
+	return &IntrospectTokenResponse{Active: true, UserId: uint64(id), Role: role}, nil
+}
+
+// notFound maps a missing record to a NotFound status
+func notFound(err error, kind string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Errorf(codes.NotFound, "%s not found", kind)
+	}
+	return fmt.Errorf("loading %s: %w", kind, err)
+}
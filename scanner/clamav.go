@@ -0,0 +1,99 @@
+// Package scanner/clamav.go
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// chunkSize is the size of the chunks files are streamed to clamd in
+const chunkSize = 64 << 10
+
+// ClamAV scans files with a clamd daemon through its INSTREAM command
+type ClamAV struct {
+	network, addr string
+	timeout       time.Duration
+}
+
+// NewClamAV creates a scanner for the clamd listening at cfg.URL
+func NewClamAV(cfg Config) (*ClamAV, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case u.Scheme == "tcp" && u.Host != "":
+		return &ClamAV{network: "tcp", addr: u.Host, timeout: cfg.Timeout}, nil
+	case u.Scheme == "unix" && u.Path != "":
+		return &ClamAV{network: "unix", addr: u.Path, timeout: cfg.Timeout}, nil
+	}
+	return nil, fmt.Errorf("clamd address %q isn't a tcp:// or unix:// URL", cfg.URL)
+}
+
+// Scan streams r to clamd and reads its verdict
+func (s *ClamAV) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, s.network, s.addr)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return Verdict{}, err
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Verdict{}, err
+	}
+	// Each chunk is prefixed with its length; an empty one ends the stream
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, werr := conn.Write(buf[:4+n]); werr != nil {
+				return Verdict{}, werr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return Verdict{}, err
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Verdict{}, err
+	}
+
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("read clamd reply: %w", err)
+	}
+	return parseReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+// parseReply reads a clamd reply such as "stream: OK" or
+// "stream: Eicar-Signature FOUND"
+func parseReply(reply string) (Verdict, error) {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return Verdict{}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return Verdict{Infected: true, Threat: strings.TrimSuffix(result, " FOUND")}, nil
+	}
+	return Verdict{}, fmt.Errorf("clamd: %s", reply)
+}
@@ -0,0 +1,60 @@
+// Package scanner/http.go
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/4cecoder/saas/requestid"
+	"github.com/4cecoder/saas/tracing"
+)
+
+// HTTP scans files with an external API. The file is posted as the request
+// body, and the API answers with a JSON verdict such as
+// {"infected": true, "threat": "Eicar-Signature"}.
+type HTTP struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTP creates a scanner posting files to cfg.URL
+func NewHTTP(cfg Config) *HTTP {
+	return &HTTP{
+		url:    cfg.URL,
+		apiKey: cfg.APIKey,
+		client: tracing.WrapClient(&http.Client{Timeout: cfg.Timeout}),
+	}
+}
+
+// Scan posts r to the API and decodes its verdict
+func (s *HTTP) Scan(ctx context.Context, r io.Reader) (Verdict, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, r)
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	requestid.Propagate(req)
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Verdict{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return Verdict{}, fmt.Errorf("scanning API responded %d: %s", resp.StatusCode, raw)
+	}
+	var verdict Verdict
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return Verdict{}, fmt.Errorf("decode scanning API verdict: %w", err)
+	}
+	return verdict, nil
+}
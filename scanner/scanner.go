@@ -0,0 +1,58 @@
+// Package scanner/scanner.go
+//
+// Package scanner checks uploaded files for malware, with ClamAV or an
+// external scanning API.
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DefaultTimeout bounds a scan when the configuration sets none
+const DefaultTimeout = 30 * time.Second
+
+// Verdict is the outcome of scanning a file
+type Verdict struct {
+	// Infected is set when the scanner flagged the file
+	Infected bool `json:"infected"`
+	// Threat names what the scanner found, when it says
+	Threat string `json:"threat"`
+}
+
+// Scanner checks files for malware
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Verdict, error)
+}
+
+// Config selects the scanner
+type Config struct {
+	// Engine is "clamav" or "http"; empty scans nothing
+	Engine string
+	// URL is the address of clamd, such as tcp://localhost:3310 or
+	// unix:///run/clamav/clamd.sock, or the endpoint of the scanning API
+	URL string
+	// APIKey is sent as a bearer token to the scanning API
+	APIKey  string
+	Timeout time.Duration
+}
+
+// New returns the configured scanner, or nil when scanning is disabled
+func New(cfg Config) (Scanner, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	switch cfg.Engine {
+	case "":
+		return nil, nil
+	case "clamav":
+		return NewClamAV(cfg)
+	case "http":
+		return NewHTTP(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown scan engine %q", cfg.Engine)
+	}
+}
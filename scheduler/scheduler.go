@@ -0,0 +1,245 @@
+// Package scheduler/scheduler.go
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/tracing"
+)
+
+// leaseName is the name of the lease row shared by all scheduler instances
+const leaseName = "scheduler"
+
+// parser accepts standard five-field cron expressions and descriptors like @daily
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// JobFunc is the function executed when a scheduled job is due
+type JobFunc func(ctx context.Context, job *models.ScheduledJob) error
+
+// Scheduler runs DB-backed cron jobs on a single elected instance
+type Scheduler struct {
+	DB               *gorm.DB
+	InstanceID       string
+	Interval         time.Duration
+	LeaseTTL         time.Duration
+	MisfireThreshold time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]JobFunc
+	wg       sync.WaitGroup
+}
+
+// New creates a new scheduler with default timings
+func New(db *gorm.DB) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		DB:               db,
+		InstanceID:       fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano()),
+		Interval:         15 * time.Second,
+		LeaseTTL:         45 * time.Second,
+		MisfireThreshold: time.Minute,
+		handlers:         make(map[string]JobFunc),
+	}
+}
+
+// Register associates a handler name with the function that executes it
+func (s *Scheduler) Register(handler string, fn JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[handler] = fn
+}
+
+// Ensure creates the named job definition if it doesn't exist yet
+func (s *Scheduler) Ensure(name, handler, spec string, payload models.JSONMap) error {
+	next, err := NextRun(spec, "", time.Now())
+	if err != nil {
+		return err
+	}
+
+	job := models.ScheduledJob{
+		Name:          name,
+		Handler:       handler,
+		Schedule:      spec,
+		MisfirePolicy: models.MisfirePolicyFireOnce,
+		Enabled:       true,
+		Payload:       payload,
+		NextRunAt:     next,
+	}
+	return s.DB.Where(models.ScheduledJob{Name: name}).FirstOrCreate(&job).Error
+}
+
+// NextRun returns the next time after the given time that matches the cron spec
+func NextRun(spec, timezone string, after time.Time) (time.Time, error) {
+	schedule, err := parser.Parse(spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+
+	loc := time.UTC
+	if timezone != "" {
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+	}
+
+	return schedule.Next(after.In(loc)).UTC(), nil
+}
+
+// Run polls for due jobs until the context is canceled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.releaseLease()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Wait blocks until all running jobs have finished
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// tick runs every due job if this instance holds the leadership lease
+func (s *Scheduler) tick(ctx context.Context) {
+	leader, err := s.acquireLease()
+	if err != nil {
+		slog.Error("scheduler: failed to acquire lease", "error", err)
+		return
+	}
+	if !leader {
+		return
+	}
+
+	now := time.Now()
+	var jobs []models.ScheduledJob
+	if err := s.DB.Where("enabled = ? AND next_run_at <= ?", true, now).Find(&jobs).Error; err != nil {
+		slog.Error("scheduler: failed to load due jobs", "error", err)
+		return
+	}
+
+	for i := range jobs {
+		s.dispatch(ctx, &jobs[i], now)
+	}
+}
+
+// dispatch claims a due job, handles misfires, and runs it in the background
+func (s *Scheduler) dispatch(ctx context.Context, job *models.ScheduledJob, now time.Time) {
+	next, err := NextRun(job.Schedule, job.Timezone, now)
+	if err != nil {
+		s.DB.Model(job).Updates(map[string]interface{}{"enabled": false, "last_error": err.Error()})
+		return
+	}
+
+	// Claim the run by moving NextRunAt forward, so a run is never picked twice
+	scheduledAt := job.NextRunAt
+	res := s.DB.Model(&models.ScheduledJob{}).
+		Where("id = ? AND next_run_at = ?", job.ID, scheduledAt).
+		Update("next_run_at", next)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return
+	}
+
+	misfired := now.Sub(scheduledAt) > s.MisfireThreshold
+	if misfired && job.MisfirePolicy == models.MisfirePolicySkip {
+		slog.Warn("scheduler: skipping misfired job", "job", job.Name, "scheduled_at", scheduledAt)
+		return
+	}
+
+	s.mu.RLock()
+	fn, ok := s.handlers[job.Handler]
+	s.mu.RUnlock()
+	if !ok {
+		s.DB.Model(job).Update("last_error", fmt.Sprintf("no handler registered for %q", job.Handler))
+		return
+	}
+
+	// Running jobs aren't canceled with the scheduler, so a shutdown lets them finish
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(context.WithoutCancel(ctx), job, fn)
+	}()
+}
+
+// execute runs the job function and records the outcome
+func (s *Scheduler) execute(ctx context.Context, job *models.ScheduledJob, fn JobFunc) {
+	ctx, span := tracing.Tracer().Start(ctx, "job "+job.Name, trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.Int64("job.id", int64(job.ID)), attribute.String("job.handler", job.Handler)))
+	defer span.End()
+
+	startedAt := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return fn(ctx, job)
+	}()
+
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, lastError)
+		slog.ErrorContext(ctx, "scheduler: job failed", "job", job.Name, "error", err)
+	}
+
+	s.DB.Model(&models.ScheduledJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"last_run_at": startedAt,
+		"last_error":  lastError,
+	})
+}
+
+// acquireLease takes or renews the leadership lease, reporting whether this instance leads
+func (s *Scheduler) acquireLease() (bool, error) {
+	now := time.Now()
+	expires := now.Add(s.LeaseTTL)
+
+	lease := models.SchedulerLease{Name: leaseName, Holder: s.InstanceID, ExpiresAt: expires}
+	if err := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&lease).Error; err != nil {
+		return false, err
+	}
+
+	res := s.DB.Model(&models.SchedulerLease{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", leaseName, s.InstanceID, now).
+		Updates(map[string]interface{}{"holder": s.InstanceID, "expires_at": expires})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// releaseLease gives up leadership so another instance can take over immediately
+func (s *Scheduler) releaseLease() {
+	err := s.DB.Model(&models.SchedulerLease{}).
+		Where("name = ? AND holder = ?", leaseName, s.InstanceID).
+		Update("expires_at", time.Time{}).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.Error("scheduler: failed to release lease", "error", err)
+	}
+}
@@ -0,0 +1,85 @@
+// Package search/client.go
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/4cecoder/saas/requestid"
+	"github.com/4cecoder/saas/tracing"
+)
+
+// StatusError is returned when a search engine responds with an error status
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("search engine responded %d: %s", e.Code, e.Body)
+}
+
+// client sends JSON requests to a search engine's HTTP API
+type client struct {
+	baseURL string
+	auth    string
+	http    *http.Client
+}
+
+// newClient creates a client for the engine at baseURL, sending auth as the Authorization header
+func newClient(baseURL, auth string) *client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		auth:    auth,
+		http:    tracing.WrapClient(&http.Client{Timeout: 10 * time.Second}),
+	}
+}
+
+// do sends body as JSON, or as-is when it's already a []byte, and decodes the response into out
+func (c *client) do(ctx context.Context, method, path, contentType string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	requestid.Propagate(req)
+	if c.auth != "" {
+		req.Header.Set("Authorization", c.auth)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &StatusError{Code: resp.StatusCode, Body: string(raw)}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,90 @@
+// Package search/database.go
+package search
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Database searches users and organizations with LIKE matching, for databases
+// other than Postgres. Matches in names rank above matches in emails.
+type Database struct {
+	DB *gorm.DB
+}
+
+// NewDatabase creates a searcher matching with LIKE
+func NewDatabase(db *gorm.DB) *Database {
+	return &Database{DB: db}
+}
+
+// Setup does nothing; LIKE matching needs no indexes
+func (d *Database) Setup(ctx context.Context) error {
+	return nil
+}
+
+// Search returns the users and organizations whose names, or users' emails,
+// contain the query text
+func (d *Database) Search(ctx context.Context, q Query) ([]Hit, error) {
+	if err := ValidateTypes(q.Types); err != nil {
+		return nil, err
+	}
+	if q.OrganizationIDs != nil && len(q.OrganizationIDs) == 0 {
+		return []Hit{}, nil
+	}
+
+	db := d.DB.WithContext(ctx)
+	pattern := "%" + escapeLike(strings.ToLower(q.Text)) + "%"
+	like := d.like()
+	hits := []Hit{}
+
+	if q.wants(TypeUser) {
+		query := db.Table("users").
+			Select("'user' AS type, id, name AS title, email AS subtitle, "+
+				"CASE WHEN LOWER(name) "+like+" THEN 2 ELSE 1 END AS score", pattern).
+			Where("deleted_at IS NULL").
+			Where("LOWER(name) "+like+" OR LOWER(email) "+like, pattern, pattern)
+		if q.OrganizationIDs != nil {
+			query = query.Where("id IN (?)", db.Table("user_organizations").Select("user_id").Where("organization_id IN ?", q.OrganizationIDs))
+		}
+
+		var users []Hit
+		if err := query.Order("score DESC").Limit(q.Limit).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		hits = append(hits, users...)
+	}
+
+	if q.wants(TypeOrganization) {
+		query := db.Table("organizations").
+			Select("'organization' AS type, id, name AS title, '' AS subtitle, 2 AS score").
+			Where("deleted_at IS NULL").
+			Where("LOWER(name) "+like, pattern)
+		if q.OrganizationIDs != nil {
+			query = query.Where("id IN ?", q.OrganizationIDs)
+		}
+
+		var orgs []Hit
+		if err := query.Limit(q.Limit).Find(&orgs).Error; err != nil {
+			return nil, err
+		}
+		hits = append(hits, orgs...)
+	}
+
+	return rank(hits, q.Limit), nil
+}
+
+// like returns the LIKE comparison with a backslash escape character, which MySQL
+// uses by default and SQLite only when given one
+func (d *Database) like() string {
+	if d.DB.Dialector.Name() == "mysql" {
+		return "LIKE ?"
+	}
+	return `LIKE ? ESCAPE '\'`
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
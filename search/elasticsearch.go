@@ -0,0 +1,183 @@
+// Package search/elasticsearch.go
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Elasticsearch searches documents mirrored into an Elasticsearch cluster
+type Elasticsearch struct {
+	cfg    Config
+	client *client
+}
+
+// NewElasticsearch creates an Elasticsearch index client
+func NewElasticsearch(cfg Config) *Elasticsearch {
+	auth := ""
+	if cfg.APIKey != "" {
+		auth = "ApiKey " + cfg.APIKey
+	}
+	return &Elasticsearch{cfg: cfg, client: newClient(cfg.URL, auth)}
+}
+
+// Setup creates every index with its mapping, leaving existing indexes alone
+func (e *Elasticsearch) Setup(ctx context.Context) error {
+	mapping := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"title":            map[string]string{"type": "text"},
+				"subtitle":         map[string]string{"type": "text"},
+				"organization_ids": map[string]string{"type": "long"},
+			},
+		},
+	}
+	for _, t := range Types {
+		err := e.client.do(ctx, http.MethodPut, "/"+indexName(e.cfg, t), "application/json", mapping, nil)
+		var status *StatusError
+		if errors.As(err, &status) && strings.Contains(status.Body, "resource_already_exists_exception") {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Upsert adds or replaces documents in one bulk request
+func (e *Elasticsearch) Upsert(ctx context.Context, docs []Document) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, d := range docs {
+		enc.Encode(map[string]interface{}{"index": e.target(d.Type, d.ID)})
+		enc.Encode(d)
+	}
+	return e.bulk(ctx, body.Bytes())
+}
+
+// Delete removes documents of a type by ID in one bulk request
+func (e *Elasticsearch) Delete(ctx context.Context, docType string, ids []uint) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, id := range ids {
+		enc.Encode(map[string]interface{}{"delete": e.target(docType, id)})
+	}
+	return e.bulk(ctx, body.Bytes())
+}
+
+// Clear removes every document of a type
+func (e *Elasticsearch) Clear(ctx context.Context, docType string) error {
+	query := map[string]interface{}{"query": map[string]interface{}{"match_all": map[string]interface{}{}}}
+	path := "/" + indexName(e.cfg, docType) + "/_delete_by_query?refresh=true"
+	return e.client.do(ctx, http.MethodPost, path, "application/json", query, nil)
+}
+
+// Search runs a fuzzy multi-field match across every requested index
+func (e *Elasticsearch) Search(ctx context.Context, q Query) ([]Hit, error) {
+	if err := ValidateTypes(q.Types); err != nil {
+		return nil, err
+	}
+	if q.OrganizationIDs != nil && len(q.OrganizationIDs) == 0 {
+		return []Hit{}, nil
+	}
+
+	indexType := map[string]string{}
+	indexes := []string{}
+	for _, t := range Types {
+		if q.wants(t) {
+			index := indexName(e.cfg, t)
+			indexType[index] = t
+			indexes = append(indexes, index)
+		}
+	}
+
+	boolQuery := map[string]interface{}{
+		"must": map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":     q.Text,
+				"fields":    []string{"title^2", "subtitle"},
+				"fuzziness": "AUTO",
+			},
+		},
+	}
+	if q.OrganizationIDs != nil {
+		boolQuery["filter"] = map[string]interface{}{
+			"terms": map[string]interface{}{"organization_ids": q.OrganizationIDs},
+		}
+	}
+	body := map[string]interface{}{
+		"size":  q.Limit,
+		"query": map[string]interface{}{"bool": boolQuery},
+	}
+
+	var resp struct {
+		Hits struct {
+			Hits []struct {
+				Index  string   `json:"_index"`
+				ID     string   `json:"_id"`
+				Score  float64  `json:"_score"`
+				Source Document `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	path := "/" + strings.Join(indexes, ",") + "/_search"
+	if err := e.client.do(ctx, http.MethodPost, path, "application/json", body, &resp); err != nil {
+		return nil, err
+	}
+
+	hits := []Hit{}
+	for _, h := range resp.Hits.Hits {
+		id, _ := strconv.ParseUint(h.ID, 10, 64)
+		hits = append(hits, Hit{
+			Type:     indexType[h.Index],
+			ID:       uint(id),
+			Title:    h.Source.Title,
+			Subtitle: h.Source.Subtitle,
+			Score:    h.Score,
+		})
+	}
+	return rank(hits, q.Limit), nil
+}
+
+// target addresses one document in a bulk action
+func (e *Elasticsearch) target(docType string, id uint) map[string]string {
+	return map[string]string{"_index": indexName(e.cfg, docType), "_id": fmt.Sprint(id)}
+}
+
+// bulk sends newline-delimited bulk actions, failing if any action failed
+func (e *Elasticsearch) bulk(ctx context.Context, body []byte) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := e.client.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body, &resp); err != nil {
+		return err
+	}
+	if !resp.Errors {
+		return nil
+	}
+
+	for _, item := range resp.Items {
+		for action, result := range item {
+			// Deleting a document that was never indexed isn't a failure
+			if result.Error != nil && !(action == "delete" && result.Status == http.StatusNotFound) {
+				return fmt.Errorf("search: bulk %s failed: %s", action, result.Error)
+			}
+		}
+	}
+	return nil
+}
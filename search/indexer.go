@@ -0,0 +1,192 @@
+// Package search/indexer.go
+package search
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/events"
+	"github.com/4cecoder/saas/models"
+)
+
+// reindexBatchSize is how many records a reindex loads and sends at a time
+const reindexBatchSize = 500
+
+// Indexer mirrors users and organizations into an external search index
+type Indexer struct {
+	DB    *gorm.DB
+	Index Index
+}
+
+// NewIndexer creates an indexer writing to idx
+func NewIndexer(db *gorm.DB, idx Index) *Indexer {
+	return &Indexer{DB: db, Index: idx}
+}
+
+// Subscribe keeps the index in sync with change events of the indexed models. Membership
+// changes don't publish events; a reindex picks them up.
+func (i *Indexer) Subscribe(bus *events.Bus) {
+	for _, t := range Types {
+		for _, suffix := range []string{"created", "updated", "deleted"} {
+			bus.Subscribe(t+"."+suffix, i.handleEvent)
+		}
+	}
+}
+
+// handleEvent re-reads the changed record and updates or removes its document
+func (i *Indexer) handleEvent(ctx context.Context, ev events.Event) {
+	docType := ev.Type[:strings.Index(ev.Type, ".")]
+	id := toUint(ev.Payload["resource_id"])
+	if id == 0 {
+		return
+	}
+
+	if err := i.Sync(ctx, docType, id); err != nil {
+		slog.Error("search: failed to index document", "type", docType, "id", id, "error", err)
+	}
+}
+
+// Sync indexes the current state of the given records, removing the ones that no longer exist
+func (i *Indexer) Sync(ctx context.Context, docType string, ids ...uint) error {
+	if err := ValidateTypes([]string{docType}); err != nil {
+		return err
+	}
+
+	docs, err := i.documents(i.DB.WithContext(ctx).Where("id IN ?", ids), docType)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[uint]bool, len(docs))
+	for _, d := range docs {
+		found[d.ID] = true
+	}
+	gone := []uint{}
+	for _, id := range ids {
+		if !found[id] {
+			gone = append(gone, id)
+		}
+	}
+
+	if len(docs) > 0 {
+		if err := i.Index.Upsert(ctx, docs); err != nil {
+			return err
+		}
+	}
+	return i.Index.Delete(ctx, docType, gone)
+}
+
+// Reindex rebuilds the documents of the given types, or of every type when none are
+// given, and returns how many documents were indexed
+func (i *Indexer) Reindex(ctx context.Context, types ...string) (int, error) {
+	if len(types) == 0 {
+		types = Types
+	}
+	if err := ValidateTypes(types); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, t := range types {
+		if err := i.Index.Clear(ctx, t); err != nil {
+			return total, err
+		}
+
+		var lastID uint
+		for {
+			query := i.DB.WithContext(ctx).Where("id > ?", lastID).Order("id").Limit(reindexBatchSize)
+			docs, err := i.documents(query, t)
+			if err != nil {
+				return total, err
+			}
+			if len(docs) == 0 {
+				break
+			}
+
+			if err := i.Index.Upsert(ctx, docs); err != nil {
+				return total, err
+			}
+			total += len(docs)
+			lastID = docs[len(docs)-1].ID
+		}
+	}
+	return total, nil
+}
+
+// documents loads the records selected by query as documents of the given type
+func (i *Indexer) documents(query *gorm.DB, docType string) ([]Document, error) {
+	if docType == TypeOrganization {
+		var orgs []models.Organization
+		if err := query.Select("id", "name").Find(&orgs).Error; err != nil {
+			return nil, err
+		}
+
+		docs := make([]Document, len(orgs))
+		for n, o := range orgs {
+			docs[n] = Document{Type: TypeOrganization, ID: o.ID, Title: o.Name, OrganizationIDs: []uint{o.ID}}
+		}
+		return docs, nil
+	}
+
+	var users []models.User
+	if err := query.Select("id", "name", "email").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(users))
+	for n, u := range users {
+		ids[n] = u.ID
+	}
+	var memberships []struct {
+		UserID         uint
+		OrganizationID uint
+	}
+	err := i.DB.WithContext(query.Statement.Context).
+		Table("user_organizations").
+		Select("user_id, organization_id").
+		Where("user_id IN ?", ids).
+		Scan(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+
+	orgIDs := map[uint][]uint{}
+	for _, m := range memberships {
+		orgIDs[m.UserID] = append(orgIDs[m.UserID], m.OrganizationID)
+	}
+
+	docs := make([]Document, len(users))
+	for n, u := range users {
+		docs[n] = Document{
+			Type:            TypeUser,
+			ID:              u.ID,
+			Title:           u.Name,
+			Subtitle:        u.Email,
+			OrganizationIDs: append([]uint{}, orgIDs[u.ID]...),
+		}
+	}
+	return docs, nil
+}
+
+// toUint converts an event's resource ID to uint
+func toUint(v interface{}) uint {
+	switch n := v.(type) {
+	case uint:
+		return n
+	case uint64:
+		return uint(n)
+	case int:
+		return uint(n)
+	case int64:
+		return uint(n)
+	case float64:
+		return uint(n)
+	}
+	return 0
+}
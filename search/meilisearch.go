@@ -0,0 +1,153 @@
+// Package search/meilisearch.go
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Meilisearch searches documents mirrored into a Meilisearch server
+type Meilisearch struct {
+	cfg    Config
+	client *client
+}
+
+// NewMeilisearch creates a Meilisearch index client
+func NewMeilisearch(cfg Config) *Meilisearch {
+	auth := ""
+	if cfg.APIKey != "" {
+		auth = "Bearer " + cfg.APIKey
+	}
+	return &Meilisearch{cfg: cfg, client: newClient(cfg.URL, auth)}
+}
+
+// Setup configures the searchable and filterable attributes of every index
+func (m *Meilisearch) Setup(ctx context.Context) error {
+	settings := map[string]interface{}{
+		"searchableAttributes": []string{"title", "subtitle"},
+		"filterableAttributes": []string{"organization_ids"},
+	}
+	for _, t := range Types {
+		if err := m.client.do(ctx, http.MethodPatch, "/indexes/"+indexName(m.cfg, t)+"/settings", "application/json", settings, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Upsert adds or replaces documents
+func (m *Meilisearch) Upsert(ctx context.Context, docs []Document) error {
+	for docType, batch := range byType(docs) {
+		path := "/indexes/" + indexName(m.cfg, docType) + "/documents?primaryKey=id"
+		if err := m.client.do(ctx, http.MethodPost, path, "application/json", batch, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes documents of a type by ID
+func (m *Meilisearch) Delete(ctx context.Context, docType string, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	path := "/indexes/" + indexName(m.cfg, docType) + "/documents/delete-batch"
+	return m.client.do(ctx, http.MethodPost, path, "application/json", ids, nil)
+}
+
+// Clear removes every document of a type
+func (m *Meilisearch) Clear(ctx context.Context, docType string) error {
+	return m.client.do(ctx, http.MethodDelete, "/indexes/"+indexName(m.cfg, docType)+"/documents", "", nil, nil)
+}
+
+// Search queries every requested index in one multi-search request and merges the
+// hits by ranking score
+func (m *Meilisearch) Search(ctx context.Context, q Query) ([]Hit, error) {
+	if err := ValidateTypes(q.Types); err != nil {
+		return nil, err
+	}
+	if q.OrganizationIDs != nil && len(q.OrganizationIDs) == 0 {
+		return []Hit{}, nil
+	}
+
+	filter := ""
+	if q.OrganizationIDs != nil {
+		ids := make([]string, len(q.OrganizationIDs))
+		for i, id := range q.OrganizationIDs {
+			ids[i] = fmt.Sprint(id)
+		}
+		filter = "organization_ids IN [" + strings.Join(ids, ", ") + "]"
+	}
+
+	indexType := map[string]string{}
+	queries := []map[string]interface{}{}
+	for _, t := range Types {
+		if !q.wants(t) {
+			continue
+		}
+		index := indexName(m.cfg, t)
+		indexType[index] = t
+
+		query := map[string]interface{}{
+			"indexUid":         index,
+			"q":                q.Text,
+			"limit":            q.Limit,
+			"showRankingScore": true,
+		}
+		if filter != "" {
+			query["filter"] = filter
+		}
+		queries = append(queries, query)
+	}
+
+	var resp struct {
+		Results []struct {
+			IndexUID string `json:"indexUid"`
+			Hits     []struct {
+				ID       uint    `json:"id"`
+				Title    string  `json:"title"`
+				Subtitle string  `json:"subtitle"`
+				Score    float64 `json:"_rankingScore"`
+			} `json:"hits"`
+		} `json:"results"`
+	}
+	body := map[string]interface{}{"queries": queries}
+	if err := m.client.do(ctx, http.MethodPost, "/multi-search", "application/json", body, &resp); err != nil {
+		return nil, err
+	}
+
+	hits := []Hit{}
+	for _, result := range resp.Results {
+		for _, h := range result.Hits {
+			hits = append(hits, Hit{
+				Type:     indexType[result.IndexUID],
+				ID:       h.ID,
+				Title:    h.Title,
+				Subtitle: h.Subtitle,
+				Score:    h.Score,
+			})
+		}
+	}
+	return rank(hits, q.Limit), nil
+}
+
+// byType groups documents by their type
+func byType(docs []Document) map[string][]Document {
+	groups := map[string][]Document{}
+	for _, d := range docs {
+		groups[d.Type] = append(groups[d.Type], d)
+	}
+	return groups
+}
+
+// rank orders hits by score, best first, and keeps at most limit of them
+func rank(hits []Hit, limit int) []Hit {
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
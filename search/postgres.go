@@ -0,0 +1,101 @@
+// Package search/postgres.go
+package search
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// userDocument and organizationDocument are the text indexed for full-text search;
+// the index expressions must match the query expressions exactly to be used
+const (
+	userDocument         = "to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(email, ''))"
+	organizationDocument = "to_tsvector('simple', coalesce(name, ''))"
+)
+
+// postgresIndexes back the tsvector and trigram matching done by Postgres.Search
+var postgresIndexes = []string{
+	"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+	"CREATE INDEX IF NOT EXISTS idx_users_search ON users USING gin ((" + userDocument + "))",
+	"CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING gin (name gin_trgm_ops)",
+	"CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING gin (email gin_trgm_ops)",
+	"CREATE INDEX IF NOT EXISTS idx_organizations_search ON organizations USING gin ((" + organizationDocument + "))",
+	"CREATE INDEX IF NOT EXISTS idx_organizations_name_trgm ON organizations USING gin (name gin_trgm_ops)",
+}
+
+// Postgres searches users and organizations with tsvector matching and trigram similarity
+type Postgres struct {
+	DB *gorm.DB
+}
+
+// NewPostgres creates a Postgres searcher
+func NewPostgres(db *gorm.DB) *Postgres {
+	return &Postgres{DB: db}
+}
+
+// Setup creates the pg_trgm extension and the search indexes
+func (p *Postgres) Setup(ctx context.Context) error {
+	for _, stmt := range postgresIndexes {
+		if err := p.DB.WithContext(ctx).Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search ranks full-text matches together with fuzzy trigram matches, so both whole
+// words and partial or misspelled terms are found
+func (p *Postgres) Search(ctx context.Context, q Query) ([]Hit, error) {
+	if err := ValidateTypes(q.Types); err != nil {
+		return nil, err
+	}
+	if q.OrganizationIDs != nil && len(q.OrganizationIDs) == 0 {
+		return []Hit{}, nil
+	}
+
+	db := p.DB.WithContext(ctx)
+	text := sql.Named("q", q.Text)
+	hits := []Hit{}
+
+	if q.wants(TypeUser) {
+		query := db.Table("users").
+			Select("'user' AS type, id, name AS title, email AS subtitle, "+
+				"ts_rank("+userDocument+", plainto_tsquery('simple', @q)) + GREATEST(similarity(name, @q), similarity(email, @q)) AS score",
+				text,
+			).
+			Where("deleted_at IS NULL").
+			Where(userDocument+" @@ plainto_tsquery('simple', @q) OR name % @q OR email % @q", text)
+		if q.OrganizationIDs != nil {
+			query = query.Where("id IN (?)", db.Table("user_organizations").Select("user_id").Where("organization_id IN ?", q.OrganizationIDs))
+		}
+
+		var users []Hit
+		if err := query.Order("score DESC").Limit(q.Limit).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		hits = append(hits, users...)
+	}
+
+	if q.wants(TypeOrganization) {
+		query := db.Table("organizations").
+			Select("'organization' AS type, id, name AS title, '' AS subtitle, "+
+				"ts_rank("+organizationDocument+", plainto_tsquery('simple', @q)) + similarity(name, @q) AS score",
+				text,
+			).
+			Where("deleted_at IS NULL").
+			Where(organizationDocument+" @@ plainto_tsquery('simple', @q) OR name % @q", text)
+		if q.OrganizationIDs != nil {
+			query = query.Where("id IN ?", q.OrganizationIDs)
+		}
+
+		var orgs []Hit
+		if err := query.Order("score DESC").Limit(q.Limit).Find(&orgs).Error; err != nil {
+			return nil, err
+		}
+		hits = append(hits, orgs...)
+	}
+
+	return rank(hits, q.Limit), nil
+}
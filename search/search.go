@@ -0,0 +1,129 @@
+// Package search/search.go
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Searchable types
+const (
+	TypeUser         = "user"
+	TypeOrganization = "organization"
+)
+
+// Types lists every searchable type
+var Types = []string{TypeUser, TypeOrganization}
+
+// ErrUnknownType is returned for queries naming a type that isn't searchable
+var ErrUnknownType = errors.New("unknown search type")
+
+// Hit is one search result
+type Hit struct {
+	Type     string  `json:"type"`
+	ID       uint    `json:"id"`
+	Title    string  `json:"title"`
+	Subtitle string  `json:"subtitle,omitempty"`
+	Score    float64 `json:"score"`
+}
+
+// Query describes a search
+type Query struct {
+	Text  string
+	Types []string
+	// OrganizationIDs restricts results to these organizations and their members;
+	// nil searches everything
+	OrganizationIDs []uint
+	Limit           int
+}
+
+// Searcher runs ranked searches
+type Searcher interface {
+	// Setup prepares the indexes the searcher relies on
+	Setup(ctx context.Context) error
+	Search(ctx context.Context, q Query) ([]Hit, error)
+}
+
+// Document is the indexed form of a record in an external search engine
+type Document struct {
+	Type            string `json:"-"`
+	ID              uint   `json:"id"`
+	Title           string `json:"title"`
+	Subtitle        string `json:"subtitle,omitempty"`
+	OrganizationIDs []uint `json:"organization_ids"`
+}
+
+// Index is a search engine keeping its own copy of the documents, which an Indexer
+// keeps in sync with the database
+type Index interface {
+	Searcher
+	Upsert(ctx context.Context, docs []Document) error
+	Delete(ctx context.Context, docType string, ids []uint) error
+	// Clear removes every document of a type
+	Clear(ctx context.Context, docType string) error
+}
+
+// Config selects the search engine
+type Config struct {
+	// Engine is "postgres", "database", "meilisearch" or "elasticsearch". It
+	// defaults to postgres on Postgres databases and database on others.
+	Engine      string
+	URL         string
+	APIKey      string
+	IndexPrefix string
+}
+
+// New returns the configured searcher; external engines also implement Index
+func New(cfg Config, db *gorm.DB) (Searcher, error) {
+	engine := cfg.Engine
+	if engine == "" {
+		engine = "database"
+		if db.Dialector.Name() == "postgres" {
+			engine = "postgres"
+		}
+	}
+
+	switch engine {
+	case "postgres":
+		return NewPostgres(db), nil
+	case "database":
+		return NewDatabase(db), nil
+	case "meilisearch":
+		return NewMeilisearch(cfg), nil
+	case "elasticsearch":
+		return NewElasticsearch(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown search engine %q", cfg.Engine)
+	}
+}
+
+// wants reports whether the query includes the type
+func (q Query) wants(t string) bool {
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, qt := range q.Types {
+		if qt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// indexName returns the external index holding documents of a type
+func indexName(cfg Config, docType string) string {
+	return cfg.IndexPrefix + docType + "s"
+}
+
+// ValidateTypes checks that every requested type is searchable
+func ValidateTypes(types []string) error {
+	for _, t := range types {
+		if t != TypeUser && t != TypeOrganization {
+			return ErrUnknownType
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Package secrets/gcp.go
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/4cecoder/saas/tracing"
+)
+
+// GCPConfig holds the project and credentials of GCP Secret Manager
+type GCPConfig struct {
+	// Project holds the secrets named without a project
+	Project string
+	// AccessToken authenticates requests; without it a token of the instance's
+	// service account is fetched from the metadata server
+	AccessToken string
+}
+
+// gcpMetadataTokenURL issues tokens of the service account of the instance
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCP reads secret versions from GCP Secret Manager
+type GCP struct {
+	Config GCPConfig
+	Client *http.Client
+	// BaseURL is the Secret Manager API
+	BaseURL string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewGCP creates a provider reading from the configured project
+func NewGCP(cfg GCPConfig) *GCP {
+	return &GCP{
+		Config:  cfg,
+		Client:  tracing.WrapClient(&http.Client{Timeout: fetchTimeout}),
+		BaseURL: "https://secretmanager.googleapis.com/v1/",
+	}
+}
+
+// Fetch implements Provider, reading a secret such as jwt-secret, its latest
+// version unless one is given
+func (g *GCP) Fetch(ctx context.Context, name string) (string, error) {
+	if !strings.HasPrefix(name, "projects/") {
+		if g.Config.Project == "" {
+			return "", fmt.Errorf("set GCP_PROJECT or name the secret as projects/<project>/secrets/%s", name)
+		}
+		name = "projects/" + g.Config.Project + "/secrets/" + name
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.BaseURL+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var out struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := g.do(req, &out); err != nil {
+		return "", fmt.Errorf("secret manager: %s: %w", name, err)
+	}
+	data, err := base64.StdEncoding.DecodeString(out.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret manager payload: %w", err)
+	}
+	return string(data), nil
+}
+
+// accessToken returns the configured token, or one of the instance's service
+// account, fetched again shortly before it expires
+func (g *GCP) accessToken(ctx context.Context) (string, error) {
+	if g.Config.AccessToken != "" {
+		return g.Config.AccessToken, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && time.Until(g.expires) > time.Minute {
+		return g.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := g.do(req, &out); err != nil {
+		return "", fmt.Errorf("no GCP credentials, set GCP_ACCESS_TOKEN outside of GCP: %w", err)
+	}
+	g.token, g.expires = out.AccessToken, time.Now().Add(time.Duration(out.ExpiresIn)*time.Second)
+	return g.token, nil
+}
+
+// do sends req and decodes the JSON response into out
+func (g *GCP) do(req *http.Request, out interface{}) error {
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("responded %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
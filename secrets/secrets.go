@@ -0,0 +1,162 @@
+// Package secrets/secrets.go
+//
+// Package secrets fetches sensitive settings from a secrets manager. An
+// environment variable whose value references a secret, such as
+// DB_PASSWORD=vault:secret/data/saas#db_password, is replaced by the secret at
+// startup, and can be fetched again periodically to pick up rotations.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Provider fetches secrets from a secrets manager
+type Provider interface {
+	// Fetch returns the secret named by a reference without its scheme
+	Fetch(ctx context.Context, name string) (string, error)
+}
+
+// Schemes of references, each naming a secret of one provider:
+//
+//	vault:<path>[#<field>], the field of a Vault KV secret, value by default
+//	ssm:<name>, an AWS Systems Manager parameter, decrypted
+//	gcp:<secret>[/versions/<version>], a GCP Secret Manager secret, in the
+//	project GCP_PROJECT unless given as projects/<project>/secrets/<secret>
+const (
+	SchemeVault = "vault"
+	SchemeSSM   = "ssm"
+	SchemeGCP   = "gcp"
+)
+
+// Config holds the credentials of the secrets managers; only the providers
+// referenced by the environment need theirs
+type Config struct {
+	Vault VaultConfig
+	SSM   SSMConfig
+	GCP   GCPConfig
+	// RefreshInterval is how often secrets are fetched again; zero fetches
+	// them only at startup
+	RefreshInterval time.Duration
+}
+
+// fetchTimeout bounds fetching one secret
+const fetchTimeout = 10 * time.Second
+
+// Resolver replaces the references in the environment with the secrets they
+// name, keeping them to fetch the secrets again
+type Resolver struct {
+	Providers       map[string]Provider
+	RefreshInterval time.Duration
+
+	// refs maps the environment variables set from secrets to their references
+	refs map[string]string
+}
+
+// New creates a resolver with a provider for each secrets manager
+func New(cfg Config) *Resolver {
+	return &Resolver{
+		Providers: map[string]Provider{
+			SchemeVault: NewVault(cfg.Vault),
+			SchemeSSM:   NewSSM(cfg.SSM),
+			SchemeGCP:   NewGCP(cfg.GCP),
+		},
+		RefreshInterval: cfg.RefreshInterval,
+		refs:            map[string]string{},
+	}
+}
+
+// IsReference reports whether value references a secret
+func (r *Resolver) IsReference(value string) bool {
+	scheme, _, ok := strings.Cut(value, ":")
+	_, known := r.Providers[scheme]
+	return ok && known
+}
+
+// Fetch returns the secret named by ref
+func (r *Resolver) Fetch(ctx context.Context, ref string) (string, error) {
+	scheme, name, _ := strings.Cut(ref, ":")
+	provider, ok := r.Providers[scheme]
+	if !ok {
+		return "", fmt.Errorf("unknown secrets manager %q", scheme)
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	return provider.Fetch(ctx, name)
+}
+
+// ResolveEnv sets every environment variable referencing a secret to the
+// secret, returning the variables that couldn't be fetched, one per line
+func (r *Resolver) ResolveEnv(ctx context.Context) error {
+	var keys []string
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if r.IsReference(value) {
+			keys = append(keys, key)
+			r.refs[key] = value
+		}
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		secret, err := r.Fetch(ctx, r.refs[key])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		os.Setenv(key, secret)
+	}
+	return errors.Join(errs...)
+}
+
+// Keys returns the environment variables set from secrets
+func (r *Resolver) Keys() []string {
+	keys := make([]string, 0, len(r.refs))
+	for key := range r.refs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Refresh fetches the secrets again, updating the environment and calling
+// changed with each variable whose secret was rotated
+func (r *Resolver) Refresh(ctx context.Context, changed func(key, value string)) {
+	for _, key := range r.Keys() {
+		secret, err := r.Fetch(ctx, r.refs[key])
+		if err != nil {
+			slog.WarnContext(ctx, "secrets: failed to refresh secret", "key", key, "error", err)
+			continue
+		}
+		if secret == os.Getenv(key) {
+			continue
+		}
+		os.Setenv(key, secret)
+		slog.InfoContext(ctx, "secrets: secret rotated", "key", key)
+		changed(key, secret)
+	}
+}
+
+// Watch refreshes the secrets every RefreshInterval until ctx is done
+func (r *Resolver) Watch(ctx context.Context, changed func(key, value string)) {
+	if r.RefreshInterval <= 0 || len(r.Keys()) == 0 {
+		return
+	}
+	ticker := time.NewTicker(r.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Refresh(ctx, changed)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package secrets/ssm.go
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+
+	"github.com/4cecoder/saas/tracing"
+)
+
+// SSMConfig overrides what the AWS SDK finds on its own. Credentials come from
+// the SDK's default chain: the AWS_* variables, the shared config files, or the
+// role of the instance, task or pod.
+type SSMConfig struct {
+	// Region overrides AWS_REGION, AWS_DEFAULT_REGION and the shared config files
+	Region string
+	// Endpoint overrides the regional endpoint, e.g. for a VPC endpoint
+	Endpoint string
+}
+
+// SSM reads parameters from the AWS Systems Manager Parameter Store,
+// decrypting SecureStrings
+type SSM struct {
+	Config SSMConfig
+
+	mu     sync.Mutex
+	client *ssm.Client
+}
+
+// NewSSM creates a provider reading from the configured region. The AWS
+// configuration is loaded on first use, so deployments without SSM references
+// need none.
+func NewSSM(cfg SSMConfig) *SSM {
+	return &SSM{Config: cfg}
+}
+
+// Fetch implements Provider, reading a parameter such as /saas/prod/db_password
+func (s *SSM) Fetch(ctx context.Context, name string) (string, error) {
+	client, err := s.ssmClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	out, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("read %s from ssm: %w", name, err)
+	}
+	return aws.ToString(out.Parameter.Value), nil
+}
+
+// ssmClient returns the SSM client, creating it from the default AWS
+// configuration the first time
+func (s *SSM) ssmClient(ctx context.Context) (*ssm.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithHTTPClient(tracing.WrapClient(&http.Client{Timeout: fetchTimeout})),
+	}
+	if s.Config.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(s.Config.Region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("set AWS_REGION to read secrets from SSM")
+	}
+
+	s.client = ssm.NewFromConfig(cfg, func(o *ssm.Options) {
+		if s.Config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(s.Config.Endpoint)
+		}
+	})
+	return s.client, nil
+}
@@ -0,0 +1,87 @@
+// Package secrets/vault.go
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/4cecoder/saas/tracing"
+)
+
+// VaultConfig locates a HashiCorp Vault server
+type VaultConfig struct {
+	// Addr is the URL of the server, such as https://vault.internal:8200
+	Addr  string
+	Token string
+	// Namespace is the Vault Enterprise namespace, if any
+	Namespace string
+}
+
+// Vault reads fields of secrets from the KV secrets engine, version 1 or 2
+type Vault struct {
+	Config VaultConfig
+	Client *http.Client
+}
+
+// NewVault creates a provider reading from the configured server
+func NewVault(cfg VaultConfig) *Vault {
+	return &Vault{Config: cfg, Client: tracing.WrapClient(&http.Client{Timeout: fetchTimeout})}
+}
+
+// Fetch implements Provider, reading a path such as secret/data/saas#db_password
+func (v *Vault) Fetch(ctx context.Context, name string) (string, error) {
+	if v.Config.Addr == "" || v.Config.Token == "" {
+		return "", fmt.Errorf("set VAULT_ADDR and VAULT_TOKEN to read secrets from Vault")
+	}
+	path, field, _ := strings.Cut(name, "#")
+	if field == "" {
+		field = "value"
+	}
+
+	url := strings.TrimSuffix(v.Config.Addr, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Config.Token)
+	if v.Config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Config.Namespace)
+	}
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("vault responded %s for %s: %s", resp.Status, path, strings.TrimSpace(string(msg)))
+	}
+
+	// KV version 2 nests the fields and metadata of the secret in data
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	switch value := data[field].(type) {
+	case string:
+		return value, nil
+	case nil:
+		return "", fmt.Errorf("vault secret %s has no field %q", path, field)
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
@@ -0,0 +1,80 @@
+// Package seed/bootstrap.go
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// MinBootstrapPasswordLength is the shortest bootstrap password accepted
+const MinBootstrapPasswordLength = 12
+
+// BootstrapConfig holds the credentials of the first administrator, created at
+// startup so a fresh deployment can be signed into
+type BootstrapConfig struct {
+	// Enabled creates the administrator when there's none; disable it once
+	// administrators are managed otherwise
+	Enabled  bool
+	Email    string
+	Password string
+}
+
+// Bootstrap creates the administrator of cfg unless bootstrap is disabled or an
+// administrator already exists. The password is only good for signing in once:
+// the administrator must change it before doing anything else. It reports
+// whether the administrator was created.
+func Bootstrap(ctx context.Context, db *gorm.DB, cfg BootstrapConfig) (bool, error) {
+	if !cfg.Enabled || cfg.Email == "" {
+		return false, nil
+	}
+
+	created := false
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role := models.Role{Name: models.AdminRole}
+		if err := tx.Where(&role).FirstOrCreate(&role).Error; err != nil {
+			return fmt.Errorf("create role %s: %w", role.Name, err)
+		}
+
+		var admins int64
+		err := tx.Table("user_roles").
+			Joins("JOIN users ON users.id = user_roles.user_id AND users.deleted_at IS NULL").
+			Where("user_roles.role_id = ?", role.ID).
+			Count(&admins).Error
+		if err != nil || admins > 0 {
+			return err
+		}
+
+		// Granting the admin role to an existing account would hand it over to
+		// whoever knows the bootstrap password
+		err = tx.Where("email = ?", cfg.Email).First(&models.User{}).Error
+		if err == nil {
+			return fmt.Errorf("bootstrap: %s exists and isn't an administrator", cfg.Email)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		admin := models.User{
+			Email:                  cfg.Email,
+			Password:               cfg.Password,
+			Name:                   "Admin User",
+			Roles:                  []models.Role{role},
+			Verified:               true,
+			PasswordChangeRequired: true,
+			Locale:                 "en",
+			Timezone:               "UTC",
+			Language:               "en",
+		}
+		if err := tx.Create(&admin).Error; err != nil {
+			return fmt.Errorf("create admin: %w", err)
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
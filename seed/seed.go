@@ -0,0 +1,357 @@
+// Package seed/seed.go
+package seed
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// Sizes maps the named dataset sizes to their number of organizations and users per organization
+var Sizes = map[string][2]int{
+	"small":  {3, 5},
+	"medium": {20, 25},
+	"large":  {100, 100},
+}
+
+// Options configures the demo dataset
+type Options struct {
+	Organizations        int
+	UsersPerOrganization int
+	// Seed makes the generated names and statuses reproducible
+	Seed int64
+	// AdminEmail and AdminPassword are the login of the administrator; a random
+	// password is generated when none is given
+	AdminEmail    string
+	AdminPassword string
+}
+
+// Summary counts the records a seed run created
+type Summary struct {
+	AdminEmail    string
+	AdminPassword string
+	// AdminCreated is false when the administrator already existed
+	AdminCreated  bool
+	Plans         int
+	Organizations int
+	Users         int
+	Seats         int
+	Subscriptions int
+	Workflows     int
+}
+
+var (
+	firstNames = []string{"Ada", "Alan", "Grace", "Linus", "Margaret", "Dennis", "Barbara", "Ken", "Frances", "Edsger", "Radia", "Donald", "Hedy", "John", "Katherine", "Tim"}
+	lastNames  = []string{"Lovelace", "Turing", "Hopper", "Torvalds", "Hamilton", "Ritchie", "Liskov", "Thompson", "Allen", "Dijkstra", "Perlman", "Knuth", "Lamarr", "McCarthy", "Johnson", "Berners-Lee"}
+	companies  = []string{"Acme", "Globex", "Initech", "Umbrella", "Hooli", "Stark", "Wayne", "Wonka", "Soylent", "Tyrell", "Cyberdyne", "Aperture"}
+	suffixes   = []string{"Labs", "Corp", "Industries", "Systems", "Group", "Analytics"}
+	locales    = []string{"en", "de", "fr", "es", "ja"}
+	timezones  = []string{"UTC", "Europe/Berlin", "America/New_York", "Asia/Tokyo", "America/Los_Angeles"}
+)
+
+// plans are the subscription plans of the demo dataset
+var plans = []struct {
+	name, description string
+	price             float64
+	features          []string
+	maxAttachmentSize int64
+}{
+	{"Starter", "For small teams getting started", 9, []string{"Audit logs", "API access"}, 5 << 20},
+	{"Team", "For growing teams", 29, []string{"Audit logs", "API access", "Workflows", "Reports"}, 25 << 20},
+	{"Business", "For organizations with compliance needs", 99, []string{"Audit logs", "API access", "Workflows", "Reports", "SIEM streaming", "SSO"}, 100 << 20},
+}
+
+// Run loads the demo dataset in one transaction: roles, the administrator,
+// subscription plans, and organizations with members, seats, a subscription, and
+// workflows. Roles, plans, and the administrator are reused when they exist.
+func Run(ctx context.Context, db *gorm.DB, opts Options) (*Summary, error) {
+	if opts.AdminEmail == "" {
+		opts.AdminEmail = "admin@example.com"
+	}
+	if opts.AdminPassword == "" {
+		opts.AdminPassword = randomPassword()
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+
+	s := &seeder{
+		rand:    mathrand.New(mathrand.NewSource(opts.Seed)),
+		summary: &Summary{AdminEmail: opts.AdminEmail},
+	}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		s.tx = tx
+		return s.run(opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.summary.AdminCreated {
+		s.summary.AdminPassword = opts.AdminPassword
+	}
+	return s.summary, nil
+}
+
+// seeder holds the state of one seed run
+type seeder struct {
+	tx      *gorm.DB
+	rand    *mathrand.Rand
+	summary *Summary
+	roles   map[string]models.Role
+	plans   []models.SubscriptionPlan
+}
+
+// run creates the dataset
+func (s *seeder) run(opts Options) error {
+	s.roles = map[string]models.Role{}
+	for _, name := range []string{models.AdminRole, models.UserRole} {
+		role := models.Role{Name: name}
+		if err := s.tx.Where(&role).FirstOrCreate(&role).Error; err != nil {
+			return fmt.Errorf("create role %s: %w", name, err)
+		}
+		s.roles[name] = role
+	}
+
+	admin, err := s.admin(opts.AdminEmail, opts.AdminPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.createPlans(); err != nil {
+		return err
+	}
+
+	for i := 0; i < opts.Organizations; i++ {
+		if err := s.createOrganization(i, opts.UsersPerOrganization, admin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// admin returns the administrator with the email, creating them if needed
+func (s *seeder) admin(email, password string) (*models.User, error) {
+	var admin models.User
+	err := s.tx.Where("email = ?", email).First(&admin).Error
+	if err == nil {
+		return &admin, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	admin = models.User{
+		Email:    email,
+		Password: password,
+		Name:     "Admin User",
+		Roles:    []models.Role{s.roles[models.AdminRole]},
+		Verified: true,
+		Locale:   "en",
+		Timezone: "UTC",
+		Language: "en",
+	}
+	if err := s.tx.Create(&admin).Error; err != nil {
+		return nil, fmt.Errorf("create admin: %w", err)
+	}
+	s.summary.AdminCreated = true
+	return &admin, nil
+}
+
+// createPlans creates the subscription plans and their features that don't exist yet
+func (s *seeder) createPlans() error {
+	features := map[string]models.Feature{}
+	for _, p := range plans {
+		plan := models.SubscriptionPlan{Name: p.name}
+		res := s.tx.Where(&plan).Attrs(models.SubscriptionPlan{
+			Description:       p.description,
+			Price:             p.price,
+			Currency:          "USD",
+			Interval:          "month",
+			MaxAttachmentSize: p.maxAttachmentSize,
+		}).FirstOrCreate(&plan)
+		if res.Error != nil {
+			return fmt.Errorf("create plan %s: %w", p.name, res.Error)
+		}
+		s.plans = append(s.plans, plan)
+		if res.RowsAffected == 0 {
+			continue
+		}
+		s.summary.Plans++
+
+		for _, name := range p.features {
+			feature, ok := features[name]
+			if !ok {
+				feature = models.Feature{Name: name}
+				if err := s.tx.Where(&feature).FirstOrCreate(&feature).Error; err != nil {
+					return fmt.Errorf("create feature %s: %w", name, err)
+				}
+				features[name] = feature
+			}
+			if err := s.tx.Model(&plan).Association("Features").Append(&feature); err != nil {
+				return fmt.Errorf("add feature %s to plan %s: %w", name, p.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// createOrganization creates the i-th organization with its members, seats,
+// subscription, and workflows
+func (s *seeder) createOrganization(i, users int, admin *models.User) error {
+	name := fmt.Sprintf("%s %s", companies[i%len(companies)], pick(s.rand, suffixes))
+	if i >= len(companies) {
+		name = fmt.Sprintf("%s %d", name, i/len(companies)+1)
+	}
+	plan := s.plans[s.rand.Intn(len(s.plans))]
+
+	org := models.Organization{
+		Name:               name,
+		SubscriptionPlanID: &plan.ID,
+		Settings: models.OrganizationSettings{
+			ThemeColor:            fmt.Sprintf("#%06x", s.rand.Intn(0xffffff)),
+			AuditLogRetentionDays: 365,
+		},
+	}
+	if err := s.tx.Create(&org).Error; err != nil {
+		return fmt.Errorf("create organization %s: %w", name, err)
+	}
+	s.summary.Organizations++
+
+	// The ID keeps the member emails unique when the dataset is loaded again
+	domain := fmt.Sprintf("%s-%d.example", strings.ToLower(strings.ReplaceAll(name, " ", "-")), org.ID)
+	members := []*models.User{admin}
+	for j := 0; j < users; j++ {
+		user, err := s.createMember(&org, domain, j)
+		if err != nil {
+			return err
+		}
+		members = append(members, user)
+	}
+
+	now := time.Now()
+	sub := models.Subscription{
+		OrganizationID:     org.ID,
+		SubscriptionPlanID: &plan.ID,
+		Status:             pickStatus(s.rand),
+		StartDate:          now.AddDate(0, -s.rand.Intn(24), 0),
+		PaymentMethod:      "card",
+		LastPaymentDate:    now.AddDate(0, 0, -s.rand.Intn(30)),
+	}
+	sub.NextBillingDate = sub.LastPaymentDate.AddDate(0, 1, 0)
+	if err := s.tx.Create(&sub).Error; err != nil {
+		return fmt.Errorf("create subscription of %s: %w", name, err)
+	}
+	s.summary.Subscriptions++
+
+	return s.createWorkflows(&org, members[s.rand.Intn(len(members))])
+}
+
+// createMember creates the j-th user of an organization with a seat in it
+func (s *seeder) createMember(org *models.Organization, domain string, j int) (*models.User, error) {
+	first, last := pick(s.rand, firstNames), pick(s.rand, lastNames)
+	user := models.User{
+		Email:    fmt.Sprintf("%s.%s%d@%s", strings.ToLower(first), strings.ToLower(last), j+1, domain),
+		Password: randomPassword(),
+		Name:     first + " " + last,
+		Roles:    []models.Role{s.roles[models.UserRole]},
+		Verified: s.rand.Intn(10) > 0,
+		Locale:   pick(s.rand, locales),
+		Timezone: pick(s.rand, timezones),
+	}
+	user.Language = user.Locale
+	if err := s.tx.Create(&user).Error; err != nil {
+		return nil, fmt.Errorf("create user %s: %w", user.Email, err)
+	}
+	if err := s.tx.Model(org).Association("Users").Append(&user); err != nil {
+		return nil, fmt.Errorf("add %s to %s: %w", user.Email, org.Name, err)
+	}
+	s.summary.Users++
+
+	status := models.SeatStatusActive
+	switch n := s.rand.Intn(10); {
+	case n == 0:
+		status = models.SeatStatusInactive
+	case n == 1:
+		status = models.SeatStatusInvited
+	}
+	seat := models.Seat{OrganizationID: org.ID, UserID: user.ID, Status: status}
+	if err := s.tx.Create(&seat).Error; err != nil {
+		return nil, fmt.Errorf("create seat of %s: %w", user.Email, err)
+	}
+	s.summary.Seats++
+	return &user, nil
+}
+
+// createWorkflows creates an invitation follow-up and an approval workflow for an organization
+func (s *seeder) createWorkflows(org *models.Organization, creator *models.User) error {
+	workflows := []models.Workflow{
+		{
+			Name:        "Follow up on invitations",
+			Description: "Remind the organization owner of members who haven't joined after a day",
+			Triggers:    []string{"seat.invited"},
+			Steps: []models.WorkflowStep{
+				{Name: "Wait a day", Order: 1, Type: models.WorkflowActionWait, Config: models.JSONMap{"duration": "24h"}},
+				{Name: "Remind owner", Order: 2, Type: models.WorkflowActionEmail, Config: models.JSONMap{
+					"to":      []string{creator.Email},
+					"subject": "A member of " + org.Name + " hasn't joined yet",
+					"text":    "User {{input.user_id}} was invited a day ago.",
+				}},
+			},
+		},
+		{
+			Name:        "Approve plan changes",
+			Description: "An administrator approves subscription changes",
+			Triggers:    []string{"subscription.updated"},
+			Steps: []models.WorkflowStep{
+				{Name: "Approve change", Order: 1, Type: models.WorkflowActionApproval, Approver: "role:" + models.AdminRole},
+			},
+		},
+	}
+
+	for i := range workflows {
+		workflows[i].OrganizationID = org.ID
+		workflows[i].CreatorID = creator.ID
+		workflows[i].Enabled = true
+		workflows[i].Version = 1
+		if err := s.tx.Create(&workflows[i]).Error; err != nil {
+			return fmt.Errorf("create workflow %s: %w", workflows[i].Name, err)
+		}
+		s.summary.Workflows++
+	}
+	return nil
+}
+
+// pickStatus returns a subscription status, mostly active
+func pickStatus(r *mathrand.Rand) models.SubscriptionStatus {
+	switch n := r.Intn(10); {
+	case n < 6:
+		return models.SubscriptionStatusActive
+	case n < 8:
+		return models.SubscriptionStatusTrialing
+	case n < 9:
+		return models.SubscriptionStatusInactive
+	default:
+		return models.SubscriptionStatusCanceled
+	}
+}
+
+// pick returns a random element of values
+func pick(r *mathrand.Rand, values []string) string {
+	return values[r.Intn(len(values))]
+}
+
+// randomPassword returns a random password for accounts nobody logs into with a known one
+func randomPassword() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,69 @@
+// Package service/resource.go
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/repository"
+)
+
+// Resource holds the create, read, update, and delete logic shared by resources
+// without further business rules. Errors are *apperror.Error values.
+type Resource[T any] struct {
+	Repo repository.Repository[T]
+	// Name is used in error messages, e.g. "User"
+	Name string
+}
+
+// Get returns the record with the given ID, preloading the given relations
+func (s *Resource[T]) Get(ctx context.Context, id uint, preload ...string) (*T, error) {
+	record, err := s.Repo.Find(ctx, id, preload...)
+	if err != nil {
+		return nil, s.wrap(err)
+	}
+	return record, nil
+}
+
+// Create stores the record a create request maps to
+func (s *Resource[T]) Create(ctx context.Context, req dto.Creator[T]) (*T, error) {
+	record := req.Model()
+	if err := s.Repo.Create(ctx, &record); err != nil {
+		return nil, s.wrap(err)
+	}
+	return &record, nil
+}
+
+// Update applies an update request to a record read at readAt; a zero readAt
+// saves unconditionally
+func (s *Resource[T]) Update(ctx context.Context, record *T, req dto.Updater[T], readAt time.Time) error {
+	req.Apply(record)
+	return s.wrap(s.Repo.Update(ctx, record, readAt))
+}
+
+// Delete deletes the record with the given ID
+func (s *Resource[T]) Delete(ctx context.Context, id uint) error {
+	record, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.wrap(s.Repo.Delete(ctx, record))
+}
+
+// wrap converts repository errors into API errors
+func (s *Resource[T]) wrap(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apperror.NotFound(s.Name + " not found")
+	case errors.Is(err, repository.ErrStale):
+		return apperror.PreconditionFailed("Resource has been modified, fetch it again before updating")
+	}
+	return apperror.From(err)
+}
@@ -0,0 +1,74 @@
+// Package service/seats.go
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/repository"
+)
+
+// Seats holds the logic of organization seats
+type Seats struct {
+	Resource[models.Seat]
+	Seats         repository.Seats
+	Organizations repository.Repository[models.Organization]
+}
+
+// Create adds a seat, unless the organization has used up its seat limit
+func (s *Seats) Create(ctx context.Context, req dto.Creator[models.Seat]) (*models.Seat, error) {
+	seat := req.Model()
+	if err := s.checkLimit(ctx, &seat); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(ctx, &seat); err != nil {
+		return nil, s.wrap(err)
+	}
+	return &seat, nil
+}
+
+// Update applies an update request; reactivating an inactive seat takes up the
+// organization's seat limit like a new one
+func (s *Seats) Update(ctx context.Context, seat *models.Seat, req dto.Updater[models.Seat], readAt time.Time) error {
+	wasInactive := seat.Status == models.SeatStatusInactive
+	req.Apply(seat)
+	if wasInactive {
+		if err := s.checkLimit(ctx, seat); err != nil {
+			return err
+		}
+	}
+	return s.wrap(s.Repo.Update(ctx, seat, readAt))
+}
+
+// checkLimit rejects occupying a seat beyond the organization's seat limit
+func (s *Seats) checkLimit(ctx context.Context, seat *models.Seat) error {
+	if seat.Status == models.SeatStatusInactive {
+		return nil
+	}
+
+	org, err := s.Organizations.Find(ctx, seat.OrganizationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperror.NotFound("Organization not found")
+	}
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	if org.Settings.SeatLimit == 0 {
+		return nil
+	}
+
+	occupied, err := s.Seats.CountOccupied(ctx, seat.OrganizationID)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	if occupied >= int64(org.Settings.SeatLimit) {
+		return apperror.QuotaExceeded("Organization has used all of its seats")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Package service/service.go
+package service
+
+import (
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/repository"
+)
+
+// Users holds the logic of user accounts
+type Users = Resource[models.User]
+
+// Organizations holds the logic of organizations
+type Organizations = Resource[models.Organization]
+
+// NewUsers creates a user service over a user repository
+func NewUsers(repo repository.Repository[models.User]) *Users {
+	return &Users{Repo: repo, Name: "User"}
+}
+
+// NewOrganizations creates an organization service over an organization repository
+func NewOrganizations(repo repository.Repository[models.Organization]) *Organizations {
+	return &Organizations{Repo: repo, Name: "Organization"}
+}
+
+// NewSubscriptions creates a subscription service over a subscription repository
+func NewSubscriptions(repo repository.Repository[models.Subscription]) *Subscriptions {
+	return &Subscriptions{Resource: Resource[models.Subscription]{Repo: repo, Name: "Subscription"}}
+}
+
+// NewSeats creates a seat service over the seat and organization repositories
+func NewSeats(seats repository.Seats, orgs repository.Repository[models.Organization]) *Seats {
+	return &Seats{
+		Resource:      Resource[models.Seat]{Repo: seats, Name: "Seat"},
+		Seats:         seats,
+		Organizations: orgs,
+	}
+}
@@ -0,0 +1,50 @@
+// Package service/subscriptions.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/dto"
+	"github.com/4cecoder/saas/models"
+)
+
+// transitions lists the statuses a subscription may move to from each status.
+// Canceled subscriptions are final; customers subscribe again instead.
+var transitions = map[models.SubscriptionStatus][]models.SubscriptionStatus{
+	models.SubscriptionStatusTrialing: {models.SubscriptionStatusActive, models.SubscriptionStatusInactive, models.SubscriptionStatusCanceled},
+	models.SubscriptionStatusActive:   {models.SubscriptionStatusInactive, models.SubscriptionStatusCanceled},
+	models.SubscriptionStatusInactive: {models.SubscriptionStatusActive, models.SubscriptionStatusCanceled},
+}
+
+// CanTransition reports whether a subscription may move from one status to another
+func CanTransition(from, to models.SubscriptionStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscriptions holds the logic of subscriptions
+type Subscriptions struct {
+	Resource[models.Subscription]
+}
+
+// Update applies an update request, rejecting status changes that aren't allowed
+func (s *Subscriptions) Update(ctx context.Context, sub *models.Subscription, req dto.Updater[models.Subscription], readAt time.Time) error {
+	from := sub.Status
+	req.Apply(sub)
+	if !CanTransition(from, sub.Status) {
+		to := sub.Status
+		sub.Status = from
+		return apperror.Conflict(fmt.Sprintf("Subscription can't change from %s to %s", from, to))
+	}
+	return s.wrap(s.Repo.Update(ctx, sub, readAt))
+}
@@ -0,0 +1,312 @@
+// Package settings/settings.go
+//
+// Package settings holds runtime settings, such as rate limits and feature
+// toggles, that platform admins change without restarting the server. Changes
+// are kept in the database, where every instance picks them up within the
+// refresh interval, and each one is recorded with who made it.
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/4cecoder/saas/apperror"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/requestid"
+)
+
+// Definition describes a setting
+type Definition struct {
+	Key         string
+	Description string
+	// Default is the value until the setting is changed, usually from the configuration
+	Default string
+	// Validate rejects invalid values; nil accepts any
+	Validate func(value string) error
+}
+
+// Value is the current value of a setting
+type Value struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Value       string `json:"value"`
+	Default     string `json:"default"`
+	// Overridden is set when the value was changed from the default
+	Overridden bool       `json:"overridden"`
+	UpdatedBy  uint       `json:"updated_by,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// Store serves the settings from memory, loaded from the database
+type Store struct {
+	DB *gorm.DB
+	// RefreshInterval is how often Run reloads the settings changed by other instances
+	RefreshInterval time.Duration
+
+	mu        sync.RWMutex
+	defs      map[string]Definition
+	overrides map[string]models.RuntimeSetting
+	listeners []func(key, value string)
+}
+
+// New creates a store of the settings in db
+func New(db *gorm.DB, refresh time.Duration) *Store {
+	return &Store{
+		DB:              db,
+		RefreshInterval: refresh,
+		defs:            map[string]Definition{},
+		overrides:       map[string]models.RuntimeSetting{},
+	}
+}
+
+// Define adds a setting
+func (s *Store) Define(d Definition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defs[d.Key] = d
+}
+
+// OnChange calls fn with the new value of every setting that changes
+func (s *Store) OnChange(fn func(key, value string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Get returns the value of a setting, or "" if it isn't defined
+func (s *Store) Get(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.get(key)
+}
+
+// get returns the value of a setting; s.mu must be held
+func (s *Store) get(key string) string {
+	if o, ok := s.overrides[key]; ok {
+		return o.Value
+	}
+	return s.defs[key].Default
+}
+
+// Bool returns the value of a boolean setting, false if it isn't one
+func (s *Store) Bool(key string) bool {
+	b, _ := strconv.ParseBool(s.Get(key))
+	return b
+}
+
+// List returns the settings ordered by key
+func (s *Store) List() []Value {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make([]Value, 0, len(s.defs))
+	for key := range s.defs {
+		values = append(values, s.value(key))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+	return values
+}
+
+// Lookup returns a setting, failing with a not found error if it isn't defined
+func (s *Store) Lookup(key string) (Value, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.defs[key]; !ok {
+		return Value{}, apperror.NotFound("Setting not found")
+	}
+	return s.value(key), nil
+}
+
+// value describes a defined setting; s.mu must be held
+func (s *Store) value(key string) Value {
+	d := s.defs[key]
+	v := Value{Key: key, Description: d.Description, Value: d.Default, Default: d.Default}
+	if o, ok := s.overrides[key]; ok {
+		updatedAt := o.UpdatedAt
+		v.Value, v.Overridden, v.UpdatedBy, v.UpdatedAt = o.Value, true, o.UpdatedBy, &updatedAt
+	}
+	return v
+}
+
+// Set changes a setting on behalf of userID, recording the change
+func (s *Store) Set(ctx context.Context, key, value string, userID uint) (Value, error) {
+	s.mu.RLock()
+	d, ok := s.defs[key]
+	s.mu.RUnlock()
+	if !ok {
+		return Value{}, apperror.NotFound("Setting not found")
+	}
+	if d.Validate != nil {
+		if err := d.Validate(value); err != nil {
+			return Value{}, apperror.Validation(map[string]string{"value": err.Error()})
+		}
+	}
+
+	setting := models.RuntimeSetting{Key: key, Value: value, UpdatedBy: userID, UpdatedAt: time.Now()}
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		old, err := current(tx, key)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&setting).Error; err != nil {
+			return err
+		}
+		return record(ctx, tx, key, old, &value, userID)
+	})
+	if err != nil {
+		return Value{}, apperror.Internal(err)
+	}
+
+	s.apply(map[string]models.RuntimeSetting{key: setting}, []string{key})
+	return s.Lookup(key)
+}
+
+// Reset restores the default of a setting on behalf of userID, recording the change
+func (s *Store) Reset(ctx context.Context, key string, userID uint) (Value, error) {
+	if _, err := s.Lookup(key); err != nil {
+		return Value{}, err
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		old, err := current(tx, key)
+		if err != nil || old == nil {
+			return err
+		}
+		if err := tx.Delete(&models.RuntimeSetting{Key: key}).Error; err != nil {
+			return err
+		}
+		return record(ctx, tx, key, old, nil, userID)
+	})
+	if err != nil {
+		return Value{}, apperror.Internal(err)
+	}
+
+	s.apply(nil, []string{key})
+	return s.Lookup(key)
+}
+
+// History returns the recorded changes of a setting, newest first
+func (s *Store) History(ctx context.Context, key string, limit int) ([]models.RuntimeSettingChange, error) {
+	if _, err := s.Lookup(key); err != nil {
+		return nil, err
+	}
+	var changes []models.RuntimeSettingChange
+	err := s.DB.WithContext(ctx).Where("setting_key = ?", key).Order("id DESC").Limit(limit).Find(&changes).Error
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return changes, nil
+}
+
+// current returns the overriding value of a setting, nil if it has none
+func current(tx *gorm.DB, key string) (*string, error) {
+	var setting models.RuntimeSetting
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("setting_key = ?", key).Take(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &setting.Value, nil
+}
+
+// record writes the change of a setting
+func record(ctx context.Context, tx *gorm.DB, key string, old, updated *string, userID uint) error {
+	slog.InfoContext(ctx, "settings: setting changed", "key", key, "user_id", userID)
+	return tx.Create(&models.RuntimeSettingChange{
+		Key:       key,
+		OldValue:  old,
+		NewValue:  updated,
+		UserID:    userID,
+		RequestID: requestid.From(ctx),
+	}).Error
+}
+
+// Load reads the settings from the database, notifying the listeners of those
+// changed since the last load
+func (s *Store) Load(ctx context.Context) error {
+	var rows []models.RuntimeSetting
+	if err := s.DB.WithContext(ctx).Find(&rows).Error; err != nil {
+		return fmt.Errorf("load settings: %w", err)
+	}
+	overrides := make(map[string]models.RuntimeSetting, len(rows))
+	for _, row := range rows {
+		overrides[row.Key] = row
+	}
+
+	s.mu.Lock()
+	var changed []string
+	for key := range s.defs {
+		o, had := s.overrides[key]
+		n, has := overrides[key]
+		if had != has || o.Value != n.Value {
+			changed = append(changed, key)
+		}
+	}
+	s.overrides = overrides
+	s.mu.Unlock()
+	s.notify(changed)
+	return nil
+}
+
+// apply updates the overrides of keys with those in overrides, dropping the
+// ones missing, and notifies the listeners
+func (s *Store) apply(overrides map[string]models.RuntimeSetting, keys []string) {
+	s.mu.Lock()
+	for _, key := range keys {
+		if o, ok := overrides[key]; ok {
+			s.overrides[key] = o
+		} else {
+			delete(s.overrides, key)
+		}
+	}
+	s.mu.Unlock()
+	s.notify(keys)
+}
+
+// notify calls the listeners with the new values of keys
+func (s *Store) notify(keys []string) {
+	sort.Strings(keys)
+	s.mu.RLock()
+	listeners := s.listeners
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = s.get(key)
+	}
+	s.mu.RUnlock()
+
+	for i, key := range keys {
+		for _, fn := range listeners {
+			fn(key, values[i])
+		}
+	}
+}
+
+// Run reloads the settings every RefreshInterval until ctx is done, picking up
+// the changes made through other instances
+func (s *Store) Run(ctx context.Context) {
+	if s.RefreshInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(ctx); err != nil {
+				slog.WarnContext(ctx, "settings: failed to reload settings", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package support/support.go
+//
+// Package support runs the ticket desk through which users ask platform admins
+// for help, emailing each side when the other one writes.
+package support
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+)
+
+// TicketPath is the page of the web frontend showing a ticket, followed by its ID
+const TicketPath = "/support/tickets"
+
+// ErrTicketClosed is returned for replies to closed tickets
+var ErrTicketClosed = errors.New("ticket closed")
+
+// Config holds the settings of the desk
+type Config struct {
+	// Email is where new tickets and the replies of users are emailed; empty
+	// emails none
+	Email string
+}
+
+// Desk records tickets and their messages
+type Desk struct {
+	DB     *gorm.DB
+	Mailer mailer.Mailer
+	// AppURL is the URL of the web frontend serving TicketPath
+	AppURL string
+	Email  string
+}
+
+// New creates the desk, linking to tickets at appURL
+func New(db *gorm.DB, mail mailer.Mailer, appURL string, cfg Config) *Desk {
+	return &Desk{DB: db, Mailer: mail, AppURL: strings.TrimSuffix(appURL, "/"), Email: cfg.Email}
+}
+
+// Open records a ticket with its first message, written by the user opening it
+func (d *Desk) Open(ctx context.Context, ticket *models.Ticket, body string) error {
+	ticket.Status = models.TicketStatusOpen
+	ticket.LastMessageAt = time.Now()
+	msg := models.TicketMessage{UserID: ticket.UserID, Body: body}
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Messages").Create(ticket).Error; err != nil {
+			return err
+		}
+		msg.TicketID = ticket.ID
+		return tx.Create(&msg).Error
+	})
+	if err != nil {
+		return err
+	}
+	ticket.Messages = []models.TicketMessage{msg}
+	d.notify(ctx, ticket, &msg, true)
+	return nil
+}
+
+// Reply posts a message of a user to a ticket. A reply of staff leaves the
+// ticket pending on the user, while one of the user reopens it; closed tickets
+// return ErrTicketClosed.
+func (d *Desk) Reply(ctx context.Context, ticket *models.Ticket, userID uint, staff bool, body string) (*models.TicketMessage, error) {
+	if ticket.Status == models.TicketStatusClosed {
+		return nil, ErrTicketClosed
+	}
+	status := models.TicketStatusOpen
+	if staff {
+		status = models.TicketStatusPending
+	}
+
+	msg := models.TicketMessage{TicketID: ticket.ID, UserID: userID, Body: body, Staff: staff}
+	now := time.Now()
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&msg).Error; err != nil {
+			return err
+		}
+		return tx.Model(ticket).Omit("Messages").Updates(map[string]interface{}{"status": status, "last_message_at": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	ticket.Status, ticket.LastMessageAt = status, now
+	d.notify(ctx, ticket, &msg, false)
+	return &msg, nil
+}
+
+// SetStatus changes the status of a ticket
+func (d *Desk) SetStatus(ctx context.Context, ticket *models.Ticket, status models.TicketStatus) error {
+	if err := d.DB.WithContext(ctx).Model(ticket).Omit("Messages").Update("status", status).Error; err != nil {
+		return err
+	}
+	ticket.Status = status
+	return nil
+}
+
+// notify emails the other side of a ticket about a message in the background:
+// the user who opened it of replies of staff, support of new tickets and the
+// replies of users
+func (d *Desk) notify(ctx context.Context, ticket *models.Ticket, msg *models.TicketMessage, opened bool) {
+	if d.Mailer == nil || (!msg.Staff && d.Email == "") {
+		return
+	}
+	t, m := *ticket, *msg
+	go func(ctx context.Context) {
+		if err := d.send(ctx, &t, &m, opened); err != nil {
+			slog.ErrorContext(ctx, "support: failed to email ticket message", "ticket_id", t.ID, "message_id", m.ID, "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+// send emails a message of a ticket, the first one when opened
+func (d *Desk) send(ctx context.Context, ticket *models.Ticket, msg *models.TicketMessage, opened bool) error {
+	db := d.DB.WithContext(ctx)
+	var org models.Organization
+	if err := db.Select("id", "name", "email_footer").First(&org, ticket.OrganizationID).Error; err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s%s/%d", d.AppURL, TicketPath, ticket.ID)
+
+	if msg.Staff {
+		var user models.User
+		if err := db.Select("id", "email").First(&user, ticket.UserID).Error; err != nil {
+			return err
+		}
+		text := fmt.Sprintf("Support replied to your ticket \"%s\":\n\n%s\n\nReply at:\n\n%s\n", ticket.Subject, msg.Body, link)
+		if footer := strings.TrimSpace(org.Settings.EmailFooter); footer != "" {
+			text += "\n--\n" + footer + "\n"
+		}
+		return d.Mailer.Send(ctx, mailer.Message{To: []string{user.Email}, Subject: "Re: " + ticket.Subject, Text: text})
+	}
+
+	var author models.User
+	if err := db.Select("id", "email").First(&author, msg.UserID).Error; err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("[#%d] %s", ticket.ID, ticket.Subject)
+	if !opened {
+		subject = "Re: " + subject
+	}
+	text := fmt.Sprintf("%s of %s wrote:\n\n%s\n\nAnswer at:\n\n%s\n", author.Email, org.Name, msg.Body, link)
+	return d.Mailer.Send(ctx, mailer.Message{To: []string{d.Email}, Subject: subject, Text: text})
+}
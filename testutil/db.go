@@ -0,0 +1,75 @@
+// Package testutil/db.go
+package testutil
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/4cecoder/saas/database"
+	"github.com/4cecoder/saas/migrations"
+)
+
+// DSNEnv names the environment variable holding the connection string of the test database
+const DSNEnv = "TEST_DATABASE_DSN"
+
+var (
+	openOnce sync.Once
+	sharedDB *gorm.DB
+	openErr  error
+)
+
+// OpenDB returns the Postgres test database named by TEST_DATABASE_DSN, or an
+// in-memory SQLite database when the variable isn't set, migrated to the current
+// schema. The connection is shared by all tests of the package.
+func OpenDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	openOnce.Do(func() {
+		if dsn := os.Getenv(DSNEnv); dsn != "" {
+			sharedDB, openErr = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		} else {
+			sharedDB, openErr = database.Open(database.Config{Driver: database.SQLite, Name: database.Memory})
+		}
+		if openErr == nil {
+			sharedDB.Logger = logger.Discard
+			openErr = Migrate(sharedDB)
+		}
+	})
+	if openErr != nil {
+		t.Fatalf("open test database: %v", openErr)
+	}
+	return sharedDB
+}
+
+// Migrate applies the schema migrations to a test database
+func Migrate(db *gorm.DB) error {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up(context.Background())
+	return err
+}
+
+// TxDB begins a transaction that is rolled back when the test finishes, so
+// tests writing through it leave the database as they found it
+func TxDB(t testing.TB, db *gorm.DB) *gorm.DB {
+	t.Helper()
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("begin test transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// DB returns a transaction on the test database that is rolled back when the test finishes
+func DB(t testing.TB) *gorm.DB {
+	t.Helper()
+	return TxDB(t, OpenDB(t))
+}
@@ -0,0 +1,136 @@
+// Package factories/factories.go
+package factories
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// Password is the plain text password of users built by User
+const Password = "password123"
+
+// seq numbers the built records so unique fields don't collide
+var seq atomic.Uint64
+
+// next returns the next sequence number
+func next() uint64 {
+	return seq.Add(1)
+}
+
+// User returns a verified user with a unique email, the password Password, and
+// the overrides applied
+func User(overrides ...func(*models.User)) models.User {
+	n := next()
+	user := models.User{
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		Password: Password,
+		Name:     fmt.Sprintf("User %d", n),
+		Verified: true,
+		Locale:   "en",
+		Timezone: "UTC",
+		Language: "en",
+	}
+	apply(&user, overrides)
+	return user
+}
+
+// Organization returns an organization with a unique name and the overrides applied
+func Organization(overrides ...func(*models.Organization)) models.Organization {
+	org := models.Organization{
+		Name: fmt.Sprintf("Organization %d", next()),
+	}
+	apply(&org, overrides)
+	return org
+}
+
+// Subscription returns an active monthly subscription of the organization with the overrides applied
+func Subscription(orgID uint, overrides ...func(*models.Subscription)) models.Subscription {
+	now := time.Now()
+	sub := models.Subscription{
+		OrganizationID:  orgID,
+		Status:          models.SubscriptionStatusActive,
+		StartDate:       now,
+		PaymentMethod:   "card",
+		LastPaymentDate: now,
+		NextBillingDate: now.AddDate(0, 1, 0),
+	}
+	apply(&sub, overrides)
+	return sub
+}
+
+// Seat returns an active seat of the user in the organization with the overrides applied
+func Seat(orgID, userID uint, overrides ...func(*models.Seat)) models.Seat {
+	seat := models.Seat{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Status:         models.SeatStatusActive,
+	}
+	apply(&seat, overrides)
+	return seat
+}
+
+// Role returns a role with the given name and permissions
+func Role(name string, permissions ...string) models.Role {
+	role := models.Role{Name: name}
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, models.Permission{Name: permission})
+	}
+	return role
+}
+
+// apply runs the overrides on a record
+func apply[T any](record *T, overrides []func(*T)) {
+	for _, override := range overrides {
+		override(record)
+	}
+}
+
+// CreateUser inserts a user built by User, failing the test on errors
+func CreateUser(t testing.TB, db *gorm.DB, overrides ...func(*models.User)) *models.User {
+	user := User(overrides...)
+	return create(t, db, &user)
+}
+
+// CreateOrganization inserts an organization built by Organization, failing the test on errors
+func CreateOrganization(t testing.TB, db *gorm.DB, overrides ...func(*models.Organization)) *models.Organization {
+	org := Organization(overrides...)
+	return create(t, db, &org)
+}
+
+// CreateSubscription inserts a subscription built by Subscription, failing the test on errors
+func CreateSubscription(t testing.TB, db *gorm.DB, orgID uint, overrides ...func(*models.Subscription)) *models.Subscription {
+	sub := Subscription(orgID, overrides...)
+	return create(t, db, &sub)
+}
+
+// CreateSeat inserts a seat built by Seat, failing the test on errors
+func CreateSeat(t testing.TB, db *gorm.DB, orgID, userID uint, overrides ...func(*models.Seat)) *models.Seat {
+	seat := Seat(orgID, userID, overrides...)
+	return create(t, db, &seat)
+}
+
+// CreateMember inserts a user who belongs to the organization with an active seat
+func CreateMember(t testing.TB, db *gorm.DB, org *models.Organization, overrides ...func(*models.User)) *models.User {
+	t.Helper()
+	user := CreateUser(t, db, overrides...)
+	if err := db.Model(org).Association("Users").Append(user); err != nil {
+		t.Fatalf("add %s to organization %d: %v", user.Email, org.ID, err)
+	}
+	CreateSeat(t, db, org.ID, user.ID)
+	return user
+}
+
+// create inserts a record, failing the test on errors
+func create[T any](t testing.TB, db *gorm.DB, record *T) *T {
+	t.Helper()
+	if err := db.Create(record).Error; err != nil {
+		t.Fatalf("create %T: %v", record, err)
+	}
+	return record
+}
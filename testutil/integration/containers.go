@@ -0,0 +1,149 @@
+// Package integration/containers.go
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/4cecoder/saas/testutil"
+)
+
+const (
+	// PostgresImage is the image started when no test database is configured
+	PostgresImage = "postgres:16-alpine"
+	// RedisImage is the image of the cache started along with Postgres
+	RedisImage = "redis:7-alpine"
+	// startTimeout bounds how long a container may take to accept connections
+	startTimeout = 2 * time.Minute
+)
+
+var (
+	dockerOnce sync.Once
+	dockerOK   bool
+
+	// schemaSeq numbers the schemas created by this test binary
+	schemaSeq atomic.Uint64
+)
+
+// dockerAvailable reports whether testcontainers can start containers
+func dockerAvailable() bool {
+	dockerOnce.Do(func() {
+		// The provider panics rather than failing when no Docker host is found
+		defer func() { recover() }()
+		provider, err := testcontainers.ProviderDocker.GetProvider()
+		if err != nil {
+			return
+		}
+		defer provider.Close()
+		dockerOK = provider.Health(context.Background()) == nil
+	})
+	return dockerOK
+}
+
+// usePostgres reports whether tests can run against Postgres, either the server
+// named by TEST_DATABASE_DSN or containers started with testcontainers
+func usePostgres() bool {
+	return os.Getenv(testutil.DSNEnv) != "" || dockerAvailable()
+}
+
+// openPostgres returns a connection to an empty database for one test: a schema
+// of the TEST_DATABASE_DSN server, or else a container of its own, terminated
+// when the test finishes
+func openPostgres(t testing.TB) *gorm.DB {
+	t.Helper()
+	if dsn := os.Getenv(testutil.DSNEnv); dsn != "" {
+		return openSchema(t, dsn)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+	ctr, err := tcpostgres.Run(ctx, PostgresImage,
+		tcpostgres.WithPassword("postgres"),
+		tcpostgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, ctr)
+	if err != nil {
+		t.Fatalf("start postgres: %v", err)
+	}
+	dsn, err := ctr.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("postgres address: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// redisURL returns the URL of a Redis container of its own for one test,
+// terminated when the test finishes, or "" without Docker, leaving the
+// application to cache in process
+func redisURL(t testing.TB) string {
+	t.Helper()
+	if !dockerAvailable() {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+	ctr, err := tcredis.Run(ctx, RedisImage)
+	testcontainers.CleanupContainer(t, ctr)
+	if err != nil {
+		t.Fatalf("start redis: %v", err)
+	}
+	url, err := ctr.ConnectionString(ctx)
+	if err != nil {
+		t.Fatalf("redis address: %v", err)
+	}
+	return url
+}
+
+// openSchema creates an empty schema for one test and returns a connection whose
+// search path points at it, followed by public for extensions. The schema is
+// dropped when the test finishes, so tests can run in parallel against one server.
+func openSchema(t testing.TB, dsn string) *gorm.DB {
+	t.Helper()
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to postgres: %v", err)
+	}
+	adminDB, _ := admin.DB()
+
+	schema := fmt.Sprintf("test_%d_%d", os.Getpid(), schemaSeq.Add(1))
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		adminDB.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema+",public"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		adminDB.Close()
+	})
+	return db
+}
@@ -0,0 +1,154 @@
+// Package integration/integration.go
+package integration
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/app"
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/client"
+	"github.com/4cecoder/saas/config"
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/testutil"
+	"github.com/4cecoder/saas/testutil/factories"
+)
+
+// Harness runs the whole application against its own Postgres database and
+// Redis cache, started with testcontainers, and serves it over HTTP for one
+// test. Without Docker it uses a schema of TEST_DATABASE_DSN or SQLite, and
+// caches in process.
+type Harness struct {
+	App    *app.App
+	DB     *gorm.DB
+	Server *httptest.Server
+	// Outbox collects the emails the application sends
+	Outbox *Outbox
+}
+
+// New migrates a fresh database, assembles the application on it with mail going
+// to the outbox, and starts it. Everything is torn down when the test finishes.
+// Harnesses of parallel tests are independent, except that audit listeners are
+// registered process-wide.
+func New(t testing.TB, providers ...app.Providers) *Harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var db *gorm.DB
+	if usePostgres() {
+		db = openPostgres(t)
+	} else {
+		db = openSQLite(t)
+	}
+	if err := testutil.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	outbox := &Outbox{}
+	var p app.Providers
+	if len(providers) > 0 {
+		p = providers[0]
+	}
+	if p.Mailer == nil {
+		p.Mailer = func(*config.Config) mailer.Mailer { return outbox }
+	}
+
+	cfg := &config.Config{DB: db, ReportDB: db, StorageDir: t.TempDir()}
+	cfg.Cache.URL = redisURL(t)
+	a, err := app.New(cfg, p)
+	if err != nil {
+		t.Fatalf("assemble application: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := a.Setup(ctx); err != nil {
+		cancel()
+		t.Fatalf("set up application: %v", err)
+	}
+	a.Start(ctx)
+
+	server := httptest.NewServer(a.Router)
+	t.Cleanup(func() {
+		server.Close()
+		cancel()
+	})
+
+	return &Harness{App: a, DB: db, Server: server, Outbox: outbox}
+}
+
+// Client returns an API client for /v1 that is not signed in
+func (h *Harness) Client() *client.Client {
+	return client.New(client.Config{
+		BaseURL:    h.Server.URL + "/v1",
+		HTTPClient: h.Server.Client(),
+		MaxRetries: -1,
+	})
+}
+
+// As returns an API client signed in as the user
+func (h *Harness) As(t testing.TB, user *models.User) *client.Client {
+	t.Helper()
+	token, err := auth.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	c := h.Client()
+	c.SetToken(token)
+	return c
+}
+
+// Admin creates an administrator and returns an API client signed in as them
+func (h *Harness) Admin(t testing.TB) (*models.User, *client.Client) {
+	t.Helper()
+	user := factories.CreateUser(t, h.DB, func(u *models.User) {
+		u.Roles = []models.Role{factories.Role(models.AdminRole)}
+	})
+	token, err := auth.GenerateAdminToken(user)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	c := h.Client()
+	c.SetToken(token)
+	return user, c
+}
+
+// Outbox is a mailer that keeps the messages it is given
+type Outbox struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+// Send stores the message
+func (o *Outbox) Send(ctx context.Context, msg mailer.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far
+func (o *Outbox) Messages() []mailer.Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]mailer.Message(nil), o.messages...)
+}
+
+// To returns the messages sent to an address
+func (o *Outbox) To(address string) []mailer.Message {
+	var found []mailer.Message
+	for _, msg := range o.Messages() {
+		for _, to := range msg.To {
+			if to == address {
+				found = append(found, msg)
+				break
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,29 @@
+// Package integration/sqlite.go
+package integration
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/4cecoder/saas/database"
+)
+
+// openSQLite creates an empty SQLite database in the test's temporary directory,
+// for running without Postgres. The database is closed when the test finishes.
+func openSQLite(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(database.Config{Driver: database.SQLite, Name: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	db.Logger = logger.Discard
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
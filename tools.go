@@ -0,0 +1,8 @@
+//go:build tools
+
+// Package tools.go pins the code generators run by go generate
+package main
+
+import (
+	_ "github.com/99designs/gqlgen"
+)
@@ -0,0 +1,15 @@
+// Package tracing/http.go
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// WrapClient makes c record a client span for each request, sent through its
+// transport or http.DefaultTransport, and pass the trace on to the server
+func WrapClient(c *http.Client) *http.Client {
+	c.Transport = otelhttp.NewTransport(c.Transport)
+	return c
+}
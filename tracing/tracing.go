@@ -0,0 +1,104 @@
+// Package tracing/tracing.go
+//
+// Package tracing sets up the OpenTelemetry SDK, which records spans of
+// requests, queries, background jobs and outbound calls and exports them to a
+// collector over OTLP/HTTP. Trace context crosses services in the W3C
+// traceparent header.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config selects where spans are exported; tracing is off without an endpoint
+type Config struct {
+	// Endpoint is the base URL of an OTLP/HTTP collector, such as http://localhost:4318
+	Endpoint string
+	// Headers are sent with every export, such as an API key of a hosted collector
+	Headers map[string]string
+	// ServiceName names this application in traces; it defaults to saas
+	ServiceName string
+	// SampleRatio is the share of new traces recorded, from 0 to 1; traces
+	// started by a caller follow the caller's decision
+	SampleRatio float64
+}
+
+// Service returns the name of this application in traces
+func (c Config) Service() string {
+	if c.ServiceName == "" {
+		return "saas"
+	}
+	return c.ServiceName
+}
+
+// ScopeName is the instrumentation scope of the spans the application starts itself
+const ScopeName = "github.com/4cecoder/saas"
+
+// provider is the tracer provider Setup installed, nil while tracing is off
+var provider atomic.Pointer[sdktrace.TracerProvider]
+
+// Setup makes the W3C trace context the global propagator and, when cfg has an
+// endpoint, installs a tracer provider exporting to it in batches. While
+// tracing is off spans aren't recorded, but a caller's trace is still passed
+// on to outbound calls.
+func Setup(cfg Config) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	if _, err := url.ParseRequestURI(cfg.Endpoint); err != nil {
+		return fmt.Errorf("invalid OTLP endpoint %q: %w", cfg.Endpoint, err)
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return fmt.Errorf("invalid sample ratio %v, expected a number from 0 to 1", cfg.SampleRatio)
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(strings.TrimSuffix(cfg.Endpoint, "/")+"/v1/traces"),
+		otlptracehttp.WithHeaders(cfg.Headers))
+	if err != nil {
+		return fmt.Errorf("create OTLP exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(cfg.Service())))
+	if err != nil {
+		return fmt.Errorf("describe service: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))))
+	otel.SetTracerProvider(tp)
+	provider.Store(tp)
+	return nil
+}
+
+// Tracer returns the tracer of the spans the application starts itself
+func Tracer() trace.Tracer {
+	return otel.Tracer(ScopeName)
+}
+
+// Shutdown exports the spans finished so far and stops the tracer provider
+func Shutdown(ctx context.Context) error {
+	if tp := provider.Swap(nil); tp != nil {
+		if err := tp.Shutdown(ctx); err != nil {
+			return fmt.Errorf("shut down tracing: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,471 @@
+// Package workflow/actions.go
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/4cecoder/saas/auth"
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/requestid"
+)
+
+// maxResponseBody caps how much of a webhook response is kept as step output,
+// which run timelines show
+const maxResponseBody = 1 << 10
+
+// placeholder matches template references such as {{input.email}} in step configs
+var placeholder = regexp.MustCompile(`{{\s*([A-Za-z0-9_.]+)\s*}}`)
+
+// EmailConfig is the config of an email step
+type EmailConfig struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	HTML    string   `json:"html"`
+}
+
+// WebhookConfig is the config of a webhook step. Without a body the run data is sent.
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    interface{}       `json:"body"`
+}
+
+// WaitConfig is the config of a wait step: a fixed Duration such as "24h",
+// or an Until template resolving to an RFC 3339 time
+type WaitConfig struct {
+	Duration string `json:"duration"`
+	Until    string `json:"until"`
+}
+
+// ApprovalConfig is the config of an approval step. The step's Approver is used
+// when the config doesn't name one.
+type ApprovalConfig struct {
+	Approver string `json:"approver"`
+	Message  string `json:"message"`
+}
+
+// UpdateRecordConfig is the config of a step that updates a record of the run's organization
+type UpdateRecordConfig struct {
+	Resource string                 `json:"resource"`
+	ID       string                 `json:"id"`
+	Fields   map[string]interface{} `json:"fields"`
+}
+
+// updatable lists the resources update_record steps may change and their writable columns
+var updatable = map[string]struct {
+	model  func() interface{}
+	fields map[string]bool
+}{
+	"subscriptions": {
+		model:  func() interface{} { return &models.Subscription{} },
+		fields: map[string]bool{"status": true, "payment_method": true, "end_date": true, "next_billing_date": true},
+	},
+	"domains": {
+		model:  func() interface{} { return &models.Domain{} },
+		fields: map[string]bool{"verified": true},
+	},
+	"organizations": {
+		model:  func() interface{} { return &models.Organization{} },
+		fields: map[string]bool{"name": true},
+	},
+}
+
+// RegisterActions registers the built-in step action types, sending email through
+// mail and calling webhooks with client
+func (e *Engine) RegisterActions(mail mailer.Mailer, client *http.Client) {
+	e.Mailer = mail
+
+	e.RegisterAction(models.WorkflowActionEmail, Action{
+		Validate: validateEmail,
+		Execute: func(ctx context.Context, run *models.WorkflowRun, stepRun *models.WorkflowStepRun, step models.WorkflowStep) (StepResult, error) {
+			return executeEmail(ctx, mail, run, step)
+		},
+	})
+	e.RegisterAction(models.WorkflowActionWebhook, Action{
+		Validate: validateWebhook,
+		Execute: func(ctx context.Context, run *models.WorkflowRun, stepRun *models.WorkflowStepRun, step models.WorkflowStep) (StepResult, error) {
+			return executeWebhook(ctx, client, run, step)
+		},
+	})
+	e.RegisterAction(models.WorkflowActionWait, Action{
+		Validate: validateWait,
+		Execute:  executeWait,
+	})
+	e.RegisterAction(models.WorkflowActionApproval, Action{
+		Validate: validateApproval,
+		Execute:  e.executeApproval,
+	})
+	e.RegisterAction(models.WorkflowActionUpdateRecord, Action{
+		Validate: validateUpdateRecord,
+		Execute:  e.executeUpdateRecord,
+	})
+}
+
+// decodeConfig decodes a step config into its typed schema, rejecting unknown keys
+func decodeConfig(config models.JSONMap, dst interface{}) error {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// render replaces {{path}} placeholders with values from the run data
+func render(tmpl string, data map[string]interface{}) string {
+	return placeholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		path := placeholder.FindStringSubmatch(m)[1]
+		return format(resolve(data, path))
+	})
+}
+
+// renderValue renders placeholders in every string of a decoded JSON value
+func renderValue(v interface{}, data map[string]interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return render(t, data)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			out[k] = renderValue(v, data)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, v := range t {
+			out[i] = renderValue(v, data)
+		}
+		return out
+	}
+	return v
+}
+
+// format prints a resolved value, keeping whole numbers free of exponents
+func format(v interface{}) string {
+	switch n := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// validateEmail checks an email step's config
+func validateEmail(step models.WorkflowStep) error {
+	var cfg EmailConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return err
+	}
+	if len(cfg.To) == 0 {
+		return errors.New("email step needs at least one recipient in to")
+	}
+	if cfg.Subject == "" {
+		return errors.New("email step needs a subject")
+	}
+	if cfg.Text == "" && cfg.HTML == "" {
+		return errors.New("email step needs a text or html body")
+	}
+	return nil
+}
+
+// executeEmail sends the rendered message to the rendered recipients
+func executeEmail(ctx context.Context, mail mailer.Mailer, run *models.WorkflowRun, step models.WorkflowStep) (StepResult, error) {
+	var cfg EmailConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return StepResult{}, err
+	}
+
+	data := runData(run)
+	var to []string
+	for _, addr := range cfg.To {
+		if addr = strings.TrimSpace(render(addr, data)); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if len(to) == 0 {
+		return StepResult{}, errors.New("no recipients after rendering")
+	}
+
+	msg := mailer.Message{
+		To:      to,
+		Subject: render(cfg.Subject, data),
+		Text:    render(cfg.Text, data),
+		HTML:    render(cfg.HTML, data),
+	}
+	if err := mail.Send(ctx, msg); err != nil {
+		return StepResult{}, err
+	}
+
+	return StepResult{Output: models.JSONMap{"to": to, "sent_at": time.Now()}}, nil
+}
+
+// validateWebhook checks a webhook step's config
+func validateWebhook(step models.WorkflowStep) error {
+	var cfg WebhookConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(cfg.URL, "https://") && !strings.HasPrefix(cfg.URL, "http://") {
+		return errors.New("webhook step needs an http or https url")
+	}
+	switch strings.ToUpper(cfg.Method) {
+	case "", http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return fmt.Errorf("unsupported webhook method %q", cfg.Method)
+	}
+	return nil
+}
+
+// executeWebhook calls the configured URL and fails the step on a non-2xx
+// response. Its output is the status and the start of the response body.
+func executeWebhook(ctx context.Context, client *http.Client, run *models.WorkflowRun, step models.WorkflowStep) (StepResult, error) {
+	var cfg WebhookConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return StepResult{}, err
+	}
+
+	data := runData(run)
+	method := strings.ToUpper(cfg.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	var body io.Reader
+	if method != http.MethodGet {
+		payload := interface{}(data)
+		if cfg.Body != nil {
+			payload = renderValue(cfg.Body, data)
+		}
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return StepResult{}, err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, render(cfg.URL, data), body)
+	if err != nil {
+		return StepResult{}, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	requestid.Propagate(req)
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, render(v, data))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return StepResult{}, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody+1))
+	if err != nil {
+		return StepResult{}, err
+	}
+	truncated := len(raw) > maxResponseBody
+	if truncated {
+		raw = raw[:maxResponseBody]
+	}
+
+	output := models.JSONMap{
+		"status_code":    resp.StatusCode,
+		"body":           strings.ToValidUTF8(string(raw), ""),
+		"body_truncated": truncated,
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return StepResult{}, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return StepResult{Output: output}, nil
+}
+
+// validateWait checks a wait step's config
+func validateWait(step models.WorkflowStep) error {
+	var cfg WaitConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return err
+	}
+	if (cfg.Duration == "") == (cfg.Until == "") {
+		return errors.New("wait step needs exactly one of duration or until")
+	}
+	if cfg.Duration != "" {
+		d, err := time.ParseDuration(cfg.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %w", err)
+		}
+		if d <= 0 {
+			return errors.New("duration must be positive")
+		}
+	}
+	return nil
+}
+
+// executeWait leaves the step waiting until the delay has elapsed
+func executeWait(ctx context.Context, run *models.WorkflowRun, _ *models.WorkflowStepRun, step models.WorkflowStep) (StepResult, error) {
+	var cfg WaitConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return StepResult{}, err
+	}
+
+	var resumeAt time.Time
+	if cfg.Duration != "" {
+		d, err := time.ParseDuration(cfg.Duration)
+		if err != nil {
+			return StepResult{}, err
+		}
+		resumeAt = time.Now().Add(d)
+	} else {
+		t, err := time.Parse(time.RFC3339, render(cfg.Until, runData(run)))
+		if err != nil {
+			return StepResult{}, fmt.Errorf("invalid until time: %w", err)
+		}
+		resumeAt = t
+	}
+
+	output := models.JSONMap{"resume_at": resumeAt}
+	if !resumeAt.After(time.Now()) {
+		return StepResult{Output: output}, nil
+	}
+	return StepResult{Output: output, Wait: true, ResumeAt: &resumeAt}, nil
+}
+
+// validateApproval checks an approval step's config
+func validateApproval(step models.WorkflowStep) error {
+	var cfg ApprovalConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return err
+	}
+
+	approver := cfg.Approver
+	if approver == "" {
+		approver = step.Approver
+	}
+	if approver == "" {
+		return errors.New("approval step needs an approver")
+	}
+	return ValidateApprover(approver)
+}
+
+// executeApproval requests approval from every user the approver resolves to and
+// leaves the step waiting until one of them approves or rejects it
+func (e *Engine) executeApproval(ctx context.Context, run *models.WorkflowRun, stepRun *models.WorkflowStepRun, step models.WorkflowStep) (StepResult, error) {
+	var cfg ApprovalConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return StepResult{}, err
+	}
+
+	data := runData(run)
+	approver := cfg.Approver
+	if approver == "" {
+		approver = step.Approver
+	}
+	approver = render(approver, data)
+	message := render(cfg.Message, data)
+
+	approvers, err := e.RequestApproval(ctx, run, stepRun, approver, message)
+	if err != nil {
+		return StepResult{}, err
+	}
+
+	ids := make([]uint, len(approvers))
+	for i, u := range approvers {
+		ids[i] = u.ID
+	}
+
+	return StepResult{
+		Wait: true,
+		Output: models.JSONMap{
+			"approver":     approver,
+			"approver_ids": ids,
+			"message":      message,
+			"requested_at": time.Now(),
+		},
+	}, nil
+}
+
+// validateUpdateRecord checks an update_record step's config
+func validateUpdateRecord(step models.WorkflowStep) error {
+	var cfg UpdateRecordConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return err
+	}
+
+	resource, ok := updatable[cfg.Resource]
+	if !ok {
+		return fmt.Errorf("resource %q can't be updated by workflows", cfg.Resource)
+	}
+	if cfg.ID == "" {
+		return errors.New("update_record step needs an id")
+	}
+	if len(cfg.Fields) == 0 {
+		return errors.New("update_record step needs at least one field")
+	}
+	for field := range cfg.Fields {
+		if !resource.fields[field] {
+			return fmt.Errorf("field %q of %s can't be updated by workflows", field, cfg.Resource)
+		}
+	}
+	return nil
+}
+
+// executeUpdateRecord updates a record belonging to the run's organization, on behalf
+// of the user who triggered the run so the change is attributed in the audit log
+func (e *Engine) executeUpdateRecord(ctx context.Context, run *models.WorkflowRun, stepRun *models.WorkflowStepRun, step models.WorkflowStep) (StepResult, error) {
+	if err := validateUpdateRecord(step); err != nil {
+		return StepResult{}, err
+	}
+	var cfg UpdateRecordConfig
+	if err := decodeConfig(step.Config, &cfg); err != nil {
+		return StepResult{}, err
+	}
+
+	data := runData(run)
+	id, err := strconv.ParseUint(render(cfg.ID, data), 10, 64)
+	if err != nil {
+		return StepResult{}, fmt.Errorf("invalid record id: %w", err)
+	}
+
+	if run.TriggeredBy != 0 {
+		ctx = auth.WithUserID(ctx, run.TriggeredBy)
+	}
+	db := e.DB.WithContext(ctx)
+
+	record := updatable[cfg.Resource].model()
+	query := db.Where("id = ?", id)
+	if cfg.Resource == "organizations" {
+		query = query.Where("id = ?", run.OrganizationID)
+	} else {
+		query = query.Where("organization_id = ?", run.OrganizationID)
+	}
+	if err := query.First(record).Error; err != nil {
+		return StepResult{}, fmt.Errorf("load %s %d: %w", cfg.Resource, id, err)
+	}
+
+	fields := renderValue(cfg.Fields, data).(map[string]interface{})
+	if err := db.Model(record).Updates(fields).Error; err != nil {
+		return StepResult{}, err
+	}
+
+	return StepResult{Output: models.JSONMap{"resource": cfg.Resource, "id": id, "fields": fields}}, nil
+}
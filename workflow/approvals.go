@@ -0,0 +1,337 @@
+// Package workflow/approvals.go
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+)
+
+// Errors returned when deciding approvals
+var (
+	ErrNotApprover        = errors.New("approval is assigned to another user")
+	ErrApprovalNotPending = errors.New("approval has already been decided")
+	ErrInvalidDelegate    = errors.New("approvals can only be delegated to another member of the organization")
+)
+
+// ValidateApprover checks the form of an approver string: "user:<id or email>",
+// "role:<name>", an email address, or a bare role name
+func ValidateApprover(approver string) error {
+	kind, value, found := strings.Cut(approver, ":")
+	if !found {
+		return nil
+	}
+	if value == "" {
+		return fmt.Errorf("approver %q is missing a value", approver)
+	}
+	switch kind {
+	case "user", "role":
+		return nil
+	}
+	return fmt.Errorf("approver %q must start with user: or role:", approver)
+}
+
+// ResolveApprovers returns the members of the organization an approver string refers to
+func ResolveApprovers(db *gorm.DB, orgID uint, approver string) ([]models.User, error) {
+	if err := ValidateApprover(approver); err != nil {
+		return nil, err
+	}
+
+	query := db.Model(&models.User{})
+	if orgID != 0 {
+		members := db.Table("user_organizations").Select("user_id").Where("organization_id = ?", orgID)
+		query = query.Where("users.id IN (?)", members)
+	}
+
+	kind, value, found := strings.Cut(approver, ":")
+	if !found {
+		kind, value = "role", approver
+		if strings.Contains(approver, "@") {
+			kind = "user"
+		}
+	}
+
+	switch {
+	case kind == "role":
+		holders := db.Table("user_roles").
+			Select("user_roles.user_id").
+			Joins("JOIN roles ON roles.id = user_roles.role_id").
+			Where("roles.name = ? AND roles.deleted_at IS NULL", value)
+		query = query.Where("users.id IN (?)", holders)
+	case strings.Contains(value, "@"):
+		query = query.Where("users.email = ?", value)
+	default:
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("approver %q is not a user ID or email", approver)
+		}
+		query = query.Where("users.id = ?", id)
+	}
+
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("approver %q matches no members of the organization", approver)
+	}
+	return users, nil
+}
+
+// RequestApproval creates a pending approval for every user the approver resolves to,
+// replacing any left over from an earlier attempt, and notifies them
+func (e *Engine) RequestApproval(ctx context.Context, run *models.WorkflowRun, stepRun *models.WorkflowStepRun, approver, message string) ([]models.User, error) {
+	db := e.DB.WithContext(ctx)
+
+	users, err := ResolveApprovers(db, run.OrganizationID, approver)
+	if err != nil {
+		return nil, err
+	}
+
+	approvals := make([]models.WorkflowApproval, len(users))
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WorkflowApproval{}).
+			Where("step_run_id = ? AND status = ?", stepRun.ID, models.WorkflowApprovalStatusPending).
+			Update("status", models.WorkflowApprovalStatusCanceled).Error
+		if err != nil {
+			return err
+		}
+
+		for i, u := range users {
+			approvals[i] = models.WorkflowApproval{
+				RunID:          run.ID,
+				StepRunID:      stepRun.ID,
+				Position:       stepRun.Position,
+				OrganizationID: run.OrganizationID,
+				ApproverID:     u.ID,
+				Status:         models.WorkflowApprovalStatusPending,
+				Message:        message,
+			}
+			if err := tx.Create(&approvals[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range approvals {
+		e.notifyApprover(ctx, &approvals[i], &users[i], false)
+	}
+	return users, nil
+}
+
+// Approve approves a pending approval, completing the step for all of its approvers
+func (e *Engine) Approve(ctx context.Context, approvalID, userID uint, comment string) error {
+	approval, err := e.decide(ctx, approvalID, userID, models.WorkflowApprovalStatusApproved, comment)
+	if err != nil {
+		return err
+	}
+
+	output, err := e.decisionOutput(ctx, approval)
+	if err != nil {
+		return err
+	}
+	return e.CompleteStep(ctx, approval.RunID, approval.Position, output)
+}
+
+// Reject rejects a pending approval, which fails the step and its run
+func (e *Engine) Reject(ctx context.Context, approvalID, userID uint, comment string) error {
+	approval, err := e.decide(ctx, approvalID, userID, models.WorkflowApprovalStatusRejected, comment)
+	if err != nil {
+		return err
+	}
+
+	output, err := e.decisionOutput(ctx, approval)
+	if err != nil {
+		return err
+	}
+
+	reason := fmt.Sprintf("rejected by user %d", userID)
+	if comment != "" {
+		reason += ": " + comment
+	}
+	return e.FailStep(ctx, approval.RunID, approval.Position, output, reason)
+}
+
+// Delegate hands a pending approval over to another member of the organization
+func (e *Engine) Delegate(ctx context.Context, approvalID, userID, delegateID uint, comment string) (*models.WorkflowApproval, error) {
+	if delegateID == userID {
+		return nil, ErrInvalidDelegate
+	}
+
+	db := e.DB.WithContext(ctx)
+
+	var approval models.WorkflowApproval
+	if err := db.First(&approval, approvalID).Error; err != nil {
+		return nil, err
+	}
+
+	users, err := ResolveApprovers(db, approval.OrganizationID, fmt.Sprintf("user:%d", delegateID))
+	if err != nil {
+		return nil, ErrInvalidDelegate
+	}
+	delegate := users[0]
+
+	next := models.WorkflowApproval{
+		RunID:           approval.RunID,
+		StepRunID:       approval.StepRunID,
+		Position:        approval.Position,
+		OrganizationID:  approval.OrganizationID,
+		ApproverID:      delegate.ID,
+		DelegatedFromID: &userID,
+		Status:          models.WorkflowApprovalStatusPending,
+		Message:         approval.Message,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if _, err := claimApproval(tx, approvalID, userID, models.WorkflowApprovalStatusDelegated, comment); err != nil {
+			return err
+		}
+		return tx.Create(&next).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.notifyApprover(ctx, &next, &delegate, false)
+	return &next, nil
+}
+
+// RemindApprovals re-notifies approvers of requests left pending for longer than the interval
+func (e *Engine) RemindApprovals(ctx context.Context, interval time.Duration) error {
+	db := e.DB.WithContext(ctx)
+	cutoff := time.Now().Add(-interval)
+
+	var approvals []models.WorkflowApproval
+	err := db.Where("status = ? AND COALESCE(reminded_at, created_at) < ?", models.WorkflowApprovalStatusPending, cutoff).
+		Find(&approvals).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range approvals {
+		a := &approvals[i]
+
+		var user models.User
+		if err := db.First(&user, a.ApproverID).Error; err != nil {
+			slog.Error("workflow: failed to load approver", "approver_id", a.ApproverID, "approval_id", a.ID, "error", err)
+			continue
+		}
+
+		e.notifyApprover(ctx, a, &user, true)
+		if err := db.Model(a).Update("reminded_at", time.Now()).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decide records the user's decision on a pending approval and cancels the step's
+// other pending approvals, since one decision settles the step
+func (e *Engine) decide(ctx context.Context, approvalID, userID uint, status models.WorkflowApprovalStatus, comment string) (*models.WorkflowApproval, error) {
+	var approval *models.WorkflowApproval
+	err := e.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		approval, err = claimApproval(tx, approvalID, userID, status, comment)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.WorkflowApproval{}).
+			Where("step_run_id = ? AND status = ?", approval.StepRunID, models.WorkflowApprovalStatusPending).
+			Update("status", models.WorkflowApprovalStatusCanceled).Error
+	})
+	return approval, err
+}
+
+// claimApproval moves a pending approval assigned to the user to its decided status
+func claimApproval(tx *gorm.DB, approvalID, userID uint, status models.WorkflowApprovalStatus, comment string) (*models.WorkflowApproval, error) {
+	var approval models.WorkflowApproval
+	if err := tx.First(&approval, approvalID).Error; err != nil {
+		return nil, err
+	}
+	if approval.ApproverID != userID {
+		return nil, ErrNotApprover
+	}
+	if approval.Status != models.WorkflowApprovalStatusPending {
+		return nil, ErrApprovalNotPending
+	}
+
+	now := time.Now()
+	res := tx.Model(&approval).
+		Where("status = ?", models.WorkflowApprovalStatusPending).
+		Updates(map[string]interface{}{"status": status, "comment": comment, "decided_at": now})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrApprovalNotPending
+	}
+
+	approval.Status = status
+	approval.Comment = comment
+	approval.DecidedAt = &now
+	return &approval, nil
+}
+
+// decisionOutput merges the decision into the approval step's existing output
+func (e *Engine) decisionOutput(ctx context.Context, approval *models.WorkflowApproval) (models.JSONMap, error) {
+	var stepRun models.WorkflowStepRun
+	if err := e.DB.WithContext(ctx).First(&stepRun, approval.StepRunID).Error; err != nil {
+		return nil, err
+	}
+
+	output := models.JSONMap{}
+	for k, v := range stepRun.Output {
+		output[k] = v
+	}
+	output["decision"] = approval.Status
+	output["decided_by"] = approval.ApproverID
+	output["comment"] = approval.Comment
+	output["decided_at"] = approval.DecidedAt
+	return output, nil
+}
+
+// notifyApprover emails an approver about a new or still pending approval request
+func (e *Engine) notifyApprover(ctx context.Context, approval *models.WorkflowApproval, user *models.User, reminder bool) {
+	if e.Mailer == nil || user.Email == "" {
+		return
+	}
+
+	var run models.WorkflowRun
+	var wf models.Workflow
+	db := e.DB.WithContext(ctx)
+	if err := db.First(&run, approval.RunID).Error; err != nil {
+		slog.Error("workflow: failed to load run of approval", "run_id", approval.RunID, "approval_id", approval.ID, "error", err)
+		return
+	}
+	db.Unscoped().First(&wf, run.WorkflowID)
+
+	subject := fmt.Sprintf("Approval requested: %s", wf.Name)
+	if reminder {
+		subject = fmt.Sprintf("Reminder: approval pending for %s", wf.Name)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Your approval is needed for step %d of workflow %q (run %d).\n", approval.Position+1, wf.Name, run.ID)
+	if approval.Message != "" {
+		fmt.Fprintf(&body, "\n%s\n", approval.Message)
+	}
+	fmt.Fprintf(&body, "\nApprove, reject, or delegate approval %d from your pending approvals.\n", approval.ID)
+
+	msg := mailer.Message{To: []string{user.Email}, Subject: subject, Text: body.String()}
+	if err := e.Mailer.Send(ctx, msg); err != nil {
+		slog.Error("workflow: failed to notify approver", "approver_id", user.ID, "approval_id", approval.ID, "error", err)
+	}
+}
@@ -0,0 +1,317 @@
+// Package workflow/conditions.go
+package workflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// EvaluateCondition evaluates a step condition such as
+// `amount > 1000 && (region == "eu" || priority == "high")` against the run data.
+// Identifiers are dotted paths into the data; an empty condition is always true.
+func EvaluateCondition(condition string, data map[string]interface{}) (bool, error) {
+	if strings.TrimSpace(condition) == "" {
+		return true, nil
+	}
+
+	tokens, err := tokenize(condition)
+	if err != nil {
+		return false, err
+	}
+
+	p := &parser{tokens: tokens, data: data}
+	v, err := p.or()
+	if err != nil {
+		return false, err
+	}
+	if p.pos != len(p.tokens) {
+		return false, fmt.Errorf("unexpected %q in condition", p.tokens[p.pos].text)
+	}
+
+	return truthy(v), nil
+}
+
+// ValidateCondition checks that a condition parses, without evaluating it against real data
+func ValidateCondition(condition string) error {
+	_, err := EvaluateCondition(condition, map[string]interface{}{})
+	return err
+}
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits a condition into identifiers, literals, and operators
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		ch := rune(s[i])
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '"' || ch == '\'':
+			end := strings.IndexByte(s[i+1:], s[i])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in condition")
+			}
+			tokens = append(tokens, token{tokString, s[i+1 : i+1+end]})
+			i += end + 2
+		case unicode.IsDigit(ch) || (ch == '-' && i+1 < len(s) && unicode.IsDigit(rune(s[i+1]))):
+			j := i + 1
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, s[i:j]})
+			i = j
+		case unicode.IsLetter(ch) || ch == '_':
+			j := i + 1
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_' || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, s[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range []string{"&&", "||", "==", "!=", ">=", "<=", ">", "<", "!", "(", ")"} {
+				if strings.HasPrefix(s[i:], op) {
+					tokens = append(tokens, token{tokOp, op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q in condition", ch)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// parser is a recursive-descent evaluator over the condition tokens
+type parser struct {
+	tokens []token
+	pos    int
+	data   map[string]interface{}
+}
+
+func (p *parser) peek(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokOp && p.tokens[p.pos].text == op
+}
+
+func (p *parser) or() (interface{}, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) || truthy(right)
+	}
+	return left, nil
+}
+
+func (p *parser) and() (interface{}, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = truthy(left) && truthy(right)
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (interface{}, error) {
+	if p.peek("!") {
+		p.pos++
+		v, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return !truthy(v), nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (interface{}, error) {
+	if p.peek("(") {
+		p.pos++
+		v, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing closing parenthesis in condition")
+		}
+		p.pos++
+		return v, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		if p.peek(op) {
+			p.pos++
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return compare(left, op, right)
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) operand() (interface{}, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch t.kind {
+	case tokNumber:
+		return strconv.ParseFloat(t.text, 64)
+	case tokString:
+		return t.text, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null", "nil":
+			return nil, nil
+		}
+		return lookup(p.data, t.text), nil
+	default:
+		return nil, fmt.Errorf("unexpected %q in condition", t.text)
+	}
+}
+
+// lookup resolves a dotted path in nested maps, returning nil when missing
+func lookup(data map[string]interface{}, path string) interface{} {
+	return normalize(resolve(data, path))
+}
+
+// resolve returns the raw value at a dotted path in nested maps
+func resolve(data map[string]interface{}, path string) interface{} {
+	var cur interface{} = data
+	for _, part := range strings.Split(path, ".") {
+		switch m := cur.(type) {
+		case map[string]interface{}:
+			cur = m[part]
+		case models.JSONMap:
+			cur = m[part]
+		default:
+			return nil
+		}
+	}
+	return cur
+}
+
+// normalize converts numeric types to float64 so they compare consistently
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+// compare applies a comparison operator to two values
+func compare(left interface{}, op string, right interface{}) (bool, error) {
+	switch op {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	}
+
+	if l, ok := left.(float64); ok {
+		r, ok := right.(float64)
+		if !ok {
+			return false, nil
+		}
+		switch op {
+		case ">":
+			return l > r, nil
+		case ">=":
+			return l >= r, nil
+		case "<":
+			return l < r, nil
+		default:
+			return l <= r, nil
+		}
+	}
+
+	if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		if !ok {
+			return false, nil
+		}
+		switch op {
+		case ">":
+			return l > r, nil
+		case ">=":
+			return l >= r, nil
+		case "<":
+			return l < r, nil
+		default:
+			return l <= r, nil
+		}
+	}
+
+	return false, nil
+}
+
+// truthy reports whether a value counts as true in a condition
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case float64:
+		return val != 0
+	case string:
+		return val != ""
+	default:
+		return true
+	}
+}
@@ -0,0 +1,507 @@
+// Package workflow/engine.go
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/mailer"
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/scheduler"
+)
+
+// Errors returned for invalid run transitions
+var (
+	ErrWorkflowDisabled = errors.New("workflow is disabled")
+	ErrNotCancelable    = errors.New("only running or waiting runs can be canceled")
+	ErrNotRetryable     = errors.New("only failed runs can be retried")
+	ErrInvalidRetryStep = errors.New("runs can only be retried from the failed step or an earlier one")
+)
+
+// StepResult is returned by a step executor
+type StepResult struct {
+	// Output is stored on the step run and exposed to later step conditions
+	Output models.JSONMap
+	// Wait leaves the step waiting until it is completed externally
+	Wait bool
+	// ResumeAt completes a waiting step automatically once the time has passed
+	ResumeAt *time.Time
+}
+
+// StepExecutor performs the work of a single workflow step
+type StepExecutor func(ctx context.Context, run *models.WorkflowRun, stepRun *models.WorkflowStepRun, step models.WorkflowStep) (StepResult, error)
+
+// Action is a step action type: the schema check for its config and its executor
+type Action struct {
+	Validate func(step models.WorkflowStep) error
+	Execute  StepExecutor
+}
+
+// Engine instantiates workflow runs and advances them step by step
+type Engine struct {
+	DB     *gorm.DB
+	Mailer mailer.Mailer
+
+	mu      sync.RWMutex
+	actions map[models.WorkflowActionType]Action
+}
+
+// NewEngine creates a new workflow engine that only knows plain steps
+func NewEngine(db *gorm.DB) *Engine {
+	e := &Engine{DB: db, actions: make(map[models.WorkflowActionType]Action)}
+	e.RegisterAction(models.WorkflowActionNone, Action{
+		Execute: func(ctx context.Context, run *models.WorkflowRun, stepRun *models.WorkflowStepRun, step models.WorkflowStep) (StepResult, error) {
+			return StepResult{}, nil
+		},
+	})
+	return e
+}
+
+// RegisterAction associates a step action type with its config validation and executor
+func (e *Engine) RegisterAction(actionType models.WorkflowActionType, action Action) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.actions[actionType] = action
+}
+
+// action returns the registered action for a step's type
+func (e *Engine) action(step models.WorkflowStep) (Action, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	action, ok := e.actions[step.Type]
+	if !ok {
+		return Action{}, fmt.Errorf("step %q: unknown action type %q", step.Name, step.Type)
+	}
+	return action, nil
+}
+
+// ValidateStep checks that a step has a known action type, a valid config, and a parsable condition
+func (e *Engine) ValidateStep(step models.WorkflowStep) error {
+	if err := ValidateCondition(step.Conditions); err != nil {
+		return fmt.Errorf("step %q: %w", step.Name, err)
+	}
+
+	action, err := e.action(step)
+	if err != nil {
+		return err
+	}
+	if action.Validate == nil {
+		return nil
+	}
+	if err := action.Validate(step); err != nil {
+		return fmt.Errorf("step %q: %w", step.Name, err)
+	}
+	return nil
+}
+
+// Register schedules the job that resumes runs left behind by a crashed instance
+// and completes waiting steps whose resume time has passed
+func (e *Engine) Register(s *scheduler.Scheduler) error {
+	s.Register("workflow.resume", func(ctx context.Context, _ *models.ScheduledJob) error {
+		if err := e.ResumeDue(ctx); err != nil {
+			return err
+		}
+		return e.ResumeStalled(ctx, 2*time.Minute)
+	})
+	s.Register("workflow.remind_approvals", func(ctx context.Context, _ *models.ScheduledJob) error {
+		return e.RemindApprovals(ctx, 24*time.Hour)
+	})
+
+	if err := s.Ensure("workflow-resume", "workflow.resume", "* * * * *", nil); err != nil {
+		return err
+	}
+	return s.Ensure("workflow-approval-reminders", "workflow.remind_approvals", "0 * * * *", nil)
+}
+
+// sortedSteps returns the workflow's steps ordered by their Order field
+func sortedSteps(steps []models.WorkflowStep) []models.WorkflowStep {
+	sorted := append([]models.WorkflowStep(nil), steps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Order < sorted[j].Order })
+	return sorted
+}
+
+// Start creates a run for the workflow and begins advancing it in the background
+func (e *Engine) Start(ctx context.Context, wf *models.Workflow, input models.JSONMap, triggeredBy uint) (*models.WorkflowRun, error) {
+	if !wf.Enabled {
+		return nil, ErrWorkflowDisabled
+	}
+
+	steps := sortedSteps(wf.Steps)
+	run := &models.WorkflowRun{
+		WorkflowID:      wf.ID,
+		WorkflowVersion: wf.Version,
+		OrganizationID:  wf.OrganizationID,
+		TriggeredBy:     triggeredBy,
+		Status:          models.WorkflowRunStatusRunning,
+		Input:           input,
+		Definition:      steps,
+		StartedAt:       time.Now(),
+	}
+	for i, step := range steps {
+		run.Steps = append(run.Steps, models.WorkflowStepRun{
+			Position: i,
+			Name:     step.Name,
+			Status:   models.WorkflowStepStatusPending,
+		})
+	}
+
+	if err := e.DB.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, err
+	}
+
+	e.advanceAsync(ctx, run.ID)
+	return run, nil
+}
+
+// advanceAsync advances a run without holding up the caller
+func (e *Engine) advanceAsync(ctx context.Context, runID uint) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := e.Advance(ctx, runID); err != nil {
+			slog.Error("workflow: failed to advance run", "run_id", runID, "error", err)
+		}
+	}()
+}
+
+// Advance executes the run's remaining steps in order until it completes, fails, or waits
+func (e *Engine) Advance(ctx context.Context, runID uint) error {
+	db := e.DB.WithContext(ctx)
+
+	var run models.WorkflowRun
+	err := db.Preload("Steps", func(tx *gorm.DB) *gorm.DB { return tx.Order("position ASC") }).
+		First(&run, runID).Error
+	if err != nil {
+		return err
+	}
+	if run.Status != models.WorkflowRunStatusRunning {
+		return nil
+	}
+
+	for i := run.CurrentStep; i < len(run.Steps); i++ {
+		stepRun := &run.Steps[i]
+		step := run.Definition[stepRun.Position]
+
+		switch stepRun.Status {
+		case models.WorkflowStepStatusCompleted, models.WorkflowStepStatusSkipped:
+			continue
+		case models.WorkflowStepStatusWaiting:
+			return e.setRunStatus(db, &run, models.WorkflowRunStatusWaiting, i, "")
+		}
+
+		// Claim the step so concurrent advances never execute it twice
+		now := time.Now()
+		claim := db.Model(&models.WorkflowStepRun{}).
+			Where("id = ? AND status = ?", stepRun.ID, models.WorkflowStepStatusPending).
+			Updates(map[string]interface{}{
+				"status":     models.WorkflowStepStatusRunning,
+				"attempts":   gorm.Expr("attempts + 1"),
+				"started_at": now,
+			})
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return nil
+		}
+
+		ok, err := EvaluateCondition(step.Conditions, runData(&run))
+		if err != nil {
+			return e.failStep(db, &run, stepRun, fmt.Errorf("condition: %w", err))
+		}
+		if !ok {
+			if err := e.finishStep(db, stepRun, models.WorkflowStepStatusSkipped, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		action, err := e.action(step)
+		if err != nil {
+			return e.failStep(db, &run, stepRun, err)
+		}
+
+		result, err := safeExecute(ctx, action.Execute, &run, stepRun, step)
+		if err != nil {
+			return e.failStep(db, &run, stepRun, err)
+		}
+
+		if result.Wait {
+			stepRun.Status = models.WorkflowStepStatusWaiting
+			stepRun.Output = result.Output
+			stepRun.ResumeAt = result.ResumeAt
+			if err := db.Model(stepRun).Select("status", "output", "resume_at").Updates(stepRun).Error; err != nil {
+				return err
+			}
+			return e.setRunStatus(db, &run, models.WorkflowRunStatusWaiting, i, "")
+		}
+
+		if err := e.finishStep(db, stepRun, models.WorkflowStepStatusCompleted, result.Output); err != nil {
+			return err
+		}
+		if err := db.Model(&run).Update("current_step", i+1).Error; err != nil {
+			return err
+		}
+	}
+
+	return e.setRunStatus(db, &run, models.WorkflowRunStatusCompleted, len(run.Steps), "")
+}
+
+// CompleteStep finishes a waiting step with the given output and resumes the run
+func (e *Engine) CompleteStep(ctx context.Context, runID uint, position int, output models.JSONMap) error {
+	return e.resolveStep(ctx, runID, position, models.WorkflowStepStatusCompleted, output, "")
+}
+
+// FailStep fails a waiting step, which fails the run
+func (e *Engine) FailStep(ctx context.Context, runID uint, position int, output models.JSONMap, reason string) error {
+	return e.resolveStep(ctx, runID, position, models.WorkflowStepStatusFailed, output, reason)
+}
+
+// resolveStep moves a waiting step to a final status and continues or fails the run
+func (e *Engine) resolveStep(ctx context.Context, runID uint, position int, status models.WorkflowStepStatus, output models.JSONMap, reason string) error {
+	db := e.DB.WithContext(ctx)
+	now := time.Now()
+
+	columns := []string{"status", "finished_at", "error"}
+	if output != nil {
+		columns = append(columns, "output")
+	}
+	res := db.Model(&models.WorkflowStepRun{}).
+		Where("run_id = ? AND position = ? AND status = ?", runID, position, models.WorkflowStepStatusWaiting).
+		Select(columns).
+		Updates(&models.WorkflowStepRun{Status: status, FinishedAt: &now, Error: reason, Output: output})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("step %d of run %d is not waiting", position, runID)
+	}
+
+	var run models.WorkflowRun
+	if err := db.First(&run, runID).Error; err != nil {
+		return err
+	}
+
+	if status == models.WorkflowStepStatusFailed {
+		return e.setRunStatus(db, &run, models.WorkflowRunStatusFailed, position, reason)
+	}
+
+	if err := e.setRunStatus(db, &run, models.WorkflowRunStatusRunning, position+1, ""); err != nil {
+		return err
+	}
+	e.advanceAsync(ctx, runID)
+	return nil
+}
+
+// Cancel stops a running or waiting run and cancels its unfinished steps
+func (e *Engine) Cancel(ctx context.Context, runID uint) error {
+	db := e.DB.WithContext(ctx)
+
+	var run models.WorkflowRun
+	if err := db.First(&run, runID).Error; err != nil {
+		return err
+	}
+	if run.Status != models.WorkflowRunStatusRunning && run.Status != models.WorkflowRunStatusWaiting {
+		return ErrNotCancelable
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WorkflowStepRun{}).
+			Where("run_id = ? AND status IN ?", runID, []models.WorkflowStepStatus{
+				models.WorkflowStepStatusPending,
+				models.WorkflowStepStatusWaiting,
+			}).
+			Update("status", models.WorkflowStepStatusCanceled).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.WorkflowApproval{}).
+			Where("run_id = ? AND status = ?", runID, models.WorkflowApprovalStatusPending).
+			Update("status", models.WorkflowApprovalStatusCanceled).Error
+		if err != nil {
+			return err
+		}
+		return e.setRunStatus(tx, &run, models.WorkflowRunStatusCanceled, run.CurrentStep, "")
+	})
+}
+
+// Retry resets the failed step of a failed run and advances it again
+func (e *Engine) Retry(ctx context.Context, runID uint) error {
+	var run models.WorkflowRun
+	if err := e.DB.WithContext(ctx).First(&run, runID).Error; err != nil {
+		return err
+	}
+	return e.RetryFrom(ctx, runID, run.CurrentStep)
+}
+
+// RetryFrom resets a failed run's steps from the given position onwards, including
+// ones that already completed, and advances the run again from that step
+func (e *Engine) RetryFrom(ctx context.Context, runID uint, position int) error {
+	db := e.DB.WithContext(ctx)
+
+	var run models.WorkflowRun
+	if err := db.First(&run, runID).Error; err != nil {
+		return err
+	}
+	if run.Status != models.WorkflowRunStatusFailed {
+		return ErrNotRetryable
+	}
+	if position < 0 || position > run.CurrentStep {
+		return ErrInvalidRetryStep
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WorkflowStepRun{}).
+			Where("run_id = ? AND position >= ?", runID, position).
+			Updates(map[string]interface{}{
+				"status":      models.WorkflowStepStatusPending,
+				"output":      nil,
+				"error":       "",
+				"started_at":  nil,
+				"finished_at": nil,
+				"resume_at":   nil,
+			}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.WorkflowApproval{}).
+			Where("run_id = ? AND position >= ? AND status = ?", runID, position, models.WorkflowApprovalStatusPending).
+			Update("status", models.WorkflowApprovalStatusCanceled).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&run).Updates(map[string]interface{}{
+			"status":       models.WorkflowRunStatusRunning,
+			"current_step": position,
+			"error":        "",
+			"finished_at":  nil,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	e.advanceAsync(ctx, runID)
+	return nil
+}
+
+// ResumeStalled advances running runs that haven't progressed within the threshold
+func (e *Engine) ResumeStalled(ctx context.Context, threshold time.Duration) error {
+	var ids []uint
+	err := e.DB.WithContext(ctx).Model(&models.WorkflowRun{}).
+		Where("status = ? AND updated_at < ?", models.WorkflowRunStatusRunning, time.Now().Add(-threshold)).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+
+	// Steps left running by a crashed instance are returned to pending so they are retried
+	if len(ids) > 0 {
+		err := e.DB.WithContext(ctx).Model(&models.WorkflowStepRun{}).
+			Where("run_id IN ? AND status = ? AND started_at < ?", ids, models.WorkflowStepStatusRunning, time.Now().Add(-threshold)).
+			Update("status", models.WorkflowStepStatusPending).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		if err := e.Advance(ctx, id); err != nil {
+			slog.Error("workflow: failed to resume run", "run_id", id, "error", err)
+		}
+	}
+	return nil
+}
+
+// ResumeDue completes waiting steps whose resume time has passed, continuing their runs
+func (e *Engine) ResumeDue(ctx context.Context) error {
+	var due []models.WorkflowStepRun
+	err := e.DB.WithContext(ctx).
+		Where("status = ? AND resume_at <= ?", models.WorkflowStepStatusWaiting, time.Now()).
+		Find(&due).Error
+	if err != nil {
+		return err
+	}
+
+	for _, s := range due {
+		if err := e.CompleteStep(ctx, s.RunID, s.Position, nil); err != nil {
+			slog.Error("workflow: failed to resume step", "position", s.Position, "run_id", s.RunID, "error", err)
+		}
+	}
+	return nil
+}
+
+// finishStep records a step's final status and output
+func (e *Engine) finishStep(db *gorm.DB, stepRun *models.WorkflowStepRun, status models.WorkflowStepStatus, output models.JSONMap) error {
+	now := time.Now()
+	stepRun.Status = status
+	stepRun.Output = output
+	stepRun.FinishedAt = &now
+	return db.Model(stepRun).Select("status", "output", "finished_at").Updates(stepRun).Error
+}
+
+// failStep marks the step and its run as failed
+func (e *Engine) failStep(db *gorm.DB, run *models.WorkflowRun, stepRun *models.WorkflowStepRun, cause error) error {
+	err := db.Model(stepRun).Updates(map[string]interface{}{
+		"status":      models.WorkflowStepStatusFailed,
+		"error":       cause.Error(),
+		"finished_at": time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+	return e.setRunStatus(db, run, models.WorkflowRunStatusFailed, stepRun.Position, cause.Error())
+}
+
+// setRunStatus updates a run's status and position, stamping the finish time for final states
+func (e *Engine) setRunStatus(db *gorm.DB, run *models.WorkflowRun, status models.WorkflowRunStatus, current int, reason string) error {
+	updates := map[string]interface{}{
+		"status":       status,
+		"current_step": current,
+		"error":        reason,
+	}
+	switch status {
+	case models.WorkflowRunStatusCompleted, models.WorkflowRunStatusFailed, models.WorkflowRunStatusCanceled:
+		updates["finished_at"] = time.Now()
+	default:
+		updates["finished_at"] = nil
+	}
+	return db.Model(run).Updates(updates).Error
+}
+
+// runData builds the data step conditions are evaluated against: the run input at the
+// top level, plus the input and each finished step's output under "input" and "steps"
+func runData(run *models.WorkflowRun) map[string]interface{} {
+	data := map[string]interface{}{}
+	for k, v := range run.Input {
+		data[k] = v
+	}
+
+	steps := map[string]interface{}{}
+	for _, s := range run.Steps {
+		if s.Status == models.WorkflowStepStatusCompleted {
+			steps[s.Name] = map[string]interface{}(s.Output)
+		}
+	}
+
+	data["input"] = map[string]interface{}(run.Input)
+	data["steps"] = steps
+	return data
+}
+
+// safeExecute runs a step executor, converting panics into errors
+func safeExecute(ctx context.Context, fn StepExecutor, run *models.WorkflowRun, stepRun *models.WorkflowStepRun, step models.WorkflowStep) (result StepResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, run, stepRun, step)
+}
@@ -0,0 +1,77 @@
+// Package workflow/triggers.go
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/4cecoder/saas/events"
+	"github.com/4cecoder/saas/models"
+)
+
+// triggerPattern matches event types such as "seat.invited" or "subscription.canceled"
+var triggerPattern = regexp.MustCompile(`^[a-z_]+\.[a-z_]+$`)
+
+// ValidateTriggers checks that every trigger names a well-formed event type
+func ValidateTriggers(triggers []string) error {
+	for _, t := range triggers {
+		if !triggerPattern.MatchString(t) {
+			return fmt.Errorf("invalid trigger %q, expected an event type like seat.invited", t)
+		}
+	}
+	return nil
+}
+
+// Subscribe starts runs of enabled workflows whose triggers match published events
+func (e *Engine) Subscribe(bus *events.Bus) {
+	bus.Subscribe("*", e.handleEvent)
+}
+
+// handleEvent starts a run for every workflow of the event's organization triggered by it
+func (e *Engine) handleEvent(ctx context.Context, ev events.Event) {
+	if ev.OrganizationID == 0 {
+		return
+	}
+
+	var workflows []models.Workflow
+	err := e.DB.WithContext(ctx).
+		Where("organization_id = ? AND enabled = ?", ev.OrganizationID, true).
+		Find(&workflows).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "workflow: failed to load workflows for event", "event", ev.Type, "error", err)
+		return
+	}
+
+	for i := range workflows {
+		wf := &workflows[i]
+		if !triggeredBy(wf, ev) {
+			continue
+		}
+
+		input := models.JSONMap{"event": ev.Type}
+		for k, v := range ev.Payload {
+			input[k] = v
+		}
+
+		if _, err := e.Start(ctx, wf, input, ev.UserID); err != nil {
+			slog.ErrorContext(ctx, "workflow: failed to start workflow for event", "workflow_id", wf.ID, "event", ev.Type, "error", err)
+		}
+	}
+}
+
+// triggeredBy reports whether the workflow declares a trigger for the event,
+// ignoring events caused by the workflow's own runs so it can't trigger itself
+func triggeredBy(wf *models.Workflow, ev events.Event) bool {
+	if ev.Payload["resource_type"] == "workflow_runs" && fmt.Sprint(ev.Payload["workflow_id"]) == fmt.Sprint(wf.ID) {
+		return false
+	}
+
+	for _, t := range wf.Triggers {
+		if t == ev.Type {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+// Package workflow/versions.go
+package workflow
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"gorm.io/gorm"
+
+	"github.com/4cecoder/saas/models"
+)
+
+// FieldChange is the old and new value of a changed field
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// StepChange lists the changed fields of a step present in both versions
+type StepChange struct {
+	Name   string                 `json:"name"`
+	Fields map[string]FieldChange `json:"fields"`
+}
+
+// VersionDiff describes what changed between two workflow versions
+type VersionDiff struct {
+	From            int                    `json:"from"`
+	To              int                    `json:"to"`
+	Fields          map[string]FieldChange `json:"fields"`
+	TriggersAdded   []string               `json:"triggers_added"`
+	TriggersRemoved []string               `json:"triggers_removed"`
+	StepsAdded      []string               `json:"steps_added"`
+	StepsRemoved    []string               `json:"steps_removed"`
+	StepsChanged    []StepChange           `json:"steps_changed"`
+}
+
+// DefinitionChanged reports whether an edit changes anything captured in a version
+func DefinitionChanged(before, after *models.Workflow) bool {
+	return before.Name != after.Name ||
+		before.Description != after.Description ||
+		!reflect.DeepEqual(before.Steps, after.Steps) ||
+		!reflect.DeepEqual(before.Triggers, after.Triggers)
+}
+
+// SaveVersion records the workflow's definition after an edit. Edits of enabled
+// workflows, or of versions that runs have started on, get a new version number;
+// other edits amend the current version in place.
+func SaveVersion(tx *gorm.DB, before, wf *models.Workflow, userID uint) error {
+	if before.Version > 0 && !before.Enabled {
+		var runs int64
+		err := tx.Model(&models.WorkflowRun{}).
+			Where("workflow_id = ? AND workflow_version = ?", wf.ID, before.Version).
+			Count(&runs).Error
+		if err != nil {
+			return err
+		}
+
+		if runs == 0 {
+			wf.Version = before.Version
+			version := snapshot(wf, userID)
+			return tx.Model(&models.WorkflowVersion{}).
+				Where("workflow_id = ? AND version = ?", wf.ID, wf.Version).
+				Select("name", "description", "steps", "triggers", "created_by").
+				Updates(&version).Error
+		}
+	}
+
+	return createVersion(tx, wf, userID, nil)
+}
+
+// Rollback restores the definition of an earlier version as a new version
+func Rollback(tx *gorm.DB, wf *models.Workflow, version *models.WorkflowVersion, userID uint) error {
+	wf.Name = version.Name
+	wf.Description = version.Description
+	wf.Steps = version.Steps
+	wf.Triggers = version.Triggers
+
+	from := version.Version
+	return createVersion(tx, wf, userID, &from)
+}
+
+// createVersion stores the workflow's definition under the next version number
+func createVersion(tx *gorm.DB, wf *models.Workflow, userID uint, rolledBackFrom *int) error {
+	var latest int
+	err := tx.Model(&models.WorkflowVersion{}).
+		Where("workflow_id = ?", wf.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+
+	wf.Version = latest + 1
+	version := snapshot(wf, userID)
+	version.RolledBackFrom = rolledBackFrom
+	return tx.Create(&version).Error
+}
+
+// snapshot copies the versioned fields of a workflow
+func snapshot(wf *models.Workflow, userID uint) models.WorkflowVersion {
+	return models.WorkflowVersion{
+		WorkflowID:     wf.ID,
+		Version:        wf.Version,
+		OrganizationID: wf.OrganizationID,
+		Name:           wf.Name,
+		Description:    wf.Description,
+		Steps:          wf.Steps,
+		Triggers:       wf.Triggers,
+		CreatedBy:      userID,
+	}
+}
+
+// Diff compares two versions of a workflow, matching steps by name
+func Diff(from, to *models.WorkflowVersion) VersionDiff {
+	diff := VersionDiff{
+		From:            from.Version,
+		To:              to.Version,
+		Fields:          map[string]FieldChange{},
+		TriggersAdded:   []string{},
+		TriggersRemoved: []string{},
+		StepsAdded:      []string{},
+		StepsRemoved:    []string{},
+		StepsChanged:    []StepChange{},
+	}
+
+	if from.Name != to.Name {
+		diff.Fields["name"] = FieldChange{From: from.Name, To: to.Name}
+	}
+	if from.Description != to.Description {
+		diff.Fields["description"] = FieldChange{From: from.Description, To: to.Description}
+	}
+
+	diff.TriggersAdded = missing(to.Triggers, from.Triggers)
+	diff.TriggersRemoved = missing(from.Triggers, to.Triggers)
+
+	oldSteps := stepsByName(from.Steps)
+	newSteps := stepsByName(to.Steps)
+	for _, s := range to.Steps {
+		old, ok := oldSteps[s.Name]
+		if !ok {
+			diff.StepsAdded = append(diff.StepsAdded, s.Name)
+			continue
+		}
+		if fields := stepChanges(old, s); len(fields) > 0 {
+			diff.StepsChanged = append(diff.StepsChanged, StepChange{Name: s.Name, Fields: fields})
+		}
+	}
+	for _, s := range from.Steps {
+		if _, ok := newSteps[s.Name]; !ok {
+			diff.StepsRemoved = append(diff.StepsRemoved, s.Name)
+		}
+	}
+
+	return diff
+}
+
+// missing returns the values of a that aren't in b
+func missing(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, v := range b {
+		seen[v] = true
+	}
+
+	out := []string{}
+	for _, v := range a {
+		if !seen[v] {
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// stepsByName indexes steps by their name
+func stepsByName(steps []models.WorkflowStep) map[string]models.WorkflowStep {
+	m := make(map[string]models.WorkflowStep, len(steps))
+	for _, s := range steps {
+		m[s.Name] = s
+	}
+	return m
+}
+
+// stepChanges compares two steps field by field using their JSON representation
+func stepChanges(from, to models.WorkflowStep) map[string]FieldChange {
+	a, b := asMap(from), asMap(to)
+
+	changes := map[string]FieldChange{}
+	for k, v := range b {
+		if !reflect.DeepEqual(a[k], v) {
+			changes[k] = FieldChange{From: a[k], To: v}
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = FieldChange{From: v}
+		}
+	}
+	return changes
+}
+
+// asMap converts a step to its JSON object form
+func asMap(step models.WorkflowStep) map[string]interface{} {
+	raw, _ := json.Marshal(step)
+	m := map[string]interface{}{}
+	json.Unmarshal(raw, &m)
+	return m
+}
@@ -0,0 +1,40 @@
+// Package workflow/webhooks.go
+package workflow
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/4cecoder/saas/netguard"
+	"github.com/4cecoder/saas/tracing"
+)
+
+// maxWebhookRedirects is how many redirects a webhook call follows
+const maxWebhookRedirects = 5
+
+// NewWebhookClient returns the client calling webhook URLs. It only connects
+// to public addresses, checked on the address of every connection rather than
+// on the URL, so neither redirects nor DNS rebinding reach internal services.
+// Proxies are not used, since the check would apply to them instead.
+func NewWebhookClient() *http.Client {
+	dialer := netguard.Dialer(5 * time.Second)
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return tracing.WrapClient(&http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxWebhookRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxWebhookRedirects)
+			}
+			return nil
+		},
+	})
+}
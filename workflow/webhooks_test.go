@@ -0,0 +1,61 @@
+// Package workflow/webhooks_test.go
+package workflow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/4cecoder/saas/models"
+	"github.com/4cecoder/saas/netguard"
+)
+
+func TestWebhookRefusesNonPublicAddresses(t *testing.T) {
+	called := false
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer internal.Close()
+
+	step := models.WorkflowStep{
+		Name:   "notify",
+		Type:   models.WorkflowActionWebhook,
+		Config: models.JSONMap{"url": internal.URL},
+	}
+	_, err := executeWebhook(context.Background(), NewWebhookClient(), &models.WorkflowRun{}, step)
+	if !errors.Is(err, netguard.ErrNonPublicAddress) {
+		t.Errorf("calling %s: err = %v, want ErrNonPublicAddress", internal.URL, err)
+	}
+	if called {
+		t.Error("the internal server was called")
+	}
+}
+
+func TestWebhookOutputKeepsStatusAndTruncatedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 2*maxResponseBody)))
+	}))
+	defer server.Close()
+
+	step := models.WorkflowStep{
+		Name:   "notify",
+		Type:   models.WorkflowActionWebhook,
+		Config: models.JSONMap{"url": server.URL},
+	}
+	result, err := executeWebhook(context.Background(), server.Client(), &models.WorkflowRun{}, step)
+	if err != nil {
+		t.Fatalf("execute webhook: %v", err)
+	}
+	if got := result.Output["status_code"]; got != http.StatusOK {
+		t.Errorf("status_code = %v, want 200", got)
+	}
+	if body, _ := result.Output["body"].(string); len(body) != maxResponseBody {
+		t.Errorf("body has %d bytes, want %d", len(body), maxResponseBody)
+	}
+	if result.Output["body_truncated"] != true {
+		t.Error("body_truncated = false, want true")
+	}
+}